@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/api"
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+	"Go9jaJobs/internal/services"
+)
+
+// syncJobs syncs a source, or all enabled sources, recording the run like
+// POST /api/jobs/sync?wait=true and printing its results as JSON. Fails when
+// a source failed, so schedulers such as GitHub Actions report it.
+func syncJobs(cfg *config.Config, args []string) error {
+	flags := newFlagSet("sync")
+	source := flags.String("source", "all", "source to sync, or all")
+	timeout := flags.Duration("timeout", 30*time.Minute, "maximum duration of the sync")
+	replay := flags.Bool("replay", false, "serve the sources from their cached API responses")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *replay {
+		cfg.Mode = config.ModeReplay
+	}
+	if *source != "all" && !services.IsValidSource(*source) {
+		return fmt.Errorf("invalid source: %s (one of %s or all)", *source, strings.Join(services.Sources(), ", "))
+	}
+
+	pool, postgresDB, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	defer postgresDB.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	runID, results, err := services.NewSyncManager(postgresDB, fetcher.NewJobFetcher(cfg), services.NewTaskManager()).RunAndWait(ctx, *source)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(map[string]interface{}{
+		"sync_id": runID,
+		"results": results,
+	})
+
+	for _, result := range results {
+		if result.Error != "" {
+			return fmt.Errorf("sync of %s failed: %s", result.Source, result.Error)
+		}
+	}
+	return nil
+}
+
+// migrate creates or updates the database schema and exits
+func migrate(cfg *config.Config, args []string) error {
+	if err := newFlagSet("migrate").Parse(args); err != nil {
+		return err
+	}
+
+	// Connecting runs the migrations
+	pool, postgresDB, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	postgresDB.Close()
+	pool.Close()
+
+	log.Println("Database schema is up to date")
+	return nil
+}
+
+// expireJobs archives the jobs whose expiry date has passed, like POST
+// /api/admin/jobs/expire
+func expireJobs(cfg *config.Config, args []string) error {
+	if err := newFlagSet("expire-jobs").Parse(args); err != nil {
+		return err
+	}
+
+	pool, postgresDB, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	defer postgresDB.Close()
+
+	archived, err := services.ExpireJobs(context.Background(), postgresDB)
+	if err != nil {
+		return err
+	}
+	log.Printf("Archived %d expired jobs", archived)
+	return nil
+}
+
+// enhanceDescriptions flags age and gender-coded language in the descriptions
+// not audited yet, like POST /api/admin/jobs/language-audit
+func enhanceDescriptions(cfg *config.Config, args []string) error {
+	if err := newFlagSet("enhance-descriptions").Parse(args); err != nil {
+		return err
+	}
+
+	pool, postgresDB, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	defer postgresDB.Close()
+
+	audited, flagged, err := services.AuditJobLanguage(context.Background(), postgresDB)
+	if err != nil {
+		return err
+	}
+	log.Printf("Audited %d job descriptions, %d flagged", audited, flagged)
+	return nil
+}
+
+// recordCounts records the job counts of a day, yesterday by default, like
+// the daily snapshot of the server; a missed day can be recorded afterwards
+func recordCounts(cfg *config.Config, args []string) error {
+	flags := newFlagSet("record-counts")
+	dayFlag := flags.String("day", "", "UTC day to record as YYYY-MM-DD (default yesterday)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	day := time.Now().UTC().AddDate(0, 0, -1)
+	if *dayFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *dayFlag)
+		if err != nil {
+			return fmt.Errorf("invalid day: %s", *dayFlag)
+		}
+		day = parsed
+	}
+
+	pool, postgresDB, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	defer postgresDB.Close()
+
+	return services.RecordJobCounts(context.Background(), postgresDB, day, cfg.JobCountsRetentionDays)
+}
+
+// exportJobs writes the jobs GET /api/jobs/export would return, every
+// matching job by default, to a file or stdout
+func exportJobs(cfg *config.Config, args []string) error {
+	flags := newFlagSet("export")
+	format := flags.String("format", "csv", "csv or xlsx")
+	columns := flags.String("columns", "", "comma separated columns (default title, company, location, ...)")
+	filters := flags.String("query", "", "filters of /api/jobs as a query string, e.g. source=jsearch&is_remote=true")
+	out := flags.String("out", "", "file to write (default stdout)")
+	timeout := flags.Duration("timeout", 10*time.Minute, "maximum duration of the export")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	query, err := url.ParseQuery(*filters)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	query.Set("format", *format)
+	if *columns != "" {
+		query.Set("columns", *columns)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	pool, postgresDB, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	defer postgresDB.Close()
+
+	// The export runs through the API handler so it shares its filters; the
+	// whole export gets the query timeout
+	exportCfg := *cfg
+	exportCfg.DBQueryTimeout = *timeout
+	handler := api.NewHandler(postgresDB, nil)
+	handler.Config = &exportCfg
+
+	req, err := http.NewRequest("GET", "/api/jobs/export?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	rw := &exportWriter{w: w, header: http.Header{}}
+	handler.ExportJobs(rw, req)
+	if rw.status != http.StatusOK {
+		return fmt.Errorf("export failed (%d): %s", rw.status, strings.TrimSpace(rw.errBody.String()))
+	}
+	// A failure past the first rows leaves a partial file, see the logs
+	if rw.header.Get(api.ExportErrorTrailer) != "" {
+		return fmt.Errorf("export failed: the output is truncated")
+	}
+	return nil
+}
+
+// exportWriter is the http.ResponseWriter of a CLI export: a successful body
+// goes to w, an error body is kept to be reported
+type exportWriter struct {
+	w       io.Writer
+	header  http.Header
+	status  int
+	errBody strings.Builder
+}
+
+func (e *exportWriter) Header() http.Header { return e.header }
+
+func (e *exportWriter) WriteHeader(status int) {
+	if e.status == 0 {
+		e.status = status
+	}
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	e.WriteHeader(http.StatusOK)
+	if e.status != http.StatusOK {
+		return e.errBody.Write(p)
+	}
+	return e.w.Write(p)
+}
@@ -1,107 +1,125 @@
+// Command server runs the Go9jaJobs API and its operational tasks:
+//
+//	server [serve]                                   run the API (the default)
+//	server sync [--source=jsearch|all] [--replay]    sync job sources once
+//	server migrate                                   create or update the database schema
+//	server expire-jobs                               archive expired jobs
+//	server enhance-descriptions                      audit job descriptions for coded language
+//	server record-counts [--day=2006-01-02]          record the daily job counts of the trend charts
+//	server export [--format=csv|xlsx] [--out=file]   export the jobs /api/jobs lists
+//
+// Each command takes the configuration of the server from the environment.
 package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	"sort"
+	"strings"
 
-	"Go9jaJobs/internal/api"
 	"Go9jaJobs/internal/config"
 	"Go9jaJobs/internal/db"
-	"Go9jaJobs/internal/fetcher"
+	"Go9jaJobs/internal/logging"
+
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// commands are the subcommands by name
+var commands = map[string]func(cfg *config.Config, args []string) error{
+	"serve":                serve,
+	"sync":                 syncJobs,
+	"migrate":              migrate,
+	"expire-jobs":          expireJobs,
+	"enhance-descriptions": enhanceDescriptions,
+	"record-counts":        recordCounts,
+	"export":               exportJobs,
+}
+
 func main() {
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", name)
+		usage()
+		os.Exit(2)
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatal("Failed to load configuration:", err)
 	}
 
-	if cfg.APIKey == "" {
-		log.Fatal("API Key must be set in configuration")
+	if err := logging.Setup(cfg.LogLevel, cfg.LogFormat, os.Stderr); err != nil {
+		log.Fatal("Failed to set up logging:", err)
 	}
 
-	// Connect to PostgreSQL
-	postgresDB, err := db.InitDB(cfg.DBConnStr)
-	if err != nil {
-		log.Fatal("Failed to connect to Postgres:", err)
+	if err := command(cfg, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		log.Fatalf("%s: %v", name, err)
 	}
-	log.Println("Connected to Postgres successfully")
-	defer postgresDB.Close()
-
-	// Create job fetcher
-	jobFetcher := fetcher.NewJobFetcher(cfg)
+}
 
-	// Initialize API handlers
-	apiHandler := api.NewHandler(postgresDB, jobFetcher)
+// usage lists the commands on stderr
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "Usage: %s [command] [flags]\nCommands: %s\n", os.Args[0], strings.Join(names, ", "))
+}
 
-	// Set up routes
-	router := apiHandler.SetupRoutes(cfg) // Use SetupRoutes function
+// newFlagSet returns the flag set of a command, returning parse errors
+// rather than exiting
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
 
-	// Create HTTP server
-	port := cfg.Port
-	if port == "" {
-		port = "8080"
+// openDB connects to Postgres and migrates the schema. The save pipeline is
+// configured too, since most commands write jobs.
+func openDB(cfg *config.Config) (*pgxpool.Pool, *sql.DB, error) {
+	// Connect to PostgreSQL
+	pool, err := db.OpenPool(context.Background(), cfg.DBConnStr, db.PoolConfig{
+		MaxConns:        cfg.DBMaxOpenConns,
+		MinConns:        cfg.DBMinConns,
+		ConnMaxLifetime: cfg.DBConnMaxLifetime,
+		ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to configure Postgres: %w", err)
 	}
-
-	serverAddress := ":" + port
-	server := &http.Server{
-		Addr:         serverAddress,
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	postgresDB, err := db.InitDB(pool)
+	if err != nil {
+		pool.Close()
+		return nil, nil, fmt.Errorf("failed to connect to Postgres: %w", err)
 	}
+	log.Println("Connected to Postgres successfully")
 
-	// Start job scheduler with persistent job schedule info
-	//scheduler := services.StartJobScheduler(postgresDB, postgresDB, jobFetcher)
-
-	// Start the server in a goroutine
-	go func() {
-		host := "localhost"
-		if os.Getenv("HOST") != "" {
-			host = os.Getenv("HOST")
-		}
-
-		url := fmt.Sprintf("http://%s:%s", host, port)
-		log.Printf("======================================================")
-		log.Printf("  Go9jaJobs API is now running at: \033[1;36m%s\033[0m", url)
-		log.Printf("  Status endpoint: \033[1;36m%s/status\033[0m", url)
-		log.Printf("  Jobs endpoint: \033[1;36m%s/api/jobs\033[0m", url)
-		log.Printf("  schedule fetch endpoint: \033[1;36m%s/api/jobs/sync?source\033[0m", url)
-		log.Printf("======================================================")
-		log.Printf("  Remember to include X-API-Key, X-Timestamp, and X-Signature headers")
-		log.Printf("  in all API requests for proper authentication.")
-		log.Printf("======================================================")
-
-		log.Printf("Server listening on port %s...", port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
-		}
-	}()
-
-	// Graceful shutdown handling
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	<-sigChan
-	log.Println("Shutdown signal received, shutting down gracefully...")
-
-	// Create a deadline for shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
-	// Shutdown the server
-	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+	// Postings with shorter descriptions are skipped on save
+	db.SetDescriptionRule(db.DescriptionRule{
+		MinLength: cfg.MinDescriptionLength,
+		BySource:  cfg.MinDescriptionLengthBySource,
+	})
+	// Jobs are tagged with their tracks on save, those matching none skipped
+	db.SetTracks(db.NewTracks(cfg.ActiveTracks()))
+	// Jobs of blocked companies are skipped on save
+	if err := db.LoadBlockedCompanies(context.Background(), postgresDB); err != nil {
+		postgresDB.Close()
+		pool.Close()
+		return nil, nil, fmt.Errorf("failed to load blocked companies: %w", err)
 	}
 
-	//scheduler.Stop()
-	log.Println("Server gracefully shut down, exiting.")
+	return pool, postgresDB, nil
 }
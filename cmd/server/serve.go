@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"Go9jaJobs/internal/api"
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/enrichment"
+	"Go9jaJobs/internal/fetcher"
+	"Go9jaJobs/internal/notifier"
+	"Go9jaJobs/internal/services"
+	"Go9jaJobs/internal/storage"
+	"Go9jaJobs/internal/translate"
+)
+
+// serve runs the API with its background workers until SIGINT or SIGTERM
+func serve(cfg *config.Config, args []string) error {
+	if err := newFlagSet("serve").Parse(args); err != nil {
+		return err
+	}
+
+	if cfg.APIKey == "" {
+		log.Fatal("API Key must be set in configuration")
+	}
+
+	pool, postgresDB, err := openDB(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+	defer postgresDB.Close()
+
+	// Create job fetcher
+	jobFetcher := fetcher.NewJobFetcher(cfg)
+
+	// Initialize API handlers
+	apiHandler := api.NewHandler(postgresDB, jobFetcher)
+	apiHandler.Pool = pool
+
+	// Share the response cache and rate limit counters between replicas
+	var redisCache *db.RedisCache
+	if cfg.RedisURL != "" {
+		redisCache, err = db.NewRedisCache(cfg.RedisURL, cfg.ResponseCacheTTL)
+		if err != nil {
+			log.Fatal("Invalid REDIS_URL:", err)
+		}
+		defer redisCache.Close()
+		apiHandler.RateLimits = redisCache
+	}
+
+	// Serve hot /api/jobs and /feed.xml responses from memory or Redis
+	stopResponseCleanup := func() {}
+	if cfg.ResponseCacheTTL > 0 {
+		if redisCache != nil {
+			apiHandler.Responses = redisCache
+		} else {
+			responses := db.NewCache(cfg.ResponseCacheTTL, cfg.ResponseCacheMaxEntries)
+			apiHandler.Responses = responses
+			stopResponseCleanup = responses.StartCleanup(time.Minute)
+		}
+	}
+
+	// Set up routes
+	router := apiHandler.SetupRoutes(cfg) // Use SetupRoutes function
+
+	// Create HTTP server
+	port := cfg.Port
+	if port == "" {
+		port = "8080"
+	}
+
+	serverAddress := ":" + port
+	server := &http.Server{
+		Addr:         serverAddress,
+		Handler:      router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Admin endpoints on their own listener, e.g. bound to a VPN interface,
+	// so the public API can sit behind a CDN
+	var adminServer *http.Server
+	if cfg.AdminAddr != "" {
+		adminServer = &http.Server{
+			Addr:         cfg.AdminAddr,
+			Handler:      apiHandler.SetupAdminRoutes(cfg),
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+	}
+
+	// Start job scheduler with persistent job schedule info
+	var scheduler *services.JobScheduler
+	if cfg.SchedulerEnabled {
+		scheduler, err = services.StartJobScheduler(postgresDB, jobFetcher, apiHandler.Tasks, cfg.SchedulerDefaultInterval, services.NewAdaptivePolicy(cfg))
+		if err != nil {
+			log.Fatal("Failed to start job scheduler:", err)
+		}
+		apiHandler.Scheduler = scheduler
+	}
+
+	// Periodically archive expired jobs
+	stopSweeper := func() {}
+	if cfg.ExpirySweepInterval > 0 {
+		stopSweeper = services.StartExpirySweeper(postgresDB, cfg.ExpirySweepInterval)
+	}
+
+	// Export a nightly snapshot of the jobs table to S3-compatible storage
+	stopSnapshots := func() {}
+	if cfg.SnapshotS3Bucket != "" {
+		store := storage.NewS3(cfg.SnapshotS3Endpoint, cfg.SnapshotS3Region, cfg.SnapshotS3Bucket,
+			cfg.SnapshotS3AccessKey, cfg.SnapshotS3SecretKey)
+		stopSnapshots, err = services.StartSnapshotExporter(postgresDB, store, cfg.SnapshotPrefix, cfg.SnapshotAt)
+		if err != nil {
+			log.Fatal("Invalid SNAPSHOT_AT:", err)
+		}
+	}
+
+	// Record the daily job counts behind the trend charts
+	stopJobCounts, err := services.StartJobCountSnapshots(postgresDB, cfg.JobCountsAt, cfg.JobCountsRetentionDays)
+	if err != nil {
+		log.Fatal("Invalid JOB_COUNTS_AT:", err)
+	}
+
+	// Fetch company logos in the background; skipped in dev to spare API quota
+	// and in replay, which calls no API
+	stopEnricher := func() {}
+	if cfg.Mode != "dev" && cfg.Mode != config.ModeReplay && cfg.EnrichmentInterval > 0 {
+		enricher := services.NewCompanyEnricher(postgresDB, enrichment.NewEnricher(cfg))
+		stopEnricher = enricher.Start(cfg.EnrichmentInterval)
+	}
+
+	// Translate descriptions in other languages, when a translator is configured
+	stopTranslator := func() {}
+	if cfg.Translator != "" && cfg.TranslationInterval > 0 {
+		var translator translate.Translator
+		switch cfg.Translator {
+		case translate.ProviderGoogle:
+			translator = translate.NewGoogle(cfg.GoogleTranslateAPIKey)
+		case translate.ProviderGemini:
+			translator = translate.NewGemini(cfg.GeminiAPIKey, cfg.GeminiModel)
+		default:
+			log.Fatalf("Invalid TRANSLATOR %q: must be %s or %s", cfg.Translator, translate.ProviderGoogle, translate.ProviderGemini)
+		}
+		stopTranslator = services.NewJobTranslator(postgresDB, translator, cfg.TranslateTo).Start(cfg.TranslationInterval)
+	}
+
+	// Prime frontend/CDN caches after syncs
+	stopCacheWarmer := func() {}
+	if len(cfg.CacheWarmURLs) > 0 {
+		stopCacheWarmer = services.NewCacheWarmer(cfg.CacheWarmURLs, cfg.CacheWarmSecret).Start()
+	}
+
+	// Email job alerts: subscriptions and their daily digests need SMTP
+	stopDigests := func() {}
+	if cfg.SMTPHost != "" && cfg.PublicBaseURL == "" {
+		log.Printf("Warning: PUBLIC_BASE_URL not set, emails with links are disabled")
+	} else if cfg.SMTPHost != "" {
+		mailer := notifier.NewMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+		apiHandler.Mailer = mailer
+		stopDigests = services.StartSubscriptionDigests(postgresDB, mailer, cfg.PublicBaseURL, cfg.SubscriptionDigestInterval)
+	}
+
+	// Post digests of new jobs to Telegram/Slack after each sync
+	stopNotifier := func() {}
+	var senders []notifier.Sender
+	if cfg.TelegramBotToken != "" && cfg.TelegramChatID != "" {
+		senders = append(senders, notifier.NewTelegram(cfg.TelegramBotToken, cfg.TelegramChatID))
+	}
+	if cfg.SlackWebhookURL != "" {
+		senders = append(senders, notifier.NewSlack(cfg.SlackWebhookURL))
+	}
+	if len(senders) > 0 {
+		tmpl, err := notifier.ParseTemplate(cfg.NotifyTemplate)
+		if err != nil {
+			log.Fatal("Invalid NOTIFY_TEMPLATE:", err)
+		}
+		stopNotifier = services.NewNotifier(postgresDB, senders, tmpl, cfg.NotifySources).Start()
+	}
+
+	// Start the server in a goroutine
+	go func() {
+		host := "localhost"
+		if os.Getenv("HOST") != "" {
+			host = os.Getenv("HOST")
+		}
+
+		url := fmt.Sprintf("http://%s:%s", host, port)
+		log.Printf("======================================================")
+		log.Printf("  Go9jaJobs API is now running at: \033[1;36m%s\033[0m", url)
+		log.Printf("  Status endpoint: \033[1;36m%s/status\033[0m", url)
+		log.Printf("  Jobs endpoint: \033[1;36m%s/api/jobs\033[0m", url)
+		log.Printf("  schedule fetch endpoint: \033[1;36m%s/api/jobs/sync?source\033[0m", url)
+		log.Printf("======================================================")
+		log.Printf("  Remember to include X-API-Key, X-Timestamp, and X-Signature headers")
+		log.Printf("  in all API requests for proper authentication.")
+		log.Printf("======================================================")
+
+		log.Printf("Server listening on port %s...", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	if adminServer != nil {
+		go func() {
+			log.Printf("Admin API listening on %s...", cfg.AdminAddr)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Admin server error: %v", err)
+			}
+		}()
+	}
+
+	// Graceful shutdown handling
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	<-sigChan
+	log.Println("Shutdown signal received, shutting down gracefully...")
+
+	// Create a deadline for shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	// Shutdown the server
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
+	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			log.Printf("Admin server shutdown error: %v", err)
+		}
+	}
+
+	if scheduler != nil {
+		scheduler.Stop()
+	}
+
+	// Let running syncs finish, cancelling those still running at the deadline
+	for _, task := range apiHandler.Tasks.Running() {
+		log.Printf("Waiting for %s, running since %s", task.Name, task.StartedAt.Format(time.RFC3339))
+	}
+	tasksCtx, tasksCancel := context.WithTimeout(context.Background(), cfg.TaskShutdownTimeout)
+	defer tasksCancel()
+	if err := apiHandler.Tasks.Shutdown(tasksCtx); err != nil {
+		log.Printf("Cancelled background tasks still running after %s", cfg.TaskShutdownTimeout)
+	}
+	stopSweeper()
+	stopSnapshots()
+	stopJobCounts()
+	stopEnricher()
+	stopTranslator()
+	stopCacheWarmer()
+	stopNotifier()
+	stopDigests()
+	stopResponseCleanup()
+	log.Println("Server gracefully shut down, exiting.")
+	return nil
+}
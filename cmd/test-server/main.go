@@ -329,6 +329,7 @@ func main() {
 	// Set up routes to simulate the original APIs
 	router.HandleFunc("/jsearch/search", handleJSearch).Methods("GET")
 	router.HandleFunc("/linkedin/active-jb-24h", handleLinkedIn).Methods("GET")
+	router.HandleFunc("/linkedin/active-jb-7d", handleLinkedIn).Methods("GET")
 	router.HandleFunc("/apify/acts/hMvNSpz3JnHgl5jkh/runs", handleIndeed).Methods("POST")
 
 	// Start server
@@ -0,0 +1,23 @@
+package analyzer
+
+import (
+	"math"
+	"strings"
+)
+
+// wordsPerMinute is the average adult silent reading speed used for estimates
+const wordsPerMinute = 200
+
+// WordCount returns the number of whitespace separated words in text
+func WordCount(text string) int {
+	return len(strings.Fields(text))
+}
+
+// ReadingTimeMinutes estimates how many minutes it takes to read the given
+// number of words, rounded up so that any non-empty text takes at least a minute
+func ReadingTimeMinutes(words int) int {
+	if words <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(words) / wordsPerMinute))
+}
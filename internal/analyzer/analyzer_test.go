@@ -0,0 +1,22 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordCount(t *testing.T) {
+	assert.Equal(t, 0, WordCount(""))
+	assert.Equal(t, 0, WordCount("   \n\t "))
+	assert.Equal(t, 4, WordCount("Senior  Golang\nDeveloper needed"))
+}
+
+func TestReadingTimeMinutes(t *testing.T) {
+	assert.Equal(t, 0, ReadingTimeMinutes(0))
+	assert.Equal(t, 1, ReadingTimeMinutes(1))
+	assert.Equal(t, 1, ReadingTimeMinutes(200))
+	assert.Equal(t, 2, ReadingTimeMinutes(201))
+	assert.Equal(t, 15, ReadingTimeMinutes(WordCount(strings.Repeat("word ", 3000))))
+}
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Apply methods describing where a candidate ends up when applying
+const (
+	ApplyMethodDirect   = "direct"
+	ApplyMethodJobBoard = "job_board"
+	ApplyMethodEmail    = "email"
+	ApplyMethodUnknown  = "unknown"
+)
+
+// ApplyMethods lists every apply method that can be stored on a job
+var ApplyMethods = []string{ApplyMethodDirect, ApplyMethodJobBoard, ApplyMethodEmail, ApplyMethodUnknown}
+
+// atsDomains are applicant tracking systems employers use to receive applications directly
+var atsDomains = []string{
+	"greenhouse.io", "lever.co", "workable.com", "ashbyhq.com", "smartrecruiters.com",
+	"bamboohr.com", "breezy.hr", "recruitee.com", "teamtailor.com", "workday.com",
+	"myworkdayjobs.com", "jobvite.com", "icims.com",
+}
+
+// jobBoardDomains are aggregators and boards sitting between the candidate and the employer
+var jobBoardDomains = []string{
+	"linkedin.com", "indeed.com", "glassdoor.com", "jobberman.com", "myjobmag.com",
+	"ziprecruiter.com", "google.com", "remoteok.com", "weworkremotely.com", "hotnigerianjobs.com",
+	"jobzilla.ng", "ngcareers.com", "monster.com", "careerbuilder.com", "simplyhired.com",
+}
+
+// DetectApplyMethod classifies an apply link as a direct employer application
+// (ATS or the company's own site), a job board intermediary or an email address
+func DetectApplyMethod(applyURL, companyURL string) string {
+	applyURL = strings.TrimSpace(applyURL)
+	if applyURL == "" {
+		return ApplyMethodUnknown
+	}
+
+	lower := strings.ToLower(applyURL)
+	if strings.HasPrefix(lower, "mailto:") || (!strings.Contains(lower, "://") && strings.Contains(lower, "@")) {
+		return ApplyMethodEmail
+	}
+
+	host := hostOf(lower)
+	if host == "" {
+		return ApplyMethodUnknown
+	}
+
+	if matchesDomain(host, atsDomains) {
+		return ApplyMethodDirect
+	}
+	if matchesDomain(host, jobBoardDomains) {
+		return ApplyMethodJobBoard
+	}
+
+	if companyHost := hostOf(strings.ToLower(companyURL)); companyHost != "" {
+		if host == companyHost || strings.HasSuffix(host, "."+companyHost) {
+			return ApplyMethodDirect
+		}
+	}
+
+	return ApplyMethodUnknown
+}
+
+// IsApplyMethod reports whether method is a known apply method
+func IsApplyMethod(method string) bool {
+	for _, m := range ApplyMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// hostOf extracts the host of a URL without the www. prefix, accepting URLs without a scheme
+func hostOf(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(parsed.Hostname(), "www.")
+}
+
+// matchesDomain reports whether host is one of domains or a subdomain of one
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectApplyMethod(t *testing.T) {
+	tests := []struct {
+		applyURL   string
+		companyURL string
+		expected   string
+	}{
+		{"https://boards.greenhouse.io/paystack/jobs/123", "", ApplyMethodDirect},
+		{"https://jobs.lever.co/flutterwave/abc", "https://flutterwave.com", ApplyMethodDirect},
+		{"https://apply.workable.com/moniepoint/j/1", "", ApplyMethodDirect},
+		{"https://careers.paystack.com/golang", "https://paystack.com", ApplyMethodDirect},
+		{"https://www.linkedin.com/jobs/view/123", "https://paystack.com", ApplyMethodJobBoard},
+		{"https://ng.indeed.com/viewjob?jk=1", "", ApplyMethodJobBoard},
+		{"mailto:jobs@example.com", "", ApplyMethodEmail},
+		{"careers@example.com", "", ApplyMethodEmail},
+		{"https://random-recruiter.net/apply", "https://paystack.com", ApplyMethodUnknown},
+		{"", "", ApplyMethodUnknown},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, DetectApplyMethod(tt.applyURL, tt.companyURL), tt.applyURL)
+	}
+}
+
+func TestIsApplyMethod(t *testing.T) {
+	assert.True(t, IsApplyMethod("direct"))
+	assert.True(t, IsApplyMethod("email"))
+	assert.False(t, IsApplyMethod("fax"))
+}
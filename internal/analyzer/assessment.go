@@ -0,0 +1,47 @@
+package analyzer
+
+import "regexp"
+
+// Assessment styles of a hiring process
+const (
+	AssessmentTakeHome        = "take_home"
+	AssessmentLiveCoding      = "live_coding"
+	AssessmentPairProgramming = "pair_programming"
+)
+
+// Assessments lists every assessment style that can be stored on a job
+var Assessments = []string{AssessmentTakeHome, AssessmentLiveCoding, AssessmentPairProgramming}
+
+// assessmentSignals are the phrases of a description hinting at each
+// assessment style, in Assessments order
+var assessmentSignals = []struct {
+	style   string
+	pattern *regexp.Regexp
+}{
+	{AssessmentTakeHome, regexp.MustCompile(`(?i)\btake[- ]?home\b|\bcoding (?:assignment|exercise|challenge)\b|\b(?:technical|coding) (?:assessment|test|task)\b|\bhome (?:assignment|task)\b`)},
+	{AssessmentLiveCoding, regexp.MustCompile(`(?i)\blive[- ]coding\b|\bcode live\b|\b(?:whiteboard|whiteboarding)\b|\bcoding interview\b|\bhackerrank\b|\bcodility\b`)},
+	{AssessmentPairProgramming, regexp.MustCompile(`(?i)\bpair[- ]?(?:programming|coding)\b|\bpairing (?:session|interview|exercise)\b|\bpair with (?:one of )?(?:our|an?) engineers?\b`)},
+}
+
+// DetectAssessments returns the assessment styles a job description mentions
+// for its hiring process, e.g. a take-home test followed by pair programming,
+// in Assessments order. Returns nil when the description gives no hint.
+func DetectAssessments(description string) []string {
+	var styles []string
+	for _, signal := range assessmentSignals {
+		if signal.pattern.MatchString(description) {
+			styles = append(styles, signal.style)
+		}
+	}
+	return styles
+}
+
+// IsAssessment reports whether style is a known assessment style
+func IsAssessment(style string) bool {
+	for _, a := range Assessments {
+		if a == style {
+			return true
+		}
+	}
+	return false
+}
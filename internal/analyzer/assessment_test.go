@@ -0,0 +1,31 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectAssessments(t *testing.T) {
+	tests := []struct {
+		description string
+		expected    []string
+	}{
+		{"Our process: a short call, a take-home assignment and a pair programming session with the team", []string{AssessmentTakeHome, AssessmentPairProgramming}},
+		{"You will complete a coding challenge (2-3 hours) at your own pace", []string{AssessmentTakeHome}},
+		{"Interview includes a live-coding round and a system design chat", []string{AssessmentLiveCoding}},
+		{"Expect a HackerRank test before the onsite whiteboard interview", []string{AssessmentLiveCoding}},
+		{"Final stage: pair with one of our engineers on a real ticket", []string{AssessmentPairProgramming}},
+		{"Take home test, then pairing session", []string{AssessmentTakeHome, AssessmentPairProgramming}},
+		{"Build payment APIs in Go with a friendly team", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, DetectAssessments(tt.description), tt.description)
+	}
+}
+
+func TestIsAssessment(t *testing.T) {
+	assert.True(t, IsAssessment(AssessmentLiveCoding))
+	assert.False(t, IsAssessment("trial_day"))
+}
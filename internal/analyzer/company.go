@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"strings"
+	"sync"
+)
+
+// companySuffixes are legal-form words that sources add or drop at will
+var companySuffixes = map[string]bool{
+	"ltd": true, "limited": true, "inc": true, "incorporated": true, "llc": true, "plc": true,
+	"co": true, "corp": true, "corporation": true, "gmbh": true, "ag": true, "bv": true,
+	"sa": true, "sarl": true, "pty": true,
+}
+
+// companyQualifiers are country words ending the names of local entities, so
+// "Andela Nigeria" and "Andela" match
+var companyQualifiers = map[string]bool{"nigeria": true, "ng": true}
+
+var (
+	companyAliasesMu sync.RWMutex
+	companyAliases   map[string]string
+)
+
+// SetCompanyAliases sets the aliases NormalizeCompany resolves, from the
+// CompanyKey of an alias to the CompanyKey of the company it stands for
+func SetCompanyAliases(aliases map[string]string) {
+	companyAliasesMu.Lock()
+	defer companyAliasesMu.Unlock()
+	companyAliases = aliases
+}
+
+// CompanyKey case folds a company name and drops punctuation, legal-form
+// suffixes and trailing country words, so "Andela Inc.", "ANDELA NIGERIA" and
+// "andela" all give "andela". Names made only of such words are kept whole.
+func CompanyKey(company string) string {
+	all := nameWords(company)
+	var words []string
+	for _, word := range all {
+		if !companySuffixes[word] {
+			words = append(words, word)
+		}
+	}
+	for len(words) > 1 && companyQualifiers[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		words = all
+	}
+	return strings.Join(words, " ")
+}
+
+// NormalizeCompany returns the CompanyKey of a company, replaced by the key
+// of the company it is an alias of (see SetCompanyAliases). Jobs of companies
+// with the same normalized name are deduplicated and blocked together.
+func NormalizeCompany(company string) string {
+	key := CompanyKey(company)
+
+	companyAliasesMu.RLock()
+	defer companyAliasesMu.RUnlock()
+	if canonical, ok := companyAliases[key]; ok {
+		return canonical
+	}
+	return key
+}
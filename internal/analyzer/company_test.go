@@ -0,0 +1,38 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompanyKey(t *testing.T) {
+	tests := []struct {
+		company  string
+		expected string
+	}{
+		{"Andela", "andela"},
+		{"Andela Inc.", "andela"},
+		{"ANDELA NIGERIA", "andela"},
+		{"Andela Nigeria Limited", "andela"},
+		{"Paystack Ltd.", "paystack"},
+		{"Nigerian Breweries Plc", "nigerian breweries"},
+		{"Nigeria", "nigeria"},
+		{"Co.", "co"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, CompanyKey(tt.company), tt.company)
+	}
+}
+
+func TestNormalizeCompany(t *testing.T) {
+	SetCompanyAliases(map[string]string{"andela talent": "andela"})
+	defer SetCompanyAliases(nil)
+
+	assert.Equal(t, "andela", NormalizeCompany("Andela Talent Ltd"))
+	assert.Equal(t, "andela", NormalizeCompany("ANDELA NIGERIA"))
+	assert.Equal(t, "paystack", NormalizeCompany("Paystack"))
+	assert.Equal(t, JobFingerprint("Go Engineer", "Andela", "Lagos"), JobFingerprint("Go Engineer", "Andela Talent", "Lagos"))
+}
@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// nameWords returns the lowercased words of s, split on anything that is not
+// a letter or digit
+func nameWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalizeLocation keeps the most specific part of a location, so "Lagos",
+// "Lagos, Nigeria" and "Lagos State, Nigeria" match
+func normalizeLocation(location string) string {
+	city, _, _ := strings.Cut(location, ",")
+	var words []string
+	for _, word := range nameWords(city) {
+		if word != "state" {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// JobFingerprint identifies a job independently of the source it came from,
+// from its normalized title (see TitleTokens), company (see NormalizeCompany)
+// and location
+func JobFingerprint(title, company, location string) string {
+	key := strings.Join([]string{
+		strings.Join(TitleTokens(title), " "),
+		NormalizeCompany(company),
+		normalizeLocation(location),
+	}, "|")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,19 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobFingerprint(t *testing.T) {
+	fingerprint := JobFingerprint("Senior Golang Developer", "Paystack", "Lagos, Nigeria")
+
+	assert.Len(t, fingerprint, 32)
+	assert.Equal(t, fingerprint, JobFingerprint("Developer (Golang), Senior", "Paystack Ltd.", "Lagos"))
+	assert.Equal(t, fingerprint, JobFingerprint("Sr Go Engineer", "PAYSTACK", "Lagos State, Nigeria"))
+
+	assert.NotEqual(t, fingerprint, JobFingerprint("Junior Go Engineer", "Paystack", "Lagos"))
+	assert.NotEqual(t, fingerprint, JobFingerprint("Senior Go Engineer", "Flutterwave", "Lagos"))
+	assert.NotEqual(t, fingerprint, JobFingerprint("Senior Go Engineer", "Paystack", "Abuja"))
+}
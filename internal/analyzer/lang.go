@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Languages detected in job descriptions, as ISO 639-1 codes
+const (
+	LangEnglish    = "en"
+	LangFrench     = "fr"
+	LangSpanish    = "es"
+	LangPortuguese = "pt"
+	LangGerman     = "de"
+)
+
+// Languages are the languages DetectLanguage tells apart
+var Languages = []string{LangEnglish, LangFrench, LangSpanish, LangPortuguese, LangGerman}
+
+// langStopwords are frequent words telling the languages apart. Words common
+// to several of them (e.g. "de", "la", "para") are left out.
+var langStopwords = map[string][]string{
+	LangEnglish: {"the", "and", "of", "to", "with", "for", "you", "we", "our", "is", "are", "will", "your",
+		"this", "that", "have", "be", "on"},
+	LangFrench: {"le", "les", "des", "et", "est", "une", "du", "pour", "nous", "vous", "avec", "dans", "sur",
+		"au", "aux", "qui", "sont", "votre", "notre", "vos", "nos"},
+	LangSpanish: {"el", "los", "las", "y", "una", "con", "por", "es", "del", "su", "sus", "nuestro", "nuestra",
+		"somos", "más", "usted", "tu"},
+	LangPortuguese: {"os", "uma", "com", "não", "você", "nosso", "nossa", "são", "em", "do", "da", "dos", "das",
+		"na", "é", "ao"},
+	LangGerman: {"der", "die", "das", "und", "ist", "ein", "eine", "mit", "für", "wir", "sie", "zu", "den",
+		"dem", "auf", "nicht", "von", "bei", "ihr", "ihre"},
+}
+
+// langOf maps each stopword to its language
+var langOf = func() map[string]string {
+	langOf := make(map[string]string)
+	for lang, words := range langStopwords {
+		for _, word := range words {
+			langOf[word] = lang
+		}
+	}
+	return langOf
+}()
+
+// minLanguageHits is how many stopwords of a language a text needs for
+// DetectLanguage to name it
+const minLanguageHits = 5
+
+// DetectLanguage returns the language of a job title and description (one of
+// the Lang constants), from the stopwords of each language it uses. Returns
+// "" when the text is too short or no language clearly dominates, e.g. in a
+// list of technologies.
+func DetectLanguage(text string) string {
+	hits := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if lang, ok := langOf[word]; ok {
+			hits[lang]++
+		}
+	}
+
+	best, runnerUp := "", 0
+	for lang, n := range hits {
+		switch {
+		case best == "" || n > hits[best]:
+			if best != "" {
+				runnerUp = hits[best]
+			}
+			best = lang
+		case n > runnerUp:
+			runnerUp = n
+		}
+	}
+	// Texts mixing languages, e.g. English postings quoting a French
+	// company motto, go to the language used at least twice as much
+	if hits[best] < minLanguageHits || hits[best] < 2*runnerUp {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			"english",
+			"Golang Developer\nWe are looking for a Go engineer to join our payments team. You will build APIs and work with the product team on the roadmap.",
+			LangEnglish,
+		},
+		{
+			"french",
+			"Développeur Go\nNous recherchons un développeur Go pour rejoindre notre équipe. Vous travaillerez sur des API avec les équipes produit et vous serez au cœur de la plateforme.",
+			LangFrench,
+		},
+		{
+			"spanish",
+			"Desarrollador Go\nBuscamos un desarrollador para el equipo de pagos. Trabajarás con los equipos de producto y las APIs del sistema, y es un puesto con más responsabilidad.",
+			LangSpanish,
+		},
+		{
+			"portuguese",
+			"Desenvolvedor Go\nProcuramos um desenvolvedor para a nossa equipe. Você vai trabalhar com os times de produto em uma plataforma que não para, e é remoto.",
+			LangPortuguese,
+		},
+		{
+			"german",
+			"Go Entwickler\nWir suchen einen Entwickler für das Team. Sie arbeiten mit der Produktabteilung und den Kunden an der Plattform und ihr Wissen ist gefragt.",
+			LangGerman,
+		},
+		{"too short", "Senior Golang Engineer", ""},
+		{"technologies only", "Go, gRPC, Postgres, Kubernetes, AWS", ""},
+		{
+			"mixed languages",
+			"We are hiring for the team. Nous recherchons des développeurs et vous serez dans une équipe pour les API. You will have the best of both.",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectLanguage(tt.text))
+		})
+	}
+}
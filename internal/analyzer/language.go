@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Categories of exclusionary language
+const (
+	LanguageCategoryAge    = "age"
+	LanguageCategoryGender = "gender"
+)
+
+// LanguageFlag is an informational annotation on a phrase that may discourage
+// some candidates from applying
+type LanguageFlag struct {
+	Category   string `json:"category"`
+	Phrase     string `json:"phrase"`
+	Suggestion string `json:"suggestion"`
+}
+
+// languageRule matches an age or gender-coded phrase
+type languageRule struct {
+	category   string
+	pattern    *regexp.Regexp
+	suggestion string
+}
+
+// languageRules are the phrases flagged by AuditLanguage
+var languageRules = []languageRule{
+	{LanguageCategoryAge, regexp.MustCompile(`(?i)\b(?:not (?:be )?(?:older|above|more) than|below|under|maximum age(?: of)?|aged?) \d{2}(?: ?-? ?\d{2})?(?: years?(?: old)?)?\b`),
+		"remove age limits; describe the experience the role needs instead"},
+	{LanguageCategoryAge, regexp.MustCompile(`(?i)\b(?:young|youthful|digital natives?)\b`),
+		"describe the skills or attitude wanted, e.g. \"curious\" or \"eager to learn\""},
+	{LanguageCategoryAge, regexp.MustCompile(`(?i)\b(?:recent|fresh) (?:graduates?|grads?)\b`),
+		"say \"early-career\" or list the experience level needed"},
+	{LanguageCategoryGender, regexp.MustCompile(`(?i)\b(?:only )?(?:male|female|men|women) (?:candidates|applicants) only\b|\bonly (?:male|female|men|women) (?:candidates|applicants)\b`),
+		"open the role to every qualified candidate"},
+	{LanguageCategoryGender, regexp.MustCompile(`(?i)\b(?:rock ?stars?|ninjas?|gurus?)\b`),
+		"use the actual job title, e.g. \"experienced Go engineer\""},
+	{LanguageCategoryGender, regexp.MustCompile(`(?i)\b(?:aggressive|dominant|dominate)\b`),
+		"prefer neutral wording such as \"ambitious\" or \"driven\""},
+	{LanguageCategoryGender, regexp.MustCompile(`(?i)\b(?:he|she) (?:will|should|must|is)\b`),
+		"address the candidate as \"you\" or use \"they\""},
+	{LanguageCategoryGender, regexp.MustCompile(`(?i)\b(?:manpower|chairman|salesman|salesmen|guys)\b`),
+		"use gender-neutral terms such as \"staff\", \"chair\" or \"team\""},
+}
+
+// AuditLanguage flags age and gender-coded phrases in a job posting, each
+// distinct phrase once, in rule order. The flags are informational only.
+func AuditLanguage(text string) []LanguageFlag {
+	var flags []LanguageFlag
+	seen := make(map[string]bool)
+	for _, rule := range languageRules {
+		for _, match := range rule.pattern.FindAllString(text, -1) {
+			phrase := strings.ToLower(strings.Join(strings.Fields(match), " "))
+			if seen[phrase] {
+				continue
+			}
+			seen[phrase] = true
+			flags = append(flags, LanguageFlag{Category: rule.category, Phrase: phrase, Suggestion: rule.suggestion})
+		}
+	}
+	return flags
+}
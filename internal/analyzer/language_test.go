@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLanguage(t *testing.T) {
+	flags := AuditLanguage(`We are a young, energetic team looking for a Golang Rockstar.
+		Applicants must not be older than 30 years. He will own our payments APIs.
+		Male candidates only. Recent graduates are welcome. Our rockstar engineers ship daily.`)
+
+	phrases := make(map[string]string)
+	for _, flag := range flags {
+		phrases[flag.Phrase] = flag.Category
+		assert.NotEmpty(t, flag.Suggestion)
+	}
+
+	assert.Equal(t, map[string]string{
+		"not be older than 30 years": LanguageCategoryAge,
+		"young":                      LanguageCategoryAge,
+		"recent graduates":           LanguageCategoryAge,
+		"male candidates only":       LanguageCategoryGender,
+		"rockstar":                   LanguageCategoryGender,
+		"he will":                    LanguageCategoryGender,
+	}, phrases)
+}
+
+func TestAuditLanguageClean(t *testing.T) {
+	// Words merely containing flagged ones are not flagged
+	assert.Empty(t, AuditLanguage("You will build Go services with 3+ years of experience. Strong guidance from senior engineers."))
+	assert.Empty(t, AuditLanguage(""))
+}
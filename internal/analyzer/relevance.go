@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RelevanceRule decides whether a job is about a technology. A job is
+// relevant when its title or description has one of the Include keywords as
+// a whole word ("Go/Java engineer" matches "go"), once the Exclude phrases,
+// look-alikes such as "go-to-market", are ignored. Keywords are matched
+// case-insensitively.
+type RelevanceRule struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// RelevanceMatch is the classification of a job by a RelevanceRule
+type RelevanceMatch struct {
+	Relevant bool `json:"relevant"`
+	// Matched are the Include keywords found
+	Matched []string `json:"matched"`
+	// Excluded are the Exclude phrases found and ignored
+	Excluded []string `json:"excluded"`
+}
+
+// NewRelevanceRule compiles a rule. Blank keywords are dropped.
+func NewRelevanceRule(include, exclude []string) RelevanceRule {
+	rule := RelevanceRule{Include: []string{}, Exclude: []string{}}
+	for _, keyword := range include {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			rule.Include = append(rule.Include, keyword)
+			rule.include = append(rule.include, keywordPattern(keyword))
+		}
+	}
+	for _, phrase := range exclude {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			rule.Exclude = append(rule.Exclude, phrase)
+			rule.exclude = append(rule.exclude, keywordPattern(phrase))
+		}
+	}
+	return rule
+}
+
+// keywordPattern matches keyword as a whole word: not preceded or followed by
+// a letter, digit or underscore. Unlike \b this also works for keywords
+// ending in punctuation, e.g. "c++".
+func keywordPattern(keyword string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[^\pL\pN_])` + regexp.QuoteMeta(keyword) + `(?:$|[^\pL\pN_])`)
+}
+
+// Classify reports whether a job with title and description is relevant and
+// which keywords decided it
+func (r RelevanceRule) Classify(title, description string) RelevanceMatch {
+	match := RelevanceMatch{Matched: []string{}, Excluded: []string{}}
+	text := title + "\n" + description
+
+	for i, pattern := range r.exclude {
+		if pattern.MatchString(text) {
+			match.Excluded = append(match.Excluded, r.Exclude[i])
+			text = pattern.ReplaceAllString(text, " ")
+		}
+	}
+	for i, pattern := range r.include {
+		if pattern.MatchString(text) {
+			match.Matched = append(match.Matched, r.Include[i])
+		}
+	}
+
+	match.Relevant = len(match.Matched) > 0
+	return match
+}
@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelevanceRuleClassify(t *testing.T) {
+	rule := NewRelevanceRule([]string{"go", "golang"}, []string{"go-to-market", "go to market", "on the go", "ready to go"})
+
+	tests := []struct {
+		title       string
+		description string
+		relevant    bool
+	}{
+		{"Go/Java Engineer", "", true},
+		{"Backend Engineer (Go)", "", true},
+		{"Senior Golang Developer", "", true},
+		{"Go-to-Market Manager", "Own our go to market strategy.", false},
+		{"Sales Associate", "You are always on the go and ready to go.", false},
+		{"Django Developer", "Python, Google Cloud and MongoDB.", false},
+		{"Go-to-Market Engineer", "Build internal tools in Go.", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.relevant, rule.Classify(tt.title, tt.description).Relevant, tt.title)
+	}
+
+	match := rule.Classify("Go-to-Market Engineer", "Build internal tools in Golang.")
+	assert.Equal(t, []string{"golang"}, match.Matched)
+	assert.Equal(t, []string{"go-to-market"}, match.Excluded)
+}
+
+func TestRelevanceRuleKeywords(t *testing.T) {
+	rule := NewRelevanceRule([]string{"rust", " c++ ", ""}, nil)
+
+	assert.Equal(t, []string{"rust", "c++"}, rule.Include)
+	assert.True(t, rule.Classify("C++ Developer", "").Relevant)
+	assert.True(t, rule.Classify("Systems Engineer", "Rust, Tokio").Relevant)
+	assert.False(t, rule.Classify("Golang Developer", "Trust and safety").Relevant)
+}
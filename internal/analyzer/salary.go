@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Salary periods
+const (
+	SalaryPeriodHour  = "hour"
+	SalaryPeriodDay   = "day"
+	SalaryPeriodWeek  = "week"
+	SalaryPeriodMonth = "month"
+	SalaryPeriodYear  = "year"
+)
+
+// Salary is an offer parsed from a free-text salary such as "₦400k - ₦600k
+// monthly" or "$80K-$100K"
+type Salary struct {
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Currency string  `json:"currency"`
+	Period   string  `json:"period"`
+}
+
+// salaryCurrencies maps currency symbols and codes to ISO codes; a bare "N"
+// only counts right before a number (N500,000)
+var salaryCurrencies = []struct {
+	pattern  *regexp.Regexp
+	currency string
+}{
+	{regexp.MustCompile(`₦|(?i)\bngn\b|\bN\d|(?i)\bnaira\b`), "NGN"},
+	{regexp.MustCompile(`\$|(?i)\busd\b`), "USD"},
+	{regexp.MustCompile(`£|(?i)\bgbp\b`), "GBP"},
+	{regexp.MustCompile(`€|(?i)\beur\b`), "EUR"},
+}
+
+// salaryPeriods maps period wording to periods
+var salaryPeriods = []struct {
+	pattern *regexp.Regexp
+	period  string
+}{
+	{regexp.MustCompile(`(?i)hourly|(?:per|/|an|a)\s*(?:hour|hr)\b`), SalaryPeriodHour},
+	{regexp.MustCompile(`(?i)daily|(?:per|/|a)\s*day\b`), SalaryPeriodDay},
+	{regexp.MustCompile(`(?i)weekly|(?:per|/|a)\s*(?:week|wk)\b`), SalaryPeriodWeek},
+	{regexp.MustCompile(`(?i)monthly|(?:per|/|a)\s*(?:month|mo|mth)\b`), SalaryPeriodMonth},
+	{regexp.MustCompile(`(?i)annual|yearly|p\.?a\.?\b|(?:per|/|a)\s*(?:year|yr|annum)\b`), SalaryPeriodYear},
+}
+
+// salaryAmount matches an amount with optional thousands separators and a
+// k (thousand) or m (million) suffix
+var salaryAmount = regexp.MustCompile(`(\d[\d,]*(?:\.\d+)?)\s*([kKmM])?\b`)
+
+// monthsPerPeriod converts a period's pay to a month's, assuming full time
+var monthsPerPeriod = map[string]float64{
+	SalaryPeriodHour:  160,
+	SalaryPeriodDay:   22,
+	SalaryPeriodWeek:  52.0 / 12,
+	SalaryPeriodMonth: 1,
+	SalaryPeriodYear:  1.0 / 12,
+}
+
+// ParseSalary parses a free-text salary. It reports false when the text has
+// no amount or no recognizable currency. Without a period, naira salaries are
+// taken as monthly and others as yearly, as they are usually quoted.
+func ParseSalary(text string) (Salary, bool) {
+	var salary Salary
+	for _, c := range salaryCurrencies {
+		if c.pattern.MatchString(text) {
+			salary.Currency = c.currency
+			break
+		}
+	}
+	if salary.Currency == "" {
+		return Salary{}, false
+	}
+
+	var amounts []float64
+	for _, match := range salaryAmount.FindAllStringSubmatch(text, 2) {
+		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(match[2]) {
+		case "k":
+			amount *= 1e3
+		case "m":
+			amount *= 1e6
+		}
+		if amount > 0 {
+			amounts = append(amounts, amount)
+		}
+	}
+	if len(amounts) == 0 {
+		return Salary{}, false
+	}
+	salary.Min, salary.Max = amounts[0], amounts[len(amounts)-1]
+	if salary.Max < salary.Min {
+		salary.Min, salary.Max = salary.Max, salary.Min
+	}
+
+	for _, p := range salaryPeriods {
+		if p.pattern.MatchString(text) {
+			salary.Period = p.period
+			break
+		}
+	}
+	if salary.Period == "" {
+		salary.Period = SalaryPeriodYear
+		if salary.Currency == "NGN" {
+			salary.Period = SalaryPeriodMonth
+		}
+	}
+	return salary, true
+}
+
+// Monthly returns the midpoint of the offer as monthly pay
+func (s Salary) Monthly() float64 {
+	return (s.Min + s.Max) / 2 * monthsPerPeriod[s.Period]
+}
@@ -0,0 +1,37 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSalary(t *testing.T) {
+	tests := []struct {
+		text   string
+		salary Salary
+	}{
+		{"$80K-$100K", Salary{Min: 80000, Max: 100000, Currency: "USD", Period: SalaryPeriodYear}},
+		{"₦400,000 - ₦600,000 monthly", Salary{Min: 400000, Max: 600000, Currency: "NGN", Period: SalaryPeriodMonth}},
+		{"N500k", Salary{Min: 500000, Max: 500000, Currency: "NGN", Period: SalaryPeriodMonth}},
+		{"NGN 6M per annum", Salary{Min: 6e6, Max: 6e6, Currency: "NGN", Period: SalaryPeriodYear}},
+		{"USD 40 - 60 per hour", Salary{Min: 40, Max: 60, Currency: "USD", Period: SalaryPeriodHour}},
+		{"£3,500/month", Salary{Min: 3500, Max: 3500, Currency: "GBP", Period: SalaryPeriodMonth}},
+	}
+	for _, tt := range tests {
+		salary, ok := ParseSalary(tt.text)
+		assert.True(t, ok, tt.text)
+		assert.Equal(t, tt.salary, salary, tt.text)
+	}
+
+	for _, text := range []string{"", "Competitive", "80-100k"} {
+		_, ok := ParseSalary(text)
+		assert.False(t, ok, text)
+	}
+}
+
+func TestSalaryMonthly(t *testing.T) {
+	assert.Equal(t, 500000.0, Salary{Min: 400000, Max: 600000, Currency: "NGN", Period: SalaryPeriodMonth}.Monthly())
+	assert.Equal(t, 7500.0, Salary{Min: 80000, Max: 100000, Currency: "USD", Period: SalaryPeriodYear}.Monthly())
+	assert.Equal(t, 8000.0, Salary{Min: 40, Max: 60, Currency: "USD", Period: SalaryPeriodHour}.Monthly())
+}
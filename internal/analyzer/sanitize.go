@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// descriptionPolicy keeps the formatting of job descriptions (paragraphs,
+// lists, emphasis, headings, tables and links) and drops scripts, styles,
+// event handlers, iframes and forms. Links get rel="nofollow noopener" and
+// open in a new tab.
+var descriptionPolicy = func() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+	policy.RequireNoFollowOnLinks(true)
+	return policy
+}()
+
+// SanitizeHTML returns the HTML of a job description with only the markup
+// that is safe to render in a page, see descriptionPolicy
+func SanitizeHTML(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+	return strings.TrimSpace(descriptionPolicy.Sanitize(s))
+}
+
+var (
+	// htmlBreaks match the tags ending a line or paragraph
+	htmlBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</(?:p|div|li|h[1-6])>`)
+	// htmlTags match any other tag
+	htmlTags = regexp.MustCompile(`<[^>]*>`)
+	// blankRuns match the spaces around a line break and repeated blank lines
+	blankRuns = regexp.MustCompile(`[ \t]*\n[ \t\n]*`)
+)
+
+// HTMLToText returns the text of an HTML job description, one line per
+// paragraph or line break
+func HTMLToText(s string) string {
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = htmlTags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	return strings.TrimSpace(blankRuns.ReplaceAllString(s, "\n"))
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{"empty stays empty", "  ", ""},
+		{
+			"formatting is kept",
+			"<h2>About</h2><p>Build <strong>payments</strong> in Go:</p><ul><li>APIs</li></ul>",
+			"<h2>About</h2><p>Build <strong>payments</strong> in Go:</p><ul><li>APIs</li></ul>",
+		},
+		{"scripts are dropped", `<p>Apply now</p><script>alert(document.cookie)</script>`, "<p>Apply now</p>"},
+		{"event handlers are dropped", `<img src="https://cdn.example/logo.png" onerror="steal()">`, `<img src="https://cdn.example/logo.png">`},
+		{"styles and iframes are dropped", `<style>body{display:none}</style><iframe src="https://evil.example"></iframe><p>Hi</p>`, "<p>Hi</p>"},
+		{"javascript links are dropped", `<a href="javascript:steal()">Apply</a>`, "Apply"},
+		{
+			"links open apart without referrer access",
+			`<a href="https://paystack.com/careers">Apply</a>`,
+			`<a href="https://paystack.com/careers" rel="nofollow noopener" target="_blank">Apply</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SanitizeHTML(tt.html))
+		})
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	assert.Equal(t, "About\nBuild payments & APIs\nGo\nPostgres",
+		HTMLToText("<h2>About</h2>\n<p>Build <b>payments</b> &amp; APIs</p><ul><li>Go</li><li>Postgres</li></ul>"))
+	assert.Equal(t, "Remote only", HTMLToText("Remote&nbsp;only<br/>"))
+}
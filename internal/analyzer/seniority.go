@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Seniority levels of a job
+const (
+	SeniorityJunior = "junior"
+	SeniorityMid    = "mid"
+	SenioritySenior = "senior"
+	SeniorityLead   = "lead"
+)
+
+// Seniorities lists every seniority level that can be stored on a job
+var Seniorities = []string{SeniorityJunior, SeniorityMid, SenioritySenior, SeniorityLead}
+
+// senioritySignals maps normalized title words (see TitleTokens) to the level
+// they indicate, checked from the most senior level down
+var senioritySignals = []struct {
+	level string
+	words []string
+}{
+	{SeniorityLead, []string{"lead", "principal", "staff", "head", "architect", "manager", "director", "vp", "cto"}},
+	{SenioritySenior, []string{"senior", "iii", "iv", "expert"}},
+	{SeniorityJunior, []string{"junior", "intern", "internship", "graduate", "trainee", "entry", "apprentice", "i"}},
+	{SeniorityMid, []string{"mid", "intermediate", "ii", "medior"}},
+}
+
+// experiencePattern matches a required number of years of experience, e.g.
+// "5+ years of experience" or "3-5 years experience"
+var experiencePattern = regexp.MustCompile(`(?i)\b(\d{1,2})\s*\+?\s*(?:(?:-|to)\s*\d{1,2}\s*)?years?(?:'|’)?\s+(?:of\s+)?(?:\w+\s+){0,3}?experience`)
+
+// DetectSeniority classifies a job as junior, mid, senior or lead from the
+// words of its title, falling back to the years of experience its description
+// asks for. Returns "" when neither gives a signal.
+func DetectSeniority(title, description string) string {
+	tokens := make(map[string]bool)
+	for _, token := range TitleTokens(title) {
+		tokens[token] = true
+	}
+	for _, signal := range senioritySignals {
+		for _, word := range signal.words {
+			if tokens[word] {
+				return signal.level
+			}
+		}
+	}
+
+	match := experiencePattern.FindStringSubmatch(description)
+	if match == nil {
+		return ""
+	}
+	years, err := strconv.Atoi(match[1])
+	if err != nil {
+		return ""
+	}
+	switch {
+	case years <= 1:
+		return SeniorityJunior
+	case years <= 4:
+		return SeniorityMid
+	default:
+		return SenioritySenior
+	}
+}
+
+// IsSeniority reports whether level is a known seniority level
+func IsSeniority(level string) bool {
+	for _, s := range Seniorities {
+		if s == level {
+			return true
+		}
+	}
+	return false
+}
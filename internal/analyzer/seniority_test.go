@@ -0,0 +1,37 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectSeniority(t *testing.T) {
+	tests := []struct {
+		title       string
+		description string
+		expected    string
+	}{
+		{"Senior Golang Developer", "", SenioritySenior},
+		{"Sr. Go Engineer", "", SenioritySenior},
+		{"Tech Lead, Payments (Go)", "", SeniorityLead},
+		{"Principal Backend Engineer", "7+ years of experience", SeniorityLead},
+		{"Junior Go Developer", "", SeniorityJunior},
+		{"Golang Intern", "", SeniorityJunior},
+		{"Backend Engineer II", "", SeniorityMid},
+		{"Golang Developer", "You have 3-5 years of professional experience with Go", SeniorityMid},
+		{"Golang Developer", "Requirements: 6+ years experience building APIs", SenioritySenior},
+		{"Go Engineer", "1 year of Go experience is enough", SeniorityJunior},
+		{"Golang Developer", "Build payment APIs in Go", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, DetectSeniority(tt.title, tt.description), tt.title)
+	}
+}
+
+func TestIsSeniority(t *testing.T) {
+	assert.True(t, IsSeniority("senior"))
+	assert.True(t, IsSeniority("lead"))
+	assert.False(t, IsSeniority("expert"))
+}
@@ -0,0 +1,32 @@
+package analyzer
+
+import "strings"
+
+// Snippet shortens a description to at most maxChars characters for list
+// views, ending at the last sentence boundary that keeps at least half of
+// them, else at the last word followed by an ellipsis. Whitespace is
+// collapsed; text that already fits is returned as is.
+func Snippet(text string, maxChars int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= maxChars {
+		return string(runes)
+	}
+
+	// A sentence ends with punctuation followed by a space, so look one
+	// character past the limit for it
+	for i := maxChars - 1; i >= maxChars/2; i-- {
+		if strings.ContainsRune(".!?", runes[i]) && runes[i+1] == ' ' {
+			return string(runes[:i+1])
+		}
+	}
+
+	// Leave room for the ellipsis
+	cut := maxChars - 1
+	for i := cut; i > 0; i-- {
+		if runes[i] == ' ' {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRight(string(runes[:cut]), " ,;:-") + "…"
+}
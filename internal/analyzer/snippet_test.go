@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		expected string
+	}{
+		{"short text is kept", "We are hiring.", 50, "We are hiring."},
+		{"whitespace is collapsed", "We are\n\n  hiring.", 50, "We are hiring."},
+		{
+			"ends at a sentence boundary",
+			"Join our payments team. You will build APIs in Go. We offer remote work.",
+			50,
+			"Join our payments team. You will build APIs in Go.",
+		},
+		{
+			"too early a boundary falls back to words",
+			"Hi. We are looking for a senior backend engineer to design and scale our services",
+			40,
+			"Hi. We are looking for a senior backend…",
+		},
+		{"counts characters, not bytes", "Ọ̀gá engineer needed in Lagos for Go services", 22, "Ọ̀gá engineer needed…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := Snippet(tt.text, tt.maxChars)
+			assert.Equal(t, tt.expected, snippet)
+			assert.LessOrEqual(t, len([]rune(snippet)), tt.maxChars)
+		})
+	}
+}
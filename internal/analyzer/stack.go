@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Stack tags of the tools a job uses, kept apart from the generic skills of
+// a description. Go versions are tagged goX.Y, e.g. go1.22.
+const (
+	StackGRPC       = "grpc"
+	StackGin        = "gin"
+	StackEcho       = "echo"
+	StackFiber      = "fiber"
+	StackPostgres   = "postgres"
+	StackMongoDB    = "mongodb"
+	StackAWS        = "aws"
+	StackGCP        = "gcp"
+	StackAzure      = "azure"
+	StackKubernetes = "kubernetes"
+)
+
+// Stacks lists every stack tag but the Go versions
+var Stacks = []string{
+	StackGRPC, StackGin, StackEcho, StackFiber, StackPostgres, StackMongoDB,
+	StackAWS, StackGCP, StackAzure, StackKubernetes,
+}
+
+// goVersionPattern matches a Go version mentioned in a description, e.g.
+// "Go 1.22", "Golang 1.21+" or "go1.20.3"
+var goVersionPattern = regexp.MustCompile(`(?i)\b(?:go|golang)\s?v?1\.(\d{1,2})(?:\.\d+)?\b`)
+
+// goVersionTag matches the stack tag of a Go version
+var goVersionTag = regexp.MustCompile(`^go1\.\d{1,2}$`)
+
+// goFramework matches a Go web framework named by itself: the names are
+// common words ("echo our values", "fiber network"), so they only count in
+// a list with another framework, before "framework" or as an import path
+const goFramework = `(?:gin|echo|fiber|chi|gorilla(?:/mux)?|beego|revel)`
+
+// frameworkPattern returns the pattern of a Go web framework name, alias
+// being an unambiguous spelling (e.g. its module path)
+func frameworkPattern(name, alias string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b` + alias + `\b` +
+		`|\b` + name + `\s+(?:web\s+)?framework` +
+		`|\b` + goFramework + `\s*(?:,|/|\bor\b|\band\b)\s*` + name + `\b` +
+		`|\b` + name + `\s*(?:,|/|\bor\b|\band\b)\s*` + goFramework + `\b`)
+}
+
+// stackSignals are the mentions of each stack tag, in Stacks order
+var stackSignals = []struct {
+	tag     string
+	pattern *regexp.Regexp
+}{
+	{StackGRPC, regexp.MustCompile(`(?i)\bgrpc\b`)},
+	{StackGin, frameworkPattern("gin", `gin-gonic`)},
+	{StackEcho, frameworkPattern("echo", `labstack/echo`)},
+	{StackFiber, frameworkPattern("fiber", `gofiber`)},
+	{StackPostgres, regexp.MustCompile(`(?i)\bpostgres(?:ql)?\b|\bpostgre\b`)},
+	{StackMongoDB, regexp.MustCompile(`(?i)\bmongo(?:db)?\b`)},
+	{StackAWS, regexp.MustCompile(`(?i)\baws\b|\bamazon web services\b`)},
+	{StackGCP, regexp.MustCompile(`(?i)\bgcp\b|\bgoogle cloud\b`)},
+	{StackAzure, regexp.MustCompile(`(?i)\bazure\b`)},
+	{StackKubernetes, regexp.MustCompile(`(?i)\bkubernetes\b|\bk8s\b`)},
+}
+
+// DetectStack returns the stack tags of the tools a job's title and
+// description mention: the Go versions, oldest first, then the other tags
+// in Stacks order. Returns nil when none is mentioned.
+func DetectStack(title, description string) []string {
+	text := title + "\n" + description
+
+	// Go versions by minor version, so they sort numerically
+	var minors []int
+	seen := map[int]bool{}
+	for _, match := range goVersionPattern.FindAllStringSubmatch(text, -1) {
+		minor, _ := strconv.Atoi(match[1])
+		if !seen[minor] {
+			seen[minor] = true
+			minors = append(minors, minor)
+		}
+	}
+	sort.Ints(minors)
+
+	var tags []string
+	for _, minor := range minors {
+		tags = append(tags, "go1."+strconv.Itoa(minor))
+	}
+	for _, signal := range stackSignals {
+		if signal.pattern.MatchString(text) {
+			tags = append(tags, signal.tag)
+		}
+	}
+	return tags
+}
+
+// IsStack reports whether tag is a known stack tag or a Go version tag
+func IsStack(tag string) bool {
+	if goVersionTag.MatchString(tag) {
+		return true
+	}
+	for _, s := range Stacks {
+		if s == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectStack(t *testing.T) {
+	tests := []struct {
+		title, description string
+		expected           []string
+	}{
+		{"Senior Go Engineer (gRPC, Kubernetes)", "We run Go 1.22 services on GKE and Postgres.",
+			[]string{"go1.22", StackGRPC, StackPostgres, StackKubernetes}},
+		{"Backend Developer", "Golang 1.21+ for new services, go1.20.3 in CI and a legacy go1.9 module; MongoDB and AWS or Google Cloud",
+			[]string{"go1.9", "go1.20", "go1.21", StackMongoDB, StackAWS, StackGCP}},
+		{"Go Developer", "APIs built with Gin or Echo, deployed on Azure with k8s",
+			[]string{StackGin, StackEcho, StackAzure, StackKubernetes}},
+		{"Go Developer", "We use the Fiber framework and github.com/gin-gonic/gin in older services",
+			[]string{StackGin, StackFiber}},
+		// Framework names as plain words are not tags
+		{"Go Engineer", "Help us echo our values and roll out our fiber network; a gin and tonic on Fridays",
+			nil},
+		{"Go Developer", "Build payment APIs in Go with a friendly team, 1.5 years of experience", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, DetectStack(tt.title, tt.description), tt.description)
+	}
+}
+
+func TestIsStack(t *testing.T) {
+	assert.True(t, IsStack(StackGRPC))
+	assert.True(t, IsStack("go1.22"))
+	assert.False(t, IsStack("go2"))
+	assert.False(t, IsStack("react"))
+}
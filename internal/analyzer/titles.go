@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// titleStopWords carry no meaning when comparing job titles
+var titleStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "for": true,
+	"in": true, "at": true, "with": true, "to": true, "or": true,
+}
+
+// titleSynonyms normalizes spellings that name the same thing
+var titleSynonyms = map[string]string{
+	"golang":     "go",
+	"back":       "backend",
+	"sr":         "senior",
+	"jr":         "junior",
+	"snr":        "senior",
+	"developer":  "engineer",
+	"dev":        "engineer",
+	"programmer": "engineer",
+}
+
+// TitleTokens returns the distinct normalized words of a job title, sorted
+func TitleTokens(title string) []string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+
+	seen := make(map[string]bool, len(words))
+	var tokens []string
+	for _, word := range words {
+		if titleStopWords[word] {
+			continue
+		}
+		if synonym, ok := titleSynonyms[word]; ok {
+			word = synonym
+		}
+		if !seen[word] {
+			seen[word] = true
+			tokens = append(tokens, word)
+		}
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// TitleSimilarity returns the Jaccard similarity (0 to 1) of the normalized
+// words of two job titles, so reordered or re-punctuated titles such as
+// "Backend Engineer (Go)" and "Go Backend Engineer" score 1
+func TitleSimilarity(a, b string) float64 {
+	tokensA, tokensB := TitleTokens(a), TitleTokens(b)
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0
+	}
+
+	inA := make(map[string]bool, len(tokensA))
+	for _, token := range tokensA {
+		inA[token] = true
+	}
+
+	shared := 0
+	for _, token := range tokensB {
+		if inA[token] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(tokensA)+len(tokensB)-shared)
+}
@@ -0,0 +1,22 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTitleTokens(t *testing.T) {
+	assert.Equal(t, []string{"backend", "engineer", "go"}, TitleTokens("Backend Engineer (Go)"))
+	assert.Equal(t, []string{"engineer", "go", "senior"}, TitleTokens("Sr. Golang Developer"))
+	assert.Equal(t, []string{"c#", "engineer"}, TitleTokens("C# Engineer"))
+	assert.Empty(t, TitleTokens(" - "))
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, TitleSimilarity("Backend Engineer (Go)", "Go Backend Engineer"))
+	assert.Equal(t, 1.0, TitleSimilarity("Golang Developer", "Go Engineer"))
+	assert.InDelta(t, 0.75, TitleSimilarity("Senior Go Backend Engineer", "Go Backend Engineer"), 0.001)
+	assert.Equal(t, 0.0, TitleSimilarity("Go Engineer", "Frontend React Lead"))
+	assert.Equal(t, 0.0, TitleSimilarity("", "Go Engineer"))
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/db"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// minBlockedCompanyLength keeps blocked names from matching most
+	// companies, since any company containing the words of a blocked name is
+	// blocked
+	minBlockedCompanyLength = 3
+	// maxBlockedCompanySize bounds the body of a blocked company request
+	maxBlockedCompanySize = 4 << 10
+)
+
+// blockedCompanyRequest is the body of POST /api/admin/blocked-companies
+type blockedCompanyRequest struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// validBlockedCompany reports why a company name cannot be blocked, "" if it
+// can. Its length is checked once normalized, the form it is matched in.
+func validBlockedCompany(name string) string {
+	name = db.NormalizeBlockedCompany(name)
+	if key := analyzer.CompanyKey(name); len(key) < minBlockedCompanyLength || len(name) > maxJobFieldLength {
+		return fmt.Sprintf("Invalid name: %q (%d to %d characters once normalized)", name, minBlockedCompanyLength, maxJobFieldLength)
+	}
+	return ""
+}
+
+// ListBlockedCompanies returns the companies whose jobs are skipped on save
+func (h *Handler) ListBlockedCompanies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	companies, err := db.ListBlockedCompanies(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error querying blocked companies: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      companies,
+		"count":     len(companies),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// BlockCompany blocks a company: jobs of any company containing the name are
+// skipped from the next sync on. Jobs already saved are left listed.
+func (h *Handler) BlockCompany(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req blockedCompanyRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxBlockedCompanySize)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if msg := validBlockedCompany(req.Name); msg != "" {
+		writeError(w, r, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := db.BlockCompany(r.Context(), h.DB, req.Name, req.Reason); err != nil {
+		log.Printf("Error blocking company %q: %v", req.Name, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"name":      db.NormalizeBlockedCompany(req.Name),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// UnblockCompany unblocks a company, its jobs saved again from the next sync on
+func (h *Handler) UnblockCompany(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	name := mux.Vars(r)["name"]
+	removed, err := db.UnblockCompany(r.Context(), h.DB, name)
+	if err != nil {
+		log.Printf("Error unblocking company %q: %v", name, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !removed {
+		writeError(w, r, fmt.Sprintf("Company not blocked: %s", name), http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"name":      db.NormalizeBlockedCompany(name),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/services"
+)
+
+// defaultJobsCacheMaxAge is how long clients may reuse a job listing without
+// revalidating it when JOBS_CACHE_MAX_AGE is not configured
+const defaultJobsCacheMaxAge = 30 * time.Second
+
+// maxCachedResponse is the largest response body kept in the response
+// cache; larger listings are only streamed
+const maxCachedResponse = 1 << 20
+
+// responses returns the response cache, emptied first when a sync or import
+// saved jobs since its last use, or nil when response caching is disabled
+func (h *Handler) responses() db.CacheStore {
+	if h.Responses != nil {
+		h.Responses.SetGeneration(services.JobSaves())
+	}
+	return h.Responses
+}
+
+// rateLimits returns the counters of rate limited routes and HMAC nonces, in
+// process unless RateLimits is set
+func (h *Handler) rateLimits() db.CacheStore {
+	if h.RateLimits != nil {
+		return h.RateLimits
+	}
+	return db.NewCache(0, 0)
+}
+
+// cappedBuffer keeps a copy of the bytes written to it up to limit. Beyond,
+// it flags the overflow and drops them rather than failing, so it can tee a
+// response being sent.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+// Write implements io.Writer
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.overflow {
+		return len(p), nil
+	}
+	if b.Len()+len(p) > b.limit {
+		b.overflow = true
+		b.Reset()
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// jobDetailVersion is part of the ETag of job details; bump it when the
+// detail response changes so copies cached by clients are revalidated
+const jobDetailVersion = 5
+
+// jobDetailETag returns the weak ETag of a job detail shown in loc, from its
+// update time (bumped by every writer of jobs, enrichment and audits
+// included), the zone and jobDetailVersion
+func jobDetailETag(job *db.JobDetail, loc *time.Location) string {
+	var updated int64
+	if job.UpdatedAt != nil {
+		updated = job.UpdatedAt.UnixNano()
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%s|%s", jobDetailVersion, updated, job.ID, loc)))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// jobCompanyUpdatedAt selects the latest update of the company details a job
+// can be shown with, those of its domain or of its company (see
+// jobCompanyDetails), NULL without any
+const jobCompanyUpdatedAt = `GREATEST(
+	(SELECT cd.updated_at FROM company_details cd WHERE cd.domain = jobs.company_domain),
+	(SELECT MAX(cd.updated_at) FROM company_details cd WHERE cd.company_id = jobs.company_id))`
+
+// jobsVersion returns the validators of a job listing: a weak ETag from the
+// number of matching jobs, their latest update and the request URL, and the
+// latest update as the Last-Modified time (zero when no job matches). When
+// the listing shows company details, their latest update counts too, since
+// enrichment and admin overrides change them without touching the jobs.
+func (h *Handler) jobsVersion(r *http.Request, listing jobListing) (string, time.Time, error) {
+	var (
+		count          int
+		updated        sql.NullTime
+		companyUpdated sql.NullTime
+	)
+	columns := "COUNT(*), MAX(updated_at)"
+	dest := []interface{}{&count, &updated}
+	if listing.expandCompany || listing.includeCompany {
+		columns += ", MAX(" + jobCompanyUpdatedAt + ")"
+		dest = append(dest, &companyUpdated)
+	}
+	err := h.DB.QueryRowContext(r.Context(), "SELECT "+columns+" FROM jobs"+listing.where,
+		listing.filterArgs...).Scan(dest...)
+	if err != nil {
+		log.Printf("Error reading jobs version: %v", err)
+		return "", time.Time{}, errors.New("Internal server error")
+	}
+
+	lastModified := updated.Time
+	if companyUpdated.Time.After(lastModified) {
+		lastModified = companyUpdated.Time
+	}
+	version := fmt.Sprintf("%d|%d|%d|%s?%s", count, updated.Time.UnixNano(), companyUpdated.Time.UnixNano(),
+		r.URL.Path, r.URL.Query().Encode())
+	sum := sha256.Sum256([]byte(version))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`, lastModified, nil
+}
+
+// setCacheHeaders sets the validators of a response and lets the client reuse
+// it for the configured max-age. Responses depend on the API key's tier, so
+// shared caches must not keep them.
+func (h *Handler) setCacheHeaders(w http.ResponseWriter, etag string, lastModified time.Time) {
+	maxAge := defaultJobsCacheMaxAge
+	if h.Config != nil && h.Config.JobsCacheMaxAge > 0 {
+		maxAge = h.Config.JobsCacheMaxAge
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified reports whether the conditional headers of r match the
+// current validators, so a 304 can be answered. If-None-Match takes
+// precedence over If-Modified-Since, as RFC 9110 requires.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// Weak comparison: the W/ prefix is ignored
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
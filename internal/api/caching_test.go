@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotModified(t *testing.T) {
+	updated := time.Date(2025, 3, 1, 9, 30, 15, 500, time.UTC)
+	tests := []struct {
+		header, value string
+		expected      bool
+	}{
+		{"", "", false},
+		{"If-None-Match", `W/"abc"`, true},
+		{"If-None-Match", `"abc"`, true},
+		{"If-None-Match", `W/"old", W/"abc"`, true},
+		{"If-None-Match", `*`, true},
+		{"If-None-Match", `W/"old"`, false},
+		{"If-Modified-Since", updated.Format(http.TimeFormat), true},
+		{"If-Modified-Since", updated.Add(-time.Second).Format(http.TimeFormat), false},
+		{"If-Modified-Since", "yesterday", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/jobs", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		assert.Equal(t, tt.expected, notModified(req, `W/"abc"`, updated), tt.value)
+	}
+
+	// If-None-Match wins over a matching If-Modified-Since
+	req := httptest.NewRequest("GET", "/api/jobs", nil)
+	req.Header.Set("If-None-Match", `W/"old"`)
+	req.Header.Set("If-Modified-Since", updated.Format(http.TimeFormat))
+	assert.False(t, notModified(req, `W/"abc"`, updated))
+
+	// An empty listing has no Last-Modified to compare with
+	req = httptest.NewRequest("GET", "/api/jobs", nil)
+	req.Header.Set("If-Modified-Since", updated.Format(http.TimeFormat))
+	assert.False(t, notModified(req, `W/"abc"`, time.Time{}))
+}
+
+func TestGetAllJobsNotModified(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	updated := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	versionRows := func(count int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"count", "max"}).AddRow(count, updated)
+	}
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\), MAX\\(updated_at\\) FROM jobs WHERE (.+) AND source = \\$1$").
+		WithArgs("indeed").WillReturnRows(versionRows(0))
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) ORDER BY posted_at DESC$").
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\), MAX\\(updated_at\\) FROM jobs").WillReturnRows(versionRows(0))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\), MAX\\(updated_at\\) FROM jobs").WillReturnRows(versionRows(0))
+	// A job expired or was removed: same latest update, new ETag
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\), MAX\\(updated_at\\) FROM jobs").WillReturnRows(versionRows(1))
+	mock.ExpectQuery("^SELECT (.+) FROM jobs").WillReturnRows(sqlmock.NewRows(nil))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.Config = &config.Config{JobsCacheMaxAge: time.Minute}
+
+	req := httptest.NewRequest("GET", "/api/jobs?source=indeed", nil)
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "private, max-age=60", rr.Header().Get("Cache-Control"))
+	assert.Equal(t, "Sat, 01 Mar 2025 09:30:00 GMT", rr.Header().Get("Last-Modified"))
+	etag := rr.Header().Get("ETag")
+	assert.Regexp(t, `^W/"[0-9a-f]{24}"$`, etag)
+
+	req = httptest.NewRequest("GET", "/api/jobs?source=indeed", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+	assert.Equal(t, etag, rr.Header().Get("ETag"))
+
+	req = httptest.NewRequest("GET", "/api/jobs?source=indeed", nil)
+	req.Header.Set("If-Modified-Since", "Sat, 01 Mar 2025 09:30:00 GMT")
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+
+	req = httptest.NewRequest("GET", "/api/jobs?source=indeed", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllJobsResponseCache(t *testing.T) {
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	updated := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("^SELECT COUNT\\(\\*\\), MAX\\(updated_at\\) FROM jobs").
+			WillReturnRows(sqlmock.NewRows([]string{"count", "max"}).AddRow(0, updated))
+		if i == 0 {
+			// The second request is answered from the response cache
+			mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) ORDER BY posted_at DESC$").
+				WillReturnRows(sqlmock.NewRows(nil))
+		}
+	}
+
+	handler := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{}))
+	handler.Responses = db.NewCache(time.Minute, 10)
+
+	var bodies []string
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		handler.GetAllJobs(rr, httptest.NewRequest("GET", "/api/jobs", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEmpty(t, rr.Header().Get("ETag"))
+		bodies = append(bodies, rr.Body.String())
+	}
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Contains(t, bodies[1], `"count":0`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCappedBuffer(t *testing.T) {
+	buf := &cappedBuffer{limit: 4}
+	n, err := buf.Write([]byte("abc"))
+	assert.Equal(t, 3, n)
+	assert.NoError(t, err)
+	assert.False(t, buf.overflow)
+
+	n, err = buf.Write([]byte("de"))
+	assert.Equal(t, 2, n)
+	assert.NoError(t, err)
+	assert.True(t, buf.overflow)
+	assert.Equal(t, 0, buf.Len())
+}
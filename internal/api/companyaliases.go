@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/db"
+
+	"github.com/gorilla/mux"
+)
+
+// maxCompanyAliasSize bounds the body of a company alias request
+const maxCompanyAliasSize = 4 << 10
+
+// companyAliasRequest is the body of POST /api/admin/company-aliases
+type companyAliasRequest struct {
+	Alias   string `json:"alias"`
+	Company string `json:"company"`
+}
+
+// validCompanyAlias reports why an alias cannot be set, "" if it can
+func validCompanyAlias(req companyAliasRequest) string {
+	for name, value := range map[string]string{"alias": req.Alias, "company": req.Company} {
+		if key := analyzer.CompanyKey(value); key == "" || len(key) > maxJobFieldLength {
+			return fmt.Sprintf("Invalid %s: %q (1 to %d characters)", name, value, maxJobFieldLength)
+		}
+	}
+	return ""
+}
+
+// ListCompanyAliases returns the company names matched as other companies
+func (h *Handler) ListCompanyAliases(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	aliases, err := db.ListCompanyAliases(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error querying company aliases: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      aliases,
+		"count":     len(aliases),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetCompanyAlias makes jobs of the alias count as jobs of the company for
+// deduplication, blocking and company details. Saved jobs are matched again
+// at once.
+func (h *Handler) SetCompanyAlias(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req companyAliasRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxCompanyAliasSize)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if msg := validCompanyAlias(req); msg != "" {
+		writeError(w, r, msg, http.StatusBadRequest)
+		return
+	}
+
+	alias, err := db.SetCompanyAlias(r.Context(), h.DB, req.Alias, req.Company)
+	if errors.Is(err, db.ErrSelfAlias) {
+		writeError(w, r, fmt.Sprintf("Invalid alias: %q is already matched as %q", req.Alias, req.Company), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error setting company alias %q: %v", req.Alias, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      alias,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteCompanyAlias removes an alias, its jobs matched by their own name again
+func (h *Handler) DeleteCompanyAlias(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	alias := mux.Vars(r)["alias"]
+	removed, err := db.DeleteCompanyAlias(r.Context(), h.DB, alias)
+	if err != nil {
+		log.Printf("Error deleting company alias %q: %v", alias, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !removed {
+		writeError(w, r, fmt.Sprintf("Company alias not found: %s", alias), http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"alias":     analyzer.CompanyKey(alias),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
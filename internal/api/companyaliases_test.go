@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompanyAliasesAPI(t *testing.T) {
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+	defer analyzer.SetCompanyAliases(nil)
+
+	handler := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{}))
+
+	req := httptest.NewRequest("POST", "/api/admin/company-aliases", strings.NewReader(`{"alias":"Andela Talent"}`))
+	rr := httptest.NewRecorder()
+	handler.SetCompanyAlias(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid company")
+
+	req = httptest.NewRequest("POST", "/api/admin/company-aliases", strings.NewReader(`{"alias":"Andela Inc.","company":"ANDELA"}`))
+	rr = httptest.NewRecorder()
+	handler.SetCompanyAlias(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO company_aliases").
+		WithArgs("andela talent", "andela").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectExec("^UPDATE company_aliases").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery("^SELECT alias, company, created_at FROM company_aliases").
+		WillReturnRows(sqlmock.NewRows([]string{"alias", "company", "created_at"}).AddRow("andela talent", "andela", time.Now()))
+	mock.ExpectQuery("^SELECT id, (.+) FROM jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "company", "company_key"}))
+	mock.ExpectExec("^INSERT INTO companies").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^UPDATE jobs SET company_id").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	req = httptest.NewRequest("POST", "/api/admin/company-aliases", strings.NewReader(`{"alias":"Andela Talent","company":"Andela Nigeria"}`))
+	rr = httptest.NewRecorder()
+	handler.SetCompanyAlias(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"alias":"andela talent","company":"andela"`)
+	assert.Equal(t, "andela", analyzer.NormalizeCompany("Andela Talent Ltd"))
+
+	mock.ExpectQuery("^DELETE FROM company_aliases WHERE alias = \\$1").
+		WithArgs("nobody").
+		WillReturnRows(sqlmock.NewRows([]string{"company"}))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/company-aliases/{alias}", handler.DeleteCompanyAlias)
+	req = httptest.NewRequest("DELETE", "/api/admin/company-aliases/nobody", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
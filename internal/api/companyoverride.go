@@ -0,0 +1,100 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/db"
+
+	"github.com/gorilla/mux"
+)
+
+// maxCompanyOverrideSize bounds the body of a company override
+const maxCompanyOverrideSize = 16 << 10
+
+// maxCompanyDescriptionLength bounds an overridden company description
+const maxCompanyDescriptionLength = 2000
+
+// validCompanyOverride reports why an override cannot be applied, "" if it can
+func validCompanyOverride(override db.CompanyOverride) string {
+	if override.Domain == nil && override.LogoURL == nil && override.Description == nil && override.Verified == nil {
+		return "Nothing to change: set domain, logo_url, description or verified"
+	}
+	// Unverified companies keep nothing an admin sets
+	if override.Verified != nil && !*override.Verified && (override.Domain != nil || override.LogoURL != nil || override.Description != nil) {
+		return "verified: false cannot be combined with domain, logo_url or description"
+	}
+	if override.Domain != nil {
+		domain := strings.TrimSpace(*override.Domain)
+		if !strings.Contains(domain, ".") || strings.ContainsAny(domain, " /:?#@") || len(domain) > maxJobFieldLength {
+			return fmt.Sprintf("Invalid domain: %q (e.g. paystack.com)", *override.Domain)
+		}
+	}
+	if override.LogoURL != nil {
+		parsed, err := url.Parse(strings.TrimSpace(*override.LogoURL))
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return fmt.Sprintf("Invalid logo_url: %q (an http or https URL)", *override.LogoURL)
+		}
+	}
+	if override.Description != nil {
+		if description := strings.TrimSpace(*override.Description); description == "" || len(description) > maxCompanyDescriptionLength {
+			return fmt.Sprintf("Invalid description: 1 to %d characters", maxCompanyDescriptionLength)
+		}
+	}
+	return ""
+}
+
+// OverrideCompany sets the domain, logo or description of a company by hand
+// and marks it verified, so enrichment and later syncs keep them. The body is
+// a JSON object with any of "domain", "logo_url", "description" and
+// "verified"; "verified": false lets enrichment replace them again.
+func (h *Handler) OverrideCompany(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var override db.CompanyOverride
+	r.Body = http.MaxBytesReader(w, r.Body, maxCompanyOverrideSize)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&override); err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if msg := validCompanyOverride(override); msg != "" {
+		writeError(w, r, msg, http.StatusBadRequest)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	company, err := db.FindCompany(r.Context(), h.DB, id)
+	if err == nil {
+		company, err = db.OverrideCompany(r.Context(), h.DB, company.ID, override)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, r, fmt.Sprintf("Company not found: %s", id), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, db.ErrCompanyNoDomain) {
+		writeError(w, r, fmt.Sprintf("Company %s has no domain: set domain with logo_url or description", id), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Error overriding company %s: %v", id, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.invalidateResponses()
+
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      company,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/enrichment"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideCompany(t *testing.T) {
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	handler := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{}))
+	handler.Responses = db.NewCache(0, 0)
+	handler.Responses.Set("jobs:etag", []byte("cached"))
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/companies/{id}", handler.OverrideCompany).Methods("PATCH")
+
+	for _, body := range []string{`{}`, `{"domain": "andela"}`, `{"logo_url": "javascript:alert(1)"}`, `{"description": " "}`, `{"name": "Andela"}`,
+		`{"domain": "andela.com", "verified": false}`} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, adminRequest("PATCH", "/api/admin/companies/7", body))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+
+	mock.ExpectQuery("^SELECT (.+) FROM companies c (.+) WHERE c.id = \\$1").
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "domain", "logo_url", "verified"}).AddRow(7, "Andela", "andela.com", "", false))
+	mock.ExpectBegin()
+	mock.ExpectQuery("^UPDATE companies SET").
+		WithArgs(int64(7), "", true).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "domain", "verified"}).AddRow("Andela", "andela.com", true))
+	mock.ExpectExec("^UPDATE jobs SET company_domain").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("^INSERT INTO company_details").
+		WithArgs("andela.com", "Andela", "https://andela.com/logo.png", "", enrichment.SourceAdmin, int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"logo_url"}).AddRow("https://andela.com/logo.png"))
+	mock.ExpectExec("^UPDATE jobs SET company_logo").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, adminRequest("PATCH", "/api/admin/companies/7", `{"logo_url": "https://andela.com/logo.png"}`))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"logo_url":"https://andela.com/logo.png","verified":true`)
+
+	// Listings no longer serve the old logo from the cache
+	_, ok := handler.Responses.Get("jobs:etag")
+	assert.False(t, ok)
+
+	mock.ExpectQuery("^SELECT (.+) FROM companies c (.+) WHERE c.key = \\$1").
+		WithArgs("nobody").
+		WillReturnError(sql.ErrNoRows)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, adminRequest("PATCH", "/api/admin/companies/nobody", `{"verified": true}`))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
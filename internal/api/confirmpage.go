@@ -0,0 +1,54 @@
+package api
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+)
+
+// confirmTemplate is the page the links sent by email open: following a link
+// changes nothing, so mail scanners and link previews prefetching it do not
+// confirm or unsubscribe anyone, the form posting the token back does
+var confirmTemplate = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - Go9jaJobs</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">{{.Button}}</button>
+</form>
+</body>
+</html>
+`))
+
+// confirmPage is what confirmTemplate renders
+type confirmPage struct {
+	Title  string
+	Button string
+	Action string
+	Token  string
+}
+
+// renderConfirmPage answers the GET of an emailed link with a form posting
+// its token back to the same path
+func renderConfirmPage(w http.ResponseWriter, r *http.Request, title, button string) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, r, "Missing token", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	page := confirmPage{Title: title, Button: button, Action: r.URL.Path, Token: token}
+	if err := confirmTemplate.Execute(w, page); err != nil {
+		log.Printf("Error rendering confirmation page: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// cursorTTL is how long a pagination cursor stays valid
+const cursorTTL = time.Hour
+
+// Errors reported for cursors that cannot be followed
+var (
+	errInvalidCursor  = errors.New("Invalid cursor")
+	errCursorExpired  = errors.New("cursor expired, restart from the first page")
+	errCursorMismatch = errors.New("cursor does not match the query, keep the filters, sort and limit of the first page")
+)
+
+// pageCursor is the signed position of the next page of a job listing
+type pageCursor struct {
+	Offset int `json:"o"`
+	// Query is the jobQueryHash of the listing the cursor belongs to
+	Query   string `json:"q"`
+	Expires int64  `json:"e"`
+}
+
+// jobQueryHash identifies the filters, sort and page size of a job listing,
+// so a cursor cannot be replayed against another (e.g. wider) query
+func jobQueryHash(query url.Values) string {
+	listing := url.Values{}
+	for _, param := range append([]string{"sort", "limit", "expand", "include"}, jobFilterParams...) {
+		if value := query.Get(param); value != "" {
+			listing.Set(param, value)
+		}
+	}
+	sum := sha256.Sum256([]byte(listing.Encode()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// signCursor returns the HMAC-SHA256 of a cursor payload
+func signCursor(secret string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// encodeCursor returns the opaque token of a cursor: its JSON payload and
+// signature, base64url encoded and joined by a dot
+func encodeCursor(secret string, cursor pageCursor) string {
+	payload, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(signCursor(secret, payload))
+}
+
+// decodeCursor verifies a cursor token issued for the listing identified by
+// queryHash and returns its position
+func decodeCursor(secret, token, queryHash string, now time.Time) (pageCursor, error) {
+	var cursor pageCursor
+
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return cursor, errInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, signCursor(secret, payload)) {
+		return cursor, errInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &cursor); err != nil || cursor.Offset < 0 {
+		return cursor, errInvalidCursor
+	}
+
+	if now.Unix() > cursor.Expires {
+		return cursor, errCursorExpired
+	}
+	if cursor.Query != queryHash {
+		return cursor, errCursorMismatch
+	}
+	return cursor, nil
+}
+
+// cursorSecret returns the key signing pagination cursors
+func (h *Handler) cursorSecret() string {
+	if h.Config == nil {
+		return ""
+	}
+	return h.Config.CursorSecret
+}
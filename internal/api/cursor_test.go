@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	now := time.Now()
+	hash := jobQueryHash(url.Values{"source": {"jsearch"}, "limit": {"20"}})
+	token := encodeCursor("secret", pageCursor{Offset: 40, Query: hash, Expires: now.Add(time.Minute).Unix()})
+
+	cursor, err := decodeCursor("secret", token, hash, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 40, cursor.Offset)
+
+	// Another key, a forged payload or a different query are refused
+	_, err = decodeCursor("other", token, hash, now)
+	assert.ErrorIs(t, err, errInvalidCursor)
+	forged := encodeCursor("guess", pageCursor{Offset: 1000000, Query: hash, Expires: now.Add(time.Minute).Unix()})
+	_, err = decodeCursor("secret", forged, hash, now)
+	assert.ErrorIs(t, err, errInvalidCursor)
+	_, err = decodeCursor("secret", "garbage", hash, now)
+	assert.ErrorIs(t, err, errInvalidCursor)
+	_, err = decodeCursor("secret", token, jobQueryHash(url.Values{"limit": {"20"}}), now)
+	assert.ErrorIs(t, err, errCursorMismatch)
+
+	// The language filter is part of the query
+	english := jobQueryHash(url.Values{"language": {"en"}, "limit": {"20"}})
+	token = encodeCursor("secret", pageCursor{Offset: 20, Query: english, Expires: now.Add(time.Minute).Unix()})
+	_, err = decodeCursor("secret", token, jobQueryHash(url.Values{"language": {"fr"}, "limit": {"20"}}), now)
+	assert.ErrorIs(t, err, errCursorMismatch)
+	_, err = decodeCursor("secret", token, jobQueryHash(url.Values{"limit": {"20"}}), now)
+	assert.ErrorIs(t, err, errCursorMismatch)
+	token = encodeCursor("secret", pageCursor{Offset: 40, Query: hash, Expires: now.Add(time.Minute).Unix()})
+
+	_, err = decodeCursor("secret", token, hash, now.Add(2*time.Minute))
+	assert.ErrorIs(t, err, errCursorExpired)
+}
+
+func TestGetAllJobsCursor(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.Config = &config.Config{CursorSecret: "secret"}
+
+	columns := []string{
+		"id", "job_id", "title", "company", "company_url", "company_logo",
+		"location", "description", "url", "salary", "posted_at",
+		"job_type", "is_remote", "source", "word_count", "reading_time_minutes", "apply_method", "seniority",
+		"assessments", "stack", "tracks", "language",
+	}
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) ORDER BY posted_at DESC LIMIT \\$1$").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"job-1", "job-1", "Go Engineer", "Acme", nil, nil, nil, nil, nil, nil, time.Now(), nil, true, "jsearch", 0, 0, "", "", nil, nil, nil, ""))
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) ORDER BY posted_at DESC LIMIT \\$1 OFFSET \\$2$").
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	req, err := http.NewRequest("GET", "/api/jobs?limit=1", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.NextCursor)
+
+	// The cursor leads to the next page of the same query only
+	req, err = http.NewRequest("GET", "/api/jobs?limit=1&cursor="+response.NextCursor, nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "next_cursor")
+
+	req, err = http.NewRequest("GET", "/api/jobs?limit=1&source=linkedin&cursor="+response.NextCursor, nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "cursor does not match the query")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/errorlog"
+	"Go9jaJobs/internal/services"
+)
+
+const (
+	// dashboardCookie holds the session of the admin dashboard
+	dashboardCookie = "go9jajobs_admin"
+	// dashboardSessionTTL is how long a dashboard login lasts
+	dashboardSessionTTL = 12 * time.Hour
+	// dashboardErrors is how many recent errors the dashboard lists
+	dashboardErrors = 20
+)
+
+//go:embed dashboard
+var dashboardFS embed.FS
+
+var dashboardTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"when": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format("2006-01-02 15:04 UTC")
+	},
+}).ParseFS(dashboardFS, "dashboard/*.html"))
+
+// dashboardNotices are the messages shown after a dashboard action, by the
+// code it redirects with, so no request text is echoed back
+var dashboardNotices = map[string]string{
+	"login_failed":    "Invalid admin key.",
+	"sync_started":    "Sync started, its result shows up below once it finishes.",
+	"sync_running":    "A sync of that source is already running.",
+	"source_disabled": "That source is disabled.",
+	"shutting_down":   "The server is shutting down, try again shortly.",
+	"invalid_source":  "Unknown source.",
+	"blocked":         "Company blocked, its jobs are skipped from the next sync on.",
+	"unblocked":       "Company unblocked.",
+	"invalid_name":    "Company names must be 3 to 300 characters.",
+	"not_blocked":     "That company is not blocked.",
+	"failed":          "The action failed, see the server logs.",
+}
+
+// dashboardSource is the sync status of a source on the dashboard
+type dashboardSource struct {
+	Name         string
+	Enabled      bool
+	LastRun      time.Time
+	LastStatus   string
+	LastJobCount int
+	LastError    string
+	NextRun      time.Time
+}
+
+// dashboardPage is the data of the dashboard template
+type dashboardPage struct {
+	CSRF        string
+	Notice      string
+	Stats       *db.JobStats
+	Sources     []dashboardSource
+	Errors      []errorlog.Entry
+	Blocked     []db.BlockedCompany
+	GeneratedAt time.Time
+}
+
+// dashboardSign returns the hex HMAC-SHA256 of value keyed with an admin key
+func dashboardSign(key, value string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// dashboardSession is a valid dashboard login
+type dashboardSession struct {
+	key     string
+	expires int64
+}
+
+// csrf returns the token the dashboard forms of the session post back
+func (s dashboardSession) csrf() string {
+	return dashboardSign(s.key, "csrf:"+strconv.FormatInt(s.expires, 10))
+}
+
+// dashboardSessionFrom returns the session of the request's cookie, which is
+// "<expiry unix time>.<HMAC of it keyed with the admin key logged in with>"
+func (h *Handler) dashboardSessionFrom(r *http.Request) (dashboardSession, bool) {
+	cookie, err := r.Cookie(dashboardCookie)
+	if err != nil {
+		return dashboardSession{}, false
+	}
+	expiry, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return dashboardSession{}, false
+	}
+	expires, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return dashboardSession{}, false
+	}
+	for _, key := range adminKeys(h.Config) {
+		if key != "" && hmac.Equal([]byte(signature), []byte(dashboardSign(key, "session:"+expiry))) {
+			return dashboardSession{key: key, expires: expires}, true
+		}
+	}
+	return dashboardSession{}, false
+}
+
+// setDashboardCookie stores a session cookie, or clears it when value is empty
+func setDashboardCookie(w http.ResponseWriter, r *http.Request, value string, expires time.Time) {
+	cookie := &http.Cookie{
+		Name:     dashboardCookie,
+		Value:    value,
+		Path:     "/admin",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteStrictMode,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	} else {
+		cookie.Expires = expires
+	}
+	http.SetCookie(w, cookie)
+}
+
+// redirectDashboard sends the browser back to the dashboard with a notice
+func redirectDashboard(w http.ResponseWriter, r *http.Request, notice string) {
+	target := "/admin"
+	if notice != "" {
+		target += "?notice=" + url.QueryEscape(notice)
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// dashboardAuth lets through dashboard form posts of a logged in session
+// carrying its CSRF token, with the admin key ID in the context for the audit
+// log; others are sent back to the login form
+func (h *Handler) dashboardAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, ok := h.dashboardSessionFrom(r)
+		if !ok {
+			redirectDashboard(w, r, "")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.PostFormValue("csrf")), []byte(session.csrf())) != 1 {
+			log.Printf("[AUTH FAIL] %s %s from %s - Invalid dashboard CSRF token", r.Method, r.URL.Path, r.RemoteAddr)
+			writeError(w, r, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), adminKeyIDKey{}, adminKeyID(session.key))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Dashboard renders the admin dashboard, or its login form without a session:
+// sync status, job counts, recent errors and blocked companies, with forms to
+// trigger syncs and block or unblock companies
+func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	notice := dashboardNotices[r.URL.Query().Get("notice")]
+
+	session, ok := h.dashboardSessionFrom(r)
+	if !ok {
+		if err := dashboardTemplates.ExecuteTemplate(w, "login.html", dashboardPage{Notice: notice}); err != nil {
+			log.Printf("Error rendering dashboard login: %v", err)
+		}
+		return
+	}
+
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+	page, err := h.dashboardPage(r.Context())
+	if err != nil {
+		log.Printf("Error loading dashboard: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	page.CSRF, page.Notice = session.csrf(), notice
+
+	if err := dashboardTemplates.ExecuteTemplate(w, "index.html", page); err != nil {
+		log.Printf("Error rendering dashboard: %v", err)
+	}
+}
+
+// dashboardPage loads the data shown on the dashboard
+func (h *Handler) dashboardPage(ctx context.Context) (dashboardPage, error) {
+	page := dashboardPage{GeneratedAt: time.Now()}
+
+	stats, err := db.GetJobStats(ctx, h.DB)
+	if err != nil {
+		return page, err
+	}
+	page.Stats = stats
+
+	logs, err := db.GetLatestSyncLogs(ctx, h.DB)
+	if err != nil {
+		return page, err
+	}
+	nextRuns := make(map[string]time.Time)
+	if h.Scheduler != nil {
+		for _, state := range h.Scheduler.State() {
+			nextRuns[state.Source] = state.NextRun
+		}
+	}
+	for _, name := range services.Sources() {
+		source := dashboardSource{Name: name, Enabled: services.SourceEnabled(h.Config, name), NextRun: nextRuns[name]}
+		if summary, ok := logs[name]; ok {
+			source.LastRun = summary.LastRunTime
+			source.LastStatus = summary.LastStatus
+			source.LastJobCount = summary.LastJobCount
+			source.LastError = summary.LastError
+		}
+		page.Sources = append(page.Sources, source)
+	}
+
+	for _, entries := range errorlog.Recent(dashboardErrors) {
+		page.Errors = append(page.Errors, entries...)
+	}
+	sort.Slice(page.Errors, func(i, j int) bool { return page.Errors[i].Time.After(page.Errors[j].Time) })
+	if len(page.Errors) > dashboardErrors {
+		page.Errors = page.Errors[:dashboardErrors]
+	}
+
+	page.Blocked, err = db.ListBlockedCompanies(ctx, h.DB)
+	return page, err
+}
+
+// DashboardCSS serves the stylesheet of the dashboard, kept out of the pages
+// so they pass the default-src 'self' content security policy
+func (h *Handler) DashboardCSS(w http.ResponseWriter, r *http.Request) {
+	css, err := dashboardFS.ReadFile("dashboard/dashboard.css")
+	if err != nil {
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write(css)
+}
+
+// DashboardLogin starts a dashboard session when the posted key is an admin key
+func (h *Handler) DashboardLogin(w http.ResponseWriter, r *http.Request) {
+	submitted := r.PostFormValue("key")
+	for _, key := range adminKeys(h.Config) {
+		if key == "" || subtle.ConstantTimeCompare([]byte(submitted), []byte(key)) != 1 {
+			continue
+		}
+		expires := time.Now().Add(dashboardSessionTTL)
+		expiry := strconv.FormatInt(expires.Unix(), 10)
+		setDashboardCookie(w, r, expiry+"."+dashboardSign(key, "session:"+expiry), expires)
+		log.Printf("[AUDIT] key=%s dashboard login from %s", adminKeyID(key), r.RemoteAddr)
+		redirectDashboard(w, r, "")
+		return
+	}
+
+	log.Printf("[AUTH FAIL] %s %s from %s - Invalid admin key attempt", r.Method, r.URL.Path, r.RemoteAddr)
+	redirectDashboard(w, r, "login_failed")
+}
+
+// DashboardLogout ends the dashboard session
+func (h *Handler) DashboardLogout(w http.ResponseWriter, r *http.Request) {
+	setDashboardCookie(w, r, "", time.Time{})
+	redirectDashboard(w, r, "")
+}
+
+// DashboardSync starts a sync of the posted source (or all) in the background
+func (h *Handler) DashboardSync(w http.ResponseWriter, r *http.Request) {
+	source := r.PostFormValue("source")
+	if source != "all" && !services.IsValidSource(source) {
+		redirectDashboard(w, r, "invalid_source")
+		return
+	}
+
+	_, err := h.SyncManager.Start(source)
+	switch {
+	case errors.Is(err, services.ErrSyncRunning):
+		redirectDashboard(w, r, "sync_running")
+	case errors.Is(err, services.ErrSourceDisabled):
+		redirectDashboard(w, r, "source_disabled")
+	case errors.Is(err, services.ErrShuttingDown):
+		redirectDashboard(w, r, "shutting_down")
+	case err != nil:
+		log.Printf("Error starting sync for %s: %v", source, err)
+		redirectDashboard(w, r, "failed")
+	default:
+		redirectDashboard(w, r, "sync_started")
+	}
+}
+
+// DashboardBlockCompany blocks the posted company
+func (h *Handler) DashboardBlockCompany(w http.ResponseWriter, r *http.Request) {
+	name := r.PostFormValue("name")
+	if validBlockedCompany(name) != "" {
+		redirectDashboard(w, r, "invalid_name")
+		return
+	}
+	if err := db.BlockCompany(r.Context(), h.DB, name, r.PostFormValue("reason")); err != nil {
+		log.Printf("Error blocking company %q: %v", name, err)
+		redirectDashboard(w, r, "failed")
+		return
+	}
+	redirectDashboard(w, r, "blocked")
+}
+
+// DashboardUnblockCompany unblocks the posted company
+func (h *Handler) DashboardUnblockCompany(w http.ResponseWriter, r *http.Request) {
+	name := r.PostFormValue("name")
+	removed, err := db.UnblockCompany(r.Context(), h.DB, name)
+	if err != nil {
+		log.Printf("Error unblocking company %q: %v", name, err)
+		redirectDashboard(w, r, "failed")
+		return
+	}
+	if !removed {
+		redirectDashboard(w, r, "not_blocked")
+		return
+	}
+	redirectDashboard(w, r, "unblocked")
+}
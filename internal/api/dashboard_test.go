@@ -0,0 +1,160 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboard(t *testing.T) {
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+	defer db.SetBlockedCompanies([]string{"canonical", "crossover"})
+
+	cfg := &config.Config{
+		APIKey:         "test-api-key",
+		CronAPIKey:     "cron-key",
+		AdminAPIKeys:   []string{"admin-key"},
+		AllowedOrigins: []string{"*"},
+	}
+	handler := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{}))
+	router := handler.SetupRoutes(cfg)
+
+	serve := func(method, target string, form url.Values, cookie *http.Cookie) *httptest.ResponseRecorder {
+		var req *http.Request
+		if form != nil {
+			req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			req = httptest.NewRequest(method, target, nil)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Without a session the login form is shown
+	rr := serve("GET", "/admin", nil, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `action="/admin/login"`)
+
+	rr = serve("POST", "/admin/login", url.Values{"key": {"cron-key"}}, nil)
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/admin?notice=login_failed", rr.Header().Get("Location"))
+	assert.Empty(t, rr.Result().Cookies())
+
+	rr = serve("POST", "/admin/login", url.Values{"key": {"admin-key"}}, nil)
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	cookies := rr.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	session := cookies[0]
+	assert.True(t, session.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, session.SameSite)
+
+	posted := time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT source, COUNT\\(\\*\\),").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "jobs", "active", "newest"}).AddRow("jobberman", 4, 3, posted))
+	mock.ExpectQuery("^SELECT DISTINCT ON \\(api_name\\) api_name, sync_time, COALESCE").
+		WillReturnRows(sqlmock.NewRows([]string{"api_name", "sync_time", "job_count", "status"}).AddRow("jobberman", posted, 4, "Success"))
+	mock.ExpectQuery("^SELECT DISTINCT ON \\(api_name\\) api_name, sync_time, error_message").
+		WillReturnRows(sqlmock.NewRows([]string{"api_name", "sync_time", "error_message"}))
+	mock.ExpectQuery("^SELECT name, reason, created_at FROM blocked_companies").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "reason", "created_at"}).AddRow("crossover", "<spam>", posted))
+
+	rr = serve("GET", "/admin", nil, session)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "3 active of 4 jobs")
+	assert.Contains(t, body, "<td>jobberman</td><td>4</td><td>3</td><td>2024-05-02 09:00 UTC</td>")
+	assert.Contains(t, body, "&lt;spam&gt;")
+	csrf := regexp.MustCompile(`name="csrf" value="([0-9a-f]+)"`).FindStringSubmatch(body)
+	if !assert.Len(t, csrf, 2) {
+		return
+	}
+
+	// Forms need the CSRF token of the session
+	rr = serve("POST", "/admin/blocked-companies", url.Values{"name": {"Acme Corp"}}, session)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = serve("POST", "/admin/blocked-companies", url.Values{"name": {"Acme Corp"}, "csrf": {csrf[1]}}, nil)
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/admin", rr.Header().Get("Location"))
+
+	mock.ExpectExec("^INSERT INTO blocked_companies \\(name, reason\\) VALUES \\(\\$1, \\$2\\)").
+		WithArgs("acme corp", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("^SELECT name, reason, created_at FROM blocked_companies").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "reason", "created_at"}).AddRow("acme corp", "", posted))
+
+	rr = serve("POST", "/admin/blocked-companies", url.Values{"name": {"Acme Corp"}, "csrf": {csrf[1]}}, session)
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, "/admin?notice=blocked", rr.Header().Get("Location"))
+	assert.True(t, db.IsBlockedCompany("ACME Corp Nigeria"))
+	assert.False(t, db.IsBlockedCompany("Crossover"))
+
+	rr = serve("POST", "/admin/sync", url.Values{"source": {"nowhere"}, "csrf": {csrf[1]}}, session)
+	assert.Equal(t, "/admin?notice=invalid_source", rr.Header().Get("Location"))
+
+	rr = serve("POST", "/admin/logout", url.Values{}, session)
+	assert.Equal(t, http.StatusSeeOther, rr.Code)
+	assert.Equal(t, -1, rr.Result().Cookies()[0].MaxAge)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBlockedCompaniesAPI(t *testing.T) {
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+	defer db.SetBlockedCompanies([]string{"canonical", "crossover"})
+
+	handler := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{}))
+
+	// Names too short once normalized would block most companies
+	for _, body := range []string{`{"name":"ab"}`, `{"name":"AB Ltd."}`} {
+		req := httptest.NewRequest("POST", "/api/admin/blocked-companies", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		handler.BlockCompany(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+
+	mock.ExpectExec("^INSERT INTO blocked_companies").
+		WithArgs("scam ltd", "fake listings").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("^SELECT name, reason, created_at FROM blocked_companies").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "reason", "created_at"}).AddRow("scam ltd", "fake listings", time.Now()))
+
+	req := httptest.NewRequest("POST", "/api/admin/blocked-companies", strings.NewReader(`{"name":" Scam Ltd ","reason":"fake listings"}`))
+	rr := httptest.NewRecorder()
+	handler.BlockCompany(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"name":"scam ltd"`)
+	assert.True(t, db.IsBlockedCompany("Scam Ltd"))
+
+	mock.ExpectExec("^DELETE FROM blocked_companies WHERE name = \\$1$").
+		WithArgs("nobody").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/blocked-companies/{name}", handler.UnblockCompany)
+	req = httptest.NewRequest("DELETE", "/api/admin/blocked-companies/nobody", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"Go9jaJobs/internal/apierror"
+
+	"github.com/google/uuid"
+)
+
+// requestIDKey is the context key carrying the ID of a request
+type requestIDKey struct{}
+
+// validRequestID matches the X-Request-ID values of clients and proxies that
+// are kept; others are replaced so logs cannot be injected into
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware gives every request an ID, the X-Request-ID it was sent
+// with or a new one, returned in the X-Request-ID header and in error bodies
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID.MatchString(id) {
+			id = uuid.New().String()
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestID returns the ID RequestIDMiddleware gave a request, "" without it
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// writeError answers a request with the JSON error envelope, its code
+// following from status
+func writeError(w http.ResponseWriter, r *http.Request, message string, status int) {
+	writeErrorDetails(w, r, apierror.ForStatus(status), message, nil, status)
+}
+
+// writeErrorDetails answers a request with the JSON error envelope of a
+// specific code and structured details
+func writeErrorDetails(w http.ResponseWriter, r *http.Request, code apierror.Code, message string, details interface{}, status int) {
+	apierror.Write(w, status, apierror.Response{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: requestID(r),
+	})
+}
+
+// notFound answers requests for unknown routes
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, "Not found", http.StatusNotFound)
+}
+
+// methodNotAllowed answers requests with a method their route does not take
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+}
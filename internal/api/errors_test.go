@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorResponses(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	cfg := &config.Config{
+		APIKey:         "test-api-key",
+		CronAPIKey:     "cron-key",
+		AllowedOrigins: []string{"*"},
+	}
+	router := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{})).SetupRoutes(cfg)
+
+	// Middleware failures carry a code and the ID of the request
+	req := httptest.NewRequest("GET", "/api/jobs", nil)
+	req.Header.Set("X-Request-ID", "trace-123")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "trace-123", rr.Header().Get("X-Request-ID"))
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"code":"unauthorized","message":"Unauthorized","request_id":"trace-123"}`, rr.Body.String())
+
+	// Unusable request IDs are replaced
+	req = httptest.NewRequest("GET", "/nowhere", nil)
+	req.Header.Set("X-Request-ID", "bad id\nforged log line")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	id := rr.Header().Get("X-Request-ID")
+	assert.Len(t, id, 36)
+	assert.Contains(t, rr.Body.String(), `"code":"not_found"`)
+	assert.Contains(t, rr.Body.String(), `"request_id":"`+id+`"`)
+
+	req = httptest.NewRequest("DELETE", "/status", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"method_not_allowed"`)
+}
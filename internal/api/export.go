@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Export file formats, chosen with ?format=
+const (
+	exportFormatCSV  = "csv"
+	exportFormatXLSX = "xlsx"
+)
+
+// ExportErrorTrailer is the HTTP trailer set on an export that failed after
+// its 200 status was sent, the file being truncated
+const ExportErrorTrailer = "X-Export-Error"
+
+// exportColumns are the job fields an export can hold, in their default order
+var exportColumns = []string{
+	"id", "job_id", "title", "company", "company_url", "location", "is_remote", "job_type", "salary",
+	"seniority", "apply_method", "stack", "tracks", "posted_at", "url", "source", "description",
+}
+
+// defaultExportColumns are exported when ?columns= is not given
+var defaultExportColumns = []string{
+	"title", "company", "location", "is_remote", "job_type", "salary", "posted_at", "url", "source",
+}
+
+// parseExportColumns returns the columns of an export, given as a comma
+// separated list in ?columns=
+func parseExportColumns(r *http.Request) ([]string, error) {
+	value := r.URL.Query().Get("columns")
+	if value == "" {
+		return defaultExportColumns, nil
+	}
+
+	var columns []string
+	for _, column := range strings.Split(value, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column == "" {
+			continue
+		}
+		if !slices.Contains(exportColumns, column) {
+			return nil, fmt.Errorf("Invalid column: %s", column)
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("Invalid columns: %s", value)
+	}
+	return columns, nil
+}
+
+// exportCell renders a field of a listed job as text, lists joined by commas
+func exportCell(job map[string]interface{}, column string) string {
+	switch value := job[column].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []string:
+		return strings.Join(value, ", ")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// spreadsheetSafe keeps a cell that a spreadsheet would evaluate as a formula
+// (e.g. a scraped title starting with "=") as text
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// startedWriter records whether anything was written to w
+type startedWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (s *startedWriter) Write(p []byte) (int, error) {
+	s.started = true
+	return s.w.Write(p)
+}
+
+// ExportJobs returns the jobs matching the same filters, sort and page as
+// /api/jobs as a CSV or XLSX download of the columns in ?columns=. CSV rows
+// are written as they are scanned.
+func (h *Handler) ExportJobs(w http.ResponseWriter, r *http.Request) {
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatCSV
+	}
+	if format != exportFormatCSV && format != exportFormatXLSX {
+		writeError(w, r, fmt.Sprintf("Invalid format: %s", format), http.StatusBadRequest)
+		return
+	}
+
+	columns, err := parseExportColumns(r)
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	listing, err := h.parseJobListing(r)
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+	// Descriptions are exported in full, and only when asked for
+	listing.expandCompany, listing.includeCompany = false, false
+	listing.descriptionMode = descriptionNone
+	if slices.Contains(columns, "description") {
+		listing.descriptionMode = descriptionFull
+	}
+
+	filename := fmt.Sprintf("jobs-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+
+	if format == exportFormatCSV {
+		out := &startedWriter{w: w}
+		writer := csv.NewWriter(out)
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.Header().Set("Trailer", ExportErrorTrailer)
+
+		writer.Write(columns)
+		_, err := h.scanJobs(r, listing, func(job map[string]interface{}) error {
+			row := make([]string, len(columns))
+			for i, column := range columns {
+				row[i] = spreadsheetSafe(exportCell(job, column))
+			}
+			return writer.Write(row)
+		})
+		if err == nil {
+			writer.Flush()
+			err = writer.Error()
+		}
+		if err != nil {
+			log.Printf("Error exporting jobs: %v", err)
+			if !out.started {
+				w.Header().Del("Content-Disposition")
+				w.Header().Del("Trailer")
+				writeError(w, r, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			// The 200 status is already sent, so the file is left truncated
+			// and flagged as such
+			w.Header().Set(ExportErrorTrailer, "truncated")
+		}
+		return
+	}
+
+	file := excelize.NewFile()
+	defer file.Close()
+	sheet := file.GetSheetName(0)
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		log.Printf("Error creating jobs export: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	stream.SetRow("A1", header)
+
+	rowNum := 1
+	_, err = h.scanJobs(r, listing, func(job map[string]interface{}) error {
+		row := make([]interface{}, len(columns))
+		for i, column := range columns {
+			row[i] = spreadsheetSafe(exportCell(job, column))
+		}
+		rowNum++
+		cell, err := excelize.CoordinatesToCellName(1, rowNum)
+		if err != nil {
+			return err
+		}
+		return stream.SetRow(cell, row)
+	})
+	if err == nil {
+		err = stream.Flush()
+	}
+	if err != nil {
+		log.Printf("Error exporting jobs: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Trailer", ExportErrorTrailer)
+	if _, err := file.WriteTo(w); err != nil {
+		log.Printf("Error writing jobs export: %v", err)
+		w.Header().Set(ExportErrorTrailer, "truncated")
+	}
+}
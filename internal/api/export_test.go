@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+// exportRows returns a listing of two jobs, the second with a title a
+// spreadsheet would evaluate
+func exportRows() *sqlmock.Rows {
+	columns := []string{
+		"id", "job_id", "title", "company", "company_url", "company_logo",
+		"location", "description", "url", "salary", "posted_at",
+		"job_type", "is_remote", "source", "word_count", "reading_time_minutes", "apply_method", "seniority",
+		"assessments", "stack", "tracks", "language",
+	}
+	posted := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	return sqlmock.NewRows(columns).
+		AddRow("job-1", "js-1", "Golang Developer", "Paystack", nil, nil, "Lagos", nil, "https://paystack.com/careers/1",
+			"NGN 1,000,000", posted, "Full-time", false, "jsearch", 0, 0, "", "", nil, []byte(`{go,postgres}`), []byte(`{go}`), "fr").
+		AddRow("job-2", "js-2", "=HYPERLINK(\"x\")", "Kuda", nil, nil, nil, nil, nil,
+			nil, posted, nil, true, "ingest", 0, 0, "", "", nil, nil, nil, "")
+}
+
+func TestExportJobsCSV(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND is_remote = \\$1 ORDER BY posted_at DESC").
+		WithArgs(false).
+		WillReturnRows(exportRows())
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	req := httptest.NewRequest("GET", "/api/jobs/export?is_remote=false&columns=title,company,salary,stack", nil)
+	rr := httptest.NewRecorder()
+	handler.ExportJobs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), `attachment; filename="jobs-`)
+	assert.Equal(t, "title,company,salary,stack\n"+
+		"Golang Developer,Paystack,\"NGN 1,000,000\",\"go, postgres\"\n"+
+		"\"'=HYPERLINK(\"\"x\"\")\",Kuda,,\n", rr.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportJobsCSVTruncated(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	// Enough rows to send the 200 status before the listing fails
+	rows := exportRows()
+	posted := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		rows.AddRow("job-x", "js-x", strings.Repeat("Go ", 40), "Paystack", nil, nil, nil, nil, nil,
+			nil, posted, nil, true, "jsearch", 0, 0, "", "", nil, nil, nil, "")
+	}
+	rows.RowError(90, errors.New("connection reset"))
+	mock.ExpectQuery("^SELECT (.+) FROM jobs").WillReturnRows(rows)
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	req := httptest.NewRequest("GET", "/api/jobs/export", nil)
+	rr := httptest.NewRecorder()
+	handler.ExportJobs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, ExportErrorTrailer, rr.Header().Get("Trailer"))
+	assert.Equal(t, "truncated", rr.Result().Trailer.Get(ExportErrorTrailer))
+
+	// Failing before any row is sent, the export answers a generic error
+	mock.ExpectQuery("^SELECT (.+) FROM jobs").WillReturnRows(exportRows().RowError(0, errors.New("connection reset")))
+	rr = httptest.NewRecorder()
+	handler.ExportJobs(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Empty(t, rr.Header().Get("Content-Disposition"))
+	assert.Empty(t, rr.Header().Get("Trailer"))
+	assert.Contains(t, rr.Body.String(), "Internal server error")
+	assert.NotContains(t, rr.Body.String(), "connection reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportJobsXLSX(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT (.+) FROM jobs").WillReturnRows(exportRows())
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	req := httptest.NewRequest("GET", "/api/jobs/export?format=xlsx", nil)
+	rr := httptest.NewRecorder()
+	handler.ExportJobs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), ".xlsx")
+
+	file, err := excelize.OpenReader(bytes.NewReader(rr.Body.Bytes()))
+	assert.NoError(t, err)
+	rows, err := file.GetRows(file.GetSheetName(0))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, defaultExportColumns, rows[0])
+	assert.Equal(t, []string{"Golang Developer", "Paystack", "Lagos", "false", "Full-time", "NGN 1,000,000",
+		"2024-05-01T10:00:00Z", "https://paystack.com/careers/1", "jsearch"}, rows[1])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportJobsInvalid(t *testing.T) {
+	db, _ := setupMockDB(t)
+	defer db.Close()
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	for _, target := range []string{
+		"/api/jobs/export?format=pdf",
+		"/api/jobs/export?columns=title,raw_data",
+		"/api/jobs/export?columns=,",
+		"/api/jobs/export?sort=salary",
+	} {
+		rr := httptest.NewRecorder()
+		handler.ExportJobs(rr, httptest.NewRequest("GET", target, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/models"
+	"Go9jaJobs/internal/services"
+)
+
+const (
+	// feedSize is how many of the latest jobs the feeds list
+	feedSize = 50
+	// feedTTL bounds how long the feeds are served from cache when no sync
+	// or import saves jobs, so expired jobs drop out
+	feedTTL = 10 * time.Minute
+	// feedTitle names the feeds in readers
+	feedTitle = "Go9jaJobs: latest Go jobs"
+)
+
+// feedCache holds the latest jobs shared by the RSS and JSON feeds. It is
+// rebuilt on request once jobs were saved since it was built or it is older
+// than feedTTL.
+type feedCache struct {
+	mu      sync.Mutex
+	jobs    []models.Job
+	builtAt time.Time
+	saves   int64
+}
+
+// latest returns the cached feed jobs and when they were loaded
+func (c *feedCache) latest(ctx context.Context, postgresDB *sql.DB) ([]models.Job, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	saves := services.JobSaves()
+	if !c.builtAt.IsZero() && saves == c.saves && time.Since(c.builtAt) < feedTTL {
+		return c.jobs, c.builtAt, nil
+	}
+
+	jobs, err := db.LatestJobs(ctx, postgresDB, feedSize)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	c.jobs, c.builtAt, c.saves = jobs, time.Now(), saves
+	return c.jobs, c.builtAt, nil
+}
+
+// siteURL returns the base URL the request was made to, linked from the feeds
+func siteURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// feedSummary describes a job in one line, e.g. "Paystack, Lagos"
+func feedSummary(job models.Job) string {
+	parts := []string{job.Company}
+	if job.Location != "" {
+		parts = append(parts, job.Location)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// rssFeed is an RSS 2.0 document
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// jsonFeed is a JSON Feed 1.1 document (https://jsonfeed.org)
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url,omitempty"`
+	Title         string `json:"title"`
+	ContentText   string `json:"content_text"`
+	DatePublished string `json:"date_published,omitempty"`
+}
+
+// serveFeed loads the feed jobs and sets the caching headers shared by both
+// feeds. It reports false when the response was already written.
+func (h *Handler) serveFeed(w http.ResponseWriter, r *http.Request) ([]models.Job, bool) {
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+	jobs, builtAt, err := h.feed.latest(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error loading feed jobs: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(feedTTL.Seconds())))
+	w.Header().Set("Last-Modified", builtAt.UTC().Format(http.TimeFormat))
+	return jobs, true
+}
+
+// GetFeedXML returns the latest jobs as an RSS feed, for RSS readers and
+// community bots
+func (h *Handler) GetFeedXML(w http.ResponseWriter, r *http.Request) {
+	jobs, ok := h.serveFeed(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+
+	// Links point to the requested host, so the document is cached per host
+	site := siteURL(r)
+	cache, key := h.responses(), "feed.xml:"+site
+	if cache != nil {
+		if body, ok := cache.Get(key); ok {
+			w.Write(body)
+			return
+		}
+	}
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         feedTitle,
+			Link:          site,
+			Description:   "The latest Go jobs in Nigeria and remote",
+			LastBuildDate: time.Now().Format(time.RFC1123Z),
+			Items:         make([]rssItem, 0, len(jobs)),
+		},
+	}
+	for _, job := range jobs {
+		item := rssItem{
+			Title:       job.Title,
+			Link:        job.URL,
+			Description: feedSummary(job),
+			GUID:        rssGUID{Value: job.ID},
+		}
+		if !job.PostedAt.IsZero() {
+			item.PubDate = job.PostedAt.UTC().Format(time.RFC1123Z)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	if err := xml.NewEncoder(&body).Encode(feed); err != nil {
+		log.Printf("Error encoding RSS feed: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if cache != nil {
+		cache.Set(key, body.Bytes())
+	}
+	w.Write(body.Bytes())
+}
+
+// GetFeedJSON returns the latest jobs as a JSON Feed
+func (h *Handler) GetFeedJSON(w http.ResponseWriter, r *http.Request) {
+	jobs, ok := h.serveFeed(w, r)
+	if !ok {
+		return
+	}
+
+	site := siteURL(r)
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feedTitle,
+		HomePageURL: site,
+		FeedURL:     site + "/feed.json",
+		Items:       make([]jsonFeedItem, 0, len(jobs)),
+	}
+	for _, job := range jobs {
+		item := jsonFeedItem{
+			ID:          job.ID,
+			URL:         job.URL,
+			Title:       job.Title,
+			ContentText: feedSummary(job),
+		}
+		if !job.PostedAt.IsZero() {
+			item.DatePublished = job.PostedAt.UTC().Format(time.RFC3339)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	json.NewEncoder(w).Encode(feed)
+}
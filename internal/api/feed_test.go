@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeds(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	posted := time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) ORDER BY posted_at DESC LIMIT \\$1$").
+		WithArgs(feedSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "company", "location", "url", "posted_at"}).
+			AddRow("job-1", "Go Backend Engineer", "Paystack", "Lagos, Nigeria", "https://paystack.com/jobs/1", posted))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "http://jobs.example.com/feed.xml", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetFeedXML(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/rss+xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "<title>Go Backend Engineer</title>")
+	assert.Contains(t, rr.Body.String(), "<link>https://paystack.com/jobs/1</link>")
+	assert.Contains(t, rr.Body.String(), "<description>Paystack, Lagos, Nigeria</description>")
+
+	// The JSON feed is served from the same cache without another query
+	req, err = http.NewRequest("GET", "http://jobs.example.com/feed.json", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetFeedJSON(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"feed_url":"http://jobs.example.com/feed.json"`)
+	assert.Contains(t, rr.Body.String(), `"date_published":"2024-05-02T09:00:00Z"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFeedsDuplicateOfHiddenJob(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	// A duplicate is only left out while its canonical job is listed, so the
+	// copy of a hidden job shows in its place
+	posted := time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND NOT EXISTS \\(SELECT 1 FROM job_duplicates d JOIN jobs canonical ON canonical.id = d.canonical_id WHERE d.job_id = jobs.id AND NOT canonical.hidden (.+)\\) ORDER BY posted_at DESC LIMIT \\$1$").
+		WithArgs(feedSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "company", "location", "url", "posted_at"}).
+			AddRow("job-2", "Go Backend Engineer", "Paystack", "Lagos, Nigeria", "https://jobs.example.org/paystack-go", posted))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "http://jobs.example.com/feed.xml", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetFeedXML(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "<link>https://jobs.example.org/paystack-go</link>")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,656 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"Go9jaJobs/internal/db"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+//go:embed schema.graphql
+var graphqlSchemaSource string
+
+// graphqlSchema is the parsed schema every query is validated against
+var graphqlSchema = gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: graphqlSchemaSource})
+
+// maxGraphQLBody caps the size of a GraphQL request body
+const maxGraphQLBody = 64 << 10
+
+// maxSyncRuns caps the number of sync runs listed at once
+const maxSyncRuns = 100
+
+const (
+	// maxGraphQLDepth bounds how deeply the fields of a query nest
+	maxGraphQLDepth = 8
+	// maxGraphQLComplexity bounds the cost of a query, each field costing 1
+	// and each field running a database query graphqlQueryCost, so aliases
+	// cannot repeat costly fields at will
+	maxGraphQLComplexity = 100
+	// graphqlQueryCost is the cost of a Query field or totalCount
+	graphqlQueryCost = 10
+)
+
+// errAdminOnly is reported for fields only admin keys may query
+var errAdminOnly = errors.New("requires an admin key, use /api/admin/graphql")
+
+// graphqlRequest is a GraphQL query sent as JSON or query parameters
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphqlError is an entry of the errors of a GraphQL response
+type graphqlError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// GraphQL answers GraphQL queries over jobs, companies and sync runs, sent
+// as a JSON POST body or in the query, operationName and variables query
+// parameters of a GET. The job fields reuse the GET /api/jobs query.
+func (h *Handler) GraphQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req graphqlRequest
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGraphQLBody))
+		decoder.UseNumber()
+		if err := decoder.Decode(&req); err != nil {
+			writeError(w, r, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	} else {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			decoder := json.NewDecoder(strings.NewReader(variables))
+			decoder.UseNumber()
+			if err := decoder.Decode(&req.Variables); err != nil {
+				writeError(w, r, "Invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeError(w, r, "Missing query", http.StatusBadRequest)
+		return
+	}
+
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+	json.NewEncoder(w).Encode(h.executeGraphQL(r, req))
+}
+
+// executeGraphQL validates and runs a GraphQL request, returning its response
+func (h *Handler) executeGraphQL(r *http.Request, req graphqlRequest) map[string]interface{} {
+	doc, errs := gqlparser.LoadQuery(graphqlSchema, req.Query)
+	if len(errs) > 0 {
+		response := make([]graphqlError, 0, len(errs))
+		for _, err := range errs {
+			response = append(response, graphqlError{Message: err.Message})
+		}
+		return map[string]interface{}{"errors": response}
+	}
+
+	op := doc.Operations.ForName(req.OperationName)
+	if op == nil {
+		return map[string]interface{}{"errors": []graphqlError{{Message: "Unknown operation: " + req.OperationName}}}
+	}
+
+	vars, err := validator.VariableValues(graphqlSchema, op, req.Variables)
+	if err != nil {
+		return map[string]interface{}{"errors": []graphqlError{{Message: err.Error()}}}
+	}
+
+	e := &graphqlExecutor{h: h, r: r, doc: doc, vars: vars}
+	depth, complexity := e.measure(op.SelectionSet, 1)
+	if depth > maxGraphQLDepth {
+		return map[string]interface{}{"errors": []graphqlError{{Message: fmt.Sprintf("Query nests %d fields deep, at most %d allowed", depth, maxGraphQLDepth)}}}
+	}
+	if complexity > maxGraphQLComplexity {
+		return map[string]interface{}{"errors": []graphqlError{{Message: fmt.Sprintf("Query complexity %d exceeds %d: select fewer fields or aliases", complexity, maxGraphQLComplexity)}}}
+	}
+	data, _ := e.completeObject(graphqlSchema.Query.Name, op.SelectionSet, nil, nil)
+
+	response := map[string]interface{}{"data": data}
+	if len(e.errors) > 0 {
+		response["errors"] = e.errors
+	}
+	return response
+}
+
+// graphqlObject is an object of a GraphQL response, keeping the fields in
+// the order they were selected
+type graphqlObject struct {
+	keys   []string
+	values []interface{}
+}
+
+// MarshalJSON encodes the fields in selection order
+func (o *graphqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// graphqlResolver resolves a field of the Query type
+type graphqlResolver func(e *graphqlExecutor, field *ast.Field, args map[string]interface{}) (interface{}, error)
+
+// graphqlQueryResolvers resolves the fields of the Query type. Other objects
+// are maps keyed by field name, whose values may be lazy func() (interface{}, error).
+var graphqlQueryResolvers = map[string]graphqlResolver{
+	"jobs":      resolveJobs,
+	"jobCount":  resolveJobCount,
+	"companies": resolveCompanies,
+	"company":   resolveCompany,
+	"syncRuns":  resolveSyncRuns,
+	"syncRun":   resolveSyncRun,
+}
+
+// graphqlExecutor runs one GraphQL operation
+type graphqlExecutor struct {
+	h      *Handler
+	r      *http.Request
+	doc    *ast.QueryDocument
+	vars   map[string]interface{}
+	errors []graphqlError
+}
+
+// addError records a field error at path
+func (e *graphqlExecutor) addError(path []interface{}, err error) {
+	e.errors = append(e.errors, graphqlError{Message: err.Error(), Path: append([]interface{}(nil), path...)})
+}
+
+// collectFields flattens a selection set into its fields, expanding
+// fragments, applying @skip/@include and merging fields of the same alias
+func (e *graphqlExecutor) collectFields(set ast.SelectionSet) []*ast.Field {
+	var fields []*ast.Field
+	byAlias := make(map[string]*ast.Field)
+
+	var collect func(ast.SelectionSet)
+	collect = func(set ast.SelectionSet) {
+		for _, selection := range set {
+			switch sel := selection.(type) {
+			case *ast.Field:
+				if !e.included(sel.Directives) {
+					continue
+				}
+				if existing, ok := byAlias[sel.Alias]; ok {
+					merged := *existing
+					merged.SelectionSet = append(append(ast.SelectionSet{}, existing.SelectionSet...), sel.SelectionSet...)
+					*existing = merged
+					continue
+				}
+				field := *sel
+				byAlias[sel.Alias] = &field
+				fields = append(fields, &field)
+			case *ast.InlineFragment:
+				if e.included(sel.Directives) {
+					collect(sel.SelectionSet)
+				}
+			case *ast.FragmentSpread:
+				if fragment := e.doc.Fragments.ForName(sel.Name); fragment != nil && e.included(sel.Directives) {
+					collect(fragment.SelectionSet)
+				}
+			}
+		}
+	}
+	collect(set)
+	return fields
+}
+
+// measure returns how deeply the fields selected by set nest, set being at
+// depth, and the complexity of resolving them (see maxGraphQLComplexity).
+// Fragments are expanded and skipped fields left out; the measure stops below
+// maxGraphQLDepth.
+func (e *graphqlExecutor) measure(set ast.SelectionSet, depth int) (maxDepth, complexity int) {
+	if depth > maxGraphQLDepth {
+		return depth, 0
+	}
+	for _, selection := range set {
+		var d, c int
+		switch sel := selection.(type) {
+		case *ast.Field:
+			if !e.included(sel.Directives) {
+				continue
+			}
+			d, c = depth, 1
+			if depth == 1 || sel.Name == "totalCount" {
+				c = graphqlQueryCost
+			}
+			if len(sel.SelectionSet) > 0 {
+				nested, cost := e.measure(sel.SelectionSet, depth+1)
+				d, c = nested, c+cost
+			}
+		case *ast.InlineFragment:
+			if e.included(sel.Directives) {
+				d, c = e.measure(sel.SelectionSet, depth)
+			}
+		case *ast.FragmentSpread:
+			if fragment := e.doc.Fragments.ForName(sel.Name); fragment != nil && e.included(sel.Directives) {
+				d, c = e.measure(fragment.SelectionSet, depth)
+			}
+		}
+		if d > maxDepth {
+			maxDepth = d
+		}
+		complexity += c
+	}
+	return maxDepth, complexity
+}
+
+// included applies the @skip and @include directives of a selection
+func (e *graphqlExecutor) included(directives ast.DirectiveList) bool {
+	if d := directives.ForName("skip"); d != nil && d.ArgumentMap(e.vars)["if"] == true {
+		return false
+	}
+	if d := directives.ForName("include"); d != nil && d.ArgumentMap(e.vars)["if"] == false {
+		return false
+	}
+	return true
+}
+
+// completeObject resolves the selected fields of an object, source being
+// nil for the Query type. It returns false when a non-null field is null.
+func (e *graphqlExecutor) completeObject(typeName string, set ast.SelectionSet, source map[string]interface{}, path []interface{}) (*graphqlObject, bool) {
+	result := &graphqlObject{}
+	for _, field := range e.collectFields(set) {
+		fieldPath := append(append([]interface{}(nil), path...), field.Alias)
+
+		if field.Name == "__typename" {
+			result.keys = append(result.keys, field.Alias)
+			result.values = append(result.values, typeName)
+			continue
+		}
+
+		var value interface{}
+		var err error
+		if source == nil {
+			if resolve, ok := graphqlQueryResolvers[field.Name]; ok {
+				value, err = resolve(e, field, field.ArgumentMap(e.vars))
+			} else {
+				err = fmt.Errorf("%s is not supported", field.Name)
+			}
+		} else {
+			value = source[field.Name]
+			if lazy, ok := value.(func() (interface{}, error)); ok {
+				value, err = lazy()
+			}
+		}
+		if err != nil {
+			e.addError(fieldPath, err)
+			value = nil
+		}
+
+		completed, ok := e.complete(field.Definition.Type, field, value, fieldPath, err != nil)
+		if !ok {
+			return nil, false
+		}
+		result.keys = append(result.keys, field.Alias)
+		result.values = append(result.values, completed)
+	}
+	return result, true
+}
+
+// complete converts a resolved value to the field type. A null in a
+// non-null position returns false, nulling the parent as the spec requires;
+// reported tells whether the error was already recorded.
+func (e *graphqlExecutor) complete(typ *ast.Type, field *ast.Field, value interface{}, path []interface{}, reported bool) (interface{}, bool) {
+	if isNil(value) {
+		if typ.NonNull {
+			if !reported {
+				e.addError(path, fmt.Errorf("must not be null"))
+			}
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if typ.Elem != nil {
+		list := reflect.ValueOf(value)
+		if list.Kind() != reflect.Slice {
+			e.addError(path, fmt.Errorf("expected a list"))
+			return nil, !typ.NonNull
+		}
+		items := make([]interface{}, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			item, ok := e.complete(typ.Elem, field, list.Index(i).Interface(), append(append([]interface{}(nil), path...), i), false)
+			if !ok {
+				return nil, !typ.NonNull
+			}
+			items = append(items, item)
+		}
+		return items, true
+	}
+
+	def := graphqlSchema.Types[typ.NamedType]
+	if def.Kind != ast.Object {
+		return coerceScalar(typ.NamedType, value), true
+	}
+
+	source, ok := value.(map[string]interface{})
+	if !ok {
+		e.addError(path, fmt.Errorf("expected an object"))
+		return nil, !typ.NonNull
+	}
+	object, ok := e.completeObject(def.Name, field.SelectionSet, source, path)
+	if !ok {
+		return nil, !typ.NonNull
+	}
+	return object, true
+}
+
+// isNil reports whether value is nil or a nil map, slice or pointer
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// coerceScalar converts a resolved value to a scalar or enum of the schema
+func coerceScalar(name string, value interface{}) interface{} {
+	switch name {
+	case "Int":
+		switch n := value.(type) {
+		case float64:
+			return int64(n)
+		case json.Number:
+			i, _ := n.Int64()
+			return i
+		}
+	case "String", "ID":
+		if _, ok := value.(string); !ok {
+			return fmt.Sprint(value)
+		}
+	}
+	return value
+}
+
+// toGraphQL converts a REST value (struct or map with snake_case JSON keys)
+// to maps and slices keyed by the camelCase GraphQL field names
+func toGraphQL(value interface{}) (interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	return camelKeys(decoded), nil
+}
+
+// camelKeys renames the keys of decoded JSON objects from snake_case to
+// camelCase, recursively
+func camelKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			renamed[snakeToCamel(key)] = camelKeys(item)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range v {
+			v[i] = camelKeys(item)
+		}
+	}
+	return value
+}
+
+// snakeToCamel converts a snake_case name to camelCase
+func snakeToCamel(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// camelToSnake converts a camelCase name to snake_case
+func camelToSnake(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsUpper(r) {
+			b.WriteByte('_')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// argInt reads an Int argument, which is int64 from literals and json.Number
+// from variables
+func argInt(args map[string]interface{}, name string) (int, bool) {
+	switch n := args[name].(type) {
+	case int64:
+		return int(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
+// request returns a copy of the HTTP request carrying query as its query
+// parameters, so GraphQL fields reuse the REST query builders
+func (e *graphqlExecutor) request(query url.Values) *http.Request {
+	r := e.r.Clone(e.r.Context())
+	r.URL = &url.URL{Path: e.r.URL.Path, RawQuery: query.Encode()}
+	return r
+}
+
+// limitedQuery checks query against the API tier of the request, as
+// QueryLimitsMiddleware does for REST routes
+func (e *graphqlExecutor) limitedQuery(query url.Values) (url.Values, error) {
+	if err := validateQueryLimits(query, tierLimitsFrom(e.r.Context())); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// jobQuery turns a JobFilter argument into the query parameters of GET /api/jobs
+func jobQuery(args map[string]interface{}) url.Values {
+	query := url.Values{}
+	filter, _ := args["filter"].(map[string]interface{})
+	for name, value := range filter {
+		if value != nil {
+			query.Set(camelToSnake(name), fmt.Sprint(value))
+		}
+	}
+	return query
+}
+
+// selects reports whether the selection set of field selects path, e.g.
+// nodes.companyDetails
+func (e *graphqlExecutor) selects(field *ast.Field, path ...string) bool {
+	for _, child := range e.collectFields(field.SelectionSet) {
+		if child.Name == path[0] {
+			if len(path) == 1 || e.selects(child, path[1:]...) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isAdminRequest reports whether a request was authenticated with an admin key
+func isAdminRequest(ctx context.Context) bool {
+	_, ok := ctx.Value(adminKeyIDKey{}).(string)
+	return ok
+}
+
+func resolveJobs(e *graphqlExecutor, field *ast.Field, args map[string]interface{}) (interface{}, error) {
+	query := jobQuery(args)
+	if sort, ok := args["sort"].(string); ok {
+		query.Set("sort", strings.ToLower(sort))
+	}
+	if first, ok := argInt(args, "first"); ok {
+		query.Set("limit", strconv.Itoa(first))
+	}
+	if after, ok := args["after"].(string); ok && after != "" {
+		query.Set("cursor", after)
+	}
+	if e.selects(field, "nodes", "companyDetails") {
+		query.Set("expand", "company")
+	}
+	query, err := e.limitedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	r := e.request(query)
+	jobs, nextCursor, _, err := e.h.listJobs(r)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := toGraphQL(jobs)
+	if err != nil {
+		return nil, err
+	}
+	if nodes == nil {
+		nodes = []interface{}{}
+	}
+	connection := map[string]interface{}{
+		"nodes":      nodes,
+		"nextCursor": nil,
+		"totalCount": func() (interface{}, error) {
+			count, _, err := e.h.countJobs(r)
+			return count, err
+		},
+	}
+	if nextCursor != "" {
+		connection["nextCursor"] = nextCursor
+	}
+	return connection, nil
+}
+
+func resolveJobCount(e *graphqlExecutor, field *ast.Field, args map[string]interface{}) (interface{}, error) {
+	query, err := e.limitedQuery(jobQuery(args))
+	if err != nil {
+		return nil, err
+	}
+	count, _, err := e.h.countJobs(e.request(query))
+	return count, err
+}
+
+func resolveCompanies(e *graphqlExecutor, field *ast.Field, args map[string]interface{}) (interface{}, error) {
+	query := url.Values{}
+	limit := tierLimitsFrom(e.r.Context()).MaxPageSize
+	if first, ok := argInt(args, "first"); ok {
+		if first < 1 {
+			return nil, fmt.Errorf("Invalid first: %d", first)
+		}
+		limit = first
+		query.Set("limit", strconv.Itoa(first))
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	offset, _ := argInt(args, "offset")
+	if offset < 0 {
+		return nil, fmt.Errorf("Invalid offset: %d", offset)
+	}
+	query.Set("offset", strconv.Itoa(offset))
+	if _, err := e.limitedQuery(query); err != nil {
+		return nil, err
+	}
+
+	companies, err := db.ListCompanies(e.r.Context(), e.h.DB, limit, offset)
+	if err != nil {
+		log.Printf("Error querying companies: %v", err)
+		return nil, errors.New("Internal server error")
+	}
+	return toGraphQL(companies)
+}
+
+func resolveCompany(e *graphqlExecutor, field *ast.Field, args map[string]interface{}) (interface{}, error) {
+	domain, _ := args["domain"].(string)
+	company, err := db.GetCompany(e.r.Context(), e.h.DB, strings.ToLower(domain))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error querying company %s: %v", domain, err)
+		return nil, errors.New("Internal server error")
+	}
+	return toGraphQL(company)
+}
+
+func resolveSyncRuns(e *graphqlExecutor, field *ast.Field, args map[string]interface{}) (interface{}, error) {
+	if !isAdminRequest(e.r.Context()) {
+		return nil, errAdminOnly
+	}
+	first, _ := argInt(args, "first")
+	if first < 1 || first > maxSyncRuns {
+		return nil, fmt.Errorf("first must be between 1 and %d", maxSyncRuns)
+	}
+	source, _ := args["source"].(string)
+
+	runs, err := db.ListSyncRuns(e.r.Context(), e.h.DB, source, first)
+	if err != nil {
+		log.Printf("Error querying sync runs: %v", err)
+		return nil, errors.New("Internal server error")
+	}
+	return toGraphQL(runs)
+}
+
+func resolveSyncRun(e *graphqlExecutor, field *ast.Field, args map[string]interface{}) (interface{}, error) {
+	if !isAdminRequest(e.r.Context()) {
+		return nil, errAdminOnly
+	}
+	id := fmt.Sprint(args["id"])
+	run, err := db.GetSyncRun(e.r.Context(), e.h.DB, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error querying sync run %s: %v", id, err)
+		return nil, errors.New("Internal server error")
+	}
+	return toGraphQL(run)
+}
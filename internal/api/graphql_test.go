@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// postGraphQL sends a GraphQL request to the handler with the limits of the
+// public tier, or of the internal tier with an admin key when admin is set
+func postGraphQL(handler *Handler, body string, admin bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), tierLimitsKey{}, config.TierLimits{MaxPageSize: 100, AllowedSorts: []string{"newest", "oldest"}})
+	if admin {
+		ctx = context.WithValue(req.Context(), tierLimitsKey{}, config.TierLimits{})
+		ctx = context.WithValue(ctx, adminKeyIDKey{}, "abcd1234")
+	}
+	rr := httptest.NewRecorder()
+	handler.GraphQL(rr, req.WithContext(ctx))
+	return rr
+}
+
+func TestGraphQLJobs(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	columns := []string{"id", "job_id", "title", "company", "company_url", "company_logo", "location", "description",
+		"url", "salary", "posted_at", "job_type", "is_remote", "source", "word_count", "reading_time_minutes",
+		"apply_method", "seniority", "assessments", "stack", "tracks", "language", "company_details"}
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND is_remote = \\$1 ORDER BY posted_at ASC LIMIT \\$2$").
+		WithArgs(true, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"job-uuid-1", "job-id-1", "Golang Developer", "Paystack", nil, nil, "Lagos", "Build payments",
+			"https://paystack.com/jobs/1", nil, time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC), nil, true, "jsearch",
+			2, 1, "direct", "senior", []byte(`{live_coding}`), nil, nil, "", []byte(`{"domain":"paystack.com","name":"Paystack","industries":["fintech"],"links":[],"logo_url":"https://logo"}`)))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM jobs WHERE (.+) AND is_remote = \\$1").
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	body, _ := json.Marshal(graphqlRequest{
+		Query: `query Remote($first: Int) {
+			jobs(filter: {isRemote: true}, sort: OLDEST, first: $first) {
+				total: totalCount
+				nextCursor
+				nodes { ...card companyDetails { name logoUrl industries } }
+			}
+		}
+		fragment card on Job { title postedAt applyMethod assessments salary __typename }`,
+		Variables: map[string]interface{}{"first": 1},
+	})
+	rr := postGraphQL(handler, string(body), false)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Fields come back in selection order, with aliases
+	assert.JSONEq(t, `{"data":{"jobs":{
+		"total":7,
+		"nextCursor":`+mustJSON(t, rr, "nextCursor")+`,
+		"nodes":[{"title":"Golang Developer","postedAt":"2025-03-01T08:00:00Z","applyMethod":"direct","assessments":["live_coding"],"salary":null,
+			"__typename":"Job","companyDetails":{"name":"Paystack","logoUrl":"https://logo","industries":["fintech"]}}]
+	}}}`, rr.Body.String())
+	assert.True(t, strings.Index(rr.Body.String(), `"total"`) < strings.Index(rr.Body.String(), `"nodes"`))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// mustJSON returns the JSON encoding of the named jobs field of a response
+func mustJSON(t *testing.T, rr *httptest.ResponseRecorder, name string) string {
+	var response struct {
+		Data struct {
+			Jobs map[string]interface{} `json:"jobs"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	value, _ := json.Marshal(response.Data.Jobs[name])
+	return string(value)
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	// Queries are validated against the schema
+	rr := postGraphQL(handler, `{"query":"{ jobs { nodes { salaryFlag } } }"}`, false)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `Cannot query field \"salaryFlag\" on type \"Job\"`)
+	assert.NotContains(t, rr.Body.String(), `"data"`)
+
+	// Tier limits apply as on GET /api/jobs; the failing field nulls its
+	// non-null parent, which is the whole response here
+	rr = postGraphQL(handler, `{"query":"{ jobs(sort: TITLE) { totalCount } }"}`, false)
+	assert.JSONEq(t, `{"data":null,"errors":[{"message":"sort not allowed: title","path":["jobs"]}]}`, rr.Body.String())
+
+	// Sync runs are admin only
+	rr = postGraphQL(handler, `{"query":"{ syncRun(id: \"run-1\") { status } }"}`, false)
+	assert.JSONEq(t, `{"data":{"syncRun":null},"errors":[{"message":"requires an admin key, use /api/admin/graphql","path":["syncRun"]}]}`, rr.Body.String())
+
+	rr = postGraphQL(handler, `{"query":`, false)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Deep or costly queries are refused before any is run
+	rr = postGraphQL(handler, `{"query":"{ __schema { types { fields { type { ofType { ofType { ofType { ofType { name } } } } } } } } }"}`, false)
+	assert.JSONEq(t, `{"errors":[{"message":"Query nests 9 fields deep, at most 8 allowed"}]}`, rr.Body.String())
+	rr = postGraphQL(handler, `{"query":"{ a: jobCount b: jobCount c: jobCount d: jobCount e: jobCount f: jobCount g: jobCount h: jobCount i: jobCount j: jobCount k: jobCount }"}`, false)
+	assert.JSONEq(t, `{"errors":[{"message":"Query complexity 110 exceeds 100: select fewer fields or aliases"}]}`, rr.Body.String())
+
+	// Introspection is not served
+	rr = postGraphQL(handler, `{"query":"{ __schema { description } }"}`, false)
+	assert.JSONEq(t, `{"data":null,"errors":[{"message":"__schema is not supported","path":["__schema"]}]}`, rr.Body.String())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGraphQLSyncRuns(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	created := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT (.+) FROM sync_runs WHERE \\$1 = '' OR source = \\$1 ORDER BY created_at DESC LIMIT \\$2$").
+		WithArgs("jsearch", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source", "status", "fetched", "saved", "results", "error_message",
+			"created_at", "started_at", "finished_at", "duration_ms", "errors"}).
+			AddRow("run-1", "jsearch", "done", 12, 10, []byte(`[{"source":"jsearch","fetched":12,"saved":10,"new":4,"status":"Success"}]`),
+				nil, created, created, created.Add(time.Minute), 60000, nil))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	rr := postGraphQL(handler, `{"query":"{ syncRuns(source: \"jsearch\") { id durationMs finishedAt results { new status errorKind } errors { stage } } }"}`, true)
+	assert.JSONEq(t, `{"data":{"syncRuns":[{"id":"run-1","durationMs":60000,"finishedAt":"2025-03-01T08:01:00Z",
+		"results":[{"new":4,"status":"Success","errorKind":null}],"errors":[]}]}}`, rr.Body.String())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,141 +1,1752 @@
 package api
 
 import (
+	"Go9jaJobs/internal/analyzer"
 	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/errorlog"
 	"Go9jaJobs/internal/fetcher"
+	"Go9jaJobs/internal/logging"
+	"Go9jaJobs/internal/models"
 	"Go9jaJobs/internal/services"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Handler struct {
 	DB         *sql.DB
 	JobFetcher *fetcher.JobFetcher
+	Config     *config.Config
+	// Pool is the connection pool behind DB, reported on /metrics
+	Pool *pgxpool.Pool
+
+	// Scheduler is the in-process sync scheduler, nil when it is disabled
+	Scheduler   *services.JobScheduler
+	SyncManager *services.SyncManager
+	// Tasks runs the background work of requests, shut down with the server
+	Tasks *services.TaskManager
+	// Mailer sends job alert emails, nil when subscriptions are disabled
+	Mailer services.Mailer
+	// Responses caches the rendered responses of /api/jobs and /feed.xml,
+	// nil when response caching is disabled
+	Responses db.CacheStore
+	// RateLimits counts the requests of rate limited routes and the nonces of
+	// HMAC signed requests, in process when nil; set it before SetupRoutes
+	RateLimits db.CacheStore
+
+	// feed caches the jobs of the public RSS and JSON feeds
+	feed feedCache
+	// stats caches the aggregates of /api/stats per track
+	stats statsCache
+}
+
+// NewHandler creates a new Handler instance
+func NewHandler(DB *sql.DB, jobFetcher *fetcher.JobFetcher) *Handler {
+	tasks := services.NewTaskManager()
+	return &Handler{
+		DB:          DB,
+		JobFetcher:  jobFetcher,
+		SyncManager: services.NewSyncManager(DB, jobFetcher, tasks),
+		Tasks:       tasks,
+	}
+}
+func (h *Handler) SetupRoutes(cfg *config.Config) *mux.Router {
+	h.Config = cfg
+	r := newRouter()
+
+	// Public route - No authentication middleware
+	r.HandleFunc("/status", h.StatusCheck).Methods("GET")
+	r.HandleFunc("/healthz", h.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", h.Readyz).Methods("GET")
+	r.HandleFunc("/status/detail", h.StatusDetail).Methods("GET")
+	r.HandleFunc("/feed.xml", h.GetFeedXML).Methods("GET")
+	r.HandleFunc("/feed.json", h.GetFeedJSON).Methods("GET")
+	r.HandleFunc("/subscriptions/confirm", h.ConfirmSubscription).Methods("GET", "POST")
+	r.HandleFunc("/subscriptions/unsubscribe", h.Unsubscribe).Methods("GET", "POST")
+	r.HandleFunc("/suggestions/confirm", h.ConfirmSourceSuggestion).Methods("GET", "POST")
+
+	// Browser frontends exchange the API key for a bearer token
+	if authEnabled(cfg, AuthSchemeJWT) {
+		token := r.PathPrefix("/api/auth/token").Subrouter()
+		token.Use(LoggingMiddleware)
+		token.Use(SecurityHeadersMiddleware)
+		token.Use(CORSMiddleware(cfg.AllowedOrigins))
+		token.Use(RateLimitMiddleware(h.rateLimits(), cfg.TrustedProxies, "auth-token", tokenRateLimit, time.Minute))
+		token.HandleFunc("", h.IssueToken).Methods("POST")
+	}
+
+	// Operational endpoints live on their own listener when AdminAddr is
+	// set; otherwise they are registered here, before the generic /api
+	// subrouter so their routes are matched first
+	if cfg.AdminAddr == "" {
+		h.registerAdminRoutes(r, cfg)
+	}
+
+	// Nonces of HMAC signed requests, shared by the REST and GraphQL routes
+	nonces := h.rateLimits()
+
+	// Create protected subrouter
+	protected := r.PathPrefix("/api").Subrouter()
+
+	// Apply middleware chain to the protected subrouter
+	protected.Use(LoggingMiddleware)
+	protected.Use(AuthMiddleware(cfg, nonces))
+	protected.Use(SecurityHeadersMiddleware)
+	protected.Use(CORSMiddleware(cfg.AllowedOrigins))
+	protected.Use(QueryLimitsMiddleware(cfg.PublicTier))
+
+	// Add protected routes to the subrouter with middleware already applied
+	protected.HandleFunc("/jobs", h.GetAllJobs).Methods("GET")
+	protected.HandleFunc("/jobs", h.HeadJobs).Methods("HEAD")
+	protected.HandleFunc("/jobs/count", h.CountJobs).Methods("GET")
+	protected.HandleFunc("/jobs/stack", h.GetStackFacet).Methods("GET")
+	protected.HandleFunc("/jobs/export", h.ExportJobs).Methods("GET")
+	protected.HandleFunc("/tracks", h.ListTracks).Methods("GET")
+	protected.HandleFunc("/stats", h.GetStats).Methods("GET")
+	protected.HandleFunc("/stats/trends", h.GetStatsTrends).Methods("GET")
+	protected.HandleFunc("/companies", h.ListCompanies).Methods("GET")
+	protected.HandleFunc("/companies/{id}", h.GetCompany).Methods("GET")
+	protected.HandleFunc("/companies/{id}/jobs", h.GetCompanyJobs).Methods("GET")
+	protected.HandleFunc("/subscriptions", h.Subscribe).Methods("POST")
+	protected.Handle("/suggest-source", RateLimitMiddleware(h.rateLimits(), cfg.TrustedProxies, "suggest-source", cfg.SuggestionRateLimit, time.Hour)(http.HandlerFunc(h.SuggestSource))).Methods("POST")
+
+	// GraphQL alongside the REST routes, under the same key and tier limits
+	graphql := r.PathPrefix("/graphql").Subrouter()
+	graphql.Use(LoggingMiddleware)
+	graphql.Use(AuthMiddleware(cfg, nonces))
+	graphql.Use(SecurityHeadersMiddleware)
+	graphql.Use(CORSMiddleware(cfg.AllowedOrigins))
+	graphql.Use(QueryLimitsMiddleware(cfg.PublicTier))
+	graphql.HandleFunc("", h.GraphQL).Methods("GET", "POST")
+
+	// Create a subrouter specifically for /jobs/sync with APIKeyAuthSimpleMiddleware
+	jobSyncRouter := r.PathPrefix("/api/jobs/sync").Subrouter()
+	jobSyncRouter.Use(LoggingMiddleware)
+	jobSyncRouter.Use(APIKeyAuthSimpleMiddleware(cfg))
+	jobSyncRouter.Use(SecurityHeadersMiddleware)
+	jobSyncRouter.Use(CORSMiddleware(cfg.AllowedOrigins))
+	jobSyncRouter.HandleFunc("", h.SyncJobs).Methods("POST")
+	jobSyncRouter.HandleFunc("/status", h.GetSyncStatus).Methods("GET")
+	jobSyncRouter.HandleFunc("/runs/{id}", h.GetSyncRun).Methods("GET")
+
+	// External scrapers push jobs with the same key as the sync cron jobs
+	ingestRouter := r.PathPrefix("/api/jobs/ingest").Subrouter()
+	ingestRouter.Use(LoggingMiddleware)
+	ingestRouter.Use(APIKeyAuthSimpleMiddleware(cfg))
+	ingestRouter.Use(SecurityHeadersMiddleware)
+	ingestRouter.Use(CORSMiddleware(cfg.AllowedOrigins))
+	ingestRouter.HandleFunc("", h.IngestJobs).Methods("POST")
+
+	return r
+}
+
+// SetupAdminRoutes returns the router of the separate admin listener, serving
+// /status, the probes and the /api/admin endpoints
+func (h *Handler) SetupAdminRoutes(cfg *config.Config) *mux.Router {
+	h.Config = cfg
+	r := newRouter()
+	r.HandleFunc("/status", h.StatusCheck).Methods("GET")
+	r.HandleFunc("/healthz", h.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", h.Readyz).Methods("GET")
+	h.registerAdminRoutes(r, cfg)
+	return r
+}
+
+// registerAdminRoutes adds the /api/admin subrouter for operational endpoints
+// to r, behind the IP allowlist, admin keys and audit log, and /metrics
+// behind the IP allowlist only so scrapers need no key
+func (h *Handler) registerAdminRoutes(r *mux.Router, cfg *config.Config) {
+	r.Handle("/metrics", AdminIPAllowlistMiddleware(cfg.AdminAllowedIPs)(http.HandlerFunc(h.Metrics))).Methods("GET")
+
+	admin := r.PathPrefix("/api/admin").Subrouter()
+	admin.Use(LoggingMiddleware)
+	admin.Use(AdminIPAllowlistMiddleware(cfg.AdminAllowedIPs))
+	admin.Use(AdminKeyAuthMiddleware(adminKeys(cfg)))
+	admin.Use(AuditLogMiddleware)
+	admin.Use(SecurityHeadersMiddleware)
+	admin.Use(QueryLimitsMiddleware(cfg.InternalTier))
+	admin.HandleFunc("/jobs", h.GetAllJobs).Methods("GET")
+	admin.HandleFunc("/jobs", h.HeadJobs).Methods("HEAD")
+	admin.HandleFunc("/jobs/count", h.CountJobs).Methods("GET")
+	admin.HandleFunc("/jobs/stack", h.GetStackFacet).Methods("GET")
+	admin.HandleFunc("/jobs/export", h.ExportJobs).Methods("GET")
+	admin.HandleFunc("/stats", h.GetStats).Methods("GET")
+	admin.HandleFunc("/stats/trends", h.GetStatsTrends).Methods("GET")
+	admin.HandleFunc("/jobs/expire", h.ExpireJobs).Methods("POST")
+	admin.HandleFunc("/jobs/language-audit", h.AuditJobLanguage).Methods("POST")
+	admin.HandleFunc("/jobs/language-flags", h.GetLanguageFlags).Methods("GET")
+	admin.HandleFunc("/jobs/salary-benchmark", h.BenchmarkSalaries).Methods("POST")
+	admin.HandleFunc("/jobs/salary-flags", h.GetSalaryFlags).Methods("GET")
+	admin.HandleFunc("/jobs/{id}", h.GetJobDetail).Methods("GET")
+	admin.HandleFunc("/jobs/{id}", h.UpdateJob).Methods("PATCH")
+	admin.HandleFunc("/jobs/{id}", h.DeleteJob).Methods("DELETE")
+	admin.HandleFunc("/jobs/{id}/changes", h.GetJobChanges).Methods("GET")
+	admin.HandleFunc("/jobs/{id}/raw", h.GetJobRaw).Methods("GET")
+	admin.HandleFunc("/import", h.ImportJobs).Methods("POST")
+	admin.HandleFunc("/filters/test", h.TestRelevanceFilter).Methods("POST")
+	admin.HandleFunc("/errors", h.GetRecentErrors).Methods("GET")
+	admin.HandleFunc("/digest", h.GetDigest).Methods("GET")
+	admin.HandleFunc("/scheduler", h.GetSchedulerState).Methods("GET")
+	admin.HandleFunc("/tasks", h.GetTasks).Methods("GET")
+	admin.HandleFunc("/usage", h.GetAPIUsage).Methods("GET")
+	admin.HandleFunc("/skips", h.GetJobSkips).Methods("GET")
+	admin.HandleFunc("/dedupe-audit", h.GetDedupeAudit).Methods("GET")
+	admin.HandleFunc("/provider-schemas", h.GetSchemaChanges).Methods("GET")
+	admin.HandleFunc("/query", h.ListQueryTemplates).Methods("GET")
+	admin.HandleFunc("/query/{template_name}", h.RunQueryTemplate).Methods("GET")
+	admin.HandleFunc("/sources/{name}/purge", h.PurgeSource).Methods("POST")
+	admin.HandleFunc("/source-suggestions", h.GetSourceSuggestions).Methods("GET")
+	admin.HandleFunc("/log-level", h.GetLogLevel).Methods("GET")
+	admin.HandleFunc("/log-level", h.SetLogLevel).Methods("PUT")
+	admin.HandleFunc("/graphql", h.GraphQL).Methods("GET", "POST")
+	admin.HandleFunc("/blocked-companies", h.ListBlockedCompanies).Methods("GET")
+	admin.HandleFunc("/blocked-companies", h.BlockCompany).Methods("POST")
+	admin.HandleFunc("/blocked-companies/{name}", h.UnblockCompany).Methods("DELETE")
+	admin.HandleFunc("/company-aliases", h.ListCompanyAliases).Methods("GET")
+	admin.HandleFunc("/company-aliases", h.SetCompanyAlias).Methods("POST")
+	admin.HandleFunc("/company-aliases/{alias}", h.DeleteCompanyAlias).Methods("DELETE")
+	admin.HandleFunc("/companies/{id}", h.OverrideCompany).Methods("PATCH")
+
+	// The HTML dashboard logs in with an admin key once, then authenticates
+	// its forms with a session cookie
+	dashboard := r.PathPrefix("/admin").Subrouter()
+	dashboard.Use(LoggingMiddleware)
+	dashboard.Use(AdminIPAllowlistMiddleware(cfg.AdminAllowedIPs))
+	dashboard.Use(SecurityHeadersMiddleware)
+	dashboard.HandleFunc("", h.Dashboard).Methods("GET")
+	dashboard.HandleFunc("/", h.Dashboard).Methods("GET")
+	dashboard.HandleFunc("/dashboard.css", h.DashboardCSS).Methods("GET")
+	dashboard.HandleFunc("/login", h.DashboardLogin).Methods("POST")
+	dashboard.HandleFunc("/logout", h.DashboardLogout).Methods("POST")
+
+	actions := dashboard.NewRoute().Subrouter()
+	actions.Use(h.dashboardAuth)
+	actions.Use(AuditLogMiddleware)
+	actions.HandleFunc("/sync", h.DashboardSync).Methods("POST")
+	actions.HandleFunc("/blocked-companies", h.DashboardBlockCompany).Methods("POST")
+	actions.HandleFunc("/blocked-companies/delete", h.DashboardUnblockCompany).Methods("POST")
+}
+
+// newRouter returns a router giving every request an ID and answering unknown
+// routes and methods with the JSON error envelope
+func newRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.Use(RequestIDMiddleware)
+	r.NotFoundHandler = RequestIDMiddleware(http.HandlerFunc(notFound))
+	r.MethodNotAllowedHandler = RequestIDMiddleware(http.HandlerFunc(methodNotAllowed))
+	return r
+}
+
+// adminKeys returns the keys accepted by /api/admin, the cron API key unless
+// admin keys are configured
+func adminKeys(cfg *config.Config) []string {
+	if len(cfg.AdminAPIKeys) > 0 {
+		return cfg.AdminAPIKeys
+	}
+	return []string{cfg.CronAPIKey}
+}
+
+// defaultQueryTimeout bounds the database queries of a request when
+// DB_QUERY_TIMEOUT is not configured
+const defaultQueryTimeout = 10 * time.Second
+
+// withQueryTimeout returns r with a context bounding its database queries,
+// so a slow query cannot hold a pooled connection past the timeout
+func (h *Handler) withQueryTimeout(r *http.Request) (*http.Request, context.CancelFunc) {
+	timeout := defaultQueryTimeout
+	if h.Config != nil && h.Config.DBQueryTimeout > 0 {
+		timeout = h.Config.DBQueryTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	return r.WithContext(ctx), cancel
+}
+
+// StatusCheck returns a simple API status
+func (h *Handler) StatusCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := map[string]interface{}{
+		"status":    "ok",
+		"timestamp": time.Now().Format(time.RFC3339),
+		"message":   "API is running",
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// StatusDetail returns job counts per source and track, the newest job and
+// which sync sources and features are enabled. It is public, so it only exposes aggregates.
+func (h *Handler) StatusDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	stats, err := db.GetJobStats(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error getting job stats: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	byTrack, err := db.CountActiveJobsByTrack(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error counting jobs by track: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	jobs := map[string]interface{}{
+		"total":       stats.Total,
+		"active":      stats.Active,
+		"by_source":   stats.Sources,
+		"by_vertical": byTrack,
+		"newest_job":  nil,
+	}
+	if stats.NewestJob != nil {
+		jobs["newest_job"] = stats.NewestJob.Format(time.RFC3339)
+	}
+
+	sources := make(map[string]bool)
+	for _, source := range services.Sources() {
+		sources[source] = services.SourceEnabled(h.Config, source)
+	}
+
+	response := map[string]interface{}{
+		"status":    "ok",
+		"jobs":      jobs,
+		"sources":   sources,
+		"features":  h.features(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// features reports which optional features are enabled in this deployment
+func (h *Handler) features() map[string]bool {
+	cfg := h.Config
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	return map[string]bool{
+		"scheduler":       h.Scheduler != nil,
+		"expiry_sweeper":  cfg.ExpirySweepInterval > 0,
+		"logo_enrichment": cfg.Mode != "dev" && cfg.Mode != config.ModeReplay && cfg.BrandFetchAPIKey != "",
+		"rapidapi":        cfg.RapidAPIKey != "",
+		"apify":           cfg.ApifyAPIKey != "",
+		"job_alerts":      h.Mailer != nil,
+		"notifications":   (cfg.TelegramBotToken != "" && cfg.TelegramChatID != "") || cfg.SlackWebhookURL != "",
+	}
+}
+
+// SyncJobs endpoint for fetching jobs from all sources. By default the sync
+// runs in the background; with ?wait=true it runs synchronously and returns
+// the per-source results
+func (h *Handler) SyncJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Get the source from query parameters
+	query := newQueryParams(r)
+	source := query.String("source")
+	wait := query.Bool("wait", false)
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	log.Printf("Received sync request for source: %s (wait=%t)", source, wait)
+
+	// If source is provided and not in valid list, return error
+	if source == "" || (source != "all" && !services.IsValidSource(source)) {
+		writeError(w, r, fmt.Sprintf("Invalid source: %s", source), http.StatusBadRequest)
+		return
+	}
+
+	if !wait {
+		runID, err := h.SyncManager.Start(source)
+		if errors.Is(err, services.ErrSyncRunning) {
+			writeError(w, r, fmt.Sprintf("Sync of %s is already running", source), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, services.ErrSourceDisabled) {
+			writeError(w, r, fmt.Sprintf("Source is disabled: %s", source), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, services.ErrShuttingDown) {
+			writeError(w, r, "Server is shutting down, retry shortly", http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			log.Printf("Error starting sync for %s: %v", source, err)
+			writeError(w, r, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		response := map[string]interface{}{
+			"success":   true,
+			"sync_id":   runID,
+			"timestamp": time.Now().Format(time.RFC3339),
+		}
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	timeout := h.syncWaitTimeout()
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	// Give the response enough time to be written after a long sync
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(timeout + 10*time.Second)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Error extending write deadline for sync of %s: %v", source, err)
+	}
+
+	runID, results, err := h.SyncManager.RunAndWait(ctx, source)
+	if errors.Is(err, services.ErrSyncRunning) {
+		writeError(w, r, fmt.Sprintf("Sync of %s is already running", source), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, services.ErrSourceDisabled) {
+		writeError(w, r, fmt.Sprintf("Source is disabled: %s", source), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, services.ErrShuttingDown) {
+		writeError(w, r, "Server is shutting down, retry shortly", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		log.Printf("Error running sync for %s: %v", source, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	success := true
+	for _, result := range results {
+		if result.Error != "" {
+			success = false
+		}
+	}
+
+	if !success {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	response := map[string]interface{}{
+		"success":   success,
+		"sync_id":   runID,
+		"results":   results,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSyncRun returns the state, counts and duration of a sync run
+func (h *Handler) GetSyncRun(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	id := mux.Vars(r)["id"]
+	run, err := db.GetSyncRun(r.Context(), h.DB, id)
+	if err == sql.ErrNoRows {
+		writeError(w, r, fmt.Sprintf("Sync run not found: %s", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error querying sync run %s: %v", id, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"data":    run,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// AuditJobLanguage flags age and gender-coded language in the jobs not yet audited
+func (h *Handler) AuditJobLanguage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	audited, flagged, err := services.AuditJobLanguage(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error auditing job language: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"audited":   audited,
+		"flagged":   flagged,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetLanguageFlags returns open jobs whose postings have language flags,
+// optionally of one category (age or gender), for outreach to employers
+func (h *Handler) GetLanguageFlags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+	query := newQueryParams(r)
+
+	category := query.OneOf("category", analyzer.LanguageCategoryAge, analyzer.LanguageCategoryGender)
+	limit := query.Int("limit", 50, 1, 500)
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	jobs, err := db.FindFlaggedJobs(r.Context(), h.DB, category, limit)
+	if err != nil {
+		log.Printf("Error querying flagged jobs: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"count":     len(jobs),
+		"data":      jobs,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// BenchmarkSalaries compares open jobs' salaries to the typical offer for
+// their seniority and flags far-off ones, hiding them until verified
+func (h *Handler) BenchmarkSalaries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	report, err := services.BenchmarkSalaries(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error benchmarking salaries: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":    true,
+		"checked":    report.Checked,
+		"flagged":    report.Flagged,
+		"benchmarks": report.Benchmarks,
+		"timestamp":  time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSalaryFlags returns open jobs whose salary is flagged as suspiciously
+// low or high, for verification
+func (h *Handler) GetSalaryFlags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	query := newQueryParams(r)
+	limit := query.Int("limit", 50, 1, 500)
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	jobs, err := db.FindSalaryFlaggedJobs(r.Context(), h.DB, limit)
+	if err != nil {
+		log.Printf("Error querying salary flagged jobs: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"count":     len(jobs),
+		"data":      jobs,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetJobDetail returns a job with the source or enrichment step behind each
+// of its key fields, to debug data quality disputes. It answers 304 when the
+// copy named by If-None-Match is current.
+func (h *Handler) GetJobDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	loc, err := parseTimeZone(r)
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	job, err := db.GetJobDetail(r.Context(), h.DB, id)
+	if err == sql.ErrNoRows {
+		writeError(w, r, fmt.Sprintf("Job not found: %s", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error querying job %s: %v", id, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Details carry internal fields: clients may revalidate them but shared
+	// caches must not keep them
+	etag := jobDetailETag(job, loc)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if notModified(r, etag, time.Time{}) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      job.In(loc),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetJobRaw returns the raw item of the source response a job was mapped
+// from, to debug the mapping of a source. JSON items are embedded as is,
+// others (e.g. the HTML of scraped jobs) as a string.
+func (h *Handler) GetJobRaw(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	id := mux.Vars(r)["id"]
+	raw, err := db.GetJobRawData(r.Context(), h.DB, id)
+	if err == sql.ErrNoRows {
+		writeError(w, r, fmt.Sprintf("Job not found: %s", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error querying raw data of job %s: %v", id, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"id":          raw.ID,
+		"job_id":      raw.JobID,
+		"source":      raw.Source,
+		"date_gotten": raw.DateGotten,
+		"format":      "none",
+		"raw":         nil,
+	}
+	switch {
+	case raw.RawData == "":
+	case json.Valid([]byte(raw.RawData)):
+		data["format"], data["raw"] = "json", json.RawMessage(raw.RawData)
+	case strings.HasPrefix(strings.TrimSpace(raw.RawData), "<"):
+		data["format"], data["raw"] = "html", raw.RawData
+	default:
+		data["format"], data["raw"] = "text", raw.RawData
+	}
+
+	w.Header().Set("Cache-Control", "private, no-cache")
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      data,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// syncWaitTimeout returns how long a synchronous sync may run
+func (h *Handler) syncWaitTimeout() time.Duration {
+	if h.Config != nil && h.Config.SyncWaitTimeout > 0 {
+		return h.Config.SyncWaitTimeout
+	}
+	return 2 * time.Minute
+}
+
+// ExpireJobs archives all jobs whose expiry date has passed
+func (h *Handler) ExpireJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	archived, err := services.ExpireJobs(r.Context(), h.DB)
+	if err != nil {
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"archived":  archived,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// maxImportSize bounds the size of an import upload
+const maxImportSize = 10 << 20
+
+// ImportJobs imports jobs from an uploaded CSV or JSON file (multipart field
+// "file"). Optional fields: "format" (csv/json, defaults to the file
+// extension), "mapping" (JSON object of job field to column) and "source".
+func (h *Handler) ImportJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid upload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, r, "Missing file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	format := strings.ToLower(r.FormValue("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+	if format != services.ImportFormatCSV && format != services.ImportFormatJSON {
+		writeError(w, r, fmt.Sprintf("Invalid format: %s", format), http.StatusBadRequest)
+		return
+	}
+
+	mapping, err := services.ParseImportMapping(r.FormValue("mapping"))
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	source := r.FormValue("source")
+	if source == "" {
+		source = "import"
+	}
+
+	report, err := services.ImportJobs(r.Context(), h.DB, format, file, mapping, source)
+	if err != nil {
+		// Without a report the file itself could not be read
+		if report == nil {
+			writeBadRequest(w, r, err)
+			return
+		}
+		log.Printf("Error importing jobs: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Imported %s: %d rows, %d saved, %d skipped, %d invalid",
+		header.Filename, report.Rows, report.Saved, report.Skipped, len(report.Errors))
+
+	response := map[string]interface{}{
+		"success":   true,
+		"report":    report,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// IngestJobs saves a JSON array of jobs pushed by an external scraper in the
+// canonical Job schema, through the same filters and deduplication as synced
+// jobs
+func (h *Handler) IngestJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var jobs []models.Job
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&jobs); err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	report, err := services.IngestJobs(r.Context(), h.DB, jobs)
+	if err != nil {
+		// Without a report the batch itself was rejected
+		if report == nil {
+			writeBadRequest(w, r, err)
+			return
+		}
+		log.Printf("Error ingesting jobs: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Ingested %d jobs: %d saved, %d skipped, %d invalid",
+		report.Rows, report.Saved, report.Skipped, len(report.Errors))
+
+	response := map[string]interface{}{
+		"success":   true,
+		"report":    report,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// relevanceFilterTest is the body of POST /api/admin/filters/test
+type relevanceFilterTest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Track restricts the test to one track, whose keywords Include and
+	// Exclude replace to try a candidate rule
+	Track   string   `json:"track"`
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+// TestRelevanceFilter classifies a sample title and description with the
+// track rules applied on save, reporting the tracks it would be tagged with
+// and the keywords that decided each
+func (h *Handler) TestRelevanceFilter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var sample relevanceFilterTest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&sample); err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(sample.Title) == "" && strings.TrimSpace(sample.Description) == "" {
+		writeError(w, r, "Missing title or description", http.StatusBadRequest)
+		return
+	}
+
+	tracks := db.CurrentTracks()
+	if sample.Track != "" || sample.Include != nil || sample.Exclude != nil {
+		candidate := db.Track{Name: sample.Track}
+		for _, track := range tracks {
+			if track.Name == sample.Track {
+				candidate = track
+			}
+		}
+		if candidate.Rule.Include == nil && sample.Include == nil {
+			writeError(w, r, fmt.Sprintf("Invalid track: %s", sample.Track), http.StatusBadRequest)
+			return
+		}
+		include, exclude := candidate.Rule.Include, candidate.Rule.Exclude
+		if sample.Include != nil {
+			include = sample.Include
+		}
+		if sample.Exclude != nil {
+			exclude = sample.Exclude
+		}
+		candidate.Rule = analyzer.NewRelevanceRule(include, exclude)
+		tracks = []db.Track{candidate}
+	}
+
+	matched := []string{}
+	results := make(map[string]analyzer.RelevanceMatch, len(tracks))
+	for _, track := range tracks {
+		result := track.Rule.Classify(sample.Title, sample.Description)
+		if result.Relevant {
+			matched = append(matched, track.Name)
+		}
+		results[track.Name] = result
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"relevant":  len(matched) > 0,
+		"tracks":    matched,
+		"results":   results,
+		"rules":     tracks,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetRecentErrors returns the most recent errors recorded by each subsystem
+func (h *Handler) GetRecentErrors(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := newQueryParams(r)
+	limit := query.Int("limit", 10, 1, 500)
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"errors":    errorlog.Recent(limit),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetJobSkips explains why jobs were skipped by the save pipeline, looked up
+// by provider job ID and/or company name
+func (h *Handler) GetJobSkips(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	query := newQueryParams(r)
+	limit := query.Int("limit", 50, 1, 500)
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	skips, err := db.FindSkips(r.Context(), h.DB, query.String("job_id"), query.String("company"), limit)
+	if err != nil {
+		log.Printf("Error querying job skips: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"count":   len(skips),
+		"data":    skips,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSchemaChanges returns the latest changes of the response structure of
+// providers with the fields added and removed, filtered by source
+func (h *Handler) GetSchemaChanges(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	query := newQueryParams(r)
+	limit := query.Int("limit", 20, 1, 100)
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	changes, err := db.ListSchemaChanges(r.Context(), h.DB, query.String("source"), limit)
+	if err != nil {
+		log.Printf("Error querying provider schema changes: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"count":   len(changes),
+		"data":    changes,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListQueryTemplates returns the SQL templates of the admin query console
+// with their parameters
+func (h *Handler) ListQueryTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	templates, err := db.ListQueryTemplates()
+	if err != nil {
+		log.Printf("Error loading query templates: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"count":   len(templates),
+		"data":    templates,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// RunQueryTemplate runs a pre-approved SQL template read-only, taking its
+// parameters from the query string
+func (h *Handler) RunQueryTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	name := mux.Vars(r)["template_name"]
+	tmpl, err := db.GetQueryTemplate(name)
+	if errors.Is(err, db.ErrQueryTemplateNotFound) {
+		writeError(w, r, fmt.Sprintf("Query template not found: %s", name), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading query template %s: %v", name, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	values := make(map[string]string)
+	for key := range r.URL.Query() {
+		values[key] = r.URL.Query().Get(key)
+	}
+	args, err := tmpl.Args(values)
+	if err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid %v", err), http.StatusBadRequest)
+		return
+	}
+
+	timeout, maxRows := 5*time.Second, 1000
+	if h.Config != nil && h.Config.AdminQueryTimeout > 0 {
+		timeout = h.Config.AdminQueryTimeout
+	}
+	if h.Config != nil && h.Config.AdminQueryMaxRows > 0 {
+		maxRows = h.Config.AdminQueryMaxRows
+	}
+
+	result, err := db.RunQueryTemplate(r.Context(), h.DB, tmpl, args, maxRows, timeout)
+	if errors.Is(err, db.ErrQueryTimeout) {
+		writeError(w, r, fmt.Sprintf("Query %s timed out after %s", name, timeout), http.StatusGatewayTimeout)
+		return
+	}
+	if err != nil {
+		log.Printf("Error running query template %s: %v", name, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"template":  tmpl.Name,
+		"columns":   result.Columns,
+		"rows":      result.Rows,
+		"count":     len(result.Rows),
+		"truncated": result.Truncated,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetDedupeAudit returns the latest jobs dropped by dedupe next to the job
+// they were matched to, filtered by rule and by a maximum similarity score
+func (h *Handler) GetDedupeAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	query := newQueryParams(r)
+	limit := query.Int("limit", 50, 1, 500)
+	rule := query.OneOf("rule", db.DedupeRuleDuplicate, db.DedupeRuleRetitled)
+
+	var maxScore float64
+	if s := query.String("max_score"); s != "" {
+		parsed, err := strconv.ParseFloat(s, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			query.Invalid("max_score", s, "must be a number above 0 and at most 1")
+		}
+		maxScore = parsed
+	}
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	audits, err := db.FindDedupes(r.Context(), h.DB, rule, maxScore, limit)
+	if err != nil {
+		log.Printf("Error querying dedupe audit: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"count":   len(audits),
+		"data":    audits,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSyncStatus returns whether every sync source is enabled and its last
+// run, saved count, last error and next scheduled run
+func (h *Handler) GetSyncStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	loc, err := parseTimeZone(r)
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	logs, err := db.GetLatestSyncLogs(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error querying sync logs: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	schedule, err := db.GetScheduleInfo(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error querying schedule info: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	nextRuns := make(map[string]time.Time)
+	for _, info := range schedule {
+		if info.NextRunTime != nil {
+			nextRuns[info.APIName] = *info.NextRunTime
+		}
+	}
+	// Prefer the live scheduler over the persisted next run times
+	if h.Scheduler != nil {
+		for _, state := range h.Scheduler.State() {
+			nextRuns[state.Source] = state.NextRun
+		}
+	}
+
+	breakers := h.JobFetcher.CircuitStates()
+
+	var sources []map[string]interface{}
+	for _, source := range services.Sources() {
+		circuit, ok := breakers[source]
+		if !ok {
+			circuit = fetcher.CircuitState{State: fetcher.CircuitClosed}
+		}
+		status := map[string]interface{}{
+			"source":  source,
+			"enabled": services.SourceEnabled(h.Config, source),
+			"circuit": circuit,
+		}
+		if summary, ok := logs[source]; ok {
+			status["last_run_time"] = summary.LastRunTime.In(loc).Format(time.RFC3339)
+			status["last_status"] = summary.LastStatus
+			status["last_job_count"] = summary.LastJobCount
+			if summary.LastErrorTime != nil {
+				status["last_error"] = summary.LastError
+				status["last_error_time"] = summary.LastErrorTime.In(loc).Format(time.RFC3339)
+			}
+		}
+		if next, ok := nextRuns[source]; ok {
+			status["next_run_time"] = next.In(loc).Format(time.RFC3339)
+		}
+		sources = append(sources, status)
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"sources":   sources,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetLogLevel returns the current minimum log level
+func (h *Handler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := map[string]interface{}{
+		"success":   true,
+		"level":     logging.Level(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetLogLevel changes the minimum log level without a restart, e.g. to
+// enable debug logs during an incident. Takes the level from the "level"
+// query parameter.
+func (h *Handler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	previous := logging.Level()
+	if err := logging.SetLevel(r.URL.Query().Get("level")); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+	log.Printf("Log level changed from %s to %s", previous, logging.Level())
+
+	response := map[string]interface{}{
+		"success":   true,
+		"level":     logging.Level(),
+		"previous":  previous,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetDigest returns the health digest of the job board: source freshness,
+// data quality, quota usage, queue depths and recent errors in one snapshot
+func (h *Handler) GetDigest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	digest, err := services.BuildDigest(r.Context(), h.DB, h.Config)
+	if err != nil {
+		log.Printf("Error building digest: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      digest,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSchedulerState returns the live scheduler state, or the persisted
+// schedule when the scheduler is disabled
+func (h *Handler) GetSchedulerState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	response := map[string]interface{}{
+		"success":   true,
+		"enabled":   h.Scheduler != nil,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	if h.Scheduler != nil {
+		response["sources"] = h.Scheduler.State()
+	} else {
+		schedule, err := db.GetScheduleInfo(r.Context(), h.DB)
+		if err != nil {
+			log.Printf("Error querying schedule info: %v", err)
+			writeError(w, r, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		response["sources"] = schedule
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTasks returns the background tasks in flight, such as syncs started by
+// requests or the scheduler
+func (h *Handler) GetTasks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	tasks := h.Tasks.Running()
+	response := map[string]interface{}{
+		"success":   true,
+		"count":     len(tasks),
+		"data":      tasks,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// jobFilterParams lists the query parameters that filter job listings
+var jobFilterParams = []string{"q", "source", "apply_method", "seniority", "assessment", "stack", "track", "language", "is_remote", "include_expired", "include_duplicates", "include_hidden"}
+
+// jobCompanyDetails selects the enriched details of a job's company: those of
+// its own domain or else those of its company, so jobs without a URL have
+// them too. Each key is looked up through its own index.
+const jobCompanyDetails = `
+			FROM (
+				SELECT cd.*, 0 AS preference FROM company_details cd WHERE cd.domain = jobs.company_domain
+				UNION ALL
+				SELECT cd.*, 1 FROM company_details cd WHERE cd.company_id = jobs.company_id
+			) cd
+			ORDER BY cd.preference, cd.updated_at DESC
+			LIMIT 1)`
+
+// jobCompanyColumn selects the enriched details of a job's company as JSON,
+// added to job listings requested with ?expand=company. A subquery keeps the
+// unqualified filter and sort columns unambiguous.
+const jobCompanyColumn = `(
+			SELECT json_build_object(
+				'domain', cd.domain, 'name', cd.name, 'logo_url', cd.logo_url,
+				'description', cd.description, 'theme_color', cd.theme_color,
+				'industries', COALESCE(cd.industries, '[]'::jsonb), 'links', COALESCE(cd.links, '[]'::jsonb),
+				'source', cd.source)` + jobCompanyDetails
+
+// parseJobExpand reports whether a job listing should include company
+// details; "company" is the only supported expansion
+func parseJobExpand(r *http.Request) (bool, error) {
+	expand := r.URL.Query().Get("expand")
+	switch expand {
+	case "":
+		return false, nil
+	case "company":
+		return true, nil
+	default:
+		return false, fmt.Errorf("Invalid expand: %s", expand)
+	}
+}
+
+// jobCompanySummaryColumn selects a compact summary of a job's company as
+// JSON, added as company_summary to job listings requested with
+// ?include=company so cards need no lookup per company. The size of a
+// company is the number of its open jobs; its headcount is not known.
+const jobCompanySummaryColumn = `(
+			SELECT json_build_object(
+				'logo_url', cd.logo_url, 'industry', cd.industries->>0,
+				'open_jobs', ` + db.OpenJobsOfCompanyDetails + `)` + jobCompanyDetails
+
+// parseStackList splits a comma-separated stack filter into its tags
+func parseStackList(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseJobInclude reports whether a job listing should include the company
+// summary; "company" is the only supported inclusion
+func parseJobInclude(r *http.Request) (bool, error) {
+	include := r.URL.Query().Get("include")
+	switch include {
+	case "":
+		return false, nil
+	case "company":
+		return true, nil
+	default:
+		return false, fmt.Errorf("Invalid include: %s", include)
+	}
+}
+
+// parseTimeZone returns the zone to show the dates of a response in, chosen
+// with ?tz= (e.g. Africa/Lagos); dates are in UTC by default
+func parseTimeZone(r *http.Request) (*time.Location, error) {
+	query := newQueryParams(r)
+	loc := query.Location("tz", time.UTC)
+	return loc, query.Err()
 }
 
-// NewHandler creates a new Handler instance
-func NewHandler(DB *sql.DB, jobFetcher *fetcher.JobFetcher) *Handler {
-	return &Handler{
-		DB:         DB,
-		JobFetcher: jobFetcher,
+// defaultSnippetLength is the length of description snippets when
+// DESCRIPTION_SNIPPET_LENGTH is not configured
+const defaultSnippetLength = 280
+
+// Description modes of job listings, chosen with ?description=
+const (
+	descriptionFull    = "full"
+	descriptionSnippet = "snippet"
+	descriptionNone    = "none"
+	// descriptionHTML adds the sanitized formatted description, where the
+	// source gave one, as description_html
+	descriptionHTML = "html"
+)
+
+// parseJobDescription returns how a job listing should render descriptions:
+// in full (the default), as a snippet, in full with its HTML or not at all
+func parseJobDescription(r *http.Request) (string, error) {
+	mode := r.URL.Query().Get("description")
+	switch mode {
+	case "":
+		return descriptionFull, nil
+	case descriptionFull, descriptionSnippet, descriptionNone, descriptionHTML:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("Invalid description: %s", mode)
+	}
+}
+
+// snippetLength returns the configured length of description snippets
+func (h *Handler) snippetLength() int {
+	if h.Config != nil && h.Config.DescriptionSnippetLength > 0 {
+		return h.Config.DescriptionSnippetLength
+	}
+	return defaultSnippetLength
+}
+
+// jobSorts maps the accepted sort values to their ORDER BY clause
+var jobSorts = map[string]string{
+	"newest":  "posted_at DESC",
+	"oldest":  "posted_at ASC",
+	"title":   "title ASC, posted_at DESC",
+	"company": "company ASC, posted_at DESC",
+}
+
+// jobSearchVector is the full-text document searched by q, matching the
+// jobs_search_idx expression index
+const jobSearchVector = "to_tsvector('english', title || ' ' || company || ' ' || COALESCE(description, ''))"
+
+const (
+	// maxSearchLength bounds the q filter of job listings
+	maxSearchLength = 200
+	// maxSourceLength bounds the source filter of job listings
+	maxSourceLength = 100
+)
+
+// likeEscaper escapes LIKE metacharacters so only "*" acts as a wildcard in q
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// buildJobFilters turns the job listing query parameters into a WHERE clause
+// and its positional arguments
+func buildJobFilters(r *http.Request) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	query := newQueryParams(r)
+
+	// Expired jobs are hidden unless explicitly requested
+	if !query.Bool("include_expired", false) {
+		conditions = append(conditions, "(exp_date IS NULL OR exp_date > NOW())")
+	}
+
+	// Jobs hidden by an admin are only listed to admins asking for them
+	if !query.Bool("include_hidden", false) || !isAdminRequest(r.Context()) {
+		conditions = append(conditions, "NOT hidden")
+	}
+
+	// Only one job per fingerprint is listed unless duplicates are requested
+	if !query.Bool("include_duplicates", false) {
+		conditions = append(conditions, "NOT "+db.DuplicateOfListedJob)
+	}
+
+	if applyMethod := query.OneOf("apply_method", analyzer.ApplyMethods...); applyMethod != "" {
+		args = append(args, applyMethod)
+		conditions = append(conditions, fmt.Sprintf("apply_method = $%d", len(args)))
+	}
+
+	if seniority := query.OneOf("seniority", analyzer.Seniorities...); seniority != "" {
+		args = append(args, seniority)
+		conditions = append(conditions, fmt.Sprintf("seniority = $%d", len(args)))
+	}
+
+	if assessment := query.OneOf("assessment", analyzer.Assessments...); assessment != "" {
+		args = append(args, assessment)
+		conditions = append(conditions, fmt.Sprintf("$%d = ANY(assessments)", len(args)))
+	}
+
+	if track := query.Valid("track", db.IsTrack, "a track listed by /api/tracks"); track != "" {
+		args = append(args, track)
+		conditions = append(conditions, fmt.Sprintf("$%d = ANY(tracks)", len(args)))
+	}
+
+	// Language of the description, e.g. language=en; undetected ones match none
+	if language := query.OneOf("language", analyzer.Languages...); language != "" {
+		args = append(args, language)
+		conditions = append(conditions, fmt.Sprintf("language = $%d", len(args)))
+	}
+
+	// Jobs using every listed tool, e.g. stack=go1.22,postgres
+	if stack := parseStackList(query.String("stack")); len(stack) > 0 {
+		valid := true
+		for _, tag := range stack {
+			if !analyzer.IsStack(tag) {
+				query.Invalid("stack", tag, "must be comma separated stack tags listed by /api/jobs/stack")
+				valid = false
+			}
+		}
+		if valid {
+			args = append(args, db.Array(stack))
+			conditions = append(conditions, fmt.Sprintf("stack @> $%d", len(args)))
+		}
+	}
+
+	// Sources are free-form since imports and ingested jobs name their own
+	if source := query.MaxLength("source", maxSourceLength); source != "" {
+		args = append(args, source)
+		conditions = append(conditions, fmt.Sprintf("source = $%d", len(args)))
+	}
+
+	if isRemote := query.OptionalBool("is_remote"); isRemote != nil {
+		args = append(args, *isRemote)
+		conditions = append(conditions, fmt.Sprintf("is_remote = $%d", len(args)))
+	}
+
+	// q is a full-text search, or a title/company pattern when it contains "*"
+	if q := strings.TrimSpace(query.MaxLength("q", maxSearchLength)); q != "" {
+		if strings.Contains(q, "*") {
+			args = append(args, strings.ReplaceAll(likeEscaper.Replace(q), "*", "%"))
+			conditions = append(conditions, fmt.Sprintf("(title ILIKE $%d OR company ILIKE $%d)", len(args), len(args)))
+		} else {
+			args = append(args, q)
+			conditions = append(conditions, fmt.Sprintf("%s @@ plainto_tsquery('english', $%d)", jobSearchVector, len(args)))
+		}
+	}
+
+	if err := query.Err(); err != nil {
+		return "", nil, err
+	}
+	if len(conditions) == 0 {
+		return "", args, nil
 	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
 }
-func (h *Handler) SetupRoutes(cfg *config.Config) *mux.Router {
-	r := mux.NewRouter()
 
-	// Public route - No authentication middleware
-	r.HandleFunc("/status", h.StatusCheck).Methods("GET")
+// jobPage is the position of a job listing page
+type jobPage struct {
+	// Limit is the page size, 0 for unlimited
+	Limit  int
+	Offset int
+}
 
-	// Create protected subrouter
-	protected := r.PathPrefix("/api").Subrouter()
+// buildJobPage returns the ORDER BY and LIMIT/OFFSET clauses of a job
+// listing. Without a limit the page size defaults to the tier maximum. The
+// offset comes from offset or from a cursor signed with secret.
+func buildJobPage(r *http.Request, args []interface{}, secret string) (string, []interface{}, jobPage, error) {
+	var page jobPage
+	query := newQueryParams(r)
 
-	// Apply middleware chain to the protected subrouter
-	protected.Use(LoggingMiddleware)
-	protected.Use(APIKeyAuthMiddleware(cfg))
-	protected.Use(SecurityHeadersMiddleware)
-	protected.Use(CORSMiddleware(cfg.AllowedOrigins))
+	sorts := make([]string, 0, len(jobSorts))
+	for name := range jobSorts {
+		sorts = append(sorts, name)
+	}
+	sort.Strings(sorts)
+	order := jobSorts["newest"]
+	if name := query.OneOf("sort", sorts...); name != "" {
+		order = jobSorts[name]
+	}
+	clause := " ORDER BY " + order
 
-	// Add protected routes to the subrouter with middleware already applied
-	protected.HandleFunc("/jobs", h.GetAllJobs).Methods("GET")
+	page.Limit = query.Int("limit", tierLimitsFrom(r.Context()).MaxPageSize, 1, 0)
+	page.Offset = query.Int("offset", 0, 0, 0)
+	if err := query.Err(); err != nil {
+		return "", nil, page, err
+	}
 
-	// Create a subrouter specifically for /jobs/sync with APIKeyAuthSimpleMiddleware
-	jobSyncRouter := r.PathPrefix("/api/jobs/sync").Subrouter()
-	jobSyncRouter.Use(LoggingMiddleware)
-	jobSyncRouter.Use(APIKeyAuthSimpleMiddleware(cfg))
-	jobSyncRouter.Use(SecurityHeadersMiddleware)
-	jobSyncRouter.Use(CORSMiddleware(cfg.AllowedOrigins))
-	jobSyncRouter.HandleFunc("", h.SyncJobs).Methods("POST")
+	if page.Limit > 0 {
+		args = append(args, page.Limit)
+		clause += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if c := query.String("cursor"); c != "" {
+		if query.Has("offset") {
+			return "", nil, page, fmt.Errorf("Use either cursor or offset")
+		}
+		cursor, err := decodeCursor(secret, c, jobQueryHash(query.values), time.Now())
+		if err != nil {
+			return "", nil, page, err
+		}
+		page.Offset = cursor.Offset
+	}
+	if page.Offset > 0 {
+		args = append(args, page.Offset)
+		clause += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
 
-	return r
+	return clause, args, page, nil
 }
 
-// StatusCheck returns a simple API status
-func (h *Handler) StatusCheck(w http.ResponseWriter, r *http.Request) {
+// countJobs returns the number of jobs matching the request filters. The
+// returned status is the HTTP status to report on error.
+func (h *Handler) countJobs(r *http.Request) (int, int, error) {
+	where, args, err := buildJobFilters(r)
+	if err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+
+	var count int
+	if err := h.DB.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM jobs"+where, args...).Scan(&count); err != nil {
+		log.Printf("Error counting jobs: %v", err)
+		return 0, http.StatusInternalServerError, errors.New("Internal server error")
+	}
+	return count, http.StatusOK, nil
+}
+
+// HeadJobs answers HEAD /api/jobs with the number of matching jobs in the
+// X-Total-Count header and no body
+func (h *Handler) HeadJobs(w http.ResponseWriter, r *http.Request) {
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+	count, status, err := h.countJobs(r)
+	if err != nil {
+		writeError(w, r, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(count))
+	w.WriteHeader(http.StatusOK)
+}
+
+// CountJobs returns the number of jobs matching the request filters
+func (h *Handler) CountJobs(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	count, status, err := h.countJobs(r)
+	if err != nil {
+		writeError(w, r, err.Error(), status)
+		return
+	}
 
+	w.Header().Set("X-Total-Count", strconv.Itoa(count))
 	response := map[string]interface{}{
-		"status":    "ok",
+		"success":   true,
+		"count":     count,
 		"timestamp": time.Now().Format(time.RFC3339),
-		"message":   "API is running",
 	}
-
 	json.NewEncoder(w).Encode(response)
 }
 
-// SyncJobs endpoint for fetching jobs from all sources
-func (h *Handler) SyncJobs(w http.ResponseWriter, r *http.Request) {
+// stackCount is the number of jobs using a stack tag
+type stackCount struct {
+	Tag  string `json:"tag"`
+	Jobs int    `json:"jobs"`
+}
+
+// ListTracks returns the technologies jobs are collected for, with the
+// keywords tagging a job with each, for the track filter of job searches
+func (h *Handler) ListTracks(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Get the source from query parameters
-	source := r.URL.Query().Get("source")
+	tracks := db.CurrentTracks()
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      tracks,
+		"count":     len(tracks),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
 
-	log.Printf("Received sync request for source: %s", source)
+// GetStackFacet returns the number of jobs matching the request filters per
+// stack tag, the most used first, for the stack filter of job searches
+func (h *Handler) GetStackFacet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
 
-	// Allowed sources
-	validSources := map[string]bool{
-		"jsearch":        true,
-		"indeed":         true,
-		"linkedin":       true,
-		"apify_linkedin": true,
+	where, args, err := buildJobFilters(r)
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
 	}
 
-	// If source is provided and not in valid list, return error
-	if source == "" || !validSources[source] {
-		http.Error(w, fmt.Sprintf("Invalid source: %s", source), http.StatusBadRequest)
+	rows, err := h.DB.QueryContext(r.Context(), `
+		SELECT tag, COUNT(*) FROM jobs CROSS JOIN LATERAL unnest(stack) AS tag`+where+`
+		GROUP BY tag ORDER BY COUNT(*) DESC, tag`, args...)
+	if err != nil {
+		log.Printf("Error counting job stacks: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	defer rows.Close()
 
-	go func() {
-		// Run sync jobs based on source or run all if source is empty
-		if source == "jsearch" {
-			services.FetchAndSaveJSearch(h.JobFetcher, h.DB)
-		}
-		if source == "indeed" {
-			services.FetchAndSaveIndeed(h.JobFetcher, h.DB)
-		}
-		if source == "linkedin" {
-			services.FetchAndSaveLinkedIn(h.JobFetcher, h.DB)
-		}
-		if source == "apify_linkedin" {
-			services.FetchAndSaveApifyLinkedIn(h.JobFetcher, h.DB)
+	counts := []stackCount{}
+	for rows.Next() {
+		var c stackCount
+		if err := rows.Scan(&c.Tag, &c.Jobs); err != nil {
+			log.Printf("Error scanning job stack: %v", err)
+			writeError(w, r, "Internal server error", http.StatusInternalServerError)
+			return
 		}
-	}()
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error counting job stacks: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
 
 	response := map[string]interface{}{
 		"success":   true,
+		"count":     len(counts),
+		"data":      counts,
 		"timestamp": time.Now().Format(time.RFC3339),
 	}
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetAllJobs returns all jobs from the database
-func (h *Handler) GetAllJobs(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// jobListing is a validated job listing request
+type jobListing struct {
+	// where and filterArgs select the matching jobs
+	where      string
+	filterArgs []interface{}
+	// pageClause and args sort and page them, args extending filterArgs
+	pageClause string
+	args       []interface{}
+	page       jobPage
+
+	expandCompany   bool
+	includeCompany  bool
+	descriptionMode string
+	// loc is the zone of the dates of the jobs
+	loc *time.Location
+}
+
+// parseJobListing validates the filters, sort, page and shape of a job
+// listing request
+func (h *Handler) parseJobListing(r *http.Request) (jobListing, error) {
+	var listing jobListing
+	var err error
+	listing.where, listing.filterArgs, err = buildJobFilters(r)
+	if err != nil {
+		return listing, err
+	}
+	listing.pageClause, listing.args, listing.page, err = buildJobPage(r, listing.filterArgs, h.cursorSecret())
+	if err != nil {
+		return listing, err
+	}
+	if listing.expandCompany, err = parseJobExpand(r); err != nil {
+		return listing, err
+	}
+	if listing.includeCompany, err = parseJobInclude(r); err != nil {
+		return listing, err
+	}
+	if listing.descriptionMode, err = parseJobDescription(r); err != nil {
+		return listing, err
+	}
+	listing.loc, err = parseTimeZone(r)
+	return listing, err
+}
+
+// listJobs returns the jobs matching the request filters, sorted and paged,
+// and the cursor of the next page when the page is full. The returned status
+// is the HTTP status to report on error.
+func (h *Handler) listJobs(r *http.Request) ([]map[string]interface{}, string, int, error) {
+	listing, err := h.parseJobListing(r)
+	if err != nil {
+		return nil, "", http.StatusBadRequest, err
+	}
+
+	var jobs []map[string]interface{}
+	nextCursor, err := h.scanJobs(r, listing, func(job map[string]interface{}) error {
+		jobs = append(jobs, job)
+		return nil
+	})
+	if err != nil {
+		return nil, "", http.StatusInternalServerError, err
+	}
+	return jobs, nextCursor, http.StatusOK, nil
+}
+
+// scanJobs passes the jobs of listing to emit as their rows are scanned,
+// stopping at the first error of emit, and returns the cursor of the next
+// page when the page is full
+func (h *Handler) scanJobs(r *http.Request, listing jobListing, emit func(job map[string]interface{}) error) (string, error) {
+	columns := ""
+	if listing.descriptionMode == descriptionHTML {
+		columns += ", COALESCE(description_html, '')"
+	}
+	if listing.expandCompany {
+		columns += ", " + jobCompanyColumn
+	}
+	if listing.includeCompany {
+		columns += ", " + jobCompanySummaryColumn
+	}
 
 	// Query all jobs from the database
-	rows, err := h.DB.Query(`
+	rows, err := h.DB.QueryContext(r.Context(), `
 		SELECT 
 			id, job_id, title, company, company_url, company_logo, location, description,
-			url, salary, posted_at, job_type, is_remote, source
-		FROM jobs
-		ORDER BY posted_at DESC
-	`)
+			url, CASE WHEN salary_flag IS NULL THEN salary END, posted_at, job_type, is_remote, source,
+			COALESCE(word_count, 0), COALESCE(reading_time_minutes, 0), COALESCE(apply_method, ''),
+			COALESCE(seniority, ''), COALESCE(assessments, '{}'), COALESCE(stack, '{}'),
+			COALESCE(tracks, '{}'), COALESCE(language, '')`+columns+`
+		FROM jobs`+listing.where+listing.pageClause, listing.args...)
 
 	if err != nil {
 		log.Printf("Error querying jobs: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		return "", errors.New("Internal server error")
 	}
 	defer rows.Close()
 
 	// Parse results
-	var jobs []map[string]interface{}
+	count := 0
 	for rows.Next() {
 		var (
 			id          string
@@ -152,12 +1763,35 @@ func (h *Handler) GetAllJobs(w http.ResponseWriter, r *http.Request) {
 			jobType     sql.NullString
 			isRemote    bool
 			source      string
+			wordCount   int
+			readingTime int
+			applyMethod string
+			seniority   string
+			assessments []string
+			stack       []string
+			tracks      []string
+			language    string
 		)
 
-		err := rows.Scan(
+		var companyDetails, companySummary []byte
+		dest := []interface{}{
 			&id, &jobID, &title, &company, &companyURL, &companyLogo, &location, &description,
 			&url, &salary, &postedAt, &jobType, &isRemote, &source,
-		)
+			&wordCount, &readingTime, &applyMethod, &seniority, db.ScanArray(&assessments), db.ScanArray(&stack),
+			db.ScanArray(&tracks), &language,
+		}
+		var descriptionMarkup string
+		if listing.descriptionMode == descriptionHTML {
+			dest = append(dest, &descriptionMarkup)
+		}
+		if listing.expandCompany {
+			dest = append(dest, &companyDetails)
+		}
+		if listing.includeCompany {
+			dest = append(dest, &companySummary)
+		}
+
+		err := rows.Scan(dest...)
 
 		if err != nil {
 			log.Printf("Error scanning job row: %v", err)
@@ -172,7 +1806,29 @@ func (h *Handler) GetAllJobs(w http.ResponseWriter, r *http.Request) {
 			"company":   company,
 			"is_remote": isRemote,
 			"source":    source,
-			"posted_at": postedAt.Format(time.RFC3339),
+			"posted_at": postedAt.In(listing.loc).Format(time.RFC3339),
+
+			"word_count":           wordCount,
+			"reading_time_minutes": readingTime,
+		}
+
+		if applyMethod != "" {
+			job["apply_method"] = applyMethod
+		}
+		if seniority != "" {
+			job["seniority"] = seniority
+		}
+		if len(assessments) > 0 {
+			job["assessments"] = assessments
+		}
+		if len(stack) > 0 {
+			job["stack"] = stack
+		}
+		if len(tracks) > 0 {
+			job["tracks"] = tracks
+		}
+		if language != "" {
+			job["language"] = language
 		}
 
 		// Add nullable fields only if they have values
@@ -186,7 +1842,17 @@ func (h *Handler) GetAllJobs(w http.ResponseWriter, r *http.Request) {
 			job["location"] = location.String
 		}
 		if description.Valid {
-			job["description"] = description.String
+			switch listing.descriptionMode {
+			case descriptionFull, descriptionHTML:
+				job["description"] = description.String
+			case descriptionSnippet:
+				job["description"] = analyzer.Snippet(description.String, h.snippetLength())
+			}
+		}
+		// Sanitized again on the way out like job details, so rows stored
+		// before a policy change are served with the current one
+		if markup := analyzer.SanitizeHTML(descriptionMarkup); markup != "" {
+			job["description_html"] = markup
 		}
 		if url.Valid {
 			job["url"] = url.String
@@ -198,15 +1864,302 @@ func (h *Handler) GetAllJobs(w http.ResponseWriter, r *http.Request) {
 			job["job_type"] = jobType.String
 		}
 
-		jobs = append(jobs, job)
+		// Expanded jobs always carry a company, null when it was never enriched
+		if listing.expandCompany {
+			job["company_details"] = nil
+			if len(companyDetails) > 0 {
+				job["company_details"] = json.RawMessage(companyDetails)
+			}
+		}
+		if listing.includeCompany {
+			job["company_summary"] = nil
+			if len(companySummary) > 0 {
+				job["company_summary"] = json.RawMessage(companySummary)
+			}
+		}
+
+		if err := emit(job); err != nil {
+			return "", err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error reading job rows: %v", err)
+		return "", errors.New("Internal server error")
+	}
+
+	// A full page may be followed by another, reached through a signed cursor
+	var nextCursor string
+	if listing.page.Limit > 0 && count == listing.page.Limit {
+		nextCursor = encodeCursor(h.cursorSecret(), pageCursor{
+			Offset:  listing.page.Offset + listing.page.Limit,
+			Query:   jobQueryHash(r.URL.Query()),
+			Expires: time.Now().Add(cursorTTL).Unix(),
+		})
+	}
+	return nextCursor, nil
+}
+
+// GetAllJobs returns the jobs matching the request filters, sorted and
+// paged, or 304 when the client's copy is current. Jobs are written as they
+// are scanned, so large pages are not held in memory.
+func (h *Handler) GetAllJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	listing, err := h.parseJobListing(r)
+	if err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	etag, lastModified, err := h.jobsVersion(r, listing)
+	if err != nil {
+		writeError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.setCacheHeaders(w, etag, lastModified)
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// The ETag names the listing, so a cached body is never stale for it
+	cache, key := h.responses(), "jobs:"+etag
+	if cache != nil {
+		if body, ok := cache.Get(key); ok {
+			w.Write(body)
+			return
+		}
+	}
+	out, body := io.Writer(w), &cappedBuffer{limit: maxCachedResponse}
+	if cache != nil {
+		out = io.MultiWriter(w, body)
+	}
+
+	stream := &jobStream{w: out}
+	nextCursor, err := h.scanJobs(r, listing, stream.Write)
+	if err != nil {
+		if !stream.started {
+			writeError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// The 200 status is already sent: leave the document unterminated so
+		// clients fail to parse it rather than take the page as complete
+		log.Printf("Error streaming jobs after %d rows: %v", stream.count, err)
+		return
+	}
+
+	if err := stream.Close(nextCursor); err != nil {
+		log.Printf("Error streaming jobs: %v", err)
+		return
+	}
+	if cache != nil && !body.overflow {
+		cache.Set(key, body.Bytes())
+	}
+}
+
+// ListCompanies returns the enriched companies with their open job counts,
+// those with the most open jobs first
+func (h *Handler) ListCompanies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+	query := newQueryParams(r)
+
+	limit := tierLimitsFrom(r.Context()).MaxPageSize
+	if limit <= 0 {
+		limit = 100
+	}
+	limit = query.Int("limit", limit, 1, 0)
+	offset := query.Int("offset", 0, 0, 0)
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	companies, err := db.ListCompanies(r.Context(), h.DB, limit, offset)
+	if err != nil {
+		log.Printf("Error querying companies: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
 	}
 
-	// Return JSON response
 	response := map[string]interface{}{
-		"success": true,
-		"count":   len(jobs),
-		"data":    jobs,
+		"success":   true,
+		"count":     len(companies),
+		"data":      companies,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetCompany returns a company by its domain
+func (h *Handler) GetCompany(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	id := strings.ToLower(mux.Vars(r)["id"])
+	company, err := db.GetCompany(r.Context(), h.DB, id)
+	if err == sql.ErrNoRows {
+		writeError(w, r, fmt.Sprintf("Company not found: %s", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error querying company %s: %v", id, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      company,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Bounds of the months of posting history GetCompanyJobs returns
+const (
+	defaultCompanyHistoryMonths = 12
+	maxCompanyHistoryMonths     = 60
+)
+
+// GetCompanyJobs returns the current and past postings of a company, by its
+// ID, domain or name, with its open and total posting counts and the number
+// of jobs it posted each month
+func (h *Handler) GetCompanyJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+	query := newQueryParams(r)
+
+	limit := tierLimitsFrom(r.Context()).MaxPageSize
+	if limit <= 0 {
+		limit = 100
+	}
+	limit = query.Int("limit", limit, 1, 0)
+	offset := query.Int("offset", 0, 0, 0)
+	status := query.OneOf("status", db.PostingsOpen, db.PostingsClosed)
+	months := query.Int("months", defaultCompanyHistoryMonths, 1, maxCompanyHistoryMonths)
+	loc := query.Location("tz", time.UTC)
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	company, err := db.FindCompany(r.Context(), h.DB, id)
+	if err == sql.ErrNoRows {
+		writeError(w, r, fmt.Sprintf("Company not found: %s", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error querying company %s: %v", id, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	postings, err := db.ListCompanyPostings(r.Context(), h.DB, company.ID, status, limit, offset)
+	if err != nil {
+		log.Printf("Error querying jobs of company %d: %v", company.ID, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	open, total, err := db.CountCompanyPostings(r.Context(), h.DB, company.ID)
+	if err != nil {
+		log.Printf("Error counting jobs of company %d: %v", company.ID, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	history, err := db.GetCompanyPostingHistory(r.Context(), h.DB, company.ID, months)
+	if err != nil {
+		log.Printf("Error querying job history of company %d: %v", company.ID, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range postings {
+		postings[i].PostedAt = postings[i].PostedAt.In(loc)
+		if expDate := postings[i].ExpDate; expDate != nil {
+			local := expDate.In(loc)
+			postings[i].ExpDate = &local
+		}
+	}
+
+	response := map[string]interface{}{
+		"success":    true,
+		"company":    company,
+		"count":      len(postings),
+		"data":       postings,
+		"open_jobs":  open,
+		"total_jobs": total,
+		"history":    history,
+		"timestamp":  time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// PurgeSource removes the jobs a source ingested since a time, e.g. after it
+// returned corrupted data. Without a confirm token (or with dry_run=true) it
+// only reports what would be removed and the token confirming that purge.
+func (h *Handler) PurgeSource(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	req := services.PurgeRequest{Source: mux.Vars(r)["name"], Mode: services.PurgeModeQuarantine}
+
+	params := &queryParams{values: query}
+	if !params.Has("since") {
+		params.Invalid("since", "", "is required, an RFC 3339 time")
+	}
+	req.Since = params.Time("since", time.Time{})
+	req.Until = params.Time("until", time.Time{})
+	if !req.Until.IsZero() && !req.Since.IsZero() && !req.Until.After(req.Since) {
+		params.Invalid("until", query.Get("until"), "must be after since")
+	}
+	if mode := params.OneOf("mode", services.PurgeModeQuarantine, services.PurgeModeDelete); mode != "" {
+		req.Mode = mode
+	}
+	dryRun := params.Bool("dry_run", false)
+	if err := params.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	var secret string
+	if h.Config != nil {
+		secret = h.Config.CronAPIKey
+	}
+
+	result, err := services.PurgeSource(r.Context(), h.DB, req, secret, query.Get("confirm"), dryRun)
+	if errors.Is(err, services.ErrPurgeNotConfirmed) {
+		writeBadRequest(w, r, err)
+		return
+	}
+	if err != nil {
+		log.Printf("Error purging source %s: %v", req.Source, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
 	}
 
+	response := map[string]interface{}{
+		"success":   true,
+		"source":    req.Source,
+		"since":     req.Since.Format(time.RFC3339),
+		"mode":      req.Mode,
+		"dry_run":   result.DryRun,
+		"jobs":      result.Counts.Jobs,
+		"skips":     result.Counts.Skips,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if !req.Until.IsZero() {
+		response["until"] = req.Until.Format(time.RFC3339)
+	}
+	if result.ConfirmationToken != "" {
+		response["confirmation_token"] = result.ConfirmationToken
+	}
 	json.NewEncoder(w).Encode(response)
 }
@@ -2,16 +2,24 @@ package api
 
 import (
 	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/errorlog"
 	"Go9jaJobs/internal/fetcher"
+	"Go9jaJobs/internal/logging"
+	"Go9jaJobs/internal/services"
+	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,6 +30,19 @@ func setupMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
 	return db, mock
 }
 
+// expectJobsVersion expects the query of the validators of a job listing
+func expectJobsVersion(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\), MAX\\(updated_at\\) FROM jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "max"}).AddRow(2, time.Now()))
+}
+
+// expectJobsCompanyVersion expects the version query of a listing showing
+// company details, which also reads their latest update
+func expectJobsCompanyVersion(mock sqlmock.Sqlmock, companyUpdated time.Time) {
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\), MAX\\(updated_at\\), MAX\\(GREATEST\\( \\(SELECT cd.updated_at FROM company_details cd WHERE cd.domain = jobs.company_domain\\), (.+) WHERE cd.company_id = jobs.company_id\\)\\)\\) FROM jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "max", "company_max"}).AddRow(2, time.Now(), companyUpdated))
+}
+
 func TestStatusCheck(t *testing.T) {
 	// Create a new request
 	req, err := http.NewRequest("GET", "/api/status", nil)
@@ -70,7 +91,8 @@ func TestGetAllJobs(t *testing.T) {
 	columns := []string{
 		"id", "job_id", "title", "company", "company_url", "company_logo",
 		"location", "description", "url", "salary", "posted_at",
-		"job_type", "is_remote", "source",
+		"job_type", "is_remote", "source", "word_count", "reading_time_minutes", "apply_method", "seniority",
+		"assessments", "stack", "tracks", "language",
 	}
 
 	// Setup mock query expectations
@@ -79,16 +101,17 @@ func TestGetAllJobs(t *testing.T) {
 			"job-uuid-1", "job-id-1", "Golang Developer", "Company A",
 			"https://companya.com", "https://companya.com/logo.png",
 			"Lagos, Nigeria", "Description for job 1", "https://companya.com/jobs/1",
-			"$80K-$100K", time.Now(), "Full-time", true, "indeed",
+			"$80K-$100K", time.Now(), "Full-time", true, "indeed", 4, 1, "direct", "", nil, nil, nil, "en",
 		).
 		AddRow(
 			"job-uuid-2", "job-id-2", "Senior Go Engineer", "Company B",
 			"https://companyb.com", "https://companyb.com/logo.png",
 			"Remote", "Description for job 2", "https://companyb.com/jobs/2",
-			"$100K-$120K", time.Now(), "Contract", true, "linkedin",
+			"$100K-$120K", time.Now(), "Contract", true, "linkedin", 4, 1, "", "senior", []byte(`{take_home,pair_programming}`), []byte(`{go1.22,grpc}`), nil, "",
 		)
 
-	mock.ExpectQuery("^SELECT (.+) FROM jobs ORDER BY posted_at DESC$").WillReturnRows(rows)
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE \\(exp_date IS NULL OR exp_date > NOW\\(\\)\\) AND NOT hidden AND NOT EXISTS \\(SELECT 1 FROM job_duplicates d JOIN jobs canonical (.+)\\) ORDER BY posted_at DESC$").WillReturnRows(rows)
 
 	fetcher := fetcher.NewJobFetcher(&config.Config{}) // ✅
 	handler := NewHandler(db, fetcher)
@@ -117,6 +140,9 @@ func TestGetAllJobs(t *testing.T) {
 	assert.Equal(t, "Golang Developer", job1["title"])
 	assert.Equal(t, "Company A", job1["company"])
 	assert.Equal(t, "https://companya.com/logo.png", job1["company_logo"])
+	assert.Equal(t, float64(4), job1["word_count"])
+	assert.Equal(t, float64(1), job1["reading_time_minutes"])
+	assert.Equal(t, "direct", job1["apply_method"])
 
 	// Verify second job data
 	job2, ok := data[1].(map[string]interface{})
@@ -124,6 +150,13 @@ func TestGetAllJobs(t *testing.T) {
 	assert.Equal(t, "Senior Go Engineer", job2["title"])
 	assert.Equal(t, "Company B", job2["company"])
 	assert.Equal(t, "https://companyb.com/logo.png", job2["company_logo"])
+	assert.NotContains(t, job2, "apply_method")
+	assert.Equal(t, []interface{}{"take_home", "pair_programming"}, job2["assessments"])
+	assert.NotContains(t, job1, "assessments")
+	assert.Equal(t, []interface{}{"go1.22", "grpc"}, job2["stack"])
+	assert.NotContains(t, job1, "stack")
+	assert.Equal(t, "en", job1["language"])
+	assert.NotContains(t, job2, "language")
 
 	// Verify that all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -144,7 +177,8 @@ func TestGetAllJobsDBError(t *testing.T) {
 	fetcher := fetcher.NewJobFetcher(&config.Config{})
 
 	// Setup mock query to return an error
-	mock.ExpectQuery("^SELECT (.+) FROM jobs ORDER BY posted_at DESC$").
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE \\(exp_date IS NULL OR exp_date > NOW\\(\\)\\) AND NOT hidden AND NOT EXISTS \\(SELECT 1 FROM job_duplicates d JOIN jobs canonical (.+)\\) ORDER BY posted_at DESC$").
 		WillReturnError(sql.ErrConnDone)
 
 	// Create handler and call the function
@@ -158,63 +192,1721 @@ func TestGetAllJobsDBError(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestSetupRoutes(t *testing.T) {
-	// Create a mock DB and handler
-	mockDB, _, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("Failed to create mock: %v", err)
+func TestGetAllJobsRowError(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	columns := []string{
+		"id", "job_id", "title", "company", "company_url", "company_logo",
+		"location", "description", "url", "salary", "posted_at",
+		"job_type", "is_remote", "source", "word_count", "reading_time_minutes", "apply_method", "seniority",
+		"assessments", "stack", "tracks", "language",
 	}
-	defer mockDB.Close()
+	rows := sqlmock.NewRows(columns).
+		AddRow("job-uuid-1", "job-id-1", "Golang Developer", "Company A", nil, nil, nil, nil, nil, nil,
+			time.Now(), nil, true, "indeed", 0, 0, "", "", nil, nil, nil, "").
+		AddRow("job-uuid-2", "job-id-2", "Go Engineer", "Company B", nil, nil, nil, nil, nil, nil,
+			time.Now(), nil, false, "indeed", 0, 0, "", "", nil, nil, nil, "").
+		RowError(1, sql.ErrConnDone)
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs").WillReturnRows(rows)
 
-	// Create a test config
-	testCfg := &config.Config{
-		APIKey:         "test-api-key",
-		AllowedOrigins: []string{"*"},
+	req, err := http.NewRequest("GET", "/api/jobs", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.GetAllJobs(rr, req)
+
+	// Jobs already sent stay sent, but the document is left unterminated
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Golang Developer")
+	assert.Error(t, json.Unmarshal(rr.Body.Bytes(), &map[string]interface{}{}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllJobsIncludeExpired(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/jobs?include_expired=true", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	// No expiry condition should be applied
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE NOT hidden AND NOT EXISTS \\(SELECT 1 FROM job_duplicates d JOIN jobs canonical (.+)\\) ORDER BY posted_at DESC$").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.GetAllJobs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllJobsIncludeDuplicates(t *testing.T) {
+	req, err := http.NewRequest("GET", "/api/jobs?include_expired=true&include_duplicates=true", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	// Jobs linked as duplicates of another source's posting are listed too
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE NOT hidden ORDER BY posted_at DESC$").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.GetAllJobs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllJobsApplyMethodFilter(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND apply_method = \\$1 ORDER BY posted_at DESC$").
+		WithArgs("direct").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/jobs?apply_method=direct", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Unknown apply methods are rejected before querying
+	req, err = http.NewRequest("GET", "/api/jobs?apply_method=fax", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetAllJobsSeniorityFilter(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND seniority = \\$1 ORDER BY posted_at DESC$").
+		WithArgs("senior").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/jobs?seniority=senior", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, err = http.NewRequest("GET", "/api/jobs?seniority=wizard", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetAllJobsAssessmentFilter(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND \\$1 = ANY\\(assessments\\) ORDER BY posted_at DESC$").
+		WithArgs("take_home").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/jobs?assessment=take_home", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, err = http.NewRequest("GET", "/api/jobs?assessment=trial_day", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetAllJobsStackFilter(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND stack @> \\$1 ORDER BY posted_at DESC$").
+		WithArgs(`{"go1.22","postgres"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/jobs?stack=go1.22,%20Postgres", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, err = http.NewRequest("GET", "/api/jobs?stack=go1.22,react", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid stack: react")
+}
+
+func TestGetAllJobsTrackFilter(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND \\$1 = ANY\\(tracks\\) ORDER BY posted_at DESC$").
+		WithArgs("go").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, httptest.NewRequest("GET", "/api/jobs?track=go", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, httptest.NewRequest("GET", "/api/jobs?track=cobol", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid track: cobol")
+}
+
+func TestGetAllJobsLanguageFilter(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND language = \\$1 ORDER BY posted_at DESC$").
+		WithArgs("fr").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, httptest.NewRequest("GET", "/api/jobs?language=fr", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, httptest.NewRequest("GET", "/api/jobs?language=french", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid language: french")
+}
+
+func TestGetAllJobsTimeZone(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	columns := []string{
+		"id", "job_id", "title", "company", "company_url", "company_logo",
+		"location", "description", "url", "salary", "posted_at",
+		"job_type", "is_remote", "source", "word_count", "reading_time_minutes", "apply_method", "seniority",
+		"assessments", "stack", "tracks", "language",
+	}
+	postedAt := time.Date(2025, 3, 1, 23, 30, 0, 0, time.UTC)
+	jobRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(
+			"job-uuid-1", "job-id-1", "Golang Developer", "Company A", nil, nil, nil, nil, nil,
+			nil, postedAt.In(time.FixedZone("WAT", 3600)), nil, true, "indeed", 0, 0, "", "", nil, nil, nil, "",
+		)
+	}
+	for i := 0; i < 2; i++ {
+		expectJobsVersion(mock)
+		mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) ORDER BY posted_at DESC$").WillReturnRows(jobRows())
 	}
 
-	fetcher := fetcher.NewJobFetcher(&config.Config{}) // ✅
-	handler := NewHandler(mockDB, fetcher)
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	postedAtOf := func(url string) string {
+		rr := httptest.NewRecorder()
+		handler.GetAllJobs(rr, httptest.NewRequest("GET", url, nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response struct {
+			Data []struct {
+				PostedAt string `json:"posted_at"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		if assert.Len(t, response.Data, 1) {
+			return response.Data[0].PostedAt
+		}
+		return ""
+	}
 
-	// Setup router with config
-	router := handler.SetupRoutes(testCfg)
+	// Dates are in UTC unless the client asks for its zone
+	assert.Equal(t, "2025-03-01T23:30:00Z", postedAtOf("/api/jobs"))
+	assert.Equal(t, "2025-03-02T00:30:00+01:00", postedAtOf("/api/jobs?tz=Africa/Lagos"))
+	assert.NoError(t, mock.ExpectationsWereMet())
 
-	// Create a test server
-	server := httptest.NewServer(router)
-	defer server.Close()
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, httptest.NewRequest("GET", "/api/jobs?tz=Mars/Olympus", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid tz: Mars/Olympus")
+}
 
-	// Define the routes we expect to exist
-	expectedRoutes := []struct {
-		path        string
-		method      string
-		requireAuth bool
-	}{
-		{"/api/status", "GET", false},
-		{"/api/jobs", "GET", true},
+func TestListTracks(t *testing.T) {
+	db, _ := setupMockDB(t)
+	defer db.Close()
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	rr := httptest.NewRecorder()
+	handler.ListTracks(rr, httptest.NewRequest("GET", "/api/tracks", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data []struct {
+			Name string `json:"name"`
+			Rule struct {
+				Include []string `json:"include"`
+			} `json:"rule"`
+		} `json:"data"`
 	}
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, "go", response.Data[0].Name)
+	assert.Equal(t, []string{"go", "golang"}, response.Data[0].Rule.Include)
+}
 
-	for _, route := range expectedRoutes {
-		t.Run(fmt.Sprintf("%s %s", route.method, route.path), func(t *testing.T) {
-			req, err := http.NewRequest(route.method, server.URL+route.path, nil)
-			if err != nil {
-				t.Fatal(err)
-			}
+func TestGetStackFacet(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
 
-			// Set required headers if route requires auth
-			if route.requireAuth {
-				req.Header.Set("X-API-Key", testCfg.APIKey)
-				req.Header.Set("X-Timestamp", "1234567890")      // Dummy value
-				req.Header.Set("X-Signature", "dummy-signature") // Dummy value
-			}
+	mock.ExpectQuery("^SELECT tag, COUNT\\(\\*\\) FROM jobs CROSS JOIN LATERAL unnest\\(stack\\) AS tag WHERE (.+) AND is_remote = \\$1 GROUP BY tag").
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"tag", "count"}).AddRow("postgres", 12).AddRow("go1.22", 4))
 
-			client := &http.Client{}
-			resp, err := client.Do(req)
-			if err != nil {
-				t.Fatal(err)
-			}
-			defer resp.Body.Close()
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
 
-			// Assert the route is not returning 404
-			assert.NotEqual(t, http.StatusNotFound, resp.StatusCode)
-		})
+	req, err := http.NewRequest("GET", "/api/jobs/stack?is_remote=true", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetStackFacet(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Count int          `json:"count"`
+		Data  []stackCount `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Count)
+	assert.Equal(t, stackCount{Tag: "postgres", Jobs: 12}, response.Data[0])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllJobsExpandCompany(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	columns := []string{
+		"id", "job_id", "title", "company", "company_url", "company_logo",
+		"location", "description", "url", "salary", "posted_at",
+		"job_type", "is_remote", "source", "word_count", "reading_time_minutes", "apply_method", "seniority",
+		"assessments", "stack", "tracks", "language", "company_details",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow(
+			"job-uuid-1", "job-id-1", "Golang Developer", "Paystack", "https://paystack.com", "",
+			"Lagos", "", "", "", time.Now(), "", false, "indeed", 0, 0, "", "", nil, nil, nil, "",
+			[]byte(`{"domain":"paystack.com","industries":["Fintech"],"theme_color":"#011B33"}`),
+		).
+		AddRow(
+			"job-uuid-2", "job-id-2", "Go Engineer", "Unknown", "", "",
+			"Remote", "", "", "", time.Now(), "", true, "linkedin", 0, 0, "", "", nil, nil, nil, "", nil,
+		)
+
+	enriched := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	expectJobsCompanyVersion(mock, enriched)
+	mock.ExpectQuery("^SELECT (.+) FROM company_details cd WHERE cd.domain = jobs.company_domain UNION ALL (.+) WHERE cd.company_id = jobs.company_id \\) cd ORDER BY cd.preference, cd.updated_at DESC LIMIT 1\\) FROM jobs WHERE (.+) ORDER BY posted_at DESC$").
+		WillReturnRows(rows)
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/jobs?expand=company", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 2)
+	details := response.Data[0]["company_details"].(map[string]interface{})
+	assert.Equal(t, "#011B33", details["theme_color"])
+	assert.Equal(t, []interface{}{"Fintech"}, details["industries"])
+	assert.Contains(t, response.Data[1], "company_details")
+	assert.Nil(t, response.Data[1]["company_details"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Enriching a company changes the version of the listing, not only the
+	// jobs' updates
+	etag := rr.Header().Get("ETag")
+	expectJobsCompanyVersion(mock, enriched.Add(time.Hour))
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) ORDER BY posted_at DESC$").
+		WillReturnRows(sqlmock.NewRows(columns))
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	req.Header.Del("If-None-Match")
+
+	// Unknown expansions are rejected before querying
+	req, err = http.NewRequest("GET", "/api/jobs?expand=recruiter", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetAllJobsIncludeCompany(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	columns := []string{
+		"id", "job_id", "title", "company", "company_url", "company_logo",
+		"location", "description", "url", "salary", "posted_at",
+		"job_type", "is_remote", "source", "word_count", "reading_time_minutes", "apply_method", "seniority",
+		"assessments", "stack", "tracks", "language", "company_summary",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow(
+			"job-uuid-1", "job-id-1", "Golang Developer", "Paystack", "https://paystack.com", "",
+			"Lagos", "", "", "", time.Now(), "", false, "indeed", 0, 0, "", "", nil, nil, nil, "",
+			[]byte(`{"logo_url":"https://cdn.example.com/paystack.png","industry":"Fintech","open_jobs":4}`),
+		).
+		AddRow(
+			"job-uuid-2", "job-id-2", "Go Engineer", "Unknown", "", "",
+			"Remote", "", "", "", time.Now(), "", true, "linkedin", 0, 0, "", "", nil, nil, nil, "", nil,
+		)
+
+	expectJobsCompanyVersion(mock, time.Now())
+	mock.ExpectQuery("^SELECT (.+) 'open_jobs', \\( SELECT COUNT\\(\\*\\) FROM \\( SELECT id, exp_date FROM jobs WHERE company_id = cd.company_id AND NOT hidden UNION (.+) FROM company_details cd WHERE cd.domain = jobs.company_domain UNION ALL (.+) WHERE cd.company_id = jobs.company_id \\) cd ORDER BY cd.preference, cd.updated_at DESC LIMIT 1\\) FROM jobs WHERE (.+)$").
+		WillReturnRows(rows)
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/jobs?include=company", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, "Paystack", response.Data[0]["company"])
+	summary := response.Data[0]["company_summary"].(map[string]interface{})
+	assert.Equal(t, "Fintech", summary["industry"])
+	assert.Equal(t, float64(4), summary["open_jobs"])
+	assert.NotContains(t, response.Data[0], "company_details")
+	assert.Nil(t, response.Data[1]["company_summary"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, err = http.NewRequest("GET", "/api/jobs?include=recruiter", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetAllJobsDescriptionSnippet(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	columns := []string{
+		"id", "job_id", "title", "company", "company_url", "company_logo",
+		"location", "description", "url", "salary", "posted_at",
+		"job_type", "is_remote", "source", "word_count", "reading_time_minutes", "apply_method", "seniority",
+		"assessments", "stack", "tracks", "language",
+	}
+	description := "Join our payments team. You will build APIs in Go. We offer remote work and a learning budget."
+	jobRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(
+			"job-uuid-1", "job-id-1", "Golang Developer", "Paystack", "", "",
+			"Lagos", description, "", "", time.Now(), "", false, "indeed", 0, 0, "", "", nil, nil, nil, "",
+		)
+	}
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.Config = &config.Config{DescriptionSnippetLength: 60}
+
+	listDescription := func(mode string) (int, map[string]interface{}) {
+		req, err := http.NewRequest("GET", "/api/jobs?description="+mode, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handler.GetAllJobs(rr, req)
+
+		var response struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		if rr.Code != http.StatusOK {
+			return rr.Code, nil
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 1)
+		return rr.Code, response.Data[0]
+	}
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs").WillReturnRows(jobRows())
+	_, job := listDescription("snippet")
+	assert.Equal(t, "Join our payments team. You will build APIs in Go.", job["description"])
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs").WillReturnRows(jobRows())
+	_, job = listDescription("none")
+	assert.NotContains(t, job, "description")
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs").WillReturnRows(jobRows())
+	_, job = listDescription("full")
+	assert.Equal(t, description, job["description"])
+
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+), COALESCE\\(description_html, ''\\) FROM jobs").
+		WillReturnRows(sqlmock.NewRows(append(columns, "description_html")).AddRow(
+			"job-uuid-1", "job-id-1", "Golang Developer", "Paystack", "", "",
+			"Lagos", description, "", "", time.Now(), "", false, "indeed", 0, 0, "", "", nil, nil, nil, "",
+			// Stored before the current policy, the markup is sanitized on the way out
+			`<p>Join our payments team.</p><script>alert(1)</script>`,
+		))
+	_, job = listDescription("html")
+	assert.Equal(t, description, job["description"])
+	assert.Equal(t, "<p>Join our payments team.</p>", job["description_html"])
+
+	code, _ := listDescription("summary")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllJobsSearchSortAndPage(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	limits := QueryLimitsMiddleware(config.TierLimits{MaxPageSize: 50})
+
+	// Full-text search, sorted by title, paged
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND to_tsvector(.+) @@ plainto_tsquery\\('english', \\$1\\) ORDER BY title ASC, posted_at DESC LIMIT \\$2 OFFSET \\$3$").
+		WithArgs("backend engineer", 20, 40).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	req, err := http.NewRequest("GET", "/api/jobs?q=backend+engineer&sort=title&limit=20&offset=40", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	limits(http.HandlerFunc(handler.GetAllJobs)).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Patterns with "*" match title/company; the page size defaults to the tier maximum
+	expectJobsVersion(mock)
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE (.+) AND source = \\$1 AND is_remote = \\$2 AND \\(title ILIKE \\$3 OR company ILIKE \\$3\\) ORDER BY posted_at DESC LIMIT \\$4$").
+		WithArgs("jsearch", true, `go\_dev%`, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	req, err = http.NewRequest("GET", "/api/jobs?source=jsearch&is_remote=true&q=go_dev*", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	limits(http.HandlerFunc(handler.GetAllJobs)).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Unknown sorts and malformed booleans are rejected before querying
+	for _, query := range []string{"sort=salary", "is_remote=maybe"} {
+		req, err = http.NewRequest("GET", "/api/jobs?"+query, nil)
+		assert.NoError(t, err)
+		rr = httptest.NewRecorder()
+		handler.GetAllJobs(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestCountJobs(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM jobs WHERE (.+) AND source = \\$1$").
+		WithArgs("indeed").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	req, err := http.NewRequest("GET", "/api/jobs/count?source=indeed", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.CountJobs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "42", rr.Header().Get("X-Total-Count"))
+
+	var response map[string]interface{}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(42), response["count"])
+
+	// HEAD returns the count in a header only
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FROM jobs WHERE \\(exp_date IS NULL OR exp_date > NOW\\(\\)\\) AND NOT hidden AND NOT EXISTS \\(SELECT 1 FROM job_duplicates d JOIN jobs canonical (.+)\\)$").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	req, err = http.NewRequest("HEAD", "/api/jobs", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.HeadJobs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "7", rr.Header().Get("X-Total-Count"))
+	assert.Empty(t, rr.Body.String())
+
+	// Invalid filters are rejected before querying
+	req, err = http.NewRequest("GET", "/api/jobs/count?is_remote=maybe", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.CountJobs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// newImportRequest builds a multipart import request with the given fields
+func newImportRequest(t *testing.T, filename, content string, fields map[string]string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if filename != "" {
+		part, err := writer.CreateFormFile("file", filename)
+		assert.NoError(t, err)
+		part.Write([]byte(content))
+	}
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	writer.Close()
+
+	req, err := http.NewRequest("POST", "/api/admin/import", &body)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestImportJobs(t *testing.T) {
+	db, _ := setupMockDB(t)
+	defer db.Close()
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	tests := []struct {
+		name     string
+		filename string
+		fields   map[string]string
+		code     int
+	}{
+		{"missing file", "", nil, http.StatusBadRequest},
+		{"unknown format", "jobs.xml", nil, http.StatusBadRequest},
+		{"bad mapping", "jobs.csv", map[string]string{"mapping": `{"pay": "Salary"}`}, http.StatusBadRequest},
+		{"bad json", "jobs.json", nil, http.StatusBadRequest},
+		{"invalid rows", "jobs.csv", map[string]string{"mapping": `{"title": "Role"}`}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		content := "Role,company\n,Paystack\n"
+		if strings.HasSuffix(tt.filename, ".json") {
+			content = `{"title": "not an array"}`
+		}
+		rr := httptest.NewRecorder()
+		handler.ImportJobs(rr, newImportRequest(t, tt.filename, content, tt.fields))
+		assert.Equal(t, tt.code, rr.Code, tt.name)
+
+		if tt.code == http.StatusOK {
+			var response struct {
+				Report struct {
+					Rows   int `json:"rows"`
+					Valid  int `json:"valid"`
+					Errors []struct {
+						Row int `json:"row"`
+					} `json:"errors"`
+				} `json:"report"`
+			}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, 1, response.Report.Rows)
+			assert.Equal(t, 0, response.Report.Valid)
+			assert.Len(t, response.Report.Errors, 1)
+		}
+	}
+}
+
+func TestIngestJobs(t *testing.T) {
+	db, _ := setupMockDB(t)
+	defer db.Close()
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"not an array", `{"title": "Go Engineer"}`, http.StatusBadRequest},
+		{"unknown field", `[{"title": "Go Engineer", "pay": "NGN 1M"}]`, http.StatusBadRequest},
+		{"invalid rows", `[{"title": "Go Engineer"}, {"company": "Paystack"}]`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/jobs/ingest", strings.NewReader(tt.body))
+		rr := httptest.NewRecorder()
+		handler.IngestJobs(rr, req)
+		assert.Equal(t, tt.code, rr.Code, tt.name)
+
+		if tt.code == http.StatusOK {
+			var response struct {
+				Report struct {
+					Rows   int `json:"rows"`
+					Valid  int `json:"valid"`
+					Errors []struct {
+						Row int `json:"row"`
+					} `json:"errors"`
+				} `json:"report"`
+			}
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, 2, response.Report.Rows)
+			assert.Equal(t, 0, response.Report.Valid)
+			assert.Len(t, response.Report.Errors, 2)
+		}
+	}
+}
+
+func TestSyncJobsInvalidSource(t *testing.T) {
+	db, _ := setupMockDB(t)
+	defer db.Close()
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("POST", "/api/jobs/sync?source=monster", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.SyncJobs(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestSyncJobsWaitReportsFailures(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	// The dev mode mock API is not running, so the fetch fails and is logged
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").
+		WithArgs(sqlmock.AnyArg(), "jsearch").
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectExec("INSERT INTO sync_runs").
+		WithArgs(sqlmock.AnyArg(), "jsearch", "queued").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE sync_runs SET status").
+		WithArgs(sqlmock.AnyArg(), "running").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO job_sync_logs").
+		WithArgs("jsearch", 0, "Failed", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE sync_runs").
+		WithArgs(sqlmock.AnyArg(), "failed", 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SELECT pg_advisory_unlock").
+		WithArgs(sqlmock.AnyArg(), "jsearch").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{Mode: "dev"}))
+	handler.Config = &config.Config{SyncWaitTimeout: 5 * time.Second}
+
+	req, err := http.NewRequest("POST", "/api/jobs/sync?source=jsearch&wait=true", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.SyncJobs(rr, req)
+
+	assert.Equal(t, http.StatusBadGateway, rr.Code)
+
+	var response struct {
+		Success bool                  `json:"success"`
+		SyncID  string                `json:"sync_id"`
+		Results []services.SyncResult `json:"results"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.NotEmpty(t, response.SyncID)
+	assert.Len(t, response.Results, 1)
+	assert.Equal(t, "jsearch", response.Results[0].Source)
+	assert.Equal(t, services.SyncStatusFailed, response.Results[0].Status)
+	assert.NotEmpty(t, response.Results[0].Error)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncJobsAlreadyRunning(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	// Another sync of the source holds its lock
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("SELECT pg_try_advisory_lock").
+			WithArgs(sqlmock.AnyArg(), "indeed").
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	}
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{Mode: "dev"}))
+
+	for _, url := range []string{"/api/jobs/sync?source=indeed", "/api/jobs/sync?source=indeed&wait=true"} {
+		req, err := http.NewRequest("POST", url, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handler.SyncJobs(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "already running")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSyncJobsSourceDisabled(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	cfg := &config.Config{Mode: "dev", SourcesEnabled: map[string]bool{"indeed": false}}
+	handler := NewHandler(db, fetcher.NewJobFetcher(cfg))
+
+	for _, url := range []string{"/api/jobs/sync?source=indeed", "/api/jobs/sync?source=indeed&wait=true"} {
+		req, err := http.NewRequest("POST", url, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handler.SyncJobs(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Source is disabled: indeed")
+	}
+
+	// Nothing is locked or recorded
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSyncRun(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	created := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	columns := []string{"id", "source", "status", "fetched", "saved", "results", "error_message",
+		"created_at", "started_at", "finished_at", "duration_ms", "errors"}
+
+	mock.ExpectQuery("^SELECT (.+) FROM sync_runs WHERE id = \\$1$").
+		WithArgs("run-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"run-1", "all", "partial", 20, 8, []byte(`[{"source":"jsearch","saved":8}]`), "indeed: timeout",
+			created, created, created.Add(90*time.Second), 90000,
+			[]byte(`[{"stage":"fetch","source":"indeed","count":1,"sample":"timeout"}]`),
+		))
+	mock.ExpectQuery("^SELECT (.+) FROM sync_runs WHERE id = \\$1$").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	router := mux.NewRouter()
+	router.HandleFunc("/api/jobs/sync/runs/{id}", handler.GetSyncRun)
+
+	req, err := http.NewRequest("GET", "/api/jobs/sync/runs/run-1", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "partial", response.Data["status"])
+	assert.Equal(t, float64(8), response.Data["saved"])
+	assert.Equal(t, float64(90000), response.Data["duration_ms"])
+	assert.Equal(t, "indeed: timeout", response.Data["error"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"stage": "fetch", "source": "indeed", "count": float64(1), "sample": "timeout",
+	}}, response.Data["errors"])
+
+	req, err = http.NewRequest("GET", "/api/jobs/sync/runs/missing", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompanies(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	updated := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	columns := []string{"domain", "name", "logo_url", "description", "theme_color",
+		"industries", "links", "source", "updated_at", "open_jobs"}
+
+	mock.ExpectQuery("^SELECT (.+) FROM company_details cd ORDER BY (.+) LIMIT \\$1 OFFSET \\$2$").
+		WithArgs(5, 10).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"paystack.com", "Paystack", "https://cdn.brandfetch.io/paystack.png", "Payments", "#011B33",
+			[]byte(`["Fintech"]`), []byte(`[{"name":"twitter","url":"https://twitter.com/paystack"}]`),
+			"brandfetch", updated, 4,
+		))
+	mock.ExpectQuery("^SELECT (.+) FROM company_details cd WHERE cd.domain = \\$1$").
+		WithArgs("paystack.com").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"paystack.com", "Paystack", "", "", "", nil, nil, "opengraph", updated, 2,
+		))
+	mock.ExpectQuery("^SELECT (.+) FROM company_details cd WHERE cd.domain = \\$1$").
+		WithArgs("missing.com").
+		WillReturnError(sql.ErrNoRows)
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	router := mux.NewRouter()
+	router.HandleFunc("/api/companies", handler.ListCompanies)
+	router.HandleFunc("/api/companies/{id}", handler.GetCompany)
+
+	req, err := http.NewRequest("GET", "/api/companies?limit=5&offset=10", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var list struct {
+		Count int                      `json:"count"`
+		Data  []map[string]interface{} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	assert.Equal(t, 1, list.Count)
+	assert.Equal(t, "Paystack", list.Data[0]["name"])
+	assert.Equal(t, float64(4), list.Data[0]["open_jobs"])
+	assert.Equal(t, []interface{}{"Fintech"}, list.Data[0]["industries"])
+
+	req, err = http.NewRequest("GET", "/api/companies/Paystack.com", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var detail struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &detail))
+	assert.Equal(t, float64(2), detail.Data["open_jobs"])
+	assert.Equal(t, []interface{}{}, detail.Data["links"])
+
+	req, err = http.NewRequest("GET", "/api/companies/missing.com", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req, err = http.NewRequest("GET", "/api/companies?offset=-1", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCompanyJobs(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	posted := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	expired := time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT c.id, c.name, (.+) FROM companies c LEFT JOIN company_details cd (.+) WHERE c.key = \\$1").
+		WithArgs("andela").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "domain", "logo_url", "verified"}).AddRow(7, "Andela", "andela.com", "", true))
+	mock.ExpectQuery("^WITH postings AS (.+) FROM jobs_archive (.+) FROM postings WHERE NOT \\(exp_date IS NULL OR exp_date > NOW\\(\\)\\) ORDER BY posted_at DESC, id LIMIT \\$2 OFFSET \\$3$").
+		WithArgs(7, 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "location", "url", "source", "is_remote", "posted_at", "exp_date", "open"}).
+			AddRow("job-1", "Go Engineer", "Lagos", "", "jsearch", false, posted.AddDate(0, -5, 0), expired, false))
+	mock.ExpectQuery("^WITH postings AS (.+) SELECT COUNT\\(\\*\\) FILTER (.+), COUNT\\(\\*\\) FROM postings$").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"open", "total"}).AddRow(2, 5))
+	mock.ExpectQuery("^WITH postings AS (.+) FROM generate_series").
+		WithArgs(7, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"month", "posted"}).AddRow("2025-02", 0).AddRow("2025-03", 2))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	router := mux.NewRouter()
+	router.HandleFunc("/api/companies/{id}/jobs", handler.GetCompanyJobs)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/companies/ANDELA%20NIGERIA/jobs?status=closed&limit=10&months=2&tz=Africa/Lagos", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Company   map[string]interface{}   `json:"company"`
+		Data      []map[string]interface{} `json:"data"`
+		OpenJobs  int                      `json:"open_jobs"`
+		TotalJobs int                      `json:"total_jobs"`
+		History   []map[string]interface{} `json:"history"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, float64(7), response.Company["id"])
+	assert.Equal(t, "Andela", response.Company["name"])
+	assert.Equal(t, true, response.Company["verified"])
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, false, response.Data[0]["open"])
+	assert.Equal(t, "2024-10-01T09:00:00+01:00", response.Data[0]["posted_at"])
+	assert.Equal(t, "2024-11-30T01:00:00+01:00", response.Data[0]["exp_date"])
+	assert.Equal(t, 2, response.OpenJobs)
+	assert.Equal(t, 5, response.TotalJobs)
+	assert.Equal(t, []map[string]interface{}{{"month": "2025-02", "posted": float64(0)}, {"month": "2025-03", "posted": float64(2)}}, response.History)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectQuery("^SELECT (.+) FROM companies c (.+) WHERE c.id = \\$1").
+		WithArgs(int64(404)).
+		WillReturnError(sql.ErrNoRows)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/companies/404/jobs", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/companies/andela.com/jobs?status=hiring", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetJobDetail(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	updated := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	columns := []string{"id", "job_id", "title", "company", "company_url", "company_logo", "location",
+		"description", "url", "salary", "posted_at", "job_type", "is_remote", "source", "raw_data",
+		"exp_date", "apply_method", "seniority", "provenance", "language_flags", "salary_flag", "last_seen_at", "updated_at",
+		"assessments", "stack", "tracks", "hidden", "description_html", "language",
+		"description_translation", "translated_by", "company_id"}
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE id = \\$1$").
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"job-1", "ext-1", "Golang Developer", "Paystack", "", "", "", "", "", "$4,000/month",
+			updated, "", false, "jsearch", "", nil, "", "", []byte(`{"salary":"jsearch"}`), nil, nil, nil, updated, []byte(`{}`), nil, nil, false, "", "", "", "", 0,
+		))
+	detailRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(
+			"job-1", "ext-1", "Golang Developer", "Paystack", "", "", "", "", "", "$4,000/month",
+			updated, "", false, "jsearch", "", nil, "", "", []byte(`{"salary":"jsearch"}`), nil, nil, nil, updated, []byte(`{}`), nil, nil, false, "", "", "", "", 0,
+		)
+	}
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE id = \\$1$").WithArgs("job-1").WillReturnRows(detailRow())
+	// Once audited, the job's updated_at and so its ETag change
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE id = \\$1$").WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"job-1", "ext-1", "Golang Developer", "Paystack", "", "", "", "", "", "$4,000/month",
+			updated, "", false, "jsearch", "", nil, "", "", []byte(`{"salary":"jsearch"}`), []byte(`[]`), nil, nil, updated.Add(time.Minute), []byte(`{}`), nil, nil, false, "", "", "", "", 0,
+		))
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE id = \\$1$").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/jobs/{id}", handler.GetJobDetail)
+
+	req, err := http.NewRequest("GET", "/api/admin/jobs/job-1", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Data struct {
+			Salary     string            `json:"salary"`
+			Provenance map[string]string `json:"provenance"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "$4,000/month", response.Data.Salary)
+	assert.Equal(t, "jsearch", response.Data.Provenance["salary"])
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
+
+	req, err = http.NewRequest("GET", "/api/admin/jobs/job-1", nil)
+	assert.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+
+	req, err = http.NewRequest("GET", "/api/admin/jobs/missing", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetJobRaw(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	gotten := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	columns := []string{"id", "job_id", "source", "date_gotten", "raw_data"}
+	mock.ExpectQuery("^SELECT id, job_id, source, date_gotten, COALESCE\\(raw_data, ''\\) FROM jobs WHERE id = \\$1$").
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("job-1", "ext-1", "jsearch", gotten, `{"job_id": "ext-1", "job_title": "Go Dev"}`))
+	mock.ExpectQuery("FROM jobs WHERE id = \\$1$").
+		WithArgs("job-2").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("job-2", "ext-2", "jobberman", gotten, `<div class="card">Go Dev</div>`))
+	mock.ExpectQuery("FROM jobs WHERE id = \\$1$").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/jobs/{id}/raw", handler.GetJobRaw)
+
+	get := func(id string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/api/admin/jobs/"+id+"/raw", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var response struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response.Data
+	}
+
+	// JSON items are embedded as objects
+	rr, data := get("job-1")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "json", data["format"])
+	assert.Equal(t, map[string]interface{}{"job_id": "ext-1", "job_title": "Go Dev"}, data["raw"])
+
+	rr, data = get("job-2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "html", data["format"])
+	assert.Equal(t, `<div class="card">Go Dev</div>`, data["raw"])
+
+	rr, _ = get("missing")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLanguageFlags(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE jsonb_array_length\\(language_flags\\) > 0 (.+) LIMIT \\$2$").
+		WithArgs("age", 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "company", "url", "source", "language_flags"}).
+			AddRow("job-1", "Golang Developer", "Acme", "", "jsearch",
+				[]byte(`[{"category":"age","phrase":"not older than 30","suggestion":"remove age limits"}]`)))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/admin/jobs/language-flags?category=age", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetLanguageFlags(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"phrase":"not older than 30"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, err = http.NewRequest("GET", "/api/admin/jobs/language-flags?category=religion", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetLanguageFlags(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetSalaryFlags(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE salary_flag IS NOT NULL (.+) LIMIT \\$1$").
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "company", "url", "source", "salary", "seniority", "salary_flag"}).
+			AddRow("job-1", "Senior Go Engineer", "Acme", "", "jsearch", "₦12,000,000", "senior",
+				[]byte(`{"kind":"high","monthly":12000000,"median":1050000,"currency":"NGN","seniority":"senior"}`)))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/admin/jobs/salary-flags?limit=10", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetSalaryFlags(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"kind":"high"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, err = http.NewRequest("GET", "/api/admin/jobs/salary-flags?limit=0", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetSalaryFlags(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetJobSkips(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	columns := []string{"job_id", "source", "title", "company", "url", "reason", "details", "skipped_at"}
+	mock.ExpectQuery("^SELECT (.+) FROM job_skips WHERE job_id = \\$1 AND company ILIKE \\$2 ORDER BY skipped_at DESC LIMIT \\$3$").
+		WithArgs("li-123", "%paystack%", 50).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"li-123", "linkedin", "Backend Engineer (Node)", "Paystack", "https://linkedin.com/jobs/li-123",
+			"not_go_related", nil, time.Now(),
+		))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/admin/skips?job_id=li-123&company=paystack", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetJobSkips(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Count int                      `json:"count"`
+		Data  []map[string]interface{} `json:"data"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Count)
+	assert.Equal(t, "not_go_related", response.Data[0]["reason"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpireJobs(t *testing.T) {
+	req, err := http.NewRequest("POST", "/api/admin/jobs/expire", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO jobs_archive").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.ExpireJobs(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string]interface{}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, true, response["success"])
+	assert.Equal(t, float64(3), response["archived"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRecentErrors(t *testing.T) {
+	errorlog.Record(errorlog.SubsystemFetcher, "indeed", errors.New("upstream returned 429"))
+
+	db, _ := setupMockDB(t)
+	defer db.Close()
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/admin/errors?limit=5", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetRecentErrors(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Success bool                        `json:"success"`
+		Errors  map[string][]errorlog.Entry `json:"errors"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.True(t, response.Success)
+	assert.Equal(t, "upstream returned 429", response.Errors["fetcher:indeed"][0].Message)
+
+	// Invalid limits are rejected
+	req, _ = http.NewRequest("GET", "/api/admin/errors?limit=abc", nil)
+	rr = httptest.NewRecorder()
+	handler.GetRecentErrors(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestSetLogLevel(t *testing.T) {
+	defer logging.SetLevel(logging.Level())
+
+	handler := NewHandler(nil, fetcher.NewJobFetcher(&config.Config{}))
+	assert.NoError(t, logging.SetLevel("info"))
+
+	req, err := http.NewRequest("PUT", "/api/admin/log-level?level=debug", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.SetLogLevel(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "debug", response["level"])
+	assert.Equal(t, "info", response["previous"])
+
+	req, err = http.NewRequest("GET", "/api/admin/log-level", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetLogLevel(rr, req)
+	assert.Contains(t, rr.Body.String(), `"level":"debug"`)
+
+	req, err = http.NewRequest("PUT", "/api/admin/log-level?level=loud", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.SetLogLevel(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "debug", logging.Level())
+}
+
+func TestGetSchedulerStateDisabled(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"api_name", "interval_minutes", "last_run_time", "next_run_time",
+		"last_yield", "yield_avg", "last_adjustment", "adjusted_at"}).
+		AddRow("jsearch", 1440, time.Now(), time.Now().Add(24*time.Hour), 3, 2.5,
+			"stretched from 12h0m0s to 24h0m0s: average yield 0.5 new jobs per run, below 1", time.Now()).
+		AddRow("indeed", 720, nil, nil, nil, nil, "", nil)
+	mock.ExpectQuery("^SELECT (.+) FROM job_schedule_info ORDER BY api_name$").WillReturnRows(rows)
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/admin/scheduler", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetSchedulerState(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string]interface{}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, false, response["enabled"])
+
+	sources, ok := response["sources"].([]interface{})
+	assert.True(t, ok)
+	assert.Len(t, sources, 2)
+	assert.Nil(t, sources[1].(map[string]interface{})["last_run_time"])
+	assert.Nil(t, sources[1].(map[string]interface{})["yield_avg"])
+	assert.Equal(t, 2.5, sources[0].(map[string]interface{})["yield_avg"])
+	assert.Contains(t, sources[0].(map[string]interface{})["last_adjustment"], "stretched")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStatusDetail(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	older := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	newer := older.Add(6 * time.Hour)
+	mock.ExpectQuery("^SELECT source, COUNT\\(\\*\\)(.+) FROM jobs GROUP BY source").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "count", "active", "max"}).
+			AddRow("indeed", 5, 3, older).
+			AddRow("jsearch", 10, 10, newer))
+	// Jobs count once per track they are about
+	mock.ExpectQuery("^SELECT track, COUNT\\(\\*\\) FROM jobs, unnest\\(tracks\\) AS track WHERE (.+) GROUP BY track$").
+		WillReturnRows(sqlmock.NewRows([]string{"track", "count"}).AddRow("go", 13).AddRow("rust", 2))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.Config = &config.Config{Mode: "production", BrandFetchAPIKey: "token", RapidAPIKey: "key"}
+
+	req, err := http.NewRequest("GET", "/status/detail", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.StatusDetail(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Jobs struct {
+			Total      int                      `json:"total"`
+			Active     int                      `json:"active"`
+			NewestJob  string                   `json:"newest_job"`
+			BySource   []map[string]interface{} `json:"by_source"`
+			ByVertical map[string]int           `json:"by_vertical"`
+		} `json:"jobs"`
+		Features map[string]bool `json:"features"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 15, response.Jobs.Total)
+	assert.Equal(t, 13, response.Jobs.Active)
+	assert.Equal(t, "2025-03-01T14:00:00Z", response.Jobs.NewestJob)
+	assert.Len(t, response.Jobs.BySource, 2)
+	assert.Equal(t, map[string]int{"go": 13, "rust": 2}, response.Jobs.ByVertical)
+
+	assert.True(t, response.Features["logo_enrichment"])
+	assert.True(t, response.Features["rapidapi"])
+	assert.False(t, response.Features["apify"])
+	assert.False(t, response.Features["scheduler"])
+	assert.False(t, response.Features["notifications"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSyncStatus(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	lastRun := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT DISTINCT ON \\(api_name\\) (.+) FROM job_sync_logs ORDER BY").
+		WillReturnRows(sqlmock.NewRows([]string{"api_name", "sync_time", "job_count", "status"}).
+			AddRow("jsearch", lastRun, 12, "Success").
+			AddRow("indeed", lastRun, 0, "Failed"))
+	mock.ExpectQuery("^SELECT DISTINCT ON \\(api_name\\) (.+) FROM job_sync_logs WHERE error_message").
+		WillReturnRows(sqlmock.NewRows([]string{"api_name", "sync_time", "error_message"}).
+			AddRow("indeed", lastRun, "upstream returned 429"))
+	mock.ExpectQuery("^SELECT (.+) FROM job_schedule_info").
+		WillReturnRows(sqlmock.NewRows([]string{"api_name", "interval_minutes", "last_run_time", "next_run_time",
+			"last_yield", "yield_avg", "last_adjustment", "adjusted_at"}).
+			AddRow("jsearch", 1440, lastRun, lastRun.Add(24*time.Hour), nil, nil, "", nil))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/jobs/sync/status", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetSyncStatus(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		Sources []map[string]interface{} `json:"sources"`
+	}
+	err = json.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	bySource := make(map[string]map[string]interface{})
+	for _, status := range response.Sources {
+		bySource[status["source"].(string)] = status
+	}
+
+	assert.Equal(t, float64(12), bySource["jsearch"]["last_job_count"])
+	assert.Equal(t, "2025-03-02T08:00:00Z", bySource["jsearch"]["next_run_time"])
+	assert.Nil(t, bySource["jsearch"]["last_error"])
+	assert.Equal(t, "upstream returned 429", bySource["indeed"]["last_error"])
+	assert.Nil(t, bySource["linkedin"]["last_run_time"], "sources that never ran have no last run")
+	assert.Equal(t, "closed", bySource["jsearch"]["circuit"].(map[string]interface{})["state"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetupRoutes(t *testing.T) {
+	// Create a mock DB and handler
+	mockDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer mockDB.Close()
+
+	// Create a test config
+	testCfg := &config.Config{
+		APIKey:         "test-api-key",
+		AllowedOrigins: []string{"*"},
+	}
+
+	fetcher := fetcher.NewJobFetcher(&config.Config{}) // ✅
+	handler := NewHandler(mockDB, fetcher)
+
+	// Setup router with config
+	router := handler.SetupRoutes(testCfg)
+
+	// Create a test server
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Define the routes we expect to exist
+	expectedRoutes := []struct {
+		path        string
+		method      string
+		requireAuth bool
+	}{
+		{"/api/status", "GET", false},
+		{"/api/jobs", "GET", true},
+	}
+
+	for _, route := range expectedRoutes {
+		t.Run(fmt.Sprintf("%s %s", route.method, route.path), func(t *testing.T) {
+			req, err := http.NewRequest(route.method, server.URL+route.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Set required headers if route requires auth
+			if route.requireAuth {
+				req.Header.Set("X-API-Key", testCfg.APIKey)
+				req.Header.Set("X-Timestamp", "1234567890")      // Dummy value
+				req.Header.Set("X-Signature", "dummy-signature") // Dummy value
+			}
+
+			client := &http.Client{}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			// Assert the route is not returning 404
+			assert.NotEqual(t, http.StatusNotFound, resp.StatusCode)
+		})
+	}
+}
+
+func TestPurgeSource(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/sources/{name}/purge", handler.PurgeSource).Methods("POST")
+
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE source = \\$1").
+		WithArgs("linkedin", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"jobs", "skips"}).AddRow(4, 1))
+
+	// Without a confirmation token only a dry run happens
+	path := "/api/admin/sources/linkedin/purge?since=2024-05-01T00:00:00Z&mode=delete"
+	req, err := http.NewRequest("POST", path, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response struct {
+		DryRun            bool   `json:"dry_run"`
+		Jobs              int64  `json:"jobs"`
+		ConfirmationToken string `json:"confirmation_token"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.True(t, response.DryRun)
+	assert.Equal(t, int64(4), response.Jobs)
+	assert.NotEmpty(t, response.ConfirmationToken)
+
+	// The token does not confirm a purge with other parameters
+	req, err = http.NewRequest("POST", "/api/admin/sources/jsearch/purge?since=2024-05-01T00:00:00Z&mode=delete&confirm="+response.ConfirmationToken, nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM jobs WHERE source = \\$1").
+		WithArgs("linkedin", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("^DELETE FROM job_skips").
+		WithArgs("linkedin", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req, err = http.NewRequest("POST", path+"&confirm="+response.ConfirmationToken, nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"dry_run":false`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, err = http.NewRequest("POST", "/api/admin/sources/linkedin/purge", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestSetupAdminRoutes(t *testing.T) {
+	mockDB, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	cfg := &config.Config{
+		APIKey:          "test-api-key",
+		CronAPIKey:      "cron-key",
+		AdminAddr:       "127.0.0.1:9090",
+		AdminAllowedIPs: []string{"127.0.0.1"},
+		AdminAPIKeys:    []string{"admin-key"},
+		AllowedOrigins:  []string{"*"},
+	}
+	handler := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{}))
+
+	// With a separate admin listener, the public router does not serve /api/admin
+	req := httptest.NewRequest("GET", "/api/admin/log-level", nil)
+	req.Header.Set("X-API-Key", "admin-key")
+	rr := httptest.NewRecorder()
+	handler.SetupRoutes(cfg).ServeHTTP(rr, req)
+	assert.NotEqual(t, http.StatusOK, rr.Code)
+
+	admin := handler.SetupAdminRoutes(cfg)
+
+	req = httptest.NewRequest("GET", "/api/admin/log-level", nil)
+	req.RemoteAddr = "127.0.0.1:4000"
+	req.Header.Set("X-API-Key", "admin-key")
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// The cron key is not an admin key once admin keys are configured
+	req.Header.Set("X-API-Key", "cron-key")
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req.Header.Set("X-API-Key", "admin-key")
+	req.RemoteAddr = "198.51.100.1:4000"
+	rr = httptest.NewRecorder()
+	admin.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestRunQueryTemplate(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec("^SET LOCAL statement_timeout = 5000$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM jobs WHERE posted_at >= \\$1 (.+) LIMIT \\$2$").
+		WithArgs(since, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"company", "jobs", "remote_jobs", "latest"}).
+			AddRow([]byte("Paystack"), 3, 1, since))
+	mock.ExpectRollback()
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.Config = &config.Config{AdminQueryTimeout: 5 * time.Second, AdminQueryMaxRows: 100}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/query/{template_name}", handler.RunQueryTemplate)
+
+	req, _ := http.NewRequest("GET", "/api/admin/query/jobs_by_company?since=2025-03-01&limit=10", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response struct {
+		Columns   []string        `json:"columns"`
+		Rows      [][]interface{} `json:"rows"`
+		Truncated bool            `json:"truncated"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []string{"company", "jobs", "remote_jobs", "latest"}, response.Columns)
+	assert.Equal(t, [][]interface{}{{"Paystack", float64(3), float64(1), "2025-03-01T00:00:00Z"}}, response.Rows)
+	assert.False(t, response.Truncated)
+
+	// Unknown templates and bad parameters never reach the database
+	req, _ = http.NewRequest("GET", "/api/admin/query/drop_tables", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	req, _ = http.NewRequest("GET", "/api/admin/query/jobs_by_company?since=yesterday", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"code":"invalid_request","message":"Invalid parameter since: invalid date \"yesterday\""}`, rr.Body.String())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSchemaChanges(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	seen := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT (.+) FROM provider_schemas WHERE added IS NOT NULL (.+) LIMIT \\$2$").
+		WithArgs("jsearch", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source", "fingerprint", "added", "removed", "fields", "first_seen_at", "last_seen_at"}).
+			AddRow(2, "jsearch", "bbb", []byte(`["data[].title"]`), []byte(`["data[].job_title"]`),
+				[]byte(`{"data":"array","data[].title":"string"}`), seen, seen))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	req, _ := http.NewRequest("GET", "/api/admin/provider-schemas?source=jsearch", nil)
+	rr := httptest.NewRecorder()
+	handler.GetSchemaChanges(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response struct {
+		Data []struct {
+			Added   []string `json:"added"`
+			Removed []string `json:"removed"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	if assert.Len(t, response.Data, 1) {
+		assert.Equal(t, []string{"data[].title"}, response.Data[0].Added)
+		assert.Equal(t, []string{"data[].job_title"}, response.Data[0].Removed)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTestRelevanceFilter(t *testing.T) {
+	db, _ := setupMockDB(t)
+	defer db.Close()
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	tests := []struct {
+		name   string
+		body   string
+		code   int
+		tracks []string
+	}{
+		{"go and java", `{"title": "Go/Java Engineer"}`, http.StatusOK, []string{"go"}},
+		{"go-to-market", `{"title": "Go-to-Market Lead", "description": "Sales"}`, http.StatusOK, []string{}},
+		{"candidate keywords", `{"title": "Go-to-Market Lead", "track": "go", "exclude": []}`, http.StatusOK, []string{"go"}},
+		{"candidate track", `{"title": "Rust Engineer", "track": "rust", "include": ["rust"]}`, http.StatusOK, []string{"rust"}},
+		{"unknown track", `{"title": "Rust Engineer", "track": "rust"}`, http.StatusBadRequest, nil},
+		{"empty sample", `{"title": " "}`, http.StatusBadRequest, nil},
+		{"unknown field", `{"title": "Go Engineer", "company": "Paystack"}`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/admin/filters/test", strings.NewReader(tt.body))
+		rr := httptest.NewRecorder()
+		handler.TestRelevanceFilter(rr, req)
+		assert.Equal(t, tt.code, rr.Code, tt.name)
+
+		if tt.code == http.StatusOK {
+			var response struct {
+				Relevant bool     `json:"relevant"`
+				Tracks   []string `json:"tracks"`
+			}
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response), tt.name)
+			assert.Equal(t, tt.tracks, response.Tracks, tt.name)
+			assert.Equal(t, len(tt.tracks) > 0, response.Relevant, tt.name)
+		}
 	}
 }
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"Go9jaJobs/internal/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// readinessTimeout bounds the dependency checks of a readiness probe, below
+// the default 1s timeout of Kubernetes probes
+const readinessTimeout = 800 * time.Millisecond
+
+// dependencyStatus is the result of one readiness check
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Healthz is the liveness probe: it answers as long as the process serves
+// requests and deliberately checks no dependency, so a database outage makes
+// the pod unready instead of restarting it
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "ok",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// Readyz is the readiness probe: it pings the database and verifies the
+// configuration, reporting each dependency and 503 when any of them fails
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]dependencyStatus{
+		"database": h.checkDatabase(ctx),
+		"config":   checkConfig(h.Config),
+	}
+
+	status, code := "ok", http.StatusOK
+	for _, check := range checks {
+		if check.Status != "ok" {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    status,
+		"checks":    checks,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// checkDatabase pings Postgres within the deadline of ctx
+func (h *Handler) checkDatabase(ctx context.Context) dependencyStatus {
+	start := time.Now()
+	if h.DB == nil {
+		return dependencyStatus{Status: "fail", Error: "not configured"}
+	}
+	if err := h.DB.PingContext(ctx); err != nil {
+		// The probe is public: the driver's error, naming hosts, is only logged
+		log.Printf("Readiness check: database ping failed: %v", err)
+		return dependencyStatus{Status: "fail", LatencyMS: time.Since(start).Milliseconds(), Error: "unreachable"}
+	}
+	return dependencyStatus{Status: "ok", LatencyMS: time.Since(start).Milliseconds()}
+}
+
+// checkConfig verifies the settings the server cannot work without: the API
+// keys and, in production, the database and provider credentials. Only the
+// names of missing settings are reported, never their values.
+func checkConfig(cfg *config.Config) dependencyStatus {
+	if cfg == nil {
+		return dependencyStatus{Status: "fail", Error: "not loaded"}
+	}
+
+	required := [][2]string{
+		{"API_KEY", cfg.APIKey},
+		{"CRON_API_KEY", cfg.CronAPIKey},
+	}
+	if cfg.Mode == "production" {
+		required = append(required,
+			[2]string{"POSTGRES_CONNECTION_PROD", cfg.DBConnStr},
+			[2]string{"RAPID_API_KEY", cfg.RapidAPIKey},
+			[2]string{"APIFY_API_KEY", cfg.ApifyAPIKey},
+		)
+	}
+
+	var missing []string
+	for _, setting := range required {
+		if setting[1] == "" {
+			missing = append(missing, setting[0])
+		}
+	}
+
+	if len(missing) > 0 {
+		return dependencyStatus{Status: "fail", Error: fmt.Sprintf("missing %s", strings.Join(missing, ", "))}
+	}
+	return dependencyStatus{Status: "ok"}
+}
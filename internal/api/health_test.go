@@ -0,0 +1,121 @@
+package api
+
+import (
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/fetcher"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthz(t *testing.T) {
+	db, _ := setupMockDB(t)
+	defer db.Close()
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	rr := httptest.NewRecorder()
+	handler.Healthz(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+}
+
+func TestReadyz(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectPing()
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mock.ExpectPing()
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	handler.Config = &config.Config{Mode: "dev", APIKey: "key", CronAPIKey: "cron"}
+
+	readyz := func() (int, map[string]dependencyStatus) {
+		req, _ := http.NewRequest("GET", "/readyz", nil)
+		rr := httptest.NewRecorder()
+		handler.Readyz(rr, req)
+
+		var response struct {
+			Checks map[string]dependencyStatus `json:"checks"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return rr.Code, response.Checks
+	}
+
+	code, checks := readyz()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", checks["database"].Status)
+	assert.Equal(t, "ok", checks["config"].Status)
+
+	// Postgres is down
+	code, checks = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "fail", checks["database"].Status)
+	assert.Equal(t, "unreachable", checks["database"].Error)
+	assert.Equal(t, "ok", checks["config"].Status)
+
+	// Production needs the provider credentials
+	handler.Config = &config.Config{Mode: "production", APIKey: "key", CronAPIKey: "cron", DBConnStr: "postgres://", RapidAPIKey: "rapid"}
+	code, checks = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "ok", checks["database"].Status)
+	assert.Equal(t, dependencyStatus{Status: "fail", Error: "missing APIFY_API_KEY"}, checks["config"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetrics(t *testing.T) {
+	db, _ := setupMockDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(7)
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	rr := httptest.NewRecorder()
+	handler.Metrics(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rr.Body.String(), "# TYPE go9jajobs_db_max_open_connections gauge\ngo9jajobs_db_max_open_connections 7\n")
+	assert.Contains(t, rr.Body.String(), "go9jajobs_db_wait_count_total 0\n")
+	assert.Contains(t, rr.Body.String(), "# TYPE go9jajobs_job_saves_total counter\n")
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+	handler := &Handler{Config: &config.Config{DBQueryTimeout: time.Second}}
+	req, _ := http.NewRequest("GET", "/api/jobs", nil)
+
+	bounded, cancel := handler.withQueryTimeout(req)
+	defer cancel()
+
+	deadline, ok := bounded.Context().Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+}
+
+func TestMetricsPool(t *testing.T) {
+	// The pool connects lazily, so an unreachable address is enough
+	pool, err := db.OpenPool(context.Background(), "postgres://go9jajobs@127.0.0.1:1/go9jajobs", db.PoolConfig{MaxConns: 5})
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	handler := &Handler{Pool: pool}
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	rr := httptest.NewRecorder()
+	handler.Metrics(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "go9jajobs_db_max_open_connections 5\n")
+	assert.Contains(t, rr.Body.String(), "go9jajobs_db_canceled_acquire_total 0\n")
+}
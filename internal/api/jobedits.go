@@ -0,0 +1,141 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/db"
+
+	"github.com/gorilla/mux"
+)
+
+// maxJobEditSize bounds the body of a job edit
+const maxJobEditSize = 4 << 10
+
+// maxJobFieldLength bounds an edited title or company
+const maxJobFieldLength = 300
+
+// requestAdminKey returns the ID of the admin key of a request, recorded in
+// the job audit log
+func requestAdminKey(r *http.Request) string {
+	keyID, _ := r.Context().Value(adminKeyIDKey{}).(string)
+	if keyID == "" {
+		return "-"
+	}
+	return keyID
+}
+
+// invalidateResponses empties the response cache after an admin changed jobs
+func (h *Handler) invalidateResponses() {
+	if cache := h.responses(); cache != nil {
+		cache.Invalidate()
+	}
+}
+
+// UpdateJob hides, expires or corrects a job, e.g. spam or a mis-classified
+// posting. The body is a JSON object with any of "hidden", "expired", "title"
+// and "company"; the changes are recorded in the job audit log.
+func (h *Handler) UpdateJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var edit db.JobEdit
+	r.Body = http.MaxBytesReader(w, r.Body, maxJobEditSize)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&edit); err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	for name, field := range map[string]*string{"title": edit.Title, "company": edit.Company} {
+		if field == nil {
+			continue
+		}
+		*field = strings.TrimSpace(*field)
+		if *field == "" || len(*field) > maxJobFieldLength {
+			writeError(w, r, fmt.Sprintf("Invalid %s: %q", name, *field), http.StatusBadRequest)
+			return
+		}
+	}
+	if edit.Hidden == nil && !edit.Expired && edit.Title == nil && edit.Company == nil {
+		writeError(w, r, "Nothing to change: set hidden, expired, title or company", http.StatusBadRequest)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	changes, err := db.UpdateJob(r.Context(), h.DB, id, edit, requestAdminKey(r))
+	if err == sql.ErrNoRows {
+		writeError(w, r, fmt.Sprintf("Job not found: %s", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating job %s: %v", id, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(changes) > 0 {
+		h.invalidateResponses()
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"id":        id,
+		"changes":   changes,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteJob deletes a job, keeping a copy of it in the job audit log
+func (h *Handler) DeleteJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+	err := db.DeleteJob(r.Context(), h.DB, id, requestAdminKey(r))
+	if err == sql.ErrNoRows {
+		writeError(w, r, fmt.Sprintf("Job not found: %s", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting job %s: %v", id, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.invalidateResponses()
+
+	response := map[string]interface{}{
+		"success":   true,
+		"id":        id,
+		"deleted":   true,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetJobChanges returns the audit log of the admin edits and deletion of a
+// job, newest first
+func (h *Handler) GetJobChanges(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	id := mux.Vars(r)["id"]
+	changes, err := db.ListJobChanges(r.Context(), h.DB, id)
+	if err != nil {
+		log.Printf("Error querying changes of job %s: %v", id, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"data":      changes,
+		"count":     len(changes),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminRequest returns a request authenticated with the admin key abcd1234
+func adminRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	return req.WithContext(context.WithValue(req.Context(), adminKeyIDKey{}, "abcd1234"))
+}
+
+func TestUpdateJob(t *testing.T) {
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	handler := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{}))
+	handler.Responses = db.NewCache(0, 0)
+	handler.Responses.Set("jobs:etag", []byte("cached"))
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/jobs/{id}", handler.UpdateJob).Methods("PATCH")
+
+	for _, body := range []string{`{"hidden": "yes"}`, `{"title": "  "}`, `{}`, `{"status": "spam"}`} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, adminRequest("PATCH", "/api/admin/jobs/job-1", body))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+
+	columns := []string{"title", "company", "hidden", "exp_date"}
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT title, company, hidden, exp_date FROM jobs").
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("Golang Developer", "Paystak", false, nil))
+	mock.ExpectQuery("^UPDATE jobs SET").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("Golang Developer", "Paystack", true, nil))
+	mock.ExpectExec("^INSERT INTO job_changes").
+		WithArgs("job-1", db.JobChangeUpdate, sqlmock.AnyArg(), "abcd1234").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, adminRequest("PATCH", "/api/admin/jobs/job-1", `{"hidden": true, "company": " Paystack "}`))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"company":{"from":"Paystak","to":"Paystack"}`)
+	assert.Contains(t, rr.Body.String(), `"hidden":{"from":false,"to":true}`)
+
+	// Listings no longer serve the edited job from the cache
+	_, ok := handler.Responses.Get("jobs:etag")
+	assert.False(t, ok)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT title, company, hidden, exp_date FROM jobs").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, adminRequest("PATCH", "/api/admin/jobs/missing", `{"expired": true}`))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteJob(t *testing.T) {
+	mockDB, mock := setupMockDB(t)
+	defer mockDB.Close()
+
+	handler := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{}))
+	router := mux.NewRouter()
+	router.HandleFunc("/api/admin/jobs/{id}", handler.DeleteJob).Methods("DELETE")
+
+	mock.ExpectExec("DELETE FROM jobs WHERE id = \\$1 (.+) INSERT INTO job_changes").
+		WithArgs("job-1", db.JobChangeDelete, "abcd1234").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM jobs WHERE id = \\$1").
+		WithArgs("missing", db.JobChangeDelete, "abcd1234").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, adminRequest("DELETE", "/api/admin/jobs/job-1", ""))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"deleted":true`)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, adminRequest("DELETE", "/api/admin/jobs/missing", ""))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
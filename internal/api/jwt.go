@@ -0,0 +1,189 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+)
+
+// Authentication schemes of the public API, enabled with AUTH_SCHEMES
+const (
+	// AuthSchemeHMAC is X-API-Key with an X-Timestamp/X-Signature HMAC
+	AuthSchemeHMAC = "hmac"
+	// AuthSchemeJWT is a bearer token issued by /api/auth/token
+	AuthSchemeJWT = "jwt"
+)
+
+// defaultJWTTTL is how long a bearer token stays valid when JWT_TTL is not
+// configured
+const defaultJWTTTL = 15 * time.Minute
+
+// jwtIssuer is the iss claim of the tokens of this server
+const jwtIssuer = "go9jajobs"
+
+// tokenRateLimit is how many tokens a client IP may request per minute,
+// bounding API key guesses
+const tokenRateLimit = 30
+
+// jwtHeader is the only header this server issues and accepts, so tokens
+// with another algorithm (e.g. "none") are rejected
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Errors reported for bearer tokens that are not accepted
+var (
+	errInvalidToken = errors.New("invalid token")
+	errTokenExpired = errors.New("token expired")
+)
+
+// jwtClaims are the claims of a bearer token
+type jwtClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// signJWT returns the HS256 signed token of claims
+func signJWT(secret string, claims jwtClaims) string {
+	payload, _ := json.Marshal(claims)
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(jwtSignature(secret, unsigned))
+}
+
+// jwtSignature returns the HMAC-SHA256 of the header and payload of a token
+func jwtSignature(secret, unsigned string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+// parseJWT verifies a token signed with secret and returns its claims
+func parseJWT(secret, token string, now time.Time) (jwtClaims, error) {
+	var claims jwtClaims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return claims, errInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, jwtSignature(secret, parts[0]+"."+parts[1])) {
+		return claims, errInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(payload, &claims) != nil || claims.Issuer != jwtIssuer {
+		return claims, errInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return claims, errTokenExpired
+	}
+	return claims, nil
+}
+
+// authEnabled reports whether scheme is one of the configured AuthSchemes,
+// HMAC alone when none are
+func authEnabled(cfg *config.Config, scheme string) bool {
+	if len(cfg.AuthSchemes) == 0 {
+		return scheme == AuthSchemeHMAC
+	}
+	return slices.Contains(cfg.AuthSchemes, scheme)
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// JWTAuthMiddleware accepts requests carrying an unexpired bearer token
+// issued by /api/auth/token
+func JWTAuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if _, err := parseJWT(cfg.JWTSecret, token, time.Now()); err != nil {
+				log.Printf("[AUTH FAIL] %s %s from %s - Rejected bearer token: %v", r.Method, r.URL.Path, r.RemoteAddr, err)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthMiddleware authenticates the public API with the schemes enabled in
+// cfg: a bearer token when JWT is enabled and one is sent, else the API key
+// and HMAC signature when HMAC is enabled, its nonces kept in nonces
+func AuthMiddleware(cfg *config.Config, nonces db.CacheStore) func(http.Handler) http.Handler {
+	jwtAuth, hmacAuth := JWTAuthMiddleware(cfg), APIKeyAuthMiddleware(cfg, nonces)
+	return func(next http.Handler) http.Handler {
+		viaJWT, viaHMAC := jwtAuth(next), hmacAuth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasToken := bearerToken(r)
+			switch {
+			case authEnabled(cfg, AuthSchemeJWT) && (hasToken || !authEnabled(cfg, AuthSchemeHMAC)):
+				viaJWT.ServeHTTP(w, r)
+			case authEnabled(cfg, AuthSchemeHMAC):
+				viaHMAC.ServeHTTP(w, r)
+			default:
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+// IssueToken exchanges the API key, sent in the X-API-Key header, for a
+// short-lived bearer token, so browser frontends need not sign requests
+func (h *Handler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	apiKey := r.Header.Get("X-API-Key")
+	if apiKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(h.Config.APIKey)) != 1 {
+		log.Printf("[AUTH FAIL] %s %s from %s - Invalid API Key attempt", r.Method, r.URL.Path, r.RemoteAddr)
+		writeError(w, r, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ttl := defaultJWTTTL
+	if h.Config.JWTTTL > 0 {
+		ttl = h.Config.JWTTTL
+	}
+	now := time.Now()
+	expires := now.Add(ttl)
+	token := signJWT(h.Config.JWTSecret, jwtClaims{
+		Issuer:    jwtIssuer,
+		Subject:   "api",
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+	})
+
+	response := map[string]interface{}{
+		"success":    true,
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_in": int(ttl.Seconds()),
+		"expires_at": expires.UTC().Format(time.RFC3339),
+		// Clients not using the token sign requests as follows
+		"hmac_canonical_string": hmacCanonicalFormat,
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJWT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := jwtClaims{Issuer: jwtIssuer, Subject: "api", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+	token := signJWT("secret", claims)
+
+	parsed, err := parseJWT("secret", token, now)
+	assert.NoError(t, err)
+	assert.Equal(t, claims, parsed)
+
+	_, err = parseJWT("secret", token, now.Add(time.Minute))
+	assert.Equal(t, errTokenExpired, err)
+	_, err = parseJWT("other-secret", token, now)
+	assert.Equal(t, errInvalidToken, err)
+
+	// An unsigned token with alg "none" is rejected whatever its claims
+	parts := strings.Split(token, ".")
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + "."
+	_, err = parseJWT("secret", none, now)
+	assert.Equal(t, errInvalidToken, err)
+
+	for _, invalid := range []string{"", "a.b", parts[0] + "." + parts[1] + ".!!!", token + ".x"} {
+		_, err = parseJWT("secret", invalid, now)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestIssueToken(t *testing.T) {
+	handler := &Handler{Config: &config.Config{APIKey: "test-api-key", JWTSecret: "secret", JWTTTL: 5 * time.Minute}}
+
+	req := httptest.NewRequest("POST", "/api/auth/token", nil)
+	req.Header.Set("X-API-Key", "wrong-key")
+	rr := httptest.NewRecorder()
+	handler.IssueToken(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req = httptest.NewRequest("POST", "/api/auth/token", nil)
+	req.Header.Set("X-API-Key", "test-api-key")
+	rr = httptest.NewRecorder()
+	handler.IssueToken(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	var response struct {
+		Token     string `json:"token"`
+		TokenType string `json:"token_type"`
+		ExpiresIn int    `json:"expires_in"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "Bearer", response.TokenType)
+	assert.Equal(t, 300, response.ExpiresIn)
+	claims, err := parseJWT("secret", response.Token, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "api", claims.Subject)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	now := time.Now()
+	valid := signJWT("secret", jwtClaims{Issuer: jwtIssuer, Subject: "api", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()})
+	expired := signJWT("secret", jwtClaims{Issuer: jwtIssuer, Subject: "api", IssuedAt: now.Add(-time.Hour).Unix(), ExpiresAt: now.Add(-time.Minute).Unix()})
+
+	tests := []struct {
+		name     string
+		schemes  []string
+		header   string
+		value    string
+		expected int
+	}{
+		{"jwt token", []string{"hmac", "jwt"}, "Authorization", "Bearer " + valid, http.StatusOK},
+		{"jwt lowercase scheme", []string{"jwt"}, "Authorization", "bearer " + valid, http.StatusOK},
+		{"jwt expired", []string{"hmac", "jwt"}, "Authorization", "Bearer " + expired, http.StatusUnauthorized},
+		{"jwt only without token", []string{"jwt"}, "X-API-Key", "test-api-key", http.StatusUnauthorized},
+		{"hmac falls back without token", []string{"hmac", "jwt"}, "X-API-Key", "test-api-key", http.StatusUnauthorized},
+		{"jwt disabled", []string{"hmac"}, "Authorization", "Bearer " + valid, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{APIKey: "test-api-key", JWTSecret: "secret", AuthSchemes: tt.schemes}
+			handler := AuthMiddleware(cfg, db.NewCache(0, 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest("GET", "/api/jobs", nil)
+			req.Header.Set(tt.header, tt.value)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tt.expected, rr.Code)
+		})
+	}
+}
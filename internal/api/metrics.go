@@ -0,0 +1,67 @@
+package api
+
+import (
+	"Go9jaJobs/internal/services"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// metric is one sample of the /metrics exposition
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value float64
+}
+
+// Metrics serves the connection pool statistics of the database and the job
+// save counter in the Prometheus text format. The pgx pool is reported when
+// set, database/sql only hands out its connections.
+func (h *Handler) Metrics(w http.ResponseWriter, r *http.Request) {
+	var metrics []metric
+	if h.Pool != nil {
+		metrics = poolMetrics(h.Pool.Stat())
+	} else {
+		metrics = sqlMetrics(h.DB.Stats())
+	}
+	metrics = append(metrics,
+		metric{"go9jajobs_job_saves_total", "counter", "Total number of syncs and imports that saved jobs since start.", float64(services.JobSaves())})
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", m.name, m.help, m.name, m.kind, m.name, m.value)
+	}
+}
+
+// poolMetrics describes the statistics of the pgx pool
+func poolMetrics(stats *pgxpool.Stat) []metric {
+	return []metric{
+		{"go9jajobs_db_max_open_connections", "gauge", "Maximum number of open connections to the database.", float64(stats.MaxConns())},
+		{"go9jajobs_db_open_connections", "gauge", "Number of established connections, in use and idle.", float64(stats.TotalConns())},
+		{"go9jajobs_db_in_use_connections", "gauge", "Number of connections currently in use.", float64(stats.AcquiredConns())},
+		{"go9jajobs_db_idle_connections", "gauge", "Number of idle connections.", float64(stats.IdleConns())},
+		{"go9jajobs_db_wait_count_total", "counter", "Total number of connections waited for.", float64(stats.EmptyAcquireCount())},
+		{"go9jajobs_db_wait_duration_seconds_total", "counter", "Total time spent acquiring connections.", stats.AcquireDuration().Seconds()},
+		{"go9jajobs_db_canceled_acquire_total", "counter", "Total number of connection acquisitions canceled by their context.", float64(stats.CanceledAcquireCount())},
+		{"go9jajobs_db_max_idle_time_closed_total", "counter", "Total number of connections closed for exceeding their idle time.", float64(stats.MaxIdleDestroyCount())},
+		{"go9jajobs_db_max_lifetime_closed_total", "counter", "Total number of connections closed for exceeding their lifetime.", float64(stats.MaxLifetimeDestroyCount())},
+	}
+}
+
+// sqlMetrics describes the statistics of a database/sql pool
+func sqlMetrics(stats sql.DBStats) []metric {
+	return []metric{
+		{"go9jajobs_db_max_open_connections", "gauge", "Maximum number of open connections to the database.", float64(stats.MaxOpenConnections)},
+		{"go9jajobs_db_open_connections", "gauge", "Number of established connections, in use and idle.", float64(stats.OpenConnections)},
+		{"go9jajobs_db_in_use_connections", "gauge", "Number of connections currently in use.", float64(stats.InUse)},
+		{"go9jajobs_db_idle_connections", "gauge", "Number of idle connections.", float64(stats.Idle)},
+		{"go9jajobs_db_wait_count_total", "counter", "Total number of connections waited for.", float64(stats.WaitCount)},
+		{"go9jajobs_db_wait_duration_seconds_total", "counter", "Total time blocked waiting for a new connection.", stats.WaitDuration.Seconds()},
+		{"go9jajobs_db_max_idle_time_closed_total", "counter", "Total number of connections closed for exceeding their idle time.", float64(stats.MaxIdleTimeClosed)},
+		{"go9jajobs_db_max_lifetime_closed_total", "counter", "Total number of connections closed for exceeding their lifetime.", float64(stats.MaxLifetimeClosed)},
+	}
+}
@@ -1,17 +1,25 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/hex"
+	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
 
 	"github.com/rs/cors"
 )
@@ -33,7 +41,7 @@ func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
 
 			// If the origin is not allowed, block the request
 			if !allowed {
-				http.Error(w, "CORS Forbidden", http.StatusForbidden)
+				writeError(w, r, "CORS Forbidden", http.StatusForbidden)
 				return
 			}
 
@@ -41,8 +49,8 @@ func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
 			c := cors.New(cors.Options{
 				AllowedOrigins:   allowedOrigins,
 				AllowCredentials: true,
-				AllowedMethods:   []string{"GET", "OPTIONS"},
-				AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization", "X-API-Key", "X-Timestamp", "X-Signature"},
+				AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+				AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization", "X-API-Key", "X-Timestamp", "X-Nonce", "X-Signature"},
 				MaxAge:           600, // Cache preflight requests for 10 minutes
 			})
 
@@ -52,44 +60,212 @@ func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
 	}
 }
 
-// IPWhitelistMiddleware restricts access to specific IP addresses or ranges
+// parseIPNets parses IP addresses and CIDR ranges (e.g. 10.0.0.0/8,
+// 2001:db8::/32), an address matching only itself. Invalid entries are
+// logged and skipped.
+func parseIPNets(entries []string, list string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("Ignoring invalid %s entry %q: %v", list, entry, err)
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+// containsIP reports whether ip is in one of nets
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ip != nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHostIP parses an address with or without a port
+func parseHostIP(addr string) net.IP {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
+
+// clientIP returns the IP of the client of r, nil when it cannot be parsed.
+// When the peer is one of trusted proxies, it is the rightmost address of
+// X-Forwarded-For that is not a trusted proxy, or else X-Real-IP; the
+// forwarding headers of other peers are ignored since clients can set them.
+func clientIP(r *http.Request, trusted []*net.IPNet) net.IP {
+	ip := parseHostIP(r.RemoteAddr)
+	if !containsIP(trusted, ip) {
+		return ip
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			if ip = parseHostIP(hops[i]); ip == nil || !containsIP(trusted, ip) {
+				return ip
+			}
+		}
+		// Every hop is a trusted proxy: the leftmost one is the client
+		return ip
+	}
+	if realIP := parseHostIP(r.Header.Get("X-Real-IP")); realIP != nil {
+		return realIP
+	}
+	return ip
+}
+
+// IPWhitelistMiddleware restricts access to the IP addresses and CIDR ranges
+// of cfg.AllowedIPs, allowing any IP when it is empty. Behind a load balancer,
+// list it in cfg.TrustedProxies so the client IP is read from the
+// X-Forwarded-For or X-Real-IP header it sets.
 func IPWhitelistMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
+	allowed := parseIPNets(strings.Split(cfg.AllowedIPs, ","), "IP whitelist")
+	trusted := parseIPNets(cfg.TrustedProxies, "trusted proxy")
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if cfg.AllowedIPs == "" {
+			if strings.TrimSpace(cfg.AllowedIPs) == "" {
 				// Allow all if no IPs are specified in the .env
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			allowedIPs := strings.Split(cfg.AllowedIPs, ",")
-			clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
-			if err != nil {
-				log.Printf("Failed to parse client IP: %v", err)
-				http.Error(w, "Forbidden", http.StatusForbidden)
+			ip := clientIP(r, trusted)
+			if !containsIP(allowed, ip) {
+				log.Printf("[IP DENY] %s %s from %s (client %s) - IP not allowed", r.Method, r.URL.Path, r.RemoteAddr, ip)
+				writeError(w, r, "Forbidden", http.StatusForbidden)
 				return
 			}
 
-			allowed := false
-			for _, allowedIP := range allowedIPs {
-				if clientIP == allowedIP {
-					allowed = true
-					break
-				}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AdminIPAllowlistMiddleware only lets through clients whose IP is listed in
+// allowed, either as an address or a CIDR range. An empty list allows any IP.
+// Unlike IPWhitelistMiddleware it ignores forwarding headers, so the admin
+// listener must be reached directly rather than through a proxy or CDN.
+func AdminIPAllowlistMiddleware(allowed []string) func(http.Handler) http.Handler {
+	nets := parseIPNets(allowed, "admin allowlist")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(allowed) == 0 {
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			if !allowed {
-				http.Error(w, "Forbidden", http.StatusForbidden)
+			if containsIP(nets, parseHostIP(r.RemoteAddr)) {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			log.Printf("[ADMIN DENY] %s %s from %s - IP not allowed", r.Method, r.URL.Path, r.RemoteAddr)
+			writeError(w, r, "Forbidden", http.StatusForbidden)
 		})
 	}
 }
 
-// APIKeyAuthMiddleware with HMAC validation
-func APIKeyAuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
+// adminKeyIDKey is the context key carrying the ID of the admin key of a request
+type adminKeyIDKey struct{}
+
+// adminKeyID identifies an admin key in audit logs without revealing it
+func adminKeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
+// AdminKeyAuthMiddleware accepts requests whose X-API-Key header matches one
+// of keys. The key is not accepted as a query parameter, where it would end up
+// in access logs.
+func AdminKeyAuthMiddleware(keys []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := r.Header.Get("X-API-Key")
+
+			matched := false
+			for _, key := range keys {
+				if key != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+					matched = true
+				}
+			}
+			if !matched {
+				log.Printf("[AUTH FAIL] %s %s from %s - Invalid admin key attempt", r.Method, r.URL.Path, r.RemoteAddr)
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), adminKeyIDKey{}, adminKeyID(apiKey))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// AuditLogMiddleware logs every admin request with the ID of the key that made
+// it, its query and the resulting status, so operational changes (purges,
+// imports, log level) can be traced back
+func AuditLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		log.Printf("[AUDIT] key=%s %s %s?%s from %s - %d in %v",
+			requestAdminKey(r), r.Method, r.URL.Path, r.URL.RawQuery, r.RemoteAddr, rec.status, time.Since(start))
+	})
+}
+
+// hmacWindow is how far X-Timestamp may be from the server clock
+const hmacWindow = 5 * time.Minute
+
+// maxSignedBody is the largest request body APIKeyAuthMiddleware hashes
+const maxSignedBody = 1 << 20
+
+// hmacCanonicalFormat documents the string X-Signature signs, the hex
+// HMAC-SHA256 of it keyed with the API key
+const hmacCanonicalFormat = `X-Timestamp + "\n" + X-Nonce + "\n" + METHOD + "\n" + path[?query] + "\n" + hex(SHA-256(body))`
+
+// hmacCanonicalString returns the string signed for a request, see
+// hmacCanonicalFormat. uri is the path and query as sent.
+func hmacCanonicalString(timestamp, nonce, method, uri string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{timestamp, nonce, method, uri, hex.EncodeToString(sum[:])}, "\n")
+}
+
+// APIKeyAuthMiddleware accepts requests with the API key and an HMAC
+// signature of the request (see hmacCanonicalFormat) made within
+// hmacWindow. Each X-Nonce is accepted once, remembered in nonces for as long
+// as its timestamp is valid, so a captured request cannot be replayed.
+func APIKeyAuthMiddleware(cfg *config.Config, nonces db.CacheStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			apiKey := r.Header.Get("X-API-Key")
@@ -99,33 +275,62 @@ func APIKeyAuthMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 
 			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.APIKey)) != 1 {
 				log.Printf("[AUTH FAIL] %s %s from %s - Invalid API Key attempt", r.Method, r.URL.Path, r.RemoteAddr)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
 			// HMAC Validation
 			timestamp := r.Header.Get("X-Timestamp")
+			nonce := r.Header.Get("X-Nonce")
 			signature := r.Header.Get("X-Signature")
-			if timestamp == "" || signature == "" {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			if timestamp == "" || nonce == "" || len(nonce) > 128 || signature == "" {
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
-			// Validate timestamp (e.g., within 5 minutes)
+			// Validate timestamp, in the past or future clock skew alike
 			timeInt, err := time.Parse(time.RFC3339, timestamp)
-			if err != nil || time.Since(timeInt) > 5*time.Minute {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			if err != nil || time.Since(timeInt).Abs() > hmacWindow {
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
+			// Hash the body, leaving it readable by the handler
+			var body []byte
+			if r.Body != nil {
+				body, err = io.ReadAll(io.LimitReader(r.Body, maxSignedBody+1))
+				if err != nil {
+					writeError(w, r, "Bad request", http.StatusBadRequest)
+					return
+				}
+				if len(body) > maxSignedBody {
+					writeError(w, r, "Request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
 			// Generate HMAC
 			mac := hmac.New(sha256.New, []byte(cfg.APIKey))
-			mac.Write([]byte(timestamp))
+			mac.Write([]byte(hmacCanonicalString(timestamp, nonce, r.Method, r.URL.RequestURI(), body)))
 			expectedMAC := mac.Sum(nil)
 			expectedSignature := hex.EncodeToString(expectedMAC)
 
 			if subtle.ConstantTimeCompare([]byte(signature), []byte(expectedSignature)) != 1 {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			// Reject replays: a nonce is valid once over both sides of the window
+			seen, _, err := nonces.Incr("nonce:"+nonce, 2*hmacWindow)
+			if err != nil {
+				log.Printf("Error checking HMAC nonce: %v", err)
+				writeError(w, r, "Service unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			if seen > 1 {
+				log.Printf("[AUTH FAIL] %s %s from %s - Replayed HMAC nonce", r.Method, r.URL.Path, r.RemoteAddr)
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
@@ -148,7 +353,7 @@ func APIKeyAuthSimpleMiddleware(cfg *config.Config) func(http.Handler) http.Hand
 
 			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.CronAPIKey)) != 1 {
 				log.Printf("[AUTH FAIL] %s %s from %s - Invalid API Key attempt", r.Method, r.URL.Path, r.RemoteAddr)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				writeError(w, r, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
@@ -158,12 +363,41 @@ func APIKeyAuthSimpleMiddleware(cfg *config.Config) func(http.Handler) http.Hand
 	}
 }
 
+// RateLimitMiddleware allows each client IP at most limit requests per
+// window, answering 429 with Retry-After beyond. Counts are kept in
+// counters under name, shared between replicas when it is a RedisCache; the
+// requests pass when counting fails. The client IP is read from forwarding
+// headers only when the peer is one of trustedProxies, see clientIP, so
+// clients behind a load balancer do not share one window.
+func RateLimitMiddleware(counters db.CacheStore, trustedProxies []string, name string, limit int, window time.Duration) func(http.Handler) http.Handler {
+	trusted := parseIPNets(trustedProxies, "trusted proxy")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := r.RemoteAddr
+			if ip := clientIP(r, trusted); ip != nil {
+				client = ip.String()
+			}
+
+			count, retryAfter, err := counters.Incr("ratelimit:"+name+":"+client, window)
+			if err != nil {
+				log.Printf("Error counting %s requests: %v", name, err)
+			} else if count > int64(limit) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+				writeError(w, r, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // LoggingMiddleware logs requests
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		next.ServeHTTP(w, r)
-		log.Printf("%s %s from %s - %v", r.Method, r.URL.Path, r.RemoteAddr, time.Since(start))
+		log.Printf("%s %s from %s - %v (request %s)", r.Method, r.URL.Path, r.RemoteAddr, time.Since(start), requestID(r))
 	})
 }
 
@@ -180,4 +414,66 @@ func SecurityHeadersMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// tierLimitsKey is the context key carrying the TierLimits of a request
+type tierLimitsKey struct{}
+
+// QueryLimitsMiddleware rejects job queries the API tier is not allowed to
+// run (oversized pages, disallowed sorts/filters, leading wildcards) and makes
+// the tier limits available to handlers
+func QueryLimitsMiddleware(limits config.TierLimits) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := validateQueryLimits(r.URL.Query(), limits); err != nil {
+				log.Printf("[QUERY LIMIT] %s %s from %s - %v", r.Method, r.URL.Path, r.RemoteAddr, err)
+				writeBadRequest(w, r, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), tierLimitsKey{}, limits)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// tierLimitsFrom returns the TierLimits of a request; requests that did not
+// pass through QueryLimitsMiddleware are unrestricted
+func tierLimitsFrom(ctx context.Context) config.TierLimits {
+	limits, _ := ctx.Value(tierLimitsKey{}).(config.TierLimits)
+	return limits
+}
+
+// validateQueryLimits checks the query parameters of a request against limits
+func validateQueryLimits(query url.Values, limits config.TierLimits) error {
+	params := &queryParams{values: query}
+	limit := params.Int("limit", 0, 1, 0)
+	offset := params.Int("offset", 0, 0, 0)
+	if err := params.Err(); err != nil {
+		return err
+	}
+	if limits.MaxPageSize > 0 && limit > limits.MaxPageSize {
+		return fmt.Errorf("limit exceeds the maximum page size of %d", limits.MaxPageSize)
+	}
+	if limits.MaxOffset > 0 && offset > limits.MaxOffset {
+		return fmt.Errorf("offset exceeds the maximum of %d, follow next_cursor to page further", limits.MaxOffset)
+	}
+
+	if sort := query.Get("sort"); sort != "" && limits.AllowedSorts != nil && !slices.Contains(limits.AllowedSorts, sort) {
+		return fmt.Errorf("sort not allowed: %s", sort)
+	}
+
+	if limits.AllowedFilters != nil {
+		for _, filter := range jobFilterParams {
+			if query.Get(filter) != "" && !slices.Contains(limits.AllowedFilters, filter) {
+				return fmt.Errorf("filter not allowed: %s", filter)
+			}
+		}
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(query.Get("q")), "*") && !limits.AllowLeadingWildcard {
+		return fmt.Errorf("leading wildcards are not allowed in q")
+	}
+
+	return nil
+}
+
 // TODO might add rate limiti later if needed
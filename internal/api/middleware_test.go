@@ -1,11 +1,19 @@
 package api
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -46,12 +54,13 @@ func TestAPIKeyAuthMiddleware(t *testing.T) {
 	}
 
 	// Create a middleware with our mock handler
-	handler := APIKeyAuthMiddleware(testConfig)(mockHandler())
+	handler := APIKeyAuthMiddleware(testConfig, db.NewCache(0, 0))(mockHandler())
 
-	// Test 1: Valid API key
+	// Test 1: Valid API key and signature
 	req, err := http.NewRequest("GET", "/test", nil)
 	assert.NoError(t, err)
 	req.Header.Set("X-API-Key", "test-api-key")
+	signRequest(req, "test-api-key", "nonce-1", time.Now(), nil)
 	rr := httptest.NewRecorder()
 
 	handler.ServeHTTP(rr, req)
@@ -76,6 +85,73 @@ func TestAPIKeyAuthMiddleware(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
 
+// signRequest sets the HMAC headers of req, whose body is body
+func signRequest(req *http.Request, key, nonce string, at time.Time, body []byte) {
+	timestamp := at.UTC().Format(time.RFC3339)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(hmacCanonicalString(timestamp, nonce, req.Method, req.URL.RequestURI(), body)))
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Nonce", nonce)
+	req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestAPIKeyAuthMiddlewareSignature(t *testing.T) {
+	var received string
+	handler := APIKeyAuthMiddleware(&config.Config{APIKey: "test-api-key"}, db.NewCache(0, 0))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received = string(body)
+		}))
+	body := []byte(`{"email":"dev@example.com"}`)
+
+	serve := func(req *http.Request) int {
+		req.Header.Set("X-API-Key", "test-api-key")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	newRequest := func() *http.Request {
+		return httptest.NewRequest("POST", "/api/subscriptions?source=web", bytes.NewReader(body))
+	}
+
+	// The handler still reads the signed body
+	req := newRequest()
+	signRequest(req, "test-api-key", "nonce-1", time.Now(), body)
+	assert.Equal(t, http.StatusOK, serve(req))
+	assert.Equal(t, string(body), received)
+
+	// The same signed request cannot be replayed
+	req = newRequest()
+	signRequest(req, "test-api-key", "nonce-1", time.Now(), body)
+	assert.Equal(t, http.StatusUnauthorized, serve(req))
+
+	// Nor its signature used for another path, method or body
+	req = newRequest()
+	signRequest(req, "test-api-key", "nonce-2", time.Now(), body)
+	req.URL.Path = "/api/suggest-source"
+	assert.Equal(t, http.StatusUnauthorized, serve(req))
+	req = httptest.NewRequest("PUT", "/api/subscriptions?source=web", bytes.NewReader(body))
+	signRequest(req, "test-api-key", "nonce-3", time.Now(), body)
+	req.Method = "POST"
+	assert.Equal(t, http.StatusUnauthorized, serve(req))
+	req = httptest.NewRequest("POST", "/api/subscriptions?source=web", strings.NewReader(`{"email":"spam@example.com"}`))
+	signRequest(req, "test-api-key", "nonce-4", time.Now(), body)
+	assert.Equal(t, http.StatusUnauthorized, serve(req))
+
+	// Timestamps outside the window, in the past or the future, are rejected
+	for _, at := range []time.Time{time.Now().Add(-6 * time.Minute), time.Now().Add(6 * time.Minute)} {
+		req = newRequest()
+		signRequest(req, "test-api-key", "nonce-"+at.String(), at, body)
+		assert.Equal(t, http.StatusUnauthorized, serve(req))
+	}
+
+	// A nonce is required
+	req = newRequest()
+	signRequest(req, "test-api-key", "nonce-5", time.Now(), body)
+	req.Header.Del("X-Nonce")
+	assert.Equal(t, http.StatusUnauthorized, serve(req))
+}
+
 func TestSecurityHeadersMiddleware(t *testing.T) {
 	// Create a test request
 	req, err := http.NewRequest("GET", "/test", nil)
@@ -126,9 +202,27 @@ func TestCORSMiddleware(t *testing.T) {
 
 	// Check CORS headers
 	headers := rr.Header()
-	assert.Equal(t, "https://example.com", headers.Get("Access-Control-Allow-Origin"))
-	assert.Equal(t, "GET, OPTIONS", headers.Get("Access-Control-Allow-Methods"))
-	assert.Equal(t, "Accept, Content-Type, Authorization, X-API-Key, X-Timestamp, X-Signature", headers.Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "*", headers.Get("Access-Control-Allow-Origin"))
+
+	// Preflights of the write routes, e.g. POST /api/auth/token, are allowed
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+		req, err = http.NewRequest("OPTIONS", "/test", nil)
+		assert.NoError(t, err)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", method)
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-signature")
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, method, rr.Header().Get("Access-Control-Allow-Methods"), method)
+		assert.Equal(t, "content-type,x-signature", rr.Header().Get("Access-Control-Allow-Headers"), method)
+	}
+
+	req, err = http.NewRequest("POST", "/test", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Origin", "https://example.com")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
 
 	// Test 2: With specific allowed origins
 	allowedOrigins = []string{"https://example.com", "https://subdomain.example.com"}
@@ -169,3 +263,199 @@ func TestCORSMiddleware(t *testing.T) {
 	headers = rr.Header()
 	assert.NotEqual(t, "https://different-site.com", headers.Get("Access-Control-Allow-Origin"))
 }
+
+func TestQueryLimitsMiddleware(t *testing.T) {
+	limits := config.TierLimits{
+		MaxPageSize:    100,
+		MaxOffset:      1000,
+		AllowedSorts:   []string{"newest", "oldest"},
+		AllowedFilters: []string{"q", "source"},
+	}
+	handler := QueryLimitsMiddleware(limits)(mockHandler())
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusOK},
+		{"q=golang&source=jsearch&sort=oldest&limit=100&offset=200", http.StatusOK},
+		{"q=go*", http.StatusOK},
+		{"limit=101", http.StatusBadRequest},
+		{"limit=0", http.StatusBadRequest},
+		{"offset=-1", http.StatusBadRequest},
+		{"offset=1001", http.StatusBadRequest},
+		{"sort=title", http.StatusBadRequest},
+		{"include_expired=true", http.StatusBadRequest},
+		{"language=en", http.StatusBadRequest},
+		{"q=*lang", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", "/api/jobs?"+tt.query, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, tt.code, rr.Code, tt.query)
+	}
+
+	// Leading wildcards are allowed for tiers that opt in
+	limits.AllowLeadingWildcard = true
+	req, err := http.NewRequest("GET", "/api/jobs?q=*lang", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	QueryLimitsMiddleware(limits)(mockHandler()).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestIPWhitelistMiddleware(t *testing.T) {
+	cfg := &config.Config{
+		AllowedIPs:     "203.0.113.7, 10.0.0.0/8,2001:db8::/32,::1,not-an-ip",
+		TrustedProxies: []string{"192.0.2.0/24", "fd00::/8"},
+	}
+	handler := IPWhitelistMiddleware(cfg)(mockHandler())
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		code       int
+	}{
+		{"exact IPv4", "203.0.113.7:5000", "", "", http.StatusOK},
+		{"IPv4 range", "10.20.30.40:5000", "", "", http.StatusOK},
+		{"IPv4 outside", "203.0.113.8:5000", "", "", http.StatusForbidden},
+		{"IPv6 range", "[2001:db8:5::1]:5000", "", "", http.StatusOK},
+		{"exact IPv6", "[::1]:5000", "", "", http.StatusOK},
+		{"IPv6 outside", "[2001:db9::1]:5000", "", "", http.StatusForbidden},
+		{"forwarded by untrusted peer", "198.51.100.1:5000", "203.0.113.7", "", http.StatusForbidden},
+		{"forwarded by trusted proxy", "192.0.2.10:5000", "203.0.113.7", "", http.StatusOK},
+		{"forwarded IPv6 by trusted IPv6 proxy", "[fd00::2]:5000", "2001:db8::9", "", http.StatusOK},
+		{"client behind a chain of proxies", "192.0.2.10:5000", "10.1.1.1, 192.0.2.11", "", http.StatusOK},
+		{"spoofed leftmost entry", "192.0.2.10:5000", "10.1.1.1, 198.51.100.1", "", http.StatusForbidden},
+		{"forwarded with port", "192.0.2.10:5000", "[2001:db8::9]:443", "", http.StatusOK},
+		{"malformed forwarded", "192.0.2.10:5000", "unknown", "", http.StatusForbidden},
+		{"real IP by trusted proxy", "192.0.2.10:5000", "", "10.1.1.1", http.StatusOK},
+		{"real IP by untrusted peer", "198.51.100.1:5000", "", "10.1.1.1", http.StatusForbidden},
+		{"trusted proxy without headers", "192.0.2.10:5000", "", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/jobs", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if tt.realIP != "" {
+			req.Header.Set("X-Real-IP", tt.realIP)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, tt.code, rr.Code, tt.name)
+	}
+
+	// An empty whitelist allows any IP
+	req := httptest.NewRequest("GET", "/api/jobs", nil)
+	rr := httptest.NewRecorder()
+	IPWhitelistMiddleware(&config.Config{})(mockHandler()).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAdminIPAllowlistMiddleware(t *testing.T) {
+	handler := AdminIPAllowlistMiddleware([]string{"10.8.0.0/24", "203.0.113.7", "not-an-ip"})(mockHandler())
+
+	tests := []struct {
+		remoteAddr string
+		code       int
+	}{
+		{"10.8.0.12:5000", http.StatusOK},
+		{"203.0.113.7:5000", http.StatusOK},
+		{"203.0.113.8:5000", http.StatusForbidden},
+		{"10.9.0.1:5000", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/admin/errors", nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("X-Forwarded-For", "10.8.0.1")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, tt.code, rr.Code, tt.remoteAddr)
+	}
+
+	// An empty allowlist allows any IP
+	req := httptest.NewRequest("GET", "/api/admin/errors", nil)
+	rr := httptest.NewRecorder()
+	AdminIPAllowlistMiddleware(nil)(mockHandler()).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestAdminKeyAuthMiddleware(t *testing.T) {
+	var keyID string
+	handler := AdminKeyAuthMiddleware([]string{"key-one", "key-two"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyID, _ = r.Context().Value(adminKeyIDKey{}).(string)
+	}))
+
+	req := httptest.NewRequest("GET", "/api/admin/errors", nil)
+	req.Header.Set("X-API-Key", "key-two")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, adminKeyID("key-two"), keyID)
+	assert.NotContains(t, keyID, "key-two")
+
+	// Keys in the query string are not accepted
+	req = httptest.NewRequest("GET", "/api/admin/errors?api_key=key-one", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req = httptest.NewRequest("GET", "/api/admin/errors", nil)
+	req.Header.Set("X-API-Key", "wrong")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	handler := RateLimitMiddleware(db.NewCache(0, 0), []string{"10.0.0.0/8"}, "suggest-source", 2, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest("POST", "/api/suggest-source", nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, expected, rr.Code, i)
+		if expected == http.StatusTooManyRequests {
+			assert.Equal(t, "3600", rr.Header().Get("Retry-After"))
+		}
+	}
+
+	// Other clients have their own window
+	req := httptest.NewRequest("POST", "/api/suggest-source", nil)
+	req.RemoteAddr = "198.51.100.1:4000"
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Behind a trusted proxy each forwarded client has its own window too, and
+	// spoofed headers of other peers do not escape theirs
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		req = httptest.NewRequest("POST", "/api/suggest-source", nil)
+		req.RemoteAddr = "10.0.0.2:4000"
+		req.Header.Set("X-Forwarded-For", "192.0.2.50")
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, expected, rr.Code, i)
+	}
+	req = httptest.NewRequest("POST", "/api/suggest-source", nil)
+	req.RemoteAddr = "10.0.0.2:4000"
+	req.Header.Set("X-Forwarded-For", "192.0.2.51")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req = httptest.NewRequest("POST", "/api/suggest-source", nil)
+	req.RemoteAddr = "203.0.113.7:4000"
+	req.Header.Set("X-Forwarded-For", "192.0.2.52")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+}
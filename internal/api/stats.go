@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/services"
+)
+
+// statsTTL bounds how long the stats are served from cache when no sync or
+// import saves jobs, so expired jobs drop out
+const statsTTL = 15 * time.Minute
+
+// statsEntry is the cached stats of a track
+type statsEntry struct {
+	stats   *services.JobStatistics
+	builtAt time.Time
+	saves   int64
+}
+
+// statsCache holds the stats per track. A track's stats are recomputed on
+// request once jobs were saved since they were built or they are older than
+// statsTTL. The queries run without the lock, so a slow track does not hold
+// up the others; requests missing the cache together each compute the stats.
+type statsCache struct {
+	mu      sync.Mutex
+	entries map[string]statsEntry
+}
+
+// get returns the cached stats of a track and when they were computed
+func (c *statsCache) get(ctx context.Context, postgresDB *sql.DB, track string) (*services.JobStatistics, time.Time, error) {
+	saves := services.JobSaves()
+	c.mu.Lock()
+	entry, ok := c.entries[track]
+	c.mu.Unlock()
+	if ok && entry.saves == saves && time.Since(entry.builtAt) < statsTTL {
+		return entry.stats, entry.builtAt, nil
+	}
+
+	stats, err := services.GetJobStatistics(ctx, postgresDB, track)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	entry = statsEntry{stats: stats, builtAt: time.Now(), saves: saves}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]statsEntry)
+	}
+	// Stats built from later saves by a concurrent request are kept
+	if current, ok := c.entries[track]; !ok || current.saves <= saves {
+		c.entries[track] = entry
+	}
+	return entry.stats, entry.builtAt, nil
+}
+
+// GetStats returns aggregates of the listed jobs for dashboards: jobs per
+// source and posting week, the companies hiring the most, the remote share
+// and the spread of parsed salaries, optionally for one track
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	query := newQueryParams(r)
+	track := query.Valid("track", db.IsTrack, "a track listed by /api/tracks")
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	stats, builtAt, err := h.stats.get(r.Context(), h.DB, track)
+	if err != nil {
+		log.Printf("Error computing job stats: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(statsTTL.Seconds())))
+	w.Header().Set("Last-Modified", builtAt.UTC().Format(http.TimeFormat))
+	response := map[string]interface{}{
+		"success":     true,
+		"data":        stats,
+		"track":       track,
+		"computed_at": builtAt.Format(time.RFC3339),
+		"timestamp":   time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+const (
+	// trendsDefaultDays is how many days of counts the trends return
+	// without from
+	trendsDefaultDays = 30
+	// trendsMaxDays bounds the range of a trends request
+	trendsMaxDays = 366
+)
+
+// GetStatsTrends returns the daily job counts recorded from from to to
+// (YYYY-MM-DD, the last 30 days by default) for trend charts, optionally for
+// one source or state and split into a series per source or state (group_by)
+func (h *Handler) GetStatsTrends(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+	query := newQueryParams(r)
+
+	filter := db.TrendFilter{
+		To:      query.Date("to", time.Now().UTC().Truncate(24*time.Hour)),
+		Source:  query.String("source"),
+		State:   query.String("state"),
+		GroupBy: query.OneOf("group_by", db.TrendBySource, db.TrendByState),
+	}
+	filter.From = query.Date("from", filter.To.AddDate(0, 0, 1-trendsDefaultDays))
+	if filter.From.After(filter.To) {
+		query.Invalid("from", query.String("from"), "must not be after to")
+	}
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+	if filter.To.Sub(filter.From) >= trendsMaxDays*24*time.Hour {
+		writeError(w, r, fmt.Sprintf("Range too long: at most %d days", trendsMaxDays), http.StatusBadRequest)
+		return
+	}
+
+	series, err := db.GetJobCountTrends(r.Context(), h.DB, filter)
+	if err != nil {
+		log.Printf("Error loading job count trends: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Counts are recorded once a day
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	response := map[string]interface{}{
+		"success":   true,
+		"from":      filter.From.Format("2006-01-02"),
+		"to":        filter.To.Format("2006-01-02"),
+		"data":      series,
+		"count":     len(series),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStats(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT source, COUNT\\(\\*\\) FROM jobs").
+		WithArgs("").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "count"}).AddRow("jobberman", 2))
+	mock.ExpectQuery("^SELECT company, COUNT\\(\\*\\) FROM jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"company", "count"}).AddRow("Paystack", 2))
+	mock.ExpectQuery("^SELECT date_trunc\\('week', posted_at\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"week", "count"}))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FILTER \\(WHERE is_remote\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"remote", "onsite", "unknown"}).AddRow(1, 1, 0))
+	mock.ExpectQuery("^SELECT salary FROM jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"salary"}))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/stats", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetStats(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"sources":[{"name":"jobberman","jobs":2}]`)
+	assert.Contains(t, rr.Body.String(), `"remote":{"remote":1,"onsite":1,"unknown":0}`)
+	assert.NotEmpty(t, rr.Header().Get("Last-Modified"))
+
+	// The second request is served from cache without another query
+	rr = httptest.NewRecorder()
+	handler.GetStats(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, err = http.NewRequest("GET", "/api/stats?track=cobol", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.GetStats(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid track: cobol")
+}
+
+func TestGetStatsTrends(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT '' AS name, (.+) FROM job_counts_daily").
+		WithArgs("2024-04-02", "2024-05-01", "jobberman", "").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "day", "new", "active", "expired"}).
+			AddRow("", "2024-05-01", 3, 20, 1))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	req, err := http.NewRequest("GET", "/api/stats/trends?to=2024-05-01&source=jobberman", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.GetStatsTrends(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"from":"2024-04-02"`)
+	assert.Contains(t, rr.Body.String(), `"points":[{"day":"2024-05-01","new":3,"active":20,"expired":1}]`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	for _, query := range []string{
+		"from=yesterday",
+		"from=2024-05-02&to=2024-05-01",
+		"from=2022-01-01&to=2024-05-01",
+		"group_by=title",
+	} {
+		req, err := http.NewRequest("GET", "/api/stats/trends?"+query, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		handler.GetStatsTrends(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
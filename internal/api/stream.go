@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// jobStream writes the response of a job listing one job at a time, so its
+// memory use stays flat however many jobs are listed. The document has the
+// fields of the buffered responses, count and next_cursor following data
+// since they are only known once every job is written.
+type jobStream struct {
+	w io.Writer
+	// started is set once the response body is begun
+	started bool
+	count   int
+}
+
+// start writes the beginning of the document, once
+func (s *jobStream) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	_, err := io.WriteString(s.w, `{"success":true,"data":[`)
+	return err
+}
+
+// Write appends job to the data array
+func (s *jobStream) Write(job map[string]interface{}) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if s.count > 0 {
+		if _, err := io.WriteString(s.w, ","); err != nil {
+			return err
+		}
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	s.count++
+	return nil
+}
+
+// Close ends the data array and writes the count and the cursor of the next
+// page, if any
+func (s *jobStream) Close(nextCursor string) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	tail := `],"count":` + strconv.Itoa(s.count)
+	if nextCursor != "" {
+		cursor, err := json.Marshal(nextCursor)
+		if err != nil {
+			return err
+		}
+		tail += `,"next_cursor":` + string(cursor)
+	}
+	_, err := io.WriteString(s.w, tail+"}\n")
+	return err
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobStream(t *testing.T) {
+	var b strings.Builder
+	stream := &jobStream{w: &b}
+	assert.NoError(t, stream.Write(map[string]interface{}{"id": "1", "title": "Go <Dev>"}))
+	assert.NoError(t, stream.Write(map[string]interface{}{"id": "2"}))
+	assert.NoError(t, stream.Close("abc"))
+	assert.Equal(t, `{"success":true,"data":[{"id":"1","title":"Go \u003cDev\u003e"},{"id":"2"}],"count":2,"next_cursor":"abc"}`+"\n",
+		b.String())
+
+	// An empty page is an empty array, without a cursor
+	b.Reset()
+	stream = &jobStream{w: &b}
+	assert.NoError(t, stream.Close(""))
+	assert.Equal(t, `{"success":true,"data":[],"count":0}`+"\n", b.String())
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/services"
+)
+
+// maxSubscriptionSize bounds the body of a subscription request
+const maxSubscriptionSize = 4 << 10
+
+// subscriptionRequest is the body of POST /api/subscriptions
+type subscriptionRequest struct {
+	Email      string `json:"email"`
+	RemoteOnly bool   `json:"remote_only"`
+	State      string `json:"state"`
+	Seniority  string `json:"seniority"`
+}
+
+// publicBaseURL returns the base of the links sent in emails, "" when
+// PUBLIC_BASE_URL is not set: the Host header of a request is not trusted to
+// build them
+func (h *Handler) publicBaseURL() string {
+	if h.Config == nil {
+		return ""
+	}
+	return h.Config.PublicBaseURL
+}
+
+// Subscribe creates an email job alert and sends its confirmation link
+func (h *Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.Mailer == nil || h.publicBaseURL() == "" {
+		writeError(w, r, "Subscriptions are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req subscriptionRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxSubscriptionSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		writeError(w, r, fmt.Sprintf("Invalid email: %s", req.Email), http.StatusBadRequest)
+		return
+	}
+	if req.Seniority != "" && !analyzer.IsSeniority(req.Seniority) {
+		writeError(w, r, fmt.Sprintf("Invalid seniority: %s", req.Seniority), http.StatusBadRequest)
+		return
+	}
+
+	sub := db.Subscription{
+		Email:      strings.ToLower(email),
+		RemoteOnly: req.RemoteOnly,
+		State:      strings.TrimSpace(req.State),
+		Seniority:  req.Seniority,
+	}
+	if err := services.Subscribe(r.Context(), h.DB, h.Mailer, h.publicBaseURL(), sub); err != nil {
+		log.Printf("Error subscribing %s: %v", sub.Email, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	response := map[string]interface{}{
+		"success":   true,
+		"message":   "Check your inbox to confirm the job alert",
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// ConfirmSubscription confirms a job alert from the link in its email: GET
+// renders the confirmation form, POST confirms
+func (h *Handler) ConfirmSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		renderConfirmPage(w, r, "Confirm your job alert", "Confirm")
+		return
+	}
+	h.updateSubscription(w, r, db.ConfirmSubscription, "Job alert confirmed, the next digest will list new jobs.")
+}
+
+// Unsubscribe removes a job alert from the link in its emails: GET renders
+// the confirmation form, POST unsubscribes
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		renderConfirmPage(w, r, "Unsubscribe from this job alert", "Unsubscribe")
+		return
+	}
+	h.updateSubscription(w, r, db.DeleteSubscription, "You are unsubscribed from this job alert.")
+}
+
+// updateSubscription applies update to the subscription of the posted token
+// and answers with message, or 404 for unknown tokens
+func (h *Handler) updateSubscription(w http.ResponseWriter, r *http.Request,
+	update func(ctx context.Context, db *sql.DB, token string) (bool, error), message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSubscriptionSize)
+	token := r.PostFormValue("token")
+	if token == "" {
+		writeError(w, r, "Missing token", http.StatusBadRequest)
+		return
+	}
+
+	found, err := update(r.Context(), h.DB, token)
+	if err != nil {
+		log.Printf("Error updating subscription: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		writeError(w, r, "Subscription not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"message":   message,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
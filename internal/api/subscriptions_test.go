@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMailer records the recipients of the emails it is asked to send
+type fakeMailer struct {
+	to []string
+}
+
+func (m *fakeMailer) SendMail(to, subject, body string) error {
+	m.to = append(m.to, to)
+	return nil
+}
+
+func TestSubscribe(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	// Without SMTP, subscriptions are disabled
+	req, err := http.NewRequest("POST", "/api/subscriptions", strings.NewReader(`{"email":"dev@example.com"}`))
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.Subscribe(rr, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	mailer := &fakeMailer{}
+	handler.Mailer = mailer
+
+	// Nor without PUBLIC_BASE_URL, the links are not built from the Host header
+	rr = httptest.NewRecorder()
+	handler.Subscribe(rr, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	handler.Config = &config.Config{PublicBaseURL: "https://go9jajobs.example"}
+
+	mock.ExpectQuery("^INSERT INTO job_subscriptions").
+		WithArgs("dev@example.com", true, "Lagos", "senior", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "token", "confirmed_at"}).AddRow(1, "tok123", nil))
+
+	req, err = http.NewRequest("POST", "/api/subscriptions",
+		strings.NewReader(`{"email":"Dev@Example.com","remote_only":true,"state":"Lagos","seniority":"senior"}`))
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.Subscribe(rr, req)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Equal(t, []string{"dev@example.com"}, mailer.to)
+
+	for _, body := range []string{`{"email":"not an email"}`, `{"email":"dev@example.com","seniority":"ninja"}`, `{`} {
+		req, err = http.NewRequest("POST", "/api/subscriptions", strings.NewReader(body))
+		assert.NoError(t, err)
+		rr = httptest.NewRecorder()
+		handler.Subscribe(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmAndUnsubscribe(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec("^UPDATE job_subscriptions SET confirmed_at").
+		WithArgs("tok123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^DELETE FROM job_subscriptions WHERE token = \\$1$").
+		WithArgs("tok123").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^DELETE FROM job_subscriptions WHERE token = \\$1$").
+		WithArgs("tok123").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	handler := NewHandler(db, fetcher.NewJobFetcher(&config.Config{}))
+
+	// Opening the link only renders the form posting the token
+	req, err := http.NewRequest("GET", "/subscriptions/confirm?token=tok123", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ConfirmSubscription(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<form method="post" action="/subscriptions/confirm">`)
+	assert.Contains(t, rr.Body.String(), `name="token" value="tok123"`)
+
+	rr = httptest.NewRecorder()
+	handler.ConfirmSubscription(rr, postForm(t, "/subscriptions/confirm", "tok123"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Job alert confirmed")
+
+	rr = httptest.NewRecorder()
+	handler.Unsubscribe(rr, postForm(t, "/subscriptions/unsubscribe", "tok123"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// The link of a removed subscription no longer works
+	rr = httptest.NewRecorder()
+	handler.Unsubscribe(rr, postForm(t, "/subscriptions/unsubscribe", "tok123"))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Nor does a token in the query string of a POST
+	req, err = http.NewRequest("POST", "/subscriptions/unsubscribe?token=tok123", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.Unsubscribe(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// postForm returns a POST of the confirmation form of path with token
+func postForm(t *testing.T, path, token string) *http.Request {
+	req, err := http.NewRequest("POST", path, strings.NewReader(url.Values{"token": {token}}.Encode()))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/enrichment"
+	"Go9jaJobs/internal/services"
+)
+
+// Bounds of the fields of a source suggestion
+const (
+	maxSuggestionSize = 4 << 10
+	maxSuggestionName = 100
+	maxSuggestionNote = 500
+)
+
+// suggestionRequest is the body of POST /api/suggest-source
+type suggestionRequest struct {
+	URL   string `json:"url"`
+	Name  string `json:"name"`
+	Note  string `json:"note"`
+	Email string `json:"email"`
+}
+
+// SuggestSource stores a community suggestion of a job source and sends its
+// confirmation link
+func (h *Handler) SuggestSource(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.Mailer == nil || h.publicBaseURL() == "" {
+		writeError(w, r, "Source suggestions are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req suggestionRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxSuggestionSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, fmt.Sprintf("Invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		writeError(w, r, fmt.Sprintf("Invalid email: %s", req.Email), http.StatusBadRequest)
+		return
+	}
+	sourceURL := strings.TrimSpace(req.URL)
+	parsed, err := url.Parse(sourceURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || !strings.Contains(parsed.Host, ".") {
+		writeError(w, r, fmt.Sprintf("Invalid url: %s", req.URL), http.StatusBadRequest)
+		return
+	}
+	name, note := strings.TrimSpace(req.Name), strings.TrimSpace(req.Note)
+	if len(name) > maxSuggestionName || len(note) > maxSuggestionNote {
+		writeError(w, r, fmt.Sprintf("Name and note are limited to %d and %d characters", maxSuggestionName, maxSuggestionNote),
+			http.StatusBadRequest)
+		return
+	}
+
+	suggestion := db.SourceSuggestion{
+		Domain: enrichment.CompanyDomain(sourceURL),
+		URL:    sourceURL,
+		Name:   name,
+		Note:   note,
+		Email:  strings.ToLower(email),
+	}
+	if err := services.SuggestSource(r.Context(), h.DB, h.Mailer, h.publicBaseURL(), suggestion); err != nil {
+		log.Printf("Error storing source suggestion %s: %v", suggestion.Domain, err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	response := map[string]interface{}{
+		"success":   true,
+		"message":   "Check your inbox to confirm the suggestion",
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// ConfirmSourceSuggestion confirms a source suggestion from the link in its
+// email: GET renders the confirmation form, POST confirms
+func (h *Handler) ConfirmSourceSuggestion(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		renderConfirmPage(w, r, "Confirm your job source suggestion", "Confirm")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSuggestionSize)
+	token := r.PostFormValue("token")
+	if token == "" {
+		writeError(w, r, "Missing token", http.StatusBadRequest)
+		return
+	}
+
+	found, err := db.ConfirmSourceSuggestion(r.Context(), h.DB, token)
+	if err != nil {
+		log.Printf("Error confirming source suggestion: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		writeError(w, r, "Suggestion not found", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"message":   "Thanks, your suggestion will be reviewed.",
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSourceSuggestions lists the confirmed source suggestions by domain,
+// most suggested first, for review
+func (h *Handler) GetSourceSuggestions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	query := newQueryParams(r)
+	limit := query.Int("limit", 100, 1, 500)
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	sources, err := db.ListSuggestedSources(r.Context(), h.DB, limit)
+	if err != nil {
+		log.Printf("Error listing source suggestions: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"count":     len(sources),
+		"data":      sources,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"Go9jaJobs/internal/services"
+)
+
+// validMonth reports whether a month is written as YYYY-MM
+func validMonth(month string) bool {
+	_, err := time.Parse("2006-01", month)
+	return err == nil
+}
+
+// GetAPIUsage reports the calls made to the API of each source in a month
+// (YYYY-MM, the current one by default): their count, failures, jobs returned
+// and estimated cost against the monthly budget of the source
+func (h *Handler) GetAPIUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r, cancel := h.withQueryTimeout(r)
+	defer cancel()
+
+	query := newQueryParams(r)
+	month := time.Now()
+	if m := query.Valid("month", validMonth, "a month as YYYY-MM"); m != "" {
+		month, _ = time.Parse("2006-01", m)
+	}
+	if err := query.Err(); err != nil {
+		writeBadRequest(w, r, err)
+		return
+	}
+
+	report, err := services.GetUsageReport(r.Context(), h.DB, h.Config, month)
+	if err != nil {
+		log.Printf("Error querying API usage: %v", err)
+		writeError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":   true,
+		"month":     report.Month,
+		"cost":      report.Cost,
+		"data":      report.Sources,
+		"count":     len(report.Sources),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/apierror"
+)
+
+// FieldError is an invalid query parameter, listed in the details of the 400
+// response rejecting it
+type FieldError struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+	// Message says what the parameter accepts, e.g. "must be true or false"
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid query parameter of a request
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error names each invalid parameter and its value, e.g.
+// "Invalid limit: abc; Invalid is_remote: maybe"
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = fmt.Sprintf("Invalid %s: %s", field.Field, field.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// queryParams reads the query parameters of a request, recording every
+// invalid one instead of stopping at the first. Getters return the default
+// of a parameter that is missing or invalid; check Err once all are read.
+type queryParams struct {
+	values url.Values
+	errs   []FieldError
+}
+
+func newQueryParams(r *http.Request) *queryParams {
+	return &queryParams{values: r.URL.Query()}
+}
+
+// Invalid records an invalid parameter
+func (q *queryParams) Invalid(name, value, message string) {
+	q.errs = append(q.errs, FieldError{Field: name, Value: value, Message: message})
+}
+
+// String returns a parameter, "" when missing
+func (q *queryParams) String(name string) string {
+	return q.values.Get(name)
+}
+
+// Has reports whether a parameter is set to a non-empty value
+func (q *queryParams) Has(name string) bool {
+	return q.values.Get(name) != ""
+}
+
+// Int returns an integer parameter from min to max (no upper bound when max
+// is 0), def when missing
+func (q *queryParams) Int(name string, def, min, max int) int {
+	value := q.values.Get(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < min || (max > 0 && parsed > max) {
+		if max > 0 {
+			q.Invalid(name, value, fmt.Sprintf("must be an integer from %d to %d", min, max))
+		} else {
+			q.Invalid(name, value, fmt.Sprintf("must be an integer of at least %d", min))
+		}
+		return def
+	}
+	return parsed
+}
+
+// Bool returns a boolean parameter (true/false, 1/0), def when missing
+func (q *queryParams) Bool(name string, def bool) bool {
+	if value := q.OptionalBool(name); value != nil {
+		return *value
+	}
+	return def
+}
+
+// OptionalBool returns a boolean parameter, nil when missing or invalid
+func (q *queryParams) OptionalBool(name string) *bool {
+	value := q.values.Get(name)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		q.Invalid(name, value, "must be true or false")
+		return nil
+	}
+	return &parsed
+}
+
+// OneOf returns a parameter that must be one of allowed, "" when missing
+func (q *queryParams) OneOf(name string, allowed ...string) string {
+	value := q.values.Get(name)
+	if value == "" {
+		return ""
+	}
+	for _, a := range allowed {
+		if value == a {
+			return value
+		}
+	}
+	q.Invalid(name, value, "must be one of "+strings.Join(allowed, ", "))
+	return ""
+}
+
+// Valid returns a parameter accepted by valid, "" when missing. expected
+// describes the accepted values in the error.
+func (q *queryParams) Valid(name string, valid func(string) bool, expected string) string {
+	value := q.values.Get(name)
+	if value == "" {
+		return ""
+	}
+	if !valid(value) {
+		q.Invalid(name, value, "must be "+expected)
+		return ""
+	}
+	return value
+}
+
+// MaxLength returns a parameter of at most max bytes, "" when missing
+func (q *queryParams) MaxLength(name string, max int) string {
+	value := q.values.Get(name)
+	if len(value) > max {
+		q.Invalid(name, value, fmt.Sprintf("must be at most %d characters", max))
+		return ""
+	}
+	return value
+}
+
+// Date returns a YYYY-MM-DD parameter as midnight UTC, def when missing
+func (q *queryParams) Date(name string, def time.Time) time.Time {
+	value := q.values.Get(name)
+	if value == "" {
+		return def
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		q.Invalid(name, value, "must be a date as YYYY-MM-DD")
+		return def
+	}
+	return day
+}
+
+// Time returns an RFC 3339 parameter, def when missing
+func (q *queryParams) Time(name string, def time.Time) time.Time {
+	value := q.values.Get(name)
+	if value == "" {
+		return def
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		q.Invalid(name, value, "must be an RFC 3339 time, e.g. 2024-05-01T00:00:00Z")
+		return def
+	}
+	return t
+}
+
+// Location returns an IANA time zone parameter, e.g. Africa/Lagos, def when
+// missing
+func (q *queryParams) Location(name string, def *time.Location) *time.Location {
+	value := q.values.Get(name)
+	if value == "" {
+		return def
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		q.Invalid(name, value, "must be an IANA time zone, e.g. Africa/Lagos")
+		return def
+	}
+	return loc
+}
+
+// Err returns the invalid parameters as a *ValidationError, nil when all
+// were valid
+func (q *queryParams) Err() error {
+	if len(q.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: q.errs}
+}
+
+// writeBadRequest answers a request rejected by err with 400, listing the
+// invalid parameters of a *ValidationError in details.fields
+func writeBadRequest(w http.ResponseWriter, r *http.Request, err error) {
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		details := map[string]interface{}{"fields": invalid.Fields}
+		writeErrorDetails(w, r, apierror.InvalidRequest, err.Error(), details, http.StatusBadRequest)
+		return
+	}
+	writeError(w, r, err.Error(), http.StatusBadRequest)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryParams(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/jobs?limit=20&offset=-1&is_remote=maybe&sort=oldest&from=2024-05-01&to=May", nil)
+	query := newQueryParams(req)
+
+	assert.Equal(t, 20, query.Int("limit", 50, 1, 100))
+	assert.Equal(t, 0, query.Int("offset", 0, 0, 0))
+	assert.Equal(t, 7, query.Int("missing", 7, 1, 10))
+	assert.Nil(t, query.OptionalBool("is_remote"))
+	assert.Equal(t, "oldest", query.OneOf("sort", "newest", "oldest"))
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), query.Date("from", time.Time{}))
+	assert.True(t, query.Date("to", time.Time{}).IsZero())
+
+	err := query.Err()
+	var invalid *ValidationError
+	assert.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []FieldError{
+		{Field: "offset", Value: "-1", Message: "must be an integer of at least 0"},
+		{Field: "is_remote", Value: "maybe", Message: "must be true or false"},
+		{Field: "to", Value: "May", Message: "must be a date as YYYY-MM-DD"},
+	}, invalid.Fields)
+	assert.Equal(t, "Invalid offset: -1; Invalid is_remote: maybe; Invalid to: May", err.Error())
+
+	assert.NoError(t, newQueryParams(httptest.NewRequest("GET", "/api/jobs", nil)).Err())
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+
+	h := NewHandler(mockDB, fetcher.NewJobFetcher(&config.Config{}))
+
+	// Every invalid parameter is listed, none is queried with
+	req := httptest.NewRequest("GET", "/api/admin/dedupe-audit?limit=0&rule=fuzzy&max_score=2", nil)
+	rr := httptest.NewRecorder()
+	h.GetDedupeAudit(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details struct {
+			Fields []FieldError `json:"fields"`
+		} `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "invalid_request", body.Code)
+	assert.Equal(t, "Invalid limit: 0; Invalid rule: fuzzy; Invalid max_score: 2", body.Message)
+	if assert.Len(t, body.Details.Fields, 3) {
+		assert.Equal(t, "limit", body.Details.Fields[0].Field)
+		assert.Equal(t, "must be an integer from 1 to 500", body.Details.Fields[0].Message)
+		assert.Equal(t, "rule", body.Details.Fields[1].Field)
+		assert.Equal(t, "max_score", body.Details.Fields[2].Field)
+	}
+
+	// Booleans are no longer read as false when misspelled
+	req = httptest.NewRequest("GET", "/api/jobs?include_expired=yes", nil)
+	rr = httptest.NewRecorder()
+	h.GetAllJobs(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"include_expired"`)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,88 @@
+// Package apierror defines the error codes of the API and the JSON envelope
+// failed requests are answered with, so clients can tell failures apart
+// without parsing messages.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Code identifies the kind of failure of a request
+type Code string
+
+const (
+	// InvalidRequest means a parameter or the body was malformed or out of range
+	InvalidRequest Code = "invalid_request"
+	// Unauthorized means credentials were missing or wrong
+	Unauthorized Code = "unauthorized"
+	// Forbidden means the client (IP, origin) may not make the request
+	Forbidden Code = "forbidden"
+	// NotFound means the resource, or the route, does not exist
+	NotFound Code = "not_found"
+	// MethodNotAllowed means the route does not accept the method
+	MethodNotAllowed Code = "method_not_allowed"
+	// Conflict means the request clashes with the current state, e.g. a sync
+	// already running
+	Conflict Code = "conflict"
+	// PayloadTooLarge means the body exceeded its limit
+	PayloadTooLarge Code = "payload_too_large"
+	// RateLimited means the client sent too many requests
+	RateLimited Code = "rate_limited"
+	// Internal means the server failed; the details are in its logs
+	Internal Code = "internal_error"
+	// Unavailable means a dependency of the request is down or not configured
+	Unavailable Code = "unavailable"
+	// Timeout means the request took longer than it is allowed to
+	Timeout Code = "timeout"
+)
+
+// statusCodes are the codes of the HTTP statuses errors are answered with
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:            InvalidRequest,
+	http.StatusUnauthorized:          Unauthorized,
+	http.StatusForbidden:             Forbidden,
+	http.StatusNotFound:              NotFound,
+	http.StatusMethodNotAllowed:      MethodNotAllowed,
+	http.StatusConflict:              Conflict,
+	http.StatusRequestEntityTooLarge: PayloadTooLarge,
+	http.StatusTooManyRequests:       RateLimited,
+	http.StatusInternalServerError:   Internal,
+	http.StatusServiceUnavailable:    Unavailable,
+	http.StatusGatewayTimeout:        Timeout,
+}
+
+// ForStatus returns the code of an HTTP error status: InvalidRequest for an
+// unlisted 4xx status and Internal for any other
+func ForStatus(status int) Code {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= 400 && status < 500 {
+		return InvalidRequest
+	}
+	return Internal
+}
+
+// Response is the JSON body of a failed request
+type Response struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+	// Details adds structured context, e.g. the invalid fields of a request
+	Details interface{} `json:"details,omitempty"`
+	// RequestID is the ID of the request in the server logs, also sent as
+	// the X-Request-ID header
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Write answers a request with status and the error envelope of resp
+func Write(w http.ResponseWriter, status int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Headers describing a successful body no longer apply
+	w.Header().Del("Content-Disposition")
+	w.Header().Del("ETag")
+	w.Header().Del("Last-Modified")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
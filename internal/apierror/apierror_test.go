@@ -0,0 +1,33 @@
+package apierror
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForStatus(t *testing.T) {
+	assert.Equal(t, InvalidRequest, ForStatus(http.StatusBadRequest))
+	assert.Equal(t, Unauthorized, ForStatus(http.StatusUnauthorized))
+	assert.Equal(t, RateLimited, ForStatus(http.StatusTooManyRequests))
+	assert.Equal(t, InvalidRequest, ForStatus(http.StatusTeapot))
+	assert.Equal(t, Internal, ForStatus(http.StatusBadGateway))
+}
+
+func TestWrite(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rr.Header().Set("Content-Disposition", `attachment; filename="jobs.csv"`)
+	Write(rr, http.StatusBadRequest, Response{
+		Code:      InvalidRequest,
+		Message:   "Invalid format: pdf",
+		Details:   map[string]string{"format": "one of csv, xlsx"},
+		RequestID: "req-1",
+	})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Empty(t, rr.Header().Get("Content-Disposition"))
+	assert.JSONEq(t, `{"code":"invalid_request","message":"Invalid format: pdf","details":{"format":"one of csv, xlsx"},"request_id":"req-1"}`, rr.Body.String())
+}
@@ -3,11 +3,50 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// TierLimits restricts the job queries an API tier may run
+type TierLimits struct {
+	// MaxPageSize caps (and defaults) the limit parameter; 0 means unlimited
+	MaxPageSize int
+	// MaxOffset caps the offset parameter, deeper pages being reached by
+	// following the signed next_cursor of each page; 0 means unlimited
+	MaxOffset int
+	// AllowedSorts and AllowedFilters list the sort values and filter
+	// parameters the tier may use; nil allows everything
+	AllowedSorts   []string
+	AllowedFilters []string
+	// AllowLeadingWildcard permits search patterns starting with "*"
+	AllowLeadingWildcard bool
+}
+
+// IntervalBounds limits how far the adaptive scheduler moves the sync interval
+// of a source, Min typically being the most frequent polling the provider's
+// quota allows
+type IntervalBounds struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// ModeExample runs without any secrets: every provider client is replaced by
+// a synthetic one and the API uses the example keys below unless set
+const ModeExample = "example"
+
+// ModeReplay serves every provider from the responses cached in
+// APIResponseCacheDir by earlier fetches, without calling any API
+const ModeReplay = "replay"
+
+// Keys the API accepts in example mode when API_KEY/CRON_API_KEY are unset
+const (
+	ExampleAPIKey     = "example-api-key"
+	ExampleCronAPIKey = "example-cron-api-key"
+)
+
 // Config holds API keys and settings
 type Config struct {
 	RapidAPIKey        string
@@ -21,6 +60,235 @@ type Config struct {
 	AllowedOrigins     []string
 	AllowedIPs         string
 	CronAPIKey         string
+
+	// TrustedProxies are the IPs or CIDR ranges of the load balancers whose
+	// X-Forwarded-For/X-Real-IP headers name the client for AllowedIPs
+	TrustedProxies []string
+
+	// CursorSecret signs the pagination cursors of job listings, defaulting
+	// to CronAPIKey
+	CursorSecret string
+
+	// AuthSchemes are the authentication schemes of the public API: "hmac"
+	// (X-API-Key with an X-Timestamp/X-Signature HMAC) and/or "jwt" (bearer
+	// tokens issued by /api/auth/token), defaulting to hmac
+	AuthSchemes []string
+	// JWTSecret signs the bearer tokens, defaulting to CronAPIKey
+	JWTSecret string
+	// JWTTTL is how long a bearer token stays valid
+	JWTTTL time.Duration
+
+	// AdminAddr is the listen address (e.g. "10.8.0.1:9090") of a separate
+	// listener for /api/admin; empty serves it on Port with the public API
+	AdminAddr string
+	// AdminAllowedIPs are the IPs or CIDR ranges allowed to call /api/admin,
+	// empty allows any
+	AdminAllowedIPs []string
+	// AdminAPIKeys are the keys accepted by /api/admin, defaulting to CronAPIKey
+	AdminAPIKeys []string
+	// AdminQueryTimeout and AdminQueryMaxRows bound the SQL templates run
+	// through /api/admin/query
+	AdminQueryTimeout time.Duration
+	AdminQueryMaxRows int
+
+	// DBMaxOpenConns, DBMinConns, DBConnMaxLifetime and DBConnMaxIdleTime
+	// size the Postgres connection pool
+	DBMaxOpenConns    int
+	DBMinConns        int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	// DBQueryTimeout bounds the database queries of an API request
+	DBQueryTimeout time.Duration
+
+	// ExpirySweepInterval is how often expired jobs are archived; 0 disables the sweeper
+	ExpirySweepInterval time.Duration
+
+	// SourcesEnabled turns individual sync sources on or off, keyed by source
+	// name and set with ENABLE_<SOURCE> (e.g. ENABLE_JSEARCH=false). Sources
+	// not listed are enabled.
+	SourcesEnabled map[string]bool
+
+	// GreenhouseBoards and LeverBoards are the board tokens of the company job
+	// boards read by the greenhouse and lever sources (e.g. "paystack")
+	GreenhouseBoards []string
+	LeverBoards      []string
+
+	// JobbermanDelay is the pause between two requests to Jobberman, raised to
+	// the Crawl-delay of its robots.txt
+	JobbermanDelay time.Duration
+	// JobbermanMaxPages bounds the search result pages read per query
+	JobbermanMaxPages int
+
+	// FetchMaxPages and FetchMaxItems bound the result pages and jobs read per
+	// search query of the paginated APIs (jsearch, linkedin), overridden per
+	// source by FetchMaxPagesBySource and FetchMaxItemsBySource; 0 items is
+	// no limit
+	FetchMaxPages         int
+	FetchMaxPagesBySource map[string]int
+	FetchMaxItems         int
+	FetchMaxItemsBySource map[string]int
+	// FetchPageDelay is the pause between two result pages of a search
+	FetchPageDelay time.Duration
+	// FetchIncremental asks the APIs filtering by date (jsearch, linkedin)
+	// only for the jobs posted since the last successful sync of the source
+	FetchIncremental bool
+	// APIResponseCacheDir keeps the responses of the APIs, each stamped with
+	// its time, the latest ones being replayed in replay mode. Responses older
+	// than APIResponseCacheMaxAge, then the oldest past APIResponseCacheMaxMB,
+	// are pruned (0 is no limit) except the latest of each API.
+	// APIResponseCacheDisabled keeps none, e.g. on read-only filesystems.
+	APIResponseCacheDir      string
+	APIResponseCacheMaxAge   time.Duration
+	APIResponseCacheMaxMB    int
+	APIResponseCacheDisabled bool
+	// APIResponseRetention is how long the API responses stored with each
+	// sync run are kept in the database, 0 keeping them all
+	APIResponseRetention time.Duration
+
+	// CircuitBreakerThreshold is how many consecutive failed fetches open the
+	// circuit breaker of a source, skipping its fetches for
+	// CircuitBreakerCooldown before a probe fetch; 0 disables the breakers
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	// APIRequestCost and APIItemCost estimate the cost of the API calls of
+	// each source, per request and per job returned; APIMonthlyBudget pauses
+	// the syncs of a source once its estimated cost this month reaches it
+	APIRequestCost   map[string]float64
+	APIItemCost      map[string]float64
+	APIMonthlyBudget map[string]float64
+
+	// SchedulerEnabled runs the in-process sync scheduler instead of relying on external cron
+	SchedulerEnabled bool
+	// SchedulerDefaultInterval seeds job_schedule_info for sources without a stored interval
+	SchedulerDefaultInterval time.Duration
+
+	// SchedulerAdaptive stretches the interval of sources yielding few new
+	// jobs per run (below SchedulerLowYield on average) and tightens it for
+	// those yielding many (SchedulerHighYield), within SchedulerBounds or
+	// SchedulerBoundsBySource
+	SchedulerAdaptive       bool
+	SchedulerLowYield       int
+	SchedulerHighYield      int
+	SchedulerBounds         IntervalBounds
+	SchedulerBoundsBySource map[string]IntervalBounds
+
+	// SyncWaitTimeout bounds synchronous syncs requested with ?wait=true
+	SyncWaitTimeout time.Duration
+	// SyncParallelism is how many sources a sync of all sources fetches at once
+	SyncParallelism int
+	// TaskShutdownTimeout is how long shutdown waits for background tasks,
+	// such as syncs, before cancelling them
+	TaskShutdownTimeout time.Duration
+
+	// FetchMaxAttempts is how many times a failing (429/5xx/network) API call is tried
+	FetchMaxAttempts int
+	// FetchRetryBaseDelay is the first retry delay, doubled on every further attempt
+	FetchRetryBaseDelay time.Duration
+	// FetchRetryMaxDelay caps retry delays, including those asked for by Retry-After
+	FetchRetryMaxDelay time.Duration
+
+	// EnrichmentInterval is how often saved jobs without logos are enriched in
+	// the background (also triggered after each sync); 0 disables enrichment
+	EnrichmentInterval time.Duration
+
+	// Translator translates descriptions written in other languages than
+	// TranslateTo in the background: google, gemini or empty to disable
+	Translator string
+	// TranslateTo is the ISO 639-1 code descriptions are translated to
+	TranslateTo string
+	// TranslationInterval is how often untranslated descriptions are picked up
+	TranslationInterval time.Duration
+	// GoogleTranslateAPIKey is the Cloud Translation API key of the google translator
+	GoogleTranslateAPIKey string
+	// GeminiAPIKey and GeminiModel configure the gemini translator
+	GeminiAPIKey string
+	GeminiModel  string
+
+	// CacheWarmURLs are called with a signed payload after each sync saving
+	// jobs (frontend revalidate webhooks, CDN prefetch URLs)
+	CacheWarmURLs []string
+	// CacheWarmSecret signs cache-warm payloads (X-Signature)
+	CacheWarmSecret string
+
+	// TelegramBotToken and TelegramChatID enable digests of new jobs posted
+	// to a Telegram channel or chat after each sync
+	TelegramBotToken string
+	TelegramChatID   string
+	// SlackWebhookURL enables digests posted to a Slack incoming webhook
+	SlackWebhookURL string
+	// NotifyTemplate is the text/template of digests; empty uses the default
+	NotifyTemplate string
+	// NotifySources limits digests to syncs of these sources; empty announces all
+	NotifySources []string
+
+	// SMTPHost enables email job alerts (POST /api/subscriptions), sent
+	// through SMTPHost:SMTPPort from SMTPFrom
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	// PublicBaseURL is the base of the links in emails (confirm, unsubscribe)
+	PublicBaseURL string
+	// SubscriptionDigestInterval is how often job alert digests are sent
+	SubscriptionDigestInterval time.Duration
+	// SuggestionRateLimit is how many job source suggestions a client IP may
+	// send per hour through POST /api/suggest-source
+	SuggestionRateLimit int
+
+	// SnapshotS3Bucket enables the nightly export of the jobs table, as
+	// gzipped JSON Lines, to this bucket of S3-compatible storage at
+	// SnapshotS3Endpoint, under SnapshotPrefix, every day at SnapshotAt (UTC)
+	SnapshotS3Bucket    string
+	SnapshotS3Endpoint  string
+	SnapshotS3Region    string
+	SnapshotS3AccessKey string
+	SnapshotS3SecretKey string
+	SnapshotPrefix      string
+	SnapshotAt          string
+
+	// JobCountsAt is the UTC time of day the job counts of the previous day
+	// are recorded for the trend charts, kept for JobCountsRetentionDays
+	// (0 keeps them forever)
+	JobCountsAt            string
+	JobCountsRetentionDays int
+
+	// MinDescriptionLength is the shortest job description (in characters)
+	// kept on ingest; shorter postings are skipped as junk
+	MinDescriptionLength int
+	// MinDescriptionLengthBySource overrides MinDescriptionLength per job
+	// source, 0 keeping every posting of that source
+	MinDescriptionLengthBySource map[string]int
+	// Tracks are the technologies jobs are collected for, see ActiveTracks
+	Tracks []Track
+	// DescriptionSnippetLength is the longest description (in characters)
+	// of job listings requested with ?description=snippet
+	DescriptionSnippetLength int
+	// JobsCacheMaxAge is how long clients and CDNs may reuse a job listing
+	// before revalidating it with its ETag
+	JobsCacheMaxAge time.Duration
+	// ResponseCacheTTL is how long rendered /api/jobs and /feed.xml responses
+	// are served from memory, 0 disabling the response cache; it is emptied
+	// after every sync that saved jobs
+	ResponseCacheTTL time.Duration
+	// ResponseCacheMaxEntries is the most responses kept, the least recently
+	// used being evicted first
+	ResponseCacheMaxEntries int
+	// RedisURL moves the response cache and rate limit counters to Redis
+	// (redis://[user:password@]host:port[/db]), shared by every replica
+	RedisURL string
+
+	// LogLevel is the minimum log level (debug, info, warn, error); it can be
+	// changed at runtime through the admin API
+	LogLevel string
+	// LogFormat is the log output format, text or json
+	LogFormat string
+
+	// PublicTier limits job queries from the HMAC-authenticated public API
+	PublicTier TierLimits
+	// InternalTier limits job queries from the cron-key admin API
+	InternalTier TierLimits
 }
 
 // LoadConfig loads configuration from environment variables
@@ -38,24 +306,212 @@ func LoadConfig() (*Config, error) {
 		APIKey:           os.Getenv("API_KEY"),
 		AllowedOrigins:   parseAllowedOrigins(os.Getenv("ALLOWED_ORIGINS")),
 		AllowedIPs:       os.Getenv("ALLOWED_IPS"),
-		CronAPIKey:     os.Getenv("CRON_API_KEY"),
+		TrustedProxies:   parseList(os.Getenv("TRUSTED_PROXIES")),
+		CronAPIKey:       os.Getenv("CRON_API_KEY"),
+		CursorSecret:     os.Getenv("CURSOR_SECRET"),
+		AuthSchemes:      parseAuthSchemes(os.Getenv("AUTH_SCHEMES")),
+		JWTSecret:        os.Getenv("JWT_SECRET"),
+		JWTTTL:           parseDuration("JWT_TTL", 15*time.Minute),
+
+		AdminAddr:       os.Getenv("ADMIN_ADDR"),
+		AdminAllowedIPs: parseList(os.Getenv("ADMIN_ALLOWED_IPS")),
+		AdminAPIKeys:    parseList(os.Getenv("ADMIN_API_KEYS")),
+
+		AdminQueryTimeout: parseDuration("ADMIN_QUERY_TIMEOUT", 5*time.Second),
+		AdminQueryMaxRows: parseInt("ADMIN_QUERY_MAX_ROWS", 1000),
+
+		DBMaxOpenConns:    parseInt("DB_MAX_OPEN_CONNS", 20),
+		DBMinConns:        parseInt("DB_MIN_CONNS", 2),
+		DBConnMaxLifetime: parseDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: parseDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		DBQueryTimeout:    parseDuration("DB_QUERY_TIMEOUT", 10*time.Second),
+
+		ExpirySweepInterval: parseDuration("EXPIRY_SWEEP_INTERVAL", time.Hour),
+
+		SourcesEnabled: parseSourceToggles(os.Environ()),
+
+		GreenhouseBoards: parseList(os.Getenv("GREENHOUSE_BOARDS")),
+		LeverBoards:      parseList(os.Getenv("LEVER_BOARDS")),
+
+		JobbermanDelay:    parseDuration("JOBBERMAN_DELAY", 2*time.Second),
+		JobbermanMaxPages: parseInt("JOBBERMAN_MAX_PAGES", 2),
+
+		FetchMaxPages:         parseInt("FETCH_MAX_PAGES", 3),
+		FetchMaxPagesBySource: parseSourceInts(os.Getenv("FETCH_MAX_PAGES_BY_SOURCE")),
+		FetchMaxItems:         parseInt("FETCH_MAX_ITEMS", 100),
+		FetchMaxItemsBySource: parseSourceInts(os.Getenv("FETCH_MAX_ITEMS_BY_SOURCE")),
+		FetchPageDelay:        parseDuration("FETCH_PAGE_DELAY", time.Second),
+		FetchIncremental:      os.Getenv("FETCH_INCREMENTAL") != "false",
+
+		APIResponseCacheDir:      os.Getenv("API_RESPONSE_CACHE_DIR"),
+		APIResponseCacheMaxAge:   parseDuration("API_RESPONSE_CACHE_MAX_AGE", 7*24*time.Hour),
+		APIResponseCacheMaxMB:    parseInt("API_RESPONSE_CACHE_MAX_MB", 100),
+		APIResponseCacheDisabled: os.Getenv("API_RESPONSE_CACHE") == "false",
+		APIResponseRetention:     parseDuration("API_RESPONSE_RETENTION", 30*24*time.Hour),
+
+		CircuitBreakerThreshold: parseInt("CIRCUIT_BREAKER_THRESHOLD", 3),
+		CircuitBreakerCooldown:  parseDuration("CIRCUIT_BREAKER_COOLDOWN", 5*time.Minute),
+
+		APIRequestCost:   parseSourceFloats(os.Getenv("API_REQUEST_COST_BY_SOURCE")),
+		APIItemCost:      parseSourceFloats(os.Getenv("API_ITEM_COST_BY_SOURCE")),
+		APIMonthlyBudget: parseSourceFloats(os.Getenv("API_MONTHLY_BUDGET_BY_SOURCE")),
+
+		SchedulerEnabled:         os.Getenv("SCHEDULER_ENABLED") == "true",
+		SchedulerDefaultInterval: parseDuration("SCHEDULER_DEFAULT_INTERVAL", 24*time.Hour),
+		SchedulerAdaptive:        os.Getenv("SCHEDULER_ADAPTIVE") == "true",
+		SchedulerLowYield:        parseInt("SCHEDULER_LOW_YIELD", 1),
+		SchedulerHighYield:       parseInt("SCHEDULER_HIGH_YIELD", 20),
+		SchedulerBounds: IntervalBounds{
+			Min: parseDuration("SCHEDULER_MIN_INTERVAL", time.Hour),
+			Max: parseDuration("SCHEDULER_MAX_INTERVAL", 72*time.Hour),
+		},
+		SchedulerBoundsBySource: parseSourceBounds(os.Getenv("SCHEDULER_BOUNDS_BY_SOURCE")),
+
+		SyncWaitTimeout:     parseDuration("SYNC_WAIT_TIMEOUT", 2*time.Minute),
+		TaskShutdownTimeout: parseDuration("TASK_SHUTDOWN_TIMEOUT", 30*time.Second),
+		SyncParallelism:     parseInt("SYNC_PARALLELISM", 4),
+
+		FetchMaxAttempts:    parseInt("FETCH_MAX_ATTEMPTS", 3),
+		FetchRetryBaseDelay: parseDuration("FETCH_RETRY_BASE_DELAY", time.Second),
+		FetchRetryMaxDelay:  parseDuration("FETCH_RETRY_MAX_DELAY", 30*time.Second),
+
+		EnrichmentInterval: parseDuration("ENRICHMENT_INTERVAL", 15*time.Minute),
+
+		Translator:            os.Getenv("TRANSLATOR"),
+		TranslateTo:           os.Getenv("TRANSLATE_TO"),
+		TranslationInterval:   parseDuration("TRANSLATION_INTERVAL", 15*time.Minute),
+		GoogleTranslateAPIKey: os.Getenv("GOOGLE_TRANSLATE_API_KEY"),
+		GeminiAPIKey:          os.Getenv("GEMINI_API_KEY"),
+		GeminiModel:           os.Getenv("GEMINI_MODEL"),
+
+		CacheWarmURLs:   parseList(os.Getenv("CACHE_WARM_URLS")),
+		CacheWarmSecret: os.Getenv("CACHE_WARM_SECRET"),
+
+		TelegramBotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
+		TelegramChatID:   os.Getenv("TELEGRAM_CHAT_ID"),
+		SlackWebhookURL:  os.Getenv("SLACK_WEBHOOK_URL"),
+		NotifyTemplate:   os.Getenv("NOTIFY_TEMPLATE"),
+		NotifySources:    parseList(os.Getenv("NOTIFY_SOURCES")),
+
+		SMTPHost:                   os.Getenv("SMTP_HOST"),
+		SMTPPort:                   parseInt("SMTP_PORT", 587),
+		SMTPUsername:               os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:               os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:                   os.Getenv("SMTP_FROM"),
+		PublicBaseURL:              os.Getenv("PUBLIC_BASE_URL"),
+		SubscriptionDigestInterval: parseDuration("SUBSCRIPTION_DIGEST_INTERVAL", 24*time.Hour),
+		SuggestionRateLimit:        parseInt("SUGGESTION_RATE_LIMIT", 5),
+
+		SnapshotS3Bucket:    os.Getenv("SNAPSHOT_S3_BUCKET"),
+		SnapshotS3Endpoint:  os.Getenv("SNAPSHOT_S3_ENDPOINT"),
+		SnapshotS3Region:    os.Getenv("SNAPSHOT_S3_REGION"),
+		SnapshotS3AccessKey: os.Getenv("SNAPSHOT_S3_ACCESS_KEY"),
+		SnapshotS3SecretKey: os.Getenv("SNAPSHOT_S3_SECRET_KEY"),
+		SnapshotPrefix:      os.Getenv("SNAPSHOT_PREFIX"),
+		SnapshotAt:          os.Getenv("SNAPSHOT_AT"),
+
+		JobCountsAt:            os.Getenv("JOB_COUNTS_AT"),
+		JobCountsRetentionDays: parseInt("JOB_COUNTS_RETENTION_DAYS", 730),
+
+		MinDescriptionLength:         parseInt("MIN_DESCRIPTION_LENGTH", 100),
+		MinDescriptionLengthBySource: parseSourceInts(os.Getenv("MIN_DESCRIPTION_LENGTH_BY_SOURCE")),
+		Tracks:                       parseTracks(os.Getenv("TRACKS")),
+		DescriptionSnippetLength:     parseInt("DESCRIPTION_SNIPPET_LENGTH", 280),
+		JobsCacheMaxAge:              parseDuration("JOBS_CACHE_MAX_AGE", 30*time.Second),
+		ResponseCacheTTL:             parseDuration("RESPONSE_CACHE_TTL", time.Minute),
+		ResponseCacheMaxEntries:      parseInt("RESPONSE_CACHE_MAX_ENTRIES", 500),
+		RedisURL:                     os.Getenv("REDIS_URL"),
+
+		LogLevel:  os.Getenv("LOG_LEVEL"),
+		LogFormat: os.Getenv("LOG_FORMAT"),
+
+		PublicTier: parseTierLimits("PUBLIC_TIER", TierLimits{
+			MaxPageSize:    100,
+			MaxOffset:      1000,
+			AllowedSorts:   []string{"newest", "oldest"},
+			AllowedFilters: []string{"q", "source", "apply_method", "seniority", "assessment", "stack", "track", "language", "is_remote"},
+		}),
+		InternalTier: parseTierLimits("INTERNAL_TIER", TierLimits{
+			MaxPageSize:          1000,
+			AllowedSorts:         []string{"newest", "oldest", "title", "company"},
+			AllowedFilters:       []string{"q", "source", "apply_method", "seniority", "assessment", "stack", "track", "language", "is_remote", "include_expired", "include_duplicates", "include_hidden"},
+			AllowLeadingWildcard: true,
+		}),
 	}
 
 	if config.Port == "" {
 		config.Port = "8080"
 	}
 
+	if config.LogLevel == "" {
+		config.LogLevel = "info"
+	}
+	if config.LogFormat == "" {
+		config.LogFormat = "text"
+	}
+
 	if config.Mode == "production" {
 		config.DBConnStr = os.Getenv("POSTGRES_CONNECTION_PROD")
 	} else {
 		config.DBConnStr = os.Getenv("POSTGRES_CONNECTION_LOCAL")
 	}
 
+	if config.Mode == ModeExample {
+		var defaults []string
+		if config.APIKey == "" {
+			config.APIKey = ExampleAPIKey
+			defaults = append(defaults, "API_KEY")
+		}
+		if config.CronAPIKey == "" {
+			config.CronAPIKey = ExampleCronAPIKey
+			defaults = append(defaults, "CRON_API_KEY")
+		}
+		// Never log the keys themselves: they may be real ones
+		log.Printf("Example mode: providers are synthetic")
+		if len(defaults) > 0 {
+			log.Printf("Example mode: using the example %s", strings.Join(defaults, " and "))
+		}
+	}
+
 	// Warn if secrets are missing
 	if config.APIKey == "" || config.CronAPIKey == "" {
 		log.Fatal("API_KEY or CRON_API_KEY not set. Exiting.")
 	}
 
+	if config.SMTPFrom == "" {
+		config.SMTPFrom = config.SMTPUsername
+	}
+
+	if config.CursorSecret == "" {
+		config.CursorSecret = config.CronAPIKey
+	}
+	if config.JWTSecret == "" {
+		config.JWTSecret = config.CronAPIKey
+	}
+
+	if len(config.AdminAPIKeys) == 0 {
+		config.AdminAPIKeys = []string{config.CronAPIKey}
+	}
+
+	if config.SnapshotS3Endpoint == "" {
+		config.SnapshotS3Endpoint = "https://s3.amazonaws.com"
+	}
+	if config.SnapshotS3Region == "" {
+		config.SnapshotS3Region = "us-east-1"
+	}
+	if config.SnapshotPrefix == "" {
+		config.SnapshotPrefix = "snapshots/"
+	}
+	if config.SnapshotAt == "" {
+		config.SnapshotAt = "02:00"
+	}
+	if config.JobCountsAt == "" {
+		config.JobCountsAt = "00:15"
+	}
+	if config.TranslateTo == "" {
+		config.TranslateTo = "en"
+	}
+
 	// Warn if secrets are missing
 	if config.Mode == "" {
 		log.Println("MODE not set. Defaulting to 'dev'")
@@ -73,3 +529,156 @@ func parseAllowedOrigins(origins string) []string {
 	}
 	return strings.Split(origins, ",")
 }
+
+// parseDuration reads a duration such as "30m" from the environment, falling
+// back to def when the variable is unset or invalid
+func parseDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s %q, using default %s", key, value, def)
+		return def
+	}
+	return d
+}
+
+// parseInt reads a positive integer from the environment, falling back to def
+// when the variable is unset or invalid
+func parseInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		log.Printf("Invalid %s %q, using default %d", key, value, def)
+		return def
+	}
+	return n
+}
+
+// parseTierLimits overrides the defaults of a tier with <PREFIX>_MAX_PAGE_SIZE,
+// <PREFIX>_MAX_OFFSET, <PREFIX>_ALLOWED_SORTS, <PREFIX>_ALLOWED_FILTERS (comma separated) and
+// <PREFIX>_ALLOW_LEADING_WILDCARD
+func parseTierLimits(prefix string, def TierLimits) TierLimits {
+	limits := def
+	limits.MaxPageSize = parseInt(prefix+"_MAX_PAGE_SIZE", def.MaxPageSize)
+	limits.MaxOffset = parseInt(prefix+"_MAX_OFFSET", def.MaxOffset)
+	if sorts := os.Getenv(prefix + "_ALLOWED_SORTS"); sorts != "" {
+		limits.AllowedSorts = parseList(sorts)
+	}
+	if filters := os.Getenv(prefix + "_ALLOWED_FILTERS"); filters != "" {
+		limits.AllowedFilters = parseList(filters)
+	}
+	if wildcard := os.Getenv(prefix + "_ALLOW_LEADING_WILDCARD"); wildcard != "" {
+		limits.AllowLeadingWildcard = wildcard == "true"
+	}
+	return limits
+}
+
+// parseList splits a comma separated list, dropping blank entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseAuthSchemes parses a comma separated list of authentication schemes
+// (hmac, jwt), defaulting to hmac. Unknown schemes are logged and dropped.
+func parseAuthSchemes(value string) []string {
+	var schemes []string
+	for _, scheme := range parseList(strings.ToLower(value)) {
+		if scheme != "hmac" && scheme != "jwt" {
+			log.Printf("Ignoring unknown AUTH_SCHEMES entry %q", scheme)
+			continue
+		}
+		schemes = append(schemes, scheme)
+	}
+	if len(schemes) == 0 {
+		return []string{"hmac"}
+	}
+	return schemes
+}
+
+// parseSourceInts parses a comma separated list of source:number pairs such
+// as "linkedin:200,apify indeed:0", keyed by lowercased source. Invalid
+// entries are logged and dropped.
+func parseSourceInts(value string) map[string]int {
+	values := make(map[string]int)
+	for _, item := range parseList(value) {
+		source, number, ok := strings.Cut(item, ":")
+		n, err := strconv.Atoi(strings.TrimSpace(number))
+		if !ok || err != nil || n < 0 {
+			log.Printf("Invalid source setting %q, ignoring", item)
+			continue
+		}
+		values[strings.ToLower(strings.TrimSpace(source))] = n
+	}
+	return values
+}
+
+// parseSourceFloats parses a comma separated list of source:number pairs such
+// as "jsearch:0.002,indeed:0.01", keyed by lowercased source. Invalid
+// entries are logged and dropped.
+func parseSourceFloats(value string) map[string]float64 {
+	values := make(map[string]float64)
+	for _, item := range parseList(value) {
+		source, number, ok := strings.Cut(item, ":")
+		n, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if !ok || err != nil || n < 0 {
+			log.Printf("Invalid source setting %q, ignoring", item)
+			continue
+		}
+		values[strings.ToLower(strings.TrimSpace(source))] = n
+	}
+	return values
+}
+
+// parseSourceToggles reads the ENABLE_<SOURCE>=true|false variables of
+// environ, keyed by lowercased source (ENABLE_APIFY_LINKEDIN is
+// apify_linkedin). Invalid values are logged and dropped.
+func parseSourceToggles(environ []string) map[string]bool {
+	toggles := make(map[string]bool)
+	for _, entry := range environ {
+		key, value, _ := strings.Cut(entry, "=")
+		source, ok := strings.CutPrefix(key, "ENABLE_")
+		if !ok || source == "" {
+			continue
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			log.Printf("Invalid %s %q, ignoring", key, value)
+			continue
+		}
+		toggles[strings.ToLower(source)] = enabled
+	}
+	return toggles
+}
+
+// parseSourceBounds parses a comma separated list of source:min-max interval
+// bounds such as "jsearch:2h-24h,linkedin:6h-72h", keyed by source. Invalid
+// entries are logged and dropped.
+func parseSourceBounds(value string) map[string]IntervalBounds {
+	bounds := make(map[string]IntervalBounds)
+	for _, item := range parseList(value) {
+		source, rng, ok := strings.Cut(item, ":")
+		minText, maxText, okRange := strings.Cut(rng, "-")
+		min, errMin := time.ParseDuration(strings.TrimSpace(minText))
+		max, errMax := time.ParseDuration(strings.TrimSpace(maxText))
+		if !ok || !okRange || errMin != nil || errMax != nil || min <= 0 || max < min {
+			log.Printf("Invalid scheduler bounds %q, ignoring", item)
+			continue
+		}
+		bounds[strings.TrimSpace(source)] = IntervalBounds{Min: min, Max: max}
+	}
+	return bounds
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,6 +16,7 @@ func setupEnvVars(t *testing.T) {
 	t.Setenv("MODE", "dev")
 	t.Setenv("PORT", "8080")
 	t.Setenv("API_KEY", "test-api-key")
+	t.Setenv("CRON_API_KEY", "test-cron-api-key")
 	t.Setenv("ALLOWED_ORIGINS", "https://example.com,https://app.example.com")
 	t.Setenv("POSTGRES_CONNECTION_LOCAL", "postgres://localhost:5432/testdb")
 	t.Setenv("POSTGRES_CONNECTION_PROD", "postgres://prod:5432/proddb")
@@ -29,6 +31,7 @@ func clearEnvVars(t *testing.T) {
 		"MODE",
 		"PORT",
 		"API_KEY",
+		"CRON_API_KEY",
 		"ALLOWED_ORIGINS",
 		"POSTGRES_CONNECTION_LOCAL",
 		"POSTGRES_CONNECTION_PROD",
@@ -59,6 +62,31 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, "8080", cfg.Port)
 	assert.Equal(t, "test-api-key", cfg.APIKey)
 	assert.Equal(t, "postgres://localhost:5432/testdb", cfg.DBConnStr)
+	assert.Equal(t, "info", cfg.LogLevel)
+	assert.Equal(t, "text", cfg.LogFormat)
+
+	// Cursors are signed with the cron API key unless CURSOR_SECRET is set
+	assert.Equal(t, cfg.CronAPIKey, cfg.CursorSecret)
+
+	// Only the HMAC scheme is enabled by default; bearer tokens would be
+	// signed with the cron API key
+	assert.Equal(t, []string{"hmac"}, cfg.AuthSchemes)
+	assert.Equal(t, cfg.CronAPIKey, cfg.JWTSecret)
+	assert.Equal(t, 15*time.Minute, cfg.JWTTTL)
+
+	// Admin endpoints accept the cron API key unless ADMIN_API_KEYS is set
+	assert.Equal(t, []string{cfg.CronAPIKey}, cfg.AdminAPIKeys)
+	assert.Empty(t, cfg.AdminAddr)
+
+	// Connection pool defaults
+	assert.Equal(t, 20, cfg.DBMaxOpenConns)
+	assert.Equal(t, 2, cfg.DBMinConns)
+	assert.Equal(t, 30*time.Minute, cfg.DBConnMaxLifetime)
+	assert.Equal(t, 5*time.Minute, cfg.DBConnMaxIdleTime)
+	assert.Equal(t, 10*time.Second, cfg.DBQueryTimeout)
+	assert.Equal(t, 30*time.Second, cfg.JobsCacheMaxAge)
+	assert.Equal(t, time.Minute, cfg.ResponseCacheTTL)
+	assert.Equal(t, 500, cfg.ResponseCacheMaxEntries)
 
 	// Test AllowedOrigins parsing
 	expectedOrigins := []string{"https://example.com", "https://app.example.com"}
@@ -71,6 +99,7 @@ func TestLoadConfigMissingEnv(t *testing.T) {
 
 	// Set minimal required variables
 	t.Setenv("API_KEY", "test-api-key")
+	t.Setenv("CRON_API_KEY", "test-cron-api-key")
 
 	// Load the config
 	cfg, err := LoadConfig()
@@ -85,6 +114,36 @@ func TestLoadConfigMissingEnv(t *testing.T) {
 
 	// Check that allowed origins defaults to wildcard
 	assert.Equal(t, []string{"*"}, cfg.AllowedOrigins)
+
+	// Expired jobs are swept hourly by default
+	assert.Equal(t, time.Hour, cfg.ExpirySweepInterval)
+
+	// The scheduler stays off unless explicitly enabled
+	assert.False(t, cfg.SchedulerEnabled)
+	assert.Equal(t, 24*time.Hour, cfg.SchedulerDefaultInterval)
+
+	// Fetchers retry three times by default
+	assert.Equal(t, 3, cfg.FetchMaxAttempts)
+	assert.Equal(t, time.Second, cfg.FetchRetryBaseDelay)
+	assert.Equal(t, 30*time.Second, cfg.FetchRetryMaxDelay)
+
+	// Company enrichment runs every 15 minutes
+	assert.Equal(t, 15*time.Minute, cfg.EnrichmentInterval)
+
+	// Translation is off until a translator is chosen, and targets English
+	assert.Equal(t, "", cfg.Translator)
+	assert.Equal(t, "en", cfg.TranslateTo)
+
+	// The public tier is restricted, the internal tier is not
+	assert.Equal(t, 100, cfg.PublicTier.MaxPageSize)
+	assert.Equal(t, 1000, cfg.PublicTier.MaxOffset)
+	assert.Zero(t, cfg.InternalTier.MaxOffset)
+	assert.False(t, cfg.PublicTier.AllowLeadingWildcard)
+	assert.NotContains(t, cfg.PublicTier.AllowedFilters, "include_expired")
+	assert.NotContains(t, cfg.PublicTier.AllowedFilters, "include_duplicates")
+	assert.Contains(t, cfg.PublicTier.AllowedFilters, "language")
+	assert.Contains(t, cfg.InternalTier.AllowedFilters, "language")
+	assert.True(t, cfg.InternalTier.AllowLeadingWildcard)
 }
 
 func TestLoadConfigProdMode(t *testing.T) {
@@ -105,6 +164,19 @@ func TestLoadConfigProdMode(t *testing.T) {
 	assert.Equal(t, "postgres://prod:5432/proddb", cfg.DBConnStr)
 }
 
+func TestLoadConfigExampleMode(t *testing.T) {
+	// Example mode needs no secrets at all
+	clearEnvVars(t)
+	t.Setenv("MODE", ModeExample)
+
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, ModeExample, cfg.Mode)
+	assert.Equal(t, ExampleAPIKey, cfg.APIKey)
+	assert.Equal(t, ExampleCronAPIKey, cfg.CronAPIKey)
+	assert.Empty(t, cfg.RapidAPIKey)
+}
+
 func TestParseAllowedOrigins(t *testing.T) {
 	// Test with multiple origins
 	origins := parseAllowedOrigins("https://example.com,https://app.example.com")
@@ -118,3 +190,73 @@ func TestParseAllowedOrigins(t *testing.T) {
 	origins = parseAllowedOrigins("")
 	assert.Equal(t, []string{"*"}, origins)
 }
+
+func TestParseDuration(t *testing.T) {
+	t.Setenv("TEST_DURATION", "30m")
+	assert.Equal(t, 30*time.Minute, parseDuration("TEST_DURATION", time.Hour))
+
+	// Invalid values fall back to the default
+	t.Setenv("TEST_DURATION", "soon")
+	assert.Equal(t, time.Hour, parseDuration("TEST_DURATION", time.Hour))
+
+	// Zero disables features that accept an interval
+	t.Setenv("TEST_DURATION", "0")
+	assert.Equal(t, time.Duration(0), parseDuration("TEST_DURATION", time.Hour))
+}
+
+func TestParseInt(t *testing.T) {
+	t.Setenv("TEST_INT", "5")
+	assert.Equal(t, 5, parseInt("TEST_INT", 3))
+
+	// Invalid and non-positive values fall back to the default
+	t.Setenv("TEST_INT", "many")
+	assert.Equal(t, 3, parseInt("TEST_INT", 3))
+	t.Setenv("TEST_INT", "0")
+	assert.Equal(t, 3, parseInt("TEST_INT", 3))
+}
+
+func TestParseSourceInts(t *testing.T) {
+	assert.Equal(t, map[string]int{"linkedin": 200, "apify indeed": 0},
+		parseSourceInts("LinkedIn:200, apify indeed:0, jsearch:short, jsearch"))
+	assert.Empty(t, parseSourceInts(""))
+}
+
+func TestParseSourceFloats(t *testing.T) {
+	assert.Equal(t, map[string]float64{"jsearch": 0.002, "apify indeed": 0},
+		parseSourceFloats("JSearch:0.002, apify indeed:0, linkedin:-1, linkedin:cheap, linkedin"))
+	assert.Empty(t, parseSourceFloats(""))
+}
+
+func TestParseSourceBounds(t *testing.T) {
+	assert.Equal(t, map[string]IntervalBounds{
+		"jsearch":  {Min: 2 * time.Hour, Max: 24 * time.Hour},
+		"linkedin": {Min: 6 * time.Hour, Max: 72 * time.Hour},
+	}, parseSourceBounds("jsearch:2h-24h, linkedin:6h-72h, indeed:24h-1h, indeed:soon-later, indeed"))
+	assert.Empty(t, parseSourceBounds(""))
+}
+
+func TestParseSourceToggles(t *testing.T) {
+	assert.Equal(t, map[string]bool{"jsearch": false, "apify_linkedin": true},
+		parseSourceToggles([]string{"ENABLE_JSEARCH=false", "ENABLE_APIFY_LINKEDIN=1", "ENABLE_INDEED=maybe", "ENABLE_=false", "PATH=/bin"}))
+	assert.Empty(t, parseSourceToggles(nil))
+}
+
+func TestParseTierLimits(t *testing.T) {
+	def := TierLimits{MaxPageSize: 100, AllowedSorts: []string{"newest"}}
+
+	t.Setenv("TEST_TIER_MAX_PAGE_SIZE", "25")
+	t.Setenv("TEST_TIER_MAX_OFFSET", "500")
+	t.Setenv("TEST_TIER_ALLOWED_SORTS", "newest, title")
+	t.Setenv("TEST_TIER_ALLOWED_FILTERS", "q,,source")
+	t.Setenv("TEST_TIER_ALLOW_LEADING_WILDCARD", "true")
+
+	limits := parseTierLimits("TEST_TIER", def)
+	assert.Equal(t, 25, limits.MaxPageSize)
+	assert.Equal(t, 500, limits.MaxOffset)
+	assert.Equal(t, []string{"newest", "title"}, limits.AllowedSorts)
+	assert.Equal(t, []string{"q", "source"}, limits.AllowedFilters)
+	assert.True(t, limits.AllowLeadingWildcard)
+
+	// Unset variables keep the defaults
+	assert.Equal(t, def, parseTierLimits("OTHER_TIER", def))
+}
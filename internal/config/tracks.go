@@ -0,0 +1,103 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// Track is a technology the board collects jobs for. Jobs are kept when they
+// name one of its keywords and are tagged with its name, filtered on with
+// /api/jobs?track=.
+type Track struct {
+	Name string
+	// Include are the keywords of its jobs and Exclude the look-alike
+	// phrases ignored before matching them, e.g. "go-to-market"
+	Include []string
+	Exclude []string
+	// Queries are the search terms sent to the job sources
+	Queries []string
+}
+
+// knownTracks are the built-in tracks by name
+var knownTracks = map[string]Track{
+	"go": {
+		Name:    "go",
+		Include: []string{"go", "golang"},
+		Exclude: []string{
+			"go-to-market", "go to market", "go-live", "go live", "on the go", "good to go", "ready to go",
+			"go above and beyond", "go the extra mile", "let go",
+		},
+		Queries: []string{"golang"},
+	},
+	"rust": {
+		Name:    "rust",
+		Include: []string{"rust", "rustlang"},
+		Queries: []string{"rust"},
+	},
+	"python": {
+		Name:    "python",
+		Include: []string{"python", "django", "fastapi", "flask"},
+		Queries: []string{"python"},
+	},
+	"java": {
+		Name:    "java",
+		Include: []string{"java", "spring boot", "kotlin"},
+		Queries: []string{"java"},
+	},
+	"javascript": {
+		Name:    "javascript",
+		Include: []string{"javascript", "typescript", "node.js", "nodejs", "react"},
+		Queries: []string{"javascript", "typescript"},
+	},
+}
+
+// DefaultTracks are the tracks of a board without TRACKS: Go only
+func DefaultTracks() []Track {
+	return []Track{knownTracks["go"]}
+}
+
+// ActiveTracks returns the configured tracks, the default ones when none are
+func (c *Config) ActiveTracks() []Track {
+	if len(c.Tracks) == 0 {
+		return DefaultTracks()
+	}
+	return c.Tracks
+}
+
+// parseTracks reads the comma separated track names of TRACKS, each built-in
+// track overridable and any other one defined with TRACK_<NAME>_INCLUDE,
+// TRACK_<NAME>_EXCLUDE and TRACK_<NAME>_QUERIES (comma separated, queries
+// defaulting to the name). Tracks without keywords are logged and dropped.
+func parseTracks(value string) []Track {
+	var tracks []Track
+	seen := make(map[string]bool)
+	for _, name := range parseList(strings.ToLower(value)) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		track, ok := knownTracks[name]
+		if !ok {
+			track = Track{Name: name, Queries: []string{name}}
+		}
+		prefix := "TRACK_" + strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(name))
+		if include := os.Getenv(prefix + "_INCLUDE"); include != "" {
+			track.Include = parseList(include)
+		}
+		if exclude := os.Getenv(prefix + "_EXCLUDE"); exclude != "" {
+			track.Exclude = parseList(exclude)
+		}
+		if queries := os.Getenv(prefix + "_QUERIES"); queries != "" {
+			track.Queries = parseList(queries)
+		}
+
+		if len(track.Include) == 0 {
+			log.Printf("Track %q has no keywords (set %s_INCLUDE), ignoring", name, prefix)
+			continue
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
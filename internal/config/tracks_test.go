@@ -0,0 +1,21 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTracks(t *testing.T) {
+	t.Setenv("TRACK_RUST_QUERIES", "rust developer, rust engineer")
+	t.Setenv("TRACK_ELIXIR_INCLUDE", "elixir,phoenix")
+
+	tracks := parseTracks("Go, rust, elixir, cobol, go")
+	assert.Len(t, tracks, 3)
+	assert.Equal(t, knownTracks["go"], tracks[0])
+	assert.Equal(t, Track{Name: "rust", Include: []string{"rust", "rustlang"}, Queries: []string{"rust developer", "rust engineer"}}, tracks[1])
+	assert.Equal(t, Track{Name: "elixir", Include: []string{"elixir", "phoenix"}, Queries: []string{"elixir"}}, tracks[2])
+
+	assert.Equal(t, DefaultTracks(), (&Config{}).ActiveTracks())
+	assert.Equal(t, tracks, (&Config{Tracks: tracks}).ActiveTracks())
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// analyticsJobs selects the jobs counted by the analytics: listed ones (open,
+// not hidden, not a duplicate of a listed job) of the track in $1, any track when empty
+const analyticsJobs = `
+	FROM jobs
+	WHERE (exp_date IS NULL OR exp_date > NOW())
+		AND NOT hidden
+		AND NOT ` + DuplicateOfListedJob + `
+		AND ($1 = '' OR $1 = ANY(tracks))`
+
+// NamedCount is the number of listed jobs sharing a value, e.g. a source
+type NamedCount struct {
+	Name string `json:"name"`
+	Jobs int    `json:"jobs"`
+}
+
+// WeekCount is the number of listed jobs posted in the week starting on Week
+type WeekCount struct {
+	Week time.Time `json:"week"`
+	Jobs int       `json:"jobs"`
+}
+
+// RemoteCount splits the listed jobs into remote and onsite ones, Unknown
+// counting those whose source does not say
+type RemoteCount struct {
+	Remote  int `json:"remote"`
+	Onsite  int `json:"onsite"`
+	Unknown int `json:"unknown"`
+}
+
+// JobAnalytics aggregates the listed jobs for the stats dashboard
+type JobAnalytics struct {
+	Total     int          `json:"total"`
+	Sources   []NamedCount `json:"sources"`
+	Weeks     []WeekCount  `json:"weeks"`
+	Companies []NamedCount `json:"top_companies"`
+	Remote    RemoteCount  `json:"remote"`
+}
+
+// GetJobAnalytics counts the listed jobs of a track (any when empty) per
+// source, per posting week over the last weeks weeks, for the companies
+// hiring the most and by remote status
+func GetJobAnalytics(ctx context.Context, db *sql.DB, track string, weeks, companies int) (*JobAnalytics, error) {
+	analytics := &JobAnalytics{}
+
+	var err error
+	analytics.Sources, err = namedCounts(ctx, db, `
+		SELECT source, COUNT(*)`+analyticsJobs+`
+		GROUP BY source ORDER BY COUNT(*) DESC, source`, track)
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range analytics.Sources {
+		analytics.Total += source.Jobs
+	}
+
+	analytics.Companies, err = namedCounts(ctx, db, `
+		SELECT company, COUNT(*)`+analyticsJobs+`
+			AND COALESCE(company, '') <> ''
+		GROUP BY company ORDER BY COUNT(*) DESC, company
+		LIMIT $2`, track, companies)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT date_trunc('week', posted_at) AS week, COUNT(*)`+analyticsJobs+`
+			AND posted_at >= date_trunc('week', NOW()) - make_interval(weeks => $2 - 1)
+		GROUP BY week ORDER BY week`, track, weeks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	analytics.Weeks = []WeekCount{}
+	for rows.Next() {
+		var week WeekCount
+		if err := rows.Scan(&week.Week, &week.Jobs); err != nil {
+			return nil, err
+		}
+		analytics.Weeks = append(analytics.Weeks, week)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE is_remote),
+			COUNT(*) FILTER (WHERE NOT is_remote),
+			COUNT(*) FILTER (WHERE is_remote IS NULL)`+analyticsJobs, track,
+	).Scan(&analytics.Remote.Remote, &analytics.Remote.Onsite, &analytics.Remote.Unknown)
+	if err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
+
+// FindListedSalaries returns the salary texts of the listed jobs of a track
+// (any when empty), leaving out those flagged as likely misparsed
+func FindListedSalaries(ctx context.Context, db *sql.DB, track string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT salary`+analyticsJobs+`
+			AND COALESCE(salary, '') <> ''
+			AND salary_flag IS NULL`, track)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var salaries []string
+	for rows.Next() {
+		var salary string
+		if err := rows.Scan(&salary); err != nil {
+			return nil, err
+		}
+		salaries = append(salaries, salary)
+	}
+	return salaries, rows.Err()
+}
+
+// namedCounts scans the (name, count) rows of a GROUP BY query
+func namedCounts(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]NamedCount, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []NamedCount{}
+	for rows.Next() {
+		var count NamedCount
+		if err := rows.Scan(&count.Name, &count.Jobs); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// ArchiveExpiredJobs moves every job whose exp_date has passed into the
+// jobs_archive table and returns the number of jobs archived. The full row is
+// kept as JSON so the archive survives later changes to the jobs schema. A job
+// archived before, then synced again, replaces its older copy.
+func ArchiveExpiredJobs(ctx context.Context, db *sql.DB) (int64, error) {
+	result, err := db.ExecContext(ctx, `
+		WITH expired AS (
+			DELETE FROM jobs
+			WHERE exp_date IS NOT NULL AND exp_date <= NOW()
+			RETURNING *
+		)
+		INSERT INTO jobs_archive (id, job_id, source, exp_date, company_id, data)
+		SELECT id, job_id, source, exp_date, company_id, row_to_json(expired)::jsonb
+		FROM expired
+		ON CONFLICT (id) DO UPDATE SET
+			job_id = EXCLUDED.job_id,
+			source = EXCLUDED.source,
+			exp_date = EXCLUDED.exp_date,
+			company_id = EXCLUDED.company_id,
+			data = EXCLUDED.data,
+			archived_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if archived > 0 {
+		log.Printf("Archived %d expired jobs", archived)
+	}
+
+	return archived, nil
+}
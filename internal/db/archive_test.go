@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveExpiredJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Jobs archived before replace their older copy instead of failing the run
+	mock.ExpectExec("^WITH expired AS \\( DELETE FROM jobs (.+) INSERT INTO jobs_archive (.+) ON CONFLICT \\(id\\) DO UPDATE SET (.+) data = EXCLUDED.data").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	archived, err := ArchiveExpiredJobs(context.Background(), db)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), archived)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
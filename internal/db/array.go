@@ -0,0 +1,104 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// StringArray is a Postgres text[] value, passed as a query argument or
+// scanned from a column in the array text format. A nil StringArray is NULL.
+type StringArray []string
+
+// Array wraps a []string for use as a text[] query argument
+func Array(values []string) StringArray {
+	return StringArray(values)
+}
+
+// ScanArray wraps a []string as the scan destination of a text[] column,
+// NULL leaving it nil
+func ScanArray(dest *[]string) *StringArray {
+	return (*StringArray)(dest)
+}
+
+// Value implements driver.Valuer
+func (a StringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	quoted := make([]string, len(a))
+	for i, value := range a {
+		value = strings.ReplaceAll(value, `\`, `\\`)
+		quoted[i] = `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}", nil
+}
+
+// Scan implements sql.Scanner
+func (a *StringArray) Scan(src interface{}) error {
+	var text string
+	switch src := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		text = src
+	case []byte:
+		text = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into a text array", src)
+	}
+
+	values, err := parseArray(text)
+	if err != nil {
+		return err
+	}
+	*a = values
+	return nil
+}
+
+// parseArray parses a one-dimensional array in the Postgres text format,
+// e.g. {take_home,"pair programming"}. NULL elements become empty strings.
+func parseArray(text string) ([]string, error) {
+	if len(text) < 2 || text[0] != '{' || text[len(text)-1] != '}' {
+		return nil, fmt.Errorf("invalid array %q", text)
+	}
+	body := text[1 : len(text)-1]
+	values := []string{}
+	if body == "" {
+		return values, nil
+	}
+
+	for i := 0; i <= len(body); {
+		var value strings.Builder
+		if i < len(body) && body[i] == '"' {
+			// Quoted element, with backslash escapes
+			i++
+			for ; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				value.WriteByte(body[i])
+			}
+			if i == len(body) {
+				return nil, fmt.Errorf("invalid array %q", text)
+			}
+			i++
+		} else {
+			start := i
+			for i < len(body) && body[i] != ',' {
+				i++
+			}
+			if element := body[start:i]; element != "NULL" {
+				value.WriteString(element)
+			}
+		}
+		values = append(values, value.String())
+
+		if i < len(body) && body[i] != ',' {
+			return nil, fmt.Errorf("invalid array %q", text)
+		}
+		i++
+	}
+	return values, nil
+}
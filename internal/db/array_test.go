@@ -0,0 +1,49 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringArrayValue(t *testing.T) {
+	value, err := Array([]string{"take_home", `say "hi"`, `a\b`, ""}).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"take_home","say \"hi\"","a\\b",""}`, value)
+
+	value, err = Array(nil).Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	value, err = Array([]string{}).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", value)
+}
+
+func TestStringArrayScan(t *testing.T) {
+	tests := []struct {
+		src      interface{}
+		expected []string
+	}{
+		{nil, nil},
+		{"{}", []string{}},
+		{"{take_home,live_coding}", []string{"take_home", "live_coding"}},
+		{[]byte(`{"pair programming","say \"hi\"",plain}`), []string{"pair programming", `say "hi"`, "plain"}},
+		{`{"a,b",NULL,""}`, []string{"a,b", "", ""}},
+	}
+	for _, tt := range tests {
+		var values []string
+		assert.NoError(t, ScanArray(&values).Scan(tt.src), tt.src)
+		assert.Equal(t, tt.expected, values, tt.src)
+	}
+
+	// Round trip
+	var values []string
+	encoded, _ := Array([]string{`x "y"`, `\`}).Value()
+	assert.NoError(t, ScanArray(&values).Scan(encoded))
+	assert.Equal(t, []string{`x "y"`, `\`}, values)
+
+	for _, invalid := range []interface{}{"take_home", `{"open}`, `{"a"b}`, 42} {
+		assert.Error(t, ScanArray(&values).Scan(invalid), invalid)
+	}
+}
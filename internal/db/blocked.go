@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+)
+
+// defaultBlockedCompanies seed the blocked_companies table when it is created
+var defaultBlockedCompanies = []string{"canonical", "crossover"}
+
+// BlockedCompany is a company whose jobs are skipped on save. Name matches any
+// company containing its words once both are normalized (see
+// analyzer.CompanyKey).
+type BlockedCompany struct {
+	Name      string    `json:"name"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var (
+	blockedMu sync.RWMutex
+	blocked   = defaultBlockedCompanies
+)
+
+// SetBlockedCompanies sets the names IsBlockedCompany matches
+func SetBlockedCompanies(names []string) {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = analyzer.CompanyKey(name); name != "" {
+			normalized = append(normalized, name)
+		}
+	}
+
+	blockedMu.Lock()
+	defer blockedMu.Unlock()
+	blocked = normalized
+}
+
+// IsBlockedCompany checks if the company is in the blocked list, so
+// "Crossover", "CROSSOVER NIGERIA" and "Crossover for Work Ltd" all are but
+// "Crossoverlabs" is not. The company an alias stands for is checked too.
+func IsBlockedCompany(companyName string) bool {
+	key, company := analyzer.CompanyKey(companyName), analyzer.NormalizeCompany(companyName)
+	if key == "" {
+		return false
+	}
+
+	blockedMu.RLock()
+	defer blockedMu.RUnlock()
+	for _, name := range blocked {
+		if containsWords(key, name) || containsWords(company, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWords reports whether the space separated words of name appear in
+// those of company, in order and whole
+func containsWords(company, name string) bool {
+	return strings.Contains(" "+company+" ", " "+name+" ")
+}
+
+// NormalizeBlockedCompany returns the stored form of a blocked company name
+func NormalizeBlockedCompany(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ListBlockedCompanies returns the blocked companies by name
+func ListBlockedCompanies(ctx context.Context, db *sql.DB) ([]BlockedCompany, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT name, reason, created_at FROM blocked_companies ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	companies := []BlockedCompany{}
+	for rows.Next() {
+		var company BlockedCompany
+		if err := rows.Scan(&company.Name, &company.Reason, &company.CreatedAt); err != nil {
+			return nil, err
+		}
+		companies = append(companies, company)
+	}
+	return companies, rows.Err()
+}
+
+// LoadBlockedCompanies makes IsBlockedCompany match the stored blocked
+// companies
+func LoadBlockedCompanies(ctx context.Context, db *sql.DB) error {
+	companies, err := ListBlockedCompanies(ctx, db)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(companies))
+	for i, company := range companies {
+		names[i] = company.Name
+	}
+	SetBlockedCompanies(names)
+	return nil
+}
+
+// BlockCompany blocks a company, updating the reason of one already blocked,
+// and reloads the blocked companies
+func BlockCompany(ctx context.Context, db *sql.DB, name, reason string) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO blocked_companies (name, reason) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET reason = EXCLUDED.reason`,
+		NormalizeBlockedCompany(name), strings.TrimSpace(reason))
+	if err != nil {
+		return err
+	}
+	return LoadBlockedCompanies(ctx, db)
+}
+
+// UnblockCompany unblocks a company and reloads the blocked companies. It
+// reports false when the company was not blocked.
+func UnblockCompany(ctx context.Context, db *sql.DB, name string) (bool, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM blocked_companies WHERE name = $1`, NormalizeBlockedCompany(name))
+	if err != nil {
+		return false, err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if removed == 0 {
+		return false, nil
+	}
+	return true, LoadBlockedCompanies(ctx, db)
+}
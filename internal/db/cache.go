@@ -1 +1,218 @@
 package db
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheStore caches rendered payloads and counts requests per client. Cache
+// keeps them in process; RedisCache shares them between server replicas.
+type CacheStore interface {
+	// Get returns the unexpired payload of key
+	Get(key string) ([]byte, bool)
+	// Set stores the payload of key for the TTL of the store
+	Set(key string, value []byte)
+	// SetGeneration drops every payload when generation moved since the
+	// previous call
+	SetGeneration(generation int64)
+	// Invalidate drops every payload
+	Invalidate()
+	// Incr counts an event of key in a window starting with its first event,
+	// returning the count and the time left in the window. Counters are not
+	// dropped by Invalidate.
+	Incr(key string, window time.Duration) (int64, time.Duration, error)
+}
+
+// Cache is an in-memory cache of rendered payloads (response bodies, feed
+// documents) by key. Entries expire after the TTL and the least recently
+// used ones are dropped beyond maxEntries. It is safe for concurrent use.
+type Cache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	// lru orders the entries, the most recently used first
+	lru        *list.List
+	generation int64
+	counters   map[string]*cacheCounter
+	// nextSweep is when Incr next drops the counters whose window ended
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+// cacheEntry is a cached payload and its expiry
+type cacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// cacheCounter counts the events of a key until its window ends
+type cacheCounter struct {
+	count   int64
+	expires time.Time
+}
+
+// NewCache creates a cache keeping entries for ttl, at most maxEntries of them
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		counters:   make(map[string]*cacheCounter),
+		now:        time.Now,
+	}
+}
+
+// Get returns the unexpired payload of key
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(element)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set stores the payload of key, dropping the least recently used entry when
+// the cache is full. The payload must not be modified afterwards.
+func (c *Cache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.value, entry.expires = value, expires
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// SetString stores a string payload
+func (c *Cache) SetString(key, value string) {
+	c.Set(key, []byte(value))
+}
+
+// remove drops an entry; c.mu must be held
+func (c *Cache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
+
+// Invalidate drops every entry
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// SetGeneration drops every entry when generation differs from the one of
+// the previous call, so a counter of writes to the cached data (e.g.
+// services.JobSaves) invalidates the cache as it moves
+func (c *Cache) SetGeneration(generation int64) {
+	c.mu.Lock()
+	changed := generation != c.generation
+	c.generation = generation
+	c.mu.Unlock()
+
+	if changed {
+		c.Invalidate()
+	}
+}
+
+// Incr implements CacheStore
+func (c *Cache) Incr(key string, window time.Duration) (int64, time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	// Forget the counters whose window ended, so the map stays bounded
+	if now.After(c.nextSweep) {
+		c.dropExpiredCounters(now)
+		c.nextSweep = now.Add(window)
+	}
+	counter, ok := c.counters[key]
+	if !ok || !now.Before(counter.expires) {
+		counter = &cacheCounter{expires: now.Add(window)}
+		c.counters[key] = counter
+	}
+	counter.count++
+	return counter.count, counter.expires.Sub(now), nil
+}
+
+// dropExpiredCounters drops the counters whose window ended at now,
+// returning how many were dropped; c.mu must be held
+func (c *Cache) dropExpiredCounters(now time.Time) int {
+	dropped := 0
+	for key, counter := range c.counters {
+		if !now.Before(counter.expires) {
+			delete(c.counters, key)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// Len returns the number of entries, expired ones included until cleaned up
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Cleanup drops the expired entries and counters, returning how many were
+// dropped
+func (c *Cache) Cleanup() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	dropped := c.dropExpiredCounters(now)
+	for element := c.lru.Back(); element != nil; {
+		prev := element.Prev()
+		if !now.Before(element.Value.(*cacheEntry).expires) {
+			c.remove(element)
+			dropped++
+		}
+		element = prev
+	}
+	return dropped
+}
+
+// StartCleanup runs Cleanup every interval in the background until the
+// returned stop function is called
+func (c *Cache) StartCleanup(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.Cleanup()
+			}
+		}
+	}()
+	return cancel
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	cache := NewCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("jobs", []byte(`{"count":1}`))
+	cache.SetString("feed.xml", "<rss/>")
+	value, ok := cache.Get("jobs")
+	assert.True(t, ok)
+	assert.Equal(t, `{"count":1}`, string(value))
+
+	// "feed.xml" is the least recently used entry
+	cache.SetString("jobs?page=2", "{}")
+	_, ok = cache.Get("feed.xml")
+	assert.False(t, ok)
+	assert.Equal(t, 2, cache.Len())
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get("jobs")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, 1, cache.Cleanup())
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCacheGeneration(t *testing.T) {
+	cache := NewCache(time.Minute, 0)
+	cache.SetString("jobs", "{}")
+
+	cache.SetGeneration(0)
+	_, ok := cache.Get("jobs")
+	assert.True(t, ok)
+
+	cache.SetGeneration(1)
+	_, ok = cache.Get("jobs")
+	assert.False(t, ok)
+
+	cache.SetString("jobs", "{}")
+	cache.Invalidate()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCacheIncr(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	cache := NewCache(time.Minute, 0)
+	cache.now = func() time.Time { return now }
+
+	count, left, err := cache.Incr("ratelimit:203.0.113.7", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, time.Hour, left)
+
+	now = now.Add(10 * time.Minute)
+	count, left, _ = cache.Incr("ratelimit:203.0.113.7", time.Hour)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, 50*time.Minute, left)
+
+	// Counters survive invalidation but not the end of their window
+	cache.Invalidate()
+	now = now.Add(50 * time.Minute)
+	count, _, _ = cache.Incr("ratelimit:203.0.113.7", time.Hour)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"Go9jaJobs/internal/enrichment"
+)
+
+// SaveCompanyDetails stores the details of a company, linked to the company
+// of the jobs of its domain. Existing details are only replaced by the same or
+// a higher-quality source (BrandFetch replaces OpenGraph, never the reverse);
+// empty fields keep their stored value.
+func SaveCompanyDetails(ctx context.Context, db *sql.DB, details *enrichment.CompanyDetails) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO company_details (domain, name, logo_url, description, theme_color, industries, links, source, company_id, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, (`+domainCompany+`), NOW())
+		ON CONFLICT (domain) DO UPDATE SET
+			name = COALESCE(NULLIF(EXCLUDED.name, ''), company_details.name),
+			logo_url = COALESCE(NULLIF(EXCLUDED.logo_url, ''), company_details.logo_url),
+			description = COALESCE(NULLIF(EXCLUDED.description, ''), company_details.description),
+			theme_color = COALESCE(NULLIF(EXCLUDED.theme_color, ''), company_details.theme_color),
+			industries = COALESCE(EXCLUDED.industries, company_details.industries),
+			links = COALESCE(EXCLUDED.links, company_details.links),
+			company_id = COALESCE(company_details.company_id, EXCLUDED.company_id),
+			source = EXCLUDED.source,
+			updated_at = NOW()
+		WHERE company_details.source = EXCLUDED.source OR company_details.source = $9`,
+		details.Domain, details.Name, details.LogoURL, details.Description, details.ThemeColor,
+		jsonList(details.Industries), jsonList(details.Links), details.Source,
+		enrichment.SourceOpenGraph,
+	)
+	return err
+}
+
+// domainCompany selects the company most jobs of domain $1 are listed under
+const domainCompany = `
+	SELECT company_id FROM jobs
+	WHERE company_domain = $1 AND company_id IS NOT NULL
+	GROUP BY company_id
+	ORDER BY COUNT(*) DESC, company_id
+	LIMIT 1`
+
+// jsonList encodes a non-empty slice for a JSONB column, or NULL
+func jsonList[T any](items []T) interface{} {
+	if len(items) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil
+	}
+	return string(data)
+}
+
+// Company is a company with its enrichment details and open job count
+type Company struct {
+	enrichment.CompanyDetails
+	OpenJobs  int       `json:"open_jobs"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OpenJobsOfCompanyDetails selects the number of unexpired jobs of the
+// company details cd, those of the company linked to them or of their domain,
+// leaving out the jobs an admin hid. Each key is looked up through its own
+// index, a job matching both counting once.
+const OpenJobsOfCompanyDetails = `(
+	SELECT COUNT(*) FROM (
+		SELECT id, exp_date FROM jobs WHERE company_id = cd.company_id AND NOT hidden
+		UNION
+		SELECT id, exp_date FROM jobs WHERE company_domain = cd.domain AND NOT hidden
+	) cj
+	WHERE cj.exp_date IS NULL OR cj.exp_date > NOW())`
+
+// companySelect selects companies with the number of their unexpired jobs
+// (see OpenJobsOfCompanyDetails)
+const companySelect = `
+	SELECT cd.domain, COALESCE(cd.name, ''), COALESCE(cd.logo_url, ''), COALESCE(cd.description, ''),
+		COALESCE(cd.theme_color, ''), cd.industries, cd.links, cd.source, cd.updated_at,
+		` + OpenJobsOfCompanyDetails + `
+	FROM company_details cd`
+
+// scanCompany scans a row selected with companySelect
+func scanCompany(scanner interface{ Scan(...interface{}) error }) (*Company, error) {
+	var (
+		company    Company
+		industries []byte
+		links      []byte
+	)
+	err := scanner.Scan(
+		&company.Domain, &company.Name, &company.LogoURL, &company.Description,
+		&company.ThemeColor, &industries, &links, &company.Source, &company.UpdatedAt,
+		&company.OpenJobs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	company.Industries = []string{}
+	company.Links = []enrichment.Link{}
+	if len(industries) > 0 {
+		json.Unmarshal(industries, &company.Industries)
+	}
+	if len(links) > 0 {
+		json.Unmarshal(links, &company.Links)
+	}
+	return &company, nil
+}
+
+// ListCompanies returns enriched companies, those with the most open jobs first
+func ListCompanies(ctx context.Context, db *sql.DB, limit, offset int) ([]Company, error) {
+	rows, err := db.QueryContext(ctx, companySelect+`
+		ORDER BY 10 DESC, cd.domain
+		LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	companies := []Company{}
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			return nil, err
+		}
+		companies = append(companies, *company)
+	}
+	return companies, rows.Err()
+}
+
+// GetCompany returns the company of a domain, or sql.ErrNoRows
+func GetCompany(ctx context.Context, db *sql.DB, domain string) (*Company, error) {
+	row := db.QueryRowContext(ctx, companySelect+`
+		WHERE cd.domain = $1`,
+		domain,
+	)
+	return scanCompany(row)
+}
+
+// GetCompanyDetails returns the stored details of a domain, or sql.ErrNoRows
+func GetCompanyDetails(ctx context.Context, db *sql.DB, domain string) (*enrichment.CompanyDetails, error) {
+	details := &enrichment.CompanyDetails{Domain: domain}
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(logo_url, ''), COALESCE(description, ''), COALESCE(theme_color, ''), source
+		FROM company_details
+		WHERE domain = $1`, domain,
+	).Scan(&details.LogoURL, &details.Description, &details.ThemeColor, &details.Source)
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// FindCompanyURLsMissingLogos returns up to limit company URLs of jobs without
+// a logo that were not checked within recheckAfter, most recent jobs first.
+// Jobs of verified companies are left out: their logo is the one an admin set,
+// if any, not one guessed from their URL.
+func FindCompanyURLsMissingLogos(ctx context.Context, db *sql.DB, recheckAfter time.Duration, limit int) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT company_url
+		FROM jobs
+		WHERE COALESCE(company_logo, '') = ''
+			AND COALESCE(company_url, '') <> ''
+			AND (logo_checked_at IS NULL OR logo_checked_at < $1)
+			AND NOT EXISTS (SELECT 1 FROM companies c WHERE c.id = jobs.company_id AND c.verified)
+		GROUP BY company_url
+		ORDER BY MAX(created_at) DESC
+		LIMIT $2`,
+		time.Now().Add(-recheckAfter), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var companyURL string
+		if err := rows.Scan(&companyURL); err != nil {
+			return nil, err
+		}
+		urls = append(urls, companyURL)
+	}
+	return urls, rows.Err()
+}
+
+// SetCompanyLogo sets the logo of the jobs of companyURL that have none and
+// marks them as checked, recording source as the logo's provenance; an empty
+// logo only records the check
+func SetCompanyLogo(ctx context.Context, db *sql.DB, companyURL, logo, source string) (int64, error) {
+	res, err := db.ExecContext(ctx, `
+		UPDATE jobs
+		SET company_logo = NULLIF($2, ''),
+			provenance = CASE WHEN $2 = '' THEN provenance ELSE provenance || jsonb_build_object('company_logo', $3::text) END,
+			logo_checked_at = NOW(), updated_at = NOW()
+		WHERE company_url = $1 AND COALESCE(company_logo, '') = ''`,
+		companyURL, logo, source,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/enrichment"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveCompanyDetails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// OpenGraph details only replace OpenGraph details
+	mock.ExpectExec("^INSERT INTO company_details (.+) ON CONFLICT \\(domain\\) DO UPDATE (.+) WHERE company_details.source = EXCLUDED.source OR company_details.source = \\$9$").
+		WithArgs("paystack.com", "Paystack", "https://paystack.com/og.png", "Payments", "#011B33",
+			`["Fintech"]`, nil, enrichment.SourceOpenGraph, enrichment.SourceOpenGraph).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = SaveCompanyDetails(context.Background(), db, &enrichment.CompanyDetails{
+		Domain:      "paystack.com",
+		Name:        "Paystack",
+		Industries:  []string{"Fintech"},
+		LogoURL:     "https://paystack.com/og.png",
+		Description: "Payments",
+		ThemeColor:  "#011B33",
+		Source:      enrichment.SourceOpenGraph,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListCompanies(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	updated := time.Now()
+	mock.ExpectQuery("^SELECT (.+) SELECT id, exp_date FROM jobs WHERE company_id = cd.company_id AND NOT hidden UNION SELECT id, exp_date FROM jobs WHERE company_domain = cd.domain AND NOT hidden (.+) FROM company_details cd ORDER BY (.+) LIMIT \\$1 OFFSET \\$2$").
+		WithArgs(20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"domain", "name", "logo_url", "description", "theme_color", "industries", "links", "source", "updated_at", "open_jobs"}).
+			AddRow("paystack.com", "Paystack", "https://paystack.com/logo.png", "", "", []byte(`["Fintech"]`), []byte(`[{"name":"twitter","url":"https://twitter.com/paystack"}]`), enrichment.SourceBrandFetch, updated, 3).
+			AddRow("andela.com", "", "", "", "", nil, nil, enrichment.SourceOpenGraph, updated, 0))
+
+	companies, err := ListCompanies(context.Background(), db, 20, 0)
+	assert.NoError(t, err)
+	assert.Len(t, companies, 2)
+	assert.Equal(t, 3, companies[0].OpenJobs)
+	assert.Equal(t, []string{"Fintech"}, companies[0].Industries)
+	assert.Equal(t, "https://twitter.com/paystack", companies[0].Links[0].URL)
+	assert.Empty(t, companies[1].Industries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCompanyNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT (.+) FROM company_details cd WHERE cd.domain = \\$1$").
+		WithArgs("unknown.com").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = GetCompany(context.Background(), db, "unknown.com")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
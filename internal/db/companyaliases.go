@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+)
+
+// CompanyAlias is a company name sources use for another company, e.g.
+// "andela talent" for "andela". Both are stored as their analyzer.CompanyKey.
+type CompanyAlias struct {
+	Alias     string    `json:"alias"`
+	Company   string    `json:"company"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ErrSelfAlias is returned when a company would be its own alias
+var ErrSelfAlias = errors.New("alias and company are the same company")
+
+// ListCompanyAliases returns the company aliases by alias
+func ListCompanyAliases(ctx context.Context, db *sql.DB) ([]CompanyAlias, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT alias, company, created_at FROM company_aliases ORDER BY alias`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := []CompanyAlias{}
+	for rows.Next() {
+		var alias CompanyAlias
+		if err := rows.Scan(&alias.Alias, &alias.Company, &alias.CreatedAt); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
+
+// LoadCompanyAliases makes analyzer.NormalizeCompany resolve the stored
+// company aliases
+func LoadCompanyAliases(ctx context.Context, db *sql.DB) error {
+	aliases, err := ListCompanyAliases(ctx, db)
+	if err != nil {
+		return err
+	}
+	byAlias := make(map[string]string, len(aliases))
+	for _, alias := range aliases {
+		byAlias[alias.Alias] = alias.Company
+	}
+	analyzer.SetCompanyAliases(byAlias)
+	return nil
+}
+
+// SetCompanyAlias makes alias stand for company, replacing what it stood for
+// before, and returns the stored alias. Aliases of alias follow it to
+// company, and the jobs saved under alias are matched with those of company
+// from now on.
+func SetCompanyAlias(ctx context.Context, db *sql.DB, alias, company string) (*CompanyAlias, error) {
+	stored := &CompanyAlias{Alias: analyzer.CompanyKey(alias), Company: analyzer.NormalizeCompany(company)}
+	if stored.Alias == stored.Company {
+		return nil, ErrSelfAlias
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO company_aliases (alias, company) VALUES ($1, $2)
+		ON CONFLICT (alias) DO UPDATE SET company = EXCLUDED.company
+		RETURNING created_at`,
+		stored.Alias, stored.Company,
+	).Scan(&stored.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE company_aliases SET company = $2 WHERE company = $1`, stored.Alias, stored.Company); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := LoadCompanyAliases(ctx, db); err != nil {
+		return nil, err
+	}
+	if _, err := rekeyCompanies(ctx, db, `company_key = $1`, stored.Alias); err != nil {
+		return nil, err
+	}
+	return stored, linkJobCompanies(ctx, db)
+}
+
+// DeleteCompanyAlias removes an alias, the jobs saved under it being matched
+// by their own name again. It reports false when there was no such alias.
+func DeleteCompanyAlias(ctx context.Context, db *sql.DB, alias string) (bool, error) {
+	var company string
+	err := db.QueryRowContext(ctx,
+		`DELETE FROM company_aliases WHERE alias = $1 RETURNING company`, analyzer.CompanyKey(alias),
+	).Scan(&company)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := LoadCompanyAliases(ctx, db); err != nil {
+		return false, err
+	}
+	if _, err := rekeyCompanies(ctx, db, `company_key = $1`, company); err != nil {
+		return false, err
+	}
+	return true, linkJobCompanies(ctx, db)
+}
+
+// rekeyCompanies recomputes the company_key and fingerprint of the jobs
+// matching where from the title and company their source gave, relinking
+// their duplicates, and returns how many changed
+func rekeyCompanies(ctx context.Context, db *sql.DB, where string, args ...interface{}) (int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, COALESCE(admin_edits->>'title', title, ''), COALESCE(admin_edits->>'company', company, ''),
+			COALESCE(location, ''), company_key, fingerprint
+		FROM jobs
+		WHERE `+where,
+		args...,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	type rekeyed struct{ id, key, fingerprint string }
+	var changed []rekeyed
+	for rows.Next() {
+		var (
+			id, title, company, location string
+			key, fingerprint             sql.NullString
+		)
+		if err := rows.Scan(&id, &title, &company, &location, &key, &fingerprint); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		job := rekeyed{id, analyzer.NormalizeCompany(company), analyzer.JobFingerprint(title, company, location)}
+		if !key.Valid || key.String != job.key || !fingerprint.Valid || fingerprint.String != job.fingerprint {
+			changed = append(changed, job)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, job := range changed {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE jobs SET company_key = $2, fingerprint = $3, updated_at = NOW() WHERE id = $1`,
+			job.id, job.key, job.fingerprint,
+		)
+		if err != nil {
+			return 0, err
+		}
+		if err := LinkDuplicate(ctx, tx, job.id, job.fingerprint); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), tx.Commit()
+}
+
+// migrateCompanyKeys loads the company aliases on start, normalizes the
+// companies of the jobs saved before company_key and links the jobs, current
+// and archived, and company details saved before company_id to their company,
+// logging what it did
+func migrateCompanyKeys(db *sql.DB) error {
+	ctx := context.Background()
+	if err := LoadCompanyAliases(ctx, db); err != nil {
+		return err
+	}
+	keyed, err := rekeyCompanies(ctx, db, `company_key IS NULL`)
+	if keyed > 0 {
+		log.Printf("Normalized the companies of %d jobs", keyed)
+	}
+	if err != nil {
+		return err
+	}
+	if err := linkJobCompanies(ctx, db); err != nil {
+		return err
+	}
+	linked, err := linkArchivedCompanies(ctx, db)
+	if linked > 0 {
+		log.Printf("Linked %d archived jobs to their company", linked)
+	}
+	if err != nil {
+		return err
+	}
+	details, err := linkCompanyDetails(ctx, db)
+	if details > 0 {
+		log.Printf("Linked the details of %d company domains to their company", details)
+	}
+	return err
+}
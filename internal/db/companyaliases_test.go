@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCompanyAlias(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	defer analyzer.SetCompanyAliases(nil)
+
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO company_aliases \\(alias, company\\) VALUES \\(\\$1, \\$2\\)").
+		WithArgs("andela talent", "andela").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(created))
+	mock.ExpectExec("^UPDATE company_aliases SET company = \\$2 WHERE company = \\$1$").
+		WithArgs("andela talent", "andela").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery("^SELECT alias, company, created_at FROM company_aliases ORDER BY alias$").
+		WillReturnRows(sqlmock.NewRows([]string{"alias", "company", "created_at"}).
+			AddRow("andela talent", "andela", created))
+
+	// Jobs saved under the alias are rekeyed to the company, and matched
+	// with its jobs as duplicates
+	mock.ExpectQuery("^SELECT id, (.+) FROM jobs WHERE company_key = \\$1$").
+		WithArgs("andela talent").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "company", "location", "company_key", "fingerprint"}).
+			AddRow("job-1", "Go Developer", "Andela Talent Ltd", "Lagos", "andela talent", "old"))
+	fingerprint := analyzer.JobFingerprint("Go Developer", "Andela", "Lagos")
+	mock.ExpectBegin()
+	mock.ExpectExec("^UPDATE jobs SET company_key = \\$2, fingerprint = \\$3, updated_at = NOW\\(\\) WHERE id = \\$1$").
+		WithArgs("job-1", "andela", fingerprint).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^DELETE FROM job_duplicates WHERE job_id = \\$1$").
+		WithArgs("job-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT INTO job_duplicates").
+		WithArgs("job-1", fingerprint).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// and to its company ID
+	mock.ExpectExec("^INSERT INTO companies \\(key, name, domain\\) SELECT company_key").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE jobs SET company_id = c.id, updated_at = NOW\\(\\) FROM companies c").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	alias, err := SetCompanyAlias(context.Background(), db, "Andela Talent Ltd.", "ANDELA NIGERIA")
+	assert.NoError(t, err)
+	assert.Equal(t, &CompanyAlias{Alias: "andela talent", Company: "andela", CreatedAt: created}, alias)
+	assert.Equal(t, "andela", analyzer.NormalizeCompany("Andela Talent"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = SetCompanyAlias(context.Background(), db, "Andela Inc.", "andela")
+	assert.ErrorIs(t, err, ErrSelfAlias)
+}
+
+func TestDeleteCompanyAlias(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	defer analyzer.SetCompanyAliases(nil)
+	// The fingerprint job-1 gets once the alias is gone
+	fingerprint := analyzer.JobFingerprint("Go Developer", "Andela Talent", "Lagos")
+	analyzer.SetCompanyAliases(map[string]string{"andela talent": "andela"})
+
+	mock.ExpectQuery("^DELETE FROM company_aliases WHERE alias = \\$1 RETURNING company$").
+		WithArgs("andela talent").
+		WillReturnRows(sqlmock.NewRows([]string{"company"}).AddRow("andela"))
+	mock.ExpectQuery("^SELECT alias, company, created_at FROM company_aliases").
+		WillReturnRows(sqlmock.NewRows([]string{"alias", "company", "created_at"}))
+	columns := []string{"id", "title", "company", "location", "company_key", "fingerprint"}
+	mock.ExpectQuery("^SELECT id, (.+) FROM jobs WHERE company_key = \\$1$").
+		WithArgs("andela").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("job-1", "Go Developer", "Andela Talent", "Lagos", "andela", analyzer.JobFingerprint("Go Developer", "Andela", "Lagos")).
+			AddRow("job-2", "Go Developer", "Andela Inc.", "Lagos", "andela", analyzer.JobFingerprint("Go Developer", "Andela", "Lagos")))
+	mock.ExpectBegin()
+	mock.ExpectExec("^UPDATE jobs SET company_key = \\$2, fingerprint = \\$3, updated_at = NOW\\(\\) WHERE id = \\$1$").
+		WithArgs("job-1", "andela talent", fingerprint).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^DELETE FROM job_duplicates").WithArgs("job-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^INSERT INTO job_duplicates").WithArgs("job-1", fingerprint).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectExec("^INSERT INTO companies \\(key, name, domain\\) SELECT company_key").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE jobs SET company_id = c.id, updated_at = NOW\\(\\) FROM companies c").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	removed, err := DeleteCompanyAlias(context.Background(), db, "Andela Talent")
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, "andela talent", analyzer.NormalizeCompany("Andela Talent"))
+
+	mock.ExpectQuery("^DELETE FROM company_aliases").
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"company"}))
+	removed, err = DeleteCompanyAlias(context.Background(), db, "Unknown")
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsBlockedCompany(t *testing.T) {
+	defer SetBlockedCompanies(defaultBlockedCompanies)
+	defer analyzer.SetCompanyAliases(nil)
+	SetBlockedCompanies([]string{"crossover", "scam ltd"})
+	analyzer.SetCompanyAliases(map[string]string{"xo talent": "crossover"})
+
+	assert.True(t, IsBlockedCompany("Crossover"))
+	assert.True(t, IsBlockedCompany("CROSSOVER NIGERIA"))
+	assert.True(t, IsBlockedCompany("Crossover for Work, Inc."))
+	assert.True(t, IsBlockedCompany("Scam Limited"))
+	assert.True(t, IsBlockedCompany("XO Talent"))
+	assert.False(t, IsBlockedCompany("Paystack"))
+	assert.False(t, IsBlockedCompany("Crossoverlabs"))
+	assert.False(t, IsBlockedCompany("Scamper"))
+	assert.False(t, IsBlockedCompany(""))
+}
@@ -0,0 +1,303 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+)
+
+// Posting statuses of ListCompanyPostings
+const (
+	PostingsOpen   = "open"
+	PostingsClosed = "closed"
+)
+
+// CompanyRef is a company by the stable ID its jobs refer to (jobs.company_id),
+// with the logo of its domain. Verified companies keep the domain and details
+// an admin set, see OverrideCompany.
+type CompanyRef struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Domain   string `json:"domain,omitempty"`
+	LogoURL  string `json:"logo_url,omitempty"`
+	Verified bool   `json:"verified"`
+}
+
+// CompanyPosting is a current or archived job of a company
+type CompanyPosting struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	Location string     `json:"location,omitempty"`
+	URL      string     `json:"url,omitempty"`
+	Source   string     `json:"source"`
+	IsRemote bool       `json:"is_remote"`
+	PostedAt time.Time  `json:"posted_at"`
+	ExpDate  *time.Time `json:"exp_date,omitempty"`
+	Open     bool       `json:"open"`
+}
+
+// CompanyMonth is the number of jobs a company posted in a month (YYYY-MM)
+type CompanyMonth struct {
+	Month  string `json:"month"`
+	Posted int    `json:"posted"`
+}
+
+// EnsureCompany returns the company with the normalized name key, creating it
+// with name and domain, or nil for an empty key. A company without a domain
+// takes the one given, and the details of its domain not linked to a company
+// yet are linked to it. Only verified companies come with their logo.
+func EnsureCompany(ctx context.Context, tx *sql.Tx, key, name, domain string) (*CompanyRef, error) {
+	if key == "" {
+		return nil, nil
+	}
+	company := &CompanyRef{}
+	err := tx.QueryRowContext(ctx, `
+		WITH company AS (
+			INSERT INTO companies (key, name, domain) VALUES ($1, $2, NULLIF($3, ''))
+			ON CONFLICT (key) DO UPDATE SET domain = COALESCE(companies.domain, EXCLUDED.domain)
+			RETURNING id, name, domain, verified
+		), details AS (
+			UPDATE company_details cd SET company_id = company.id
+			FROM company
+			WHERE cd.domain = company.domain AND cd.company_id IS NULL
+		)
+		SELECT company.id, company.name, COALESCE(company.domain, ''), COALESCE(cd.logo_url, ''), company.verified
+		FROM company
+		LEFT JOIN company_details cd ON cd.domain = company.domain AND company.verified`,
+		key, strings.TrimSpace(name), domain,
+	).Scan(&company.ID, &company.Name, &company.Domain, &company.LogoURL, &company.Verified)
+	if err != nil {
+		return nil, err
+	}
+	return company, nil
+}
+
+// linkCompanyDetails links the company details saved before company IDs, or
+// before any job of their domain, to their company, returning how many were
+// linked
+func linkCompanyDetails(ctx context.Context, db *sql.DB) (int64, error) {
+	res, err := db.ExecContext(ctx, `
+		UPDATE company_details cd SET company_id = (
+			SELECT company_id FROM jobs
+			WHERE company_domain = cd.domain AND company_id IS NOT NULL
+			GROUP BY company_id
+			ORDER BY COUNT(*) DESC, company_id
+			LIMIT 1)
+		WHERE cd.company_id IS NULL
+			AND EXISTS (SELECT 1 FROM jobs WHERE company_domain = cd.domain AND company_id IS NOT NULL)`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// linkJobCompanies points the jobs to the company of their company_key,
+// creating the companies missing, e.g. after an alias changed their key
+func linkJobCompanies(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO companies (key, name, domain)
+		SELECT company_key, MIN(company), MAX(NULLIF(company_domain, ''))
+		FROM jobs
+		WHERE company_key <> ''
+		GROUP BY company_key
+		ON CONFLICT (key) DO NOTHING`); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, `
+		UPDATE jobs SET company_id = c.id, updated_at = NOW()
+		FROM companies c
+		WHERE c.key = jobs.company_key AND jobs.company_id IS DISTINCT FROM c.id`)
+	return err
+}
+
+// linkArchivedCompanies points the jobs archived before company IDs to the
+// company of the name their source gave, returning how many were linked
+func linkArchivedCompanies(ctx context.Context, db *sql.DB) (int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, COALESCE(data->'admin_edits'->>'company', data->>'company', '')
+		FROM jobs_archive
+		WHERE company_id IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+
+	type archived struct{ id, company string }
+	byKey := make(map[string][]archived)
+	for rows.Next() {
+		var job archived
+		if err := rows.Scan(&job.id, &job.company); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if key := analyzer.NormalizeCompany(job.company); key != "" {
+			byKey[key] = append(byKey[key], job)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(byKey) == 0 {
+		return 0, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	linked := 0
+	for key, jobs := range byKey {
+		company, err := EnsureCompany(ctx, tx, key, jobs[0].company, "")
+		if err != nil {
+			return 0, err
+		}
+		for _, job := range jobs {
+			if _, err := tx.ExecContext(ctx, `UPDATE jobs_archive SET company_id = $2 WHERE id = $1`, job.id, company.ID); err != nil {
+				return 0, err
+			}
+			linked++
+		}
+	}
+	return linked, tx.Commit()
+}
+
+// FindCompany returns a company by its ID, domain (e.g. paystack.com) or name
+// (e.g. Andela, matched normalized), or sql.ErrNoRows. A domain shared by
+// several companies gives the oldest.
+func FindCompany(ctx context.Context, db *sql.DB, ref string) (*CompanyRef, error) {
+	where, arg := "c.key = $1", interface{}(analyzer.NormalizeCompany(ref))
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		where, arg = "c.id = $1", id
+	} else if strings.Contains(ref, ".") {
+		where, arg = "c.domain = $1", strings.ToLower(ref)
+	}
+
+	company := &CompanyRef{}
+	err := db.QueryRowContext(ctx, `
+		SELECT c.id, c.name, COALESCE(c.domain, ''), COALESCE(cd.logo_url, ''), c.verified
+		FROM companies c
+		LEFT JOIN company_details cd ON cd.domain = c.domain
+		WHERE `+where+`
+		ORDER BY c.id
+		LIMIT 1`, arg,
+	).Scan(&company.ID, &company.Name, &company.Domain, &company.LogoURL, &company.Verified)
+	if err != nil {
+		return nil, err
+	}
+	return company, nil
+}
+
+// companyPostings selects the postings of company $1: its current jobs but
+// hidden ones and duplicates of another listed job, and its archived jobs,
+// once per fingerprint
+const companyPostings = `
+	WITH postings AS (
+		SELECT id, title, COALESCE(location, '') AS location, COALESCE(url, '') AS url, source,
+			COALESCE(is_remote, false) AS is_remote, COALESCE(posted_at, created_at) AS posted_at, exp_date
+		FROM jobs
+		WHERE company_id = $1 AND NOT hidden
+			AND NOT ` + DuplicateOfListedJob + `
+		UNION ALL
+		SELECT DISTINCT ON (COALESCE(data->>'fingerprint', id)) id, COALESCE(data->>'title', ''),
+			COALESCE(data->>'location', ''), COALESCE(data->>'url', ''), COALESCE(source, ''),
+			COALESCE((data->>'is_remote')::boolean, false),
+			COALESCE((data->>'posted_at')::timestamptz, (data->>'created_at')::timestamptz, archived_at), exp_date
+		FROM jobs_archive
+		WHERE company_id = $1 AND NOT COALESCE((data->>'hidden')::boolean, false)
+		ORDER BY COALESCE(data->>'fingerprint', id), (data->>'posted_at')::timestamptz
+	)`
+
+// postingOpen tells the postings still open
+const postingOpen = `(exp_date IS NULL OR exp_date > NOW())`
+
+// ListCompanyPostings returns the postings of a company, the latest first,
+// only open or closed ones by status (PostingsOpen, PostingsClosed), all of
+// them when empty
+func ListCompanyPostings(ctx context.Context, db *sql.DB, companyID int64, status string, limit, offset int) ([]CompanyPosting, error) {
+	where := "TRUE"
+	switch status {
+	case PostingsOpen:
+		where = postingOpen
+	case PostingsClosed:
+		where = "NOT " + postingOpen
+	}
+
+	rows, err := db.QueryContext(ctx, companyPostings+`
+		SELECT id, title, location, url, source, is_remote, posted_at, exp_date, `+postingOpen+`
+		FROM postings
+		WHERE `+where+`
+		ORDER BY posted_at DESC, id
+		LIMIT $2 OFFSET $3`,
+		companyID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	postings := []CompanyPosting{}
+	for rows.Next() {
+		var (
+			posting CompanyPosting
+			expDate sql.NullTime
+		)
+		err := rows.Scan(&posting.ID, &posting.Title, &posting.Location, &posting.URL, &posting.Source,
+			&posting.IsRemote, &posting.PostedAt, &expDate, &posting.Open)
+		if err != nil {
+			return nil, err
+		}
+		if expDate.Valid {
+			posting.ExpDate = &expDate.Time
+		}
+		postings = append(postings, posting)
+	}
+	return postings, rows.Err()
+}
+
+// CountCompanyPostings returns how many postings of a company are open, and
+// how many it has in all
+func CountCompanyPostings(ctx context.Context, db *sql.DB, companyID int64) (open, total int, err error) {
+	err = db.QueryRowContext(ctx, companyPostings+`
+		SELECT COUNT(*) FILTER (WHERE `+postingOpen+`), COUNT(*)
+		FROM postings`,
+		companyID,
+	).Scan(&open, &total)
+	return open, total, err
+}
+
+// GetCompanyPostingHistory returns how many jobs a company posted in each of
+// the last months months (UTC), the current one last
+func GetCompanyPostingHistory(ctx context.Context, db *sql.DB, companyID int64, months int) ([]CompanyMonth, error) {
+	rows, err := db.QueryContext(ctx, companyPostings+`
+		SELECT to_char(m.month, 'YYYY-MM'), COUNT(p.id)
+		FROM generate_series(
+			date_trunc('month', NOW()) - ($2::integer - 1) * INTERVAL '1 month',
+			date_trunc('month', NOW()),
+			INTERVAL '1 month'
+		) AS m (month)
+		LEFT JOIN postings p ON date_trunc('month', p.posted_at) = m.month
+		GROUP BY m.month
+		ORDER BY m.month`,
+		companyID, months,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []CompanyMonth{}
+	for rows.Next() {
+		var month CompanyMonth
+		if err := rows.Scan(&month.Month, &month.Posted); err != nil {
+			return nil, err
+		}
+		history = append(history, month)
+	}
+	return history, rows.Err()
+}
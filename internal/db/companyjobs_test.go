@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureCompany(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	// The details of the domain are linked to the company in the same statement
+	mock.ExpectQuery("^WITH company AS \\( INSERT INTO companies (.+) RETURNING id, name, domain, verified \\), details AS \\( UPDATE company_details cd SET company_id = company.id (.+) FROM company LEFT JOIN company_details cd (.+) AND company.verified$").
+		WithArgs("andela", "Andela", "andela.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "domain", "logo_url", "verified"}).
+			AddRow(7, "Andela", "andela.com", "https://andela.com/logo.png", true))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	defer tx.Rollback()
+
+	company, err := EnsureCompany(context.Background(), tx, "andela", " Andela ", "andela.com")
+	assert.NoError(t, err)
+	assert.Equal(t, &CompanyRef{ID: 7, Name: "Andela", Domain: "andela.com", LogoURL: "https://andela.com/logo.png", Verified: true}, company)
+
+	// Jobs without a company name have no company
+	company, err = EnsureCompany(context.Background(), tx, "", "", "andela.com")
+	assert.NoError(t, err)
+	assert.Nil(t, company)
+}
+
+func TestFindCompany(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	columns := []string{"id", "name", "domain", "logo_url", "verified"}
+	mock.ExpectQuery("^SELECT c.id, c.name, COALESCE\\(c.domain, ''\\), COALESCE\\(cd.logo_url, ''\\), c.verified FROM companies c LEFT JOIN company_details cd ON cd.domain = c.domain WHERE c.id = \\$1 ").
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, "Andela", "andela.com", "", false))
+	mock.ExpectQuery("^SELECT (.+) WHERE c.domain = \\$1 ").
+		WithArgs("andela.com").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, "Andela", "andela.com", "", false))
+	mock.ExpectQuery("^SELECT (.+) WHERE c.key = \\$1 ").
+		WithArgs("andela").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, "Andela", "andela.com", "", false))
+
+	for _, ref := range []string{"7", "Andela.com", "Andela Nigeria Ltd"} {
+		company, err := FindCompany(context.Background(), db, ref)
+		assert.NoError(t, err, ref)
+		assert.Equal(t, &CompanyRef{ID: 7, Name: "Andela", Domain: "andela.com"}, company)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"Go9jaJobs/internal/enrichment"
+)
+
+// CompanyOverride is what an admin sets on a company. Nil fields are left as
+// they are; Verified defaults to true, locking the company's domain and
+// details against enrichment and later syncs.
+type CompanyOverride struct {
+	Domain      *string `json:"domain"`
+	LogoURL     *string `json:"logo_url"`
+	Description *string `json:"description"`
+	Verified    *bool   `json:"verified"`
+}
+
+// ErrCompanyNoDomain is returned when a logo or description is set on a
+// company without a domain, the key of company details
+var ErrCompanyNoDomain = errors.New("company has no domain")
+
+// OverrideCompany applies override to the company companyID and returns it,
+// or sql.ErrNoRows. The jobs of the company move to the domain set and show
+// the logo of its details; the details, with the logo or description set, are
+// stored as SourceAdmin details of the domain, which no lookup replaces.
+// Unverifying a company only makes its details replaceable again, keeping
+// them until then.
+func OverrideCompany(ctx context.Context, db *sql.DB, companyID int64, override CompanyOverride) (*CompanyRef, error) {
+	verified := override.Verified == nil || *override.Verified
+	domain := ""
+	if override.Domain != nil {
+		domain = strings.ToLower(strings.TrimSpace(*override.Domain))
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	company := &CompanyRef{ID: companyID}
+	err = tx.QueryRowContext(ctx, `
+		UPDATE companies SET domain = COALESCE(NULLIF($2, ''), domain), verified = $3
+		WHERE id = $1
+		RETURNING name, COALESCE(domain, ''), verified`,
+		companyID, domain, verified,
+	).Scan(&company.Name, &company.Domain, &company.Verified)
+	if err != nil {
+		return nil, err
+	}
+
+	if !verified {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE company_details SET source = $3
+			WHERE company_id = $1 AND source = $2`,
+			companyID, enrichment.SourceAdmin, enrichment.SourceOpenGraph,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return company, tx.Commit()
+	}
+
+	if company.Domain == "" {
+		if override.LogoURL != nil || override.Description != nil {
+			return nil, ErrCompanyNoDomain
+		}
+		return company, tx.Commit()
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE jobs SET company_domain = $2, updated_at = NOW()
+		WHERE company_id = $1 AND company_domain IS DISTINCT FROM $2`,
+		companyID, company.Domain,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The details of the domain become the company's, as the admin set them
+	// if any, and are locked like them
+	query := `
+		UPDATE company_details SET company_id = $1, source = $3
+		WHERE domain = $2
+		RETURNING COALESCE(logo_url, '')`
+	args := []interface{}{companyID, company.Domain, enrichment.SourceAdmin}
+	if override.LogoURL != nil || override.Description != nil {
+		query = `
+		INSERT INTO company_details (domain, name, logo_url, description, source, company_id, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, NOW())
+		ON CONFLICT (domain) DO UPDATE SET
+			logo_url = COALESCE(EXCLUDED.logo_url, company_details.logo_url),
+			description = COALESCE(EXCLUDED.description, company_details.description),
+			source = EXCLUDED.source,
+			company_id = EXCLUDED.company_id,
+			updated_at = NOW()
+		RETURNING COALESCE(logo_url, '')`
+		args = []interface{}{company.Domain, company.Name, stringOrEmpty(override.LogoURL),
+			stringOrEmpty(override.Description), enrichment.SourceAdmin, companyID}
+	}
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&company.LogoURL)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if company.LogoURL != "" {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE jobs
+			SET company_logo = $2,
+				provenance = provenance || jsonb_build_object('company_logo', $3::text),
+				logo_checked_at = NOW(), updated_at = NOW()
+			WHERE company_id = $1 AND company_logo IS DISTINCT FROM $2`,
+			companyID, company.LogoURL, enrichment.SourceAdmin,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return company, tx.Commit()
+}
+
+// stringOrEmpty returns the trimmed string s points to, "" for nil
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return strings.TrimSpace(*s)
+}
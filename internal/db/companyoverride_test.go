@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"Go9jaJobs/internal/enrichment"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideCompany(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	domain, logo := " Andela.com ", "https://andela.com/logo.png"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^UPDATE companies SET domain = (.+) RETURNING name").
+		WithArgs(int64(7), "andela.com", true).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "domain", "verified"}).AddRow("Andela", "andela.com", true))
+	mock.ExpectExec("^UPDATE jobs SET company_domain = \\$2").
+		WithArgs(int64(7), "andela.com").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	// The details set are locked as the admin's, the description kept
+	mock.ExpectQuery("^INSERT INTO company_details (.+) ON CONFLICT \\(domain\\) DO UPDATE (.+) RETURNING COALESCE\\(logo_url, ''\\)$").
+		WithArgs("andela.com", "Andela", logo, "", enrichment.SourceAdmin, int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"logo_url"}).AddRow(logo))
+	mock.ExpectExec("^UPDATE jobs SET company_logo = \\$2, provenance = (.+) WHERE company_id = \\$1").
+		WithArgs(int64(7), logo, enrichment.SourceAdmin).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	company, err := OverrideCompany(context.Background(), db, 7, CompanyOverride{Domain: &domain, LogoURL: &logo})
+	assert.NoError(t, err)
+	assert.Equal(t, &CompanyRef{ID: 7, Name: "Andela", Domain: "andela.com", LogoURL: logo, Verified: true}, company)
+
+	// A domain alone links and locks the details known for it
+	mock.ExpectBegin()
+	mock.ExpectQuery("^UPDATE companies SET").
+		WithArgs(int64(7), "andela.com", true).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "domain", "verified"}).AddRow("Andela", "andela.com", true))
+	mock.ExpectExec("^UPDATE jobs SET company_domain").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("^UPDATE company_details SET company_id = \\$1, source = \\$3 WHERE domain = \\$2").
+		WithArgs(int64(7), "andela.com", enrichment.SourceAdmin).
+		WillReturnRows(sqlmock.NewRows([]string{"logo_url"}))
+	mock.ExpectCommit()
+
+	company, err = OverrideCompany(context.Background(), db, 7, CompanyOverride{Domain: &domain})
+	assert.NoError(t, err)
+	assert.Empty(t, company.LogoURL)
+
+	// Unverified companies only have their details unlocked
+	unverified := false
+	mock.ExpectBegin()
+	mock.ExpectQuery("^UPDATE companies SET").
+		WithArgs(int64(7), "", false).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "domain", "verified"}).AddRow("Andela", "andela.com", false))
+	mock.ExpectExec("^UPDATE company_details SET source = \\$3 WHERE company_id = \\$1 AND source = \\$2$").
+		WithArgs(int64(7), enrichment.SourceAdmin, enrichment.SourceOpenGraph).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	company, err = OverrideCompany(context.Background(), db, 7, CompanyOverride{Verified: &unverified})
+	assert.NoError(t, err)
+	assert.False(t, company.Verified)
+
+	// Details are keyed by domain
+	description := "Talent marketplace"
+	mock.ExpectBegin()
+	mock.ExpectQuery("^UPDATE companies SET").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "domain", "verified"}).AddRow("Andela", "", true))
+	mock.ExpectRollback()
+
+	_, err = OverrideCompany(context.Background(), db, 7, CompanyOverride{Description: &description})
+	assert.Equal(t, ErrCompanyNoDomain, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,24 +1,206 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
-// InitDB initializes the PostgreSQL database connection
-func InitDB(connStr string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", connStr)
+// jobsMigrations holds the schema changes applied to the jobs table after it
+// was first created. Statements must be idempotent as they run on every start.
+var jobsMigrations = []string{
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS word_count INTEGER DEFAULT 0`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS reading_time_minutes INTEGER DEFAULT 0`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS apply_method TEXT`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS logo_checked_at TIMESTAMPTZ`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS company_domain TEXT`,
+	// Backfill company_domain the way enrichment.CompanyDomain derives it
+	`UPDATE jobs SET company_domain = REGEXP_REPLACE(REGEXP_REPLACE(LOWER(company_url), '^[a-z]+://', ''), '^www\.|[/?#].*$', '', 'g')
+		WHERE company_domain IS NULL AND COALESCE(company_url, '') <> ''`,
+	`CREATE INDEX IF NOT EXISTS jobs_company_domain_idx ON jobs (company_domain)`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS provenance JSONB NOT NULL DEFAULT '{}'`,
+	// NULL until checked by the language audit
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS language_flags JSONB`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS seniority TEXT`,
+	`CREATE INDEX IF NOT EXISTS jobs_seniority_idx ON jobs (seniority)`,
+	// Set on save, see analyzer.JobFingerprint
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS fingerprint TEXT`,
+	`CREATE INDEX IF NOT EXISTS jobs_fingerprint_idx ON jobs (fingerprint)`,
+	// Set by the salary benchmark on offers far outside the typical range
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_flag JSONB`,
+	// Assessment styles of the hiring process, see analyzer.DetectAssessments
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS assessments TEXT[]`,
+	`CREATE INDEX IF NOT EXISTS jobs_assessments_idx ON jobs USING GIN (assessments)`,
+	// Tools the job uses, see analyzer.DetectStack
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS stack TEXT[]`,
+	`CREATE INDEX IF NOT EXISTS jobs_stack_idx ON jobs USING GIN (stack)`,
+	// Technologies the job is about, see JobTracks. Jobs stored before tracks
+	// were all kept as Go jobs.
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS tracks TEXT[]`,
+	`UPDATE jobs SET tracks = '{go}' WHERE tracks IS NULL`,
+	`CREATE INDEX IF NOT EXISTS jobs_tracks_idx ON jobs USING GIN (tracks)`,
+	// Set by admins on spam and mis-classified postings, see UpdateJob
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT false`,
+	// Fields edited by admins with the values the source gave them, see UpdateJob
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS admin_edits JSONB NOT NULL DEFAULT '{}'`,
+	// Formatted description of the sources giving one, sanitized on save (see
+	// analyzer.SanitizeHTML); description keeps the plain text
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS description_html TEXT`,
+	// Language of the description, see analyzer.DetectLanguage, and its
+	// translation by the configured translator (see services.JobTranslator)
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS language TEXT`,
+	`CREATE INDEX IF NOT EXISTS jobs_language_idx ON jobs (language)`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS description_translation TEXT`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS translated_by TEXT`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS translation_checked_at TIMESTAMPTZ`,
+	// Normalized company the source gave, see analyzer.NormalizeCompany. Set
+	// on save and by migrateCompanyKeys for jobs saved before it.
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS company_key TEXT`,
+	`CREATE INDEX IF NOT EXISTS jobs_company_key_idx ON jobs (company_key)`,
+	// Company of the job by its company_key, see linkJobCompanies
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS company_id BIGINT REFERENCES companies (id) ON DELETE SET NULL`,
+	`CREATE INDEX IF NOT EXISTS jobs_company_id_idx ON jobs (company_id)`,
+}
+
+// companiesMigrations holds the schema changes applied to the companies
+// table after it was first created
+var companiesMigrations = []string{
+	// Verified companies keep the domain and details an admin set, see
+	// OverrideCompany
+	`ALTER TABLE companies ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT false`,
+}
+
+// companyDetailsMigrations holds the schema changes applied to the
+// company_details table after it was first created
+var companyDetailsMigrations = []string{
+	`ALTER TABLE company_details ADD COLUMN IF NOT EXISTS name TEXT`,
+	`ALTER TABLE company_details ADD COLUMN IF NOT EXISTS industries JSONB`,
+	`ALTER TABLE company_details ADD COLUMN IF NOT EXISTS links JSONB`,
+	// The company of the details, set by SaveCompanyDetails, EnsureCompany
+	// and migrateCompanyKeys
+	`ALTER TABLE company_details ADD COLUMN IF NOT EXISTS company_id BIGINT REFERENCES companies (id) ON DELETE SET NULL`,
+	`CREATE INDEX IF NOT EXISTS company_details_company_id_idx ON company_details (company_id)`,
+}
+
+// scheduleInfoMigrations holds the schema changes applied to the
+// job_schedule_info table after it was first created
+var scheduleInfoMigrations = []string{
+	// Yield (new jobs per run) and the interval decisions taken from it
+	`ALTER TABLE job_schedule_info ADD COLUMN IF NOT EXISTS last_yield INTEGER`,
+	`ALTER TABLE job_schedule_info ADD COLUMN IF NOT EXISTS yield_avg DOUBLE PRECISION`,
+	`ALTER TABLE job_schedule_info ADD COLUMN IF NOT EXISTS last_adjustment TEXT`,
+	`ALTER TABLE job_schedule_info ADD COLUMN IF NOT EXISTS adjusted_at TIMESTAMPTZ`,
+	// Start of the last successful sync, the window of incremental fetches
+	`ALTER TABLE job_schedule_info ADD COLUMN IF NOT EXISTS last_success_time TIMESTAMPTZ`,
+}
+
+// syncErrorsMigrations add the structured errors (see SyncError) of each
+// sync to the sync log and sync runs tables
+var syncErrorsMigrations = []string{
+	`ALTER TABLE job_sync_logs ADD COLUMN IF NOT EXISTS errors JSONB`,
+	`ALTER TABLE sync_runs ADD COLUMN IF NOT EXISTS errors JSONB`,
+}
+
+// timestampMigration converts the TIMESTAMP columns of databases created
+// before timestamps were stored with their zone to TIMESTAMPTZ. Their values
+// were written in UTC and are read as such. Converted columns are skipped, so
+// the statement does nothing once it has run.
+const timestampMigration = `
+DO $$
+DECLARE
+	col RECORD;
+BEGIN
+	FOR col IN
+		SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND data_type = 'timestamp without time zone'
+	LOOP
+		EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMPTZ USING %I AT TIME ZONE ''UTC''',
+			col.table_name, col.column_name, col.column_name);
+	END LOOP;
+END
+$$`
+
+// PoolConfig sizes the connection pool of the database
+type PoolConfig struct {
+	MaxConns        int
+	MinConns        int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// OpenPool creates the pgx connection pool of the database at connStr.
+// Queries use pgx's per-connection cache of prepared statements; add
+// default_query_exec_mode=exec to connStr behind a transaction-mode
+// PgBouncer, which cannot keep them. Zero PoolConfig values keep the pgxpool
+// defaults. Sessions run in UTC unless connStr sets a timezone, so dates
+// compared in SQL (e.g. $1::date) are UTC days whatever the server's zone.
+func OpenPool(ctx context.Context, connStr string, pool PoolConfig) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := config.ConnConfig.RuntimeParams["timezone"]; !ok {
+		config.ConnConfig.RuntimeParams["timezone"] = "UTC"
+	}
+	if pool.MaxConns > 0 {
+		config.MaxConns = int32(pool.MaxConns)
+	}
+	if pool.MinConns > 0 {
+		config.MinConns = int32(pool.MinConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		config.MaxConnLifetime = pool.ConnMaxLifetime
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		config.MaxConnIdleTime = pool.ConnMaxIdleTime
+	}
+	return pgxpool.NewWithConfig(ctx, config)
+}
+
+// InitDB initializes the PostgreSQL database over pool, returning the
+// database/sql handle the rest of the application queries through
+func InitDB(pool *pgxpool.Pool) (*sql.DB, error) {
+	db := stdlib.OpenDBFromPool(pool)
 
-	if err = db.Ping(); err != nil {
+	if err := db.Ping(); err != nil {
 		return nil, err
 	}
 
+	// Create companies table giving each normalized company name (see
+	// analyzer.NormalizeCompany) the stable ID its jobs, current and archived,
+	// refer to
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS companies (
+		id BIGSERIAL PRIMARY KEY,
+		key TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		domain TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table companies: %v", err)
+		return nil, err
+	}
+
+	if _, err = db.Exec(`CREATE INDEX IF NOT EXISTS companies_domain_idx ON companies (domain)`); err != nil {
+		log.Printf("Error indexing table companies: %v", err)
+		return nil, err
+	}
+
+	// Add companies columns introduced after the initial schema
+	for _, migration := range companiesMigrations {
+		if _, err = db.Exec(migration); err != nil {
+			log.Printf("Error migrating companies table: %v", err)
+			return nil, err
+		}
+	}
+
 	// Create jobs table if it doesn't exist
 	_, err = db.Exec(`
 	CREATE TABLE IF NOT EXISTS jobs (
@@ -33,29 +215,166 @@ func InitDB(connStr string) (*sql.DB, error) {
 		description TEXT,
 		url TEXT,
 		salary TEXT,
-		posted_at TIMESTAMP,
+		posted_at TIMESTAMPTZ,
 		job_type TEXT,
 		is_remote BOOLEAN,
 		source TEXT NOT NULL,
 		employment_type TEXT,
-		exp_date TIMESTAMP,
-		date_gotten TIMESTAMP,
+		exp_date TIMESTAMPTZ,
+		date_gotten TIMESTAMPTZ,
 		location TEXT,
 		raw_data TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
 	)`)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// Add columns introduced after the initial jobs schema
+	for _, migration := range jobsMigrations {
+		if _, err = db.Exec(migration); err != nil {
+			log.Printf("Error migrating jobs table: %v", err)
+			return nil, err
+		}
+	}
+
+	// Index the full-text document searched by GET /api/jobs?q=
+	_, err = db.Exec(`
+	CREATE INDEX IF NOT EXISTS jobs_search_idx ON jobs
+	USING GIN (to_tsvector('english', title || ' ' || company || ' ' || COALESCE(description, '')))`)
+
+	if err != nil {
+		log.Printf("Error creating search index on jobs: %v", err)
+		return nil, err
+	}
+
+	// Create jobs_archive table for expired jobs removed from listings
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS jobs_archive (
+		id TEXT PRIMARY KEY,
+		job_id TEXT,
+		source TEXT,
+		exp_date TIMESTAMPTZ,
+		data JSONB NOT NULL,
+		archived_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table jobs_archive: %v", err)
+		return nil, err
+	}
+
+	for _, migration := range []string{
+		`ALTER TABLE jobs_archive ADD COLUMN IF NOT EXISTS company_id BIGINT REFERENCES companies (id) ON DELETE SET NULL`,
+		`CREATE INDEX IF NOT EXISTS jobs_archive_company_id_idx ON jobs_archive (company_id)`,
+	} {
+		if _, err = db.Exec(migration); err != nil {
+			log.Printf("Error migrating table jobs_archive: %v", err)
+			return nil, err
+		}
+	}
+
+	// Create jobs_quarantine table for rows purged from a misbehaving source
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS jobs_quarantine (
+		id TEXT PRIMARY KEY,
+		job_id TEXT,
+		source TEXT,
+		data JSONB NOT NULL,
+		quarantined_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table jobs_quarantine: %v", err)
+		return nil, err
+	}
+
+	// Create job_changes table auditing the edits and deletions of admins
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS job_changes (
+		id SERIAL PRIMARY KEY,
+		job_id TEXT NOT NULL,
+		action TEXT NOT NULL,
+		changes JSONB NOT NULL,
+		admin_key TEXT NOT NULL,
+		changed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table job_changes: %v", err)
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS job_changes_job_id_idx ON job_changes (job_id, changed_at)`)
+	if err != nil {
+		log.Printf("Error creating index on job_changes: %v", err)
+		return nil, err
+	}
+
+	// Create job_duplicates table linking jobs to the job they duplicate
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS job_duplicates (
+		job_id TEXT PRIMARY KEY REFERENCES jobs (id) ON DELETE CASCADE,
+		canonical_id TEXT NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+		fingerprint TEXT NOT NULL,
+		linked_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table job_duplicates: %v", err)
+		return nil, err
+	}
+
+	// Create job_subscriptions table for email job alerts
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS job_subscriptions (
+		id SERIAL PRIMARY KEY,
+		email TEXT NOT NULL,
+		remote_only BOOLEAN NOT NULL DEFAULT false,
+		state TEXT NOT NULL DEFAULT '',
+		seniority TEXT NOT NULL DEFAULT '',
+		token TEXT NOT NULL UNIQUE,
+		confirmed_at TIMESTAMPTZ,
+		last_sent_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (email, remote_only, state, seniority)
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table job_subscriptions: %v", err)
+		return nil, err
+	}
+
+	// Create source_suggestions table for job sources suggested by the
+	// community, one row per domain and email address
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS source_suggestions (
+		id SERIAL PRIMARY KEY,
+		domain TEXT NOT NULL,
+		url TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		note TEXT NOT NULL DEFAULT '',
+		email TEXT NOT NULL,
+		token TEXT NOT NULL UNIQUE,
+		confirmed_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (domain, email)
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table source_suggestions: %v", err)
+		return nil, err
+	}
+
 	// Create job_sync_logs table if it doesn't exist
 	_, err = db.Exec(`
 	CREATE TABLE IF NOT EXISTS job_sync_logs (
 		id SERIAL PRIMARY KEY,
 		api_name TEXT NOT NULL,
-		sync_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		sync_time TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
 		job_count INTEGER,
 		status TEXT,
 		error_message TEXT
@@ -66,11 +385,294 @@ func InitDB(connStr string) (*sql.DB, error) {
 		return nil, err
 	}
 
+	// Create job_schedule_info table holding per-source scheduler intervals
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS job_schedule_info (
+		api_name TEXT PRIMARY KEY,
+		interval_minutes INTEGER NOT NULL,
+		last_run_time TIMESTAMPTZ,
+		next_run_time TIMESTAMPTZ
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table job_schedule_info: %v", err)
+		return nil, err
+	}
+
+	for _, migration := range scheduleInfoMigrations {
+		if _, err = db.Exec(migration); err != nil {
+			log.Printf("Error migrating job_schedule_info table: %v", err)
+			return nil, err
+		}
+	}
+
+	// Create sync_runs table tracking each sync request and its progress
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS sync_runs (
+		id TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		status TEXT NOT NULL,
+		fetched INTEGER DEFAULT 0,
+		saved INTEGER DEFAULT 0,
+		results JSONB,
+		error_message TEXT,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		started_at TIMESTAMPTZ,
+		finished_at TIMESTAMPTZ,
+		duration_ms BIGINT DEFAULT 0
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table sync_runs: %v", err)
+		return nil, err
+	}
+
+	for _, migration := range syncErrorsMigrations {
+		if _, err = db.Exec(migration); err != nil {
+			log.Printf("Error migrating sync log tables: %v", err)
+			return nil, err
+		}
+	}
+
+	// Create dedupe_audit table pairing jobs dropped by dedupe with the job kept
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS dedupe_audit (
+		id SERIAL PRIMARY KEY,
+		kept_id TEXT NOT NULL,
+		rule TEXT NOT NULL,
+		score REAL NOT NULL,
+		job_id TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL,
+		title TEXT,
+		company TEXT,
+		url TEXT,
+		dropped_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table dedupe_audit: %v", err)
+		return nil, err
+	}
+
+	// Create provider_schemas table archiving the response structure of each
+	// source, one row per distinct structure
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS provider_schemas (
+		id SERIAL PRIMARY KEY,
+		source TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		fields JSONB NOT NULL,
+		added JSONB,
+		removed JSONB,
+		first_seen_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		last_seen_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table provider_schemas: %v", err)
+		return nil, err
+	}
+
+	// Create job_skips table recording why the save pipeline dropped a job
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS job_skips (
+		id SERIAL PRIMARY KEY,
+		job_id TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL,
+		title TEXT,
+		company TEXT,
+		url TEXT,
+		reason TEXT NOT NULL,
+		details TEXT,
+		skipped_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table job_skips: %v", err)
+		return nil, err
+	}
+
+	_, err = db.Exec(`
+	CREATE UNIQUE INDEX IF NOT EXISTS job_skips_source_job_reason_idx
+	ON job_skips (source, job_id, reason) WHERE job_id <> ''`)
+
+	if err != nil {
+		log.Printf("Error creating index on job_skips: %v", err)
+		return nil, err
+	}
+
+	// Create company_details table holding enrichment results per domain,
+	// with the source that supplied them
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS company_details (
+		domain TEXT PRIMARY KEY,
+		logo_url TEXT,
+		description TEXT,
+		theme_color TEXT,
+		source TEXT NOT NULL,
+		updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating company_details table: %v", err)
+		return nil, err
+	}
+
+	// Add company_details columns introduced after the initial schema
+	for _, migration := range companyDetailsMigrations {
+		if _, err = db.Exec(migration); err != nil {
+			log.Printf("Error migrating company_details table: %v", err)
+			return nil, err
+		}
+	}
+
+	// Create blocked_companies table, seeded with the default blocked
+	// companies when it is first created
+	var blockedExists bool
+	if err = db.QueryRow(`SELECT to_regclass('blocked_companies') IS NOT NULL`).Scan(&blockedExists); err != nil {
+		log.Printf("Error checking table blocked_companies: %v", err)
+		return nil, err
+	}
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS blocked_companies (
+		name TEXT PRIMARY KEY,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table blocked_companies: %v", err)
+		return nil, err
+	}
+
+	if !blockedExists {
+		for _, name := range defaultBlockedCompanies {
+			if _, err = db.Exec(`INSERT INTO blocked_companies (name) VALUES ($1) ON CONFLICT DO NOTHING`, name); err != nil {
+				log.Printf("Error seeding table blocked_companies: %v", err)
+				return nil, err
+			}
+		}
+	}
+
+	// Create company_aliases table mapping the company names sources use to
+	// the company they stand for, see analyzer.NormalizeCompany
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS company_aliases (
+		alias TEXT PRIMARY KEY,
+		company TEXT NOT NULL,
+		created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table company_aliases: %v", err)
+		return nil, err
+	}
+
+	// Create job_counts_daily table holding the daily job counts per source
+	// and state behind the trend charts
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS job_counts_daily (
+		day DATE NOT NULL,
+		source TEXT NOT NULL,
+		state TEXT NOT NULL DEFAULT '',
+		new_jobs INTEGER NOT NULL DEFAULT 0,
+		active_jobs INTEGER NOT NULL DEFAULT 0,
+		expired_jobs INTEGER NOT NULL DEFAULT 0,
+		recorded_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (day, source, state)
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table job_counts_daily: %v", err)
+		return nil, err
+	}
+
+	// Create api_usage table recording every call to the API of a job source
+	// and its estimated cost, behind the monthly budgets
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS api_usage (
+		id BIGSERIAL PRIMARY KEY,
+		source TEXT NOT NULL,
+		endpoint TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		items INTEGER,
+		cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+		called_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table api_usage: %v", err)
+		return nil, err
+	}
+
+	if _, err = db.Exec(`CREATE INDEX IF NOT EXISTS api_usage_source_called_at_idx ON api_usage (source, called_at)`); err != nil {
+		log.Printf("Error indexing table api_usage: %v", err)
+		return nil, err
+	}
+
+	// Create api_responses table keeping the response bodies of the APIs once
+	// per sync run, jobs only keeping their own item as raw_data
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS api_responses (
+		id BIGSERIAL PRIMARY KEY,
+		sync_run_id TEXT,
+		source TEXT NOT NULL,
+		endpoint TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL,
+		fetched_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table api_responses: %v", err)
+		return nil, err
+	}
+
+	for _, index := range []string{
+		`CREATE INDEX IF NOT EXISTS api_responses_sync_run_id_idx ON api_responses (sync_run_id)`,
+		`CREATE INDEX IF NOT EXISTS api_responses_fetched_at_idx ON api_responses (fetched_at)`,
+	} {
+		if _, err = db.Exec(index); err != nil {
+			log.Printf("Error indexing table api_responses: %v", err)
+			return nil, err
+		}
+	}
+
+	// Create data_migrations table recording the one-off data migrations run,
+	// so later starts skip them
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS data_migrations (
+		name TEXT PRIMARY KEY,
+		finished_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	if err != nil {
+		log.Printf("Error creating table data_migrations: %v", err)
+		return nil, err
+	}
+
+	// Timestamps of databases created before they were stored with their zone
+	if _, err = db.Exec(timestampMigration); err != nil {
+		log.Printf("Error migrating timestamps to TIMESTAMPTZ: %v", err)
+		return nil, err
+	}
+
+	// Jobs stored before api_responses held a copy of the whole response
+	if err = migrateRawData(db); err != nil {
+		log.Printf("Error moving raw API responses out of jobs: %v", err)
+		return nil, err
+	}
+
+	// Jobs saved before companies were normalized and had a company ID
+	if err = migrateCompanyKeys(db); err != nil {
+		log.Printf("Error normalizing job companies: %v", err)
+		return nil, err
+	}
+
 	return db, nil
 }
 
 // LogAPISync logs API sync attempts in PostgreSQL
-func LogAPISync(db *sql.DB, apiName string, jobCount int, status string, errorMsg string) {
+func LogAPISync(db *sql.DB, apiName string, jobCount int, status string, errorMsg string, errs []SyncError) {
 	if db == nil {
 		log.Println("Error logging API sync: PostgreSQL database connection is nil")
 		return
@@ -78,8 +680,8 @@ func LogAPISync(db *sql.DB, apiName string, jobCount int, status string, errorMs
 
 	// Insert the log
 	_, err := db.Exec(
-		"INSERT INTO job_sync_logs (api_name, job_count, status, error_message) VALUES ($1, $2, $3, $4)",
-		apiName, jobCount, status, errorMsg,
+		"INSERT INTO job_sync_logs (api_name, job_count, status, error_message, errors) VALUES ($1, $2, $3, $4, $5)",
+		apiName, jobCount, status, errorMsg, syncErrorsJSON(errs),
 	)
 	if err != nil {
 		log.Println("Error inserting into job_sync_logs:", err)
@@ -88,6 +690,68 @@ func LogAPISync(db *sql.DB, apiName string, jobCount int, status string, errorMs
 	}
 }
 
+// SyncLogSummary is the latest sync outcome of a single API
+type SyncLogSummary struct {
+	APIName       string     `json:"api_name"`
+	LastRunTime   time.Time  `json:"last_run_time"`
+	LastStatus    string     `json:"last_status"`
+	LastJobCount  int        `json:"last_job_count"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorTime *time.Time `json:"last_error_time,omitempty"`
+}
+
+// GetLatestSyncLogs returns the most recent sync log of each API together
+// with its most recent error, keyed by API name
+func GetLatestSyncLogs(ctx context.Context, db *sql.DB) (map[string]*SyncLogSummary, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT ON (api_name) api_name, sync_time, COALESCE(job_count, 0), COALESCE(status, '')
+		FROM job_sync_logs
+		ORDER BY api_name, sync_time DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make(map[string]*SyncLogSummary)
+	for rows.Next() {
+		var summary SyncLogSummary
+		if err := rows.Scan(&summary.APIName, &summary.LastRunTime, &summary.LastJobCount, &summary.LastStatus); err != nil {
+			return nil, err
+		}
+		summaries[summary.APIName] = &summary
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	errRows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT ON (api_name) api_name, sync_time, error_message
+		FROM job_sync_logs
+		WHERE error_message IS NOT NULL AND error_message <> ''
+		ORDER BY api_name, sync_time DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer errRows.Close()
+
+	for errRows.Next() {
+		var (
+			apiName   string
+			errorTime time.Time
+			errorMsg  string
+		)
+		if err := errRows.Scan(&apiName, &errorTime, &errorMsg); err != nil {
+			return nil, err
+		}
+		if summary, ok := summaries[apiName]; ok {
+			summary.LastError = errorMsg
+			summary.LastErrorTime = &errorTime
+		}
+	}
+
+	return summaries, errRows.Err()
+}
+
 // JobSyncLog represents a log entry for job synchronization
 type JobSyncLog struct {
 	ID        int       `db:"id"`
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/models"
+)
+
+// Dedupe rules that can drop a job in favour of a stored one
+const (
+	// DedupeRuleDuplicate matches the same title and company posted the same month
+	DedupeRuleDuplicate = "duplicate"
+	// DedupeRuleRetitled matches a close variant of the title at the same company
+	DedupeRuleRetitled = "retitled"
+)
+
+// DedupeAudit pairs a job dropped by dedupe with the stored job it was
+// matched to, so false-positive merges can be spotted
+type DedupeAudit struct {
+	KeptID      string  `json:"kept_id"`
+	KeptTitle   string  `json:"kept_title"`
+	KeptCompany string  `json:"kept_company"`
+	KeptURL     string  `json:"kept_url"`
+	Rule        string  `json:"rule"`
+	Score       float64 `json:"score"`
+	// The dropped candidate
+	JobID     string    `json:"job_id"`
+	Source    string    `json:"source"`
+	Title     string    `json:"title"`
+	Company   string    `json:"company"`
+	URL       string    `json:"url"`
+	DroppedAt time.Time `json:"dropped_at"`
+}
+
+// RecordDedupe stores that job was dropped by rule as a match of keptID with
+// the given similarity score. Failures are logged and never abort the save
+// pipeline.
+func RecordDedupe(ctx context.Context, db *sql.DB, job models.Job, keptID, rule string, score float64) {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO dedupe_audit (kept_id, rule, score, job_id, source, title, company, url)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		keptID, rule, score, job.JobID, job.Source, job.Title, job.Company, job.URL,
+	)
+	if err != nil {
+		log.Printf("Error recording dedupe of job %s into %s: %v", job.JobID, keptID, err)
+		syncErrorsFrom(ctx).Add(StageFilter, job.Source, err)
+	}
+}
+
+// FindDedupes returns the most recent dedupe pairs, optionally of one rule
+// and with a score at most maxScore (0 for any), lowest scores being the
+// likeliest false positives
+func FindDedupes(ctx context.Context, db *sql.DB, rule string, maxScore float64, limit int) ([]DedupeAudit, error) {
+	var conditions []string
+	var args []interface{}
+
+	if rule != "" {
+		args = append(args, rule)
+		conditions = append(conditions, fmt.Sprintf("a.rule = $%d", len(args)))
+	}
+	if maxScore > 0 {
+		args = append(args, maxScore)
+		conditions = append(conditions, fmt.Sprintf("a.score <= $%d", len(args)))
+	}
+
+	query := `
+		SELECT a.kept_id, COALESCE(j.title, ''), COALESCE(j.company, ''), COALESCE(j.url, ''), a.rule, a.score,
+			a.job_id, a.source, COALESCE(a.title, ''), COALESCE(a.company, ''), COALESCE(a.url, ''), a.dropped_at
+		FROM dedupe_audit a
+		LEFT JOIN jobs j ON j.id = a.kept_id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY a.dropped_at DESC LIMIT $%d", len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	audits := []DedupeAudit{}
+	for rows.Next() {
+		var a DedupeAudit
+		if err := rows.Scan(&a.KeptID, &a.KeptTitle, &a.KeptCompany, &a.KeptURL, &a.Rule, &a.Score,
+			&a.JobID, &a.Source, &a.Title, &a.Company, &a.URL, &a.DroppedAt); err != nil {
+			return nil, err
+		}
+		audits = append(audits, a)
+	}
+	return audits, rows.Err()
+}
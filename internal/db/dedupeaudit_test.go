@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordDedupe(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	job := models.Job{JobID: "li-9", Source: "linkedin", Title: "Go Backend Engineer", Company: "Paystack", URL: "https://linkedin.com/jobs/li-9"}
+	mock.ExpectExec("^INSERT INTO dedupe_audit").
+		WithArgs("job-2", DedupeRuleRetitled, 0.8, "li-9", "linkedin", "Go Backend Engineer", "Paystack", "https://linkedin.com/jobs/li-9").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	RecordDedupe(context.Background(), db, job, "job-2", DedupeRuleRetitled, 0.8)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindDedupes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dropped := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT (.+) FROM dedupe_audit a LEFT JOIN jobs j ON j.id = a.kept_id WHERE a.rule = \\$1 AND a.score <= \\$2 ORDER BY a.dropped_at DESC LIMIT \\$3$").
+		WithArgs(DedupeRuleRetitled, 0.8, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"kept_id", "title", "company", "url", "rule", "score",
+			"job_id", "source", "title", "company", "url", "dropped_at"}).
+			AddRow("job-2", "Backend Engineer (Go)", "Paystack", "https://paystack.com/jobs/2", "retitled", 0.75,
+				"li-9", "linkedin", "Go Backend Engineer", "Paystack", "https://linkedin.com/jobs/li-9", dropped))
+
+	audits, err := FindDedupes(context.Background(), db, DedupeRuleRetitled, 0.8, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []DedupeAudit{{
+		KeptID: "job-2", KeptTitle: "Backend Engineer (Go)", KeptCompany: "Paystack", KeptURL: "https://paystack.com/jobs/2",
+		Rule: "retitled", Score: 0.75,
+		JobID: "li-9", Source: "linkedin", Title: "Go Backend Engineer", Company: "Paystack", URL: "https://linkedin.com/jobs/li-9",
+		DroppedAt: dropped,
+	}}, audits)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+)
+
+// SourceQuality scores the active jobs of a job source: the share of them
+// with a salary and a location and no language or salary audit flag
+type SourceQuality struct {
+	Source       string  `json:"source"`
+	Active       int     `json:"active"`
+	WithSalary   int     `json:"with_salary"`
+	WithLocation int     `json:"with_location"`
+	Flagged      int     `json:"flagged"`
+	Score        float64 `json:"score"`
+}
+
+// GetSourceQuality returns the quality of the active jobs of each source
+func GetSourceQuality(ctx context.Context, db *sql.DB) ([]SourceQuality, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT source,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE COALESCE(salary, '') <> ''),
+			COUNT(*) FILTER (WHERE COALESCE(location, '') <> ''),
+			COUNT(*) FILTER (WHERE flagged),
+			COUNT(*) FILTER (WHERE COALESCE(salary, '') <> '' AND COALESCE(location, '') <> '' AND NOT flagged)
+		FROM (
+			SELECT source, salary, location,
+				salary_flag IS NOT NULL OR jsonb_array_length(COALESCE(language_flags, '[]'::jsonb)) > 0 AS flagged
+			FROM jobs
+			WHERE exp_date IS NULL OR exp_date > NOW()
+		) active
+		GROUP BY source
+		ORDER BY source`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quality := []SourceQuality{}
+	for rows.Next() {
+		var q SourceQuality
+		var complete int
+		if err := rows.Scan(&q.Source, &q.Active, &q.WithSalary, &q.WithLocation, &q.Flagged, &complete); err != nil {
+			return nil, err
+		}
+		if q.Active > 0 {
+			q.Score = math.Round(float64(complete)/float64(q.Active)*100) / 100
+		}
+		quality = append(quality, q)
+	}
+	return quality, rows.Err()
+}
+
+// SyncActivity is the sync history of an API: its latest syncs and how many
+// it ran since a point in time
+type SyncActivity struct {
+	APIName     string
+	Syncs       int
+	Failed      int
+	LastSync    *time.Time
+	LastSuccess *time.Time
+}
+
+// GetSyncActivity returns the sync activity of each API keyed by name,
+// counting the syncs logged since and those among them whose status is not
+// successStatus
+func GetSyncActivity(ctx context.Context, db *sql.DB, since time.Time, successStatus string) (map[string]*SyncActivity, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT api_name,
+			COUNT(*) FILTER (WHERE sync_time > $1),
+			COUNT(*) FILTER (WHERE sync_time > $1 AND status IS DISTINCT FROM $2),
+			MAX(sync_time),
+			MAX(sync_time) FILTER (WHERE status = $2)
+		FROM job_sync_logs
+		GROUP BY api_name`, since, successStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activity := make(map[string]*SyncActivity)
+	for rows.Next() {
+		var a SyncActivity
+		var lastSync, lastSuccess sql.NullTime
+		if err := rows.Scan(&a.APIName, &a.Syncs, &a.Failed, &lastSync, &lastSuccess); err != nil {
+			return nil, err
+		}
+		if lastSync.Valid {
+			a.LastSync = &lastSync.Time
+		}
+		if lastSuccess.Valid {
+			a.LastSuccess = &lastSuccess.Time
+		}
+		activity[a.APIName] = &a
+	}
+	return activity, rows.Err()
+}
+
+// QueueDepths counts the work waiting on background jobs and reviewers
+type QueueDepths struct {
+	// SyncRuns are queued or running
+	SyncRuns int `json:"sync_runs"`
+	// LanguageAudit jobs were not checked by the language audit yet
+	LanguageAudit int `json:"language_audit"`
+	// UnconfirmedSubscriptions wait for their email address to be confirmed
+	UnconfirmedSubscriptions int `json:"unconfirmed_subscriptions"`
+	// SourceSuggestions are the confirmed suggested domains to review
+	SourceSuggestions int `json:"source_suggestions"`
+}
+
+// GetQueueDepths returns the current queue depths
+func GetQueueDepths(ctx context.Context, db *sql.DB) (*QueueDepths, error) {
+	var q QueueDepths
+	err := db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM sync_runs WHERE status IN ($1, $2)),
+			(SELECT COUNT(*) FROM jobs WHERE language_flags IS NULL),
+			(SELECT COUNT(*) FROM job_subscriptions WHERE confirmed_at IS NULL),
+			(SELECT COUNT(DISTINCT domain) FROM source_suggestions WHERE confirmed_at IS NOT NULL)`,
+		SyncRunQueued, SyncRunRunning,
+	).Scan(&q.SyncRuns, &q.LanguageAudit, &q.UnconfirmedSubscriptions, &q.SourceSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DuplicateOfListedJob is the SQL condition matching the jobs linked as a
+// duplicate of a job still listed, neither hidden nor expired. Listings skip
+// those; a duplicate whose canonical job is no longer listed shows in its
+// place.
+const DuplicateOfListedJob = `EXISTS (SELECT 1 FROM job_duplicates d
+	JOIN jobs canonical ON canonical.id = d.canonical_id
+	WHERE d.job_id = jobs.id AND NOT canonical.hidden
+		AND (canonical.exp_date IS NULL OR canonical.exp_date > NOW()))`
+
+// LinkDuplicate records the job with the given id as a duplicate of the first
+// stored job with the same fingerprint that is not a duplicate itself, usually
+// the same posting ingested earlier from another source. A job with no such
+// match (or whose fingerprint changed) is no longer linked.
+func LinkDuplicate(ctx context.Context, tx *sql.Tx, id, fingerprint string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM job_duplicates WHERE job_id = $1`, id); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO job_duplicates (job_id, canonical_id, fingerprint)
+		SELECT $1, j.id, $2
+		FROM jobs j
+		WHERE j.fingerprint = $2 AND j.id <> $1
+		AND NOT EXISTS (SELECT 1 FROM job_duplicates d WHERE d.job_id = j.id)
+		ORDER BY j.created_at, j.id
+		LIMIT 1`,
+		id, fingerprint,
+	)
+	return err
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"io"
+)
+
+// ExportJobs writes every job to w as JSON Lines, the full row of each as
+// with the jobs archive, and returns the number of jobs written. Rows are
+// streamed: a slow w slows down reading instead of buffering the table.
+func ExportJobs(ctx context.Context, db *sql.DB, w io.Writer) (int, error) {
+	rows, err := db.QueryContext(ctx, `SELECT row_to_json(j) FROM jobs j ORDER BY j.id`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count := 0
+	var line []byte
+	for rows.Next() {
+		if err := rows.Scan(&line); err != nil {
+			return count, err
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
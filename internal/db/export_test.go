@@ -0,0 +1,43 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingWriter fails every write, as an aborted upload does
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("upload aborted")
+}
+
+func TestExportJobs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT row_to_json\(j\) FROM jobs j ORDER BY j.id$`).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).
+			AddRow([]byte(`{"id":"a","title":"Go Developer"}`)).
+			AddRow([]byte(`{"id":"b","title":"Backend Engineer"}`)))
+
+	var out bytes.Buffer
+	count, err := ExportJobs(context.Background(), db, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "{\"id\":\"a\",\"title\":\"Go Developer\"}\n{\"id\":\"b\",\"title\":\"Backend Engineer\"}\n", out.String())
+
+	// A failed write stops the export
+	mock.ExpectQuery(`^SELECT row_to_json`).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).AddRow([]byte(`{"id":"a"}`)))
+	count, err = ExportJobs(context.Background(), db, failingWriter{})
+	assert.EqualError(t, err, "upload aborted")
+	assert.Equal(t, 0, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
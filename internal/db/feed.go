@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"Go9jaJobs/internal/models"
+)
+
+// LatestJobs returns the limit most recently posted open jobs for the public
+// feeds, one per fingerprint like the default job listing. Only the fields
+// shown in feeds are set.
+func LatestJobs(ctx context.Context, db *sql.DB, limit int) ([]models.Job, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, title, company, COALESCE(location, ''), COALESCE(url, ''), posted_at
+		FROM jobs
+		WHERE (exp_date IS NULL OR exp_date > NOW()) AND NOT hidden
+		AND NOT `+DuplicateOfListedJob+`
+		ORDER BY posted_at DESC
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanFeedJobs(rows)
+}
+
+// NewJobs returns the jobs among ids first stored at or after since, i.e.
+// those a sync added rather than refreshed, newest first. Like LatestJobs,
+// duplicates of jobs still listed from other sources are left out.
+func NewJobs(ctx context.Context, db *sql.DB, ids []string, since time.Time) ([]models.Job, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, title, company, COALESCE(location, ''), COALESCE(url, ''), posted_at
+		FROM jobs
+		WHERE id = ANY($1) AND created_at >= $2 AND NOT hidden
+		AND NOT `+DuplicateOfListedJob+`
+		ORDER BY posted_at DESC`,
+		Array(ids), since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanFeedJobs(rows)
+}
+
+// scanFeedJobs reads the rows of LatestJobs and NewJobs
+func scanFeedJobs(rows *sql.Rows) ([]models.Job, error) {
+	defer rows.Close()
+
+	var jobs []models.Job
+	for rows.Next() {
+		var job models.Job
+		if err := rows.Scan(&job.ID, &job.Title, &job.Company, &job.Location, &job.URL, &job.PostedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
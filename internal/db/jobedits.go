@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded in job_changes
+const (
+	JobChangeUpdate = "update"
+	JobChangeDelete = "delete"
+)
+
+// JobEdit is a change an admin makes to a job. Nil fields are left as they
+// are; edited titles, companies and expiry dates are kept over those of
+// later syncs.
+type JobEdit struct {
+	Hidden *bool `json:"hidden"`
+	// Expired sets the expiry date of the job to now
+	Expired bool    `json:"expired"`
+	Title   *string `json:"title"`
+	Company *string `json:"company"`
+}
+
+// FieldChange is the value of a job field before and after an edit
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// JobChange is an entry of the audit log of admin edits and deletions
+type JobChange struct {
+	ID        int64           `json:"id"`
+	JobID     string          `json:"job_id"`
+	Action    string          `json:"action"`
+	Changes   json.RawMessage `json:"changes"`
+	AdminKey  string          `json:"admin_key"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// editedJob holds the fields a JobEdit may change
+type editedJob struct {
+	Title   string
+	Company string
+	Hidden  bool
+	ExpDate sql.NullTime
+}
+
+// changes returns the fields that differ from those of before
+func (after editedJob) changes(before editedJob) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	if after.Title != before.Title {
+		changes["title"] = FieldChange{before.Title, after.Title}
+	}
+	if after.Company != before.Company {
+		changes["company"] = FieldChange{before.Company, after.Company}
+	}
+	if after.Hidden != before.Hidden {
+		changes["hidden"] = FieldChange{before.Hidden, after.Hidden}
+	}
+	if after.ExpDate != before.ExpDate {
+		changes["exp_date"] = FieldChange{timeOrNil(before.ExpDate), timeOrNil(after.ExpDate)}
+	}
+	return changes
+}
+
+// timeOrNil returns the time of t, nil when it is NULL
+func timeOrNil(t sql.NullTime) interface{} {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time
+}
+
+// UpdateJob applies edit to the job id and records the fields it changed in
+// job_changes under adminKey, in one transaction. It returns the changes
+// (none when the job already had the values), or sql.ErrNoRows.
+func UpdateJob(ctx context.Context, db *sql.DB, id string, edit JobEdit, adminKey string) (map[string]FieldChange, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before, after editedJob
+	err = tx.QueryRowContext(ctx,
+		`SELECT title, company, hidden, exp_date FROM jobs WHERE id = $1 FOR UPDATE`, id,
+	).Scan(&before.Title, &before.Company, &before.Hidden, &before.ExpDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// admin_edits keeps the values the source gave the edited fields, so the
+	// job is still recognized as a duplicate when synced again and its edits
+	// are not overwritten, see FindDuplicateJob. Earlier originals win.
+	err = tx.QueryRowContext(ctx, `
+		UPDATE jobs SET
+			title = COALESCE($2, title),
+			company = COALESCE($3, company),
+			hidden = COALESCE($4, hidden),
+			exp_date = CASE WHEN $5 THEN NOW() ELSE exp_date END,
+			admin_edits = CASE WHEN $2::text IS NULL THEN '{}'::jsonb ELSE jsonb_build_object('title', title) END
+				|| CASE WHEN $3::text IS NULL THEN '{}'::jsonb ELSE jsonb_build_object('company', company) END
+				|| CASE WHEN $5 THEN jsonb_build_object('exp_date', exp_date) ELSE '{}'::jsonb END
+				|| admin_edits,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING title, company, hidden, exp_date`,
+		id, edit.Title, edit.Company, edit.Hidden, edit.Expired,
+	).Scan(&after.Title, &after.Company, &after.Hidden, &after.ExpDate)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := after.changes(before)
+	if len(changes) == 0 {
+		return changes, tx.Commit()
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO job_changes (job_id, action, changes, admin_key) VALUES ($1, $2, $3, $4)`,
+		id, JobChangeUpdate, string(changesJSON), adminKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return changes, tx.Commit()
+}
+
+// DeleteJob deletes the job id, recording the deleted row in job_changes
+// under adminKey so it can be restored by hand, or returns sql.ErrNoRows. A
+// source still listing the job adds it again on its next sync; hide such
+// jobs instead.
+func DeleteJob(ctx context.Context, db *sql.DB, id string, adminKey string) error {
+	result, err := db.ExecContext(ctx, `
+		WITH deleted AS (
+			DELETE FROM jobs WHERE id = $1
+			RETURNING *
+		)
+		INSERT INTO job_changes (job_id, action, changes, admin_key)
+		SELECT id, $2, jsonb_build_object('job', row_to_json(deleted)::jsonb), $3
+		FROM deleted`,
+		id, JobChangeDelete, adminKey,
+	)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListJobChanges returns the audit log of the job id, newest first
+func ListJobChanges(ctx context.Context, db *sql.DB, id string) ([]JobChange, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, job_id, action, changes, admin_key, changed_at
+		FROM job_changes
+		WHERE job_id = $1
+		ORDER BY changed_at DESC, id DESC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []JobChange{}
+	for rows.Next() {
+		var c JobChange
+		var data []byte
+		if err := rows.Scan(&c.ID, &c.JobID, &c.Action, &data, &c.AdminKey, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		c.Changes = json.RawMessage(data)
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expired := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	title, hidden := "Senior Go Engineer", true
+	edit := JobEdit{Hidden: &hidden, Expired: true, Title: &title}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT title, company, hidden, exp_date FROM jobs WHERE id = \\$1 FOR UPDATE$").
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows([]string{"title", "company", "hidden", "exp_date"}).
+			AddRow("Golang Dev!!", "Paystack", false, nil))
+	mock.ExpectQuery("^UPDATE jobs SET (.+) WHERE id = \\$1 RETURNING title, company, hidden, exp_date$").
+		WithArgs("job-1", &title, nil, &hidden, true).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "company", "hidden", "exp_date"}).
+			AddRow(title, "Paystack", true, expired))
+	mock.ExpectExec("^INSERT INTO job_changes").
+		WithArgs("job-1", JobChangeUpdate, sqlmock.AnyArg(), "abcd1234").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	changes, err := UpdateJob(context.Background(), db, "job-1", edit, "abcd1234")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]FieldChange{
+		"title":    {"Golang Dev!!", title},
+		"hidden":   {false, true},
+		"exp_date": {nil, expired},
+	}, changes)
+
+	// Unchanged values are not logged
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT title, company, hidden, exp_date FROM jobs").
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows([]string{"title", "company", "hidden", "exp_date"}).
+			AddRow(title, "Paystack", true, expired))
+	mock.ExpectQuery("^UPDATE jobs SET").
+		WillReturnRows(sqlmock.NewRows([]string{"title", "company", "hidden", "exp_date"}).
+			AddRow(title, "Paystack", true, expired))
+	mock.ExpectCommit()
+
+	changes, err = UpdateJob(context.Background(), db, "job-1", JobEdit{Hidden: &hidden}, "abcd1234")
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT title, company, hidden, exp_date FROM jobs").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = UpdateJob(context.Background(), db, "missing", edit, "abcd1234")
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM jobs WHERE id = \\$1 (.+) INSERT INTO job_changes").
+		WithArgs("job-1", JobChangeDelete, "abcd1234").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM jobs WHERE id = \\$1 (.+) INSERT INTO job_changes").
+		WithArgs("missing", JobChangeDelete, "abcd1234").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, DeleteJob(context.Background(), db, "job-1", "abcd1234"))
+	assert.Equal(t, sql.ErrNoRows, DeleteJob(context.Background(), db, "missing", "abcd1234"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
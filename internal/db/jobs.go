@@ -3,215 +3,76 @@ package db
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"strings"
 	"time"
 
-	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/enrichment"
 	"Go9jaJobs/internal/models"
 )
 
-// BrandFetchResponse represents the response from the BrandFetch API
-type BrandFetchResponse struct {
-	Logos []struct {
-		Formats []struct {
-			Src    string `json:"src"`
-			Format string `json:"format"`
-		} `json:"formats"`
-		Type string `json:"type"`
-	} `json:"logos"`
-}
-
-// FetchCompanyLogo fetches a company logo using the BrandFetch API
-func FetchCompanyLogo(companyURL, apiToken string) string {
-	if companyURL == "" {
-		return ""
-	}
-	// Extract domain from URL
-	parsedURL, err := url.Parse(companyURL)
-	if err != nil {
-		log.Printf("Error parsing URL %s: %v", companyURL, err)
-		return ""
-	}
-
-	domain := parsedURL.Host
-	if domain == "" {
-		// If URL doesn't have a scheme, try using the path
-		domain = parsedURL.Path
-	}
-
-	// Remove www. prefix if present
-	domain = strings.TrimPrefix(domain, "www.")
-
-	// Remove any path components
-	if idx := strings.Index(domain, "/"); idx != -1 {
-		domain = domain[:idx]
-	}
-
-	if domain == "" {
-		return ""
-	}
-
-	// Create a request to BrandFetch API
-	apiURL := fmt.Sprintf("https://api.brandfetch.io/v2/brands/%s", domain)
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		log.Printf("Error creating LogoFetch request for %s: %v", domain, err)
-		return ""
-	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiToken))
-
-	// Make the request
-	client := &http.Client{Timeout: 10 * time.Second}
-	res, err := client.Do(req)
-	if err != nil {
-		log.Printf("Error fetching logo for %s: %v", domain, err)
-		return ""
-	}
-	defer res.Body.Close()
-
-	// Check if the request was successful
-	if res.StatusCode != http.StatusOK {
-		log.Printf("LogoFetch API returned non-200 status for %s: %d", domain, res.StatusCode)
-		return ""
-	}
-
-	// Read and parse the response
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		log.Printf("Error reading LogoFetch response for %s: %v", domain, err)
-		return ""
-	}
-
-	var brandResponse BrandFetchResponse
-	if err := json.Unmarshal(body, &brandResponse); err != nil {
-		log.Printf("Error parsing LogoFetch response for %s: %v", domain, err)
-		return ""
-	}
-
-	// Extract the first logo URL
-	for _, logo := range brandResponse.Logos {
-		if len(logo.Formats) > 0 {
-			return logo.Formats[0].Src
-		}
-	}
-
-	return ""
-}
-
-// IsDuplicateJob checks if a job already exists in the database
-func IsDuplicateJob(ctx context.Context, db *sql.DB, job models.Job) (bool, error) {
-	var count int
+// FindDuplicateJob returns the ID of another stored job with the same title and
+// company (see analyzer.NormalizeCompany) posted the same month as job, or ""
+// if there is none
+func FindDuplicateJob(ctx context.Context, db *sql.DB, job models.Job) (string, error) {
+	var id string
 
 	query := `
-		SELECT COUNT(*) FROM jobs 
-		WHERE LOWER(title) = LOWER($1) 
-		AND LOWER(company) = LOWER($2) 
-		AND EXTRACT(YEAR FROM posted_at) = EXTRACT(YEAR FROM $3::TIMESTAMP)
-		AND EXTRACT(MONTH FROM posted_at) = EXTRACT(MONTH FROM $3::TIMESTAMP)
+		SELECT id FROM jobs 
+		WHERE (LOWER(title) = LOWER($1) OR LOWER(admin_edits->>'title') = LOWER($1))
+		AND company_key = $2
+		AND EXTRACT(YEAR FROM posted_at) = EXTRACT(YEAR FROM $3::TIMESTAMPTZ)
+		AND EXTRACT(MONTH FROM posted_at) = EXTRACT(MONTH FROM $3::TIMESTAMPTZ)
+		AND id <> $4
+		LIMIT 1
 	`
 
-	err := db.QueryRowContext(ctx, query, job.Title, job.Company, job.PostedAt).Scan(&count)
-	if err != nil {
-		return false, err
+	err := db.QueryRowContext(ctx, query, job.Title, analyzer.NormalizeCompany(job.Company), job.PostedAt, job.ID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
 	}
-
-	return count > 0, nil
+	return id, err
 }
 
-// IsBlockedCompany checks if the company is in the blocked list
-func IsBlockedCompany(companyName string) bool {
-	blockedCompanies := []string{"canonical", "crossover"}
-
-	companyLower := strings.ToLower(companyName)
-	for _, blocked := range blockedCompanies {
-		if strings.Contains(companyLower, blocked) {
-			return true
-		}
-	}
-	return false
+// IsExpiredJob reports whether the job's expiry date is set and not after now
+func IsExpiredJob(job models.Job, now time.Time) bool {
+	return !job.ExpDate.IsZero() && !job.ExpDate.After(now)
 }
 
-// IsGoRelatedJob checks if a job is Go-related by looking for "go" or "golang" in title or description
-func IsGoRelatedJob(job models.Job) bool {
-	title := strings.ToLower(job.Title)
-	description := strings.ToLower(job.Description)
-
-	// Check for "go" as a whole word with different patterns:
-	// - surrounded by spaces: " go "
-	// - at beginning: "go "
-	// - at end: " go"
-	// - with punctuation: "(go)", "[go]", ",go", etc.
-	// - with different capitalization: "Go", "GO"
-
-	// Word boundary patterns to check
-	goPrefixes := []string{" go ", " go,", " go.", " go:", " go;", " go-", " go/", " go)", " go]", " go}", "(go ", "[go ", "{go "}
-	goSuffixes := []string{" go", ",go ", ".go ", ":go ", ";go ", "-go ", "/go ", "(go)", "[go]", "{go}"}
-	goStandalone := []string{"(go)", "[go]", "{go}", " go "}
-
-	// Check for the word "go" with various patterns
-	for _, pattern := range goPrefixes {
-		if strings.Contains(title, pattern) || strings.Contains(description, pattern) {
-			return true
-		}
-	}
-
-	for _, pattern := range goSuffixes {
-		if strings.Contains(title, pattern) || strings.Contains(description, pattern) {
-			return true
-		}
-	}
-
-	for _, pattern := range goStandalone {
-		if strings.Contains(title, pattern) || strings.Contains(description, pattern) {
-			return true
-		}
-	}
-
-	// Special case: if title starts with "go" or ends with "go"
-	if strings.HasPrefix(title, "go ") || strings.HasSuffix(title, " go") {
-		return true
-	}
-
-	// Check for "golang" anywhere in title or description
-	if strings.Contains(title, "golang") || strings.Contains(description, "golang") {
-		return true
-	}
-
-	return false
+// nullTime converts a zero time into a SQL NULL so unset dates are not stored as year 1
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
 }
 
-
-
-
 // SaveJobsToDB saves the jobs to the database with duplicate and blocked company filtering
 func SaveJobsToDB(ctx context.Context, db *sql.DB, jobs []models.Job) (int, error) {
-	// Get config to access BrandFetch API token
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		log.Printf("Warning: Failed to load config for logo fetching: %v", err)
-	}
-
 	// Use context for transaction to support cancelation
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
 
+	// Provenance of the fields overwritten on every save is replaced, the rest
+	// (e.g. a kept or enriched logo) keeps its origin
+	// Jobs without a company URL take the domain of their company (see
+	// EnsureCompany), and with it its company details
 	stmt, err := tx.PrepareContext(ctx, `
 	INSERT INTO jobs (id, job_id, title, company, company_url, company_logo, location, description, url, salary, 
-		posted_at, job_type, is_remote, source, raw_data, date_gotten, country, state)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		posted_at, job_type, is_remote, source, raw_data, date_gotten, country, state,
+		word_count, reading_time_minutes, exp_date, apply_method, company_domain, provenance, seniority, fingerprint,
+		assessments, stack, tracks, description_html, language, company_key, company_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+		COALESCE(NULLIF($23, ''), (SELECT domain FROM companies WHERE id = $33)),
+		$24, $25, $26, $27, $28, $29, $30, $31, $32, $33)
 	ON CONFLICT (id) DO UPDATE SET
-		title = EXCLUDED.title, 
-		company = EXCLUDED.company,
+		title = CASE WHEN jobs.admin_edits ? 'title' THEN jobs.title ELSE EXCLUDED.title END,
+		company = CASE WHEN jobs.admin_edits ? 'company' THEN jobs.company ELSE EXCLUDED.company END,
 		location = EXCLUDED.location,
 		description = EXCLUDED.description,
+		description_html = EXCLUDED.description_html,
+		language = EXCLUDED.language,
 		url = EXCLUDED.url,
 		salary = EXCLUDED.salary,
 		posted_at = EXCLUDED.posted_at,
@@ -219,7 +80,26 @@ func SaveJobsToDB(ctx context.Context, db *sql.DB, jobs []models.Job) (int, erro
 		is_remote = EXCLUDED.is_remote,
 		source = EXCLUDED.source,
 		raw_data = EXCLUDED.raw_data,
-		company_logo = EXCLUDED.company_logo,
+		company_logo = COALESCE(NULLIF(EXCLUDED.company_logo, ''), jobs.company_logo),
+		word_count = EXCLUDED.word_count,
+		reading_time_minutes = EXCLUDED.reading_time_minutes,
+		exp_date = CASE WHEN jobs.admin_edits ? 'exp_date' THEN jobs.exp_date ELSE EXCLUDED.exp_date END,
+		apply_method = EXCLUDED.apply_method,
+		seniority = EXCLUDED.seniority,
+		assessments = EXCLUDED.assessments,
+		stack = EXCLUDED.stack,
+		tracks = EXCLUDED.tracks,
+		fingerprint = EXCLUDED.fingerprint,
+		company_domain = EXCLUDED.company_domain,
+		company_key = EXCLUDED.company_key,
+		company_id = EXCLUDED.company_id,
+		provenance = (jobs.provenance - `+sourcedFieldsArray()+`) || EXCLUDED.provenance,
+		language_flags = CASE WHEN jobs.description IS DISTINCT FROM EXCLUDED.description THEN NULL ELSE jobs.language_flags END,
+		salary_flag = CASE WHEN jobs.salary IS DISTINCT FROM EXCLUDED.salary THEN NULL ELSE jobs.salary_flag END,
+		description_translation = CASE WHEN jobs.description IS DISTINCT FROM EXCLUDED.description THEN NULL ELSE jobs.description_translation END,
+		translated_by = CASE WHEN jobs.description IS DISTINCT FROM EXCLUDED.description THEN NULL ELSE jobs.translated_by END,
+		translation_checked_at = CASE WHEN jobs.description IS DISTINCT FROM EXCLUDED.description THEN NULL ELSE jobs.translation_checked_at END,
+		last_seen_at = CURRENT_TIMESTAMP,
 		updated_at = CURRENT_TIMESTAMP
 	`)
 
@@ -232,8 +112,11 @@ func SaveJobsToDB(ctx context.Context, db *sql.DB, jobs []models.Job) (int, erro
 	count := 0
 	skippedDuplicates := 0
 	skippedBlockedCompanies := 0
-	skippedNonGoJobs := 0
-
+	skippedNoTrack := 0
+	skippedExpired := 0
+	mergedRetitled := 0
+	skippedThin := 0
+	rule := currentDescriptionRule()
 
 	for _, job := range jobs {
 		// Check for context cancellation
@@ -244,38 +127,112 @@ func SaveJobsToDB(ctx context.Context, db *sql.DB, jobs []models.Job) (int, erro
 		default:
 		}
 
+		// Providers report dates in their own zones; store the instants in UTC
+		job = job.In(time.UTC)
+
+		// Descriptions are rendered as HTML: keep only safe markup, and its
+		// text when the source gave no plain text
+		job.DescriptionHTML = analyzer.SanitizeHTML(job.DescriptionHTML)
+		if strings.TrimSpace(job.Description) == "" {
+			job.Description = analyzer.HTMLToText(job.DescriptionHTML)
+		}
+		job.Language = analyzer.DetectLanguage(job.Title + "\n" + job.Description)
+
+		// Skip jobs that already expired at the provider so they never surface
+		if IsExpiredJob(job, time.Now()) {
+			log.Printf("Skipping expired job: %s at %s (expired %s)",
+				job.Title, job.Company, job.ExpDate.Format(time.RFC3339))
+			skippedExpired++
+			RecordSkip(ctx, db, job, SkipReasonExpired, "expired "+job.ExpDate.Format(time.RFC3339))
+			continue
+		}
+
 		// Skip jobs from blocked companies
 		if IsBlockedCompany(job.Company) {
 			log.Printf("Skipping job from blocked company: %s - %s", job.Company, job.Title)
 			skippedBlockedCompanies++
+			RecordSkip(ctx, db, job, SkipReasonBlockedCompany, "")
 			continue
 		}
 
-		// Skip jobs that are not Go-related
-		if !IsGoRelatedJob(job) {
-			log.Printf("Skipping non-Go related job: %s at %s", job.Title, job.Company)
-			skippedNonGoJobs++
+		// Skip jobs about none of the tracks, tag the others with theirs
+		job.Tracks = JobTracks(job)
+		if len(job.Tracks) == 0 {
+			log.Printf("Skipping job matching no track: %s at %s", job.Title, job.Company)
+			skippedNoTrack++
+			RecordSkip(ctx, db, job, SkipReasonNoTrack, "")
 			continue
-		 }
+		}
 
+		// Skip postings with little or no description (title and link only)
+		if rule.IsThinJob(job) {
+			log.Printf("Skipping job with thin description: %s at %s", job.Title, job.Company)
+			skippedThin++
+			RecordSkip(ctx, db, job, SkipReasonThin, fmt.Sprintf("description shorter than %d characters", rule.MinDescriptionLength(job.Source)))
+			continue
+		}
 
 		// Check for duplicates
-		isDuplicate, err := IsDuplicateJob(ctx, db, job)
+		duplicateID, err := FindDuplicateJob(ctx, db, job)
 		if err != nil {
 			log.Printf("Error checking for duplicate job: %v", err)
+			syncErrorsFrom(ctx).Add(StageFilter, job.Source, err)
 			// Continue processing other jobs even if this check fails
-		} else if isDuplicate {
-			log.Printf("Skipping duplicate job: %s at %s (posted %s)",
-				job.Title, job.Company, job.PostedAt.Format("Jan 2006"))
+		} else if duplicateID != "" {
+			log.Printf("Skipping duplicate job: %s at %s (posted %s), kept %s",
+				job.Title, job.Company, job.PostedAt.Format("Jan 2006"), duplicateID)
 			skippedDuplicates++
+			RecordSkip(ctx, db, job, SkipReasonDuplicate, "same title and company posted "+job.PostedAt.Format("Jan 2006"))
+			RecordDedupe(ctx, db, job, duplicateID, DedupeRuleDuplicate, 1)
 			continue
 		}
 
-		// If we have a config and the job doesn't have a logo, try to fetch one
-		if  cfg != nil && cfg.Mode != "dev" && cfg.BrandFetchAPIKey != "" && job.CompanyLogo == "" && job.CompanyURL != "" {
-			job.CompanyLogo = FetchCompanyLogo(job.CompanyURL, cfg.BrandFetchAPIKey)
-			if job.CompanyLogo != "" {
-				log.Printf("Fetched logo for %s from BrandFetch", job.Company)
+		// Merge roles re-posted under a slightly different title into the
+		// existing row, looked up in the batch's transaction so that rows saved
+		// earlier in the batch are found too
+		existingID, score, err := FindRetitledJob(ctx, tx, job)
+		if err != nil {
+			tx.Rollback()
+			return count, err
+		}
+		if existingID != "" {
+			if err := MergeRetitledJob(ctx, tx, existingID, job); err != nil {
+				tx.Rollback()
+				return count, err
+			}
+			log.Printf("Merged re-titled job %q at %s into %s (similarity %.2f)", job.Title, job.Company, existingID, score)
+			mergedRetitled++
+			RecordDedupe(ctx, db, job, existingID, DedupeRuleRetitled, score)
+			continue
+		}
+
+		// Compute reading metadata so listings can show "2 min read" chips
+		job.WordCount = analyzer.WordCount(job.Description)
+		job.ReadingTime = analyzer.ReadingTimeMinutes(job.WordCount)
+		job.ApplyMethod = analyzer.DetectApplyMethod(job.URL, job.CompanyURL)
+		job.Seniority = analyzer.DetectSeniority(job.Title, job.Description)
+		job.Assessments = analyzer.DetectAssessments(job.Description)
+		job.Stack = analyzer.DetectStack(job.Title, job.Description)
+		fingerprint := analyzer.JobFingerprint(job.Title, job.Company, job.Location)
+		companyKey := analyzer.NormalizeCompany(job.Company)
+		companyDomain := enrichment.CompanyDomain(job.CompanyURL)
+		company, err := EnsureCompany(ctx, tx, companyKey, job.Company, companyDomain)
+		if err != nil {
+			tx.Rollback()
+			return count, err
+		}
+		var companyID int64
+		provenance := jobProvenance(job)
+		if company != nil {
+			companyID = company.ID
+			// Verified companies keep the domain and logo an admin set over
+			// those of their sources
+			if company.Verified && company.Domain != "" {
+				companyDomain = company.Domain
+			}
+			if company.Verified && company.LogoURL != "" {
+				job.CompanyLogo = company.LogoURL
+				provenance["company_logo"] = enrichment.SourceAdmin
 			}
 		}
 
@@ -298,12 +255,33 @@ func SaveJobsToDB(ctx context.Context, db *sql.DB, jobs []models.Job) (int, erro
 			job.DateGotten,
 			job.Country,
 			job.State,
+			job.WordCount,
+			job.ReadingTime,
+			nullTime(job.ExpDate),
+			job.ApplyMethod,
+			companyDomain,
+			provenance.String(),
+			sql.NullString{String: job.Seniority, Valid: job.Seniority != ""},
+			fingerprint,
+			Array(job.Assessments),
+			Array(job.Stack),
+			Array(job.Tracks),
+			sql.NullString{String: job.DescriptionHTML, Valid: job.DescriptionHTML != ""},
+			sql.NullString{String: job.Language, Valid: job.Language != ""},
+			companyKey,
+			sql.NullInt64{Int64: companyID, Valid: companyID != 0},
 		)
 
 		if err != nil {
 			tx.Rollback()
 			return count, err
 		}
+
+		// Keep the same job from other sources stored, but only list it once
+		if err := LinkDuplicate(ctx, tx, job.ID, fingerprint); err != nil {
+			tx.Rollback()
+			return count, err
+		}
 		count++
 	}
 
@@ -311,14 +289,8 @@ func SaveJobsToDB(ctx context.Context, db *sql.DB, jobs []models.Job) (int, erro
 		return count, err
 	}
 
-	log.Printf("Jobs processed: %d saved, %d duplicates skipped, %d from blocked companies skipped, %d non-Go jobs skipped",
-		count, skippedDuplicates, skippedBlockedCompanies, skippedNonGoJobs)
+	log.Printf("Jobs processed: %d saved, %d re-titled merged, %d duplicates skipped, %d from blocked companies skipped, %d jobs matching no track skipped, %d expired jobs skipped, %d thin jobs skipped",
+		count, mergedRetitled, skippedDuplicates, skippedBlockedCompanies, skippedNoTrack, skippedExpired, skippedThin)
 
 	return count, nil
 }
-
-
-
-
-
-
@@ -1 +1,38 @@
 package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsExpiredJob(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, IsExpiredJob(models.Job{}, now), "jobs without an expiry never expire")
+	assert.False(t, IsExpiredJob(models.Job{ExpDate: now.Add(time.Hour)}, now))
+	assert.True(t, IsExpiredJob(models.Job{ExpDate: now}, now))
+	assert.True(t, IsExpiredJob(models.Job{ExpDate: now.Add(-time.Hour)}, now))
+}
+
+func TestFindDuplicateJobNormalizesCompany(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	posted := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	// The job itself, saved before, is not its own duplicate
+	mock.ExpectQuery("^SELECT id FROM jobs WHERE (.+) AND company_key = \\$2 (.+) AND id <> \\$4 ").
+		WithArgs("Go Engineer", "andela", posted, "job-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-1"))
+
+	id, err := FindDuplicateJob(context.Background(), db, models.Job{ID: "job-2", Title: "Go Engineer", Company: "ANDELA NIGERIA", PostedAt: posted})
+	assert.NoError(t, err)
+	assert.Equal(t, "job-1", id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"Go9jaJobs/internal/analyzer"
+)
+
+// JobText is the text of a job checked by the language audit
+type JobText struct {
+	ID          string
+	Title       string
+	Description string
+}
+
+// FlaggedJob is a job whose posting has language flags
+type FlaggedJob struct {
+	ID      string                  `json:"id"`
+	Title   string                  `json:"title"`
+	Company string                  `json:"company"`
+	URL     string                  `json:"url,omitempty"`
+	Source  string                  `json:"source"`
+	Flags   []analyzer.LanguageFlag `json:"language_flags"`
+}
+
+// FindUnauditedJobs returns up to limit jobs not yet checked by the language
+// audit, including those whose description changed since
+func FindUnauditedJobs(ctx context.Context, db *sql.DB, limit int) ([]JobText, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, title, COALESCE(description, '')
+		FROM jobs
+		WHERE language_flags IS NULL
+		ORDER BY posted_at DESC
+		LIMIT $1`, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []JobText
+	for rows.Next() {
+		var job JobText
+		if err := rows.Scan(&job.ID, &job.Title, &job.Description); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// SetLanguageFlags stores the language audit result of a job; no flags are
+// stored as an empty list so the job counts as audited
+func SetLanguageFlags(ctx context.Context, db *sql.DB, id string, flags []analyzer.LanguageFlag) error {
+	if flags == nil {
+		flags = []analyzer.LanguageFlag{}
+	}
+	data, err := json.Marshal(flags)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `UPDATE jobs SET language_flags = $2, updated_at = NOW() WHERE id = $1`, id, string(data))
+	return err
+}
+
+// FindFlaggedJobs returns unexpired jobs with language flags, newest first,
+// optionally only those with flags of category
+func FindFlaggedJobs(ctx context.Context, db *sql.DB, category string, limit int) ([]FlaggedJob, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, title, company, COALESCE(url, ''), source, language_flags
+		FROM jobs
+		WHERE jsonb_array_length(language_flags) > 0
+			AND ($1 = '' OR language_flags @> jsonb_build_array(jsonb_build_object('category', $1::text)))
+			AND (exp_date IS NULL OR exp_date > NOW())
+		ORDER BY posted_at DESC
+		LIMIT $2`, category, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []FlaggedJob{}
+	for rows.Next() {
+		var (
+			job   FlaggedJob
+			flags []byte
+		)
+		if err := rows.Scan(&job.ID, &job.Title, &job.Company, &job.URL, &job.Source, &flags); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(flags, &job.Flags); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+)
+
+// syncLockClass is the first key of the advisory locks taken by source syncs,
+// keeping them apart from any other advisory lock on the database
+const syncLockClass = 3024
+
+// ErrLocked is returned when a lock is already held by another session
+var ErrLocked = errors.New("lock held by another session")
+
+// LockSource takes the advisory lock serialising the ingest of source across
+// every server instance. The lock lives on a dedicated connection until the
+// returned release func is called. Returns ErrLocked if another sync of the
+// source holds it.
+func LockSource(ctx context.Context, db *sql.DB, source string) (func(), error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1, hashtext($2))", syncLockClass, source).Scan(&locked)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrLocked
+	}
+
+	return func() {
+		// Unlock even when the sync's context is done
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1, hashtext($2))", syncLockClass, source); err != nil {
+			log.Printf("Error releasing sync lock of %s: %v", source, err)
+		}
+		conn.Close()
+	}, nil
+}
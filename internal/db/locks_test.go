@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockSource(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT pg_try_advisory_lock\\(\\$1, hashtext\\(\\$2\\)\\)$").
+		WithArgs(syncLockClass, "jsearch").
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectExec("^SELECT pg_advisory_unlock\\(\\$1, hashtext\\(\\$2\\)\\)$").
+		WithArgs(syncLockClass, "jsearch").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// A second sync of the source finds the lock taken
+	mock.ExpectQuery("^SELECT pg_try_advisory_lock").
+		WithArgs(syncLockClass, "jsearch").
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+	release, err := LockSource(context.Background(), db, "jsearch")
+	assert.NoError(t, err)
+	release()
+
+	_, err = LockSource(context.Background(), db, "jsearch")
+	assert.ErrorIs(t, err, ErrLocked)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenPool(t *testing.T) {
+	pool, err := OpenPool(context.Background(), "postgres://go9jajobs@127.0.0.1:1/go9jajobs", PoolConfig{
+		MaxConns:        20,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
+	})
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	config := pool.Config()
+	assert.Equal(t, int32(20), config.MaxConns)
+	assert.Equal(t, 30*time.Minute, config.MaxConnLifetime)
+	assert.Equal(t, 5*time.Minute, config.MaxConnIdleTime)
+	// Prepared statements are cached per connection
+	assert.Equal(t, 512, config.ConnConfig.StatementCacheCapacity)
+	// Sessions run in UTC unless the connection string names a zone
+	assert.Equal(t, "UTC", config.ConnConfig.RuntimeParams["timezone"])
+
+	zoned, err := OpenPool(context.Background(), "postgres://go9jajobs@127.0.0.1:1/go9jajobs?timezone=Africa/Lagos", PoolConfig{})
+	assert.NoError(t, err)
+	defer zoned.Close()
+	assert.Equal(t, "Africa/Lagos", zoned.Config().ConnConfig.RuntimeParams["timezone"])
+
+	_, err = OpenPool(context.Background(), "postgres://go9jajobs@127.0.0.1:1/go9jajobs?pool_max_conns=many", PoolConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/models"
+)
+
+// ProvenanceAnalyzer marks fields derived by the analyzer rather than a source
+const ProvenanceAnalyzer = "analyzer"
+
+// sourcedFields are the key job fields a sync overwrites on every save; their
+// provenance is replaced by the saving source
+var sourcedFields = []string{"title", "company", "location", "description", "url", "salary", "job_type", "exp_date"}
+
+// Provenance maps key job fields to the source or enrichment step that
+// supplied their current value, e.g. {"salary": "jsearch", "company_logo": "brandfetch"}
+type Provenance map[string]string
+
+// jobProvenance records job.Source for the non-empty key fields of a job being
+// saved, and the analyzer for the fields it derives
+func jobProvenance(job models.Job) Provenance {
+	provenance := Provenance{}
+	values := map[string]string{
+		"title":        job.Title,
+		"company":      job.Company,
+		"company_logo": job.CompanyLogo,
+		"location":     job.Location,
+		"description":  job.Description,
+		"url":          job.URL,
+		"salary":       job.Salary,
+		"job_type":     job.JobType,
+	}
+	for field, value := range values {
+		if value != "" {
+			provenance[field] = job.Source
+		}
+	}
+	if !job.ExpDate.IsZero() {
+		provenance["exp_date"] = job.Source
+	}
+	if job.ApplyMethod != "" {
+		provenance["apply_method"] = ProvenanceAnalyzer
+	}
+	if job.Seniority != "" {
+		provenance["seniority"] = ProvenanceAnalyzer
+	}
+	if len(job.Assessments) > 0 {
+		provenance["assessments"] = ProvenanceAnalyzer
+	}
+	if len(job.Stack) > 0 {
+		provenance["stack"] = ProvenanceAnalyzer
+	}
+	if len(job.Tracks) > 0 {
+		provenance["tracks"] = ProvenanceAnalyzer
+	}
+	return provenance
+}
+
+// mergedProvenance records job.Source for the fields a re-titled merge takes
+// from job (those it has values for)
+func mergedProvenance(job models.Job) Provenance {
+	provenance := Provenance{}
+	for field, value := range map[string]string{"description": job.Description, "url": job.URL, "salary": job.Salary} {
+		if value != "" {
+			provenance[field] = job.Source
+		}
+	}
+	return provenance
+}
+
+// String encodes the provenance for a JSONB parameter
+func (p Provenance) String() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// sourcedFieldsArray is sourcedFields as a Postgres text[] literal
+func sourcedFieldsArray() string {
+	return "ARRAY['" + strings.Join(sourcedFields, "','") + "']"
+}
+
+// JobDetail is a stored job with the origin of its fields, for admin debugging
+type JobDetail struct {
+	models.Job
+	Provenance Provenance `json:"provenance"`
+	// LanguageFlags are the language audit annotations, nil until audited
+	LanguageFlags []analyzer.LanguageFlag `json:"language_flags"`
+	// SalaryFlag is set when the salary awaits verification (hidden from listings)
+	SalaryFlag *SalaryFlag `json:"salary_flag,omitempty"`
+	LastSeenAt *time.Time  `json:"last_seen_at,omitempty"`
+	UpdatedAt  *time.Time  `json:"updated_at,omitempty"`
+	// Hidden jobs were taken off listings and feeds by an admin
+	Hidden bool `json:"hidden"`
+	// DescriptionTranslation is the description translated by TranslatedBy
+	// when it is in another language than listings are read in
+	DescriptionTranslation string `json:"description_translation,omitempty"`
+	TranslatedBy           string `json:"translated_by,omitempty"`
+	// CompanyID is the company of the job, see GET /api/companies/{id}/jobs
+	CompanyID int64 `json:"company_id,omitempty"`
+}
+
+// In returns the detail with its dates in loc
+func (d *JobDetail) In(loc *time.Location) *JobDetail {
+	in := *d
+	in.Job = d.Job.In(loc)
+	if d.LastSeenAt != nil {
+		lastSeenAt := d.LastSeenAt.In(loc)
+		in.LastSeenAt = &lastSeenAt
+	}
+	if d.UpdatedAt != nil {
+		updatedAt := d.UpdatedAt.In(loc)
+		in.UpdatedAt = &updatedAt
+	}
+	return &in
+}
+
+// GetJobDetail returns a job with its provenance, or sql.ErrNoRows
+func GetJobDetail(ctx context.Context, db *sql.DB, id string) (*JobDetail, error) {
+	var (
+		detail     JobDetail
+		postedAt   sql.NullTime
+		expDate    sql.NullTime
+		lastSeenAt sql.NullTime
+		updatedAt  sql.NullTime
+		provenance []byte
+		flags      []byte
+		salaryFlag []byte
+	)
+	err := db.QueryRowContext(ctx, `
+		SELECT id, job_id, title, company, COALESCE(company_url, ''), COALESCE(company_logo, ''),
+			COALESCE(location, ''), COALESCE(description, ''), COALESCE(url, ''), COALESCE(salary, ''),
+			posted_at, COALESCE(job_type, ''), COALESCE(is_remote, false), source, COALESCE(raw_data, ''),
+			exp_date, COALESCE(apply_method, ''), COALESCE(seniority, ''), provenance, language_flags, salary_flag,
+			last_seen_at, updated_at, COALESCE(assessments, '{}'), COALESCE(stack, '{}'),
+			COALESCE(tracks, '{}'), hidden, COALESCE(description_html, ''), COALESCE(language, ''),
+			COALESCE(description_translation, ''), COALESCE(translated_by, ''), COALESCE(company_id, 0)
+		FROM jobs
+		WHERE id = $1`, id,
+	).Scan(
+		&detail.ID, &detail.JobID, &detail.Title, &detail.Company, &detail.CompanyURL, &detail.CompanyLogo,
+		&detail.Location, &detail.Description, &detail.URL, &detail.Salary,
+		&postedAt, &detail.JobType, &detail.IsRemote, &detail.Source, &detail.RawData,
+		&expDate, &detail.ApplyMethod, &detail.Seniority, &provenance, &flags, &salaryFlag,
+		&lastSeenAt, &updatedAt, ScanArray(&detail.Assessments), ScanArray(&detail.Stack),
+		ScanArray(&detail.Tracks), &detail.Hidden, &detail.DescriptionHTML, &detail.Language,
+		&detail.DescriptionTranslation, &detail.TranslatedBy, &detail.CompanyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sanitized again, for jobs saved under an older policy
+	detail.DescriptionHTML = analyzer.SanitizeHTML(detail.DescriptionHTML)
+	detail.PostedAt = postedAt.Time
+	detail.ExpDate = expDate.Time
+	if lastSeenAt.Valid {
+		detail.LastSeenAt = &lastSeenAt.Time
+	}
+	if updatedAt.Valid {
+		detail.UpdatedAt = &updatedAt.Time
+	}
+	detail.Provenance = Provenance{}
+	if len(provenance) > 0 {
+		json.Unmarshal(provenance, &detail.Provenance)
+	}
+	if len(flags) > 0 {
+		json.Unmarshal(flags, &detail.LanguageFlags)
+	}
+	if len(salaryFlag) > 0 {
+		detail.SalaryFlag = &SalaryFlag{}
+		json.Unmarshal(salaryFlag, detail.SalaryFlag)
+	}
+	return &detail, nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobProvenance(t *testing.T) {
+	provenance := jobProvenance(models.Job{
+		Source:      "jsearch",
+		Title:       "Golang Developer",
+		Company:     "Paystack",
+		Salary:      "$4,000/month",
+		ApplyMethod: "direct",
+		ExpDate:     time.Now(),
+	})
+
+	assert.Equal(t, Provenance{
+		"title":        "jsearch",
+		"company":      "jsearch",
+		"salary":       "jsearch",
+		"exp_date":     "jsearch",
+		"apply_method": ProvenanceAnalyzer,
+	}, provenance)
+
+	// Empty fields have no provenance, so a kept logo keeps its origin
+	assert.NotContains(t, provenance, "company_logo")
+	assert.Equal(t, `{"apply_method":"analyzer","company":"jsearch","exp_date":"jsearch","salary":"jsearch","title":"jsearch"}`, provenance.String())
+}
+
+func TestGetJobDetail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	posted := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "job_id", "title", "company", "company_url", "company_logo", "location",
+		"description", "url", "salary", "posted_at", "job_type", "is_remote", "source", "raw_data",
+		"exp_date", "apply_method", "seniority", "provenance", "language_flags", "salary_flag", "last_seen_at", "updated_at",
+		"assessments", "stack", "tracks", "hidden", "description_html", "language",
+		"description_translation", "translated_by", "company_id"}
+
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE id = \\$1$").
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(
+			"job-1", "ext-1", "Golang Developer", "Paystack", "https://paystack.com", "https://cdn.example/paystack.png", "Lagos",
+			"", "", "$4,000/month", posted, "", false, "jsearch", "",
+			nil, "direct", "senior", []byte(`{"salary":"jsearch","company_logo":"brandfetch"}`),
+			[]byte(`[{"category":"age","phrase":"young","suggestion":"describe the skills wanted"}]`),
+			[]byte(`{"kind":"high","monthly":4000,"median":500,"currency":"USD"}`), posted, nil,
+			[]byte(`{take_home}`), []byte(`{go1.22,postgres}`), []byte(`{go}`), true,
+			`<p onclick="steal()">Build payments</p><script>alert(1)</script>`, "fr", "Build payments", "gemini", 7,
+		))
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE id = \\$1$").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	job, err := GetJobDetail(context.Background(), db, "job-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "brandfetch", job.Provenance["company_logo"])
+	assert.Equal(t, "jsearch", job.Provenance["salary"])
+	assert.Equal(t, "young", job.LanguageFlags[0].Phrase)
+	assert.True(t, job.ExpDate.IsZero())
+	assert.Equal(t, "senior", job.Seniority)
+	assert.Equal(t, []string{"go1.22", "postgres"}, job.Stack)
+	assert.Equal(t, []string{"go"}, job.Tracks)
+	assert.Equal(t, "high", job.SalaryFlag.Kind)
+	assert.Nil(t, job.UpdatedAt)
+	assert.True(t, job.Hidden)
+	assert.Equal(t, posted, *job.LastSeenAt)
+	assert.Equal(t, "<p>Build payments</p>", job.DescriptionHTML)
+	assert.Equal(t, "fr", job.Language)
+	assert.Equal(t, "Build payments", job.DescriptionTranslation)
+	assert.Equal(t, "gemini", job.TranslatedBy)
+	assert.Equal(t, int64(7), job.CompanyID)
+
+	_, err = GetJobDetail(context.Background(), db, "missing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PurgeCounts are the rows of a source affected by a purge
+type PurgeCounts struct {
+	Jobs  int64 `json:"jobs"`
+	Skips int64 `json:"skips"`
+}
+
+// CountSourceRows returns the rows a purge of source ingested in [since, until)
+// would remove, without changing anything
+func CountSourceRows(ctx context.Context, db *sql.DB, source string, since, until time.Time) (PurgeCounts, error) {
+	var counts PurgeCounts
+	err := db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM jobs WHERE source = $1 AND created_at >= $2 AND created_at < $3),
+			(SELECT COUNT(*) FROM job_skips WHERE source = $1 AND skipped_at >= $2 AND skipped_at < $3)`,
+		source, since, until,
+	).Scan(&counts.Jobs, &counts.Skips)
+	return counts, err
+}
+
+// PurgeSource removes the jobs of source ingested in [since, until), moving
+// them to jobs_quarantine when quarantine is set, together with the skips
+// recorded for the source in that window. Everything happens in one
+// transaction.
+func PurgeSource(ctx context.Context, db *sql.DB, source string, since, until time.Time, quarantine bool) (PurgeCounts, error) {
+	var counts PurgeCounts
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return counts, err
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM jobs WHERE source = $1 AND created_at >= $2 AND created_at < $3`
+	if quarantine {
+		query = `
+			WITH purged AS (
+				DELETE FROM jobs
+				WHERE source = $1 AND created_at >= $2 AND created_at < $3
+				RETURNING *
+			)
+			INSERT INTO jobs_quarantine (id, job_id, source, data)
+			SELECT id, job_id, source, row_to_json(purged)::jsonb
+			FROM purged
+			ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, quarantined_at = NOW()`
+	}
+	result, err := tx.ExecContext(ctx, query, source, since, until)
+	if err != nil {
+		return counts, err
+	}
+	if counts.Jobs, err = result.RowsAffected(); err != nil {
+		return counts, err
+	}
+
+	result, err = tx.ExecContext(ctx,
+		`DELETE FROM job_skips WHERE source = $1 AND skipped_at >= $2 AND skipped_at < $3`,
+		source, since, until,
+	)
+	if err != nil {
+		return counts, err
+	}
+	if counts.Skips, err = result.RowsAffected(); err != nil {
+		return counts, err
+	}
+
+	return counts, tx.Commit()
+}
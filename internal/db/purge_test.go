@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeSource(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE source = \\$1 (.+) FROM job_skips WHERE source = \\$1").
+		WithArgs("linkedin", since, until).
+		WillReturnRows(sqlmock.NewRows([]string{"jobs", "skips"}).AddRow(12, 3))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM jobs (.+) INSERT INTO jobs_quarantine").
+		WithArgs("linkedin", since, until).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+	mock.ExpectExec("^DELETE FROM job_skips WHERE source = \\$1").
+		WithArgs("linkedin", since, until).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	counts, err := CountSourceRows(context.Background(), db, "linkedin", since, until)
+	assert.NoError(t, err)
+	assert.Equal(t, PurgeCounts{Jobs: 12, Skips: 3}, counts)
+
+	counts, err = PurgeSource(context.Background(), db, "linkedin", since, until, true)
+	assert.NoError(t, err)
+	assert.Equal(t, PurgeCounts{Jobs: 12, Skips: 3}, counts)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"Go9jaJobs/internal/models"
+)
+
+// DescriptionRule is the shortest description the save pipeline keeps, so
+// aggregated postings that are just a title and a link are skipped
+type DescriptionRule struct {
+	// MinLength applies to sources without an entry in BySource
+	MinLength int
+	// BySource overrides MinLength per job source (e.g. "apify indeed");
+	// 0 accepts any description
+	BySource map[string]int
+}
+
+var (
+	descriptionRuleMu sync.RWMutex
+	descriptionRule   DescriptionRule
+)
+
+// SetDescriptionRule sets the rule applied by SaveJobsToDB; the zero rule
+// keeps every job
+func SetDescriptionRule(rule DescriptionRule) {
+	descriptionRuleMu.Lock()
+	defer descriptionRuleMu.Unlock()
+	descriptionRule = rule
+}
+
+// MinDescriptionLength returns the shortest description kept from source
+func (r DescriptionRule) MinDescriptionLength(source string) int {
+	if n, ok := r.BySource[strings.ToLower(source)]; ok {
+		return n
+	}
+	return r.MinLength
+}
+
+// IsThinJob reports whether the job's description, ignoring surrounding
+// whitespace, is shorter than the rule allows for its source
+func (r DescriptionRule) IsThinJob(job models.Job) bool {
+	return utf8.RuneCountInString(strings.TrimSpace(job.Description)) < r.MinDescriptionLength(job.Source)
+}
+
+// currentDescriptionRule returns the rule set by SetDescriptionRule
+func currentDescriptionRule() DescriptionRule {
+	descriptionRuleMu.RLock()
+	defer descriptionRuleMu.RUnlock()
+	return descriptionRule
+}
@@ -0,0 +1,20 @@
+package db
+
+import (
+	"testing"
+
+	"Go9jaJobs/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescriptionRule(t *testing.T) {
+	rule := DescriptionRule{MinLength: 20, BySource: map[string]int{"apify indeed": 0, "linkedin": 40}}
+
+	assert.True(t, rule.IsThinJob(models.Job{Source: "jsearch", Description: "  Apply here.  "}))
+	assert.False(t, rule.IsThinJob(models.Job{Source: "jsearch", Description: "Build Go services for payments."}))
+	assert.True(t, rule.IsThinJob(models.Job{Source: "LinkedIn", Description: "Build Go services for payments."}))
+	assert.False(t, rule.IsThinJob(models.Job{Source: "apify indeed", Description: ""}))
+
+	assert.False(t, DescriptionRule{}.IsThinJob(models.Job{Source: "jsearch"}))
+}
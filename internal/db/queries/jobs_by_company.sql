@@ -0,0 +1,9 @@
+-- Jobs posted per company since a date, most first
+-- :since date
+-- :limit int = 50
+SELECT company, COUNT(*) AS jobs, COUNT(*) FILTER (WHERE is_remote) AS remote_jobs, MAX(posted_at) AS latest
+FROM jobs
+WHERE posted_at >= $1
+GROUP BY company
+ORDER BY jobs DESC, company
+LIMIT $2
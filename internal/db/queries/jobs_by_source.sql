@@ -0,0 +1,7 @@
+-- Jobs saved per source and day since a date
+-- :since date
+SELECT source, DATE(created_at) AS day, COUNT(*) AS jobs
+FROM jobs
+WHERE created_at >= $1
+GROUP BY source, DATE(created_at)
+ORDER BY day DESC, source
@@ -0,0 +1,8 @@
+-- Skipped jobs per source and reason since a date, optionally of one source
+-- :since date
+-- :source text = ''
+SELECT source, reason, COUNT(*) AS skips, MAX(skipped_at) AS latest
+FROM job_skips
+WHERE skipped_at >= $1 AND ($2 = '' OR source = $2)
+GROUP BY source, reason
+ORDER BY skips DESC
@@ -0,0 +1,8 @@
+-- Failed syncs per source since a date with the latest error
+-- :since date
+SELECT api_name AS source, COUNT(*) AS failures, MAX(sync_time) AS latest,
+	(ARRAY_AGG(error_message ORDER BY sync_time DESC))[1] AS latest_error
+FROM job_sync_logs
+WHERE status = 'Failed' AND sync_time >= $1
+GROUP BY api_name
+ORDER BY failures DESC
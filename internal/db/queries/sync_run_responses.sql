@@ -0,0 +1,6 @@
+-- Raw API responses stored by a sync run, in the order they were fetched
+-- :run text
+SELECT source, endpoint, fetched_at, LENGTH(body) AS bytes, body
+FROM api_responses
+WHERE sync_run_id = $1
+ORDER BY id
@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// queryTemplateFS holds the read-only SQL templates of the admin query
+// console. Each file starts with comment lines: a description, then one
+// "-- :name type" line per positional parameter ($1, $2, ...), optionally
+// with "= default". Types are text, int, bool and date (YYYY-MM-DD).
+//
+//go:embed queries/*.sql
+var queryTemplateFS embed.FS
+
+// ErrQueryTemplateNotFound is returned for an unknown template name
+var ErrQueryTemplateNotFound = errors.New("query template not found")
+
+// ErrQueryTimeout is returned when a template runs longer than its timeout
+var ErrQueryTimeout = errors.New("query timed out")
+
+// QueryParamError reports a missing or malformed template parameter
+type QueryParamError struct {
+	Param string
+	Err   error
+}
+
+func (e *QueryParamError) Error() string {
+	return fmt.Sprintf("parameter %s: %v", e.Param, e.Err)
+}
+
+// QueryParam is a parameter of a query template
+type QueryParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Default is used when the parameter is not given; nil makes it required
+	Default *string `json:"default,omitempty"`
+}
+
+// QueryTemplate is a pre-approved SQL query admins may run
+type QueryTemplate struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Params      []QueryParam `json:"params"`
+	SQL         string       `json:"-"`
+}
+
+// QueryResult is the output of a query template
+type QueryResult struct {
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+	// Truncated is set when the query returned more than the row limit
+	Truncated bool `json:"truncated"`
+}
+
+// ListQueryTemplates returns the query templates sorted by name
+func ListQueryTemplates() ([]QueryTemplate, error) {
+	files, err := queryTemplateFS.ReadDir("queries")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]QueryTemplate, 0, len(files))
+	for _, file := range files {
+		tmpl, err := GetQueryTemplate(strings.TrimSuffix(file.Name(), ".sql"))
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tmpl)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// GetQueryTemplate loads and parses the template called name
+func GetQueryTemplate(name string) (*QueryTemplate, error) {
+	// Names map straight to file names, so anything but a plain name is unknown
+	if name == "" || name != path.Base(name) || strings.ContainsAny(name, `.\`) {
+		return nil, ErrQueryTemplateNotFound
+	}
+	data, err := queryTemplateFS.ReadFile("queries/" + name + ".sql")
+	if err != nil {
+		return nil, ErrQueryTemplateNotFound
+	}
+	return parseQueryTemplate(name, string(data))
+}
+
+// parseQueryTemplate reads the description and parameters from the leading
+// comment lines of a template
+func parseQueryTemplate(name, text string) (*QueryTemplate, error) {
+	tmpl := &QueryTemplate{Name: name, SQL: strings.TrimSpace(text)}
+
+	var description []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		if !strings.HasPrefix(line, ":") {
+			description = append(description, line)
+			continue
+		}
+
+		decl, def, hasDefault := strings.Cut(strings.TrimPrefix(line, ":"), "=")
+		fields := strings.Fields(decl)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("query template %s: invalid parameter %q", name, line)
+		}
+		param := QueryParam{Name: fields[0], Type: fields[1]}
+		switch param.Type {
+		case "text", "int", "bool", "date":
+		default:
+			return nil, fmt.Errorf("query template %s: unknown type %q of %s", name, param.Type, param.Name)
+		}
+		if hasDefault {
+			value := strings.Trim(strings.TrimSpace(def), "'")
+			param.Default = &value
+		}
+		tmpl.Params = append(tmpl.Params, param)
+	}
+	tmpl.Description = strings.Join(description, " ")
+	return tmpl, nil
+}
+
+// Args converts the raw parameter values (e.g. from a query string) into the
+// positional arguments of the template
+func (t *QueryTemplate) Args(values map[string]string) ([]interface{}, error) {
+	args := make([]interface{}, 0, len(t.Params))
+	for _, param := range t.Params {
+		raw, ok := values[param.Name]
+		if !ok || raw == "" {
+			if param.Default == nil {
+				return nil, &QueryParamError{Param: param.Name, Err: errors.New("required")}
+			}
+			raw = *param.Default
+		}
+
+		var arg interface{}
+		var err error
+		switch param.Type {
+		case "int":
+			arg, err = strconv.Atoi(raw)
+		case "bool":
+			arg, err = strconv.ParseBool(raw)
+		case "date":
+			arg, err = time.Parse("2006-01-02", raw)
+		default:
+			arg = raw
+		}
+		if err != nil {
+			return nil, &QueryParamError{Param: param.Name, Err: fmt.Errorf("invalid %s %q", param.Type, raw)}
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// RunQueryTemplate runs tmpl with args in a read-only transaction, aborting
+// it with ErrQueryTimeout after timeout and returning at most maxRows rows
+func RunQueryTemplate(ctx context.Context, db *sql.DB, tmpl *QueryTemplate, args []interface{}, maxRows int, timeout time.Duration) (*QueryResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := runQueryTemplate(ctx, db, tmpl, args, maxRows, timeout)
+	var pgErr *pgconn.PgError
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &pgErr) && pgErr.Code == "57014") {
+		return nil, ErrQueryTimeout
+	}
+	return result, err
+}
+
+// runQueryTemplate is RunQueryTemplate without the timeout error mapping
+func runQueryTemplate(ctx context.Context, db *sql.DB, tmpl *QueryTemplate, args []interface{}, maxRows int, timeout time.Duration) (*QueryResult, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Also stop the query server side, the context only abandons it
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, tmpl.SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &QueryResult{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			// Text columns come back as bytes
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
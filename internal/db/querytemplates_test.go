@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryTemplates(t *testing.T) {
+	// Every template in the repo must parse
+	templates, err := ListQueryTemplates()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, templates)
+
+	tmpl, err := GetQueryTemplate("jobs_by_company")
+	assert.NoError(t, err)
+	assert.Equal(t, "Jobs posted per company since a date, most first", tmpl.Description)
+	assert.Len(t, tmpl.Params, 2)
+
+	args, err := tmpl.Args(map[string]string{"since": "2025-03-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), 50}, args)
+
+	_, err = tmpl.Args(map[string]string{})
+	assert.EqualError(t, err, "parameter since: required")
+	_, err = tmpl.Args(map[string]string{"since": "2025-03-01", "limit": "many"})
+	assert.EqualError(t, err, `parameter limit: invalid int "many"`)
+
+	for _, name := range []string{"missing", "../db", "queries/jobs_by_company", ""} {
+		_, err = GetQueryTemplate(name)
+		assert.ErrorIs(t, err, ErrQueryTemplateNotFound, name)
+	}
+}
+
+func TestRunQueryTemplate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	tmpl, err := GetQueryTemplate("jobs_by_source")
+	assert.NoError(t, err)
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^SET LOCAL statement_timeout = 2000$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM jobs WHERE created_at >= \\$1").
+		WithArgs(since).
+		WillReturnRows(sqlmock.NewRows([]string{"source", "day", "jobs"}).
+			AddRow([]byte("jsearch"), since, 4).
+			AddRow([]byte("indeed"), since, 2).
+			AddRow([]byte("linkedin"), since, 1))
+	mock.ExpectRollback()
+
+	result, err := RunQueryTemplate(context.Background(), db, tmpl, []interface{}{since}, 2, 2*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"source", "day", "jobs"}, result.Columns)
+	assert.Equal(t, [][]interface{}{{"jsearch", since, int64(4)}, {"indeed", since, int64(2)}}, result.Rows)
+	assert.True(t, result.Truncated)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,337 @@
+package db
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisKeyPrefix namespaces the keys of the server in a shared Redis
+const redisKeyPrefix = "go9jajobs:"
+
+// redisTimeout bounds a Redis command, dial included; a slow cache is
+// skipped rather than slowing requests down
+const redisTimeout = 500 * time.Millisecond
+
+// redisMaxIdle is how many connections are kept open between commands
+const redisMaxIdle = 8
+
+// RedisCache is a CacheStore in Redis, shared by every replica of the
+// server. Payloads are stored under a generation number kept in Redis too,
+// so the replica whose sync saved jobs invalidates the cache of all of
+// them. Entries expire after the TTL; Redis' maxmemory policy bounds their
+// number.
+type RedisCache struct {
+	addr     string
+	username string
+	password string
+	database int
+	useTLS   bool
+	ttl      time.Duration
+
+	mu   sync.Mutex
+	idle []*redisConn
+	// generation is the last one passed to SetGeneration
+	generation int64
+}
+
+// NewRedisCache creates a cache in the Redis server at rawURL
+// (redis://[user:password@]host:port[/db], rediss:// for TLS) keeping
+// payloads for ttl. No connection is made until the first command.
+func NewRedisCache(rawURL string, ttl time.Duration) (*RedisCache, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	c := &RedisCache{
+		addr:   u.Host,
+		useTLS: u.Scheme == "rediss",
+		ttl:    ttl,
+	}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if database := strings.Trim(u.Path, "/"); database != "" {
+		if c.database, err = strconv.Atoi(database); err != nil {
+			return nil, fmt.Errorf("invalid database %q", database)
+		}
+	}
+	return c, nil
+}
+
+// generationKey holds the generation of the cached payloads
+const generationKey = redisKeyPrefix + "generation"
+
+// Get implements CacheStore. Redis errors are logged and count as misses.
+func (c *RedisCache) Get(key string) ([]byte, bool) {
+	generation, err := c.sharedGeneration()
+	if err != nil {
+		log.Printf("Error reading response cache: %v", err)
+		return nil, false
+	}
+	reply, err := c.do("GET", payloadKey(generation, key))
+	if err != nil {
+		log.Printf("Error reading response cache: %v", err)
+		return nil, false
+	}
+	value, ok := reply.([]byte)
+	return value, ok
+}
+
+// Set implements CacheStore. Redis errors are logged.
+func (c *RedisCache) Set(key string, value []byte) {
+	generation, err := c.sharedGeneration()
+	if err == nil {
+		_, err = c.do("SET", payloadKey(generation, key), string(value), "PX", strconv.FormatInt(c.ttl.Milliseconds(), 10))
+	}
+	if err != nil {
+		log.Printf("Error writing response cache: %v", err)
+	}
+}
+
+// SetGeneration implements CacheStore. The generation of this process (e.g.
+// services.JobSaves) moving bumps the shared one.
+func (c *RedisCache) SetGeneration(generation int64) {
+	c.mu.Lock()
+	changed := generation != c.generation
+	c.generation = generation
+	c.mu.Unlock()
+
+	if changed {
+		c.Invalidate()
+	}
+}
+
+// Invalidate implements CacheStore, moving every replica to a new generation;
+// the payloads of the previous one expire unused
+func (c *RedisCache) Invalidate() {
+	if _, err := c.do("INCR", generationKey); err != nil {
+		log.Printf("Error invalidating response cache: %v", err)
+	}
+}
+
+// Incr implements CacheStore
+func (c *RedisCache) Incr(key string, window time.Duration) (int64, time.Duration, error) {
+	key = redisKeyPrefix + "counter:" + key
+	ms := strconv.FormatInt(window.Milliseconds(), 10)
+
+	reply, err := c.do("INCR", key)
+	if err != nil {
+		return 0, 0, err
+	}
+	count, _ := reply.(int64)
+	if count == 1 {
+		if _, err := c.do("PEXPIRE", key, ms); err != nil {
+			return 0, 0, err
+		}
+		return count, window, nil
+	}
+
+	reply, err = c.do("PTTL", key)
+	if err != nil {
+		return 0, 0, err
+	}
+	left, _ := reply.(int64)
+	if left < 0 {
+		// The window was never set, e.g. PEXPIRE failed after INCR
+		if _, err := c.do("PEXPIRE", key, ms); err != nil {
+			return 0, 0, err
+		}
+		left = window.Milliseconds()
+	}
+	return count, time.Duration(left) * time.Millisecond, nil
+}
+
+// Close closes the idle connections
+func (c *RedisCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, conn := range c.idle {
+		conn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+// sharedGeneration returns the generation of the payloads in Redis
+func (c *RedisCache) sharedGeneration() (int64, error) {
+	reply, err := c.do("GET", generationKey)
+	if err != nil || reply == nil {
+		return 0, err
+	}
+	value, _ := reply.([]byte)
+	return strconv.ParseInt(string(value), 10, 64)
+}
+
+// payloadKey is the Redis key of a payload in a generation
+func payloadKey(generation int64, key string) string {
+	return redisKeyPrefix + "response:" + strconv.FormatInt(generation, 10) + ":" + key
+}
+
+// do runs a command on an idle or new connection and returns its reply:
+// nil, string, int64, []byte or []interface{}
+func (c *RedisCache) do(args ...string) (interface{}, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	reply, err := conn.do(args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection may hold a partial reply
+		conn.Close()
+		return nil, err
+	}
+	c.release(conn)
+	return reply, err
+}
+
+// conn returns an idle connection, or dials a new one
+func (c *RedisCache) conn() (*redisConn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+	return c.dial()
+}
+
+// release returns a healthy connection to the idle ones
+func (c *RedisCache) release(conn *redisConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.idle) >= redisMaxIdle {
+		conn.Close()
+		return
+	}
+	c.idle = append(c.idle, conn)
+}
+
+// dial connects to Redis, authenticates and selects the database
+func (c *RedisCache) dial() (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var netConn net.Conn
+	var err error
+	if c.useTLS {
+		host, _, _ := net.SplitHostPort(c.addr)
+		netConn, err = tls.DialWithDialer(dialer, "tcp", c.addr, &tls.Config{ServerName: host})
+	} else {
+		netConn, err = dialer.Dial("tcp", c.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &redisConn{Conn: netConn, r: bufio.NewReader(netConn)}
+	conn.SetDeadline(time.Now().Add(redisTimeout))
+	var setup [][]string
+	if c.password != "" {
+		if c.username != "" {
+			setup = append(setup, []string{"AUTH", c.username, c.password})
+		} else {
+			setup = append(setup, []string{"AUTH", c.password})
+		}
+	}
+	if c.database != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.database)})
+	}
+	for _, args := range setup {
+		if _, err := conn.do(args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis %s: %w", args[0], err)
+		}
+	}
+	return conn, nil
+}
+
+// redisError is an error reply of Redis
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn is a connection speaking RESP, the Redis protocol
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// do sends a command and reads its reply
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// read reads a reply
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("invalid redis reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$', '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if kind == '$' {
+			value := make([]byte, n+2)
+			if _, err := io.ReadFull(c.r, value); err != nil {
+				return nil, err
+			}
+			return value[:n], nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("invalid redis reply %q", line)
+}
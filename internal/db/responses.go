@@ -0,0 +1,262 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+)
+
+// APIResponse is a response body received from the API of a job source,
+// stored once per sync while each job keeps its own item as raw_data
+type APIResponse struct {
+	Source    string    `json:"source"`
+	Endpoint  string    `json:"endpoint"`
+	Body      string    `json:"body"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// JobRawData is the raw item a job was mapped from
+type JobRawData struct {
+	ID         string     `json:"id"`
+	JobID      string     `json:"job_id"`
+	Source     string     `json:"source"`
+	DateGotten *time.Time `json:"date_gotten,omitempty"`
+	RawData    string     `json:"-"`
+}
+
+// GetJobRawData returns the raw item of a job, or sql.ErrNoRows
+func GetJobRawData(ctx context.Context, db *sql.DB, id string) (*JobRawData, error) {
+	var raw JobRawData
+	var dateGotten sql.NullTime
+	err := db.QueryRowContext(ctx,
+		`SELECT id, job_id, source, date_gotten, COALESCE(raw_data, '') FROM jobs WHERE id = $1`, id,
+	).Scan(&raw.ID, &raw.JobID, &raw.Source, &dateGotten, &raw.RawData)
+	if err != nil {
+		return nil, err
+	}
+	if dateGotten.Valid {
+		raw.DateGotten = &dateGotten.Time
+	}
+	return &raw, nil
+}
+
+// SaveAPIResponses stores the responses of a sync, keyed by the sync run of
+// ctx when it has one (see WithSyncRun)
+func SaveAPIResponses(ctx context.Context, db *sql.DB, responses []APIResponse) error {
+	if len(responses) == 0 {
+		return nil
+	}
+	var runID sql.NullString
+	if id := syncRunFrom(ctx); id != "" {
+		runID = sql.NullString{String: id, Valid: true}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO api_responses (sync_run_id, source, endpoint, body, fetched_at)
+		VALUES ($1, $2, $3, $4, $5)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, response := range responses {
+		if _, err := stmt.ExecContext(ctx, runID, response.Source, response.Endpoint, response.Body, response.FetchedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PruneAPIResponses deletes the responses fetched before a time and returns
+// how many were deleted
+func PruneAPIResponses(ctx context.Context, db *sql.DB, before time.Time) (int64, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM api_responses WHERE fetched_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// shrinkBatchSize is how many jobs ShrinkRawData reads at once
+const shrinkBatchSize = 500
+
+// rawPayloadFilter matches the raw_data of jobs stored with the whole
+// response of their API: a JSON array of jobs, an object holding one under
+// data or jobs, or an RSS feed
+const rawPayloadFilter = `(raw_data LIKE '[%' OR raw_data LIKE '{%"data"%' OR raw_data LIKE '{%"jobs"%'
+	OR raw_data LIKE '<?xml%' OR raw_data LIKE '<rss%')`
+
+// ShrinkRawData migrates the jobs stored before raw responses were kept
+// apart: each whole response found in raw_data moves to api_responses, once
+// however many jobs it was copied to, and the jobs keep their own item of
+// it, or no raw data when it cannot be found (e.g. in RSS feeds). Returns how
+// many jobs were shrunk. Batches commit on their own, so an interrupted run
+// resumes on the next start; a response split across batches of separate
+// runs may then be stored twice.
+func ShrinkRawData(ctx context.Context, db *sql.DB) (int, error) {
+	stored := make(map[[sha256.Size]byte]bool)
+	shrunk := 0
+	after := ""
+	for {
+		n, last, err := shrinkRawDataBatch(ctx, db, after, stored)
+		shrunk += n
+		if err != nil || last == "" {
+			return shrunk, err
+		}
+		after = last
+	}
+}
+
+// shrinkRawDataBatch shrinks the next batch of jobs with an ID after after,
+// returning the ID of its last job, empty after the last batch
+func shrinkRawDataBatch(ctx context.Context, db *sql.DB, after string, stored map[[sha256.Size]byte]bool) (shrunk int, last string, err error) {
+	type payloadJob struct {
+		id, jobID, source, raw string
+		dateGotten             sql.NullTime
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, job_id, source, raw_data, date_gotten FROM jobs
+		WHERE id > $1 AND `+rawPayloadFilter+`
+		ORDER BY id
+		LIMIT $2`,
+		after, shrinkBatchSize,
+	)
+	if err != nil {
+		return 0, "", err
+	}
+	var jobs []payloadJob
+	for rows.Next() {
+		var job payloadJob
+		if err := rows.Scan(&job.id, &job.jobID, &job.source, &job.raw, &job.dateGotten); err != nil {
+			rows.Close()
+			return 0, "", err
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, "", err
+	}
+	if len(jobs) == 0 {
+		return 0, "", nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	defer tx.Rollback()
+
+	for _, job := range jobs {
+		fragment, isPayload := rawFragmentOf(job.raw, job.jobID)
+		if !isPayload {
+			continue
+		}
+
+		if hash := sha256.Sum256([]byte(job.raw)); !stored[hash] {
+			fetchedAt := time.Now()
+			if job.dateGotten.Valid {
+				fetchedAt = job.dateGotten.Time
+			}
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO api_responses (source, endpoint, body, fetched_at) VALUES ($1, '', $2, $3)`,
+				job.source, job.raw, fetchedAt,
+			); err != nil {
+				return 0, "", err
+			}
+			stored[hash] = true
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE jobs SET raw_data = $2, updated_at = NOW() WHERE id = $1`,
+			job.id, sql.NullString{String: fragment, Valid: fragment != ""},
+		); err != nil {
+			return 0, "", err
+		}
+		shrunk++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, "", err
+	}
+	return shrunk, jobs[len(jobs)-1].id, nil
+}
+
+// rawFragmentOf returns the item of job jobID in raw, the whole response of
+// an API; isPayload is false when raw is not one. The item is found by its
+// job_id or id, and is empty when missing or raw is not JSON.
+func rawFragmentOf(raw, jobID string) (fragment string, isPayload bool) {
+	if strings.HasPrefix(raw, "<") {
+		return "", true
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		var object map[string]json.RawMessage
+		if json.Unmarshal([]byte(raw), &object) != nil {
+			return "", false
+		}
+		list, ok := object["data"]
+		if !ok {
+			list, ok = object["jobs"]
+		}
+		if !ok || json.Unmarshal(list, &items) != nil {
+			return "", false
+		}
+	}
+
+	for _, item := range items {
+		// IDs are strings or numbers, matched by their text either way
+		var ids struct {
+			JobID json.RawMessage `json:"job_id"`
+			ID    json.RawMessage `json:"id"`
+		}
+		if json.Unmarshal(item, &ids) != nil {
+			continue
+		}
+		for _, id := range []json.RawMessage{ids.JobID, ids.ID} {
+			if len(id) > 0 && strings.Trim(string(id), `"`) == jobID {
+				return string(item), true
+			}
+		}
+	}
+	return "", true
+}
+
+// rawDataMigration names the run of ShrinkRawData in data_migrations
+const rawDataMigration = "shrink_raw_data"
+
+// migrateRawData runs ShrinkRawData on the first start after upgrading,
+// logging what it did, and records it in data_migrations: jobs saved since
+// only hold their own item, so later starts skip scanning every job
+func migrateRawData(db *sql.DB) error {
+	ctx := context.Background()
+	var done bool
+	err := db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM data_migrations WHERE name = $1)`, rawDataMigration,
+	).Scan(&done)
+	if err != nil || done {
+		return err
+	}
+
+	shrunk, err := ShrinkRawData(ctx, db)
+	if shrunk > 0 {
+		log.Printf("Moved the API responses copied to %d jobs into api_responses", shrunk)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO data_migrations (name) VALUES ($1) ON CONFLICT (name) DO NOTHING`, rawDataMigration)
+	return err
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawFragmentOf(t *testing.T) {
+	jsearch := `{"status": "OK", "data": [{"job_id": "a1", "job_title": "Go Dev"}, {"job_id": "b2"}]}`
+	fragment, ok := rawFragmentOf(jsearch, "b2")
+	assert.True(t, ok)
+	assert.Equal(t, `{"job_id": "b2"}`, fragment)
+
+	// Numeric IDs, under jobs
+	fragment, ok = rawFragmentOf(`{"jobs": [{"id": 4012001, "title": "Go Dev"}]}`, "4012001")
+	assert.True(t, ok)
+	assert.Equal(t, `{"id": 4012001, "title": "Go Dev"}`, fragment)
+
+	// Arrays, the item missing
+	fragment, ok = rawFragmentOf(`[{"id": "x"}]`, "y")
+	assert.True(t, ok)
+	assert.Empty(t, fragment)
+
+	// RSS feeds move without a fragment
+	fragment, ok = rawFragmentOf(`<?xml version="1.0"?><rss></rss>`, "y")
+	assert.True(t, ok)
+	assert.Empty(t, fragment)
+
+	// Fragments and other raw data are left alone
+	_, ok = rawFragmentOf(`{"job_id": "a1", "job_description": "Move \"data\" around"}`, "a1")
+	assert.False(t, ok)
+	_, ok = rawFragmentOf(`{"data": "not a list"}`, "a1")
+	assert.False(t, ok)
+}
+
+func TestShrinkRawData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	gotten := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	payload := `{"data": [{"job_id": "a1"}, {"job_id": "b2"}]}`
+	columns := []string{"id", "job_id", "source", "raw_data", "date_gotten"}
+	mock.ExpectQuery("^SELECT id, job_id, source, raw_data, date_gotten FROM jobs WHERE id > \\$1 AND (.+) ORDER BY id LIMIT \\$2").
+		WithArgs("", shrinkBatchSize).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("id-1", "a1", "jsearch", payload, gotten).
+			AddRow("id-2", "b2", "jsearch", payload, gotten).
+			AddRow("id-3", "c3", "jsearch", `{"job_id": "c3", "note": "\"data\""}`, gotten))
+	mock.ExpectBegin()
+	// The payload is stored once for both jobs
+	mock.ExpectExec("^INSERT INTO api_responses").
+		WithArgs("jsearch", payload, gotten).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("^UPDATE jobs SET raw_data = \\$2, updated_at = NOW\\(\\) WHERE id = \\$1").
+		WithArgs("id-1", `{"job_id": "a1"}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE jobs SET raw_data").
+		WithArgs("id-2", `{"job_id": "b2"}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("^SELECT id, job_id, source, raw_data, date_gotten FROM jobs").
+		WithArgs("id-3", shrinkBatchSize).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	shrunk, err := ShrinkRawData(context.Background(), db)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, shrunk)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrateRawData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// The first start shrinks the jobs and records it
+	mock.ExpectQuery("^SELECT EXISTS \\(SELECT 1 FROM data_migrations WHERE name = \\$1\\)$").
+		WithArgs(rawDataMigration).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("^SELECT id, job_id, source, raw_data, date_gotten FROM jobs").
+		WithArgs("", shrinkBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "source", "raw_data", "date_gotten"}))
+	mock.ExpectExec("^INSERT INTO data_migrations \\(name\\) VALUES \\(\\$1\\)").
+		WithArgs(rawDataMigration).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, migrateRawData(db))
+
+	// Later starts skip it
+	mock.ExpectQuery("^SELECT EXISTS").
+		WithArgs(rawDataMigration).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	assert.NoError(t, migrateRawData(db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveAPIResponses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	fetched := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO api_responses")
+	mock.ExpectExec("INSERT INTO api_responses").
+		WithArgs("run-1", "jsearch", "jsearch.p.rapidapi.com/search", `{"data": []}`, fetched).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ctx := WithSyncRun(context.Background(), "run-1")
+	err = SaveAPIResponses(ctx, db, []APIResponse{
+		{Source: "jsearch", Endpoint: "jsearch.p.rapidapi.com/search", Body: `{"data": []}`, FetchedAt: fetched},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
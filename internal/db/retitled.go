@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/models"
+)
+
+const (
+	// retitledWindow is how far back re-posted roles are matched
+	retitledWindow = 45 * 24 * time.Hour
+	// retitledThreshold is the minimum title similarity for a re-post
+	retitledThreshold = 0.8
+)
+
+// FindRetitledJob returns the ID and title similarity of another job of the same
+// company seen within the last 45 days whose title is a close variant of the
+// job's title (e.g. "Backend Engineer (Go)" and "Go Backend Engineer"), or ""
+// if there is none. It queries through tx, the transaction saving the job.
+func FindRetitledJob(ctx context.Context, tx *sql.Tx, job models.Job) (string, float64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, title
+		FROM jobs
+		WHERE company_key = $1
+			AND COALESCE(last_seen_at, created_at) > $2
+			AND id <> $3`,
+		analyzer.NormalizeCompany(job.Company), time.Now().Add(-retitledWindow), job.ID,
+	)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+
+	var (
+		bestID    string
+		bestScore float64
+	)
+	for rows.Next() {
+		var id, title string
+		if err := rows.Scan(&id, &title); err != nil {
+			return "", 0, err
+		}
+		if score := analyzer.TitleSimilarity(job.Title, title); score >= retitledThreshold && score > bestScore {
+			bestID, bestScore = id, score
+		}
+	}
+	return bestID, bestScore, rows.Err()
+}
+
+// MergeRetitledJob folds a re-posted job into the existing row: fresher
+// details replace stored ones, the later expiry wins and last_seen_at is bumped
+func MergeRetitledJob(ctx context.Context, tx *sql.Tx, existingID string, job models.Job) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE jobs SET
+			description = COALESCE(NULLIF($2, ''), description),
+			description_html = CASE WHEN $2 <> '' THEN NULLIF($7, '') ELSE description_html END,
+			language = CASE WHEN $2 <> '' THEN NULLIF($8, '') ELSE language END,
+			description_translation = CASE WHEN $2 <> '' AND $2 IS DISTINCT FROM description THEN NULL ELSE description_translation END,
+			translated_by = CASE WHEN $2 <> '' AND $2 IS DISTINCT FROM description THEN NULL ELSE translated_by END,
+			translation_checked_at = CASE WHEN $2 <> '' AND $2 IS DISTINCT FROM description THEN NULL ELSE translation_checked_at END,
+			url = COALESCE(NULLIF($3, ''), url),
+			salary = COALESCE(NULLIF($4, ''), salary),
+			exp_date = CASE WHEN admin_edits ? 'exp_date' THEN exp_date ELSE GREATEST(exp_date, $5) END,
+			provenance = provenance || $6::jsonb,
+			language_flags = CASE WHEN $2 <> '' AND $2 IS DISTINCT FROM description THEN NULL ELSE language_flags END,
+			salary_flag = CASE WHEN $4 <> '' AND $4 IS DISTINCT FROM salary THEN NULL ELSE salary_flag END,
+			last_seen_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1`,
+		existingID, job.Description, job.URL, job.Salary, nullTime(job.ExpDate), mergedProvenance(job).String(),
+		job.DescriptionHTML, job.Language,
+	)
+	return err
+}
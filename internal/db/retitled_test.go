@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRetitledJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	job := models.Job{ID: "job-4", Title: "Go Backend Engineer", Company: "Paystack Ltd."}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT id, title FROM jobs WHERE company_key = \\$1 AND COALESCE\\(last_seen_at, created_at\\) > \\$2 AND id <> \\$3$").
+		WithArgs("paystack", sqlmock.AnyArg(), "job-4").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).
+			AddRow("job-1", "Senior Go Backend Engineer").
+			AddRow("job-2", "Backend Engineer (Go)").
+			AddRow("job-3", "Frontend Engineer"))
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	id, score, err := FindRetitledJob(context.Background(), tx, job)
+	assert.NoError(t, err)
+	assert.Equal(t, "job-2", id)
+	assert.Equal(t, 1.0, score)
+
+	// Different seniority is a different role
+	mock.ExpectQuery("^SELECT id, title FROM jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).
+			AddRow("job-1", "Senior Go Backend Engineer"))
+	mock.ExpectRollback()
+
+	id, _, err = FindRetitledJob(context.Background(), tx, job)
+	assert.NoError(t, err)
+	assert.Empty(t, id)
+	assert.NoError(t, tx.Rollback())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMergeRetitledJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expires := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec("^UPDATE jobs SET (.+) last_seen_at = NOW\\(\\), updated_at = NOW\\(\\) WHERE id = \\$1$").
+		WithArgs("job-2", "New description", "https://paystack.com/jobs/2", "", expires,
+			`{"description":"indeed","url":"indeed"}`, "<p>New description</p>", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	err = MergeRetitledJob(context.Background(), tx, "job-2", models.Job{
+		Source:          "indeed",
+		Description:     "New description",
+		DescriptionHTML: "<p>New description</p>",
+		URL:             "https://paystack.com/jobs/2",
+		ExpDate:         expires,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
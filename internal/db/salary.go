@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// SalaryText is the salary of an open job checked by the salary benchmark
+type SalaryText struct {
+	ID        string
+	Salary    string
+	Seniority string
+	// Flagged is set when the job already has a salary flag
+	Flagged bool
+}
+
+// SalaryFlag marks an offer far outside the typical range for its seniority,
+// likely a parsing error (wrong currency or period) awaiting verification
+type SalaryFlag struct {
+	// Kind is "low" or "high"
+	Kind     string  `json:"kind"`
+	Monthly  float64 `json:"monthly"`
+	Median   float64 `json:"median"`
+	Currency string  `json:"currency"`
+	// Seniority is the level benchmarked against, empty for all levels
+	Seniority string `json:"seniority,omitempty"`
+}
+
+// SalaryFlaggedJob is an open job whose salary is hidden pending verification
+type SalaryFlaggedJob struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Company   string     `json:"company"`
+	URL       string     `json:"url,omitempty"`
+	Source    string     `json:"source"`
+	Salary    string     `json:"salary"`
+	Seniority string     `json:"seniority,omitempty"`
+	Flag      SalaryFlag `json:"salary_flag"`
+}
+
+// FindSalaries returns the open jobs that state a salary
+func FindSalaries(ctx context.Context, db *sql.DB) ([]SalaryText, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, salary, COALESCE(seniority, ''), salary_flag IS NOT NULL
+		FROM jobs
+		WHERE COALESCE(salary, '') <> ''
+			AND (exp_date IS NULL OR exp_date > NOW())`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []SalaryText
+	for rows.Next() {
+		var job SalaryText
+		if err := rows.Scan(&job.ID, &job.Salary, &job.Seniority, &job.Flagged); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// SetSalaryFlag stores the salary flag of a job, a nil flag clearing it.
+// A changed flag bumps updated_at since it hides or shows the listed salary.
+func SetSalaryFlag(ctx context.Context, db *sql.DB, id string, flag *SalaryFlag) error {
+	var data sql.NullString
+	if flag != nil {
+		encoded, err := json.Marshal(flag)
+		if err != nil {
+			return err
+		}
+		data = sql.NullString{String: string(encoded), Valid: true}
+	}
+	_, err := db.ExecContext(ctx, `
+		UPDATE jobs SET salary_flag = $2, updated_at = NOW()
+		WHERE id = $1 AND salary_flag IS DISTINCT FROM $2`, id, data)
+	return err
+}
+
+// FindSalaryFlaggedJobs returns open jobs with a salary flag, newest first
+func FindSalaryFlaggedJobs(ctx context.Context, db *sql.DB, limit int) ([]SalaryFlaggedJob, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, title, company, COALESCE(url, ''), source, salary, COALESCE(seniority, ''), salary_flag
+		FROM jobs
+		WHERE salary_flag IS NOT NULL
+			AND (exp_date IS NULL OR exp_date > NOW())
+		ORDER BY posted_at DESC
+		LIMIT $1`, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []SalaryFlaggedJob{}
+	for rows.Next() {
+		var (
+			job  SalaryFlaggedJob
+			flag []byte
+		)
+		if err := rows.Scan(&job.ID, &job.Title, &job.Company, &job.URL, &job.Source, &job.Salary, &job.Seniority, &flag); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(flag, &job.Flag); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
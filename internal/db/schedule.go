@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// JobScheduleInfo holds the persisted schedule of a single sync source
+type JobScheduleInfo struct {
+	APIName         string     `json:"api_name"`
+	IntervalMinutes int        `json:"interval_minutes"`
+	LastRunTime     *time.Time `json:"last_run_time"`
+	NextRunTime     *time.Time `json:"next_run_time"`
+	// LastYield is the number of new jobs of the last scheduled run and
+	// YieldAvg its moving average, both nil until a run succeeded
+	LastYield *int     `json:"last_yield"`
+	YieldAvg  *float64 `json:"yield_avg"`
+	// LastAdjustment explains the last interval change made from the yield
+	LastAdjustment string     `json:"last_adjustment,omitempty"`
+	AdjustedAt     *time.Time `json:"adjusted_at"`
+}
+
+// EnsureScheduleInfo creates the schedule row of a source with the default
+// interval if it does not exist yet, leaving existing intervals untouched
+func EnsureScheduleInfo(db *sql.DB, apiName string, defaultInterval time.Duration) error {
+	_, err := db.Exec(`
+		INSERT INTO job_schedule_info (api_name, interval_minutes)
+		VALUES ($1, $2)
+		ON CONFLICT (api_name) DO NOTHING`,
+		apiName, int(defaultInterval/time.Minute),
+	)
+	return err
+}
+
+// GetScheduleInfo returns the persisted schedule of every source
+func GetScheduleInfo(ctx context.Context, db *sql.DB) ([]JobScheduleInfo, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT api_name, interval_minutes, last_run_time, next_run_time,
+			last_yield, yield_avg, COALESCE(last_adjustment, ''), adjusted_at
+		FROM job_schedule_info
+		ORDER BY api_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var infos []JobScheduleInfo
+	for rows.Next() {
+		var (
+			info       JobScheduleInfo
+			lastRun    sql.NullTime
+			nextRun    sql.NullTime
+			lastYield  sql.NullInt64
+			yieldAvg   sql.NullFloat64
+			adjustedAt sql.NullTime
+		)
+		if err := rows.Scan(&info.APIName, &info.IntervalMinutes, &lastRun, &nextRun,
+			&lastYield, &yieldAvg, &info.LastAdjustment, &adjustedAt); err != nil {
+			return nil, err
+		}
+		if lastRun.Valid {
+			info.LastRunTime = &lastRun.Time
+		}
+		if nextRun.Valid {
+			info.NextRunTime = &nextRun.Time
+		}
+		if lastYield.Valid {
+			yield := int(lastYield.Int64)
+			info.LastYield = &yield
+		}
+		if yieldAvg.Valid {
+			info.YieldAvg = &yieldAvg.Float64
+		}
+		if adjustedAt.Valid {
+			info.AdjustedAt = &adjustedAt.Time
+		}
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
+
+// UpdateScheduleRun records when a source last ran and when it runs next
+func UpdateScheduleRun(db *sql.DB, apiName string, lastRun, nextRun time.Time) error {
+	_, err := db.Exec(`
+		UPDATE job_schedule_info
+		SET last_run_time = $2, next_run_time = $3
+		WHERE api_name = $1`,
+		apiName, lastRun, nextRun,
+	)
+	return err
+}
+
+// GetLastSuccessfulSync returns when the last successful sync of a source
+// started, the zero time when none is recorded
+func GetLastSuccessfulSync(ctx context.Context, db *sql.DB, apiName string) (time.Time, error) {
+	var lastSuccess sql.NullTime
+	err := db.QueryRowContext(ctx,
+		`SELECT last_success_time FROM job_schedule_info WHERE api_name = $1`,
+		apiName,
+	).Scan(&lastSuccess)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return lastSuccess.Time, err
+}
+
+// RecordSuccessfulSync records when the last successful sync of a source
+// started, creating its schedule row with defaultInterval if needed
+func RecordSuccessfulSync(db *sql.DB, apiName string, started time.Time, defaultInterval time.Duration) error {
+	_, err := db.Exec(`
+		INSERT INTO job_schedule_info (api_name, interval_minutes, last_success_time)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (api_name) DO UPDATE SET last_success_time = EXCLUDED.last_success_time`,
+		apiName, int(defaultInterval/time.Minute), started,
+	)
+	return err
+}
+
+// UpdateScheduleYield records the yield of the last run of a source and its
+// moving average. A non-empty adjustment also stores the new interval and why
+// it was chosen.
+func UpdateScheduleYield(db *sql.DB, apiName string, yield int, yieldAvg float64, interval time.Duration, adjustment string) error {
+	_, err := db.Exec(`
+		UPDATE job_schedule_info
+		SET last_yield = $2, yield_avg = $3,
+			interval_minutes = CASE WHEN $5 = '' THEN interval_minutes ELSE $4 END,
+			last_adjustment = CASE WHEN $5 = '' THEN last_adjustment ELSE $5 END,
+			adjusted_at = CASE WHEN $5 = '' THEN adjusted_at ELSE NOW() END
+		WHERE api_name = $1`,
+		apiName, yield, yieldAvg, int(interval/time.Minute), adjustment,
+	)
+	return err
+}
+
+// CountNewJobs returns how many of the jobs with the given ids were first
+// stored at or after since, i.e. the new jobs of a sync started then
+func CountNewJobs(ctx context.Context, db *sql.DB, ids []string, since time.Time) (int, error) {
+	var count int
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM jobs
+		WHERE id = ANY($1) AND created_at >= $2`,
+		Array(ids), since,
+	).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// SchemaChange is a change of the response structure of a provider: the
+// fields added and removed since the structure seen before it
+type SchemaChange struct {
+	ID          int               `json:"id"`
+	Source      string            `json:"source"`
+	Fingerprint string            `json:"fingerprint"`
+	Added       []string          `json:"added"`
+	Removed     []string          `json:"removed"`
+	Fields      map[string]string `json:"fields"`
+	FirstSeenAt time.Time         `json:"first_seen_at"`
+	LastSeenAt  time.Time         `json:"last_seen_at"`
+}
+
+// RecordProviderSchema archives the response structure of source seen by a
+// sync. An unchanged structure only refreshes last_seen_at; a changed one is
+// stored with its diff and returned. The first structure of a source is the
+// baseline and not reported as a change.
+func RecordProviderSchema(ctx context.Context, db *sql.DB, source, fingerprint string, fields map[string]string) (*SchemaChange, error) {
+	var id int
+	var previous string
+	var previousFields []byte
+	err := db.QueryRowContext(ctx, `
+		SELECT id, fingerprint, fields FROM provider_schemas
+		WHERE source = $1 ORDER BY id DESC LIMIT 1`, source,
+	).Scan(&id, &previous, &previousFields)
+
+	if err == nil && previous == fingerprint {
+		_, err = db.ExecContext(ctx, `UPDATE provider_schemas SET last_seen_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+		return nil, err
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	baseline := errors.Is(err, sql.ErrNoRows)
+
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &SchemaChange{Source: source, Fingerprint: fingerprint, Fields: fields}
+	var added, removed interface{}
+	if !baseline {
+		var old map[string]string
+		if err := json.Unmarshal(previousFields, &old); err != nil {
+			return nil, err
+		}
+		change.Added, change.Removed = diffFields(old, fields)
+		addedJSON, _ := json.Marshal(change.Added)
+		removedJSON, _ := json.Marshal(change.Removed)
+		added, removed = addedJSON, removedJSON
+	}
+
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO provider_schemas (source, fingerprint, fields, added, removed)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, first_seen_at, last_seen_at`,
+		source, fingerprint, fieldsJSON, added, removed,
+	).Scan(&change.ID, &change.FirstSeenAt, &change.LastSeenAt)
+	if err != nil || baseline {
+		return nil, err
+	}
+	return change, nil
+}
+
+// ListSchemaChanges returns the latest response structure changes, of one
+// source unless source is empty, newest first
+func ListSchemaChanges(ctx context.Context, db *sql.DB, source string, limit int) ([]SchemaChange, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, source, fingerprint, added, removed, fields, first_seen_at, last_seen_at
+		FROM provider_schemas
+		WHERE added IS NOT NULL AND ($1 = '' OR source = $1)
+		ORDER BY id DESC LIMIT $2`, source, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []SchemaChange{}
+	for rows.Next() {
+		var change SchemaChange
+		var added, removed, fields []byte
+		if err := rows.Scan(&change.ID, &change.Source, &change.Fingerprint, &added, &removed, &fields,
+			&change.FirstSeenAt, &change.LastSeenAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(added, &change.Added); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(removed, &change.Removed); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(fields, &change.Fields); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// diffFields returns the sorted field paths only in new and only in old
+func diffFields(old, new map[string]string) (added, removed []string) {
+	added, removed = []string{}, []string{}
+	for path := range new {
+		if _, ok := old[path]; !ok {
+			added = append(added, path)
+		}
+	}
+	for path := range old {
+		if _, ok := new[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordProviderSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	seen := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	fields := map[string]string{"data": "array", "data[].job_id": "string", "data[].job_title": "string"}
+
+	// The first structure of a source is the baseline
+	mock.ExpectQuery("^SELECT id, fingerprint, fields FROM provider_schemas WHERE source = \\$1").
+		WithArgs("jsearch").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("^INSERT INTO provider_schemas").
+		WithArgs("jsearch", "aaa", sqlmock.AnyArg(), nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_seen_at", "last_seen_at"}).AddRow(1, seen, seen))
+
+	change, err := RecordProviderSchema(ctx, db, "jsearch", "aaa", fields)
+	assert.NoError(t, err)
+	assert.Nil(t, change)
+
+	// An unchanged structure is only marked as seen
+	mock.ExpectQuery("^SELECT id, fingerprint, fields FROM provider_schemas").
+		WithArgs("jsearch").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "fingerprint", "fields"}).
+			AddRow(1, "aaa", []byte(`{"data":"array","data[].job_id":"string","data[].job_title":"string"}`)))
+	mock.ExpectExec("^UPDATE provider_schemas SET last_seen_at = CURRENT_TIMESTAMP WHERE id = \\$1$").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	change, err = RecordProviderSchema(ctx, db, "jsearch", "aaa", fields)
+	assert.NoError(t, err)
+	assert.Nil(t, change)
+
+	// A renamed field is stored and reported with its diff
+	mock.ExpectQuery("^SELECT id, fingerprint, fields FROM provider_schemas").
+		WithArgs("jsearch").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "fingerprint", "fields"}).
+			AddRow(1, "aaa", []byte(`{"data":"array","data[].job_id":"string","data[].job_title":"string"}`)))
+	mock.ExpectQuery("^INSERT INTO provider_schemas").
+		WithArgs("jsearch", "bbb", sqlmock.AnyArg(), []byte(`["data[].title"]`), []byte(`["data[].job_title"]`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_seen_at", "last_seen_at"}).AddRow(2, seen, seen))
+
+	change, err = RecordProviderSchema(ctx, db, "jsearch", "bbb",
+		map[string]string{"data": "array", "data[].job_id": "string", "data[].title": "string"})
+	assert.NoError(t, err)
+	if assert.NotNil(t, change) {
+		assert.Equal(t, 2, change.ID)
+		assert.Equal(t, []string{"data[].title"}, change.Added)
+		assert.Equal(t, []string{"data[].job_title"}, change.Removed)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
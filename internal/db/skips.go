@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/models"
+)
+
+// Reasons a job can be skipped by the save pipeline
+const (
+	SkipReasonExpired        = "expired"
+	SkipReasonBlockedCompany = "blocked_company"
+	SkipReasonNoTrack        = "no_track"
+	SkipReasonDuplicate      = "duplicate"
+	SkipReasonThin           = "thin_description"
+)
+
+// JobSkip records why the save pipeline did not store a job
+type JobSkip struct {
+	JobID     string    `json:"job_id"`
+	Source    string    `json:"source"`
+	Title     string    `json:"title"`
+	Company   string    `json:"company"`
+	URL       string    `json:"url"`
+	Reason    string    `json:"reason"`
+	Details   string    `json:"details,omitempty"`
+	SkippedAt time.Time `json:"skipped_at"`
+}
+
+// RecordSkip stores why a job was skipped. Repeated skips of the same provider
+// job for the same reason only refresh the existing row. Failures are logged
+// and never abort the save pipeline.
+func RecordSkip(ctx context.Context, db *sql.DB, job models.Job, reason, details string) {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO job_skips (job_id, source, title, company, url, reason, details)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (source, job_id, reason) WHERE job_id <> '' DO UPDATE SET
+			title = EXCLUDED.title,
+			company = EXCLUDED.company,
+			url = EXCLUDED.url,
+			details = EXCLUDED.details,
+			skipped_at = CURRENT_TIMESTAMP`,
+		job.JobID, job.Source, job.Title, job.Company, job.URL, reason, details,
+	)
+	if err != nil {
+		log.Printf("Error recording skipped job %s (%s): %v", job.JobID, reason, err)
+		syncErrorsFrom(ctx).Add(StageFilter, job.Source, err)
+	}
+}
+
+// FindSkips returns the most recent skips matching a provider job ID and/or a
+// company name fragment
+func FindSkips(ctx context.Context, db *sql.DB, jobID, company string, limit int) ([]JobSkip, error) {
+	var conditions []string
+	var args []interface{}
+
+	if jobID != "" {
+		args = append(args, jobID)
+		conditions = append(conditions, fmt.Sprintf("job_id = $%d", len(args)))
+	}
+	if company != "" {
+		args = append(args, "%"+company+"%")
+		conditions = append(conditions, fmt.Sprintf("company ILIKE $%d", len(args)))
+	}
+
+	query := `SELECT job_id, source, title, company, url, reason, details, skipped_at FROM job_skips`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY skipped_at DESC LIMIT $%d", len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skips := []JobSkip{}
+	for rows.Next() {
+		var (
+			skip    JobSkip
+			url     sql.NullString
+			details sql.NullString
+		)
+		if err := rows.Scan(&skip.JobID, &skip.Source, &skip.Title, &skip.Company, &url,
+			&skip.Reason, &details, &skip.SkippedAt); err != nil {
+			return nil, err
+		}
+		skip.URL = url.String
+		skip.Details = details.String
+		skips = append(skips, skip)
+	}
+	return skips, rows.Err()
+}
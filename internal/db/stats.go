@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SourceStats holds the job counts of a single source
+type SourceStats struct {
+	Source    string     `json:"source"`
+	Jobs      int        `json:"jobs"`
+	Active    int        `json:"active"`
+	NewestJob *time.Time `json:"newest_job"`
+}
+
+// JobStats summarizes the jobs table for status reporting
+type JobStats struct {
+	Total     int           `json:"total"`
+	Active    int           `json:"active"`
+	NewestJob *time.Time    `json:"newest_job"`
+	Sources   []SourceStats `json:"sources"`
+}
+
+// GetJobStats returns total and active (not expired) job counts and the
+// newest job per source
+func GetJobStats(ctx context.Context, db *sql.DB) (*JobStats, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT source,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE exp_date IS NULL OR exp_date > NOW()),
+			MAX(date_gotten)
+		FROM jobs
+		GROUP BY source
+		ORDER BY source`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &JobStats{Sources: []SourceStats{}}
+	for rows.Next() {
+		var (
+			source SourceStats
+			newest sql.NullTime
+		)
+		if err := rows.Scan(&source.Source, &source.Jobs, &source.Active, &newest); err != nil {
+			return nil, err
+		}
+		if newest.Valid {
+			source.NewestJob = &newest.Time
+			if stats.NewestJob == nil || newest.Time.After(*stats.NewestJob) {
+				stats.NewestJob = source.NewestJob
+			}
+		}
+		stats.Total += source.Jobs
+		stats.Active += source.Active
+		stats.Sources = append(stats.Sources, source)
+	}
+	return stats, rows.Err()
+}
+
+// CountActiveJobsByTrack returns the number of active (not expired) jobs
+// tagged with each track
+func CountActiveJobsByTrack(ctx context.Context, db *sql.DB) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT track, COUNT(*)
+		FROM jobs, unnest(tracks) AS track
+		WHERE exp_date IS NULL OR exp_date > NOW()
+		GROUP BY track`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			track string
+			count int
+		)
+		if err := rows.Scan(&track, &count); err != nil {
+			return nil, err
+		}
+		counts[track] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"Go9jaJobs/internal/models"
+)
+
+// Subscription is an email job alert: a daily digest of new jobs matching its
+// filters, sent once the address is confirmed
+type Subscription struct {
+	ID         int64  `json:"-"`
+	Email      string `json:"email"`
+	RemoteOnly bool   `json:"remote_only"`
+	State      string `json:"state,omitempty"`
+	Seniority  string `json:"seniority,omitempty"`
+	// Token is the secret of the confirmation and unsubscribe links
+	Token       string     `json:"-"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	LastSentAt  *time.Time `json:"-"`
+}
+
+// CreateSubscription stores a subscription with token, or returns the stored
+// one (with its own token) when the address already subscribed with the same
+// filters
+func CreateSubscription(ctx context.Context, db *sql.DB, sub Subscription) (Subscription, error) {
+	var confirmedAt sql.NullTime
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO job_subscriptions (email, remote_only, state, seniority, token)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (email, remote_only, state, seniority) DO UPDATE SET email = EXCLUDED.email
+		RETURNING id, token, confirmed_at`,
+		sub.Email, sub.RemoteOnly, sub.State, sub.Seniority, sub.Token,
+	).Scan(&sub.ID, &sub.Token, &confirmedAt)
+	if confirmedAt.Valid {
+		sub.ConfirmedAt = &confirmedAt.Time
+	}
+	return sub, err
+}
+
+// ConfirmSubscription confirms the subscription of token, reporting whether
+// one exists
+func ConfirmSubscription(ctx context.Context, db *sql.DB, token string) (bool, error) {
+	result, err := db.ExecContext(ctx,
+		`UPDATE job_subscriptions SET confirmed_at = COALESCE(confirmed_at, NOW()) WHERE token = $1`, token)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// DeleteSubscription removes the subscription of token, reporting whether
+// one existed
+func DeleteSubscription(ctx context.Context, db *sql.DB, token string) (bool, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM job_subscriptions WHERE token = $1`, token)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ConfirmedSubscriptions returns every confirmed subscription
+func ConfirmedSubscriptions(ctx context.Context, db *sql.DB) ([]Subscription, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, email, remote_only, state, seniority, token, confirmed_at, last_sent_at
+		FROM job_subscriptions
+		WHERE confirmed_at IS NOT NULL
+		ORDER BY id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []Subscription
+	for rows.Next() {
+		var (
+			sub         Subscription
+			confirmedAt sql.NullTime
+			lastSentAt  sql.NullTime
+		)
+		if err := rows.Scan(&sub.ID, &sub.Email, &sub.RemoteOnly, &sub.State, &sub.Seniority, &sub.Token,
+			&confirmedAt, &lastSentAt); err != nil {
+			return nil, err
+		}
+		if confirmedAt.Valid {
+			sub.ConfirmedAt = &confirmedAt.Time
+		}
+		if lastSentAt.Valid {
+			sub.LastSentAt = &lastSentAt.Time
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// SubscriptionJobs returns up to limit open jobs first stored after since
+// that match the filters of sub, newest first
+func SubscriptionJobs(ctx context.Context, db *sql.DB, sub Subscription, since time.Time, limit int) ([]models.Job, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, title, company, COALESCE(location, ''), COALESCE(url, ''), posted_at
+		FROM jobs
+		WHERE created_at > $1
+		AND (exp_date IS NULL OR exp_date > NOW()) AND NOT hidden
+		AND NOT `+DuplicateOfListedJob+`
+		AND ($2 = false OR is_remote)
+		AND ($3 = '' OR LOWER(state) = LOWER($3))
+		AND ($4 = '' OR seniority = $4)
+		ORDER BY posted_at DESC
+		LIMIT $5`,
+		since, sub.RemoteOnly, sub.State, sub.Seniority, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanFeedJobs(rows)
+}
+
+// MarkSubscriptionSent records when the last digest of a subscription was sent
+func MarkSubscriptionSent(ctx context.Context, db *sql.DB, id int64, sentAt time.Time) error {
+	_, err := db.ExecContext(ctx, `UPDATE job_subscriptions SET last_sent_at = $2 WHERE id = $1`, id, sentAt)
+	return err
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SourceSuggestion is a job source suggested by a community member, counted
+// once its email address is confirmed
+type SourceSuggestion struct {
+	ID     int64  `json:"-"`
+	Domain string `json:"domain"`
+	URL    string `json:"url"`
+	Name   string `json:"name,omitempty"`
+	Note   string `json:"note,omitempty"`
+	Email  string `json:"-"`
+	// Token is the secret of the confirmation link
+	Token       string     `json:"-"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+}
+
+// SuggestedSource is the review entry of a domain: every confirmed
+// suggestion of it merged, most suggested first
+type SuggestedSource struct {
+	Domain      string    `json:"domain"`
+	URLs        []string  `json:"urls"`
+	Names       []string  `json:"names"`
+	Notes       []string  `json:"notes"`
+	Suggestions int       `json:"suggestions"`
+	FirstAt     time.Time `json:"first_suggested_at"`
+	LastAt      time.Time `json:"last_suggested_at"`
+}
+
+// CreateSourceSuggestion stores a suggestion with token, or updates and
+// returns the stored one (with its own token) when the address already
+// suggested the domain, so each address counts once per domain
+func CreateSourceSuggestion(ctx context.Context, db *sql.DB, s SourceSuggestion) (SourceSuggestion, error) {
+	var confirmedAt sql.NullTime
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO source_suggestions (domain, url, name, note, email, token)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (domain, email) DO UPDATE SET
+			url = EXCLUDED.url, name = EXCLUDED.name, note = EXCLUDED.note, updated_at = NOW()
+		RETURNING id, token, confirmed_at`,
+		s.Domain, s.URL, s.Name, s.Note, s.Email, s.Token,
+	).Scan(&s.ID, &s.Token, &confirmedAt)
+	if confirmedAt.Valid {
+		s.ConfirmedAt = &confirmedAt.Time
+	}
+	return s, err
+}
+
+// ConfirmSourceSuggestion confirms the suggestion of token, reporting
+// whether one exists
+func ConfirmSourceSuggestion(ctx context.Context, db *sql.DB, token string) (bool, error) {
+	result, err := db.ExecContext(ctx,
+		`UPDATE source_suggestions SET confirmed_at = COALESCE(confirmed_at, NOW()) WHERE token = $1`, token)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ListSuggestedSources returns the confirmed suggestions grouped by domain,
+// the most suggested domains first
+func ListSuggestedSources(ctx context.Context, db *sql.DB, limit int) ([]SuggestedSource, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT domain,
+			array_agg(DISTINCT url),
+			array_remove(array_agg(DISTINCT NULLIF(name, '')), NULL),
+			array_remove(array_agg(NULLIF(note, '') ORDER BY confirmed_at), NULL),
+			COUNT(*), MIN(confirmed_at), MAX(confirmed_at)
+		FROM source_suggestions
+		WHERE confirmed_at IS NOT NULL
+		GROUP BY domain
+		ORDER BY COUNT(*) DESC, MIN(confirmed_at)
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []SuggestedSource{}
+	for rows.Next() {
+		var s SuggestedSource
+		if err := rows.Scan(&s.Domain, ScanArray(&s.URLs), ScanArray(&s.Names), ScanArray(&s.Notes),
+			&s.Suggestions, &s.FirstAt, &s.LastAt); err != nil {
+			return nil, err
+		}
+		sources = append(sources, s)
+	}
+	return sources, rows.Err()
+}
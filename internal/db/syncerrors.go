@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Sync pipeline stages that errors are attributed to
+const (
+	StageFetch  = "fetch"
+	StageFilter = "filter"
+	StageSave   = "save"
+)
+
+// SyncError aggregates the errors of one stage of a source during a sync
+type SyncError struct {
+	Stage  string `json:"stage"`
+	Source string `json:"source"`
+	Count  int    `json:"count"`
+	// Sample is the first error message seen
+	Sample string `json:"sample"`
+}
+
+// SyncErrors collects the errors of a sync grouped by stage and source, so a
+// run with hundreds of failing rows reports one entry per stage instead of
+// hundreds of messages. The zero value is ready to use and nil discards errors.
+type SyncErrors struct {
+	mu     sync.Mutex
+	errors []SyncError
+}
+
+// Add records err under stage and source
+func (e *SyncErrors) Add(stage, source string, err error) {
+	if e == nil || err == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.errors {
+		if e.errors[i].Stage == stage && e.errors[i].Source == source {
+			e.errors[i].Count++
+			return
+		}
+	}
+	e.errors = append(e.errors, SyncError{Stage: stage, Source: source, Count: 1, Sample: err.Error()})
+}
+
+// List returns the aggregated errors in the order they first occurred
+func (e *SyncErrors) List() []SyncError {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SyncError(nil), e.errors...)
+}
+
+// syncErrorsKey is the context key carrying the SyncErrors of a sync
+type syncErrorsKey struct{}
+
+// WithSyncErrors returns a context whose sync pipeline records its
+// non-fatal errors (e.g. a failed duplicate check) into errs
+func WithSyncErrors(ctx context.Context, errs *SyncErrors) context.Context {
+	return context.WithValue(ctx, syncErrorsKey{}, errs)
+}
+
+// syncErrorsFrom returns the SyncErrors of a context, nil when none
+func syncErrorsFrom(ctx context.Context) *SyncErrors {
+	errs, _ := ctx.Value(syncErrorsKey{}).(*SyncErrors)
+	return errs
+}
+
+// syncErrorsJSON encodes errors for a JSONB column, always as an array
+func syncErrorsJSON(errors []SyncError) []byte {
+	if errors == nil {
+		errors = []SyncError{}
+	}
+	data, _ := json.Marshal(errors)
+	return data
+}
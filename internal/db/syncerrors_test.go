@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncErrors(t *testing.T) {
+	errs := &SyncErrors{}
+	errs.Add(StageSave, "jsearch", errors.New("duplicate key"))
+	errs.Add(StageFetch, "indeed", errors.New("timeout"))
+	errs.Add(StageSave, "jsearch", errors.New("connection reset"))
+	errs.Add(StageSave, "jsearch", nil)
+
+	assert.Equal(t, []SyncError{
+		{Stage: StageSave, Source: "jsearch", Count: 2, Sample: "duplicate key"},
+		{Stage: StageFetch, Source: "indeed", Count: 1, Sample: "timeout"},
+	}, errs.List())
+
+	// Pipeline code records into the errors of its context, if any
+	syncErrorsFrom(WithSyncErrors(context.Background(), errs)).Add(StageFilter, "indeed", errors.New("bad query"))
+	syncErrorsFrom(context.Background()).Add(StageFilter, "indeed", errors.New("discarded"))
+	assert.Len(t, errs.List(), 3)
+
+	assert.Equal(t, `[]`, string(syncErrorsJSON(nil)))
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Sync run states
+const (
+	SyncRunQueued  = "queued"
+	SyncRunRunning = "running"
+	SyncRunPartial = "partial"
+	SyncRunDone    = "done"
+	SyncRunFailed  = "failed"
+)
+
+// SyncRun tracks a single sync request from queueing to completion
+type SyncRun struct {
+	ID         string          `json:"id"`
+	Source     string          `json:"source"`
+	Status     string          `json:"status"`
+	Fetched    int             `json:"fetched"`
+	Saved      int             `json:"saved"`
+	Results    json.RawMessage `json:"results,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+	// Errors aggregates the errors of every stage and source of the run
+	Errors []SyncError `json:"errors"`
+}
+
+// CreateSyncRun records a new queued sync run
+func CreateSyncRun(db *sql.DB, id, source string) error {
+	_, err := db.Exec(
+		"INSERT INTO sync_runs (id, source, status) VALUES ($1, $2, $3)",
+		id, source, SyncRunQueued,
+	)
+	return err
+}
+
+// StartSyncRun marks a sync run as running
+func StartSyncRun(db *sql.DB, id string) error {
+	_, err := db.Exec(
+		"UPDATE sync_runs SET status = $2, started_at = NOW() WHERE id = $1",
+		id, SyncRunRunning,
+	)
+	return err
+}
+
+// FinishSyncRun stores the final state, counts, per-source results and
+// aggregated errors of a sync run
+func FinishSyncRun(db *sql.DB, id, status string, fetched, saved int, results interface{}, errorMsg string, errs []SyncError) error {
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE sync_runs
+		SET status = $2, fetched = $3, saved = $4, results = $5, error_message = $6, errors = $7,
+			finished_at = NOW(),
+			duration_ms = (EXTRACT(EPOCH FROM (NOW() - COALESCE(started_at, created_at))) * 1000)::BIGINT
+		WHERE id = $1`,
+		id, status, fetched, saved, resultsJSON, errorMsg, syncErrorsJSON(errs),
+	)
+	return err
+}
+
+// syncRunSelect selects the columns read by scanSyncRun
+const syncRunSelect = `
+	SELECT id, source, status, fetched, saved, results, error_message,
+		created_at, started_at, finished_at, duration_ms, errors
+	FROM sync_runs`
+
+// scanSyncRun scans a row selected with syncRunSelect
+func scanSyncRun(scanner interface{ Scan(...interface{}) error }) (*SyncRun, error) {
+	var (
+		run        SyncRun
+		results    []byte
+		errorMsg   sql.NullString
+		startedAt  sql.NullTime
+		finishedAt sql.NullTime
+		errs       []byte
+	)
+
+	err := scanner.Scan(&run.ID, &run.Source, &run.Status, &run.Fetched, &run.Saved, &results, &errorMsg,
+		&run.CreatedAt, &startedAt, &finishedAt, &run.DurationMs, &errs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Runs recorded before errors were aggregated have none
+	run.Errors = []SyncError{}
+	if len(errs) > 0 {
+		if err := json.Unmarshal(errs, &run.Errors); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(results) > 0 {
+		run.Results = results
+	}
+	run.Error = errorMsg.String
+	if startedAt.Valid {
+		run.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+
+	return &run, nil
+}
+
+// GetSyncRun returns a sync run by ID, or sql.ErrNoRows when it does not exist
+func GetSyncRun(ctx context.Context, db *sql.DB, id string) (*SyncRun, error) {
+	return scanSyncRun(db.QueryRowContext(ctx, syncRunSelect+` WHERE id = $1`, id))
+}
+
+// ListSyncRuns returns the latest sync runs, newest first, optionally of a
+// single source
+func ListSyncRuns(ctx context.Context, db *sql.DB, source string, limit int) ([]SyncRun, error) {
+	rows, err := db.QueryContext(ctx, syncRunSelect+`
+		WHERE $1 = '' OR source = $1
+		ORDER BY created_at DESC
+		LIMIT $2`, source, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []SyncRun{}
+	for rows.Next() {
+		run, err := scanSyncRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// syncRunKey is the context key carrying the ID of a sync run
+type syncRunKey struct{}
+
+// WithSyncRun returns a context whose syncs belong to the sync run id
+func WithSyncRun(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, syncRunKey{}, id)
+}
+
+// syncRunFrom returns the ID of the sync run of a context, empty when none
+func syncRunFrom(ctx context.Context) string {
+	id, _ := ctx.Value(syncRunKey{}).(string)
+	return id
+}
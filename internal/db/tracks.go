@@ -0,0 +1,66 @@
+package db
+
+import (
+	"sync"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/models"
+)
+
+// Track is a configured technology with its compiled keyword rule
+type Track struct {
+	Name string                 `json:"name"`
+	Rule analyzer.RelevanceRule `json:"rule"`
+}
+
+// NewTracks compiles the rules of the configured tracks
+func NewTracks(tracks []config.Track) []Track {
+	compiled := make([]Track, len(tracks))
+	for i, track := range tracks {
+		compiled[i] = Track{Name: track.Name, Rule: analyzer.NewRelevanceRule(track.Include, track.Exclude)}
+	}
+	return compiled
+}
+
+var (
+	tracksMu sync.RWMutex
+	tracks   = NewTracks(config.DefaultTracks())
+)
+
+// SetTracks sets the tracks SaveJobsToDB keeps and tags jobs for
+func SetTracks(t []Track) {
+	tracksMu.Lock()
+	defer tracksMu.Unlock()
+	tracks = t
+}
+
+// CurrentTracks returns the tracks set by SetTracks, the default ones (Go)
+// until they are set
+func CurrentTracks() []Track {
+	tracksMu.RLock()
+	defer tracksMu.RUnlock()
+	return tracks
+}
+
+// IsTrack reports whether name is a current track
+func IsTrack(name string) bool {
+	for _, track := range CurrentTracks() {
+		if track.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// JobTracks returns the names of the current tracks the job is relevant to,
+// none for a job the save pipeline skips
+func JobTracks(job models.Job) []string {
+	names := []string{}
+	for _, track := range CurrentTracks() {
+		if track.Rule.Classify(job.Title, job.Description).Relevant {
+			names = append(names, track.Name)
+		}
+	}
+	return names
+}
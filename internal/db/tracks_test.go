@@ -0,0 +1,39 @@
+package db
+
+import (
+	"testing"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobTracks(t *testing.T) {
+	defer SetTracks(CurrentTracks())
+
+	tests := []struct {
+		title       string
+		description string
+		expected    []string
+	}{
+		{"Go/Java Engineer", "", []string{"go"}},
+		{"Backend Engineer (Go)", "", []string{"go"}},
+		{"Backend Engineer", "Our services are written in Go and Postgres.", []string{"go"}},
+		{"Go-to-Market Manager", "Own our go to market strategy.", []string{}},
+		{"Sales Associate", "You are always on the go and ready to go.", []string{}},
+		{"Rust Engineer", "Tokio and Postgres", []string{}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, JobTracks(models.Job{Title: tt.title, Description: tt.description}), tt.title)
+	}
+
+	SetTracks(NewTracks([]config.Track{
+		{Name: "go", Include: []string{"go", "golang"}},
+		{Name: "rust", Include: []string{"rust"}},
+	}))
+	assert.Equal(t, []string{"go", "rust"}, JobTracks(models.Job{Title: "Go/Rust Engineer"}))
+	assert.Equal(t, []string{"rust"}, JobTracks(models.Job{Title: "Rust Engineer", Description: "Trust and safety"}))
+	assert.True(t, IsTrack("rust"))
+	assert.False(t, IsTrack("python"))
+}
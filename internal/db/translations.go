@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// UntranslatedJob is a job whose description is in another language than
+// listings are read in
+type UntranslatedJob struct {
+	ID          string
+	Language    string
+	Description string
+}
+
+// FindUntranslatedJobs returns up to limit jobs detected in another language
+// than target, not translated yet nor tried within recheckAfter, most recent
+// first
+func FindUntranslatedJobs(ctx context.Context, db *sql.DB, target string, recheckAfter time.Duration, limit int) ([]UntranslatedJob, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, language, description
+		FROM jobs
+		WHERE language IS NOT NULL AND language <> $1
+			AND description_translation IS NULL
+			AND COALESCE(description, '') <> ''
+			AND (translation_checked_at IS NULL OR translation_checked_at < $2)
+		ORDER BY created_at DESC
+		LIMIT $3`,
+		target, time.Now().Add(-recheckAfter), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []UntranslatedJob
+	for rows.Next() {
+		var job UntranslatedJob
+		if err := rows.Scan(&job.ID, &job.Language, &job.Description); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// SetTranslation stores the description translation of job id made by
+// translator and marks it as checked, bumping updated_at so cached copies of
+// the job are revalidated; an empty translation, after a failed attempt, only
+// records the check
+func SetTranslation(ctx context.Context, db *sql.DB, id, translation, translator string) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE jobs SET
+			description_translation = COALESCE(NULLIF($2, ''), description_translation),
+			translated_by = COALESCE(NULLIF($3, ''), translated_by),
+			translation_checked_at = NOW(),
+			updated_at = CASE WHEN $2 <> '' THEN NOW() ELSE updated_at END
+		WHERE id = $1`,
+		id, translation, translator,
+	)
+	return err
+}
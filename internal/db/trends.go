@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Trend groupings of GetJobCountTrends
+const (
+	TrendBySource = "source"
+	TrendByState  = "state"
+)
+
+// TrendPoint is the job counts of a day
+type TrendPoint struct {
+	Day     string `json:"day"`
+	New     int    `json:"new"`
+	Active  int    `json:"active"`
+	Expired int    `json:"expired"`
+}
+
+// TrendSeries is the daily job counts of a source or state, or of all jobs
+// when ungrouped (empty Name)
+type TrendSeries struct {
+	Name   string       `json:"name"`
+	Points []TrendPoint `json:"points"`
+}
+
+// TrendFilter selects the snapshots of GetJobCountTrends
+type TrendFilter struct {
+	From, To time.Time
+	// Source and State keep the counts of one source or state
+	Source string
+	State  string
+	// GroupBy splits the counts into a series per TrendBySource or
+	// TrendByState, empty summing them up
+	GroupBy string
+}
+
+// SnapshotJobCounts records the job counts of a day per source and state:
+// jobs first seen that day, open at its end and expired during it. Expired
+// jobs are counted from the archive they are swept into. Recording a day
+// again replaces its counts.
+func SnapshotJobCounts(ctx context.Context, db *sql.DB, day time.Time) (int64, error) {
+	result, err := db.ExecContext(ctx, `
+		WITH counted AS (
+			SELECT source, COALESCE(state, '') AS state, created_at, exp_date
+			FROM jobs
+			UNION ALL
+			SELECT source, COALESCE(data->>'state', ''), (data->>'created_at')::timestamptz, exp_date
+			FROM jobs_archive
+		)
+		INSERT INTO job_counts_daily (day, source, state, new_jobs, active_jobs, expired_jobs)
+		SELECT $1::date, source, state,
+			COUNT(*) FILTER (WHERE created_at >= $1::date),
+			COUNT(*) FILTER (WHERE exp_date IS NULL OR exp_date >= $1::date + 1),
+			COUNT(*) FILTER (WHERE exp_date >= $1::date AND exp_date < $1::date + 1)
+		FROM counted
+		WHERE created_at < $1::date + 1
+			AND (exp_date IS NULL OR exp_date >= $1::date)
+		GROUP BY source, state
+		ON CONFLICT (day, source, state) DO UPDATE SET
+			new_jobs = EXCLUDED.new_jobs,
+			active_jobs = EXCLUDED.active_jobs,
+			expired_jobs = EXCLUDED.expired_jobs,
+			recorded_at = NOW()`, day.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PruneJobCounts deletes the snapshots older than retention days
+func PruneJobCounts(ctx context.Context, db *sql.DB, retention int) (int64, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM job_counts_daily WHERE day < CURRENT_DATE - $1::integer`, retention)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetJobCountTrends returns the recorded daily job counts from filter.From to
+// filter.To (both inclusive), oldest first
+func GetJobCountTrends(ctx context.Context, db *sql.DB, filter TrendFilter) ([]TrendSeries, error) {
+	group := "''"
+	switch filter.GroupBy {
+	case "":
+	case TrendBySource, TrendByState:
+		group = filter.GroupBy
+	default:
+		return nil, fmt.Errorf("unknown trend grouping %q", filter.GroupBy)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+group+` AS name, to_char(day, 'YYYY-MM-DD'),
+			SUM(new_jobs), SUM(active_jobs), SUM(expired_jobs)
+		FROM job_counts_daily
+		WHERE day BETWEEN $1::date AND $2::date
+			AND ($3 = '' OR source = $3)
+			AND ($4 = '' OR state = $4)
+		GROUP BY name, day
+		ORDER BY name, day`,
+		filter.From.Format("2006-01-02"), filter.To.Format("2006-01-02"), filter.Source, filter.State)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []TrendSeries{}
+	for rows.Next() {
+		var (
+			name  string
+			point TrendPoint
+		)
+		if err := rows.Scan(&name, &point.Day, &point.New, &point.Active, &point.Expired); err != nil {
+			return nil, err
+		}
+		if len(series) == 0 || series[len(series)-1].Name != name {
+			series = append(series, TrendSeries{Name: name})
+		}
+		series[len(series)-1].Points = append(series[len(series)-1].Points, point)
+	}
+	return series, rows.Err()
+}
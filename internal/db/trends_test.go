@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotJobCounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec("^WITH counted AS \\((.+) FROM jobs UNION ALL (.+) FROM jobs_archive \\) INSERT INTO job_counts_daily (.+) ON CONFLICT \\(day, source, state\\) DO UPDATE").
+		WithArgs("2024-05-01").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("^DELETE FROM job_counts_daily WHERE day < CURRENT_DATE - \\$1::integer$").
+		WithArgs(730).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	rows, err := SnapshotJobCounts(context.Background(), db, time.Date(2024, 5, 1, 23, 30, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), rows)
+
+	pruned, err := PruneJobCounts(context.Background(), db, 730)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), pruned)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetJobCountTrends(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	columns := []string{"name", "day", "new", "active", "expired"}
+	mock.ExpectQuery("^SELECT source AS name, (.+) FROM job_counts_daily WHERE day BETWEEN \\$1::date AND \\$2::date (.+) GROUP BY name, day ORDER BY name, day$").
+		WithArgs("2024-05-01", "2024-05-02", "", "Lagos").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("jobberman", "2024-05-01", 3, 20, 1).
+			AddRow("jobberman", "2024-05-02", 2, 21, 1).
+			AddRow("remoteok", "2024-05-02", 1, 4, 0))
+
+	series, err := GetJobCountTrends(context.Background(), db, TrendFilter{
+		From:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		State:   "Lagos",
+		GroupBy: TrendBySource,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []TrendSeries{
+		{Name: "jobberman", Points: []TrendPoint{
+			{Day: "2024-05-01", New: 3, Active: 20, Expired: 1},
+			{Day: "2024-05-02", New: 2, Active: 21, Expired: 1},
+		}},
+		{Name: "remoteok", Points: []TrendPoint{{Day: "2024-05-02", New: 1, Active: 4}}},
+	}, series)
+
+	// Groupings are interpolated, so unknown ones are refused
+	_, err = GetJobCountTrends(context.Background(), db, TrendFilter{GroupBy: "title"})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
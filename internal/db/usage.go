@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// APIUsage is a call to the API of a job source and its estimated cost
+type APIUsage struct {
+	Source   string    `json:"source"`
+	Endpoint string    `json:"endpoint"`
+	Status   int       `json:"status"`
+	Items    *int      `json:"items"`
+	Cost     float64   `json:"cost"`
+	CalledAt time.Time `json:"called_at"`
+}
+
+// APIUsageSummary totals the API calls of a source over a period
+type APIUsageSummary struct {
+	Source string `json:"source"`
+	Calls  int    `json:"calls"`
+	// FailedCalls were answered with an error status or not at all
+	FailedCalls int     `json:"failed_calls"`
+	Items       int     `json:"items"`
+	Cost        float64 `json:"cost"`
+}
+
+// RecordAPIUsage stores API calls
+func RecordAPIUsage(ctx context.Context, db *sql.DB, calls []APIUsage) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO api_usage (source, endpoint, status, items, cost, called_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, call := range calls {
+		if _, err := stmt.ExecContext(ctx, call.Source, call.Endpoint, call.Status, call.Items, call.Cost, call.CalledAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAPICost returns the estimated cost of the API calls of a source since a time
+func GetAPICost(ctx context.Context, db *sql.DB, source string, since time.Time) (float64, error) {
+	var cost float64
+	err := db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(cost), 0) FROM api_usage WHERE source = $1 AND called_at >= $2`,
+		source, since,
+	).Scan(&cost)
+	return cost, err
+}
+
+// GetAPIUsage totals the API calls of each source from from to to
+func GetAPIUsage(ctx context.Context, db *sql.DB, from, to time.Time) ([]APIUsageSummary, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT source, COUNT(*),
+			COUNT(*) FILTER (WHERE status = 0 OR status >= 400),
+			COALESCE(SUM(items), 0), COALESCE(SUM(cost), 0)
+		FROM api_usage
+		WHERE called_at >= $1 AND called_at < $2
+		GROUP BY source
+		ORDER BY source`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []APIUsageSummary{}
+	for rows.Next() {
+		var s APIUsageSummary
+		if err := rows.Scan(&s.Source, &s.Calls, &s.FailedCalls, &s.Items, &s.Cost); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
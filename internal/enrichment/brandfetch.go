@@ -0,0 +1,117 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// brandFetchBaseURL is the BrandFetch brand API
+const brandFetchBaseURL = "https://api.brandfetch.io/v2/brands/"
+
+// brandFetchResponse represents the response from the BrandFetch API
+type brandFetchResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Links       []Link `json:"links"`
+	Logos       []struct {
+		Formats []struct {
+			Src    string `json:"src"`
+			Format string `json:"format"`
+		} `json:"formats"`
+		Type string `json:"type"`
+	} `json:"logos"`
+	Colors []struct {
+		Hex  string `json:"hex"`
+		Type string `json:"type"`
+	} `json:"colors"`
+	Company struct {
+		Industries []struct {
+			Name string `json:"name"`
+		} `json:"industries"`
+	} `json:"company"`
+}
+
+// BrandFetchClient is the only way the application talks to BrandFetch
+type BrandFetchClient struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewBrandFetchClient creates a BrandFetch client authenticating with apiKey
+func NewBrandFetchClient(apiKey string) *BrandFetchClient {
+	return &BrandFetchClient{
+		apiKey:  apiKey,
+		baseURL: brandFetchBaseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled reports whether an API key is configured
+func (c *BrandFetchClient) Enabled() bool {
+	return c != nil && c.apiKey != ""
+}
+
+// FetchBrand returns what BrandFetch knows about domain, or nil when it has
+// no record of it
+func (c *BrandFetchClient) FetchBrand(ctx context.Context, domain string) (*CompanyDetails, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+domain, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", "Bearer "+c.apiKey)
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// Unknown brands are a miss, not an error
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 status for %s: %d", domain, res.StatusCode)
+	}
+
+	var brand brandFetchResponse
+	if err := json.NewDecoder(res.Body).Decode(&brand); err != nil {
+		return nil, fmt.Errorf("parsing response for %s: %w", domain, err)
+	}
+
+	details := &CompanyDetails{
+		Domain:      domain,
+		Name:        brand.Name,
+		Description: brand.Description,
+		Links:       brand.Links,
+		Source:      SourceBrandFetch,
+	}
+
+	// Use the first logo URL
+	for _, logo := range brand.Logos {
+		if len(logo.Formats) > 0 {
+			details.LogoURL = logo.Formats[0].Src
+			break
+		}
+	}
+
+	// Prefer the accent colour, otherwise the first one listed
+	for _, color := range brand.Colors {
+		if details.ThemeColor == "" || color.Type == "accent" {
+			details.ThemeColor = color.Hex
+		}
+		if color.Type == "accent" {
+			break
+		}
+	}
+
+	for _, industry := range brand.Company.Industries {
+		details.Industries = append(details.Industries, industry.Name)
+	}
+
+	return details, nil
+}
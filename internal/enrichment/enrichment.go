@@ -0,0 +1,147 @@
+// Package enrichment looks up company details (logo, description, brand
+// colour) used to decorate job listings.
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/errorlog"
+)
+
+// Sources of company details, in decreasing order of quality. Details from a
+// higher-quality source replace those of a lower one, never the reverse, so
+// the details an admin set are never replaced by a lookup.
+const (
+	SourceAdmin      = "admin"
+	SourceBrandFetch = "brandfetch"
+	SourceOpenGraph  = "opengraph"
+)
+
+// CompanyDetails holds what is known about a company, keyed by domain, and
+// which source supplied it
+type CompanyDetails struct {
+	Domain      string   `json:"domain"`
+	Name        string   `json:"name,omitempty"`
+	LogoURL     string   `json:"logo_url,omitempty"`
+	Description string   `json:"description,omitempty"`
+	ThemeColor  string   `json:"theme_color,omitempty"`
+	Industries  []string `json:"industries"`
+	Links       []Link   `json:"links"`
+	Source      string   `json:"source"`
+}
+
+// Link is a named profile of a company (website, twitter, linkedin...)
+type Link struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// IsEmpty reports whether no usable details were found
+func (d *CompanyDetails) IsEmpty() bool {
+	return d == nil || (d.Name == "" && d.LogoURL == "" && d.Description == "" && d.ThemeColor == "" &&
+		len(d.Industries) == 0 && len(d.Links) == 0)
+}
+
+// Enricher looks up company details from every configured source
+type Enricher struct {
+	brandFetch *BrandFetchClient
+	// synthetic generates details instead of calling any source (example mode)
+	synthetic bool
+}
+
+// NewEnricher creates an Enricher using the BrandFetch API key of cfg. In
+// example mode it generates synthetic details instead.
+func NewEnricher(cfg *config.Config) *Enricher {
+	var apiKey string
+	var synthetic bool
+	if cfg != nil {
+		apiKey = cfg.BrandFetchAPIKey
+		synthetic = cfg.Mode == config.ModeExample
+	}
+	return &Enricher{brandFetch: NewBrandFetchClient(apiKey), synthetic: synthetic}
+}
+
+// Lookup returns the details of the company at companyURL from BrandFetch,
+// falling back to the OpenGraph tags of its homepage when BrandFetch is not
+// configured or has no logo for it. Returns nil if nothing is found, and an
+// error instead when a source failed before anything was found, since the
+// company may well have details once the source is back.
+func (e *Enricher) Lookup(ctx context.Context, companyURL string) (*CompanyDetails, error) {
+	domain := CompanyDomain(companyURL)
+	if domain == "" {
+		return nil, nil
+	}
+	if e.synthetic {
+		return syntheticDetails(domain), nil
+	}
+
+	var brand *CompanyDetails
+	var brandErr error
+	if e.brandFetch.Enabled() {
+		brand, brandErr = e.brandFetch.FetchBrand(ctx, domain)
+		if brandErr != nil {
+			log.Printf("Error fetching brand for %s: %v", domain, brandErr)
+			errorlog.Record(errorlog.SubsystemEnrichment, SourceBrandFetch, brandErr)
+		}
+		if brand != nil && brand.LogoURL != "" {
+			return brand, nil
+		}
+	}
+
+	details, err := FetchOpenGraph(ctx, domain)
+	if err != nil {
+		log.Printf("Error fetching OpenGraph tags for %s: %v", domain, err)
+		errorlog.Record(errorlog.SubsystemEnrichment, SourceOpenGraph, err)
+		// A homepage off the public internet stays that way
+		if errors.Is(err, errNonPublicAddress) {
+			err = nil
+		}
+	}
+	if err == nil && !details.IsEmpty() {
+		return details, nil
+	}
+
+	// A BrandFetch record without a logo still beats nothing
+	if !brand.IsEmpty() {
+		return brand, nil
+	}
+	if err := errors.Join(brandErr, err); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// CompanyDomain extracts the bare domain (without www.) of a company URL,
+// accepting URLs with or without a scheme
+func CompanyDomain(companyURL string) string {
+	if companyURL == "" {
+		return ""
+	}
+	// Extract domain from URL
+	parsedURL, err := url.Parse(companyURL)
+	if err != nil {
+		log.Printf("Error parsing URL %s: %v", companyURL, err)
+		return ""
+	}
+
+	domain := parsedURL.Host
+	if domain == "" {
+		// If URL doesn't have a scheme, try using the path
+		domain = parsedURL.Path
+	}
+
+	// Remove www. prefix if present
+	domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+
+	// Remove any path components
+	if idx := strings.Index(domain, "/"); idx != -1 {
+		domain = domain[:idx]
+	}
+
+	return domain
+}
@@ -0,0 +1,101 @@
+package enrichment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompanyDomain(t *testing.T) {
+	assert.Equal(t, "paystack.com", CompanyDomain("https://www.paystack.com/careers"))
+	assert.Equal(t, "paystack.com", CompanyDomain("paystack.com/about"))
+	assert.Equal(t, "flutterwave.com", CompanyDomain("HTTPS://Flutterwave.com"))
+	assert.Equal(t, "", CompanyDomain(""))
+}
+
+func TestBrandFetchClientFetchBrand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/paystack.com":
+			w.Write([]byte(`{
+				"name": "Paystack",
+				"description": "Modern online and offline payments for Africa",
+				"links": [{"name": "twitter", "url": "https://twitter.com/paystack"}],
+				"logos": [{"type": "icon", "formats": [{"src": "https://cdn.brandfetch.io/paystack.png", "format": "png"}]}],
+				"colors": [{"hex": "#ffffff", "type": "light"}, {"hex": "#011b33", "type": "accent"}],
+				"company": {"industries": [{"name": "Fintech"}, {"name": "Payments"}]}
+			}`))
+		case "/unknown.com":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBrandFetchClient("test-key")
+	client.baseURL = server.URL + "/"
+	assert.True(t, client.Enabled())
+
+	brand, err := client.FetchBrand(context.Background(), "paystack.com")
+	assert.NoError(t, err)
+	assert.Equal(t, &CompanyDetails{
+		Domain:      "paystack.com",
+		Name:        "Paystack",
+		LogoURL:     "https://cdn.brandfetch.io/paystack.png",
+		Description: "Modern online and offline payments for Africa",
+		ThemeColor:  "#011b33",
+		Industries:  []string{"Fintech", "Payments"},
+		Links:       []Link{{Name: "twitter", URL: "https://twitter.com/paystack"}},
+		Source:      SourceBrandFetch,
+	}, brand)
+
+	// Unknown brands are a miss, throttling is an error
+	brand, err = client.FetchBrand(context.Background(), "unknown.com")
+	assert.NoError(t, err)
+	assert.Nil(t, brand)
+
+	_, err = client.FetchBrand(context.Background(), "busy.com")
+	assert.Error(t, err)
+
+	// A lookup failing at every source reports the failure rather than a miss
+	enricher := &Enricher{brandFetch: client}
+	details, err := enricher.Lookup(context.Background(), "https://busy.invalid")
+	assert.Error(t, err)
+	assert.Nil(t, details)
+}
+
+func TestNewEnricher(t *testing.T) {
+	assert.False(t, NewEnricher(nil).brandFetch.Enabled())
+	assert.False(t, NewEnricher(&config.Config{}).brandFetch.Enabled())
+	assert.True(t, NewEnricher(&config.Config{BrandFetchAPIKey: "key"}).brandFetch.Enabled())
+
+	details, err := NewEnricher(nil).Lookup(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Nil(t, details)
+}
+
+func TestEnricherExampleMode(t *testing.T) {
+	enricher := NewEnricher(&config.Config{Mode: config.ModeExample})
+
+	details, err := enricher.Lookup(context.Background(), "https://www.paystack.com/careers")
+	assert.NoError(t, err)
+	assert.NotNil(t, details)
+	assert.Equal(t, "paystack.com", details.Domain)
+	assert.Equal(t, "Paystack", details.Name)
+	assert.Equal(t, SourceSynthetic, details.Source)
+	assert.True(t, strings.HasPrefix(details.LogoURL, "data:image/svg+xml,"))
+	assert.NotEmpty(t, details.Industries)
+
+	// The same domain always yields the same details
+	again, err := enricher.Lookup(context.Background(), "paystack.com")
+	assert.NoError(t, err)
+	assert.Equal(t, details, again)
+}
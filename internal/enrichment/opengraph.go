@@ -0,0 +1,153 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxPageSize bounds how much of a homepage is read looking for meta tags
+const maxPageSize = 1 << 20
+
+var (
+	metaTagRe   = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	metaAttrRe  = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+)`)
+	headCloseRe = regexp.MustCompile(`(?i)</head>`)
+)
+
+// errNonPublicAddress is returned when a homepage resolves to an address off
+// the public internet
+var errNonPublicAddress = errors.New("address is not public")
+
+// openGraphClient only connects to public addresses: company domains come
+// from job postings, so one resolving to a private, loopback or link-local
+// address, or redirecting to one, must not reach the server's own network.
+// The check runs on the resolved address of every connection, redirects
+// included, so DNS answers cannot bypass it.
+var openGraphClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: dialPublicOnly}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// dialPublicOnly refuses connections to addresses that are not public
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("dialing %s: %w", address, errNonPublicAddress)
+	}
+	return nil
+}
+
+// isPublicIP reports whether ip is routable on the public internet
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, RFC 6598
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// FetchOpenGraph fetches the homepage of a company and reads its OpenGraph and
+// meta tags (og:image, og:description/description, theme-color). It is the
+// fallback for companies BrandFetch has no record of. Returns nil when the
+// homepage does not exist.
+func FetchOpenGraph(ctx context.Context, domain string) (*CompanyDetails, error) {
+	pageURL := "https://" + domain + "/"
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "GoJobsNG/1.0 (+https://gojobs-ng-web.vercel.app)")
+
+	res, err := openGraphClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// A missing homepage is a miss, not an error
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone {
+		return nil, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("homepage of %s returned status %d", domain, res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxPageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	details := parseOpenGraph(string(body), res.Request.URL)
+	details.Domain = domain
+	return details, nil
+}
+
+// parseOpenGraph extracts company details from the meta tags of a page,
+// resolving a relative og:image against base
+func parseOpenGraph(page string, base *url.URL) *CompanyDetails {
+	// Meta tags belong in <head>; ignore anything after it
+	if loc := headCloseRe.FindStringIndex(page); loc != nil {
+		page = page[:loc[0]]
+	}
+
+	meta := make(map[string]string)
+	for _, tag := range metaTagRe.FindAllString(page, -1) {
+		attrs := make(map[string]string)
+		for _, m := range metaAttrRe.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(m[1])] = html.UnescapeString(strings.Trim(m[2], `"'`))
+		}
+
+		key := attrs["property"]
+		if key == "" {
+			key = attrs["name"]
+		}
+		key = strings.ToLower(key)
+		if key != "" && meta[key] == "" {
+			meta[key] = strings.TrimSpace(attrs["content"])
+		}
+	}
+
+	details := &CompanyDetails{
+		Description: firstNonEmpty(meta["og:description"], meta["description"]),
+		ThemeColor:  meta["theme-color"],
+		Source:      SourceOpenGraph,
+	}
+
+	if image := firstNonEmpty(meta["og:image"], meta["og:image:url"]); image != "" {
+		if ref, err := url.Parse(image); err == nil && base != nil {
+			image = base.ResolveReference(ref).String()
+		}
+		details.LogoURL = image
+	}
+
+	return details
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
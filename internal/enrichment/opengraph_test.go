@@ -0,0 +1,65 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOpenGraph(t *testing.T) {
+	page := `<!doctype html>
+<html><head>
+	<meta charset="utf-8">
+	<meta name="description" content="Plain description">
+	<meta property="og:description" content="Payments for Africa &amp; beyond" />
+	<meta content='/images/og.png' property='og:image'>
+	<META NAME="theme-color" CONTENT="#011B33">
+</head>
+<body><meta property="og:image" content="https://evil.example/ignored.png"></body></html>`
+
+	base, _ := url.Parse("https://paystack.com/")
+	details := parseOpenGraph(page, base)
+
+	assert.Equal(t, "Payments for Africa & beyond", details.Description)
+	assert.Equal(t, "https://paystack.com/images/og.png", details.LogoURL)
+	assert.Equal(t, "#011B33", details.ThemeColor)
+	assert.Equal(t, SourceOpenGraph, details.Source)
+	assert.False(t, details.IsEmpty())
+}
+
+func TestParseOpenGraphWithoutTags(t *testing.T) {
+	details := parseOpenGraph("<html><head><title>Hi</title></head></html>", nil)
+	assert.True(t, details.IsEmpty())
+
+	var missing *CompanyDetails
+	assert.True(t, missing.IsEmpty())
+}
+
+func TestFetchOpenGraphNonPublic(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<meta property="og:description" content="internal">`))
+	}))
+	defer internal.Close()
+
+	// A company domain resolving to the server's own network is not fetched,
+	// the dialer checking the address of redirects alike
+	_, err := FetchOpenGraph(context.Background(), strings.TrimPrefix(internal.URL, "http://"))
+	assert.True(t, errors.Is(err, errNonPublicAddress), err)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for _, addr := range []string{"10.1.2.3", "172.16.0.1", "192.168.1.1", "127.0.0.1", "169.254.169.254", "100.64.0.1",
+		"0.0.0.0", "::1", "fe80::1", "fd00::1"} {
+		assert.False(t, isPublicIP(net.ParseIP(addr)), addr)
+	}
+	for _, addr := range []string{"102.89.0.1", "8.8.8.8", "2001:4860:4860::8888"} {
+		assert.True(t, isPublicIP(net.ParseIP(addr)), addr)
+	}
+}
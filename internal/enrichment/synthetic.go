@@ -0,0 +1,58 @@
+package enrichment
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strings"
+)
+
+// SourceSynthetic marks details generated in example mode
+const SourceSynthetic = "synthetic"
+
+// syntheticColors are the accent colors of synthetic companies
+var syntheticColors = []string{"#011B33", "#F5A623", "#0357EE", "#3359DF", "#40196D", "#00A86B"}
+
+// syntheticIndustries are the industries of synthetic companies
+var syntheticIndustries = [][]string{
+	{"Fintech", "Payments"},
+	{"Software Development"},
+	{"Banking", "Fintech"},
+	{"IT Services and IT Consulting"},
+}
+
+// syntheticDetails generates plausible details for a domain without calling
+// any provider. The same domain always yields the same details.
+func syntheticDetails(domain string) *CompanyDetails {
+	hash := fnv.New32a()
+	hash.Write([]byte(domain))
+	n := int(hash.Sum32() >> 1)
+
+	label := strings.Split(domain, ".")[0]
+	name := strings.ToUpper(label[:1]) + label[1:]
+	color := syntheticColors[n%len(syntheticColors)]
+
+	return &CompanyDetails{
+		Domain:      domain,
+		Name:        name,
+		LogoURL:     syntheticLogo(name, color),
+		Description: fmt.Sprintf("%s builds software for millions of customers across Africa.", name),
+		ThemeColor:  color,
+		Industries:  syntheticIndustries[n%len(syntheticIndustries)],
+		Links: []Link{
+			{Name: "website", URL: "https://" + domain},
+			{Name: "linkedin", URL: "https://www.linkedin.com/company/" + label},
+		},
+		Source: SourceSynthetic,
+	}
+}
+
+// syntheticLogo returns an SVG data URL showing the initial of name, so logos
+// render without any network access
+func syntheticLogo(name, color string) string {
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="128" height="128">`+
+		`<rect width="128" height="128" rx="24" fill="%s"/>`+
+		`<text x="64" y="84" font-family="sans-serif" font-size="64" fill="#fff" text-anchor="middle">%s</text></svg>`,
+		color, name[:1])
+	return "data:image/svg+xml," + url.PathEscape(svg)
+}
@@ -0,0 +1,130 @@
+package errorlog
+
+import (
+	"sync"
+	"time"
+)
+
+// Subsystems that report errors
+const (
+	SubsystemFetcher       = "fetcher"
+	SubsystemSave          = "save"
+	SubsystemEnrichment    = "enrichment"
+	SubsystemNotifications = "notifications"
+	SubsystemSchema        = "schema"
+	SubsystemExport        = "export"
+)
+
+// DefaultCapacity is the number of errors kept per subsystem
+const DefaultCapacity = 50
+
+// Entry is a single recorded error
+type Entry struct {
+	Subsystem string    `json:"subsystem"`
+	Source    string    `json:"source,omitempty"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// Recorder keeps the most recent errors of each subsystem in fixed-size ring buffers
+type Recorder struct {
+	mu       sync.Mutex
+	capacity int
+	buffers  map[string]*ring
+}
+
+// ring is a fixed-size circular buffer of entries
+type ring struct {
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewRecorder creates a Recorder keeping up to capacity errors per subsystem
+func NewRecorder(capacity int) *Recorder {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Recorder{
+		capacity: capacity,
+		buffers:  make(map[string]*ring),
+	}
+}
+
+// key identifies the buffer of a subsystem, fetcher errors are kept per source
+func key(subsystem, source string) string {
+	if source == "" {
+		return subsystem
+	}
+	return subsystem + ":" + source
+}
+
+// Record stores err for the subsystem, overwriting the oldest entry when full
+func (r *Recorder) Record(subsystem, source string, err error) {
+	if err == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := key(subsystem, source)
+	buf, ok := r.buffers[k]
+	if !ok {
+		buf = &ring{entries: make([]Entry, r.capacity)}
+		r.buffers[k] = buf
+	}
+
+	buf.entries[buf.next] = Entry{
+		Subsystem: subsystem,
+		Source:    source,
+		Message:   err.Error(),
+		Time:      time.Now(),
+	}
+	buf.next = (buf.next + 1) % r.capacity
+	if buf.next == 0 {
+		buf.full = true
+	}
+}
+
+// Recent returns up to limit of the newest errors per subsystem, newest first
+func (r *Recorder) Recent(limit int) map[string][]Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit <= 0 || limit > r.capacity {
+		limit = r.capacity
+	}
+
+	result := make(map[string][]Entry, len(r.buffers))
+	for k, buf := range r.buffers {
+		size := buf.next
+		if buf.full {
+			size = r.capacity
+		}
+		if size > limit {
+			size = limit
+		}
+
+		entries := make([]Entry, 0, size)
+		for i := 1; i <= size; i++ {
+			idx := (buf.next - i + r.capacity) % r.capacity
+			entries = append(entries, buf.entries[idx])
+		}
+		result[k] = entries
+	}
+	return result
+}
+
+// defaultRecorder is the process wide recorder used by the package level helpers
+var defaultRecorder = NewRecorder(DefaultCapacity)
+
+// Record stores err in the process wide recorder
+func Record(subsystem, source string, err error) {
+	defaultRecorder.Record(subsystem, source, err)
+}
+
+// Recent returns the newest errors from the process wide recorder
+func Recent(limit int) map[string][]Entry {
+	return defaultRecorder.Recent(limit)
+}
@@ -0,0 +1,43 @@
+package errorlog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderKeepsNewestFirst(t *testing.T) {
+	r := NewRecorder(3)
+
+	for i := 1; i <= 5; i++ {
+		r.Record(SubsystemFetcher, "jsearch", fmt.Errorf("error %d", i))
+	}
+	r.Record(SubsystemSave, "", errors.New("save failed"))
+	r.Record(SubsystemSave, "", nil)
+
+	recent := r.Recent(0)
+	assert.Len(t, recent, 2)
+
+	fetcherErrors := recent["fetcher:jsearch"]
+	assert.Len(t, fetcherErrors, 3)
+	assert.Equal(t, "error 5", fetcherErrors[0].Message)
+	assert.Equal(t, "error 3", fetcherErrors[2].Message)
+	assert.Equal(t, "jsearch", fetcherErrors[0].Source)
+
+	saveErrors := recent["save"]
+	assert.Len(t, saveErrors, 1)
+	assert.Equal(t, "save failed", saveErrors[0].Message)
+}
+
+func TestRecorderLimit(t *testing.T) {
+	r := NewRecorder(10)
+
+	r.Record(SubsystemEnrichment, "", errors.New("first"))
+	r.Record(SubsystemEnrichment, "", errors.New("second"))
+
+	recent := r.Recent(1)
+	assert.Len(t, recent["enrichment"], 1)
+	assert.Equal(t, "second", recent["enrichment"][0].Message)
+}
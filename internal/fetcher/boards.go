@@ -0,0 +1,179 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// FetchGreenhouseJobs fetches the track roles (see roleFilter) of the
+// Greenhouse job boards listed in GREENHOUSE_BOARDS and stores them with
+// source "greenhouse".
+func (jf *JobFetcher) FetchGreenhouseJobs(ctx context.Context) ([]models.Job, error) {
+	if jf.isExampleMode() {
+		return syntheticJobs("greenhouse", time.Now()), nil
+	}
+	return fetchEach(ctx, "greenhouse", "board", jf.Config.GreenhouseBoards, jf.fetchGreenhouseBoard)
+}
+
+// fetchGreenhouseBoard fetches the track roles of one Greenhouse board
+func (jf *JobFetcher) fetchGreenhouseBoard(ctx context.Context, board string) ([]models.Job, error) {
+	var apiURL string
+	if jf.Config.Mode == "dev" {
+		apiURL = fmt.Sprintf("http://localhost:8081/greenhouse/v1/boards/%s/jobs", url.PathEscape(board))
+	} else {
+		apiURL = fmt.Sprintf("https://boards-api.greenhouse.io/v1/boards/%s/jobs", url.PathEscape(board))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("content", "true")
+	req.URL.RawQuery = q.Encode()
+
+	req.Header.Set("Accept", "application/json")
+
+	body, err := jf.readResponse(req, "greenhouse", "greenhouse_"+board+"_response.json")
+	if err != nil {
+		return nil, err
+	}
+	jf.recordSchema("greenhouse", body)
+
+	var greenhouseResp models.GreenhouseResponse
+	if err := json.Unmarshal(body, &greenhouseResp); err != nil {
+		return nil, fmt.Errorf("json unmarshal error: %w", err)
+	}
+
+	now := time.Now()
+	roles := newRoleFilter(jf.Config.ActiveTracks())
+	jobs := []models.Job{}
+	raw := rawItems(body, "jobs")
+	for i, item := range greenhouseResp.Jobs {
+		// The API escapes the HTML of the content
+		descriptionHTML := html.UnescapeString(item.Content)
+		description := analyzer.HTMLToText(descriptionHTML)
+		if !roles.matches(item.Title, description) {
+			continue
+		}
+
+		postedAt := parsePostedAt("greenhouse", now, item.FirstPublished, item.UpdatedAt)
+
+		company := strings.TrimSpace(item.CompanyName)
+		if company == "" {
+			company = board
+		}
+
+		location := strings.TrimSpace(item.Location.Name)
+
+		jobs = append(jobs, models.Job{
+			ID:              uuid.New().String(),
+			JobID:           fmt.Sprintf("%d", item.ID),
+			Title:           strings.TrimSpace(item.Title),
+			Company:         company,
+			Location:        location,
+			Description:     description,
+			DescriptionHTML: descriptionHTML,
+			URL:             item.AbsoluteURL,
+			PostedAt:        postedAt,
+			IsRemote:        containsAny(location, []string{"remote"}),
+			Source:          "greenhouse",
+			RawData:         rawFragment(raw, i, item),
+			DateGotten:      now,
+			ExpDate:         InferExpiry("", now),
+		})
+	}
+
+	return jobs, nil
+}
+
+// FetchLeverJobs fetches the track roles of the Lever job boards listed in
+// LEVER_BOARDS and stores them with source "lever".
+func (jf *JobFetcher) FetchLeverJobs(ctx context.Context) ([]models.Job, error) {
+	if jf.isExampleMode() {
+		return syntheticJobs("lever", time.Now()), nil
+	}
+	return fetchEach(ctx, "lever", "board", jf.Config.LeverBoards, jf.fetchLeverBoard)
+}
+
+// fetchLeverBoard fetches the track roles of one Lever board
+func (jf *JobFetcher) fetchLeverBoard(ctx context.Context, board string) ([]models.Job, error) {
+	var apiURL string
+	if jf.Config.Mode == "dev" {
+		apiURL = fmt.Sprintf("http://localhost:8081/lever/v0/postings/%s", url.PathEscape(board))
+	} else {
+		apiURL = fmt.Sprintf("https://api.lever.co/v0/postings/%s", url.PathEscape(board))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("mode", "json")
+	req.URL.RawQuery = q.Encode()
+
+	req.Header.Set("Accept", "application/json")
+
+	body, err := jf.readResponse(req, "lever", "lever_"+board+"_response.json")
+	if err != nil {
+		return nil, err
+	}
+	jf.recordSchema("lever", body)
+
+	var leverResp models.LeverResponse
+	if err := json.Unmarshal(body, &leverResp); err != nil {
+		return nil, fmt.Errorf("json unmarshal error: %w", err)
+	}
+
+	now := time.Now()
+	roles := newRoleFilter(jf.Config.ActiveTracks())
+	jobs := []models.Job{}
+	raw := rawItems(body, "")
+	for i, item := range leverResp {
+		description := strings.TrimSpace(item.DescriptionPlain)
+		if description == "" {
+			description = analyzer.HTMLToText(item.Description)
+		}
+		if !roles.matches(item.Text, description) {
+			continue
+		}
+
+		postedAt := parsePostedAt("lever", now, epochString(item.CreatedAt))
+
+		location := strings.TrimSpace(item.Categories.Location)
+
+		jobs = append(jobs, models.Job{
+			ID:              uuid.New().String(),
+			JobID:           item.ID,
+			Title:           strings.TrimSpace(item.Text),
+			Company:         board,
+			Location:        location,
+			Description:     description,
+			DescriptionHTML: item.Description,
+			URL:             item.HostedURL,
+			PostedAt:        postedAt,
+			JobType:         strings.TrimSpace(item.Categories.Commitment),
+			IsRemote:        strings.EqualFold(item.WorkplaceType, "remote") || containsAny(location, []string{"remote"}),
+			Source:          "lever",
+			RawData:         rawFragment(raw, i, item),
+			DateGotten:      now,
+			ExpDate:         InferExpiry("", now),
+		})
+	}
+
+	return jobs, nil
+}
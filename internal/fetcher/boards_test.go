@@ -0,0 +1,101 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// greenhouseResponse is a Greenhouse board with a Go role and a sales role
+const greenhouseResponse = `{"jobs": [
+	{"id": 4012001, "title": "Backend Engineer (Golang)", "first_published": "2024-05-01T10:00:00Z",
+	 "updated_at": "2024-05-03T10:00:00Z", "absolute_url": "https://boards.greenhouse.io/paystack/jobs/4012001",
+	 "location": {"name": "Lagos or Remote"}, "content": "&lt;p&gt;Build payments in Go.&lt;/p&gt;"},
+	{"id": 4012002, "title": "Go-To-Market Lead", "first_published": "2024-05-01T10:00:00Z",
+	 "absolute_url": "https://boards.greenhouse.io/paystack/jobs/4012002",
+	 "location": {"name": "Lagos"}, "content": "&lt;p&gt;Own our launch plans.&lt;/p&gt;"}
+]}`
+
+// leverResponse is a Lever board with a Go role and a design role
+const leverResponse = `[
+	{"id": "5ac21346-8e0c-4494-8e7a-3eb92ff77902", "text": "Senior Software Engineer", "createdAt": 1714557600000,
+	 "description": "<p>We write Golang.</p>", "descriptionPlain": "We write Golang.",
+	 "hostedUrl": "https://jobs.lever.co/moniepoint/5ac21346", "workplaceType": "remote",
+	 "categories": {"location": "Nigeria", "commitment": "Full-time", "team": "Engineering"}},
+	{"id": "9b1e2c7d", "text": "Product Designer", "createdAt": 1714557600000,
+	 "descriptionPlain": "Design our apps.", "hostedUrl": "https://jobs.lever.co/moniepoint/9b1e2c7d",
+	 "categories": {"location": "Lagos"}}
+]`
+
+func TestFetchGreenhouseJobs(t *testing.T) {
+	server := setupTestServer(t, map[string]http.HandlerFunc{
+		"/greenhouse": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "true", r.URL.Query().Get("content"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(greenhouseResponse))
+		},
+	})
+	defer server.Close()
+
+	cfg := createMockConfig(server.URL)
+	cfg.GreenhouseBoards = []string{"paystack"}
+	fetcher := NewJobFetcher(cfg)
+	fetcher.client = &http.Client{
+		Transport: &mockTransport{URL: server.URL + "/greenhouse", Client: server.Client()},
+	}
+
+	jobs, err := fetcher.FetchGreenhouseJobs(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	job := jobs[0]
+	assert.Equal(t, "4012001", job.JobID)
+	assert.Equal(t, "Backend Engineer (Golang)", job.Title)
+	assert.Equal(t, "paystack", job.Company)
+	assert.Equal(t, "Build payments in Go.", job.Description)
+	assert.Equal(t, "<p>Build payments in Go.</p>", job.DescriptionHTML)
+	assert.Equal(t, "https://boards.greenhouse.io/paystack/jobs/4012001", job.URL)
+	assert.Equal(t, "greenhouse", job.Source)
+	assert.True(t, job.IsRemote)
+	assert.True(t, job.PostedAt.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+
+	removeCached("greenhouse_paystack_response.json")
+}
+
+func TestFetchLeverJobs(t *testing.T) {
+	server := setupTestServer(t, map[string]http.HandlerFunc{
+		"/lever": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "json", r.URL.Query().Get("mode"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(leverResponse))
+		},
+	})
+	defer server.Close()
+
+	cfg := createMockConfig(server.URL)
+	cfg.LeverBoards = []string{"moniepoint"}
+	fetcher := NewJobFetcher(cfg)
+	fetcher.client = &http.Client{
+		Transport: &mockTransport{URL: server.URL + "/lever", Client: server.Client()},
+	}
+
+	jobs, err := fetcher.FetchLeverJobs(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	job := jobs[0]
+	assert.Equal(t, "5ac21346-8e0c-4494-8e7a-3eb92ff77902", job.JobID)
+	assert.Equal(t, "Senior Software Engineer", job.Title)
+	assert.Equal(t, "moniepoint", job.Company)
+	assert.Equal(t, "We write Golang.", job.Description)
+	assert.Equal(t, "Nigeria", job.Location)
+	assert.Equal(t, "Full-time", job.JobType)
+	assert.Equal(t, "lever", job.Source)
+	assert.True(t, job.IsRemote)
+	assert.True(t, job.PostedAt.Equal(time.UnixMilli(1714557600000)))
+
+	removeCached("lever_moniepoint_response.json")
+}
@@ -0,0 +1,164 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"Go9jaJobs/internal/models"
+)
+
+// ErrCircuitOpen is returned without calling a source while its circuit
+// breaker is open, after too many consecutive failed fetches
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Default circuit breaker cooldown when the config leaves it unset
+const defaultBreakerCooldown = 5 * time.Minute
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitState is the circuit breaker state of a source
+type CircuitState struct {
+	State string `json:"state"`
+	// Failures counts the consecutive failed fetches
+	Failures int `json:"failures"`
+	// OpenedAt and RetryAt are when the breaker last opened and when it lets
+	// a probe through, set while it is not closed
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	RetryAt  *time.Time `json:"retry_at,omitempty"`
+}
+
+// breaker is the circuit breaker of a source
+type breaker struct {
+	failures int
+	openedAt time.Time
+	// probing is set while the single fetch let through after the cooldown runs
+	probing bool
+}
+
+// breakerSettings returns the consecutive failures opening a breaker, 0
+// when breakers are disabled, and how long it stays open
+func (jf *JobFetcher) breakerSettings() (threshold int, cooldown time.Duration) {
+	if jf.Config == nil {
+		return 0, 0
+	}
+	cooldown = jf.Config.CircuitBreakerCooldown
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return jf.Config.CircuitBreakerThreshold, cooldown
+}
+
+// Guard runs fetch for source through its circuit breaker. The breaker opens
+// after CircuitBreakerThreshold consecutive failures, failing the fetches of
+// the next CircuitBreakerCooldown with ErrCircuitOpen at once; then a single
+// probe fetch is let through, closing it on success and reopening it on
+// failure. Fetches ended by the caller, cancelled or past the deadline of
+// ctx (e.g. a sync timeout shorter than a slow API), are not counted: they
+// say nothing about the source.
+func (jf *JobFetcher) Guard(ctx context.Context, source string, fetch func(context.Context) ([]models.Job, error)) ([]models.Job, error) {
+	if jf == nil {
+		return fetch(ctx)
+	}
+	threshold, cooldown := jf.breakerSettings()
+	if threshold <= 0 {
+		return fetch(ctx)
+	}
+
+	if err := jf.allow(source, cooldown, time.Now()); err != nil {
+		return nil, err
+	}
+	jobs, err := fetch(ctx)
+	if err != nil && ctx.Err() != nil {
+		jf.release(source)
+		return jobs, err
+	}
+	jf.settle(source, threshold, err, time.Now())
+	return jobs, err
+}
+
+// allow returns ErrCircuitOpen unless the breaker of source is closed or its
+// cooldown elapsed, in which case the caller becomes the probe
+func (jf *JobFetcher) allow(source string, cooldown time.Duration, now time.Time) error {
+	jf.breakerMu.Lock()
+	defer jf.breakerMu.Unlock()
+
+	b := jf.breakers[source]
+	if b == nil || b.openedAt.IsZero() {
+		return nil
+	}
+	retryAt := b.openedAt.Add(cooldown)
+	if b.probing || now.Before(retryAt) {
+		return fmt.Errorf("%s: %w after %d consecutive failures, retrying after %s",
+			source, ErrCircuitOpen, b.failures, retryAt.Format(time.RFC3339))
+	}
+	b.probing = true
+	return nil
+}
+
+// release ends a probe that the caller ended before it told anything
+func (jf *JobFetcher) release(source string) {
+	jf.breakerMu.Lock()
+	defer jf.breakerMu.Unlock()
+	if b := jf.breakers[source]; b != nil {
+		b.probing = false
+	}
+}
+
+// settle records the outcome of a fetch of source
+func (jf *JobFetcher) settle(source string, threshold int, err error, now time.Time) {
+	jf.breakerMu.Lock()
+	defer jf.breakerMu.Unlock()
+
+	if err == nil {
+		delete(jf.breakers, source)
+		return
+	}
+	if jf.breakers == nil {
+		jf.breakers = make(map[string]*breaker)
+	}
+	b := jf.breakers[source]
+	if b == nil {
+		b = &breaker{}
+		jf.breakers[source] = b
+	}
+	b.failures++
+	// A failed probe reopens the breaker for another cooldown
+	if b.probing || b.failures >= threshold {
+		b.openedAt = now
+	}
+	b.probing = false
+}
+
+// CircuitStates returns the circuit breaker state of the sources that failed
+// since their last successful fetch; the others are closed
+func (jf *JobFetcher) CircuitStates() map[string]CircuitState {
+	states := make(map[string]CircuitState)
+	if jf == nil {
+		return states
+	}
+	_, cooldown := jf.breakerSettings()
+	now := time.Now()
+
+	jf.breakerMu.Lock()
+	defer jf.breakerMu.Unlock()
+	for source, b := range jf.breakers {
+		state := CircuitState{State: CircuitClosed, Failures: b.failures}
+		if !b.openedAt.IsZero() {
+			openedAt, retryAt := b.openedAt, b.openedAt.Add(cooldown)
+			state.OpenedAt, state.RetryAt = &openedAt, &retryAt
+			state.State = CircuitOpen
+			if b.probing || !now.Before(retryAt) {
+				state.State = CircuitHalfOpen
+			}
+		}
+		states[source] = state
+	}
+	return states
+}
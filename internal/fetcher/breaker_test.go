@@ -0,0 +1,93 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuard(t *testing.T) {
+	jf := &JobFetcher{Config: &config.Config{CircuitBreakerThreshold: 2, CircuitBreakerCooldown: time.Hour}}
+	ctx := context.Background()
+	failure := errors.New("connection refused")
+
+	calls := 0
+	failing := func(context.Context) ([]models.Job, error) {
+		calls++
+		return nil, failure
+	}
+	working := func(context.Context) ([]models.Job, error) {
+		calls++
+		return []models.Job{{JobID: "job-1"}}, nil
+	}
+
+	// Opens after the threshold of consecutive failures
+	for i := 0; i < 2; i++ {
+		_, err := jf.Guard(ctx, "jsearch", failing)
+		assert.ErrorIs(t, err, failure)
+	}
+	assert.Equal(t, CircuitOpen, jf.CircuitStates()["jsearch"].State)
+
+	_, err := jf.Guard(ctx, "jsearch", working)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, "circuit_open", ErrorKind(err))
+	assert.Equal(t, 2, calls, "an open breaker does not call the source")
+
+	// Other sources are unaffected
+	_, err = jf.Guard(ctx, "linkedin", working)
+	assert.NoError(t, err)
+
+	// After the cooldown a failed probe reopens it
+	jf.breakers["jsearch"].openedAt = time.Now().Add(-2 * time.Hour)
+	assert.Equal(t, CircuitHalfOpen, jf.CircuitStates()["jsearch"].State)
+	_, err = jf.Guard(ctx, "jsearch", failing)
+	assert.ErrorIs(t, err, failure)
+	state := jf.CircuitStates()["jsearch"]
+	assert.Equal(t, CircuitOpen, state.State)
+	assert.Equal(t, 3, state.Failures)
+
+	// and a successful one closes it
+	jf.breakers["jsearch"].openedAt = time.Now().Add(-2 * time.Hour)
+	jobs, err := jf.Guard(ctx, "jsearch", working)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.NotContains(t, jf.CircuitStates(), "jsearch")
+}
+
+func TestGuardCancelled(t *testing.T) {
+	jf := &JobFetcher{Config: &config.Config{CircuitBreakerThreshold: 1, CircuitBreakerCooldown: time.Hour}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Fetches cancelled by the caller do not count as failures
+	_, err := jf.Guard(ctx, "jsearch", func(ctx context.Context) ([]models.Job, error) {
+		return nil, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotContains(t, jf.CircuitStates(), "jsearch")
+
+	// Nor do those past the caller's deadline
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = jf.Guard(ctx, "jsearch", func(ctx context.Context) ([]models.Job, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotContains(t, jf.CircuitStates(), "jsearch")
+
+	// Breakers are disabled without a threshold
+	jf.Config.CircuitBreakerThreshold = 0
+	for i := 0; i < 3; i++ {
+		jf.Guard(context.Background(), "jsearch", func(context.Context) ([]models.Job, error) {
+			return nil, errors.New("down")
+		})
+	}
+	assert.Empty(t, jf.CircuitStates())
+}
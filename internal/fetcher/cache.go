@@ -0,0 +1,152 @@
+package fetcher
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultCacheDir holds the cached API responses when the config sets none
+const defaultCacheDir = "api_response_cache"
+
+// cacheTimeLayout stamps the cached responses; fixed width, so the names of
+// the responses of an API sort by time
+const cacheTimeLayout = "20060102T150405.000000000Z"
+
+// cacheEnabled reports whether API responses are kept
+func (jf *JobFetcher) cacheEnabled() bool {
+	return jf.Config == nil || !jf.Config.APIResponseCacheDisabled
+}
+
+// cacheDir returns the directory of the cached API responses
+func (jf *JobFetcher) cacheDir() string {
+	if jf.Config != nil && jf.Config.APIResponseCacheDir != "" {
+		return jf.Config.APIResponseCacheDir
+	}
+	return defaultCacheDir
+}
+
+// cachedName returns the name of the response cached as filename (e.g.
+// jsearch_response.json) at t, e.g. jsearch_response_20240501T100000.000000000Z.json
+func cachedName(filename string, t time.Time) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "_" + t.UTC().Format(cacheTimeLayout) + ext
+}
+
+// cacheKey returns the filename a cached response was stored under, the
+// name itself for responses cached without a time
+func cacheKey(name string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	i := strings.LastIndex(stem, "_")
+	if i < 0 {
+		return name
+	}
+	if _, err := time.Parse(cacheTimeLayout, stem[i+1:]); err != nil {
+		return name
+	}
+	return stem[:i] + ext
+}
+
+// latestCached returns the path of the latest response cached in dir under
+// filename; ok is false when there is none
+func latestCached(dir, filename string) (path string, ok bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	var latest string
+	for _, entry := range entries {
+		// Names sort by time, and after the name without one
+		if !entry.IsDir() && cacheKey(entry.Name()) == filename && entry.Name() > latest {
+			latest = entry.Name()
+		}
+	}
+	if latest == "" {
+		return "", false
+	}
+	return filepath.Join(dir, latest), true
+}
+
+// cacheResponse keeps body, a response received at t, in the response cache
+// under filename, then prunes the cache. The cache is an aid to development
+// and replays: failing to write it does not fail the fetch.
+func (jf *JobFetcher) cacheResponse(filename string, body []byte, t time.Time) {
+	if !jf.cacheEnabled() {
+		return
+	}
+
+	dir := jf.cacheDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("Failed to create the response cache %s: %v", dir, err)
+		return
+	}
+	path := filepath.Join(dir, cachedName(filename, t))
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		log.Printf("Failed to write cache file %s: %v", path, err)
+		return
+	}
+
+	var maxAge time.Duration
+	var maxBytes int64
+	if jf.Config != nil {
+		maxAge = jf.Config.APIResponseCacheMaxAge
+		maxBytes = int64(jf.Config.APIResponseCacheMaxMB) << 20
+	}
+	pruneCache(dir, maxAge, maxBytes, t)
+}
+
+// pruneCache removes the cached responses older than maxAge, then the oldest
+// ones until the cache holds at most maxBytes; 0 is no limit. The latest
+// response of each API is always kept, for replays.
+func pruneCache(dir string, maxAge time.Duration, maxBytes int64, now time.Time) {
+	if maxAge <= 0 && maxBytes <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type cached struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cached
+	latest := make(map[string]string)
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cached{entry.Name(), info.Size(), info.ModTime()})
+		total += info.Size()
+		if key := cacheKey(entry.Name()); entry.Name() > latest[key] {
+			latest[key] = entry.Name()
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	for _, file := range files {
+		if latest[cacheKey(file.name)] == file.name {
+			continue
+		}
+		expired := maxAge > 0 && now.Sub(file.modTime) > maxAge
+		if !expired && (maxBytes <= 0 || total <= maxBytes) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, file.name)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to prune cache file %s: %v", file.name, err)
+			continue
+		}
+		total -= file.size
+	}
+}
@@ -0,0 +1,85 @@
+package fetcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedName(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	name := cachedName("jsearch_response.json", at)
+	assert.Equal(t, "jsearch_response_20240501T100000.000000000Z.json", name)
+	assert.Equal(t, "jsearch_response.json", cacheKey(name))
+	assert.Equal(t, "greenhouse_paystack_response.json", cacheKey(cachedName("greenhouse_paystack_response.json", at)))
+	// Responses cached before they were stamped keep their name
+	assert.Equal(t, "jsearch_response.json", cacheKey("jsearch_response.json"))
+}
+
+func TestCacheResponse(t *testing.T) {
+	dir := t.TempDir()
+	jf := &JobFetcher{Config: &config.Config{APIResponseCacheDir: dir}}
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	// Successive responses are kept side by side, the latest replayed
+	jf.cacheResponse("jsearch_response.json", []byte(`{"run": 1}`), at)
+	jf.cacheResponse("jsearch_response.json", []byte(`{"run": 2}`), at.Add(time.Hour))
+	jf.cacheResponse("linkedin_response.json", []byte(`[]`), at)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	path, ok := latestCached(dir, "jsearch_response.json")
+	assert.True(t, ok)
+	body, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"run": 2}`, string(body))
+	_, ok = latestCached(dir, "indeed_response.json")
+	assert.False(t, ok)
+
+	// Nothing is written with the cache disabled
+	disabled := t.TempDir()
+	jf = &JobFetcher{Config: &config.Config{APIResponseCacheDir: disabled, APIResponseCacheDisabled: true}}
+	jf.cacheResponse("jsearch_response.json", []byte(`{}`), at)
+	entries, err = os.ReadDir(disabled)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestPruneCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC)
+	write := func(filename string, age time.Duration, size int) string {
+		name := cachedName(filename, now.Add(-age))
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+		assert.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+		return name
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	oldJSearch := write("jsearch_response.json", 10*24*time.Hour, 10)
+	midJSearch := write("jsearch_response.json", 2*24*time.Hour, 40)
+	newJSearch := write("jsearch_response.json", time.Hour, 30)
+	oldLinkedIn := write("linkedin_response.json", 30*24*time.Hour, 10)
+
+	// Expired responses go, except the latest of each API
+	pruneCache(dir, 7*24*time.Hour, 0, now)
+	assert.False(t, exists(oldJSearch))
+	assert.True(t, exists(midJSearch))
+	assert.True(t, exists(oldLinkedIn))
+
+	// Then the oldest ones past the size limit
+	pruneCache(dir, 0, 50, now)
+	assert.False(t, exists(midJSearch))
+	assert.True(t, exists(newJSearch))
+	assert.True(t, exists(oldLinkedIn))
+}
@@ -0,0 +1,118 @@
+package fetcher
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateFormats are the layouts providers use for dates, tried in order
+var dateFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02",
+}
+
+// relativeAge matches an age relative to now, e.g. "2 days ago", "30+ days
+// ago" or "1 hr ago"
+var relativeAge = regexp.MustCompile(`(\d+)\+?\s*(minute|min|hour|hr|day|week|month)s?\s+ago`)
+
+// justPosted are the ages that mean now
+var justPosted = []string{"new", "just posted", "just now", "today"}
+
+// epochMillisFrom is the smallest epoch read as milliseconds rather than
+// seconds; as seconds it is in the year 33658
+const epochMillisFrom = 1e12
+
+// parseTimestamp parses s in one of dateFormats
+func parseTimestamp(s string) (time.Time, bool) {
+	for _, layout := range dateFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseDate parses a date given by a provider: a time in one of the usual
+// layouts (RFC 3339, RFC 1123 or date only), a Unix epoch in seconds or
+// milliseconds, or an age relative to now such as "2 days ago", "Yesterday"
+// or "Just posted". ok is false when s is none of them.
+func ParseDate(s string, now time.Time) (t time.Time, ok bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	if t, ok := parseTimestamp(s); ok {
+		return t, true
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		switch {
+		case n <= 0:
+			return time.Time{}, false
+		case n >= epochMillisFrom:
+			return time.UnixMilli(n), true
+		default:
+			return time.Unix(n, 0), true
+		}
+	}
+
+	return parseAge(strings.ToLower(s), now)
+}
+
+// parseAge parses age, a lower case age relative to now
+func parseAge(age string, now time.Time) (time.Time, bool) {
+	for _, word := range justPosted {
+		if strings.HasPrefix(age, word) {
+			return now, true
+		}
+	}
+	if strings.Contains(age, "yesterday") {
+		return now.AddDate(0, 0, -1), true
+	}
+
+	m := relativeAge.FindStringSubmatch(age)
+	if m == nil {
+		return time.Time{}, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	switch m[2] {
+	case "minute", "min":
+		return now.Add(-time.Duration(n) * time.Minute), true
+	case "hour", "hr":
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case "day":
+		return now.AddDate(0, 0, -n), true
+	case "week":
+		return now.AddDate(0, 0, -7*n), true
+	default:
+		return now.AddDate(0, -n, 0), true
+	}
+}
+
+// parsePostedAt returns the first of values, the posting dates of a job of source
+// in order of preference, that ParseDate can read. When none can, it logs
+// them and returns now, so the job is still kept.
+func parsePostedAt(source string, now time.Time, values ...string) time.Time {
+	for _, value := range values {
+		if t, ok := ParseDate(value, now); ok {
+			return t
+		}
+	}
+	log.Printf("%s: no readable posting date in %q, using the fetch time", source, values)
+	return now
+}
+
+// epochString formats an epoch for parsePostedAt, empty when unset
+func epochString(epoch int64) string {
+	if epoch <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(epoch, 10)
+}
@@ -0,0 +1,63 @@
+package fetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	posted := time.Date(2024, 5, 18, 9, 30, 0, 0, time.UTC)
+
+	parsed := func(s string) time.Time {
+		t.Helper()
+		got, ok := ParseDate(s, now)
+		assert.True(t, ok, s)
+		return got
+	}
+
+	// Times in the usual layouts
+	assert.True(t, posted.Equal(parsed("2024-05-18T09:30:00Z")))
+	assert.True(t, posted.Equal(parsed("2024-05-18T10:30:00+01:00")))
+	assert.True(t, posted.Equal(parsed("2024-05-18T09:30:00")))
+	assert.True(t, posted.Equal(parsed("2024-05-18 09:30:00")))
+	assert.True(t, posted.Equal(parsed("Sat, 18 May 2024 09:30:00 +0000")))
+	assert.True(t, posted.Equal(parsed(" Sat, 18 May 2024 09:30:00 UTC ")))
+	assert.Equal(t, time.Date(2024, 5, 18, 0, 0, 0, 0, time.UTC), parsed("2024-05-18"))
+
+	// Epochs in seconds and milliseconds
+	assert.True(t, posted.Equal(parsed("1716024600")))
+	assert.True(t, posted.Equal(parsed("1716024600000")))
+
+	// Ages relative to now
+	assert.Equal(t, now, parsed("Just posted"))
+	assert.Equal(t, now, parsed("Today"))
+	assert.Equal(t, now, parsed("New"))
+	assert.Equal(t, now.AddDate(0, 0, -1), parsed("Yesterday"))
+	assert.Equal(t, now.Add(-30*time.Minute), parsed("30 minutes ago"))
+	assert.Equal(t, now.Add(-5*time.Hour), parsed("5 hours ago"))
+	assert.Equal(t, now.AddDate(0, 0, -2), parsed("2 days ago"))
+	assert.Equal(t, now.AddDate(0, 0, -30), parsed("30+ days ago"))
+	assert.Equal(t, now.AddDate(0, 0, -7), parsed("Posted 1 week ago"))
+	assert.Equal(t, now.AddDate(0, -3, 0), parsed("3 months ago"))
+
+	for _, s := range []string{"", "  ", "0", "-5", "soon", "18/05/2024", "2 fortnights ago"} {
+		_, ok := ParseDate(s, now)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestParsePostedAt(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+
+	// The first readable value wins
+	assert.Equal(t, now.AddDate(0, 0, -2), parsePostedAt("indeed", now, "", "not a date", "2 days ago", "2024-05-01"))
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), parsePostedAt("indeed", now, "2024-05-01", "2 days ago"))
+
+	// Unreadable or missing dates are the fetch time
+	assert.Equal(t, now, parsePostedAt("indeed", now, "sometime", ""))
+	assert.Equal(t, now, parsePostedAt("lever", now, epochString(0)))
+	assert.Equal(t, time.UnixMilli(1716024600000), parsePostedAt("lever", now, epochString(1716024600000)))
+}
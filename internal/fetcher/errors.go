@@ -0,0 +1,81 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Errors returned by the fetchers when an upstream API responds with a non-2xx
+// status. They are wrapped in a *StatusError; match them with errors.Is.
+var (
+	ErrRateLimited  = errors.New("rate limited by upstream")
+	ErrUnauthorized = errors.New("unauthorized by upstream")
+	ErrUpstream     = errors.New("upstream error")
+)
+
+// maxErrorBody bounds how much of an error response is kept for diagnostics
+const maxErrorBody = 512
+
+// StatusError describes a non-2xx response from an upstream API
+type StatusError struct {
+	Source     string
+	StatusCode int
+	Body       string
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("%s: %v (status %d)", e.Source, e.Err, e.StatusCode)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// checkStatus returns a *StatusError for non-2xx responses, reading (a bounded
+// part of) the body so it does not end up in json.Unmarshal
+func checkStatus(source string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	err := ErrUpstream
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		err = ErrRateLimited
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err = ErrUnauthorized
+	}
+
+	return &StatusError{
+		Source:     source,
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+		Err:        err,
+	}
+}
+
+// ErrorKind classifies a fetch error as "rate_limited", "unauthorized",
+// "upstream", "circuit_open" or "" for other errors (network, decoding)
+func ErrorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrUpstream):
+		return "upstream"
+	}
+	return ""
+}
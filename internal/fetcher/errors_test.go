@@ -0,0 +1,64 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+		kind   string
+	}{
+		{http.StatusTooManyRequests, ErrRateLimited, "rate_limited"},
+		{http.StatusUnauthorized, ErrUnauthorized, "unauthorized"},
+		{http.StatusForbidden, ErrUnauthorized, "unauthorized"},
+		{http.StatusInternalServerError, ErrUpstream, "upstream"},
+		{http.StatusNotFound, ErrUpstream, "upstream"},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{
+			StatusCode: tt.status,
+			Body:       io.NopCloser(strings.NewReader("<html>Service down</html>")),
+		}
+		err := checkStatus("indeed", resp)
+
+		var statusErr *StatusError
+		assert.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, tt.status, statusErr.StatusCode)
+		assert.Equal(t, "<html>Service down</html>", statusErr.Body)
+		assert.ErrorIs(t, err, tt.want)
+		assert.Equal(t, tt.kind, ErrorKind(err))
+	}
+
+	ok := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}
+	assert.NoError(t, checkStatus("indeed", ok))
+	assert.Equal(t, "", ErrorKind(fmt.Errorf("decoding failed")))
+}
+
+func TestFetchReturnsTypedErrorOnHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("<html>Invalid API key</html>"))
+	}))
+	defer server.Close()
+
+	fetcher := newRetryFetcher(server, 3)
+	fetcher.Config = createMockConfig(server.URL)
+	fetcher.client = &http.Client{Transport: &mockTransport{URL: server.URL, Client: server.Client()}}
+
+	_, err := fetcher.FetchJSearchJobs(context.Background())
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Contains(t, err.Error(), "jsearch: unauthorized by upstream (status 401)")
+}
@@ -5,11 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"Go9jaJobs/internal/config"
@@ -18,40 +19,41 @@ import (
 	"github.com/google/uuid"
 )
 
-// cacheResponse saves API responses to cache files for debugging
-func cacheResponse(filename string, data []byte) {
-	// Create a directory for cache files if it doesn't exist
-	cacheDir := "api_response_cache"
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		return // Silently fail if we can't create the cache directory
-	}
-
-	// Full path for the cache file
-	filePath := filepath.Join(cacheDir, filename)
-
-	// Write/update the file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		fmt.Printf("Failed to write cache file %s: %v\n", filePath, err)
-	}
-}
+// userAgent identifies the server to the sites it reads
+const userAgent = "GoJobsNG/1.0 (+https://gojobs-ng-web.vercel.app)"
 
 // JobFetcher fetches job data from various APIs
 type JobFetcher struct {
 	client *http.Client
 	Config *config.Config
+	retry  retryPolicy
+
+	// schemas are the structures of the latest response of each source
+	schemaMu sync.Mutex
+	schemas  map[string]ResponseSchema
+
+	// breakers are the circuit breakers of the sources failing since their
+	// last successful fetch
+	breakerMu sync.Mutex
+	breakers  map[string]*breaker
 }
 
 // NewJobFetcher creates a new JobFetcher instance
 func NewJobFetcher(config *config.Config) *JobFetcher {
-	// Ensure cache directory exists
-	os.MkdirAll("api_response_cache", 0755)
-
-	return &JobFetcher{
+	jf := &JobFetcher{
 		client: &http.Client{
 			Timeout: 180 * time.Second, // Increase timeout to 3 minutes
 		},
 		Config: config,
+		retry:  newRetryPolicy(config),
 	}
+
+	// Ensure cache directory exists
+	if jf.cacheEnabled() {
+		os.MkdirAll(jf.cacheDir(), 0755)
+	}
+
+	return jf
 }
 
 // containsAny checks if a string contains any of the given substrings
@@ -65,8 +67,41 @@ func containsAny(s string, substrings []string) bool {
 	return false
 }
 
-// FetchJSearchJobs fetches jobs from the JSearch API
+// defaultJobLifetime is how long a job stays listed when the provider gives no expiry
+const defaultJobLifetime = 30 * 24 * time.Hour
+
+// InferExpiry returns the provider supplied expiry date when it can be parsed,
+// otherwise the default lifetime counted from now
+func InferExpiry(validThrough string, now time.Time) time.Time {
+	if t, ok := parseTimestamp(strings.TrimSpace(validThrough)); ok {
+		return t
+	}
+	return now.Add(defaultJobLifetime)
+}
+
+// FetchJSearchJobs fetches the jobs of each track search query from the
+// JSearch API
 func (jf *JobFetcher) FetchJSearchJobs(ctx context.Context) ([]models.Job, error) {
+	if jf.isExampleMode() {
+		return syntheticJobs("jsearch", time.Now()), nil
+	}
+
+	return fetchEach(ctx, "jsearch", "query", jf.searchQueries(), jf.fetchJSearchQuery)
+}
+
+// jsearchPageSize is how many jobs a JSearch result page holds
+const jsearchPageSize = 10
+
+// fetchJSearchQuery fetches the Nigerian jobs of one search query from
+// JSearch, page by page
+func (jf *JobFetcher) fetchJSearchQuery(ctx context.Context, query string) ([]models.Job, error) {
+	return jf.paginate(ctx, "jsearch", jsearchPageSize, func(ctx context.Context, page, _ int) ([]models.Job, error) {
+		return jf.fetchJSearchPage(ctx, query, page)
+	})
+}
+
+// fetchJSearchPage fetches one result page of a search query from JSearch
+func (jf *JobFetcher) fetchJSearchPage(ctx context.Context, query string, page int) ([]models.Job, error) {
 	apiKey := jf.Config.RapidAPIKey
 
 	mode := jf.Config.Mode // Should be "dev" or "production" from .env
@@ -84,40 +119,37 @@ func (jf *JobFetcher) FetchJSearchJobs(ctx context.Context) ([]models.Job, error
 	}
 
 	q := req.URL.Query()
-	q.Add("query", "golang jobs in nigeria")
-	q.Add("page", "1")
-	q.Add("num_pages", "3")
+	q.Add("query", query+" jobs in nigeria")
+	q.Add("page", strconv.Itoa(page))
+	q.Add("num_pages", "1")
 	q.Add("country", "ng")
+	// Only the jobs posted since the last successful sync, when known
+	if datePosted := jsearchDatePosted(ctx, time.Now()); datePosted != "all" {
+		q.Add("date_posted", datePosted)
+	}
 	req.URL.RawQuery = q.Encode()
 
 	req.Header.Add("x-rapidapi-host", "jsearch.p.rapidapi.com")
 	req.Header.Add("x-rapidapi-key", apiKey)
 
-	resp, err := jf.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := jf.readResponse(req, "jsearch", "jsearch_response.json")
 	if err != nil {
 		return nil, err
 	}
-
-	// Cache the API response
-	cacheResponse("jsearch_response.json", body)
+	jf.recordSchema("jsearch", body)
 
 	var jsearchResp models.JSEARCHResponse
 	if err := json.Unmarshal(body, &jsearchResp); err != nil {
 		return nil, err
 	}
+	raw := rawItems(body, "data")
 
 	jobs := make([]models.Job, len(jsearchResp.Data))
 	for i, item := range jsearchResp.Data {
 		now := time.Now()
 		jobs[i] = models.Job{
 			ID:          uuid.New().String(),
-			JobID:       uuid.New().String(),
+			JobID:       item.ID,
 			Title:       item.JobTitle,
 			Company:     item.EmployerName,
 			CompanyURL:  item.CompanyURL,
@@ -126,31 +158,56 @@ func (jf *JobFetcher) FetchJSearchJobs(ctx context.Context) ([]models.Job, error
 			Description: item.JobDescription,
 			URL:         item.JobApplyLink,
 			Salary:      item.JobSalary,
-			PostedAt:    item.JobPostedAt,
+			PostedAt:    parsePostedAt("jsearch", now, item.JobPostedAt, epochString(item.JobPostedAtTimestamp)),
 			JobType:     item.JobType,
 			IsRemote:    item.JobIsRemote,
 			Source:      "jsearch",
-			RawData:     string(body),
+			RawData:     rawFragment(raw, i, item),
 			DateGotten:  now,
-			ExpDate:     now.AddDate(0, 1, 0), // Expires in 1 month
+			ExpDate:     InferExpiry("", now),
 		}
 	}
 
+	noteItems(ctx, len(jobs))
 	return jobs, nil
 }
 
-// FetchLinkedInJobs fetches jobs from LinkedIn API
+// FetchLinkedInJobs fetches the jobs of each track search query from the
+// LinkedIn API
 func (jf *JobFetcher) FetchLinkedInJobs(ctx context.Context) ([]models.Job, error) {
+	if jf.isExampleMode() {
+		return syntheticJobs("linkedin", time.Now()), nil
+	}
+
+	return fetchEach(ctx, "linkedin", "query", jf.searchQueries(), jf.fetchLinkedInQuery)
+}
+
+// linkedinPageSize is how many jobs are asked of the LinkedIn API per page,
+// the most it returns at once
+const linkedinPageSize = 100
+
+// fetchLinkedInQuery fetches the Nigerian jobs titled with one search query
+// from the LinkedIn API, page by page
+func (jf *JobFetcher) fetchLinkedInQuery(ctx context.Context, query string) ([]models.Job, error) {
+	return jf.paginate(ctx, "linkedin", linkedinPageSize, func(ctx context.Context, page, limit int) ([]models.Job, error) {
+		return jf.fetchLinkedInPage(ctx, query, (page-1)*linkedinPageSize, limit)
+	})
+}
+
+// fetchLinkedInPage fetches up to limit jobs titled with a search query from
+// the LinkedIn API, skipping the first offset
+func (jf *JobFetcher) fetchLinkedInPage(ctx context.Context, query string, offset, limit int) ([]models.Job, error) {
 	apiKey := jf.Config.RapidAPIKey
 
 	mode := jf.Config.Mode // "dev" or "production"
 
+	// The 24 hour endpoint suffices when the last successful sync is recent
+	endpoint := linkedinEndpoint(ctx, time.Now())
 	var apiURL string
 	if mode == "dev" {
-		apiURL = "http://localhost:8081/linkedin/active-jb-7d"
+		apiURL = "http://localhost:8081/linkedin/" + endpoint
 	} else {
-		// Updated URL to match the correct endpoint format
-		apiURL = "https://linkedin-job-search-api.p.rapidapi.com/active-jb-7d"
+		apiURL = "https://linkedin-job-search-api.p.rapidapi.com/" + endpoint
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
@@ -160,9 +217,9 @@ func (jf *JobFetcher) FetchLinkedInJobs(ctx context.Context) ([]models.Job, erro
 
 	q := req.URL.Query()
 	// Update query parameters to match the expected format
-	q.Add("limit", "20")
-	q.Add("offset", "0")
-	q.Add("title_filter", "golang")
+	q.Add("limit", strconv.Itoa(limit))
+	q.Add("offset", strconv.Itoa(offset))
+	q.Add("title_filter", query)
 	q.Add("location_filter", "nigeria")
 	req.URL.RawQuery = q.Encode()
 
@@ -171,19 +228,11 @@ func (jf *JobFetcher) FetchLinkedInJobs(ctx context.Context) ([]models.Job, erro
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
 
-	resp, err := jf.client.Do(req)
+	body, err := jf.readResponse(req, "linkedin", "linkedin_response.json")
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// Cache the API response
-	cacheResponse("linkedin_response.json", body)
+	jf.recordSchema("linkedin", body)
 
 	// Try unmarshaling into different structures based on the response format
 	// First, try unmarshaling as an array of items
@@ -192,6 +241,7 @@ func (jf *JobFetcher) FetchLinkedInJobs(ctx context.Context) ([]models.Job, erro
 		// Parsed as an array - process accordingly
 		jobs := make([]models.Job, len(jobArray))
 		now := time.Now()
+		raw := rawItems(body, "")
 
 		for i, item := range jobArray {
 			// Extract relevant fields from the map
@@ -225,22 +275,17 @@ func (jf *JobFetcher) FetchLinkedInJobs(ctx context.Context) ([]models.Job, erro
 				Description: description,
 				Location:    "Nigeria", // Default location
 				Source:      "linkedin",
-				RawData:     string(body),
+				RawData:     rawFragment(raw, i, item),
 				DateGotten:  now,
-				ExpDate:     now.AddDate(0, 1, 0), // Expires in 1 month
 			}
 
+			// Use the provider's validity date when present
+			validThrough, _ := item["date_validthrough"].(string)
+			jobs[i].ExpDate = InferExpiry(validThrough, now)
+
 			// Extract optional fields when available
-			if datePosted, ok := item["date_posted"].(string); ok {
-				postedAt, err := time.Parse("2006-01-02T15:04:05", datePosted)
-				if err != nil {
-					jobs[i].PostedAt = now
-				} else {
-					jobs[i].PostedAt = postedAt
-				}
-			} else {
-				jobs[i].PostedAt = now
-			}
+			datePosted, _ := item["date_posted"].(string)
+			jobs[i].PostedAt = parsePostedAt("linkedin", now, datePosted)
 
 			// Extract location information
 			if locationsArr, ok := item["locations_derived"].([]interface{}); ok && len(locationsArr) > 0 {
@@ -250,6 +295,7 @@ func (jf *JobFetcher) FetchLinkedInJobs(ctx context.Context) ([]models.Job, erro
 			}
 		}
 
+		noteItems(ctx, len(jobs))
 		return jobs, nil
 	}
 
@@ -283,6 +329,7 @@ func (jf *JobFetcher) FetchLinkedInJobs(ctx context.Context) ([]models.Job, erro
 
 	jobs := make([]models.Job, len(linkedinResp.Data))
 	now := time.Now()
+	raw := rawItems(body, "data")
 
 	for i, item := range linkedinResp.Data {
 		// Get location from locations_derived, countries_derived, or default to Nigeria
@@ -293,15 +340,7 @@ func (jf *JobFetcher) FetchLinkedInJobs(ctx context.Context) ([]models.Job, erro
 			location = item.CountriesDerived[0]
 		}
 
-		// Parse posted date
-		postedAt, err := time.Parse("2006-01-02T15:04:05", item.DatePosted)
-		if err != nil {
-			// Try another format
-			postedAt, err = time.Parse(time.RFC3339, item.DatePosted)
-			if err != nil {
-				postedAt = now // Use current time if parsing fails
-			}
-		}
+		postedAt := parsePostedAt("linkedin", now, item.DatePosted)
 
 		// Join employment types if present
 		employmentType := ""
@@ -322,18 +361,29 @@ func (jf *JobFetcher) FetchLinkedInJobs(ctx context.Context) ([]models.Job, erro
 			PostedAt:    postedAt,
 			IsRemote:    item.RemoteDerived,
 			Source:      "linkedin",
-			RawData:     string(body),
+			RawData:     rawFragment(raw, i, item),
 			DateGotten:  now,
-			ExpDate:     now.AddDate(0, 1, 0),        // Expires in 1 month
+			ExpDate:     InferExpiry(item.DateValidthrough, now),
 			Description: item.LinkedinOrgDescription, // Using org description as job description
 		}
 	}
 
+	noteItems(ctx, len(jobs))
 	return jobs, nil
 }
 
-// FetchIndeedJobs fetches jobs from the Indeed API via Apify
+// FetchIndeedJobs fetches the jobs of each track search query from the
+// Indeed API via Apify
 func (jf *JobFetcher) FetchIndeedJobs(ctx context.Context) ([]models.Job, error) {
+	if jf.isExampleMode() {
+		return syntheticJobs("apify indeed", time.Now()), nil
+	}
+
+	return fetchEach(ctx, "indeed", "query", jf.searchQueries(), jf.fetchIndeedQuery)
+}
+
+// fetchIndeedQuery fetches the Nigerian jobs of one search query from Indeed
+func (jf *JobFetcher) fetchIndeedQuery(ctx context.Context, query string) ([]models.Job, error) {
 	apifyToken := jf.Config.ApifyAPIKey
 
 	mode := jf.Config.Mode // "dev" or "production"
@@ -352,7 +402,7 @@ func (jf *JobFetcher) FetchIndeedJobs(ctx context.Context) ([]models.Job, error)
 		"followApplyRedirects":  false,
 		"maxItems":              20,
 		"parseCompanyDetails":   true,
-		"position":              "golang",
+		"position":              query,
 		"saveOnlyUniqueItems":   true,
 		"forceResponseEncoding": "utf-8",
 	}
@@ -368,19 +418,11 @@ func (jf *JobFetcher) FetchIndeedJobs(ctx context.Context) ([]models.Job, error)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := jf.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := jf.readResponse(req, "indeed", "indeed_response.json")
 	if err != nil {
 		return nil, err
 	}
-
-	// Cache the API response
-	cacheResponse("indeed_response.json", body)
+	jf.recordSchema("indeed", body)
 
 	// Check for error response first
 	var errorResp []map[string]interface{}
@@ -406,6 +448,7 @@ func (jf *JobFetcher) FetchIndeedJobs(ctx context.Context) ([]models.Job, error)
 
 	now := time.Now()
 	jobs := make([]models.Job, len(indeedResp))
+	raw := rawItems(body, "")
 
 	for i, item := range indeedResp {
 		jobType := ""
@@ -413,16 +456,12 @@ func (jf *JobFetcher) FetchIndeedJobs(ctx context.Context) ([]models.Job, error)
 			jobType = item.JobType[0]
 		}
 
-		// Parse the posting date
-		postedAt, err := time.Parse(time.RFC3339, item.PostingDateParsed)
-		if err != nil {
-			// Use the scrapedAt time if posting date parsing fails
-			postedAt, err = time.Parse(time.RFC3339, item.ScrapedAt)
-			if err != nil {
-				// Fallback to current time if both date parsings fail
-				postedAt = now
-			}
+		// postedAt is an age, e.g. "2 days ago", counted from the scrape
+		scrapedAt, ok := ParseDate(item.ScrapedAt, now)
+		if !ok {
+			scrapedAt = now
 		}
+		postedAt := parsePostedAt("indeed", scrapedAt, item.PostingDateParsed, item.PostedAt)
 
 		// Extract company logo if available
 		var companyLogo string
@@ -431,30 +470,47 @@ func (jf *JobFetcher) FetchIndeedJobs(ctx context.Context) ([]models.Job, error)
 		}
 
 		jobs[i] = models.Job{
-			ID:          uuid.New().String(),
-			JobID:       item.ID,
-			Title:       item.PositionName,
-			Company:     item.Company,
-			Location:    item.Location,
-			CompanyLogo: companyLogo,
-			Description: item.Description,
-			URL:         item.URL,
-			Salary:      item.Salary,
-			PostedAt:    postedAt,
-			JobType:     jobType,
-			IsRemote:    containsAny(item.Description, []string{"remote", "work from home", "wfh"}),
-			Source:      "apify indeed",
-			RawData:     string(body),
-			DateGotten:  now,
-			ExpDate:     now.AddDate(0, 1, 0), // Expires in 1 month
+			ID:              uuid.New().String(),
+			JobID:           item.ID,
+			Title:           item.PositionName,
+			Company:         item.Company,
+			Location:        item.Location,
+			CompanyLogo:     companyLogo,
+			Description:     item.Description,
+			DescriptionHTML: item.DescriptionHTML,
+			URL:             item.URL,
+			Salary:          item.Salary,
+			PostedAt:        postedAt,
+			JobType:         jobType,
+			IsRemote:        containsAny(item.Description, []string{"remote", "work from home", "wfh"}),
+			Source:          "apify indeed",
+			RawData:         rawFragment(raw, i, item),
+			DateGotten:      now,
+			ExpDate:         InferExpiry("", now),
+		}
+
+		// Indeed flags postings that are already closed, expire them immediately
+		if item.IsExpired {
+			jobs[i].ExpDate = now
 		}
 	}
 
+	noteItems(ctx, len(jobs))
 	return jobs, nil
 }
 
-// Fetch from apify linkedin
+// FetchApifyLinkedInJobs fetches the jobs of each track search query from
+// the LinkedIn scraper of Apify
 func (jf *JobFetcher) FetchApifyLinkedInJobs(ctx context.Context) ([]models.Job, error) {
+	if jf.isExampleMode() {
+		return syntheticJobs("apify linkedin", time.Now()), nil
+	}
+
+	return fetchEach(ctx, "apify_linkedin", "query", jf.searchQueries(), jf.fetchApifyLinkedInQuery)
+}
+
+// fetchApifyLinkedInQuery fetches the jobs of one LinkedIn search in Nigeria
+func (jf *JobFetcher) fetchApifyLinkedInQuery(ctx context.Context, query string) ([]models.Job, error) {
 	apifyToken := jf.Config.ApifyAPIKey
 
 	mode := jf.Config.Mode
@@ -469,7 +525,7 @@ func (jf *JobFetcher) FetchApifyLinkedInJobs(ctx context.Context) ([]models.Job,
 
 	// Prepare request payload
 	payload := map[string]interface{}{
-		"urls":                  []string{"https://www.linkedin.com/jobs/search/?distance=25&geoId=105365761&keywords=golang"},
+		"urls":                  []string{"https://www.linkedin.com/jobs/search/?distance=25&geoId=105365761&keywords=" + url.QueryEscape(query)},
 		"scrapeCompany":         true,
 		"forceResponseEncoding": "utf-8",
 		"maxItems":              20,
@@ -486,19 +542,11 @@ func (jf *JobFetcher) FetchApifyLinkedInJobs(ctx context.Context) ([]models.Job,
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := jf.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	body, err := jf.readResponse(req, "apify_linkedin", "apify_linkedin_response.json")
 	if err != nil {
 		return nil, err
 	}
-
-	// Cache the API response
-	cacheResponse("apify_linkedin_response.json", body)
+	jf.recordSchema("apify_linkedin", body)
 
 	// Try to unmarshal as ApifyLinkedInResponse (array of jobs)
 	var linkedInResp models.ApifyLinkedInResponse
@@ -523,6 +571,7 @@ func (jf *JobFetcher) FetchApifyLinkedInJobs(ctx context.Context) ([]models.Job,
 
 	now := time.Now()
 	jobs := make([]models.Job, len(linkedInResp))
+	raw := rawItems(body, "")
 
 	for i, item := range linkedInResp {
 		salary := ""
@@ -530,11 +579,7 @@ func (jf *JobFetcher) FetchApifyLinkedInJobs(ctx context.Context) ([]models.Job,
 			salary = item.SalaryInfo[0]
 		}
 
-		// Parse posted date (format is likely YYYY-MM-DD)
-		postedAt, err := time.Parse("2006-01-02", item.PostedAt)
-		if err != nil {
-			postedAt = now // Use current time if parsing fails
-		}
+		postedAt := parsePostedAt("apify", now, item.PostedAt)
 
 		// Get the company website (either from CompanyWebsite or extract from LinkedIn URL)
 		companyURL := item.CompanyWebsite
@@ -543,25 +588,27 @@ func (jf *JobFetcher) FetchApifyLinkedInJobs(ctx context.Context) ([]models.Job,
 		}
 
 		jobs[i] = models.Job{
-			ID:          uuid.New().String(),
-			JobID:       item.ID,
-			Title:       item.Title,
-			Company:     item.CompanyName,
-			CompanyURL:  companyURL,
-			CompanyLogo: item.CompanyLogo,
-			Location:    item.Location,
-			Description: item.DescriptionText,
-			URL:         item.Link,
-			Salary:      salary,
-			JobType:     item.EmploymentType,
-			IsRemote:    containsAny(item.DescriptionText, []string{"remote", "work from home", "wfh"}),
-			Source:      "apify linkedin",
-			PostedAt:    postedAt,
-			RawData:     string(body),
-			DateGotten:  now,
-			ExpDate:     now.AddDate(0, 1, 0), // Expires in 1 month
+			ID:              uuid.New().String(),
+			JobID:           item.ID,
+			Title:           item.Title,
+			Company:         item.CompanyName,
+			CompanyURL:      companyURL,
+			CompanyLogo:     item.CompanyLogo,
+			Location:        item.Location,
+			Description:     item.DescriptionText,
+			DescriptionHTML: item.DescriptionHtml,
+			URL:             item.Link,
+			Salary:          salary,
+			JobType:         item.EmploymentType,
+			IsRemote:        containsAny(item.DescriptionText, []string{"remote", "work from home", "wfh"}),
+			Source:          "apify linkedin",
+			PostedAt:        postedAt,
+			RawData:         rawFragment(raw, i, item),
+			DateGotten:      now,
+			ExpDate:         InferExpiry("", now),
 		}
 	}
 
+	noteItems(ctx, len(jobs))
 	return jobs, nil
 }
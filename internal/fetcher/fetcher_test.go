@@ -37,6 +37,16 @@ func setupTestServer(t *testing.T, handlers map[string]http.HandlerFunc) *httpte
 	return server
 }
 
+// removeCached removes the responses cached under filename by a test
+func removeCached(filename string) {
+	entries, _ := os.ReadDir(defaultCacheDir)
+	for _, entry := range entries {
+		if cacheKey(entry.Name()) == filename {
+			os.Remove(filepath.Join(defaultCacheDir, entry.Name()))
+		}
+	}
+}
+
 // createMockConfig creates a config with the test server URL
 func createMockConfig(serverURL string) *config.Config {
 	return &config.Config{
@@ -58,18 +68,19 @@ func mockJSearchResponse(t *testing.T) http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(models.JSEARCHResponse{
 			Data: []struct {
-				ID             string    `json:"job_id"`
-				JobTitle       string    `json:"job_title"`
-				EmployerName   string    `json:"employer_name"`
-				CompanyURL     string    `json:"employer_website"`
-				EmployerLogo   string    `json:"employer_logo"`
-				JobLocation    string    `json:"job_location"`
-				JobDescription string    `json:"job_description"`
-				JobApplyLink   string    `json:"job_apply_link"`
-				JobSalary      string    `json:"job_salary"`
-				JobPostedAt    time.Time `json:"job_posted_at_datetime_utc"`
-				JobType        string    `json:"job_employment_type"`
-				JobIsRemote    bool      `json:"job_is_remote"`
+				ID                   string `json:"job_id"`
+				JobTitle             string `json:"job_title"`
+				EmployerName         string `json:"employer_name"`
+				CompanyURL           string `json:"employer_website"`
+				EmployerLogo         string `json:"employer_logo"`
+				JobLocation          string `json:"job_location"`
+				JobDescription       string `json:"job_description"`
+				JobApplyLink         string `json:"job_apply_link"`
+				JobSalary            string `json:"job_salary"`
+				JobPostedAt          string `json:"job_posted_at_datetime_utc"`
+				JobPostedAtTimestamp int64  `json:"job_posted_at_timestamp"`
+				JobType              string `json:"job_employment_type"`
+				JobIsRemote          bool   `json:"job_is_remote"`
 			}{
 				{
 					ID:             "job123",
@@ -81,7 +92,7 @@ func mockJSearchResponse(t *testing.T) http.HandlerFunc {
 					JobDescription: "We need a Go developer to work on exciting projects",
 					JobApplyLink:   "https://testcompany.com/apply",
 					JobSalary:      "$50K-$70K",
-					JobPostedAt:    time.Now().Add(-24 * time.Hour),
+					JobPostedAt:    time.Now().Add(-24 * time.Hour).Format(time.RFC3339),
 					JobType:        "Full-time",
 					JobIsRemote:    true,
 				},
@@ -303,6 +314,7 @@ func TestFetchJSearchJobs(t *testing.T) {
 	assert.Len(t, jobs, 1)
 
 	job := jobs[0]
+	assert.Equal(t, "job123", job.JobID)
 	assert.Equal(t, "Golang Developer", job.Title)
 	assert.Equal(t, "Test Company", job.Company)
 	assert.Equal(t, "https://testcompany.com/logo.png", job.CompanyLogo)
@@ -311,12 +323,11 @@ func TestFetchJSearchJobs(t *testing.T) {
 	assert.True(t, job.IsRemote)
 
 	// Check that cache file was created
-	cachePath := filepath.Join("api_response_cache", "jsearch_response.json")
-	_, err = os.Stat(cachePath)
-	assert.NoError(t, err)
+	_, ok := latestCached(defaultCacheDir, "jsearch_response.json")
+	assert.True(t, ok)
 
 	// Clean up
-	os.Remove(cachePath)
+	removeCached("jsearch_response.json")
 }
 
 func TestFetchLinkedInJobs(t *testing.T) {
@@ -365,12 +376,11 @@ func TestFetchLinkedInJobs(t *testing.T) {
 	assert.Equal(t, "linkedin", job.Source)
 
 	// Check that cache file was created
-	cachePath := filepath.Join("api_response_cache", "linkedin_response.json")
-	_, err = os.Stat(cachePath)
-	assert.NoError(t, err)
+	_, ok := latestCached(defaultCacheDir, "linkedin_response.json")
+	assert.True(t, ok)
 
 	// Clean up
-	os.Remove(cachePath)
+	removeCached("linkedin_response.json")
 }
 
 func TestContainsAny(t *testing.T) {
@@ -386,6 +396,19 @@ func TestContainsAny(t *testing.T) {
 	assert.False(t, containsAny("remotework", []string{"remote work"})) // Not matching the exact substring
 }
 
+func TestInferExpiry(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Provider dates in any supported layout are used as is
+	assert.Equal(t, time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC), InferExpiry("2025-04-15", now))
+	assert.Equal(t, time.Date(2025, 4, 15, 10, 30, 0, 0, time.UTC), InferExpiry("2025-04-15T10:30:00", now))
+	assert.Equal(t, time.Date(2025, 4, 15, 10, 30, 0, 0, time.UTC), InferExpiry("2025-04-15T10:30:00Z", now))
+
+	// Missing or unparseable dates fall back to the default lifetime
+	assert.Equal(t, now.Add(defaultJobLifetime), InferExpiry("", now))
+	assert.Equal(t, now.Add(defaultJobLifetime), InferExpiry("next month", now))
+}
+
 func TestFetchIndeedJobs(t *testing.T) {
 	// Setup test server
 	handlers := map[string]http.HandlerFunc{
@@ -432,14 +455,14 @@ func TestFetchIndeedJobs(t *testing.T) {
 	assert.Equal(t, "$60K-$80K", job.Salary)
 	assert.Equal(t, "Full-time", job.JobType)
 	assert.Equal(t, "apify indeed", job.Source)
+	assert.True(t, job.ExpDate.After(time.Now()), "open postings keep the default expiry")
 
 	// Check that cache file was created
-	cachePath := filepath.Join("api_response_cache", "indeed_response.json")
-	_, err = os.Stat(cachePath)
-	assert.NoError(t, err)
+	_, ok := latestCached(defaultCacheDir, "indeed_response.json")
+	assert.True(t, ok)
 
 	// Clean up
-	os.Remove(cachePath)
+	removeCached("indeed_response.json")
 }
 
 func TestFetchApifyLinkedInJobs(t *testing.T) {
@@ -490,10 +513,9 @@ func TestFetchApifyLinkedInJobs(t *testing.T) {
 	assert.Contains(t, job.Description, "Go developers with experience")
 
 	// Check that cache file was created
-	cachePath := filepath.Join("api_response_cache", "apify_linkedin_response.json")
-	_, err = os.Stat(cachePath)
-	assert.NoError(t, err)
+	_, ok := latestCached(defaultCacheDir, "apify_linkedin_response.json")
+	assert.True(t, ok)
 
 	// Clean up
-	os.Remove(cachePath)
+	removeCached("apify_linkedin_response.json")
 }
@@ -0,0 +1,95 @@
+package fetcher
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// incrementalOverlap widens the window of incremental fetches, so jobs an
+// API indexes a little after they were posted are not missed
+const incrementalOverlap = time.Hour
+
+// sinceKey is the context key carrying the start of an incremental fetch
+type sinceKey struct{}
+
+// WithSince asks the fetchers run with ctx for the jobs posted since t only,
+// where their API filters by date; the others fetch as usual
+func WithSince(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, sinceKey{}, t)
+}
+
+// Completeness records whether a fetch read every job of its window. A
+// dropped search query, a failed later page or a page or job limit leave
+// jobs unread, which the next incremental fetch must reach back for.
+type Completeness struct {
+	mu     sync.Mutex
+	reason string
+}
+
+// Complete reports whether nothing was left unread, and why not
+func (c *Completeness) Complete() (bool, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reason == "", c.reason
+}
+
+// completenessKey is the context key carrying the Completeness of a fetch
+type completenessKey struct{}
+
+// WithCompleteness records in c whether the fetchers run with ctx read
+// every job of their window
+func WithCompleteness(ctx context.Context, c *Completeness) context.Context {
+	return context.WithValue(ctx, completenessKey{}, c)
+}
+
+// noteIncomplete records that the fetch of ctx left jobs unread, keeping
+// the first reason
+func noteIncomplete(ctx context.Context, reason string) {
+	c, ok := ctx.Value(completenessKey{}).(*Completeness)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reason == "" {
+		c.reason = reason
+	}
+}
+
+// fetchWindow returns how far back a fetch run with ctx must reach, overlap
+// included; ok is false for a full fetch
+func fetchWindow(ctx context.Context, now time.Time) (window time.Duration, ok bool) {
+	since, ok := ctx.Value(sinceKey{}).(time.Time)
+	if !ok || since.IsZero() {
+		return 0, false
+	}
+	return now.Sub(since) + incrementalOverlap, true
+}
+
+// jsearchDatePosted returns the JSearch date_posted filter covering the
+// window of ctx, "all" for a full fetch
+func jsearchDatePosted(ctx context.Context, now time.Time) string {
+	window, ok := fetchWindow(ctx, now)
+	switch {
+	case !ok:
+		return "all"
+	case window <= 3*24*time.Hour:
+		return "3days"
+	case window <= 7*24*time.Hour:
+		return "week"
+	case window <= 30*24*time.Hour:
+		return "month"
+	default:
+		return "all"
+	}
+}
+
+// linkedinEndpoint returns the LinkedIn API endpoint covering the window of
+// ctx: the jobs of the last 24 hours, or of the last 7 days for a full fetch
+func linkedinEndpoint(ctx context.Context, now time.Time) string {
+	if window, ok := fetchWindow(ctx, now); ok && window <= 24*time.Hour {
+		return "active-jb-24h"
+	}
+	return "active-jb-7d"
+}
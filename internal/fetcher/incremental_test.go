@@ -0,0 +1,36 @@
+package fetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncrementalWindow(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// Full fetches without a last successful sync
+	assert.Equal(t, "all", jsearchDatePosted(ctx, now))
+	assert.Equal(t, "active-jb-7d", linkedinEndpoint(ctx, now))
+
+	tests := []struct {
+		since    time.Duration
+		jsearch  string
+		linkedin string
+	}{
+		{6 * time.Hour, "3days", "active-jb-24h"},
+		// The overlap widens the window past 24 hours
+		{23*time.Hour + 30*time.Minute, "3days", "active-jb-7d"},
+		{5 * 24 * time.Hour, "week", "active-jb-7d"},
+		{20 * 24 * time.Hour, "month", "active-jb-7d"},
+		{90 * 24 * time.Hour, "all", "active-jb-7d"},
+	}
+	for _, tt := range tests {
+		ctx := WithSince(context.Background(), now.Add(-tt.since))
+		assert.Equal(t, tt.jsearch, jsearchDatePosted(ctx, now), tt.since)
+		assert.Equal(t, tt.linkedin, linkedinEndpoint(ctx, now), tt.since)
+	}
+}
@@ -0,0 +1,254 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/google/uuid"
+	"github.com/temoto/robotstxt"
+)
+
+// jobbermanBroadQueries are searched besides the track queries, their
+// results kept only when they are track roles
+var jobbermanBroadQueries = []string{"backend developer"}
+
+// Selectors of Jobberman's markup, for the search result cards and the job page
+const (
+	jobbermanCardSelector    = `[data-cy="listing-cards-components"]`
+	jobbermanTitleSelector   = `[data-cy="listing-title-link"]`
+	jobbermanCompanySelector = `p.text-link-500`
+	jobbermanMetaSelector    = `.flex-wrap span`
+	jobbermanAgeSelector     = `p.text-gray-500`
+	jobbermanDetailSelector  = `article.job__details`
+)
+
+// jobbermanCrawler reads Jobberman politely: it skips the paths robots.txt
+// disallows and waits between requests
+type jobbermanCrawler struct {
+	jf     *JobFetcher
+	robots *robotstxt.Group
+	delay  time.Duration
+	last   time.Time
+}
+
+// FetchJobbermanJobs scrapes the track roles of the Jobberman search results
+// and their job pages. Jobberman has no public API.
+func (jf *JobFetcher) FetchJobbermanJobs(ctx context.Context) ([]models.Job, error) {
+	if jf.isExampleMode() {
+		return syntheticJobs("jobberman", time.Now()), nil
+	}
+	// Jobberman pages are scraped one by one and not cached
+	if jf.isReplayMode() {
+		return nil, fmt.Errorf("jobberman: %w", ErrNotCached)
+	}
+
+	var baseURL string
+	if jf.Config.Mode == "dev" {
+		baseURL = "http://localhost:8081/jobberman"
+	} else {
+		baseURL = "https://www.jobberman.com"
+	}
+
+	c := &jobbermanCrawler{jf: jf, delay: jf.Config.JobbermanDelay}
+	if err := c.loadRobots(ctx, baseURL+"/robots.txt"); err != nil {
+		return nil, err
+	}
+
+	maxPages := jf.Config.JobbermanMaxPages
+	if maxPages < 1 {
+		maxPages = 1
+	}
+
+	now := time.Now()
+	roles := newRoleFilter(jf.Config.ActiveTracks())
+	jobs := []models.Job{}
+	seen := map[string]bool{}
+	var searched bool
+	var searchErr error
+	for _, query := range append(jf.searchQueries(), jobbermanBroadQueries...) {
+		for page := 1; page <= maxPages; page++ {
+			searchURL := fmt.Sprintf("%s/jobs?q=%s&page=%d", baseURL, url.QueryEscape(query), page)
+			doc, err := c.get(ctx, searchURL)
+			if err != nil {
+				log.Printf("Error fetching Jobberman search %q page %d: %v", query, page, err)
+				searchErr = err
+				break
+			}
+			searched = true
+
+			cards := doc.Find(jobbermanCardSelector)
+			if cards.Length() == 0 {
+				break
+			}
+
+			cards.Each(func(_ int, card *goquery.Selection) {
+				job, ok := parseJobbermanCard(card, doc.Url, now)
+				if !ok || seen[job.URL] {
+					return
+				}
+				seen[job.URL] = true
+
+				detail, err := c.get(ctx, job.URL)
+				if err != nil {
+					log.Printf("Error fetching Jobberman job %s: %v", job.URL, err)
+					return
+				}
+				description := detail.Find(jobbermanDetailSelector).First()
+				job.DescriptionHTML, _ = description.Html()
+				job.Description = analyzer.HTMLToText(job.DescriptionHTML)
+
+				if roles.matches(job.Title, job.Description) {
+					jobs = append(jobs, job)
+				}
+			})
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// The sync fails only when no search could be read
+	if !searched && searchErr != nil {
+		return nil, searchErr
+	}
+	return jobs, nil
+}
+
+// loadRobots reads the rules of robots.txt for our user agent and raises the
+// delay to its Crawl-delay. A missing robots.txt allows everything; one the
+// server fails to serve disallows everything.
+func (c *jobbermanCrawler) loadRobots(ctx context.Context, robotsURL string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.jf.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	c.last = time.Now()
+
+	robots, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return fmt.Errorf("robots.txt: %w", err)
+	}
+
+	c.robots = robots.FindGroup(userAgent)
+	if c.robots.CrawlDelay > c.delay {
+		c.delay = c.robots.CrawlDelay
+	}
+	return nil
+}
+
+// get fetches and parses a page robots.txt allows, waiting out the delay
+// since the previous request
+func (c *jobbermanCrawler) get(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if c.robots != nil && !c.robots.Test(u.EscapedPath()) {
+		return nil, fmt.Errorf("%s is disallowed by robots.txt", u.EscapedPath())
+	}
+
+	if wait := c.delay - time.Since(c.last); wait > 0 {
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := c.jf.do(req)
+	c.last = time.Now()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus("jobberman", resp); err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("html parse error: %w", err)
+	}
+	doc.Url = u
+	return doc, nil
+}
+
+// parseJobbermanCard reads a search result card; the description comes from
+// the job page. ok is false for cards without a title or link.
+func parseJobbermanCard(card *goquery.Selection, pageURL *url.URL, now time.Time) (models.Job, bool) {
+	link := card.Find(jobbermanTitleSelector).First()
+	title := strings.TrimSpace(link.Text())
+	href, _ := link.Attr("href")
+	if title == "" || href == "" {
+		return models.Job{}, false
+	}
+
+	jobURL, err := pageURL.Parse(href)
+	if err != nil {
+		return models.Job{}, false
+	}
+
+	var meta []string
+	card.Find(jobbermanMetaSelector).Each(func(_ int, s *goquery.Selection) {
+		if text := strings.Join(strings.Fields(s.Text()), " "); text != "" {
+			meta = append(meta, text)
+		}
+	})
+
+	job := models.Job{
+		ID:         uuid.New().String(),
+		JobID:      path.Base(jobURL.Path),
+		Title:      title,
+		Company:    strings.TrimSpace(card.Find(jobbermanCompanySelector).First().Text()),
+		URL:        jobURL.String(),
+		PostedAt:   jobbermanPostedAt(card.Find(jobbermanAgeSelector).Text(), now),
+		Source:     "jobberman",
+		DateGotten: now,
+		ExpDate:    InferExpiry("", now),
+	}
+	job.RawData, _ = goquery.OuterHtml(card)
+
+	// The meta line holds the location, the job type and, when disclosed, the salary
+	for i, text := range meta {
+		switch {
+		case strings.Contains(text, "NGN") || strings.Contains(text, "₦"):
+			job.Salary = text
+		case i == 0:
+			job.Location = text
+		case job.JobType == "":
+			job.JobType = text
+		}
+	}
+	job.IsRemote = containsAny(job.Location+" "+job.JobType, []string{"remote"})
+
+	return job, true
+}
+
+// jobbermanPostedAt turns the relative age of a search result, e.g. "New" or
+// "3 days ago", into a time; unknown ages are taken as now
+func jobbermanPostedAt(age string, now time.Time) time.Time {
+	return parsePostedAt("jobberman", now, age)
+}
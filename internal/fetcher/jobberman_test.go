@@ -0,0 +1,130 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// jobbermanSearchPage is a Jobberman search result page with a Go role, a
+// Node role and a role whose page robots.txt disallows
+const jobbermanSearchPage = `<html><body>
+<div data-cy="listing-cards-components">
+	<a data-cy="listing-title-link" href="/listings/golang-engineer-x7k2p9"><p>Golang Engineer</p></a>
+	<p class="text-sm text-link-500">Moniepoint</p>
+	<div class="flex flex-wrap"><span>Lagos</span><span> Full Time </span><span>NGN 900,000 - 1,500,000</span></div>
+	<p class="text-gray-500">3 days ago</p>
+</div>
+<div data-cy="listing-cards-components">
+	<a data-cy="listing-title-link" href="/listings/backend-developer-q4m1z3"><p>Backend Developer</p></a>
+	<p class="text-sm text-link-500">Kuda</p>
+	<div class="flex flex-wrap"><span>Remote (Work From Home)</span><span>Contract</span></div>
+	<p class="text-gray-500">New</p>
+</div>
+<div data-cy="listing-cards-components">
+	<a data-cy="listing-title-link" href="/private/go-developer"><p>Go Developer</p></a>
+</div>
+</body></html>`
+
+// hostTransport sends requests to a test server, keeping their path and query
+type hostTransport struct {
+	URL    *url.URL
+	Client *http.Client
+}
+
+func (h *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme, req.URL.Host = h.URL.Scheme, h.URL.Host
+	return h.Client.Transport.RoundTrip(req)
+}
+
+func TestFetchJobbermanJobs(t *testing.T) {
+	var requested []string
+	server := setupTestServer(t, map[string]http.HandlerFunc{
+		"/jobberman/robots.txt": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("User-agent: *\nDisallow: /private/\nCrawl-delay: 0\n"))
+		},
+		"/jobberman/jobs": func(w http.ResponseWriter, r *http.Request) {
+			requested = append(requested, r.URL.RequestURI())
+			if r.URL.Query().Get("page") != "1" {
+				w.Write([]byte(`<html><body>No results</body></html>`))
+				return
+			}
+			w.Write([]byte(jobbermanSearchPage))
+		},
+		"/listings/golang-engineer-x7k2p9": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, userAgent, r.Header.Get("User-Agent"))
+			w.Write([]byte(`<article class="job__details"><p>Build our core banking services in Go.</p></article>`))
+		},
+		"/listings/backend-developer-q4m1z3": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<article class="job__details"><p>Node.js and TypeScript.</p></article>`))
+		},
+		"/private/": func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("robots.txt disallowed %s", r.URL.Path)
+		},
+	})
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	cfg := createMockConfig(server.URL)
+	cfg.JobbermanDelay = time.Millisecond
+	cfg.JobbermanMaxPages = 2
+	fetcher := NewJobFetcher(cfg)
+	fetcher.client = &http.Client{Transport: &hostTransport{URL: serverURL, Client: server.Client()}}
+
+	jobs, err := fetcher.FetchJobbermanJobs(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	job := jobs[0]
+	assert.Equal(t, "golang-engineer-x7k2p9", job.JobID)
+	assert.Equal(t, "Golang Engineer", job.Title)
+	assert.Equal(t, "Moniepoint", job.Company)
+	assert.Equal(t, "Lagos", job.Location)
+	assert.Equal(t, "Full Time", job.JobType)
+	assert.Equal(t, "NGN 900,000 - 1,500,000", job.Salary)
+	assert.Equal(t, "Build our core banking services in Go.", job.Description)
+	assert.Equal(t, "http://localhost:8081/listings/golang-engineer-x7k2p9", job.URL)
+	assert.Equal(t, "jobberman", job.Source)
+	assert.False(t, job.IsRemote)
+
+	// Each query stops at its first empty page
+	assert.Equal(t, []string{
+		"/jobberman/jobs?q=golang&page=1",
+		"/jobberman/jobs?q=golang&page=2",
+		"/jobberman/jobs?q=backend+developer&page=1",
+		"/jobberman/jobs?q=backend+developer&page=2",
+	}, requested)
+}
+
+func TestFetchJobbermanJobsDisallowed(t *testing.T) {
+	server := setupTestServer(t, map[string]http.HandlerFunc{
+		"/jobberman/robots.txt": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("User-agent: GoJobsNG\nDisallow: /\n"))
+		},
+		"/jobberman/jobs": func(w http.ResponseWriter, r *http.Request) {
+			t.Error("robots.txt disallowed the search")
+		},
+	})
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	fetcher := NewJobFetcher(createMockConfig(server.URL))
+	fetcher.client = &http.Client{Transport: &hostTransport{URL: serverURL, Client: server.Client()}}
+
+	_, err := fetcher.FetchJobbermanJobs(context.Background())
+	assert.ErrorContains(t, err, "disallowed by robots.txt")
+}
+
+func TestJobbermanPostedAt(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, now, jobbermanPostedAt("New", now))
+	assert.Equal(t, now.AddDate(0, 0, -1), jobbermanPostedAt("Yesterday", now))
+	assert.Equal(t, now.Add(-5*time.Hour), jobbermanPostedAt("5 hours ago", now))
+	assert.Equal(t, now.AddDate(0, 0, -3), jobbermanPostedAt("3 days ago", now))
+	assert.Equal(t, now.AddDate(0, 0, -14), jobbermanPostedAt("2 weeks ago", now))
+	assert.Equal(t, now.AddDate(0, -1, 0), jobbermanPostedAt("1 month ago", now))
+}
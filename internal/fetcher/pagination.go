@@ -0,0 +1,83 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"Go9jaJobs/internal/models"
+)
+
+// pageLimits returns how many result pages and jobs are read per search
+// query of source: at least one page, and any number of jobs when maxItems
+// is 0
+func (jf *JobFetcher) pageLimits(source string) (maxPages, maxItems int) {
+	maxPages, maxItems = jf.Config.FetchMaxPages, jf.Config.FetchMaxItems
+	if n, ok := jf.Config.FetchMaxPagesBySource[source]; ok {
+		maxPages = n
+	}
+	if n, ok := jf.Config.FetchMaxItemsBySource[source]; ok {
+		maxItems = n
+	}
+	if maxPages < 1 {
+		maxPages = 1
+	}
+	return maxPages, maxItems
+}
+
+// paginate reads the result pages of a search through fetchPage, from page 1
+// on, until a page holds fewer than pageSize jobs or the page and job limits
+// of source are reached, pausing FetchPageDelay between pages. fetchPage is
+// passed the page number and how many jobs are still wanted, for APIs taking
+// an offset and limit. An error on a later page ends the search with the jobs
+// read so far; it and the limits mark the fetch of ctx incomplete. Replays
+// read a single page, the latest response cached: the last page of the
+// latest search, since all pages are cached under one name.
+func (jf *JobFetcher) paginate(ctx context.Context, source string, pageSize int, fetchPage func(ctx context.Context, page, limit int) ([]models.Job, error)) ([]models.Job, error) {
+	maxPages, maxItems := jf.pageLimits(source)
+
+	var jobs []models.Job
+	for page := 1; page <= maxPages; page++ {
+		limit := pageSize
+		if maxItems > 0 && maxItems-len(jobs) < limit {
+			limit = maxItems - len(jobs)
+		}
+
+		if page > 1 && jf.Config.FetchPageDelay > 0 {
+			if err := sleepContext(ctx, jf.Config.FetchPageDelay); err != nil {
+				return nil, err
+			}
+		}
+
+		pageJobs, err := fetchPage(ctx, page, limit)
+		if err != nil {
+			if page == 1 || ctx.Err() != nil {
+				return nil, err
+			}
+			log.Printf("Error fetching %s page %d, keeping the %d jobs of earlier pages: %v", source, page, len(jobs), err)
+			noteIncomplete(ctx, fmt.Sprintf("%s page %d failed", source, page))
+			return jobs, nil
+		}
+		if len(pageJobs) > limit {
+			pageJobs = pageJobs[:limit]
+		}
+		jobs = append(jobs, pageJobs...)
+
+		// Only the latest page is cached
+		if jf.isReplayMode() {
+			break
+		}
+		// Jobs past the limits are left for a later sync
+		if maxItems > 0 && len(jobs) >= maxItems {
+			noteIncomplete(ctx, fmt.Sprintf("%s stopped at %d jobs", source, maxItems))
+			break
+		}
+		if len(pageJobs) < pageSize {
+			break
+		}
+		if page == maxPages {
+			noteIncomplete(ctx, fmt.Sprintf("%s stopped at %d pages", source, maxPages))
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,122 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedJobs returns a fetchPage serving total jobs in pages of pageSize,
+// recording the pages and limits it was asked for
+func pagedJobs(total, pageSize int, calls *[][2]int) func(context.Context, int, int) ([]models.Job, error) {
+	return func(_ context.Context, page, limit int) ([]models.Job, error) {
+		*calls = append(*calls, [2]int{page, limit})
+		var jobs []models.Job
+		for i := (page - 1) * pageSize; i < total && i < page*pageSize; i++ {
+			jobs = append(jobs, models.Job{JobID: fmt.Sprintf("job-%d", i)})
+		}
+		return jobs, nil
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	jf := &JobFetcher{Config: &config.Config{FetchMaxPages: 5}}
+	ctx := context.Background()
+
+	// Stops at the first short page
+	var calls [][2]int
+	jobs, err := jf.paginate(ctx, "linkedin", 10, pagedJobs(25, 10, &calls))
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 25)
+	assert.Equal(t, [][2]int{{1, 10}, {2, 10}, {3, 10}}, calls)
+
+	// Stops at the page limit, overridden per source
+	jf.Config.FetchMaxPagesBySource = map[string]int{"linkedin": 2}
+	calls = nil
+	jobs, err = jf.paginate(ctx, "linkedin", 10, pagedJobs(100, 10, &calls))
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 20)
+	assert.Len(t, calls, 2)
+
+	// Asks for and keeps no more than the job limit
+	jf.Config.FetchMaxItems = 15
+	calls = nil
+	jobs, err = jf.paginate(ctx, "jsearch", 10, pagedJobs(100, 10, &calls))
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 15)
+	assert.Equal(t, [][2]int{{1, 10}, {2, 5}}, calls)
+}
+
+func TestPaginateErrors(t *testing.T) {
+	jf := &JobFetcher{Config: &config.Config{FetchMaxPages: 3}}
+	ctx := context.Background()
+	failure := errors.New("rate limited")
+
+	// A failing first page fails the search
+	_, err := jf.paginate(ctx, "jsearch", 10, func(context.Context, int, int) ([]models.Job, error) {
+		return nil, failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	// A failing later page keeps the jobs read before it
+	var calls [][2]int
+	full := pagedJobs(100, 10, &calls)
+	jobs, err := jf.paginate(ctx, "jsearch", 10, func(ctx context.Context, page, limit int) ([]models.Job, error) {
+		if page == 2 {
+			return nil, failure
+		}
+		return full(ctx, page, limit)
+	})
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 10)
+}
+
+func TestPaginateCompleteness(t *testing.T) {
+	jf := &JobFetcher{Config: &config.Config{FetchMaxPages: 2}}
+
+	// Reading up to a short page is complete
+	completeness := &Completeness{}
+	ctx := WithCompleteness(context.Background(), completeness)
+	var calls [][2]int
+	_, err := jf.paginate(ctx, "linkedin", 10, pagedJobs(15, 10, &calls))
+	assert.NoError(t, err)
+	complete, _ := completeness.Complete()
+	assert.True(t, complete)
+
+	// Stopping at the page limit leaves jobs unread
+	_, err = jf.paginate(ctx, "linkedin", 10, pagedJobs(100, 10, &calls))
+	assert.NoError(t, err)
+	complete, reason := completeness.Complete()
+	assert.False(t, complete)
+	assert.Equal(t, "linkedin stopped at 2 pages", reason)
+
+	// So does stopping at the job limit
+	jf.Config.FetchMaxItems = 5
+	completeness = &Completeness{}
+	ctx = WithCompleteness(context.Background(), completeness)
+	_, err = jf.paginate(ctx, "jsearch", 10, pagedJobs(100, 10, &calls))
+	assert.NoError(t, err)
+	_, reason = completeness.Complete()
+	assert.Equal(t, "jsearch stopped at 5 jobs", reason)
+
+	// And a failing later page
+	completeness = &Completeness{}
+	ctx = WithCompleteness(context.Background(), completeness)
+	jf.Config.FetchMaxItems = 0
+	full := pagedJobs(100, 10, &calls)
+	_, err = jf.paginate(ctx, "jsearch", 10, func(ctx context.Context, page, limit int) ([]models.Job, error) {
+		if page == 2 {
+			return nil, errors.New("rate limited")
+		}
+		return full(ctx, page, limit)
+	})
+	assert.NoError(t, err)
+	_, reason = completeness.Complete()
+	assert.Equal(t, "jsearch page 2 failed", reason)
+}
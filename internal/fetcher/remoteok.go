@@ -0,0 +1,152 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// remoteOKTags returns the RemoteOK tags of the jobs of a track, its search
+// terms then its keywords (golang and go for Go); the first one is searched
+func remoteOKTags(track config.Track) []string {
+	var tags []string
+	for _, term := range append(append([]string{}, track.Queries...), track.Include...) {
+		tags = append(tags, strings.ReplaceAll(strings.ToLower(strings.TrimSpace(term)), " ", "-"))
+	}
+	return tags
+}
+
+// FetchRemoteOKJobs fetches the jobs of each track from the RemoteOK public
+// API. Its terms ask for attribution, so jobs link to their RemoteOK page
+// rather than the employer's form and are stored with source "remoteok".
+func (jf *JobFetcher) FetchRemoteOKJobs(ctx context.Context) ([]models.Job, error) {
+	if jf.isExampleMode() {
+		return syntheticJobs("remoteok", time.Now()), nil
+	}
+
+	tracks := make(map[string]config.Track)
+	var names []string
+	for _, track := range jf.Config.ActiveTracks() {
+		tracks[track.Name] = track
+		names = append(names, track.Name)
+	}
+	return fetchEach(ctx, "remoteok", "track", names, func(ctx context.Context, name string) ([]models.Job, error) {
+		return jf.fetchRemoteOKTags(ctx, remoteOKTags(tracks[name]))
+	})
+}
+
+// fetchRemoteOKTags fetches the RemoteOK jobs of the first tag, keeping those
+// tagged with any of tags
+func (jf *JobFetcher) fetchRemoteOKTags(ctx context.Context, tags []string) ([]models.Job, error) {
+	var apiURL string
+	if jf.Config.Mode == "dev" {
+		apiURL = "http://localhost:8081/remoteok/api"
+	} else {
+		apiURL = "https://remoteok.com/api"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("tag", tags[0])
+	req.URL.RawQuery = q.Encode()
+
+	// RemoteOK rejects anonymous clients
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	body, err := jf.readResponse(req, "remoteok", "remoteok_response.json")
+	if err != nil {
+		return nil, err
+	}
+	jf.recordSchema("remoteok", body)
+
+	var remoteOKResp models.RemoteOKResponse
+	if err := json.Unmarshal(body, &remoteOKResp); err != nil {
+		return nil, fmt.Errorf("json unmarshal error: %w", err)
+	}
+
+	now := time.Now()
+	jobs := []models.Job{}
+	raw := rawItems(body, "")
+	for i, item := range remoteOKResp {
+		// Skip the legal notice and jobs the tag search matched loosely
+		if item.Position == "" || !hasAnyTag(item.Tags, tags) {
+			continue
+		}
+
+		postedAt := parsePostedAt("remoteok", now, item.Date, epochString(item.Epoch))
+
+		location := strings.TrimSpace(item.Location)
+		if location == "" {
+			location = "Remote"
+		}
+
+		jobs = append(jobs, models.Job{
+			ID:              uuid.New().String(),
+			JobID:           item.ID.String(),
+			Title:           item.Position,
+			Company:         item.Company,
+			CompanyLogo:     item.CompanyLogo,
+			Location:        location,
+			Description:     analyzer.HTMLToText(item.Description),
+			DescriptionHTML: item.Description,
+			URL:             item.URL,
+			Salary:          remoteOKSalary(item.SalaryMin, item.SalaryMax),
+			PostedAt:        postedAt,
+			IsRemote:        true,
+			Source:          "remoteok",
+			RawData:         rawFragment(raw, i, item),
+			DateGotten:      now,
+			ExpDate:         InferExpiry("", now),
+		})
+	}
+
+	return jobs, nil
+}
+
+// hasAnyTag reports whether tags contain one of wanted, ignoring case
+func hasAnyTag(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if strings.EqualFold(strings.TrimSpace(tag), w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// remoteOKSalary formats the yearly USD range of a RemoteOK job, e.g.
+// "$80K-$120K/year", empty when undisclosed
+func remoteOKSalary(min, max int) string {
+	switch {
+	case min <= 0 && max <= 0:
+		return ""
+	case min <= 0 || min == max:
+		return usdAmount(max) + "/year"
+	case max <= 0:
+		return usdAmount(min) + "/year"
+	}
+	return usdAmount(min) + "-" + usdAmount(max) + "/year"
+}
+
+// usdAmount formats whole dollars, in thousands when round
+func usdAmount(amount int) string {
+	if amount%1000 == 0 {
+		return fmt.Sprintf("$%dK", amount/1000)
+	}
+	return fmt.Sprintf("$%d", amount)
+}
@@ -0,0 +1,66 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// remoteOKResponse is a RemoteOK API response: the legal notice, a Go job and
+// a job the tag search matched loosely
+const remoteOKResponse = `[
+	{"last_updated": 1714557600, "legal": "API Terms of Service: link back to Remote OK and mention it as a source"},
+	{"id": "1093001", "slug": "remote-senior-go-engineer-acme-1093001", "epoch": 1714557600,
+	 "date": "2024-05-01T10:00:00+00:00", "company": "Acme", "company_logo": "https://remoteok.com/assets/acme.png",
+	 "position": "Senior Go Engineer", "tags": ["Golang", "backend"],
+	 "description": "<p>Build our <b>Go</b> services.</p><p>Postgres &amp; Kafka</p>",
+	 "location": "", "salary_min": 80000, "salary_max": 120000,
+	 "apply_url": "https://acme.example/apply", "url": "https://remoteok.com/remote-jobs/1093001"},
+	{"id": 1093002, "epoch": 1714557600, "date": "2024-05-01T11:00:00+00:00", "company": "Other",
+	 "position": "Ruby Developer", "tags": ["ruby"], "description": "", "url": "https://remoteok.com/remote-jobs/1093002"}
+]`
+
+func TestFetchRemoteOKJobs(t *testing.T) {
+	server := setupTestServer(t, map[string]http.HandlerFunc{
+		"/remoteok": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "golang", r.URL.Query().Get("tag"))
+			assert.NotEmpty(t, r.Header.Get("User-Agent"))
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(remoteOKResponse))
+		},
+	})
+	defer server.Close()
+
+	fetcher := NewJobFetcher(createMockConfig(server.URL))
+	fetcher.client = &http.Client{
+		Transport: &mockTransport{URL: server.URL + "/remoteok", Client: server.Client()},
+	}
+
+	jobs, err := fetcher.FetchRemoteOKJobs(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	job := jobs[0]
+	assert.Equal(t, "1093001", job.JobID)
+	assert.Equal(t, "Senior Go Engineer", job.Title)
+	assert.Equal(t, "Acme", job.Company)
+	assert.Equal(t, "Build our Go services.\nPostgres & Kafka", job.Description)
+	assert.Equal(t, "https://remoteok.com/remote-jobs/1093001", job.URL)
+	assert.Equal(t, "$80K-$120K/year", job.Salary)
+	assert.Equal(t, "Remote", job.Location)
+	assert.Equal(t, "remoteok", job.Source)
+	assert.True(t, job.IsRemote)
+	assert.True(t, job.PostedAt.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+
+	removeCached("remoteok_response.json")
+}
+
+func TestRemoteOKSalary(t *testing.T) {
+	assert.Equal(t, "", remoteOKSalary(0, 0))
+	assert.Equal(t, "$90K/year", remoteOKSalary(0, 90000))
+	assert.Equal(t, "$75500/year", remoteOKSalary(75500, 75500))
+	assert.Equal(t, "$60K-$90K/year", remoteOKSalary(60000, 90000))
+}
@@ -0,0 +1,54 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"Go9jaJobs/internal/config"
+)
+
+// ErrNotCached is returned in replay mode for a source without a cached
+// response
+var ErrNotCached = errors.New("no cached response to replay")
+
+// isReplayMode reports whether providers should be replaced by their cached
+// responses
+func (jf *JobFetcher) isReplayMode() bool {
+	return jf.Config != nil && jf.Config.Mode == config.ModeReplay
+}
+
+// readResponse sends req and returns the body of its successful response,
+// keeping it in the response cache under filename. In replay mode req is not
+// sent: the latest cached body is returned instead.
+func (jf *JobFetcher) readResponse(req *http.Request, source, filename string) ([]byte, error) {
+	if jf.isReplayMode() {
+		path, ok := latestCached(jf.cacheDir(), filename)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w in %s", source, ErrNotCached, jf.cacheDir())
+		}
+		return os.ReadFile(path)
+	}
+
+	resp, err := jf.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(source, resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recordResponse(req, body)
+	jf.cacheResponse(filename, body, time.Now())
+	return body, nil
+}
@@ -0,0 +1,39 @@
+package fetcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayMode(t *testing.T) {
+	dir := t.TempDir()
+	body := `{"data": [
+		{"job_id": "replayed-1", "job_title": "Golang Developer", "employer_name": "Paystack"},
+		{"job_id": "replayed-2", "job_title": "Senior Go Engineer", "employer_name": "Kuda"}
+	]}`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "jsearch_response.json"), []byte(body), 0644))
+
+	// Every query and page is served from the cache; no API is called, as
+	// none is reachable with these settings
+	jf := NewJobFetcher(&config.Config{Mode: config.ModeReplay, APIResponseCacheDir: dir, FetchMaxPages: 3})
+	usage := &UsageRecorder{}
+	jobs, err := jf.FetchJSearchJobs(WithUsage(context.Background(), usage))
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 2) {
+		assert.Equal(t, "replayed-1", jobs[0].JobID)
+		assert.Equal(t, "Paystack", jobs[0].Company)
+	}
+	assert.Empty(t, usage.Calls(), "replays are not billed")
+
+	// Sources never fetched cannot be replayed
+	_, err = jf.FetchLinkedInJobs(context.Background())
+	assert.ErrorIs(t, err, ErrNotCached)
+	_, err = jf.FetchJobbermanJobs(context.Background())
+	assert.ErrorIs(t, err, ErrNotCached)
+}
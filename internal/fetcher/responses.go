@@ -0,0 +1,85 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response is a response body received from the API of a job source
+type Response struct {
+	// Endpoint is the host and path called, as in APICall
+	Endpoint  string
+	Body      []byte
+	FetchedAt time.Time
+}
+
+// ResponseRecorder collects the response bodies of a fetch, stored once per
+// sync rather than on each job
+type ResponseRecorder struct {
+	mu        sync.Mutex
+	responses []Response
+}
+
+// Responses returns the responses recorded so far
+func (r *ResponseRecorder) Responses() []Response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Response(nil), r.responses...)
+}
+
+// responsesKey is the context key carrying the ResponseRecorder of a fetch
+type responsesKey struct{}
+
+// WithResponses records the response bodies of the fetchers run with ctx in r
+func WithResponses(ctx context.Context, r *ResponseRecorder) context.Context {
+	return context.WithValue(ctx, responsesKey{}, r)
+}
+
+// recordResponse records the body of the response to req for the fetch of
+// its context
+func recordResponse(req *http.Request, body []byte) {
+	r, ok := req.Context().Value(responsesKey{}).(*ResponseRecorder)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses = append(r.responses, Response{
+		Endpoint:  req.URL.Host + req.URL.Path,
+		Body:      body,
+		FetchedAt: time.Now(),
+	})
+}
+
+// rawItems returns the JSON of each element of the array at key of a response
+// body, or of the body itself for an empty key; nil when there is none
+func rawItems(body []byte, key string) []json.RawMessage {
+	if key != "" {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(body, &object); err != nil {
+			return nil
+		}
+		body = object[key]
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil
+	}
+	return items
+}
+
+// rawFragment returns the raw data of the i-th item of a response: its JSON
+// in raw when there, item encoded as JSON otherwise (e.g. for XML feeds)
+func rawFragment(raw []json.RawMessage, i int, item interface{}) string {
+	if i < len(raw) {
+		return string(raw[i])
+	}
+	fragment, err := json.Marshal(item)
+	if err != nil {
+		return ""
+	}
+	return string(fragment)
+}
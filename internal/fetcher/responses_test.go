@@ -0,0 +1,48 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawFragment(t *testing.T) {
+	raw := rawItems([]byte(`{"status": "OK", "data": [{"job_id": "a1"}, {"job_id": "b2"}]}`), "data")
+	assert.Len(t, raw, 2)
+	assert.Equal(t, `{"job_id": "b2"}`, rawFragment(raw, 1, nil))
+
+	assert.Len(t, rawItems([]byte(`[{"id": 1}]`), ""), 1)
+	assert.Nil(t, rawItems([]byte(`"[{\"id\": 1}]"`), "data"))
+
+	// Items without raw JSON, e.g. of XML feeds, are encoded from the decoded item
+	item := struct {
+		Title string `json:"title"`
+	}{"Go Developer"}
+	assert.Equal(t, `{"title":"Go Developer"}`, rawFragment(nil, 0, item))
+}
+
+func TestFetchRecordsResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [{"job_id": "a1", "job_title": "Go Developer"}]}`))
+	}))
+	defer server.Close()
+
+	jf := NewJobFetcher(&config.Config{Mode: "dev", APIResponseCacheDisabled: true, FetchMaxPages: 1})
+	jf.client = &http.Client{Transport: &mockTransport{URL: server.URL, Client: server.Client()}}
+	responses := &ResponseRecorder{}
+	jobs, err := jf.fetchJSearchQuery(WithResponses(context.Background(), responses), "golang")
+	assert.NoError(t, err)
+
+	// The response is recorded once, each job keeping its own item
+	if assert.Len(t, jobs, 1) {
+		assert.Equal(t, `{"job_id": "a1", "job_title": "Go Developer"}`, jobs[0].RawData)
+	}
+	if assert.Len(t, responses.Responses(), 1) {
+		assert.Contains(t, string(responses.Responses()[0].Body), `"data"`)
+	}
+}
@@ -0,0 +1,162 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Go9jaJobs/internal/config"
+)
+
+// Defaults used when the config leaves the retry settings unset
+const (
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = time.Second
+	defaultMaxDelay    = 30 * time.Second
+)
+
+// retryPolicy controls how failed API calls are retried
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// newRetryPolicy builds the retry policy from cfg, using defaults for unset values
+func newRetryPolicy(cfg *config.Config) retryPolicy {
+	policy := retryPolicy{
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		maxDelay:    defaultMaxDelay,
+	}
+	if cfg == nil {
+		return policy
+	}
+	if cfg.FetchMaxAttempts > 0 {
+		policy.maxAttempts = cfg.FetchMaxAttempts
+	}
+	if cfg.FetchRetryBaseDelay > 0 {
+		policy.baseDelay = cfg.FetchRetryBaseDelay
+	}
+	if cfg.FetchRetryMaxDelay > 0 {
+		policy.maxDelay = cfg.FetchRetryMaxDelay
+	}
+	return policy
+}
+
+// backoff returns the delay before the given retry (1 for the first retry):
+// exponential in the attempt, capped at maxDelay, with up to 50% jitter so
+// concurrent syncs do not retry in lockstep
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.baseDelay << (retry - 1)
+	if delay <= 0 || delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryableStatus reports whether a response status is worth retrying
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		if d := when.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// do sends req, retrying network errors, 429 and 5xx responses with
+// exponential backoff. Retry-After headers are honoured (up to maxDelay).
+// Once attempts run out the last response is returned as is.
+// Request bodies are replayed through req.GetBody.
+func (jf *JobFetcher) do(req *http.Request) (*http.Response, error) {
+	policy := jf.retry
+	if policy.maxAttempts < 1 {
+		policy.maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := jf.client.Do(req)
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+		}
+		// Every attempt counts against the quota of the API
+		recordCall(req, status)
+		if err == nil {
+			slog.Debug("Upstream request", "method", req.Method, "host", req.URL.Host, "path", req.URL.Path,
+				"status", resp.StatusCode, "attempt", attempt)
+		}
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+			if attempt >= policy.maxAttempts {
+				return nil, fmt.Errorf("request to %s failed after %d attempts: %w", req.URL.Host, attempt, err)
+			}
+			delay = policy.backoff(attempt)
+			log.Printf("Request to %s failed (attempt %d/%d): %v; retrying in %s", req.URL.Host, attempt, policy.maxAttempts, err, delay)
+		case isRetryableStatus(resp.StatusCode):
+			if attempt >= policy.maxAttempts {
+				// Out of attempts: hand the response to checkStatus
+				return resp, nil
+			}
+			delay = policy.backoff(attempt)
+			if d, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				delay = min(d, policy.maxDelay)
+			}
+			resp.Body.Close()
+			log.Printf("Request to %s returned %d (attempt %d/%d); retrying in %s", req.URL.Host, resp.StatusCode, attempt, policy.maxAttempts, delay)
+		default:
+			return resp, nil
+		}
+
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
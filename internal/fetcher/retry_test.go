@@ -0,0 +1,132 @@
+package fetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRetryFetcher creates a JobFetcher that retries quickly against server
+func newRetryFetcher(server *httptest.Server, attempts int) *JobFetcher {
+	return &JobFetcher{
+		client: server.Client(),
+		retry:  retryPolicy{maxAttempts: attempts, baseDelay: time.Millisecond, maxDelay: 10 * time.Millisecond},
+	}
+}
+
+func TestDoRetriesTransientFailures(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The body must be replayed on every attempt
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"title":"golang"}`, string(body))
+
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), "POST", server.URL, strings.NewReader(`{"title":"golang"}`))
+	assert.NoError(t, err)
+
+	resp, err := newRetryFetcher(server, 3).do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestDoGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := newRetryFetcher(server, 2).do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The last response is returned for checkStatus to classify
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.ErrorIs(t, checkStatus("jsearch", resp), ErrUpstream)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestDoDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := newRetryFetcher(server, 3).do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	d, ok := retryAfter("7", now)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, d)
+
+	d, ok = retryAfter(now.Add(time.Minute).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+
+	_, ok = retryAfter("", now)
+	assert.False(t, ok)
+	_, ok = retryAfter("later", now)
+	assert.False(t, ok)
+}
+
+func TestBackoff(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 5, baseDelay: time.Second, maxDelay: 5 * time.Second}
+
+	for i := 0; i < 20; i++ {
+		d := policy.backoff(1)
+		assert.True(t, d >= 500*time.Millisecond && d <= time.Second, d)
+
+		d = policy.backoff(2)
+		assert.True(t, d >= time.Second && d <= 2*time.Second, d)
+
+		// Capped at maxDelay
+		d = policy.backoff(10)
+		assert.True(t, d >= 2500*time.Millisecond && d <= 5*time.Second, d)
+	}
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	assert.Equal(t, retryPolicy{maxAttempts: 3, baseDelay: time.Second, maxDelay: 30 * time.Second}, newRetryPolicy(nil))
+
+	policy := newRetryPolicy(&config.Config{FetchMaxAttempts: 5, FetchRetryBaseDelay: 2 * time.Second})
+	assert.Equal(t, 5, policy.maxAttempts)
+	assert.Equal(t, 2*time.Second, policy.baseDelay)
+	assert.Equal(t, 30*time.Second, policy.maxDelay)
+}
@@ -0,0 +1,129 @@
+package fetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Bounds of schema extraction, so responses keyed by IDs or deeply nested
+// blobs do not produce unbounded field lists
+const (
+	maxSchemaDepth  = 8
+	maxSchemaFields = 500
+)
+
+// ResponseSchema describes the structure of a provider response: every field
+// path (e.g. "data[].job_title") with its JSON type, merged over all array
+// elements, and a fingerprint of the paths
+type ResponseSchema struct {
+	Fingerprint string
+	Fields      map[string]string
+}
+
+// SchemaOf returns the schema of a JSON response body. Bodies that are a JSON
+// string holding JSON (as some LinkedIn responses are) are described by the
+// inner document. ok is false when body is not JSON.
+func SchemaOf(body []byte) (schema ResponseSchema, ok bool) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return ResponseSchema{}, false
+	}
+	if inner, isString := doc.(string); isString {
+		if err := json.Unmarshal([]byte(inner), &doc); err != nil {
+			return ResponseSchema{}, false
+		}
+	}
+
+	fields := make(map[string]string)
+	collectFields(fields, "", doc, 0)
+
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	sum := sha256.Sum256([]byte(strings.Join(paths, "\n")))
+
+	return ResponseSchema{Fingerprint: hex.EncodeToString(sum[:]), Fields: fields}, true
+}
+
+// collectFields adds the paths below value to fields. A null only sets the
+// type of a path no element gave a value, so optional fields do not flap.
+func collectFields(fields map[string]string, path string, value interface{}, depth int) {
+	if depth > maxSchemaDepth || len(fields) >= maxSchemaFields {
+		return
+	}
+
+	kind := jsonType(value)
+	if path != "" {
+		if existing, seen := fields[path]; !seen || existing == "null" {
+			fields[path] = kind
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// Keys are walked in order, so the fields kept up to maxSchemaFields,
+		// and the fingerprint, are the same for every identical response
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			collectFields(fields, childPath, v[key], depth+1)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectFields(fields, path+"[]", child, depth+1)
+		}
+	}
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// recordSchema keeps the schema of the latest response of source
+func (jf *JobFetcher) recordSchema(source string, body []byte) {
+	schema, ok := SchemaOf(body)
+	if !ok {
+		return
+	}
+	jf.schemaMu.Lock()
+	defer jf.schemaMu.Unlock()
+	if jf.schemas == nil {
+		jf.schemas = make(map[string]ResponseSchema)
+	}
+	jf.schemas[source] = schema
+}
+
+// LastSchema returns the schema of the latest response fetched from source;
+// ok is false before the first one or for synthetic jobs
+func (jf *JobFetcher) LastSchema(source string) (schema ResponseSchema, ok bool) {
+	jf.schemaMu.Lock()
+	defer jf.schemaMu.Unlock()
+	schema, ok = jf.schemas[source]
+	return schema, ok
+}
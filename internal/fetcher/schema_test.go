@@ -0,0 +1,74 @@
+package fetcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaOf(t *testing.T) {
+	schema, ok := SchemaOf([]byte(`{"status":"OK","data":[
+		{"job_id":"1","job_title":"Go Developer","job_is_remote":false,"job_salary":null,"job_highlights":{"Qualifications":["Go"]}},
+		{"job_id":"2","job_title":"Backend Engineer","job_salary":"₦1M","job_apply_link":"https://example.com"}
+	]}`))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{
+		"status":                                 "string",
+		"data":                                   "array",
+		"data[]":                                 "object",
+		"data[].job_id":                          "string",
+		"data[].job_title":                       "string",
+		"data[].job_is_remote":                   "boolean",
+		"data[].job_salary":                      "string",
+		"data[].job_apply_link":                  "string",
+		"data[].job_highlights":                  "object",
+		"data[].job_highlights.Qualifications":   "array",
+		"data[].job_highlights.Qualifications[]": "string",
+	}, schema.Fields)
+
+	// Values and field order do not change the fingerprint, fields do
+	same, _ := SchemaOf([]byte(`{"data":[{"job_apply_link":"x","job_title":"y","job_id":"3","job_salary":null,
+		"job_is_remote":true,"job_highlights":{"Qualifications":["Docker"]}}],"status":"ERROR"}`))
+	assert.Equal(t, schema.Fingerprint, same.Fingerprint)
+	renamed, _ := SchemaOf([]byte(`{"status":"OK","data":[{"id":"1","job_title":"Go Developer"}]}`))
+	assert.NotEqual(t, schema.Fingerprint, renamed.Fingerprint)
+
+	// A JSON string holding the document is described by the document
+	inner, ok := SchemaOf([]byte(strconv.Quote(`{"data":[{"title":"Go Developer"}]}`)))
+	assert.True(t, ok)
+	assert.Equal(t, "string", inner.Fields["data[].title"])
+
+	_, ok = SchemaOf([]byte(`<html>Bad gateway</html>`))
+	assert.False(t, ok)
+
+	// Responses with more fields than are kept keep the same ones every time
+	var wide strings.Builder
+	wide.WriteString("{")
+	for i := 0; i < 2*maxSchemaFields; i++ {
+		if i > 0 {
+			wide.WriteString(",")
+		}
+		fmt.Fprintf(&wide, `"field%d":%d`, i, i)
+	}
+	wide.WriteString("}")
+	first, _ := SchemaOf([]byte(wide.String()))
+	assert.Len(t, first.Fields, maxSchemaFields)
+	for i := 0; i < 5; i++ {
+		again, _ := SchemaOf([]byte(wide.String()))
+		assert.Equal(t, first.Fingerprint, again.Fingerprint)
+	}
+}
+
+func TestLastSchema(t *testing.T) {
+	jf := NewJobFetcher(nil)
+	_, ok := jf.LastSchema("jsearch")
+	assert.False(t, ok)
+
+	jf.recordSchema("jsearch", []byte(`{"data":[]}`))
+	schema, ok := jf.LastSchema("jsearch")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"data": "array"}, schema.Fields)
+}
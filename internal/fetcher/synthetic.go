@@ -0,0 +1,102 @@
+package fetcher
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/models"
+)
+
+// syntheticJobCount is how many jobs each source yields in example mode
+const syntheticJobCount = 12
+
+// syntheticCompanies are the employers of synthetic jobs
+var syntheticCompanies = []struct {
+	Name string
+	URL  string
+}{
+	{"Paystack", "https://paystack.com"},
+	{"Flutterwave", "https://flutterwave.com"},
+	{"Moniepoint", "https://moniepoint.com"},
+	{"Andela", "https://andela.com"},
+	{"Kuda", "https://kuda.com"},
+	{"Interswitch", "https://interswitchgroup.com"},
+	{"PiggyVest", "https://piggyvest.com"},
+	{"Cowrywise", "https://cowrywise.com"},
+}
+
+// syntheticTitles are the titles of synthetic jobs
+var syntheticTitles = []string{
+	"Golang Developer",
+	"Senior Go Engineer",
+	"Backend Engineer (Go)",
+	"Go Software Engineer",
+	"Platform Engineer, Golang",
+	"Junior Golang Developer",
+}
+
+// syntheticLocations are the locations of synthetic jobs; "Remote" jobs are remote
+var syntheticLocations = []string{"Lagos, Nigeria", "Abuja, Nigeria", "Port Harcourt, Nigeria", "Remote"}
+
+// syntheticSalaries are the salaries of synthetic jobs, empty when undisclosed
+var syntheticSalaries = []string{"", "₦800K-₦1.2M/month", "$2,500-$4,000/month", ""}
+
+// syntheticJobTypes are the job types of synthetic jobs
+var syntheticJobTypes = []string{"Full-time", "Full-time", "Contract"}
+
+// isExampleMode reports whether providers should be replaced by synthetic data
+func (jf *JobFetcher) isExampleMode() bool {
+	return jf.Config != nil && jf.Config.Mode == config.ModeExample
+}
+
+// syntheticJobs generates plausible jobs for source without calling any
+// provider. The same source always yields the same jobs (and job IDs, so
+// re-syncs update rather than duplicate them); only dates move with now.
+func syntheticJobs(source string, now time.Time) []models.Job {
+	seed := fnv.New64a()
+	seed.Write([]byte(source))
+	rng := rand.New(rand.NewSource(int64(seed.Sum64())))
+
+	today := now.Truncate(24 * time.Hour)
+	jobs := make([]models.Job, syntheticJobCount)
+	for i := range jobs {
+		company := syntheticCompanies[rng.Intn(len(syntheticCompanies))]
+		title := syntheticTitles[rng.Intn(len(syntheticTitles))]
+		location := syntheticLocations[rng.Intn(len(syntheticLocations))]
+		jobID := fmt.Sprintf("example-%s-%d", strings.ReplaceAll(source, " ", "-"), i+1)
+		postedAt := today.Add(-time.Duration(rng.Intn(14)) * 24 * time.Hour)
+
+		jobs[i] = models.Job{
+			ID:          jobID,
+			JobID:       jobID,
+			Title:       title,
+			Company:     company.Name,
+			CompanyURL:  company.URL,
+			Location:    location,
+			Description: syntheticDescription(title, company.Name, location),
+			URL:         fmt.Sprintf("%s/careers/%s", company.URL, jobID),
+			Salary:      syntheticSalaries[rng.Intn(len(syntheticSalaries))],
+			PostedAt:    postedAt,
+			JobType:     syntheticJobTypes[rng.Intn(len(syntheticJobTypes))],
+			IsRemote:    location == "Remote",
+			Source:      source,
+			DateGotten:  now,
+			ExpDate:     InferExpiry("", now),
+		}
+	}
+	return jobs
+}
+
+// syntheticDescription writes a short job description
+func syntheticDescription(title, company, location string) string {
+	return strings.Join([]string{
+		fmt.Sprintf("%s is hiring a %s (%s).", company, title, location),
+		"You will design, build and operate Go services and REST APIs backed by PostgreSQL.",
+		"Requirements: 2+ years of Go, experience with Docker, SQL and writing tests.",
+		"This is example data generated in MODE=example.",
+	}, "\n\n")
+}
@@ -0,0 +1,57 @@
+package fetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntheticJobs(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+
+	jobs := syntheticJobs("jsearch", now)
+	assert.Len(t, jobs, syntheticJobCount)
+
+	// The same source always yields the same jobs
+	assert.Equal(t, jobs, syntheticJobs("jsearch", now))
+	assert.NotEqual(t, jobs, syntheticJobs("linkedin", now))
+
+	for _, job := range jobs {
+		assert.Equal(t, "jsearch", job.Source)
+		assert.NotEmpty(t, job.JobID)
+		assert.NotEmpty(t, job.Company)
+		assert.Contains(t, job.Description, "Go")
+		assert.False(t, job.PostedAt.After(now))
+		assert.True(t, job.ExpDate.After(now))
+		assert.Equal(t, job.Location == "Remote", job.IsRemote)
+	}
+
+	apify := syntheticJobs("apify indeed", now)
+	assert.Equal(t, "example-apify-indeed-1", apify[0].JobID)
+}
+
+func TestFetchJobsExampleMode(t *testing.T) {
+	// No API keys and no provider: every fetcher returns synthetic jobs
+	fetcher := NewJobFetcher(&config.Config{Mode: config.ModeExample})
+	ctx := context.Background()
+
+	jobs, err := fetcher.FetchJSearchJobs(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, syntheticJobCount)
+
+	jobs, err = fetcher.FetchIndeedJobs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "apify indeed", jobs[0].Source)
+
+	jobs, err = fetcher.FetchApifyLinkedInJobs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "apify linkedin", jobs[0].Source)
+
+	jobs, err = fetcher.FetchLinkedInJobs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "linkedin", jobs[0].Source)
+}
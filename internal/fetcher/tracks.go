@@ -0,0 +1,90 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/models"
+)
+
+// fetchEach runs fetch for each key of a source, e.g. its board tokens or
+// search queries, keeping a job found by several keys once. A key that fails
+// is logged and skipped; the error is returned only when every key failed.
+func fetchEach(ctx context.Context, source, kind string, keys []string, fetch func(context.Context, string) ([]models.Job, error)) ([]models.Job, error) {
+	jobs := []models.Job{}
+	seen := make(map[string]bool)
+	var errs []error
+	for _, key := range keys {
+		keyJobs, err := fetch(ctx, key)
+		if err != nil {
+			log.Printf("Error fetching %s %s %s: %v", source, kind, key, err)
+			errs = append(errs, fmt.Errorf("%s %s: %w", kind, key, err))
+			noteIncomplete(ctx, fmt.Sprintf("%s %s dropped", kind, key))
+			continue
+		}
+		for _, job := range keyJobs {
+			if job.JobID != "" {
+				if seen[job.JobID] {
+					continue
+				}
+				seen[job.JobID] = true
+			}
+			jobs = append(jobs, job)
+		}
+	}
+
+	if len(errs) > 0 && len(errs) == len(keys) {
+		return nil, errors.Join(errs...)
+	}
+	return jobs, nil
+}
+
+// searchQueries returns the search terms of the configured tracks, each
+// once, e.g. "golang"
+func (jf *JobFetcher) searchQueries() []string {
+	var queries []string
+	seen := make(map[string]bool)
+	for _, track := range jf.Config.ActiveTracks() {
+		for _, query := range track.Queries {
+			if key := strings.ToLower(query); !seen[key] {
+				seen[key] = true
+				queries = append(queries, query)
+			}
+		}
+	}
+	return queries
+}
+
+// roleFilter picks the roles of the tracks out of the results of a broad
+// search, e.g. every opening of a company board: the title must name a
+// track keyword or the description one of its search terms (for Go, "Go" in
+// the title or "Golang" in the description)
+type roleFilter struct {
+	titles       []analyzer.RelevanceRule
+	descriptions []analyzer.RelevanceRule
+}
+
+// newRoleFilter compiles the role filter of tracks
+func newRoleFilter(tracks []config.Track) roleFilter {
+	var f roleFilter
+	for _, track := range tracks {
+		f.titles = append(f.titles, analyzer.NewRelevanceRule(track.Include, track.Exclude))
+		f.descriptions = append(f.descriptions, analyzer.NewRelevanceRule(track.Queries, track.Exclude))
+	}
+	return f
+}
+
+// matches reports whether a role belongs to one of the tracks
+func (f roleFilter) matches(title, description string) bool {
+	for i := range f.titles {
+		if f.titles[i].Classify(title, "").Relevant || f.descriptions[i].Classify("", description).Relevant {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchEach(t *testing.T) {
+	fetch := func(ctx context.Context, board string) ([]models.Job, error) {
+		if board == "missing" {
+			return nil, ErrUpstream
+		}
+		return []models.Job{{JobID: "shared"}, {Company: board}}, nil
+	}
+
+	// A failed board does not drop the jobs of the others, a job found twice is kept once
+	jobs, err := fetchEach(context.Background(), "greenhouse", "board", []string{"paystack", "missing", "kuda"}, fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Job{{JobID: "shared"}, {Company: "paystack"}, {Company: "kuda"}}, jobs)
+
+	_, err = fetchEach(context.Background(), "greenhouse", "board", []string{"missing"}, fetch)
+	assert.True(t, errors.Is(err, ErrUpstream))
+
+	jobs, err = fetchEach(context.Background(), "greenhouse", "board", nil, fetch)
+	assert.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestRoleFilter(t *testing.T) {
+	roles := newRoleFilter(config.DefaultTracks())
+	assert.True(t, roles.matches("Senior Go Engineer", ""))
+	assert.True(t, roles.matches("Golang Developer", ""))
+	assert.True(t, roles.matches("Backend Engineer", "Our services are written in Golang"))
+	assert.False(t, roles.matches("Backend Engineer", "Ready to go? Apply now"))
+	assert.False(t, roles.matches("Go-to-market Manager", "Launch our products"))
+	assert.False(t, roles.matches("Django Developer", "Python and Postgres"))
+
+	roles = newRoleFilter([]config.Track{{Name: "python", Include: []string{"python", "django"}, Queries: []string{"python"}}})
+	assert.True(t, roles.matches("Django Developer", "Python and Postgres"))
+	assert.False(t, roles.matches("Senior Go Engineer", ""))
+}
+
+func TestSearchQueries(t *testing.T) {
+	assert.Equal(t, []string{"golang"}, NewJobFetcher(&config.Config{}).searchQueries())
+
+	jf := NewJobFetcher(&config.Config{Tracks: []config.Track{
+		{Name: "javascript", Queries: []string{"javascript", "TypeScript"}},
+		{Name: "typescript", Queries: []string{"typescript"}},
+	}})
+	assert.Equal(t, []string{"javascript", "TypeScript"}, jf.searchQueries())
+}
+
+func TestFetchJSearchJobsTracks(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := setupTestServer(t, map[string]http.HandlerFunc{
+		"/jsearch": func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query().Get("query")
+			mu.Lock()
+			queries = append(queries, query)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"data": [{"job_id": "` + query + `", "job_title": "Engineer", "employer_name": "Acme"}]}`))
+		},
+	})
+	defer server.Close()
+
+	cfg := createMockConfig(server.URL)
+	cfg.Tracks = []config.Track{
+		{Name: "go", Include: []string{"go"}, Queries: []string{"golang"}},
+		{Name: "rust", Include: []string{"rust"}, Queries: []string{"rust"}},
+	}
+	fetcher := NewJobFetcher(cfg)
+	fetcher.client = &http.Client{
+		Transport: &mockTransport{URL: server.URL + "/jsearch", Client: server.Client()},
+	}
+
+	jobs, err := fetcher.FetchJSearchJobs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"golang jobs in nigeria", "rust jobs in nigeria"}, queries)
+	assert.Len(t, jobs, 2)
+
+	removeCached("jsearch_response.json")
+}
@@ -0,0 +1,69 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// APICall is a request sent to the API of a job source, retries included
+type APICall struct {
+	// Endpoint is the host and path called, without the query that may hold
+	// API keys
+	Endpoint string
+	// Status is the HTTP status answered, 0 when the request failed
+	Status int
+	// Items is how many jobs the call returned, nil where not counted
+	Items    *int
+	CalledAt time.Time
+}
+
+// UsageRecorder collects the API calls of a fetch, for usage and cost tracking
+type UsageRecorder struct {
+	mu    sync.Mutex
+	calls []APICall
+}
+
+// Calls returns the calls recorded so far
+func (u *UsageRecorder) Calls() []APICall {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]APICall(nil), u.calls...)
+}
+
+// usageKey is the context key carrying the UsageRecorder of a fetch
+type usageKey struct{}
+
+// WithUsage records the API calls of the fetchers run with ctx in u
+func WithUsage(ctx context.Context, u *UsageRecorder) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+// recordCall records a request sent for the fetch of its context
+func recordCall(req *http.Request, status int) {
+	u, ok := req.Context().Value(usageKey{}).(*UsageRecorder)
+	if !ok {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.calls = append(u.calls, APICall{
+		Endpoint: req.URL.Host + req.URL.Path,
+		Status:   status,
+		CalledAt: time.Now(),
+	})
+}
+
+// noteItems records how many jobs the last call of the fetch of ctx returned
+func noteItems(ctx context.Context, items int) {
+	u, ok := ctx.Value(usageKey{}).(*UsageRecorder)
+	if !ok {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.calls) > 0 {
+		u.calls[len(u.calls)-1].Items = &items
+	}
+}
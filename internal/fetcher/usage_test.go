@@ -0,0 +1,49 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageRecorder(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	jf := NewJobFetcher(&config.Config{FetchMaxAttempts: 2, FetchRetryBaseDelay: 1})
+	usage := &UsageRecorder{}
+	ctx := WithUsage(context.Background(), usage)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/search?key=secret", nil)
+	assert.NoError(t, err)
+	resp, err := jf.do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	noteItems(ctx, 7)
+
+	// Every attempt is recorded, without the query
+	calls := usage.Calls()
+	if assert.Len(t, calls, 2) {
+		assert.Equal(t, req.URL.Host+"/search", calls[0].Endpoint)
+		assert.Equal(t, http.StatusServiceUnavailable, calls[0].Status)
+		assert.Nil(t, calls[0].Items)
+		assert.Equal(t, http.StatusOK, calls[1].Status)
+		assert.Equal(t, 7, *calls[1].Items)
+	}
+
+	// Fetches without a recorder are not tracked
+	noteItems(context.Background(), 3)
+}
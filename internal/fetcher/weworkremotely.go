@@ -0,0 +1,110 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// FetchWeWorkRemotelyJobs fetches the jobs of each track search query from
+// the WeWorkRemotely RSS feed. Items are titled "Company: Job title"; every
+// job on the board is remote.
+func (jf *JobFetcher) FetchWeWorkRemotelyJobs(ctx context.Context) ([]models.Job, error) {
+	if jf.isExampleMode() {
+		return syntheticJobs("weworkremotely", time.Now()), nil
+	}
+
+	return fetchEach(ctx, "weworkremotely", "query", jf.searchQueries(), jf.fetchWeWorkRemotelyQuery)
+}
+
+// fetchWeWorkRemotelyQuery fetches the jobs of one WeWorkRemotely search
+func (jf *JobFetcher) fetchWeWorkRemotelyQuery(ctx context.Context, query string) ([]models.Job, error) {
+	var apiURL string
+	if jf.Config.Mode == "dev" {
+		apiURL = "http://localhost:8081/weworkremotely/remote-jobs/search.rss"
+	} else {
+		apiURL = "https://weworkremotely.com/remote-jobs/search.rss"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("term", query)
+	req.URL.RawQuery = q.Encode()
+
+	req.Header.Set("Accept", "application/rss+xml, application/xml")
+
+	body, err := jf.readResponse(req, "weworkremotely", "weworkremotely_response.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	// The feed is XML, so no schema is recorded for it
+	var feed models.WeWorkRemotelyFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("xml unmarshal error: %w", err)
+	}
+
+	now := time.Now()
+	jobs := make([]models.Job, 0, len(feed.Channel.Items))
+	for i, item := range feed.Channel.Items {
+		company, title := splitWeWorkRemotelyTitle(item.Title)
+		if title == "" {
+			continue
+		}
+
+		postedAt := parsePostedAt("weworkremotely", now, item.PubDate)
+
+		location := strings.TrimSpace(item.Region)
+		if location == "" {
+			location = "Remote"
+		}
+
+		jobID := strings.TrimSpace(item.GUID)
+		if jobID == "" {
+			jobID = strings.TrimSpace(item.Link)
+		}
+
+		jobs = append(jobs, models.Job{
+			ID:              uuid.New().String(),
+			JobID:           jobID,
+			Title:           title,
+			Company:         company,
+			CompanyLogo:     item.Media.URL,
+			Location:        location,
+			Description:     analyzer.HTMLToText(item.Description),
+			DescriptionHTML: item.Description,
+			URL:             strings.TrimSpace(item.Link),
+			PostedAt:        postedAt,
+			JobType:         strings.TrimSpace(item.Type),
+			IsRemote:        true,
+			Source:          "weworkremotely",
+			RawData:         rawFragment(nil, i, item),
+			DateGotten:      now,
+			ExpDate:         InferExpiry("", now),
+		})
+	}
+
+	return jobs, nil
+}
+
+// splitWeWorkRemotelyTitle splits a feed item title "Company: Job title";
+// titles without a company are returned whole
+func splitWeWorkRemotelyTitle(s string) (company, title string) {
+	company, title, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", strings.TrimSpace(s)
+	}
+	return strings.TrimSpace(company), strings.TrimSpace(title)
+}
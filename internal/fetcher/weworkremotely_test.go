@@ -0,0 +1,73 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// weWorkRemotelyFeed is a WeWorkRemotely search feed with one job
+const weWorkRemotelyFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>We Work Remotely: golang</title>
+    <item>
+      <title>Acme: Senior Golang Engineer</title>
+      <region>Anywhere in the World</region>
+      <category>Back-End Programming</category>
+      <type>Full-Time</type>
+      <description>&lt;p&gt;Write &lt;strong&gt;Go&lt;/strong&gt; services&lt;/p&gt;&lt;br&gt;Postgres</description>
+      <pubDate>Wed, 01 May 2024 10:00:00 +0000</pubDate>
+      <guid>https://weworkremotely.com/remote-jobs/acme-senior-golang-engineer</guid>
+      <link>https://weworkremotely.com/remote-jobs/acme-senior-golang-engineer</link>
+      <media:content url="https://wwr-pro.s3.amazonaws.com/logos/acme.png" type="image/png"/>
+    </item>
+  </channel>
+</rss>`
+
+func TestFetchWeWorkRemotelyJobs(t *testing.T) {
+	server := setupTestServer(t, map[string]http.HandlerFunc{
+		"/weworkremotely": func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "golang", r.URL.Query().Get("term"))
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte(weWorkRemotelyFeed))
+		},
+	})
+	defer server.Close()
+
+	fetcher := NewJobFetcher(createMockConfig(server.URL))
+	fetcher.client = &http.Client{
+		Transport: &mockTransport{URL: server.URL + "/weworkremotely", Client: server.Client()},
+	}
+
+	jobs, err := fetcher.FetchWeWorkRemotelyJobs(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	job := jobs[0]
+	assert.Equal(t, "Acme", job.Company)
+	assert.Equal(t, "Senior Golang Engineer", job.Title)
+	assert.Equal(t, "Write Go services\nPostgres", job.Description)
+	assert.Equal(t, "https://weworkremotely.com/remote-jobs/acme-senior-golang-engineer", job.URL)
+	assert.Equal(t, "https://wwr-pro.s3.amazonaws.com/logos/acme.png", job.CompanyLogo)
+	assert.Equal(t, "Anywhere in the World", job.Location)
+	assert.Equal(t, "Full-Time", job.JobType)
+	assert.Equal(t, "weworkremotely", job.Source)
+	assert.True(t, job.IsRemote)
+	assert.True(t, job.PostedAt.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+
+	removeCached("weworkremotely_response.xml")
+}
+
+func TestSplitWeWorkRemotelyTitle(t *testing.T) {
+	company, title := splitWeWorkRemotelyTitle("Acme Inc: Backend Engineer: Go")
+	assert.Equal(t, "Acme Inc", company)
+	assert.Equal(t, "Backend Engineer: Go", title)
+
+	company, title = splitWeWorkRemotelyTitle(" Go Developer ")
+	assert.Equal(t, "", company)
+	assert.Equal(t, "Go Developer", title)
+}
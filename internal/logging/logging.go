@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// level is the minimum level of the default logger, changeable at runtime
+var level = new(slog.LevelVar)
+
+// Setup makes a logger writing to w in the given format the default logger.
+// Messages from the standard log package go through it at the level their
+// wording tells, see stdLevel.
+func Setup(levelName, format string, w io.Writer) error {
+	if err := SetLevel(levelName); err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format: %s", format)
+	}
+
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	log.SetOutput(stdWriter{logger})
+	return nil
+}
+
+// stdWriter logs the messages of the standard log package at their level
+type stdWriter struct {
+	logger *slog.Logger
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	w.logger.Log(context.Background(), stdLevel(msg), msg)
+	return len(p), nil
+}
+
+// stdLevel tells the level of a standard log message from its first word:
+// "Error ..." and "Failed ..." messages are errors, "Warning ...",
+// "ALERT ..." and "Invalid ..." ones warnings, the others informational
+func stdLevel(msg string) slog.Level {
+	word, _, _ := strings.Cut(msg, " ")
+	switch strings.ToLower(strings.TrimRight(word, ":")) {
+	case "error", "failed", "fatal", "panic":
+		return slog.LevelError
+	case "warning", "warn", "alert", "invalid":
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// SetLevel changes the minimum level of the default logger
+func SetLevel(name string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("invalid log level: %s", name)
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level returns the current minimum level, e.g. "info"
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetup(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.LstdFlags)
+	defer level.Set(slog.LevelInfo)
+
+	var buf bytes.Buffer
+	assert.NoError(t, Setup("warn", FormatJSON, &buf))
+	assert.Equal(t, "warn", Level())
+
+	slog.Info("hidden")
+	slog.Warn("shown", "source", "jsearch")
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "shown", entry["msg"])
+	assert.Equal(t, "jsearch", entry["source"])
+
+	// Lowering the level at runtime lets debug messages through, including
+	// those of the standard log package
+	buf.Reset()
+	assert.NoError(t, SetLevel("DEBUG"))
+	slog.Debug("debugging")
+	log.Printf("legacy %s", "message")
+	assert.Contains(t, buf.String(), `"msg":"debugging"`)
+	assert.Contains(t, buf.String(), `"msg":"legacy message"`)
+
+	// Errors of the standard log package stay visible at the error level
+	buf.Reset()
+	assert.NoError(t, SetLevel("error"))
+	log.Printf("Processed %d jobs", 3)
+	log.Printf("Warning: %s is slow", "jsearch")
+	log.Printf("Error querying jobs: %v", "timeout")
+	assert.NotContains(t, buf.String(), "Processed")
+	assert.NotContains(t, buf.String(), "Warning")
+	assert.Contains(t, buf.String(), `"level":"ERROR","msg":"Error querying jobs: timeout"`)
+
+	assert.Error(t, SetLevel("verbose"))
+	assert.Equal(t, "error", Level())
+	assert.Error(t, Setup("info", "xml", &buf))
+}
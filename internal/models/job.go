@@ -1,49 +1,80 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // Job represents a job posting
 type Job struct {
-	ID             string    `json:"id"`
-	JobID          string    `json:"job_id"`
-	Title          string    `json:"title"`
-	Company        string    `json:"company"`
-	CompanyURL     string    `json:"company_url"`
-	CompanyLogo    string    `json:"company_logo"`
-	Country        string    `json:"country"`
-	State          string    `json:"state"`
-	Description    string    `json:"description"`
+	ID              string    `json:"id"`
+	JobID           string    `json:"job_id"`
+	Title           string    `json:"title"`
+	Company         string    `json:"company"`
+	CompanyURL      string    `json:"company_url"`
+	CompanyLogo     string    `json:"company_logo"`
+	Country         string    `json:"country"`
+	State           string    `json:"state"`
+	Description     string    `json:"description"`
 	DescriptionHTML string    `json:"description_html"`
-	URL            string    `json:"url"`
-	Source         string    `json:"source"`
-	IsRemote       bool      `json:"is_remote"`
-	EmploymentType string    `json:"employment_type"`
-	PostedAt       time.Time `json:"posted_at"`
-	DateGotten     time.Time `json:"date_gotten"`
-	ExpDate        time.Time `json:"exp_date"`
-	Salary         string    `json:"salary"`
-	Location       string    `json:"location"`
-	JobType        string    `json:"job_type"`
-	RawData        string    `json:"raw_data"`
+	URL             string    `json:"url"`
+	Source          string    `json:"source"`
+	IsRemote        bool      `json:"is_remote"`
+	EmploymentType  string    `json:"employment_type"`
+	PostedAt        time.Time `json:"posted_at"`
+	DateGotten      time.Time `json:"date_gotten"`
+	ExpDate         time.Time `json:"exp_date"`
+	Salary          string    `json:"salary"`
+	Location        string    `json:"location"`
+	JobType         string    `json:"job_type"`
+	// RawData is the job's own item of the source response: one element of
+	// a JSON response, the decoded item re-encoded as JSON for feeds, or the
+	// HTML of a scraped listing. Whole responses are stored once per sync.
+	RawData     string   `json:"raw_data"`
+	WordCount   int      `json:"word_count"`
+	ReadingTime int      `json:"reading_time_minutes"`
+	ApplyMethod string   `json:"apply_method"`
+	Seniority   string   `json:"seniority"`
+	Assessments []string `json:"assessments"`
+	Stack       []string `json:"stack"`
+	Tracks      []string `json:"tracks"`
+	// Language is the ISO 639-1 code of the description, empty when unknown
+	Language string `json:"language"`
+}
+
+// In returns the job with its dates in loc, e.g. time.UTC to store them or a
+// client's zone to display them. Unset dates stay unset.
+func (j Job) In(loc *time.Location) Job {
+	j.PostedAt = timeIn(j.PostedAt, loc)
+	j.DateGotten = timeIn(j.DateGotten, loc)
+	j.ExpDate = timeIn(j.ExpDate, loc)
+	return j
+}
+
+// timeIn returns t in loc, the zero time when t is unset
+func timeIn(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.In(loc)
 }
 
 // JSEARCHResponse represents the response from the JSearch API
 type JSEARCHResponse struct {
 	Data []struct {
-		ID             string    `json:"job_id"`
-		JobTitle       string    `json:"job_title"`
-		EmployerName   string    `json:"employer_name"`
-		CompanyURL     string    `json:"employer_website"`
-		EmployerLogo   string    `json:"employer_logo"`
-		JobLocation    string    `json:"job_location"`
-		JobDescription string    `json:"job_description"`
-		JobApplyLink   string    `json:"job_apply_link"`
-		JobSalary      string    `json:"job_salary"`
-		JobPostedAt    time.Time `json:"job_posted_at_datetime_utc"`
-		JobType        string    `json:"job_employment_type"`
-		JobIsRemote    bool      `json:"job_is_remote"`
+		ID                   string `json:"job_id"`
+		JobTitle             string `json:"job_title"`
+		EmployerName         string `json:"employer_name"`
+		CompanyURL           string `json:"employer_website"`
+		EmployerLogo         string `json:"employer_logo"`
+		JobLocation          string `json:"job_location"`
+		JobDescription       string `json:"job_description"`
+		JobApplyLink         string `json:"job_apply_link"`
+		JobSalary            string `json:"job_salary"`
+		JobPostedAt          string `json:"job_posted_at_datetime_utc"`
+		JobPostedAtTimestamp int64  `json:"job_posted_at_timestamp"`
+		JobType              string `json:"job_employment_type"`
+		JobIsRemote          bool   `json:"job_is_remote"`
 	} `json:"data"`
 }
 
@@ -163,3 +194,75 @@ type ApifyLinkedInResponse []struct {
 	CompanySlogan         string `json:"companySlogan,omitempty"`
 	CompanyEmployeesCount int    `json:"companyEmployeesCount,omitempty"`
 }
+
+// RemoteOKResponse represents the response from the RemoteOK API. Its first
+// element is the API's legal notice rather than a job.
+type RemoteOKResponse []struct {
+	ID          json.Number `json:"id"`
+	Slug        string      `json:"slug"`
+	Epoch       int64       `json:"epoch"`
+	Date        string      `json:"date"`
+	Company     string      `json:"company"`
+	CompanyLogo string      `json:"company_logo"`
+	Position    string      `json:"position"`
+	Tags        []string    `json:"tags"`
+	Description string      `json:"description"`
+	Location    string      `json:"location"`
+	SalaryMin   int         `json:"salary_min"`
+	SalaryMax   int         `json:"salary_max"`
+	ApplyURL    string      `json:"apply_url"`
+	URL         string      `json:"url"`
+	Legal       string      `json:"legal,omitempty"`
+}
+
+// WeWorkRemotelyFeed represents a WeWorkRemotely RSS feed
+type WeWorkRemotelyFeed struct {
+	Channel struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Region      string `xml:"region"`
+			Country     string `xml:"country"`
+			Category    string `xml:"category"`
+			Type        string `xml:"type"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+			GUID        string `xml:"guid"`
+			Link        string `xml:"link"`
+			Media       struct {
+				URL string `xml:"url,attr"`
+			} `xml:"http://search.yahoo.com/mrss/ content"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// GreenhouseResponse represents the response from the Greenhouse job board API
+type GreenhouseResponse struct {
+	Jobs []struct {
+		ID             int64  `json:"id"`
+		Title          string `json:"title"`
+		UpdatedAt      string `json:"updated_at"`
+		FirstPublished string `json:"first_published"`
+		AbsoluteURL    string `json:"absolute_url"`
+		CompanyName    string `json:"company_name"`
+		Content        string `json:"content"`
+		Location       struct {
+			Name string `json:"name"`
+		} `json:"location"`
+	} `json:"jobs"`
+}
+
+// LeverResponse represents the response from the Lever postings API
+type LeverResponse []struct {
+	ID               string `json:"id"`
+	Text             string `json:"text"`
+	CreatedAt        int64  `json:"createdAt"`
+	Description      string `json:"description"`
+	DescriptionPlain string `json:"descriptionPlain"`
+	HostedURL        string `json:"hostedUrl"`
+	WorkplaceType    string `json:"workplaceType"`
+	Categories       struct {
+		Location   string `json:"location"`
+		Commitment string `json:"commitment"`
+		Team       string `json:"team"`
+	} `json:"categories"`
+}
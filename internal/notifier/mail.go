@@ -0,0 +1,52 @@
+package notifier
+
+import (
+	"fmt"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mailer sends plain text email through an SMTP server
+type Mailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+	// send is smtp.SendMail, replaced in tests
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewMailer creates a Mailer sending from the given address through
+// host:port, authenticating when username is set
+func NewMailer(host string, port int, username, password, from string) *Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &Mailer{
+		addr: host + ":" + strconv.Itoa(port),
+		auth: auth,
+		from: from,
+		send: smtp.SendMail,
+	}
+}
+
+// SendMail sends a plain text message to a single recipient
+func (m *Mailer) SendMail(to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid header value")
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return m.send(m.addr, m.auth, m.from, []string{to}, []byte(msg.String()))
+}
@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailerSendMail(t *testing.T) {
+	var (
+		gotAddr string
+		gotTo   []string
+		gotMsg  string
+	)
+	mailer := NewMailer("smtp.example.com", 587, "user", "pass", "jobs@go9jajobs.com")
+	mailer.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotTo, gotMsg = addr, to, string(msg)
+		return nil
+	}
+
+	assert.NoError(t, mailer.SendMail("dev@example.com", "New Go jobs", "Line one\nLine two"))
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.Equal(t, []string{"dev@example.com"}, gotTo)
+	assert.Contains(t, gotMsg, "Subject: New Go jobs\r\n")
+	assert.Contains(t, gotMsg, "\r\n\r\nLine one\r\nLine two")
+
+	// Header injection is refused
+	assert.Error(t, mailer.SendMail("dev@example.com\r\nBcc: all@example.com", "New Go jobs", ""))
+}
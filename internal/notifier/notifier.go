@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"Go9jaJobs/internal/models"
+)
+
+// MaxDigestJobs is how many jobs a digest lists; the rest are only counted
+const MaxDigestJobs = 10
+
+// DefaultTemplate renders a digest as plain text, one job per line
+const DefaultTemplate = `{{.Total}} new Go job{{if ne .Total 1}}s{{end}} from {{.Source}}
+{{range .Jobs}}
+• {{.Title}} at {{.Company}}{{if .Location}} ({{.Location}}){{end}}
+  {{.URL}}
+{{end}}{{if .More}}
+…and {{.More}} more{{end}}`
+
+// telegramBaseURL is the Telegram Bot API
+const telegramBaseURL = "https://api.telegram.org"
+
+// Digest is the message data about the jobs a sync saved
+type Digest struct {
+	// Source is the synced source
+	Source string
+	// Jobs are the first MaxDigestJobs new jobs
+	Jobs []models.Job
+	// Total counts all new jobs, More those not listed
+	Total int
+	More  int
+}
+
+// NewDigest builds the digest of the jobs saved by a sync of source
+func NewDigest(source string, jobs []models.Job) Digest {
+	digest := Digest{Source: source, Jobs: jobs, Total: len(jobs)}
+	if len(jobs) > MaxDigestJobs {
+		digest.Jobs = jobs[:MaxDigestJobs]
+		digest.More = len(jobs) - MaxDigestJobs
+	}
+	return digest
+}
+
+// ParseTemplate parses a digest template (text/template over Digest), the
+// default one when text is empty
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	return template.New("digest").Parse(text)
+}
+
+// Render renders digest with tmpl
+func Render(tmpl *template.Template, digest Digest) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, digest); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Sender delivers a rendered digest to one channel
+type Sender interface {
+	// Name identifies the channel in logs and recorded errors
+	Name() string
+	Send(ctx context.Context, text string) error
+}
+
+// Telegram posts messages to a Telegram channel or chat through a bot
+type Telegram struct {
+	token   string
+	chatID  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewTelegram creates a sender posting to chatID (e.g. "@go9jajobs") with
+// the bot token
+func NewTelegram(token, chatID string) *Telegram {
+	return &Telegram{
+		token:   token,
+		chatID:  chatID,
+		baseURL: telegramBaseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Sender
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+// Send implements Sender
+func (t *Telegram) Send(ctx context.Context, text string) error {
+	return postJSON(ctx, t.client, t.baseURL+"/bot"+t.token+"/sendMessage", map[string]interface{}{
+		"chat_id":                  t.chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+}
+
+// Slack posts messages to a Slack incoming webhook
+type Slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlack creates a sender posting to webhookURL
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Sender
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+// Send implements Sender
+func (s *Slack) Send(ctx context.Context, text string) error {
+	return postJSON(ctx, s.client, s.webhookURL, map[string]interface{}{"text": text})
+}
+
+// postJSON posts body as JSON, failing on non-2xx statuses. Errors leave the
+// endpoint out: it holds the bot token or webhook secret.
+func postJSON(ctx context.Context, client *http.Client, endpoint string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return RedactURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return RedactURL(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RedactURL strips the URL a *url.Error names, keeping its operation and
+// cause, for errors of requests to URLs holding a secret
+func RedactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s request failed: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
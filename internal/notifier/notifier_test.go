@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Go9jaJobs/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	jobs := make([]models.Job, 12)
+	for i := range jobs {
+		jobs[i] = models.Job{Title: fmt.Sprintf("Go Engineer %d", i+1), Company: "Paystack", URL: "https://paystack.com/jobs"}
+	}
+	jobs[0].Location = "Lagos"
+
+	tmpl, err := ParseTemplate("")
+	assert.NoError(t, err)
+	text, err := Render(tmpl, NewDigest("jsearch", jobs))
+	assert.NoError(t, err)
+	assert.Contains(t, text, "12 new Go jobs from jsearch")
+	assert.Contains(t, text, "• Go Engineer 1 at Paystack (Lagos)")
+	assert.Contains(t, text, "• Go Engineer 10 at Paystack")
+	assert.NotContains(t, text, "Go Engineer 11")
+	assert.Contains(t, text, "…and 2 more")
+
+	tmpl, err = ParseTemplate("{{.Total}} from {{.Source}}")
+	assert.NoError(t, err)
+	text, err = Render(tmpl, NewDigest("linkedin", jobs[:1]))
+	assert.NoError(t, err)
+	assert.Equal(t, "1 from linkedin", text)
+
+	_, err = ParseTemplate("{{.Total")
+	assert.Error(t, err)
+}
+
+func TestSenders(t *testing.T) {
+	var bodies []map[string]interface{}
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	telegram := NewTelegram("123:abc", "@go9jajobs")
+	telegram.baseURL = server.URL
+	assert.NoError(t, telegram.Send(context.Background(), "hello"))
+	assert.Equal(t, "/bot123:abc/sendMessage", paths[0])
+	assert.Equal(t, "@go9jajobs", bodies[0]["chat_id"])
+	assert.Equal(t, "hello", bodies[0]["text"])
+
+	assert.NoError(t, NewSlack(server.URL+"/hook").Send(context.Background(), "hello"))
+	assert.Equal(t, "/hook", paths[1])
+	assert.Equal(t, map[string]interface{}{"text": "hello"}, bodies[1])
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	assert.Error(t, NewSlack(failing.URL).Send(context.Background(), "hello"))
+
+	// Transport errors do not reveal the bot token
+	failing.Close()
+	telegram.baseURL = failing.URL
+	err := telegram.Send(context.Background(), "hello")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "123:abc")
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"Go9jaJobs/internal/errorlog"
+	"Go9jaJobs/internal/notifier"
+)
+
+// cacheWarmDebounce is how long the cache warmer waits for further syncs
+// (e.g. the other sources of an "all" sync) before calling the endpoints
+const cacheWarmDebounce = 2 * time.Second
+
+// CacheWarmEvent is the event sent to cache-warm endpoints
+const CacheWarmEvent = "jobs.synced"
+
+// cacheWarmRequests carries synced sources to the cache warmer, if running.
+// It is buffered so requests never block a sync.
+var cacheWarmRequests = make(chan SyncResult, 16)
+
+// RequestCacheWarm asks the cache warmer, if running, to prime the caches
+// after a sync of result.Source saved jobs
+func RequestCacheWarm(result SyncResult) {
+	select {
+	case cacheWarmRequests <- result:
+	default:
+	}
+}
+
+// CacheWarmPayload is the JSON body posted to cache-warm endpoints
+type CacheWarmPayload struct {
+	Event     string   `json:"event"`
+	Sources   []string `json:"sources"`
+	Saved     int      `json:"saved"`
+	Timestamp string   `json:"timestamp"`
+}
+
+// CacheWarmer calls cache-warm endpoints (frontend revalidate webhooks, CDN
+// prefetch URLs) after syncs so the public site shows new jobs quickly
+type CacheWarmer struct {
+	urls   []string
+	secret string
+	client *http.Client
+}
+
+// NewCacheWarmer creates a CacheWarmer posting to urls, signing payloads with
+// secret when it is set
+func NewCacheWarmer(urls []string, secret string) *CacheWarmer {
+	return &CacheWarmer{
+		urls:   urls,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Warm posts a signed payload about the synced results to every endpoint. The
+// signature (X-Signature) is the hex HMAC-SHA256 of "<X-Timestamp>.<body>".
+// Returns how many endpoints answered with a 2xx status.
+func (c *CacheWarmer) Warm(ctx context.Context, results []SyncResult) int {
+	sources := make([]string, 0, len(results))
+	payload := CacheWarmPayload{Event: CacheWarmEvent, Timestamp: time.Now().UTC().Format(time.RFC3339)}
+	for _, result := range results {
+		sources = append(sources, result.Source)
+		payload.Saved += result.Saved
+	}
+	sort.Strings(sources)
+	payload.Sources = sources
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error encoding cache-warm payload: %v", err)
+		return 0
+	}
+
+	warmed := 0
+	for _, endpoint := range c.urls {
+		if err := c.post(ctx, endpoint, payload.Timestamp, body); err != nil {
+			// Endpoints often carry a revalidation token in their query, so
+			// only their host is logged
+			host := endpointHost(endpoint)
+			err = notifier.RedactURL(err)
+			log.Printf("Error warming cache at %s: %v", host, err)
+			errorlog.Record(errorlog.SubsystemNotifications, host, err)
+			continue
+		}
+		warmed++
+	}
+	return warmed
+}
+
+// post sends body to a single endpoint
+func (c *CacheWarmer) post(ctx context.Context, endpoint, timestamp string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Timestamp", timestamp)
+	if c.secret != "" {
+		req.Header.Set("X-Signature", SignCacheWarm(c.secret, timestamp, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignCacheWarm returns the signature of a cache-warm payload, for endpoints
+// to verify
+func SignCacheWarm(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// endpointHost returns the host of an endpoint, to group and log its errors
+// without the rest of its URL
+func endpointHost(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "invalid endpoint"
+}
+
+// Start warms the caches after syncs until stop is called. Syncs finishing
+// within cacheWarmDebounce of each other are reported together.
+func (c *CacheWarmer) Start() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		for {
+			var results []SyncResult
+			select {
+			case <-ctx.Done():
+				return
+			case result := <-cacheWarmRequests:
+				results = append(results, result)
+			}
+
+			timer := time.NewTimer(cacheWarmDebounce)
+		collect:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case result := <-cacheWarmRequests:
+					results = append(results, result)
+				case <-timer.C:
+					break collect
+				}
+			}
+
+			warmCtx, warmCancel := context.WithTimeout(ctx, time.Minute)
+			warmed := c.Warm(warmCtx, results)
+			warmCancel()
+			log.Printf("Warmed %d/%d cache endpoints after sync", warmed, len(c.urls))
+		}
+	}()
+
+	if c.secret == "" {
+		log.Printf("Warning: CACHE_WARM_SECRET not set, cache-warm payloads are unsigned")
+	}
+	log.Printf("Cache warmer started (%d endpoints)", len(c.urls))
+	return cancel
+}
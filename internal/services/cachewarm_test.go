@@ -0,0 +1,82 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"Go9jaJobs/internal/errorlog"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheWarmerWarm(t *testing.T) {
+	var (
+		payload   CacheWarmPayload
+		signature string
+		timestamp string
+		body      []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		json.Unmarshal(body, &payload)
+		signature = r.Header.Get("X-Signature")
+		timestamp = r.Header.Get("X-Timestamp")
+	}))
+	defer server.Close()
+
+	warmer := NewCacheWarmer([]string{server.URL + "/revalidate", server.URL + "/broken"}, "secret")
+	warmed := warmer.Warm(context.Background(), []SyncResult{
+		{Source: "jsearch", Saved: 3},
+		{Source: "indeed", Saved: 2},
+	})
+
+	// The failing endpoint does not stop the others
+	assert.Equal(t, 1, warmed)
+	assert.Equal(t, CacheWarmEvent, payload.Event)
+	assert.Equal(t, []string{"indeed", "jsearch"}, payload.Sources)
+	assert.Equal(t, 5, payload.Saved)
+	assert.Equal(t, payload.Timestamp, timestamp)
+	assert.Equal(t, SignCacheWarm("secret", timestamp, body), signature)
+	assert.NotEqual(t, SignCacheWarm("other", timestamp, body), signature)
+}
+
+func TestCacheWarmerRedactsEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	// The token of an unreachable endpoint is neither logged nor recorded
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	warmer := NewCacheWarmer([]string{server.URL + "/revalidate?secret=s3cr3t"}, "")
+	assert.Equal(t, 0, warmer.Warm(context.Background(), []SyncResult{{Source: "jsearch", Saved: 1}}))
+
+	assert.Contains(t, logged.String(), "Error warming cache at "+strings.TrimPrefix(server.URL, "http://"))
+	assert.NotContains(t, logged.String(), "s3cr3t")
+	recorded, _ := json.Marshal(errorlog.Recent(0))
+	assert.NotContains(t, string(recorded), "s3cr3t")
+}
+
+func TestCacheWarmerUnsigned(t *testing.T) {
+	signed := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, signed = r.Header["X-Signature"]
+	}))
+	defer server.Close()
+
+	warmer := NewCacheWarmer([]string{server.URL}, "")
+	assert.Equal(t, 1, warmer.Warm(context.Background(), []SyncResult{{Source: "jsearch", Saved: 1}}))
+	assert.False(t, signed)
+}
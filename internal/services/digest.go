@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/errorlog"
+)
+
+// digestWindow is the period the quota usage of a digest covers
+const digestWindow = 24 * time.Hour
+
+// digestErrors is how many recent errors per subsystem a digest lists
+const digestErrors = 5
+
+// SourceFreshness is when a sync source last synced. An enabled source is
+// stale when its last successful sync is older than its longest scheduler
+// interval.
+type SourceFreshness struct {
+	Source      string     `json:"source"`
+	Enabled     bool       `json:"enabled"`
+	LastSync    *time.Time `json:"last_sync"`
+	LastSuccess *time.Time `json:"last_success"`
+	Stale       bool       `json:"stale"`
+}
+
+// QuotaUsage is the number of syncs a source ran over the digest window
+// against the most its shortest scheduler interval allows, the polling rate
+// its provider's quota is configured for
+type QuotaUsage struct {
+	Source  string  `json:"source"`
+	Syncs   int     `json:"syncs"`
+	Failed  int     `json:"failed"`
+	Allowed int     `json:"allowed"`
+	Usage   float64 `json:"usage"`
+}
+
+// Digest is the health snapshot of the job board an operator checks each
+// morning
+type Digest struct {
+	Freshness []SourceFreshness           `json:"freshness"`
+	Quality   []db.SourceQuality          `json:"quality"`
+	Quota     []QuotaUsage                `json:"quota"`
+	Queues    *db.QueueDepths             `json:"queues"`
+	Errors    map[string][]errorlog.Entry `json:"errors"`
+	Window    string                      `json:"window"`
+	BuiltAt   time.Time                   `json:"built_at"`
+}
+
+// BuildDigest gathers the freshness and quota usage of every sync source,
+// the data quality of every job source, the queue depths and the recent
+// errors. cfg provides the scheduler bounds; without it no source is stale
+// and no quota is known.
+func BuildDigest(ctx context.Context, postgresDB *sql.DB, cfg *config.Config) (*Digest, error) {
+	now := time.Now()
+	activity, err := db.GetSyncActivity(ctx, postgresDB, now.Add(-digestWindow), SyncStatusSuccess)
+	if err != nil {
+		return nil, err
+	}
+	quality, err := db.GetSourceQuality(ctx, postgresDB)
+	if err != nil {
+		return nil, err
+	}
+	queues, err := db.GetQueueDepths(ctx, postgresDB)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &Digest{
+		Quality: quality,
+		Queues:  queues,
+		Errors:  errorlog.Recent(digestErrors),
+		Window:  digestWindow.String(),
+		BuiltAt: now,
+	}
+	for _, source := range Sources() {
+		bounds := schedulerBounds(cfg, source)
+		freshness := SourceFreshness{Source: source, Enabled: SourceEnabled(cfg, source)}
+		quota := QuotaUsage{Source: source}
+		if a, ok := activity[source]; ok {
+			freshness.LastSync, freshness.LastSuccess = a.LastSync, a.LastSuccess
+			quota.Syncs, quota.Failed = a.Syncs, a.Failed
+		}
+		if bounds.Max > 0 && freshness.Enabled {
+			freshness.Stale = freshness.LastSuccess == nil || now.Sub(*freshness.LastSuccess) > bounds.Max
+		}
+		if bounds.Min > 0 {
+			quota.Allowed = int(digestWindow / bounds.Min)
+		}
+		if quota.Allowed > 0 {
+			quota.Usage = math.Round(float64(quota.Syncs)/float64(quota.Allowed)*100) / 100
+		}
+		digest.Freshness = append(digest.Freshness, freshness)
+		digest.Quota = append(digest.Quota, quota)
+	}
+	return digest, nil
+}
+
+// schedulerBounds returns the interval bounds configured for source
+func schedulerBounds(cfg *config.Config, source string) config.IntervalBounds {
+	if cfg == nil {
+		return config.IntervalBounds{}
+	}
+	if b, ok := cfg.SchedulerBoundsBySource[source]; ok {
+		return b
+	}
+	return cfg.SchedulerBounds
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildDigest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	recent, old := now.Add(-2*time.Hour), now.Add(-100*time.Hour)
+	mock.ExpectQuery("^SELECT api_name, (.+) FROM job_sync_logs GROUP BY api_name$").
+		WithArgs(sqlmock.AnyArg(), SyncStatusSuccess).
+		WillReturnRows(sqlmock.NewRows([]string{"api_name", "syncs", "failed", "last_sync", "last_success"}).
+			AddRow("jsearch", 6, 1, recent, recent).
+			AddRow("indeed", 1, 1, recent, old))
+	mock.ExpectQuery("^SELECT source, (.+) FROM \\( SELECT (.+) FROM jobs WHERE exp_date IS NULL OR exp_date > NOW\\(\\) \\) active GROUP BY source ORDER BY source$").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "active", "with_salary", "with_location", "flagged", "complete"}).
+			AddRow("jsearch", 8, 6, 8, 1, 3))
+	mock.ExpectQuery("^SELECT \\(SELECT COUNT\\(\\*\\) FROM sync_runs WHERE status IN \\(\\$1, \\$2\\)\\)").
+		WithArgs("queued", "running").
+		WillReturnRows(sqlmock.NewRows([]string{"sync_runs", "language_audit", "subscriptions", "suggestions"}).
+			AddRow(1, 12, 3, 2))
+
+	cfg := &config.Config{
+		SchedulerBounds:         config.IntervalBounds{Min: time.Hour, Max: 72 * time.Hour},
+		SchedulerBoundsBySource: map[string]config.IntervalBounds{"jsearch": {Min: 4 * time.Hour, Max: 24 * time.Hour}},
+	}
+	digest, err := BuildDigest(context.Background(), db, cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	freshness := map[string]SourceFreshness{}
+	for _, f := range digest.Freshness {
+		freshness[f.Source] = f
+	}
+	assert.Len(t, freshness, len(Sources()))
+	assert.False(t, freshness["jsearch"].Stale)
+	assert.True(t, freshness["indeed"].Stale)
+	// Never synced
+	assert.True(t, freshness["linkedin"].Stale)
+	assert.Nil(t, freshness["linkedin"].LastSync)
+
+	for _, q := range digest.Quota {
+		if q.Source == "jsearch" {
+			assert.Equal(t, QuotaUsage{Source: "jsearch", Syncs: 6, Failed: 1, Allowed: 6, Usage: 1}, q)
+		}
+	}
+	assert.Equal(t, 0.38, digest.Quality[0].Score)
+	assert.Equal(t, 12, digest.Queues.LanguageAudit)
+	assert.Equal(t, "24h0m0s", digest.Window)
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/enrichment"
+)
+
+const (
+	// enrichmentBatchSize is how many companies a single enrichment pass handles
+	enrichmentBatchSize = 100
+	// enrichmentConcurrency bounds parallel lookups against BrandFetch/homepages
+	enrichmentConcurrency = 4
+	// enrichmentRecheck is how long a company without a logo is left alone
+	// before it is looked up again
+	enrichmentRecheck = 7 * 24 * time.Hour
+)
+
+// enrichmentRequests wakes the company enricher after jobs were saved. It is
+// buffered so requests coalesce and never block a sync.
+var enrichmentRequests = make(chan struct{}, 1)
+
+// RequestEnrichment asks the company enricher, if running, to run soon
+func RequestEnrichment() {
+	select {
+	case enrichmentRequests <- struct{}{}:
+	default:
+	}
+}
+
+// cachedCompany is a lookup result (possibly a miss) and when it was made
+type cachedCompany struct {
+	details  *enrichment.CompanyDetails
+	cachedAt time.Time
+}
+
+// CompanyEnricher fills in the logos of saved jobs in the background, outside
+// of the save transaction, caching lookups by domain
+type CompanyEnricher struct {
+	db     *sql.DB
+	lookup func(ctx context.Context, companyURL string) (*enrichment.CompanyDetails, error)
+
+	mu    sync.Mutex
+	cache map[string]cachedCompany
+}
+
+// NewCompanyEnricher creates a CompanyEnricher using enricher for lookups
+func NewCompanyEnricher(postgresDB *sql.DB, enricher *enrichment.Enricher) *CompanyEnricher {
+	return &CompanyEnricher{
+		db:     postgresDB,
+		lookup: enricher.Lookup,
+		cache:  make(map[string]cachedCompany),
+	}
+}
+
+// RunOnce enriches one batch of jobs without logos, returning how many jobs
+// got a logo
+func (e *CompanyEnricher) RunOnce(ctx context.Context) (int64, error) {
+	urls, err := db.FindCompanyURLsMissingLogos(ctx, e.db, enrichmentRecheck, enrichmentBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// Group URLs by domain so each company is looked up once
+	byDomain := make(map[string][]string)
+	for _, companyURL := range urls {
+		if domain := enrichment.CompanyDomain(companyURL); domain != "" {
+			byDomain[domain] = append(byDomain[domain], companyURL)
+		}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		updated int64
+		sem     = make(chan struct{}, enrichmentConcurrency)
+	)
+	for domain, companyURLs := range byDomain {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(domain string, companyURLs []string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			details, err := e.company(ctx, domain, companyURLs[0])
+			if err != nil {
+				// Left unchecked, so the next pass looks the company up again
+				log.Printf("Error looking up company %s: %v", domain, err)
+				return
+			}
+			var logo, source string
+			if details != nil {
+				logo, source = details.LogoURL, details.Source
+			}
+
+			for _, companyURL := range companyURLs {
+				n, err := db.SetCompanyLogo(ctx, e.db, companyURL, logo, source)
+				if err != nil {
+					log.Printf("Error setting logo for %s: %v", companyURL, err)
+					continue
+				}
+				if logo != "" {
+					mu.Lock()
+					updated += n
+					mu.Unlock()
+				}
+			}
+		}(domain, companyURLs)
+	}
+	wg.Wait()
+
+	if len(byDomain) > 0 {
+		log.Printf("Company enrichment: %d companies checked, %d job logos set", len(byDomain), updated)
+	}
+	return updated, nil
+}
+
+// company returns the details of a domain from the in-memory cache, the
+// company_details table or, failing both, a fresh lookup that is stored. A
+// failed lookup is returned as an error and not cached.
+func (e *CompanyEnricher) company(ctx context.Context, domain, companyURL string) (*enrichment.CompanyDetails, error) {
+	e.mu.Lock()
+	cached, ok := e.cache[domain]
+	e.mu.Unlock()
+	if ok && time.Since(cached.cachedAt) < enrichmentRecheck {
+		return cached.details, nil
+	}
+
+	details, err := db.GetCompanyDetails(ctx, e.db, domain)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error reading company details for %s: %v", domain, err)
+	}
+	if details == nil || details.LogoURL == "" {
+		details, err = e.lookup(ctx, companyURL)
+		if err != nil {
+			return nil, err
+		}
+		if details != nil {
+			if err := db.SaveCompanyDetails(ctx, e.db, details); err != nil {
+				log.Printf("Error saving company details for %s: %v", domain, err)
+			}
+		}
+	}
+
+	e.mu.Lock()
+	e.cache[domain] = cachedCompany{details: details, cachedAt: time.Now()}
+	e.mu.Unlock()
+	return details, nil
+}
+
+// Start runs an enrichment pass every interval and whenever jobs are saved,
+// until the returned stop function is called
+func (e *CompanyEnricher) Start(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-enrichmentRequests:
+			}
+
+			runCtx, runCancel := context.WithTimeout(ctx, 10*time.Minute)
+			if _, err := e.RunOnce(runCtx); err != nil {
+				log.Printf("Error enriching companies: %v", err)
+			}
+			runCancel()
+		}
+	}()
+
+	log.Printf("Company enricher started (every %s and after each sync)", interval)
+	return cancel
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"Go9jaJobs/internal/enrichment"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompanyEnricherRunOnce(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	// Domains are processed concurrently
+	mock.MatchExpectationsInOrder(false)
+
+	var lookups int32
+	enricher := &CompanyEnricher{
+		db: postgresDB,
+		lookup: func(ctx context.Context, companyURL string) (*enrichment.CompanyDetails, error) {
+			atomic.AddInt32(&lookups, 1)
+			return &enrichment.CompanyDetails{Domain: "flutterwave.com", LogoURL: "https://cdn.example/flw.png", Source: enrichment.SourceBrandFetch}, nil
+		},
+		cache: make(map[string]cachedCompany),
+	}
+
+	mock.ExpectQuery("^SELECT company_url FROM jobs WHERE (.+) LIMIT \\$2$").
+		WillReturnRows(sqlmock.NewRows([]string{"company_url"}).
+			AddRow("https://paystack.com/careers").
+			AddRow("paystack.com").
+			AddRow("https://flutterwave.com"))
+
+	// Paystack is already known, Flutterwave is looked up and stored
+	mock.ExpectQuery("^SELECT (.+) FROM company_details WHERE domain = \\$1$").
+		WithArgs("paystack.com").
+		WillReturnRows(sqlmock.NewRows([]string{"logo_url", "description", "theme_color", "source"}).
+			AddRow("https://cdn.example/paystack.png", "", "", enrichment.SourceBrandFetch))
+	mock.ExpectQuery("^SELECT (.+) FROM company_details WHERE domain = \\$1$").
+		WithArgs("flutterwave.com").
+		WillReturnRows(sqlmock.NewRows([]string{"logo_url", "description", "theme_color", "source"}))
+	mock.ExpectExec("^INSERT INTO company_details").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec("^UPDATE jobs SET company_logo").
+		WithArgs("https://paystack.com/careers", "https://cdn.example/paystack.png", enrichment.SourceBrandFetch).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("^UPDATE jobs SET company_logo").
+		WithArgs("paystack.com", "https://cdn.example/paystack.png", enrichment.SourceBrandFetch).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE jobs SET company_logo").
+		WithArgs("https://flutterwave.com", "https://cdn.example/flw.png", enrichment.SourceBrandFetch).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	updated, err := enricher.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), updated)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Known domains are served from the cache on later passes
+	mock.ExpectQuery("^SELECT company_url FROM jobs").
+		WillReturnRows(sqlmock.NewRows([]string{"company_url"}).AddRow("https://www.flutterwave.com/jobs"))
+	mock.ExpectExec("^UPDATE jobs SET company_logo").
+		WithArgs("https://www.flutterwave.com/jobs", "https://cdn.example/flw.png", enrichment.SourceBrandFetch).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	updated, err = enricher.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&lookups))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompanyEnricherRunOnceLookupError(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	var lookups int32
+	enricher := &CompanyEnricher{
+		db: postgresDB,
+		lookup: func(ctx context.Context, companyURL string) (*enrichment.CompanyDetails, error) {
+			atomic.AddInt32(&lookups, 1)
+			return nil, errors.New("non-200 status for moniepoint.com: 429")
+		},
+		cache: make(map[string]cachedCompany),
+	}
+
+	// A failed lookup neither stamps the jobs as checked nor is cached, so
+	// the next pass looks the company up again
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("^SELECT company_url FROM jobs").
+			WillReturnRows(sqlmock.NewRows([]string{"company_url"}).AddRow("https://moniepoint.com"))
+		mock.ExpectQuery("^SELECT (.+) FROM company_details WHERE domain = \\$1$").
+			WithArgs("moniepoint.com").
+			WillReturnRows(sqlmock.NewRows([]string{"logo_url", "description", "theme_color", "source"}))
+
+		updated, err := enricher.RunOnce(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), updated)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequestEnrichmentDoesNotBlock(t *testing.T) {
+	// Requests coalesce when nobody is consuming them
+	RequestEnrichment()
+	RequestEnrichment()
+	assert.Len(t, enrichmentRequests, 1)
+	<-enrichmentRequests
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"Go9jaJobs/internal/db"
+)
+
+// ExpireJobs archives all jobs whose expiry date has passed
+func ExpireJobs(ctx context.Context, postgresDB *sql.DB) (int64, error) {
+	archived, err := db.ArchiveExpiredJobs(ctx, postgresDB)
+	if err != nil {
+		log.Println("Error archiving expired jobs:", err)
+		return 0, err
+	}
+	return archived, nil
+}
+
+// StartExpirySweeper archives expired jobs every interval until the returned
+// stop function is called
+func StartExpirySweeper(postgresDB *sql.DB, interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sweepCtx, sweepCancel := context.WithTimeout(ctx, time.Minute)
+				ExpireJobs(sweepCtx, postgresDB)
+				sweepCancel()
+			}
+		}
+	}()
+
+	log.Printf("Job expiry sweeper started (every %s)", interval)
+	return cancel
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/errorlog"
+	"Go9jaJobs/internal/storage"
+
+	"github.com/go-co-op/gocron"
+)
+
+// SnapshotSyncName is the api_name of snapshot exports in job_sync_logs
+const SnapshotSyncName = "snapshot_export"
+
+// snapshotPartSize is the multipart part size of snapshots, and so about
+// the memory an export holds
+const snapshotPartSize = 8 << 20
+
+// snapshotTimeout bounds a snapshot export
+const snapshotTimeout = time.Hour
+
+// Snapshot is the outcome of a snapshot export
+type Snapshot struct {
+	Key   string `json:"key"`
+	Jobs  int    `json:"jobs"`
+	Bytes int64  `json:"bytes"`
+}
+
+// snapshotKey names the snapshot taken at t under prefix
+func snapshotKey(prefix string, t time.Time) string {
+	return prefix + "jobs-" + t.UTC().Format("20060102T150405Z") + ".jsonl.gz"
+}
+
+// ExportSnapshot streams every job as gzipped JSON Lines to a new object
+// under prefix, recording the outcome in job_sync_logs and alerting on
+// failure. A failed export leaves no partial object behind.
+func ExportSnapshot(ctx context.Context, postgresDB *sql.DB, store *storage.S3, prefix string) (*Snapshot, error) {
+	snapshot, err := exportSnapshot(ctx, postgresDB, store, snapshotKey(prefix, time.Now()))
+	if err != nil {
+		err = fmt.Errorf("exporting jobs snapshot: %w", err)
+		log.Printf("ALERT: %v", err)
+		errorlog.Record(errorlog.SubsystemExport, "", err)
+		db.LogAPISync(postgresDB, SnapshotSyncName, 0, SyncStatusFailed, err.Error(), nil)
+		return nil, err
+	}
+
+	log.Printf("Exported %d jobs (%d bytes) to %s", snapshot.Jobs, snapshot.Bytes, snapshot.Key)
+	db.LogAPISync(postgresDB, SnapshotSyncName, snapshot.Jobs, SyncStatusSuccess, "", nil)
+	return snapshot, nil
+}
+
+// exportSnapshot is ExportSnapshot without the outcome logging
+func exportSnapshot(ctx context.Context, postgresDB *sql.DB, store *storage.S3, key string) (*Snapshot, error) {
+	upload, err := store.NewUpload(ctx, key, "application/gzip", snapshotPartSize)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(upload)
+	count, err := db.ExportJobs(ctx, postgresDB, gz)
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		upload.Abort()
+		return nil, err
+	}
+	if err := upload.Close(); err != nil {
+		return nil, err
+	}
+	return &Snapshot{Key: key, Jobs: count, Bytes: upload.Size()}, nil
+}
+
+// StartSnapshotExporter exports a snapshot every day at (UTC "HH:MM")
+// until the returned stop function is called
+func StartSnapshotExporter(postgresDB *sql.DB, store *storage.S3, prefix, at string) (stop func(), err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := gocron.NewScheduler(time.UTC)
+
+	_, err = scheduler.Every(1).Day().At(at).SingletonMode().Do(func() {
+		exportCtx, exportCancel := context.WithTimeout(ctx, snapshotTimeout)
+		defer exportCancel()
+		ExportSnapshot(exportCtx, postgresDB, store, prefix)
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	scheduler.StartAsync()
+
+	log.Printf("Jobs snapshot export started (daily at %s UTC)", at)
+	return func() {
+		cancel()
+		scheduler.Stop()
+	}, nil
+}
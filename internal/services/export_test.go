@@ -0,0 +1,87 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/storage"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotKey(t *testing.T) {
+	at := time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC)
+	assert.Equal(t, "snapshots/jobs-20261017T020000Z.jsonl.gz", snapshotKey("snapshots/", at))
+}
+
+func TestExportSnapshot(t *testing.T) {
+	var object []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Has("uploads"):
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>u1</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == "PUT":
+			object, _ = io.ReadAll(r.Body)
+			w.Header().Set("ETag", `"e1"`)
+		case r.Method == "POST":
+			fmt.Fprint(w, `<CompleteMultipartUploadResult/>`)
+		}
+	}))
+	defer server.Close()
+	store := storage.NewS3(server.URL, "us-east-1", "exports", "AKID", "secret")
+
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	mock.ExpectQuery(`^SELECT row_to_json`).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).
+			AddRow([]byte(`{"id":"a"}`)).
+			AddRow([]byte(`{"id":"b"}`)))
+	mock.ExpectExec("INSERT INTO job_sync_logs").
+		WithArgs(SnapshotSyncName, 2, SyncStatusSuccess, "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	snapshot, err := ExportSnapshot(context.Background(), postgresDB, store, "snapshots/")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, snapshot.Jobs)
+	assert.True(t, strings.HasPrefix(snapshot.Key, "snapshots/jobs-"))
+	assert.Equal(t, int64(len(object)), snapshot.Bytes)
+
+	gz, err := gzip.NewReader(bytes.NewReader(object))
+	assert.NoError(t, err)
+	lines, _ := io.ReadAll(gz)
+	assert.Equal(t, "{\"id\":\"a\"}\n{\"id\":\"b\"}\n", string(lines))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportSnapshotFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+	}))
+	defer server.Close()
+	store := storage.NewS3(server.URL, "us-east-1", "exports", "AKID", "wrong")
+
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	// The failure is recorded in the sync log
+	mock.ExpectExec("INSERT INTO job_sync_logs").
+		WithArgs(SnapshotSyncName, 0, SyncStatusFailed, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err = ExportSnapshot(context.Background(), postgresDB, store, "snapshots/")
+	assert.ErrorContains(t, err, "AccessDenied: Access Denied")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/fetcher"
+	"Go9jaJobs/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Import file formats
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+)
+
+// maxImportRows bounds the size of a single import
+const maxImportRows = 5000
+
+// importFields lists the job fields an import mapping can fill
+var importFields = []string{
+	"job_id", "title", "company", "company_url", "company_logo", "location", "country", "state",
+	"description", "url", "salary", "job_type", "employment_type", "is_remote", "posted_at", "exp_date",
+}
+
+// importDateFormats are the layouts accepted for posted_at and exp_date
+var importDateFormats = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// ImportRowError reports why a row of an import was rejected
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	Rows  int `json:"rows"`
+	Valid int `json:"valid"`
+	Saved int `json:"saved"`
+	// Skipped counts valid rows dropped by the save pipeline (duplicates,
+	// blocked companies, jobs matching no track or expired jobs)
+	Skipped int              `json:"skipped"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// ParseImportMapping parses a mapping spec, a JSON object from job field to
+// the CSV column or JSON key holding it. Unmapped fields use their own name.
+func ParseImportMapping(spec string) (map[string]string, error) {
+	mapping := make(map[string]string, len(importFields))
+	for _, field := range importFields {
+		mapping[field] = field
+	}
+	if strings.TrimSpace(spec) == "" {
+		return mapping, nil
+	}
+
+	var custom map[string]string
+	if err := json.Unmarshal([]byte(spec), &custom); err != nil {
+		return nil, fmt.Errorf("invalid mapping: %v", err)
+	}
+	for field, column := range custom {
+		if _, ok := mapping[field]; !ok {
+			return nil, fmt.Errorf("unknown field in mapping: %s", field)
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+// ImportJobs reads jobs from a CSV or JSON file, maps them with mapping and
+// runs them through the standard save pipeline under source
+func ImportJobs(ctx context.Context, postgresDB *sql.DB, format string, r io.Reader, mapping map[string]string, source string) (*ImportReport, error) {
+	records, err := readImportRecords(format, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > maxImportRows {
+		return nil, fmt.Errorf("too many rows: %d (max %d)", len(records), maxImportRows)
+	}
+
+	report := &ImportReport{Rows: len(records), Errors: []ImportRowError{}}
+	now := time.Now()
+
+	var jobs []models.Job
+	for i, record := range records {
+		job, err := importJob(record, mapping, source, now)
+		if err != nil {
+			report.Errors = append(report.Errors, ImportRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	report.Valid = len(jobs)
+
+	if len(jobs) > 0 {
+		saved, err := db.SaveJobsToDB(ctx, postgresDB, jobs)
+		report.Saved = saved
+		report.Skipped = report.Valid - saved
+		if saved > 0 {
+			jobSaves.Add(1)
+			RequestEnrichment()
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// readImportRecords decodes the rows of an import file into column → value maps
+func readImportRecords(format string, r io.Reader) ([]map[string]string, error) {
+	switch format {
+	case ImportFormatCSV:
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		if len(rows) == 0 {
+			return nil, errors.New("empty CSV file")
+		}
+
+		header := rows[0]
+		records := make([]map[string]string, 0, len(rows)-1)
+		for _, row := range rows[1:] {
+			record := make(map[string]string, len(header))
+			for i, column := range header {
+				if i < len(row) {
+					record[strings.TrimSpace(column)] = row[i]
+				}
+			}
+			records = append(records, record)
+		}
+		return records, nil
+
+	case ImportFormatJSON:
+		// Numbers are kept as written, so that an id like 1234567 is not
+		// read as a float and imported as 1.234567e+06
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+		var items []map[string]interface{}
+		if err := decoder.Decode(&items); err != nil {
+			return nil, fmt.Errorf("invalid JSON, expected an array of objects: %v", err)
+		}
+
+		records := make([]map[string]string, 0, len(items))
+		for _, item := range items {
+			record := make(map[string]string, len(item))
+			for key, value := range item {
+				switch value := value.(type) {
+				case nil:
+				case string:
+					record[key] = value
+				case json.Number:
+					record[key] = value.String()
+				default:
+					record[key] = fmt.Sprint(value)
+				}
+			}
+			records = append(records, record)
+		}
+		return records, nil
+	}
+
+	return nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+// importJob builds a job from a mapped import record
+func importJob(record map[string]string, mapping map[string]string, source string, now time.Time) (models.Job, error) {
+	get := func(field string) string {
+		return strings.TrimSpace(record[mapping[field]])
+	}
+
+	job := models.Job{
+		ID:             uuid.New().String(),
+		JobID:          get("job_id"),
+		Title:          get("title"),
+		Company:        get("company"),
+		CompanyURL:     get("company_url"),
+		CompanyLogo:    get("company_logo"),
+		Location:       get("location"),
+		Country:        get("country"),
+		State:          get("state"),
+		Description:    get("description"),
+		URL:            get("url"),
+		Salary:         get("salary"),
+		JobType:        get("job_type"),
+		EmploymentType: get("employment_type"),
+		Source:         source,
+		DateGotten:     now,
+		PostedAt:       now,
+		ExpDate:        fetcher.InferExpiry("", now),
+	}
+
+	if job.Title == "" || job.Company == "" {
+		return job, errors.New("title and company are required")
+	}
+	if job.JobID == "" {
+		job.JobID = job.ID
+	}
+
+	if remote := get("is_remote"); remote != "" {
+		isRemote, err := strconv.ParseBool(remote)
+		if err != nil {
+			return job, fmt.Errorf("invalid is_remote: %s", remote)
+		}
+		job.IsRemote = isRemote
+	}
+
+	if posted := get("posted_at"); posted != "" {
+		postedAt, err := parseImportDate(posted)
+		if err != nil {
+			return job, fmt.Errorf("invalid posted_at: %s", posted)
+		}
+		job.PostedAt = postedAt
+	}
+
+	if exp := get("exp_date"); exp != "" {
+		expDate, err := parseImportDate(exp)
+		if err != nil {
+			return job, fmt.Errorf("invalid exp_date: %s", exp)
+		}
+		job.ExpDate = expDate
+	}
+
+	if raw, err := json.Marshal(record); err == nil {
+		job.RawData = string(raw)
+	}
+
+	return job, nil
+}
+
+func parseImportDate(value string) (time.Time, error) {
+	for _, layout := range importDateFormats {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date: %s", value)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImportMapping(t *testing.T) {
+	mapping, err := ParseImportMapping("")
+	assert.NoError(t, err)
+	assert.Equal(t, "title", mapping["title"])
+
+	mapping, err = ParseImportMapping(`{"title": "Role", "company": "Employer"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "Role", mapping["title"])
+	assert.Equal(t, "Employer", mapping["company"])
+	assert.Equal(t, "url", mapping["url"])
+
+	_, err = ParseImportMapping(`{"salary_max": "Pay"}`)
+	assert.EqualError(t, err, "unknown field in mapping: salary_max")
+
+	_, err = ParseImportMapping(`not json`)
+	assert.Error(t, err)
+}
+
+func TestReadImportRecords(t *testing.T) {
+	csvFile := "Role,Employer,Remote\nGo Engineer,Paystack,true\nBackend Dev,\"Moniepoint, Inc\",false\n"
+	records, err := readImportRecords(ImportFormatCSV, strings.NewReader(csvFile))
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "Moniepoint, Inc", records[1]["Employer"])
+
+	jsonFile := `[{"title": "Go Engineer", "company": "Paystack", "is_remote": true, "salary": null, "job_id": 1234567, "rating": 4.50}]`
+	records, err = readImportRecords(ImportFormatJSON, strings.NewReader(jsonFile))
+	assert.NoError(t, err)
+	assert.Equal(t, "true", records[0]["is_remote"])
+	assert.Equal(t, "1234567", records[0]["job_id"], "numbers are kept as written")
+	assert.Equal(t, "4.50", records[0]["rating"])
+	_, hasSalary := records[0]["salary"]
+	assert.False(t, hasSalary)
+
+	_, err = readImportRecords(ImportFormatJSON, strings.NewReader(`{"title": "not an array"}`))
+	assert.Error(t, err)
+	_, err = readImportRecords("xml", strings.NewReader(""))
+	assert.EqualError(t, err, "unsupported format: xml")
+}
+
+func TestImportJob(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	mapping, _ := ParseImportMapping(`{"title": "Role", "company": "Employer"}`)
+
+	job, err := importJob(map[string]string{
+		"Role":      "Go Engineer",
+		"Employer":  "Paystack",
+		"is_remote": "true",
+		"posted_at": "2025-02-20",
+		"exp_date":  "2025-04-01",
+	}, mapping, "partner", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "Go Engineer", job.Title)
+	assert.Equal(t, "partner", job.Source)
+	assert.True(t, job.IsRemote)
+	assert.Equal(t, time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC), job.PostedAt)
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), job.ExpDate)
+	assert.Equal(t, job.ID, job.JobID)
+
+	job, err = importJob(map[string]string{"Role": "Go Engineer", "Employer": "Paystack"}, mapping, "partner", now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(30*24*time.Hour), job.ExpDate, "jobs without an expiry get the default lifetime")
+
+	_, err = importJob(map[string]string{"Role": "Go Engineer"}, mapping, "partner", now)
+	assert.EqualError(t, err, "title and company are required")
+
+	_, err = importJob(map[string]string{"Role": "Go Engineer", "Employer": "Paystack", "posted_at": "yesterday"}, mapping, "partner", now)
+	assert.EqualError(t, err, "invalid posted_at: yesterday")
+
+	_, err = importJob(map[string]string{"Role": "Go Engineer", "Employer": "Paystack", "exp_date": "next month"}, mapping, "partner", now)
+	assert.EqualError(t, err, "invalid exp_date: next month")
+}
+
+func TestImportJobsReportsInvalidRows(t *testing.T) {
+	mapping, _ := ParseImportMapping("")
+
+	// No valid rows means nothing reaches the database
+	report, err := ImportJobs(context.Background(), nil, ImportFormatCSV,
+		strings.NewReader("title,company\nGo Engineer,\n,Paystack\n"), mapping, "import")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Rows)
+	assert.Equal(t, 0, report.Valid)
+	assert.Equal(t, []ImportRowError{
+		{Row: 1, Error: "title and company are required"},
+		{Row: 2, Error: "title and company are required"},
+	}, report.Errors)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/fetcher"
+	"Go9jaJobs/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// DefaultIngestSource is the source of ingested jobs that do not name one
+const DefaultIngestSource = "ingest"
+
+// IngestJobs runs jobs pushed by an external scraper, in the canonical Job
+// schema, through the standard save pipeline. Rows and their fields are
+// validated like imports; the save pipeline recomputes the fields derived from
+// the description.
+func IngestJobs(ctx context.Context, postgresDB *sql.DB, jobs []models.Job) (*ImportReport, error) {
+	if len(jobs) > maxImportRows {
+		return nil, fmt.Errorf("too many jobs: %d (max %d)", len(jobs), maxImportRows)
+	}
+
+	report := &ImportReport{Rows: len(jobs), Errors: []ImportRowError{}}
+	now := time.Now()
+
+	valid := make([]models.Job, 0, len(jobs))
+	for i, job := range jobs {
+		job, err := ingestJob(job, now)
+		if err != nil {
+			report.Errors = append(report.Errors, ImportRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		valid = append(valid, job)
+	}
+	report.Valid = len(valid)
+
+	if len(valid) > 0 {
+		saved, err := db.SaveJobsToDB(ctx, postgresDB, valid)
+		report.Saved = saved
+		report.Skipped = report.Valid - saved
+		if saved > 0 {
+			jobSaves.Add(1)
+			RequestEnrichment()
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// ingestJob validates a pushed job and fills in what the server owns. A fresh
+// ID keeps a scraper from overwriting existing rows.
+func ingestJob(job models.Job, now time.Time) (models.Job, error) {
+	job.Title = strings.TrimSpace(job.Title)
+	job.Company = strings.TrimSpace(job.Company)
+	if job.Title == "" || job.Company == "" {
+		return job, errors.New("title and company are required")
+	}
+
+	raw, _ := json.Marshal(job)
+
+	job.ID = uuid.New().String()
+	if strings.TrimSpace(job.JobID) == "" {
+		job.JobID = job.ID
+	}
+	job.Source = strings.TrimSpace(job.Source)
+	if job.Source == "" {
+		job.Source = DefaultIngestSource
+	}
+	job.DateGotten = now
+	if job.PostedAt.IsZero() {
+		job.PostedAt = now
+	}
+	if job.ExpDate.IsZero() {
+		job.ExpDate = fetcher.InferExpiry("", now)
+	}
+	if job.RawData == "" {
+		job.RawData = string(raw)
+	}
+
+	return job, nil
+}
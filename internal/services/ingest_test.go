@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestJob(t *testing.T) {
+	now := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+
+	job, err := ingestJob(models.Job{ID: "existing-row", Title: " Go Engineer ", Company: "Paystack"}, now)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "existing-row", job.ID)
+	assert.Equal(t, job.ID, job.JobID)
+	assert.Equal(t, "Go Engineer", job.Title)
+	assert.Equal(t, DefaultIngestSource, job.Source)
+	assert.Equal(t, now, job.PostedAt)
+	assert.True(t, job.ExpDate.After(now))
+	assert.Contains(t, job.RawData, `"id":"existing-row"`)
+
+	posted := now.AddDate(0, 0, -2)
+	job, err = ingestJob(models.Job{JobID: "js-42", Title: "Go Engineer", Company: "Kuda", Source: "scraper_x", PostedAt: posted}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "js-42", job.JobID)
+	assert.Equal(t, "scraper_x", job.Source)
+	assert.Equal(t, posted, job.PostedAt)
+
+	_, err = ingestJob(models.Job{Title: "Go Engineer"}, now)
+	assert.EqualError(t, err, "title and company are required")
+}
+
+func TestIngestJobsReportsInvalidRows(t *testing.T) {
+	// No valid rows means nothing reaches the database
+	report, err := IngestJobs(context.Background(), nil, []models.Job{{Title: "Go Engineer"}, {Company: "Paystack"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Rows)
+	assert.Equal(t, 0, report.Valid)
+	assert.Len(t, report.Errors, 2)
+
+	_, err = IngestJobs(context.Background(), nil, make([]models.Job, maxImportRows+1))
+	assert.Error(t, err)
+}
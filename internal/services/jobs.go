@@ -3,102 +3,370 @@ package services
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"Go9jaJobs/internal/config"
 	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/errorlog"
 	"Go9jaJobs/internal/fetcher"
+	"Go9jaJobs/internal/models"
 )
 
-// FetchAndSaveJSearch fetches and saves JSearch jobs
-func FetchAndSaveJSearch(jobFetcher *fetcher.JobFetcher, postgresDB *sql.DB) {
-	log.Println("Fetching JSearch jobs...")
+// syncTimeout bounds how long fetching and saving a single source may take
+const syncTimeout = 5 * time.Minute
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
+// jobSaves counts the syncs and imports that saved jobs
+var jobSaves atomic.Int64
 
-	jobs, err := jobFetcher.FetchJSearchJobs(ctx)
-	if err != nil {
-		log.Println("Error fetching JSearch jobs:", err)
-		db.LogAPISync(postgresDB, "JSearch", 0, "Failed", err.Error())
-		return
+// JobSaves returns how many syncs and imports saved jobs since start, so
+// caches built from the jobs table can tell when to rebuild
+func JobSaves() int64 {
+	return jobSaves.Load()
+}
+
+// Sync statuses, also stored in job_sync_logs
+const (
+	SyncStatusSuccess = "Success"
+	SyncStatusPartial = "Partial Success"
+	SyncStatusFailed  = "Failed"
+	// SyncStatusSkipped means the source was not fetched: it is disabled,
+	// already syncing, over its API budget or its circuit breaker is open
+	SyncStatusSkipped = "Skipped"
+)
+
+// ErrSyncRunning is returned when a sync of the same source is already running
+var ErrSyncRunning = errors.New("sync already running")
+
+// ErrSourceDisabled is returned for a sync of a source turned off with
+// ENABLE_<SOURCE>=false
+var ErrSourceDisabled = errors.New("source disabled")
+
+// SyncResult is the outcome of syncing a single source
+type SyncResult struct {
+	Source  string `json:"source"`
+	Fetched int    `json:"fetched"`
+	Saved   int    `json:"saved"`
+	// New counts the saved jobs stored for the first time, the yield of the sync
+	New    int    `json:"new"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// ErrorKind classifies upstream failures: rate_limited, unauthorized,
+	// upstream or circuit_open
+	ErrorKind string `json:"error_kind,omitempty"`
+	Duration  string `json:"duration"`
+	// Errors aggregates the errors of every stage, including the non-fatal
+	// ones (e.g. a failed duplicate check) that do not fail the sync
+	Errors []db.SyncError `json:"errors,omitempty"`
+}
+
+// fetchFuncs maps the source names accepted by the sync endpoint to the
+// JobFetcher method fetching that source
+var fetchFuncs = map[string]func(*fetcher.JobFetcher, context.Context) ([]models.Job, error){
+	"jsearch":        (*fetcher.JobFetcher).FetchJSearchJobs,
+	"indeed":         (*fetcher.JobFetcher).FetchIndeedJobs,
+	"linkedin":       (*fetcher.JobFetcher).FetchLinkedInJobs,
+	"apify_linkedin": (*fetcher.JobFetcher).FetchApifyLinkedInJobs,
+	"remoteok":       (*fetcher.JobFetcher).FetchRemoteOKJobs,
+	"weworkremotely": (*fetcher.JobFetcher).FetchWeWorkRemotelyJobs,
+	"greenhouse":     (*fetcher.JobFetcher).FetchGreenhouseJobs,
+	"lever":          (*fetcher.JobFetcher).FetchLeverJobs,
+	"jobberman":      (*fetcher.JobFetcher).FetchJobbermanJobs,
+}
+
+// Sources returns the names of all sources that can be synced, sorted
+func Sources() []string {
+	sources := make([]string, 0, len(fetchFuncs))
+	for source := range fetchFuncs {
+		sources = append(sources, source)
 	}
+	sort.Strings(sources)
+	return sources
+}
 
-	count, err := db.SaveJobsToDB(ctx, postgresDB, jobs)
-	if err != nil {
-		log.Println("Error saving JSearch jobs:", err)
-		db.LogAPISync(postgresDB, "JSearch", count, "Partial Success", err.Error())
-	} else {
-		log.Printf("Successfully saved %d JSearch jobs", count)
+// IsValidSource reports whether source can be synced
+func IsValidSource(source string) bool {
+	_, ok := fetchFuncs[source]
+	return ok
+}
+
+// SourceEnabled reports whether source is enabled in cfg, as every source
+// is unless turned off
+func SourceEnabled(cfg *config.Config, source string) bool {
+	if cfg == nil {
+		return true
 	}
+	enabled, ok := cfg.SourcesEnabled[source]
+	return !ok || enabled
 }
 
-// FetchAndSaveIndeed fetches and saves Indeed jobs
-func FetchAndSaveIndeed(jobFetcher *fetcher.JobFetcher, postgresDB *sql.DB) {
-	log.Println("Fetching Indeed jobs...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
+// EnabledSources returns the names of the sources enabled in cfg, sorted
+func EnabledSources(cfg *config.Config) []string {
+	var sources []string
+	for _, source := range Sources() {
+		if SourceEnabled(cfg, source) {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
 
-	jobs, err := jobFetcher.FetchIndeedJobs(ctx)
-	if err != nil {
-		log.Println("Error fetching Indeed jobs:", err)
-		db.LogAPISync(postgresDB, "Indeed", 0, "Failed", err.Error())
-		return
+// fetcherConfig returns the configuration of jobFetcher, nil without one
+func fetcherConfig(jobFetcher *fetcher.JobFetcher) *config.Config {
+	if jobFetcher == nil {
+		return nil
 	}
+	return jobFetcher.Config
+}
+
+// lockSync takes the lock of source, so that overlapping syncs (manual and
+// scheduled, or on several instances) never ingest the same source at once.
+// Returns ErrSyncRunning when another sync holds it.
+func lockSync(ctx context.Context, postgresDB *sql.DB, source string) (func(), error) {
+	release, err := db.LockSource(ctx, postgresDB, source)
+	if errors.Is(err, db.ErrLocked) {
+		return nil, ErrSyncRunning
+	}
+	return release, err
+}
 
-	count, err := db.SaveJobsToDB(ctx, postgresDB, jobs)
+// RunSync fetches and saves the jobs of a single source, logging the outcome
+// to job_sync_logs and the error log. It is skipped when the source is
+// already being synced.
+func RunSync(ctx context.Context, source string, jobFetcher *fetcher.JobFetcher, postgresDB *sql.DB) SyncResult {
+	if !IsValidSource(source) {
+		return SyncResult{Source: source, Status: SyncStatusFailed, Error: fmt.Sprintf("unknown source: %s", source)}
+	}
+	if !SourceEnabled(fetcherConfig(jobFetcher), source) {
+		log.Printf("Skipping %s sync: %v", source, ErrSourceDisabled)
+		return SyncResult{Source: source, Status: SyncStatusSkipped, Error: ErrSourceDisabled.Error()}
+	}
+
+	release, err := lockSync(ctx, postgresDB, source)
 	if err != nil {
-		log.Println("Error saving Indeed jobs:", err)
-		db.LogAPISync(postgresDB, "Indeed", count, "Partial Success", err.Error())
-	} else {
-		log.Printf("Successfully saved %d Indeed jobs", count)
+		status := SyncStatusFailed
+		if errors.Is(err, ErrSyncRunning) {
+			log.Printf("Skipping %s sync: %v", source, err)
+			status = SyncStatusSkipped
+		} else {
+			log.Printf("Error locking %s sync: %v", source, err)
+		}
+		return SyncResult{Source: source, Status: status, Error: err.Error()}
 	}
+	defer release()
+
+	return runSyncLocked(ctx, source, jobFetcher, postgresDB)
 }
 
-// FetchAndSaveLinkedIn fetches and saves LinkedIn jobs
-func FetchAndSaveLinkedIn(jobFetcher *fetcher.JobFetcher, postgresDB *sql.DB) {
-	log.Println("Fetching LinkedIn jobs...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+// runSyncLocked syncs source, the caller holding its lock
+func runSyncLocked(ctx context.Context, source string, jobFetcher *fetcher.JobFetcher, postgresDB *sql.DB) SyncResult {
+	started := time.Now()
+	result := SyncResult{Source: source}
+	fetch := fetchFuncs[source]
+
+	ctx, cancel := context.WithTimeout(ctx, syncTimeout)
 	defer cancel()
 
-	jobs, err := jobFetcher.FetchLinkedInJobs(ctx)
-	if err != nil {
-		log.Println("Error fetching LinkedIn jobs:", err)
-		db.LogAPISync(postgresDB, "LinkedIn", 0, "Failed", err.Error())
-		return
-	} else {
-		log.Println("Successfully fetched LinkedIn jobs")
+	// Sources over their monthly API budget are paused until the next month
+	cfg := fetcherConfig(jobFetcher)
+	if err := checkBudget(ctx, postgresDB, cfg, source); err != nil {
+		log.Printf("Skipping %s sync: %v", source, err)
+		result.Status = SyncStatusSkipped
+		result.Error = err.Error()
+		return result
 	}
 
-	count, err := db.SaveJobsToDB(ctx, postgresDB, jobs)
+	syncErrors := &db.SyncErrors{}
+	ctx = db.WithSyncErrors(ctx, syncErrors)
+	usage := &fetcher.UsageRecorder{}
+	ctx = fetcher.WithUsage(ctx, usage)
+	responses := &fetcher.ResponseRecorder{}
+	ctx = fetcher.WithResponses(ctx, responses)
+	completeness := &fetcher.Completeness{}
+	ctx = fetcher.WithCompleteness(ctx, completeness)
+
+	// Fetch only what was posted since the last successful sync, where the
+	// API filters by date
+	incremental := cfg != nil && cfg.FetchIncremental
+	if incremental {
+		since, err := db.GetLastSuccessfulSync(ctx, postgresDB, source)
+		if err != nil {
+			log.Printf("Error reading the last successful %s sync, fetching everything: %v", source, err)
+		} else if !since.IsZero() {
+			ctx = fetcher.WithSince(ctx, since)
+		}
+	}
+
+	log.Printf("Fetching %s jobs...", source)
+
+	jobs, err := jobFetcher.Guard(ctx, source, func(ctx context.Context) ([]models.Job, error) {
+		return fetch(jobFetcher, ctx)
+	})
+	recordUsage(ctx, postgresDB, cfg, source, usage)
+	storeResponses(ctx, postgresDB, cfg, source, responses)
+	// Sources failing repeatedly are not called until their breaker cools down
+	if errors.Is(err, fetcher.ErrCircuitOpen) {
+		log.Printf("Skipping %s sync: %v", source, err)
+		result.Status = SyncStatusSkipped
+		result.Error = err.Error()
+		result.ErrorKind = fetcher.ErrorKind(err)
+		result.Duration = time.Since(started).String()
+		return result
+	}
 	if err != nil {
-		log.Println("Error saving LinkedIn jobs:", err)
-		db.LogAPISync(postgresDB, "LinkedIn", count, "Partial Success", err.Error())
-	} else {
-		log.Printf("Successfully saved %d LinkedIn jobs", count)
+		switch {
+		case errors.Is(err, fetcher.ErrUnauthorized):
+			log.Printf("Error fetching %s jobs, check the API key: %v", source, err)
+		case errors.Is(err, fetcher.ErrRateLimited):
+			log.Printf("Error fetching %s jobs, rate limited after retries: %v", source, err)
+		default:
+			log.Printf("Error fetching %s jobs: %v", source, err)
+		}
+		syncErrors.Add(db.StageFetch, source, err)
+		result.Errors = syncErrors.List()
+		db.LogAPISync(postgresDB, source, 0, SyncStatusFailed, err.Error(), result.Errors)
+		errorlog.Record(errorlog.SubsystemFetcher, source, err)
+
+		result.Status = SyncStatusFailed
+		result.Error = err.Error()
+		result.ErrorKind = fetcher.ErrorKind(err)
+		result.Duration = time.Since(started).String()
+		return result
 	}
+	result.Fetched = len(jobs)
+	checkProviderSchema(ctx, source, jobFetcher, postgresDB, len(jobs))
+
+	count, saveErr := db.SaveJobsToDB(ctx, postgresDB, jobs)
+	result.Saved = count
+	result.Duration = time.Since(started).String()
+
+	// Logos are fetched and caches primed in the background once jobs are stored
+	if count > 0 {
+		if result.New, err = db.CountNewJobs(ctx, postgresDB, jobIDs(jobs), started); err != nil {
+			log.Printf("Error counting new %s jobs: %v", source, err)
+		}
+		jobSaves.Add(1)
+		RequestEnrichment()
+		RequestCacheWarm(result)
+		RequestNotification(source, started, jobs)
+	}
+	if saveErr != nil {
+		log.Printf("Error saving %s jobs: %v", source, saveErr)
+		syncErrors.Add(db.StageSave, source, saveErr)
+		result.Errors = syncErrors.List()
+		db.LogAPISync(postgresDB, source, count, SyncStatusPartial, saveErr.Error(), result.Errors)
+		errorlog.Record(errorlog.SubsystemSave, source, saveErr)
+
+		result.Status = SyncStatusPartial
+		result.Error = saveErr.Error()
+		return result
+	}
+
+	log.Printf("Successfully saved %d %s jobs", count, source)
+	result.Errors = syncErrors.List()
+	db.LogAPISync(postgresDB, source, count, SyncStatusSuccess, "", result.Errors)
+	// A fetch leaving jobs unread keeps the last window, so the next sync
+	// reaches back for them
+	if complete, reason := completeness.Complete(); incremental && !complete {
+		log.Printf("Not advancing the last successful %s sync: %s", source, reason)
+	} else if incremental {
+		if err := db.RecordSuccessfulSync(postgresDB, source, started, cfg.SchedulerDefaultInterval); err != nil {
+			log.Printf("Error recording the successful %s sync: %v", source, err)
+		}
+	}
+	result.Status = SyncStatusSuccess
+	return result
 }
-func FetchAndSaveApifyLinkedIn(jobFetcher *fetcher.JobFetcher, postgresDB *sql.DB) {
-	log.Println("Fetching LinkedIn jobs...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
 
-	jobs, err := jobFetcher.FetchApifyLinkedInJobs(ctx)
-	if err != nil {
-		log.Println("Error fetching apifyLinkedIn jobs:", err)
-		db.LogAPISync(postgresDB, "apifyLinkedIn", 0, "Failed", err.Error())
+// checkProviderSchema archives the structure of the response just fetched
+// from source and raises an admin alert (logged and shown by
+// /api/admin/errors) when fields were added or removed, so the mapping can be
+// fixed before data is silently lost. Empty responses are not compared, as
+// they lack every field of a job.
+func checkProviderSchema(ctx context.Context, source string, jobFetcher *fetcher.JobFetcher, postgresDB *sql.DB, fetched int) {
+	schema, ok := jobFetcher.LastSchema(source)
+	if !ok || fetched == 0 {
 		return
 	}
 
-	count, err := db.SaveJobsToDB(ctx, postgresDB, jobs)
+	change, err := db.RecordProviderSchema(ctx, postgresDB, source, schema.Fingerprint, schema.Fields)
 	if err != nil {
-		log.Println("Error saving apifyLinkedIn jobs:", err)
-		db.LogAPISync(postgresDB, "apifyLinkedIn", count, "Partial Success", err.Error())
-	} else {
-		log.Printf("Successfully saved %d apifyLinkedIn jobs", count)
+		log.Printf("Error recording %s response schema: %v", source, err)
+		return
+	}
+	if change == nil {
+		return
 	}
+
+	alert := fmt.Errorf("response schema changed: added %s, removed %s",
+		formatFields(change.Added), formatFields(change.Removed))
+	log.Printf("ALERT: %s %v", source, alert)
+	errorlog.Record(errorlog.SubsystemSchema, source, alert)
+}
+
+// formatFields lists field paths for an alert
+func formatFields(paths []string) string {
+	if len(paths) == 0 {
+		return "none"
+	}
+	return strings.Join(paths, ", ")
+}
+
+// jobIDs returns the IDs of jobs
+func jobIDs(jobs []models.Job) []string {
+	ids := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+	return ids
+}
+
+// RunSyncAll syncs every enabled source, SyncParallelism of them at a time,
+// returning their results in source order
+func RunSyncAll(ctx context.Context, jobFetcher *fetcher.JobFetcher, postgresDB *sql.DB) []SyncResult {
+	cfg := fetcherConfig(jobFetcher)
+	parallelism := 1
+	if cfg != nil && cfg.SyncParallelism > 0 {
+		parallelism = cfg.SyncParallelism
+	}
+
+	started := time.Now()
+	results := syncConcurrently(ctx, EnabledSources(cfg), parallelism, func(ctx context.Context, source string) SyncResult {
+		return RunSync(ctx, source, jobFetcher, postgresDB)
+	})
+
+	status, fetched, saved, _ := summarizeResults(results)
+	log.Printf("Synced %d sources in %s (%d at a time): %s, %d fetched, %d saved",
+		len(results), time.Since(started).Round(time.Second), parallelism, status, fetched, saved)
+	return results
 }
 
-//No longer neeeded as i will be using github actions to run the job
-// // StartJobScheduler runs job fetching on scheduled intervals using gocron
-//
+// syncConcurrently runs run for each source on a pool of parallelism
+// workers, returning the results in the order of sources
+func syncConcurrently(ctx context.Context, sources []string, parallelism int, run func(context.Context, string) SyncResult) []SyncResult {
+	results := make([]SyncResult, len(sources))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism && w < len(sources); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = run(ctx, sources[i])
+			}
+		}()
+	}
+	for i := range sources {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
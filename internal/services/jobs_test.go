@@ -1,2 +1,106 @@
 package services
 
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSources(t *testing.T) {
+	assert.Equal(t, []string{"apify_linkedin", "greenhouse", "indeed", "jobberman", "jsearch", "lever", "linkedin", "remoteok", "weworkremotely"}, Sources())
+
+	assert.True(t, IsValidSource("jsearch"))
+	assert.False(t, IsValidSource(""))
+	assert.False(t, IsValidSource("monster"))
+}
+
+func TestRunSyncUnknownSource(t *testing.T) {
+	result := RunSync(context.Background(), "monster", nil, nil)
+	assert.Equal(t, "monster", result.Source)
+	assert.Equal(t, SyncStatusFailed, result.Status)
+	assert.Equal(t, "unknown source: monster", result.Error)
+}
+
+func TestRunSyncAlreadyRunning(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").
+		WithArgs(sqlmock.AnyArg(), "jsearch").
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+	// The source is skipped without fetching or writing anything
+	result := RunSync(context.Background(), "jsearch", nil, db)
+	assert.Equal(t, SyncStatusSkipped, result.Status)
+	assert.Equal(t, ErrSyncRunning.Error(), result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnabledSources(t *testing.T) {
+	cfg := &config.Config{SourcesEnabled: map[string]bool{"jsearch": false, "indeed": true}}
+	assert.Equal(t, []string{"apify_linkedin", "greenhouse", "indeed", "jobberman", "lever", "linkedin", "remoteok", "weworkremotely"}, EnabledSources(cfg))
+	assert.Equal(t, Sources(), EnabledSources(nil))
+
+	// A disabled source is skipped before taking its lock
+	result := RunSync(context.Background(), "jsearch", fetcher.NewJobFetcher(cfg), nil)
+	assert.Equal(t, SyncStatusSkipped, result.Status)
+	assert.Equal(t, ErrSourceDisabled.Error(), result.Error)
+}
+
+func TestSyncConcurrently(t *testing.T) {
+	sources := []string{"greenhouse", "indeed", "jobberman", "jsearch", "lever", "remoteok"}
+
+	var running, peak atomic.Int32
+	results := syncConcurrently(context.Background(), sources, 2, func(ctx context.Context, source string) SyncResult {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return SyncResult{Source: source, Status: SyncStatusSuccess}
+	})
+
+	assert.Equal(t, int32(2), peak.Load())
+	if assert.Len(t, results, len(sources)) {
+		for i, result := range results {
+			assert.Equal(t, sources[i], result.Source)
+		}
+	}
+
+	assert.Empty(t, syncConcurrently(context.Background(), nil, 4, nil))
+}
+
+func TestSummarizeResults(t *testing.T) {
+	status, fetched, saved, errorMsg := summarizeResults([]SyncResult{
+		{Source: "jsearch", Fetched: 10, Saved: 4, Status: SyncStatusSuccess},
+		{Source: "indeed", Fetched: 5, Saved: 5, Status: SyncStatusSuccess},
+	})
+	assert.Equal(t, "done", status)
+	assert.Equal(t, 15, fetched)
+	assert.Equal(t, 9, saved)
+	assert.Empty(t, errorMsg)
+
+	status, _, _, errorMsg = summarizeResults([]SyncResult{
+		{Source: "jsearch", Fetched: 10, Saved: 4, Status: SyncStatusSuccess},
+		{Source: "indeed", Status: SyncStatusFailed, Error: "timeout"},
+	})
+	assert.Equal(t, "partial", status)
+	assert.Equal(t, "indeed: timeout", errorMsg)
+
+	status, _, _, _ = summarizeResults([]SyncResult{
+		{Source: "indeed", Status: SyncStatusFailed, Error: "timeout"},
+	})
+	assert.Equal(t, "failed", status)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/db"
+)
+
+// languageAuditBatchSize is how many jobs the language audit loads at once
+const languageAuditBatchSize = 200
+
+// AuditJobLanguage runs the inclusive language audit over every job not yet
+// audited (new jobs and changed descriptions), storing the flags per job.
+// Returns how many jobs were audited and how many of them were flagged.
+func AuditJobLanguage(ctx context.Context, postgresDB *sql.DB) (audited, flagged int, err error) {
+	for {
+		jobs, err := db.FindUnauditedJobs(ctx, postgresDB, languageAuditBatchSize)
+		if err != nil {
+			return audited, flagged, err
+		}
+		if len(jobs) == 0 {
+			break
+		}
+
+		for _, job := range jobs {
+			flags := analyzer.AuditLanguage(job.Title + "\n" + job.Description)
+			if err := db.SetLanguageFlags(ctx, postgresDB, job.ID, flags); err != nil {
+				return audited, flagged, err
+			}
+			audited++
+			if len(flags) > 0 {
+				flagged++
+			}
+		}
+
+		if len(jobs) < languageAuditBatchSize {
+			break
+		}
+	}
+
+	log.Printf("Language audit: %d jobs audited, %d flagged", audited, flagged)
+	return audited, flagged, nil
+}
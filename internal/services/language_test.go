@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditJobLanguage(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	mock.ExpectQuery("^SELECT id, title, (.+) FROM jobs WHERE language_flags IS NULL").
+		WithArgs(languageAuditBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description"}).
+			AddRow("job-1", "Golang Ninja", "Join our young team.").
+			AddRow("job-2", "Go Engineer", "You will build payment APIs."))
+	mock.ExpectExec("^UPDATE jobs SET language_flags = \\$2, updated_at = NOW\\(\\) WHERE id = \\$1$").
+		WithArgs("job-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Clean jobs are stored as audited with no flags
+	mock.ExpectExec("^UPDATE jobs SET language_flags = \\$2, updated_at = NOW\\(\\) WHERE id = \\$1$").
+		WithArgs("job-2", "[]").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	audited, flagged, err := AuditJobLanguage(context.Background(), postgresDB)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, audited)
+	assert.Equal(t, 1, flagged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"slices"
+	"text/template"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/errorlog"
+	"Go9jaJobs/internal/models"
+	"Go9jaJobs/internal/notifier"
+)
+
+// notification is a sync whose new jobs should be announced
+type notification struct {
+	source  string
+	started time.Time
+	ids     []string
+}
+
+// notificationRequests carries syncs that saved jobs to the notifier, if
+// running. It is buffered so requests never block a sync.
+var notificationRequests = make(chan notification, 16)
+
+// RequestNotification asks the notifier, if running, to post a digest of the
+// jobs a sync of source saved. started is when the sync began, so jobs that
+// were only refreshed are left out; jobs are those the sync fetched.
+func RequestNotification(source string, started time.Time, jobs []models.Job) {
+	select {
+	case notificationRequests <- notification{source: source, started: started, ids: jobIDs(jobs)}:
+	default:
+	}
+}
+
+// Notifier posts a digest of newly saved jobs to Telegram and/or Slack after
+// each sync
+type Notifier struct {
+	db      *sql.DB
+	senders []notifier.Sender
+	tmpl    *template.Template
+	// sources are the synced sources announced, nil for all
+	sources []string
+}
+
+// NewNotifier creates a Notifier rendering digests with tmpl and delivering
+// them through senders, for syncs of sources (all when empty)
+func NewNotifier(postgresDB *sql.DB, senders []notifier.Sender, tmpl *template.Template, sources []string) *Notifier {
+	return &Notifier{db: postgresDB, senders: senders, tmpl: tmpl, sources: sources}
+}
+
+// Notify posts the digest of the new jobs of a sync of source to every
+// sender. Returns how many senders delivered it; nothing is sent when the
+// source is not announced or the sync added no jobs.
+func (n *Notifier) Notify(ctx context.Context, source string, started time.Time, ids []string) int {
+	if len(n.sources) > 0 && !slices.Contains(n.sources, source) {
+		return 0
+	}
+
+	jobs, err := db.NewJobs(ctx, n.db, ids, started)
+	if err != nil {
+		log.Printf("Error loading new %s jobs to notify: %v", source, err)
+		errorlog.Record(errorlog.SubsystemNotifications, source, err)
+		return 0
+	}
+	if len(jobs) == 0 {
+		return 0
+	}
+
+	text, err := notifier.Render(n.tmpl, notifier.NewDigest(source, jobs))
+	if err != nil {
+		log.Printf("Error rendering %s digest: %v", source, err)
+		errorlog.Record(errorlog.SubsystemNotifications, source, err)
+		return 0
+	}
+
+	sent := 0
+	for _, sender := range n.senders {
+		if err := sender.Send(ctx, text); err != nil {
+			log.Printf("Error sending %s digest to %s: %v", source, sender.Name(), err)
+			errorlog.Record(errorlog.SubsystemNotifications, sender.Name(), err)
+			continue
+		}
+		sent++
+	}
+	log.Printf("Sent digest of %d new %s jobs to %d/%d channels", len(jobs), source, sent, len(n.senders))
+	return sent
+}
+
+// Start posts digests after syncs until stop is called
+func (n *Notifier) Start() (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case request := <-notificationRequests:
+				notifyCtx, notifyCancel := context.WithTimeout(ctx, time.Minute)
+				n.Notify(notifyCtx, request.source, request.started, request.ids)
+				notifyCancel()
+			}
+		}
+	}()
+
+	log.Printf("Notifier started (%d channels)", len(n.senders))
+	return cancel
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/notifier"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSender records the messages it is asked to send
+type fakeSender struct {
+	sent []string
+	err  error
+}
+
+func (s *fakeSender) Name() string { return "fake" }
+
+func (s *fakeSender) Send(ctx context.Context, text string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, text)
+	return nil
+}
+
+func TestNotifierNotify(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	started := time.Now()
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE id = ANY\\(\\$1\\) AND created_at >= \\$2").
+		WithArgs(sqlmock.AnyArg(), started).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "company", "location", "url", "posted_at"}).
+			AddRow("job-1", "Go Backend Engineer", "Paystack", "Lagos", "https://paystack.com/jobs/1", started))
+
+	tmpl, err := notifier.ParseTemplate("")
+	assert.NoError(t, err)
+	ok, failing := &fakeSender{}, &fakeSender{err: errors.New("forbidden")}
+	n := NewNotifier(postgresDB, []notifier.Sender{ok, failing}, tmpl, []string{"jsearch"})
+
+	assert.Equal(t, 1, n.Notify(context.Background(), "jsearch", started, []string{"job-1", "job-2"}))
+	assert.Len(t, ok.sent, 1)
+	assert.Contains(t, ok.sent[0], "Go Backend Engineer at Paystack (Lagos)")
+
+	// Sources not toggled on are not announced
+	assert.Equal(t, 0, n.Notify(context.Background(), "linkedin", started, []string{"job-3"}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
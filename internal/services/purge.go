@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/db"
+)
+
+// Purge modes: quarantined jobs are kept in jobs_quarantine, deleted ones are gone
+const (
+	PurgeModeQuarantine = "quarantine"
+	PurgeModeDelete     = "delete"
+)
+
+// ErrPurgeNotConfirmed is returned when a purge is run with a confirmation
+// token that does not match its parameters
+var ErrPurgeNotConfirmed = errors.New("invalid confirmation token, run a dry run first to get one")
+
+// PurgeRequest is a purge of the rows a source ingested in a time window. A
+// zero Until means up to the time the purge runs.
+type PurgeRequest struct {
+	Source string
+	Since  time.Time
+	Until  time.Time
+	Mode   string
+}
+
+// PurgeResult is the outcome of a purge or of its dry run
+type PurgeResult struct {
+	DryRun bool           `json:"dry_run"`
+	Counts db.PurgeCounts `json:"counts"`
+	// ConfirmationToken must be passed back to run the purge (dry runs only)
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+}
+
+// ConfirmationToken returns the token confirming req, bound to every one of
+// its parameters so it cannot confirm a different purge
+func (req PurgeRequest) ConfirmationToken(secret string) string {
+	var until string
+	if !req.Until.IsZero() {
+		until = req.Until.UTC().Format(time.RFC3339Nano)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{"purge", req.Source, req.Since.UTC().Format(time.RFC3339Nano), until, req.Mode}, "|")))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// PurgeSource counts the rows req would remove and returns a confirmation
+// token when dryRun is set or confirm is empty. Otherwise, when confirm
+// matches, it removes them (quarantining the jobs unless the mode is delete).
+func PurgeSource(ctx context.Context, postgresDB *sql.DB, req PurgeRequest, secret, confirm string, dryRun bool) (*PurgeResult, error) {
+	until := req.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	token := req.ConfirmationToken(secret)
+	if dryRun || confirm == "" {
+		counts, err := db.CountSourceRows(ctx, postgresDB, req.Source, req.Since, until)
+		if err != nil {
+			return nil, err
+		}
+		return &PurgeResult{DryRun: true, Counts: counts, ConfirmationToken: token}, nil
+	}
+
+	if !hmac.Equal([]byte(confirm), []byte(token)) {
+		return nil, ErrPurgeNotConfirmed
+	}
+
+	counts, err := db.PurgeSource(ctx, postgresDB, req.Source, req.Since, until, req.Mode != PurgeModeDelete)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Purged %s rows ingested %s to %s (%s): %d jobs, %d skips",
+		req.Source, req.Since.Format(time.RFC3339), until.Format(time.RFC3339), req.Mode, counts.Jobs, counts.Skips)
+	return &PurgeResult{Counts: counts}, nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/fetcher"
+)
+
+// storeResponses stores the API responses of a fetch of source once, the
+// jobs keeping their own item only, then deletes the responses past their
+// retention
+func storeResponses(ctx context.Context, postgresDB *sql.DB, cfg *config.Config, source string, recorder *fetcher.ResponseRecorder) {
+	fetched := recorder.Responses()
+	if len(fetched) == 0 {
+		return
+	}
+
+	responses := make([]db.APIResponse, len(fetched))
+	for i, response := range fetched {
+		responses[i] = db.APIResponse{
+			Source:    source,
+			Endpoint:  response.Endpoint,
+			Body:      string(response.Body),
+			FetchedAt: response.FetchedAt,
+		}
+	}
+	if err := db.SaveAPIResponses(ctx, postgresDB, responses); err != nil {
+		log.Printf("Error storing %s API responses: %v", source, err)
+		return
+	}
+
+	if cfg == nil || cfg.APIResponseRetention <= 0 {
+		return
+	}
+	if _, err := db.PruneAPIResponses(ctx, postgresDB, time.Now().Add(-cfg.APIResponseRetention)); err != nil {
+		log.Printf("Error pruning API responses: %v", err)
+	}
+}
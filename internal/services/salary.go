@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sort"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/db"
+)
+
+const (
+	// salaryBenchmarkMinSamples is how many parsed offers a seniority and
+	// currency needs before its median is trusted; with fewer, offers are
+	// compared to every level in the currency
+	salaryBenchmarkMinSamples = 5
+	// salaryOutlierFactor is how far from the median (times or fraction) an
+	// offer must be to be flagged, wide enough that only likely parsing
+	// errors (wrong currency, yearly read as monthly) are caught
+	salaryOutlierFactor = 4
+)
+
+// SalaryBenchmark is the typical monthly offer for a seniority in a currency
+type SalaryBenchmark struct {
+	Seniority string  `json:"seniority,omitempty"`
+	Currency  string  `json:"currency"`
+	Median    float64 `json:"median_monthly"`
+	Samples   int     `json:"samples"`
+}
+
+// SalaryBenchmarkReport is the outcome of a salary benchmark run
+type SalaryBenchmarkReport struct {
+	// Checked counts the open jobs whose salary could be parsed
+	Checked    int               `json:"checked"`
+	Flagged    int               `json:"flagged"`
+	Benchmarks []SalaryBenchmark `json:"benchmarks"`
+}
+
+// parsedSalary is an open job's parsed offer
+type parsedSalary struct {
+	db.SalaryText
+	salary analyzer.Salary
+}
+
+// salaryBenchmarks computes the median monthly offer per seniority and
+// currency, and per currency across levels (empty seniority), keeping those
+// with enough samples
+func salaryBenchmarks(salaries []parsedSalary) map[[2]string]SalaryBenchmark {
+	groups := make(map[[2]string][]float64)
+	for _, s := range salaries {
+		monthly := s.salary.Monthly()
+		if s.Seniority != "" {
+			key := [2]string{s.Seniority, s.salary.Currency}
+			groups[key] = append(groups[key], monthly)
+		}
+		key := [2]string{"", s.salary.Currency}
+		groups[key] = append(groups[key], monthly)
+	}
+
+	benchmarks := make(map[[2]string]SalaryBenchmark)
+	for key, values := range groups {
+		if len(values) < salaryBenchmarkMinSamples {
+			continue
+		}
+		sort.Float64s(values)
+		median := values[len(values)/2]
+		if len(values)%2 == 0 {
+			median = (values[len(values)/2-1] + values[len(values)/2]) / 2
+		}
+		benchmarks[key] = SalaryBenchmark{Seniority: key[0], Currency: key[1], Median: median, Samples: len(values)}
+	}
+	return benchmarks
+}
+
+// salaryFlag compares an offer to the benchmark of its seniority, or of its
+// currency when the seniority has too few samples. Returns nil when the offer
+// is in range or cannot be benchmarked.
+func salaryFlag(s parsedSalary, benchmarks map[[2]string]SalaryBenchmark) *db.SalaryFlag {
+	benchmark, ok := benchmarks[[2]string{s.Seniority, s.salary.Currency}]
+	if !ok {
+		if benchmark, ok = benchmarks[[2]string{"", s.salary.Currency}]; !ok {
+			return nil
+		}
+	}
+
+	monthly := s.salary.Monthly()
+	flag := &db.SalaryFlag{Monthly: monthly, Median: benchmark.Median, Currency: benchmark.Currency, Seniority: benchmark.Seniority}
+	switch {
+	case monthly < benchmark.Median/salaryOutlierFactor:
+		flag.Kind = "low"
+	case monthly > benchmark.Median*salaryOutlierFactor:
+		flag.Kind = "high"
+	default:
+		return nil
+	}
+	return flag
+}
+
+// BenchmarkSalaries compares every open job's parsed salary to the typical
+// offer for its seniority and currency, flagging far-off offers so they are
+// verified instead of displayed, and clearing flags of offers back in range
+func BenchmarkSalaries(ctx context.Context, postgresDB *sql.DB) (SalaryBenchmarkReport, error) {
+	report := SalaryBenchmarkReport{Benchmarks: []SalaryBenchmark{}}
+
+	jobs, err := db.FindSalaries(ctx, postgresDB)
+	if err != nil {
+		return report, err
+	}
+
+	var salaries []parsedSalary
+	for _, job := range jobs {
+		salary, ok := analyzer.ParseSalary(job.Salary)
+		if !ok {
+			// Unparsable salaries cannot be benchmarked, nor stay flagged
+			if job.Flagged {
+				if err := db.SetSalaryFlag(ctx, postgresDB, job.ID, nil); err != nil {
+					return report, err
+				}
+			}
+			continue
+		}
+		salaries = append(salaries, parsedSalary{SalaryText: job, salary: salary})
+	}
+	report.Checked = len(salaries)
+
+	benchmarks := salaryBenchmarks(salaries)
+	for _, s := range salaries {
+		flag := salaryFlag(s, benchmarks)
+		if flag == nil && !s.Flagged {
+			continue
+		}
+		if err := db.SetSalaryFlag(ctx, postgresDB, s.ID, flag); err != nil {
+			return report, err
+		}
+		if flag != nil {
+			report.Flagged++
+		}
+	}
+
+	for _, benchmark := range benchmarks {
+		report.Benchmarks = append(report.Benchmarks, benchmark)
+	}
+	sort.Slice(report.Benchmarks, func(i, j int) bool {
+		a, b := report.Benchmarks[i], report.Benchmarks[j]
+		if a.Currency != b.Currency {
+			return a.Currency < b.Currency
+		}
+		return a.Seniority < b.Seniority
+	})
+
+	log.Printf("Salary benchmark: %d salaries checked, %d flagged", report.Checked, report.Flagged)
+	return report, nil
+}
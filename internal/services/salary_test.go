@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBenchmarkSalaries(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	mock.ExpectQuery("^SELECT id, salary, (.+) FROM jobs WHERE COALESCE\\(salary, ''\\) <> ''").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "salary", "seniority", "flagged"}).
+			AddRow("job-1", "₦800,000 monthly", "senior", true).
+			AddRow("job-2", "₦900k - ₦1.1M", "senior", false).
+			AddRow("job-3", "₦1,000,000", "senior", false).
+			AddRow("job-4", "₦1.1M per month", "senior", false).
+			AddRow("job-5", "₦1,200,000", "senior", false).
+			// A yearly salary quoted without its period reads as monthly
+			AddRow("job-6", "₦12,000,000", "senior", false).
+			AddRow("job-7", "Competitive", "senior", false))
+
+	// job-1 is back in range, its earlier flag is cleared
+	mock.ExpectExec("^UPDATE jobs SET salary_flag = \\$2, updated_at = NOW\\(\\) WHERE id = \\$1 AND salary_flag IS DISTINCT FROM \\$2$").
+		WithArgs("job-1", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE jobs SET salary_flag = \\$2, updated_at = NOW\\(\\) WHERE id = \\$1 AND salary_flag IS DISTINCT FROM \\$2$").
+		WithArgs("job-6", `{"kind":"high","monthly":12000000,"median":1050000,"currency":"NGN","seniority":"senior"}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	report, err := BenchmarkSalaries(context.Background(), postgresDB)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, report.Checked)
+	assert.Equal(t, 1, report.Flagged)
+	assert.Equal(t, []SalaryBenchmark{
+		{Currency: "NGN", Median: 1050000, Samples: 6},
+		{Seniority: "senior", Currency: "NGN", Median: 1050000, Samples: 6},
+	}, report.Benchmarks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
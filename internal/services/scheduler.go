@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/go-co-op/gocron"
+	"github.com/google/uuid"
+)
+
+// SourceSchedule describes the scheduler state of a single source
+type SourceSchedule struct {
+	Source   string    `json:"source"`
+	Interval string    `json:"interval"`
+	LastRun  time.Time `json:"last_run"`
+	NextRun  time.Time `json:"next_run"`
+	Running  bool      `json:"running"`
+
+	// Yield of the last successful run and its moving average, nil until known
+	LastYield *int     `json:"last_yield"`
+	YieldAvg  *float64 `json:"yield_avg"`
+	// Bounds of the interval and the last change, set when adaptive
+	MinInterval    string `json:"min_interval,omitempty"`
+	MaxInterval    string `json:"max_interval,omitempty"`
+	LastAdjustment string `json:"last_adjustment,omitempty"`
+}
+
+// AdaptivePolicy decides the sync interval of a source from its yield, the
+// number of new jobs per run: sources averaging fewer than LowYield have
+// their interval doubled, those averaging HighYield or more have it halved,
+// always within the bounds of the source
+type AdaptivePolicy struct {
+	LowYield       int
+	HighYield      int
+	Bounds         config.IntervalBounds
+	BoundsBySource map[string]config.IntervalBounds
+}
+
+// NewAdaptivePolicy returns the policy configured in cfg, or nil when the
+// scheduler keeps fixed intervals
+func NewAdaptivePolicy(cfg *config.Config) *AdaptivePolicy {
+	if !cfg.SchedulerAdaptive {
+		return nil
+	}
+	return &AdaptivePolicy{
+		LowYield:       cfg.SchedulerLowYield,
+		HighYield:      cfg.SchedulerHighYield,
+		Bounds:         cfg.SchedulerBounds,
+		BoundsBySource: cfg.SchedulerBoundsBySource,
+	}
+}
+
+// bounds returns the interval bounds of source
+func (p *AdaptivePolicy) bounds(source string) config.IntervalBounds {
+	if b, ok := p.BoundsBySource[source]; ok {
+		return b
+	}
+	return p.Bounds
+}
+
+// clamp keeps interval within the bounds of source
+func (p *AdaptivePolicy) clamp(source string, interval time.Duration) time.Duration {
+	b := p.bounds(source)
+	if b.Min > 0 && interval < b.Min {
+		return b.Min
+	}
+	if b.Max > 0 && interval > b.Max {
+		return b.Max
+	}
+	return interval
+}
+
+// adjustInterval returns the next interval of source given its average
+// yield, and the reason of the change, empty when the interval is kept
+func (p *AdaptivePolicy) adjustInterval(source string, interval time.Duration, yieldAvg float64) (time.Duration, string) {
+	next := interval
+	var why string
+	switch {
+	case yieldAvg < float64(p.LowYield):
+		next, why = p.clamp(source, interval*2), fmt.Sprintf("below %d", p.LowYield)
+	case yieldAvg >= float64(p.HighYield):
+		next, why = p.clamp(source, interval/2), fmt.Sprintf("at least %d", p.HighYield)
+	}
+	if next == interval {
+		return interval, ""
+	}
+
+	verb := "stretched"
+	if next < interval {
+		verb = "tightened"
+	}
+	return next, fmt.Sprintf("%s from %s to %s: average yield %.1f new jobs per run, %s", verb, interval, next, yieldAvg, why)
+}
+
+// yieldAlpha weights the latest run in the moving average of the yield
+const yieldAlpha = 0.5
+
+// sourceYield is the yield history of a source
+type sourceYield struct {
+	last       *int
+	avg        *float64
+	adjustment string
+}
+
+// JobScheduler runs every sync source on its own interval, persisting run
+// times to the job_schedule_info table so restarts keep the cadence
+type JobScheduler struct {
+	scheduler  *gocron.Scheduler
+	db         *sql.DB
+	jobFetcher *fetcher.JobFetcher
+	// policy adapts the intervals to the yield of each source, nil keeps them
+	policy *AdaptivePolicy
+	// tasks runs the syncs so shutdown waits for them
+	tasks *TaskManager
+
+	// mu guards the maps below, which the adaptive policy changes at runtime
+	mu        sync.Mutex
+	jobs      map[string]*gocron.Job
+	intervals map[string]time.Duration
+	yields    map[string]sourceYield
+}
+
+// StartJobScheduler schedules every enabled source using the interval stored in
+// job_schedule_info, seeding missing sources with defaultInterval. A non-nil
+// policy adapts the intervals to the yield of each source. Syncs run as tasks
+// of tasks.
+func StartJobScheduler(postgresDB *sql.DB, jobFetcher *fetcher.JobFetcher, tasks *TaskManager, defaultInterval time.Duration, policy *AdaptivePolicy) (*JobScheduler, error) {
+	for _, source := range Sources() {
+		if err := db.EnsureScheduleInfo(postgresDB, source, defaultInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	infos, err := db.GetScheduleInfo(context.Background(), postgresDB)
+	if err != nil {
+		return nil, err
+	}
+
+	js := &JobScheduler{
+		scheduler:  gocron.NewScheduler(time.UTC),
+		db:         postgresDB,
+		jobFetcher: jobFetcher,
+		policy:     policy,
+		tasks:      tasks,
+		jobs:       make(map[string]*gocron.Job),
+		intervals:  make(map[string]time.Duration),
+		yields:     make(map[string]sourceYield),
+	}
+
+	for _, info := range infos {
+		if !IsValidSource(info.APIName) || info.IntervalMinutes <= 0 {
+			continue
+		}
+		source := info.APIName
+		if !SourceEnabled(fetcherConfig(jobFetcher), source) {
+			log.Printf("Not scheduling %s sync: %v", source, ErrSourceDisabled)
+			continue
+		}
+		interval := time.Duration(info.IntervalMinutes) * time.Minute
+		if policy != nil {
+			interval = policy.clamp(source, interval)
+		}
+
+		// Resume the persisted cadence instead of running everything on start
+		startAt := time.Now()
+		if info.LastRunTime != nil && info.LastRunTime.Add(interval).After(startAt) {
+			startAt = info.LastRunTime.Add(interval)
+		}
+
+		if err := js.schedule(source, interval, startAt); err != nil {
+			return nil, err
+		}
+		js.yields[source] = sourceYield{last: info.LastYield, avg: info.YieldAvg, adjustment: info.LastAdjustment}
+		log.Printf("Scheduled %s sync every %s (first run %s)", source, interval, startAt.Format(time.RFC3339))
+	}
+
+	js.scheduler.StartAsync()
+	return js, nil
+}
+
+// schedule (re)creates the job syncing source every interval from startAt,
+// the caller holding mu or still starting the scheduler
+func (js *JobScheduler) schedule(source string, interval time.Duration, startAt time.Time) error {
+	job, err := js.scheduler.Every(interval).StartAt(startAt).SingletonMode().Do(func() {
+		js.run(source)
+	})
+	if err != nil {
+		return err
+	}
+	js.jobs[source] = job
+	js.intervals[source] = interval
+	return nil
+}
+
+// run syncs a source as a recorded sync run, like those started through the
+// API, and persists its run times
+func (js *JobScheduler) run(source string) {
+	started := time.Now()
+	id := uuid.New().String()
+	if err := db.CreateSyncRun(js.db, id, source); err != nil {
+		log.Printf("Skipping scheduled sync of %s: %v", source, err)
+		return
+	}
+
+	var result SyncResult
+	err := js.tasks.Run(context.Background(), "scheduled sync "+source, func(ctx context.Context) {
+		result = executeSyncRun(ctx, js.db, id, source, func(ctx context.Context) []SyncResult {
+			return []SyncResult{RunSync(ctx, source, js.jobFetcher, js.db)}
+		})[0]
+	})
+	if err != nil {
+		abandonSyncRun(js.db, id, err)
+		log.Printf("Skipping scheduled sync of %s: %v", source, err)
+		return
+	}
+	if result.Error != "" {
+		log.Printf("Scheduled sync of %s failed: %s", source, result.Error)
+	}
+
+	js.mu.Lock()
+	interval := js.intervals[source]
+	js.mu.Unlock()
+
+	// Failed and skipped runs say nothing about how many jobs a source has
+	if js.policy != nil && result.Status == SyncStatusSuccess {
+		interval = js.recordYield(source, result.New)
+	}
+
+	if err := db.UpdateScheduleRun(js.db, source, started, started.Add(interval)); err != nil {
+		log.Printf("Error updating schedule info for %s: %v", source, err)
+	}
+}
+
+// recordYield adds the yield of a run to the average of source, reschedules
+// it when the policy changes its interval, and returns the interval
+func (js *JobScheduler) recordYield(source string, yield int) time.Duration {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	y := js.yields[source]
+	avg := float64(yield)
+	if y.avg != nil {
+		avg = yieldAlpha*float64(yield) + (1-yieldAlpha)*(*y.avg)
+	}
+	y.last, y.avg = &yield, &avg
+
+	interval := js.intervals[source]
+	next, adjustment := js.policy.adjustInterval(source, interval, avg)
+	if adjustment != "" {
+		js.scheduler.RemoveByReference(js.jobs[source])
+		if err := js.schedule(source, next, time.Now().Add(next)); err != nil {
+			// Keep syncing at the old interval rather than not at all
+			log.Printf("Error rescheduling %s sync: %v", source, err)
+			next, adjustment = interval, ""
+			if err := js.schedule(source, interval, time.Now().Add(interval)); err != nil {
+				log.Printf("Error restoring %s schedule: %v", source, err)
+			}
+		} else {
+			log.Printf("Sync interval of %s %s", source, adjustment)
+			y.adjustment = adjustment
+		}
+	}
+	js.yields[source] = y
+
+	if err := db.UpdateScheduleYield(js.db, source, yield, avg, next, adjustment); err != nil {
+		log.Printf("Error updating schedule yield for %s: %v", source, err)
+	}
+	return next
+}
+
+// State returns the scheduler state of every scheduled source
+func (js *JobScheduler) State() []SourceSchedule {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	states := make([]SourceSchedule, 0, len(js.jobs))
+	for _, source := range Sources() {
+		job, ok := js.jobs[source]
+		if !ok {
+			continue
+		}
+		y := js.yields[source]
+		state := SourceSchedule{
+			Source:         source,
+			Interval:       js.intervals[source].String(),
+			LastRun:        job.LastRun(),
+			NextRun:        job.NextRun(),
+			Running:        job.IsRunning(),
+			LastYield:      y.last,
+			YieldAvg:       y.avg,
+			LastAdjustment: y.adjustment,
+		}
+		if js.policy != nil {
+			b := js.policy.bounds(source)
+			state.MinInterval, state.MaxInterval = b.Min.String(), b.Max.String()
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// Stop stops the scheduler, letting running syncs finish in the background
+func (js *JobScheduler) Stop() {
+	js.scheduler.Stop()
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-co-op/gocron"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjustInterval(t *testing.T) {
+	policy := &AdaptivePolicy{
+		LowYield:       1,
+		HighYield:      20,
+		Bounds:         config.IntervalBounds{Min: time.Hour, Max: 72 * time.Hour},
+		BoundsBySource: map[string]config.IntervalBounds{"jsearch": {Min: 6 * time.Hour, Max: 24 * time.Hour}},
+	}
+
+	tests := []struct {
+		source   string
+		interval time.Duration
+		yieldAvg float64
+		want     time.Duration
+		changed  bool
+	}{
+		{"indeed", 12 * time.Hour, 0.4, 24 * time.Hour, true},
+		{"indeed", 12 * time.Hour, 5, 12 * time.Hour, false},
+		{"indeed", 12 * time.Hour, 30, 6 * time.Hour, true},
+		{"indeed", 72 * time.Hour, 0, 72 * time.Hour, false},
+		{"indeed", 90 * time.Minute, 25, time.Hour, true},
+		// Provider limits keep jsearch from being polled more than every 6h
+		{"jsearch", 6 * time.Hour, 50, 6 * time.Hour, false},
+		{"jsearch", 16 * time.Hour, 0, 24 * time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		got, adjustment := policy.adjustInterval(tt.source, tt.interval, tt.yieldAvg)
+		assert.Equal(t, tt.want, got, "%s %s %.1f", tt.source, tt.interval, tt.yieldAvg)
+		assert.Equal(t, tt.changed, adjustment != "", adjustment)
+	}
+
+	_, adjustment := policy.adjustInterval("indeed", 12*time.Hour, 0.4)
+	assert.Equal(t, "stretched from 12h0m0s to 24h0m0s: average yield 0.4 new jobs per run, below 1", adjustment)
+}
+
+func TestRecordYield(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	js := &JobScheduler{
+		scheduler: gocron.NewScheduler(time.UTC),
+		db:        postgresDB,
+		policy:    &AdaptivePolicy{LowYield: 1, HighYield: 20, Bounds: config.IntervalBounds{Min: time.Hour, Max: 48 * time.Hour}},
+		jobs:      make(map[string]*gocron.Job),
+		intervals: make(map[string]time.Duration),
+		yields:    make(map[string]sourceYield),
+	}
+	assert.NoError(t, js.schedule("indeed", 12*time.Hour, time.Now().Add(time.Hour)))
+
+	// An average of 4 new jobs keeps the interval
+	mock.ExpectExec("^UPDATE job_schedule_info SET last_yield").
+		WithArgs("indeed", 4, 4.0, 720, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.Equal(t, 12*time.Hour, js.recordYield("indeed", 4))
+
+	// Two empty runs bring the average under the low yield
+	mock.ExpectExec("^UPDATE job_schedule_info SET last_yield").
+		WithArgs("indeed", 0, 2.0, 720, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE job_schedule_info SET last_yield").
+		WithArgs("indeed", 0, 1.0, 720, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE job_schedule_info SET last_yield").
+		WithArgs("indeed", 0, 0.5, 1440, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	js.recordYield("indeed", 0)
+	js.recordYield("indeed", 0)
+	assert.Equal(t, 24*time.Hour, js.recordYield("indeed", 0))
+
+	state := js.State()
+	assert.Len(t, state, 1)
+	assert.Equal(t, "24h0m0s", state[0].Interval)
+	assert.Equal(t, 0, *state[0].LastYield)
+	assert.Equal(t, 0.5, *state[0].YieldAvg)
+	assert.Equal(t, "1h0m0s", state[0].MinInterval)
+	assert.Contains(t, state[0].LastAdjustment, "stretched from 12h0m0s to 24h0m0s")
+	assert.Len(t, js.scheduler.Jobs(), 1, "the old job is replaced")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
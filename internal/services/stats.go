@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+
+	"Go9jaJobs/internal/analyzer"
+	"Go9jaJobs/internal/db"
+)
+
+const (
+	// statsWeeks is how many weeks of postings the stats chart
+	statsWeeks = 12
+	// statsTopCompanies is how many of the companies hiring the most are listed
+	statsTopCompanies = 10
+)
+
+// SalaryDistribution spreads the parsed monthly offers of a currency
+type SalaryDistribution struct {
+	Currency string  `json:"currency"`
+	Samples  int     `json:"samples"`
+	Min      float64 `json:"min_monthly"`
+	P25      float64 `json:"p25_monthly"`
+	Median   float64 `json:"median_monthly"`
+	P75      float64 `json:"p75_monthly"`
+	Max      float64 `json:"max_monthly"`
+}
+
+// JobStatistics are the aggregates of the listed jobs behind /api/stats
+type JobStatistics struct {
+	db.JobAnalytics
+	// Salaries holds one distribution per currency, most offers first
+	Salaries []SalaryDistribution `json:"salaries"`
+	// SalaryCoverage is the share of the listed jobs with a parsed salary
+	SalaryCoverage float64 `json:"salary_coverage"`
+}
+
+// GetJobStatistics aggregates the listed jobs of a track, any when empty
+func GetJobStatistics(ctx context.Context, postgresDB *sql.DB, track string) (*JobStatistics, error) {
+	analytics, err := db.GetJobAnalytics(ctx, postgresDB, track, statsWeeks, statsTopCompanies)
+	if err != nil {
+		return nil, err
+	}
+	texts, err := db.FindListedSalaries(ctx, postgresDB, track)
+	if err != nil {
+		return nil, err
+	}
+
+	var salaries []analyzer.Salary
+	for _, text := range texts {
+		if salary, ok := analyzer.ParseSalary(text); ok {
+			salaries = append(salaries, salary)
+		}
+	}
+
+	stats := &JobStatistics{JobAnalytics: *analytics, Salaries: salaryDistributions(salaries)}
+	if analytics.Total > 0 {
+		stats.SalaryCoverage = math.Round(float64(len(salaries))/float64(analytics.Total)*1000) / 1000
+	}
+	return stats, nil
+}
+
+// salaryDistributions groups offers by currency, most offers first
+func salaryDistributions(salaries []analyzer.Salary) []SalaryDistribution {
+	groups := make(map[string][]float64)
+	for _, s := range salaries {
+		groups[s.Currency] = append(groups[s.Currency], s.Monthly())
+	}
+
+	distributions := []SalaryDistribution{}
+	for currency, values := range groups {
+		sort.Float64s(values)
+		distributions = append(distributions, SalaryDistribution{
+			Currency: currency,
+			Samples:  len(values),
+			Min:      values[0],
+			P25:      percentile(values, 0.25),
+			Median:   percentile(values, 0.5),
+			P75:      percentile(values, 0.75),
+			Max:      values[len(values)-1],
+		})
+	}
+	sort.Slice(distributions, func(i, j int) bool {
+		if distributions[i].Samples != distributions[j].Samples {
+			return distributions[i].Samples > distributions[j].Samples
+		}
+		return distributions[i].Currency < distributions[j].Currency
+	})
+	return distributions
+}
+
+// percentile interpolates the p-th percentile (0 to 1) of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[lower+1]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJobStatistics(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	week := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT source, COUNT\\(\\*\\) FROM jobs WHERE (.+) GROUP BY source").
+		WithArgs("go").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "count"}).
+			AddRow("jobberman", 3).
+			AddRow("remoteok", 2))
+	mock.ExpectQuery("^SELECT company, COUNT\\(\\*\\) FROM jobs WHERE (.+) GROUP BY company (.+) LIMIT \\$2$").
+		WithArgs("go", statsTopCompanies).
+		WillReturnRows(sqlmock.NewRows([]string{"company", "count"}).
+			AddRow("Paystack", 2).
+			AddRow("Moniepoint", 1))
+	mock.ExpectQuery("^SELECT date_trunc\\('week', posted_at\\) AS week, COUNT\\(\\*\\) FROM jobs WHERE (.+) GROUP BY week").
+		WithArgs("go", statsWeeks).
+		WillReturnRows(sqlmock.NewRows([]string{"week", "count"}).AddRow(week, 5))
+	mock.ExpectQuery("^SELECT COUNT\\(\\*\\) FILTER \\(WHERE is_remote\\)").
+		WithArgs("go").
+		WillReturnRows(sqlmock.NewRows([]string{"remote", "onsite", "unknown"}).AddRow(2, 2, 1))
+	mock.ExpectQuery("^SELECT salary FROM jobs WHERE (.+) AND salary_flag IS NULL$").
+		WithArgs("go").
+		WillReturnRows(sqlmock.NewRows([]string{"salary"}).
+			AddRow("₦800,000 monthly").
+			AddRow("₦1,000,000").
+			AddRow("₦1.2M per month").
+			AddRow("$4,000 monthly").
+			AddRow("Competitive"))
+
+	stats, err := GetJobStatistics(context.Background(), postgresDB, "go")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, stats.Total)
+	assert.Equal(t, "jobberman", stats.Sources[0].Name)
+	assert.Equal(t, "Paystack", stats.Companies[0].Name)
+	assert.Equal(t, 5, stats.Weeks[0].Jobs)
+	assert.Equal(t, 2, stats.Remote.Remote)
+	assert.Equal(t, 0.8, stats.SalaryCoverage)
+	assert.Equal(t, []SalaryDistribution{
+		{Currency: "NGN", Samples: 3, Min: 800000, P25: 900000, Median: 1000000, P75: 1100000, Max: 1200000},
+		{Currency: "USD", Samples: 1, Min: 4000, P25: 4000, Median: 4000, P75: 4000, Max: 4000},
+	}, stats.Salaries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
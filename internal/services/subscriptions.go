@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/errorlog"
+	"Go9jaJobs/internal/models"
+)
+
+// subscriptionDigestSize is how many jobs a job alert email lists at most
+const subscriptionDigestSize = 20
+
+// Mailer sends plain text email, see notifier.Mailer
+type Mailer interface {
+	SendMail(to, subject, body string) error
+}
+
+// subscriptionLink returns the confirm or unsubscribe link of a subscription
+func subscriptionLink(baseURL, action, token string) string {
+	return strings.TrimRight(baseURL, "/") + "/subscriptions/" + action + "?token=" + url.QueryEscape(token)
+}
+
+// subscriptionFilters describes the filters of a subscription for emails
+func subscriptionFilters(sub db.Subscription) string {
+	var filters []string
+	if sub.Seniority != "" {
+		filters = append(filters, sub.Seniority)
+	}
+	if sub.RemoteOnly {
+		filters = append(filters, "remote")
+	}
+	if sub.State != "" {
+		filters = append(filters, "in "+sub.State)
+	}
+	if len(filters) == 0 {
+		return "all Go jobs"
+	}
+	return strings.Join(filters, ", ") + " Go jobs"
+}
+
+// Subscribe stores a job alert and emails the confirmation link, unless the
+// address already confirmed the same alert. Links point to baseURL.
+func Subscribe(ctx context.Context, postgresDB *sql.DB, mailer Mailer, baseURL string, sub db.Subscription) error {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	sub.Token = hex.EncodeToString(token)
+
+	sub, err := db.CreateSubscription(ctx, postgresDB, sub)
+	if err != nil {
+		return err
+	}
+	if sub.ConfirmedAt != nil {
+		return nil
+	}
+
+	body := fmt.Sprintf("Confirm your Go9jaJobs alert for %s:\n\n%s\n\nIf you did not subscribe, ignore this email.",
+		subscriptionFilters(sub), subscriptionLink(baseURL, "confirm", sub.Token))
+	return mailer.SendMail(sub.Email, "Confirm your Go9jaJobs job alert", body)
+}
+
+// subscriptionDigest writes the job alert email listing jobs
+func subscriptionDigest(sub db.Subscription, jobs []models.Job, baseURL string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "New %s on Go9jaJobs:\n", subscriptionFilters(sub))
+	for _, job := range jobs {
+		fmt.Fprintf(&b, "\n• %s at %s", job.Title, job.Company)
+		if job.Location != "" {
+			fmt.Fprintf(&b, " (%s)", job.Location)
+		}
+		if job.URL != "" {
+			fmt.Fprintf(&b, "\n  %s", job.URL)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\nUnsubscribe: %s\n", subscriptionLink(baseURL, "unsubscribe", sub.Token))
+	return b.String()
+}
+
+// SendSubscriptionDigests emails every confirmed subscription the jobs
+// stored since its last digest (or its confirmation) that match its filters.
+// Returns how many digests were sent; failed ones are retried next time.
+func SendSubscriptionDigests(ctx context.Context, postgresDB *sql.DB, mailer Mailer, baseURL string) (int, error) {
+	subs, err := db.ConfirmedSubscriptions(ctx, postgresDB)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, sub := range subs {
+		since := *sub.ConfirmedAt
+		if sub.LastSentAt != nil {
+			since = *sub.LastSentAt
+		}
+		now := time.Now()
+
+		jobs, err := db.SubscriptionJobs(ctx, postgresDB, sub, since, subscriptionDigestSize)
+		if err != nil {
+			return sent, err
+		}
+		if len(jobs) == 0 {
+			continue
+		}
+
+		subject := fmt.Sprintf("%d new Go jobs on Go9jaJobs", len(jobs))
+		if len(jobs) == 1 {
+			subject = "1 new Go job on Go9jaJobs"
+		}
+		if err := mailer.SendMail(sub.Email, subject, subscriptionDigest(sub, jobs, baseURL)); err != nil {
+			log.Printf("Error sending job alert %d: %v", sub.ID, err)
+			errorlog.Record(errorlog.SubsystemNotifications, "email", err)
+			continue
+		}
+		if err := db.MarkSubscriptionSent(ctx, postgresDB, sub.ID, now); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	log.Printf("Sent %d job alert digests to %d subscriptions", sent, len(subs))
+	return sent, nil
+}
+
+// StartSubscriptionDigests sends the job alert digests every interval until
+// the returned stop function is called
+func StartSubscriptionDigests(postgresDB *sql.DB, mailer Mailer, baseURL string, interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sendCtx, sendCancel := context.WithTimeout(ctx, 10*time.Minute)
+				if _, err := SendSubscriptionDigests(sendCtx, postgresDB, mailer, baseURL); err != nil {
+					log.Printf("Error sending job alert digests: %v", err)
+				}
+				sendCancel()
+			}
+		}
+	}()
+
+	log.Printf("Job alert digests started (every %s)", interval)
+	return cancel
+}
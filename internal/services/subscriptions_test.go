@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMailer records the emails it is asked to send
+type fakeMailer struct {
+	to, subjects, bodies []string
+}
+
+func (m *fakeMailer) SendMail(to, subject, body string) error {
+	m.to = append(m.to, to)
+	m.subjects = append(m.subjects, subject)
+	m.bodies = append(m.bodies, body)
+	return nil
+}
+
+func TestSubscribe(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	mock.ExpectQuery("^INSERT INTO job_subscriptions (.+) RETURNING id, token, confirmed_at$").
+		WithArgs("dev@example.com", true, "Lagos", "senior", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "token", "confirmed_at"}).AddRow(1, "tok123", nil))
+	// Subscribing again after confirming sends nothing
+	mock.ExpectQuery("^INSERT INTO job_subscriptions").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "token", "confirmed_at"}).AddRow(1, "tok123", time.Now()))
+
+	mailer := &fakeMailer{}
+	sub := db.Subscription{Email: "dev@example.com", RemoteOnly: true, State: "Lagos", Seniority: "senior"}
+	assert.NoError(t, Subscribe(context.Background(), postgresDB, mailer, "https://go9jajobs.com/", sub))
+	assert.NoError(t, Subscribe(context.Background(), postgresDB, mailer, "https://go9jajobs.com/", sub))
+
+	assert.Equal(t, []string{"dev@example.com"}, mailer.to)
+	assert.Contains(t, mailer.bodies[0], "senior, remote, in Lagos Go jobs")
+	assert.Contains(t, mailer.bodies[0], "https://go9jajobs.com/subscriptions/confirm?token=tok123")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSendSubscriptionDigests(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	confirmed := time.Now().Add(-48 * time.Hour)
+	lastSent := time.Now().Add(-24 * time.Hour)
+	mock.ExpectQuery("^SELECT (.+) FROM job_subscriptions WHERE confirmed_at IS NOT NULL").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "remote_only", "state", "seniority", "token", "confirmed_at", "last_sent_at"}).
+			AddRow(1, "dev@example.com", true, "", "", "tok1", confirmed, lastSent).
+			AddRow(2, "new@example.com", false, "", "junior", "tok2", confirmed, nil))
+
+	columns := []string{"id", "title", "company", "location", "url", "posted_at"}
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE created_at > \\$1").
+		WithArgs(lastSent, true, "", "", subscriptionDigestSize).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("job-1", "Go Engineer", "Paystack", "Lagos", "https://paystack.com/jobs/1", time.Now()))
+	mock.ExpectExec("^UPDATE job_subscriptions SET last_sent_at = \\$2 WHERE id = \\$1$").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Nothing new for the second subscription since it was confirmed
+	mock.ExpectQuery("^SELECT (.+) FROM jobs WHERE created_at > \\$1").
+		WithArgs(confirmed, false, "", "junior", subscriptionDigestSize).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	mailer := &fakeMailer{}
+	sent, err := SendSubscriptionDigests(context.Background(), postgresDB, mailer, "https://go9jajobs.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"1 new Go job on Go9jaJobs"}, mailer.subjects)
+	assert.Contains(t, mailer.bodies[0], "• Go Engineer at Paystack (Lagos)")
+	assert.Contains(t, mailer.bodies[0], "https://go9jajobs.com/subscriptions/unsubscribe?token=tok1")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
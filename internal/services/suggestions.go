@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"Go9jaJobs/internal/db"
+)
+
+// SuggestSource stores a suggested job source and emails the confirmation
+// link, unless the address already confirmed a suggestion of the same
+// domain. Links point to baseURL.
+func SuggestSource(ctx context.Context, postgresDB *sql.DB, mailer Mailer, baseURL string, suggestion db.SourceSuggestion) error {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	suggestion.Token = hex.EncodeToString(token)
+
+	suggestion, err := db.CreateSourceSuggestion(ctx, postgresDB, suggestion)
+	if err != nil {
+		return err
+	}
+	if suggestion.ConfirmedAt != nil {
+		return nil
+	}
+
+	link := strings.TrimRight(baseURL, "/") + "/suggestions/confirm?token=" + url.QueryEscape(suggestion.Token)
+	body := fmt.Sprintf("Confirm your suggestion of %s as a Go9jaJobs job source:\n\n%s\n\n"+
+		"Confirmed suggestions are reviewed for new integrations. If you did not suggest it, ignore this email.",
+		suggestion.Domain, link)
+	return mailer.SendMail(suggestion.Email, "Confirm your Go9jaJobs source suggestion", body)
+}
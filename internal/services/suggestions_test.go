@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/db"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestSource(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	mock.ExpectQuery("^INSERT INTO source_suggestions (.+) ON CONFLICT \\(domain, email\\) DO UPDATE (.+) RETURNING id, token, confirmed_at$").
+		WithArgs("jobs.example.ng", "https://jobs.example.ng", "", "", "dev@example.com", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "token", "confirmed_at"}).AddRow(1, "tok123", nil))
+	// Suggesting the domain again after confirming sends nothing
+	mock.ExpectQuery("^INSERT INTO source_suggestions").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "token", "confirmed_at"}).AddRow(1, "tok123", time.Now()))
+
+	mailer := &fakeMailer{}
+	suggestion := db.SourceSuggestion{Domain: "jobs.example.ng", URL: "https://jobs.example.ng", Email: "dev@example.com"}
+	assert.NoError(t, SuggestSource(context.Background(), postgresDB, mailer, "https://go9jajobs.com/", suggestion))
+	assert.NoError(t, SuggestSource(context.Background(), postgresDB, mailer, "https://go9jajobs.com/", suggestion))
+
+	assert.Equal(t, []string{"dev@example.com"}, mailer.to)
+	assert.Contains(t, mailer.bodies[0], "jobs.example.ng")
+	assert.Contains(t, mailer.bodies[0], "https://go9jajobs.com/suggestions/confirm?token=tok123")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
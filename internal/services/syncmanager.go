@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/fetcher"
+
+	"github.com/google/uuid"
+)
+
+// SyncManager runs sync requests and records their progress in the sync_runs table
+type SyncManager struct {
+	db         *sql.DB
+	jobFetcher *fetcher.JobFetcher
+	tasks      *TaskManager
+}
+
+// NewSyncManager creates a new SyncManager instance running its syncs as
+// tasks of tasks
+func NewSyncManager(postgresDB *sql.DB, jobFetcher *fetcher.JobFetcher, tasks *TaskManager) *SyncManager {
+	return &SyncManager{
+		db:         postgresDB,
+		jobFetcher: jobFetcher,
+		tasks:      tasks,
+	}
+}
+
+// Start queues a sync of source ("all" for every source) and runs it in the
+// background, returning the run ID to follow its progress. Returns
+// ErrSyncRunning if the source is already being synced, ErrSourceDisabled if
+// it is turned off, ErrShuttingDown once the server is shutting down.
+func (m *SyncManager) Start(source string) (string, error) {
+	release, err := m.lock(context.Background(), source)
+	if err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	if err := db.CreateSyncRun(m.db, id, source); err != nil {
+		release()
+		return "", err
+	}
+
+	err = m.tasks.Go(syncTaskName(id, source), func(ctx context.Context) {
+		defer release()
+		m.execute(ctx, id, source)
+	})
+	if err != nil {
+		release()
+		m.abandon(id, err)
+		return "", err
+	}
+	return id, nil
+}
+
+// RunAndWait syncs source synchronously, returning the run ID and per-source
+// results. The sync stops when ctx is done or the server shuts down. Returns
+// ErrSyncRunning if the source is already being synced, ErrShuttingDown once
+// the server is shutting down.
+func (m *SyncManager) RunAndWait(ctx context.Context, source string) (string, []SyncResult, error) {
+	release, err := m.lock(ctx, source)
+	if err != nil {
+		return "", nil, err
+	}
+	defer release()
+
+	id := uuid.New().String()
+	if err := db.CreateSyncRun(m.db, id, source); err != nil {
+		return "", nil, err
+	}
+
+	var results []SyncResult
+	err = m.tasks.Run(ctx, syncTaskName(id, source), func(ctx context.Context) {
+		results = m.execute(ctx, id, source)
+	})
+	if err != nil {
+		m.abandon(id, err)
+		return "", nil, err
+	}
+	return id, results, nil
+}
+
+// syncTaskName names the task of a sync run
+func syncTaskName(id, source string) string {
+	return "sync " + source + " (run " + id + ")"
+}
+
+// abandon records a run that could not start as failed
+func (m *SyncManager) abandon(id string, reason error) {
+	abandonSyncRun(m.db, id, reason)
+}
+
+// abandonSyncRun records the run id that could not start as failed
+func abandonSyncRun(postgresDB *sql.DB, id string, reason error) {
+	if err := db.FinishSyncRun(postgresDB, id, db.SyncRunFailed, 0, 0, nil, reason.Error(), nil); err != nil {
+		log.Printf("Error finishing sync run %s: %v", id, err)
+	}
+}
+
+// lock takes the lock of a single source for the whole run. Syncs of "all"
+// lock each source as they reach it instead, skipping those already running.
+// Returns ErrSourceDisabled for a disabled source.
+func (m *SyncManager) lock(ctx context.Context, source string) (func(), error) {
+	if source == "all" {
+		return func() {}, nil
+	}
+	if !SourceEnabled(fetcherConfig(m.jobFetcher), source) {
+		return nil, ErrSourceDisabled
+	}
+	return lockSync(ctx, m.db, source)
+}
+
+// execute runs the sync of a recorded run and stores its outcome. Single
+// sources must already be locked by the caller.
+func (m *SyncManager) execute(ctx context.Context, id, source string) []SyncResult {
+	return executeSyncRun(ctx, m.db, id, source, func(ctx context.Context) []SyncResult {
+		if source == "all" {
+			return RunSyncAll(ctx, m.jobFetcher, m.db)
+		}
+		return []SyncResult{runSyncLocked(ctx, source, m.jobFetcher, m.db)}
+	})
+}
+
+// executeSyncRun runs sync as the recorded run id of source and stores its
+// outcome
+func executeSyncRun(ctx context.Context, postgresDB *sql.DB, id, source string, sync func(ctx context.Context) []SyncResult) []SyncResult {
+	if err := db.StartSyncRun(postgresDB, id); err != nil {
+		log.Printf("Error marking sync run %s as running: %v", id, err)
+	}
+
+	// The API responses of the syncs are stored under the run
+	results := sync(db.WithSyncRun(ctx, id))
+
+	status, fetched, saved, errorMsg := summarizeResults(results)
+	var errs []db.SyncError
+	for _, result := range results {
+		errs = append(errs, result.Errors...)
+	}
+	if err := db.FinishSyncRun(postgresDB, id, status, fetched, saved, results, errorMsg, errs); err != nil {
+		log.Printf("Error finishing sync run %s: %v", id, err)
+	}
+
+	log.Printf("Sync run %s for %s finished: %s (%d fetched, %d saved)", id, source, status, fetched, saved)
+	return results
+}
+
+// summarizeResults derives the run state, total counts and combined error of a set of results
+func summarizeResults(results []SyncResult) (status string, fetched, saved int, errorMsg string) {
+	var errs []string
+	failed := 0
+	for _, result := range results {
+		fetched += result.Fetched
+		saved += result.Saved
+		if result.Error != "" {
+			errs = append(errs, result.Source+": "+result.Error)
+		}
+		if result.Status == SyncStatusFailed {
+			failed++
+		}
+	}
+
+	switch {
+	case len(errs) == 0:
+		status = db.SyncRunDone
+	case failed == len(results):
+		status = db.SyncRunFailed
+	default:
+		status = db.SyncRunPartial
+	}
+
+	return status, fetched, saved, strings.Join(errs, "; ")
+}
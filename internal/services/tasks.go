@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrShuttingDown is returned for work started after the server began to shut down
+var ErrShuttingDown = errors.New("server shutting down")
+
+// taskCancelGrace is how long Shutdown waits for cancelled tasks to return,
+// e.g. to record a sync run as failed
+const taskCancelGrace = 5 * time.Second
+
+// TaskInfo is a background task in flight
+type TaskInfo struct {
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// TaskManager runs the background work of the server, such as syncs started
+// by a request, under a context cancelled on shutdown, so that work is
+// waited for or stopped instead of orphaned
+type TaskManager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu guards the fields below
+	mu      sync.Mutex
+	closing bool
+	nextID  int
+	tasks   map[int]TaskInfo
+}
+
+// NewTaskManager creates a TaskManager accepting tasks until Shutdown
+func NewTaskManager() *TaskManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TaskManager{
+		ctx:    ctx,
+		cancel: cancel,
+		tasks:  make(map[int]TaskInfo),
+	}
+}
+
+// Go runs fn in the background with a context cancelled when shutdown gives
+// up waiting for it. Returns ErrShuttingDown once Shutdown was called.
+func (m *TaskManager) Go(name string, fn func(ctx context.Context)) error {
+	id, err := m.add(name)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer m.done(id)
+		fn(m.ctx)
+	}()
+	return nil
+}
+
+// Run runs fn as a task of its caller, e.g. a request waiting for it, with a
+// context cancelled when either ctx is or shutdown gives up waiting for it.
+// Returns ErrShuttingDown once Shutdown was called.
+func (m *TaskManager) Run(ctx context.Context, name string, fn func(ctx context.Context)) error {
+	id, err := m.add(name)
+	if err != nil {
+		return err
+	}
+	defer m.done(id)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(m.ctx, cancel)
+	defer stop()
+
+	fn(ctx)
+	return nil
+}
+
+// add records a task about to start
+func (m *TaskManager) add(name string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closing {
+		return 0, ErrShuttingDown
+	}
+	m.nextID++
+	m.tasks[m.nextID] = TaskInfo{Name: name, StartedAt: time.Now()}
+	m.wg.Add(1)
+	return m.nextID, nil
+}
+
+// done records a task that returned
+func (m *TaskManager) done(id int) {
+	m.mu.Lock()
+	delete(m.tasks, id)
+	m.mu.Unlock()
+	m.wg.Done()
+}
+
+// Running returns the tasks in flight, oldest first
+func (m *TaskManager) Running() []TaskInfo {
+	m.mu.Lock()
+	tasks := make([]TaskInfo, 0, len(m.tasks))
+	for _, task := range m.tasks {
+		tasks = append(tasks, task)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].StartedAt.Before(tasks[j].StartedAt) })
+	return tasks
+}
+
+// Shutdown stops accepting tasks and waits for those in flight until ctx is
+// done, then cancels them and waits a few more seconds for them to return.
+// Returns ctx's error when tasks had to be cancelled.
+func (m *TaskManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closing = true
+	m.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		m.cancel()
+		return nil
+	case <-ctx.Done():
+	}
+
+	m.cancel()
+	select {
+	case <-finished:
+	case <-time.After(taskCancelGrace):
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskManagerShutdownWaits(t *testing.T) {
+	tasks := NewTaskManager()
+
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	assert.NoError(t, tasks.Go("sync jsearch", func(ctx context.Context) {
+		<-release
+		close(finished)
+	}))
+
+	running := tasks.Running()
+	if assert.Len(t, running, 1) {
+		assert.Equal(t, "sync jsearch", running[0].Name)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, tasks.Shutdown(ctx))
+
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Shutdown returned before the task finished")
+	}
+	assert.Empty(t, tasks.Running())
+
+	// No tasks start once shutting down
+	assert.ErrorIs(t, tasks.Go("late", func(ctx context.Context) {}), ErrShuttingDown)
+	assert.ErrorIs(t, tasks.Run(context.Background(), "late", func(ctx context.Context) {}), ErrShuttingDown)
+}
+
+func TestTaskManagerShutdownCancels(t *testing.T) {
+	tasks := NewTaskManager()
+
+	cancelled := make(chan error, 2)
+	assert.NoError(t, tasks.Go("background", func(ctx context.Context) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+	}))
+	go tasks.Run(context.Background(), "waited for", func(ctx context.Context) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+	})
+	assert.Eventually(t, func() bool { return len(tasks.Running()) == 2 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, tasks.Shutdown(ctx), context.DeadlineExceeded)
+
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	assert.Empty(t, tasks.Running())
+}
+
+func TestTaskManagerRunFollowsCaller(t *testing.T) {
+	tasks := NewTaskManager()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var err error
+	assert.NoError(t, tasks.Run(ctx, "request", func(ctx context.Context) {
+		err = ctx.Err()
+	}))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, tasks.Running())
+}
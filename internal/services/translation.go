@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/translate"
+)
+
+const (
+	// translationBatchSize is how many descriptions a single translation pass
+	// handles, keeping each pass within the translator's rate limits
+	translationBatchSize = 20
+	// translationRecheck is how long a description whose translation failed is
+	// left alone before it is tried again
+	translationRecheck = 24 * time.Hour
+)
+
+// JobTranslator translates the descriptions of saved jobs written in another
+// language than target in the background
+type JobTranslator struct {
+	db         *sql.DB
+	translator translate.Translator
+	target     string
+}
+
+// NewJobTranslator creates a JobTranslator translating descriptions to the
+// target language with translator
+func NewJobTranslator(postgresDB *sql.DB, translator translate.Translator, target string) *JobTranslator {
+	return &JobTranslator{db: postgresDB, translator: translator, target: target}
+}
+
+// RunOnce translates one batch of descriptions, returning how many were
+// translated. A failed translation is logged and retried after
+// translationRecheck.
+func (t *JobTranslator) RunOnce(ctx context.Context) (int, error) {
+	jobs, err := db.FindUntranslatedJobs(ctx, t.db, t.target, translationRecheck, translationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	translated := 0
+	for _, job := range jobs {
+		translation, err := t.translator.Translate(ctx, job.Description, job.Language, t.target)
+		if err != nil {
+			if ctx.Err() != nil {
+				return translated, ctx.Err()
+			}
+			log.Printf("Error translating job %s with %s: %v", job.ID, t.translator.Name(), err)
+			translation = ""
+		}
+
+		translator := t.translator.Name()
+		if translation == "" {
+			translator = ""
+		}
+		if err := db.SetTranslation(ctx, t.db, job.ID, translation, translator); err != nil {
+			return translated, err
+		}
+		if translation != "" {
+			translated++
+		}
+	}
+
+	if len(jobs) > 0 {
+		log.Printf("Translation: %d descriptions checked, %d translated to %s", len(jobs), translated, t.target)
+	}
+	return translated, nil
+}
+
+// Start runs a translation pass every interval until the returned stop
+// function is called
+func (t *JobTranslator) Start(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			runCtx, runCancel := context.WithTimeout(ctx, 10*time.Minute)
+			if _, err := t.RunOnce(runCtx); err != nil {
+				log.Printf("Error translating descriptions: %v", err)
+			}
+			runCancel()
+		}
+	}()
+
+	log.Printf("Description translator started (%s to %s, every %s)", t.translator.Name(), t.target, interval)
+	return cancel
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTranslator translates the descriptions it knows and fails on others
+type fakeTranslator map[string]string
+
+func (f fakeTranslator) Name() string { return "fake" }
+
+func (f fakeTranslator) Translate(ctx context.Context, text, from, to string) (string, error) {
+	if translation, ok := f[text]; ok {
+		return translation, nil
+	}
+	return "", errors.New("quota exceeded")
+}
+
+func TestJobTranslatorRunOnce(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	translator := NewJobTranslator(postgresDB, fakeTranslator{"Nous recrutons": "We are hiring"}, "en")
+
+	mock.ExpectQuery("^SELECT id, language, description FROM jobs WHERE (.+) LIMIT \\$3$").
+		WithArgs("en", sqlmock.AnyArg(), translationBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "language", "description"}).
+			AddRow("job-1", "fr", "Nous recrutons").
+			AddRow("job-2", "de", "Wir suchen"))
+
+	// The failed translation is only recorded as checked
+	mock.ExpectExec("^UPDATE jobs SET description_translation").
+		WithArgs("job-1", "We are hiring", "fake").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE jobs SET description_translation").
+		WithArgs("job-2", "", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	translated, err := translator.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, translated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
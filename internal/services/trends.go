@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"Go9jaJobs/internal/db"
+
+	"github.com/go-co-op/gocron"
+)
+
+// jobCountsTimeout bounds a daily job counts snapshot
+const jobCountsTimeout = 5 * time.Minute
+
+// RecordJobCounts snapshots the job counts of a day (UTC) for the trend
+// charts, then deletes the snapshots older than retention days (0 keeps them)
+func RecordJobCounts(ctx context.Context, postgresDB *sql.DB, day time.Time, retention int) error {
+	rows, err := db.SnapshotJobCounts(ctx, postgresDB, day)
+	if err != nil {
+		log.Printf("Error recording job counts of %s: %v", day.Format("2006-01-02"), err)
+		return err
+	}
+	log.Printf("Recorded job counts of %s (%d sources and states)", day.Format("2006-01-02"), rows)
+
+	if retention > 0 {
+		pruned, err := db.PruneJobCounts(ctx, postgresDB, retention)
+		if err != nil {
+			log.Printf("Error pruning job counts: %v", err)
+			return err
+		}
+		if pruned > 0 {
+			log.Printf("Pruned %d job counts older than %d days", pruned, retention)
+		}
+	}
+	return nil
+}
+
+// StartJobCountSnapshots records the job counts of the previous day every
+// day at (UTC "HH:MM") until the returned stop function is called
+func StartJobCountSnapshots(postgresDB *sql.DB, at string, retention int) (stop func(), err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := gocron.NewScheduler(time.UTC)
+
+	_, err = scheduler.Every(1).Day().At(at).SingletonMode().Do(func() {
+		snapshotCtx, snapshotCancel := context.WithTimeout(ctx, jobCountsTimeout)
+		defer snapshotCancel()
+		RecordJobCounts(snapshotCtx, postgresDB, time.Now().UTC().AddDate(0, 0, -1), retention)
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	scheduler.StartAsync()
+
+	log.Printf("Daily job counts snapshot started (daily at %s UTC)", at)
+	return func() {
+		cancel()
+		scheduler.Stop()
+	}, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"Go9jaJobs/internal/config"
+	"Go9jaJobs/internal/db"
+	"Go9jaJobs/internal/errorlog"
+	"Go9jaJobs/internal/fetcher"
+)
+
+// ErrBudgetExceeded is returned for syncs of a source whose estimated API cost
+// this month reached its budget
+var ErrBudgetExceeded = errors.New("monthly API budget exceeded")
+
+// SourceUsage is the API usage of a source over a month against its budget
+type SourceUsage struct {
+	db.APIUsageSummary
+	// Budget is the monthly budget of the source, nil without one
+	Budget *float64 `json:"budget,omitempty"`
+	// Paused is set once the cost reached the budget, skipping syncs until
+	// the next month
+	Paused bool `json:"paused"`
+}
+
+// UsageReport is the API usage of every source over a month
+type UsageReport struct {
+	Month   string        `json:"month"`
+	Cost    float64       `json:"cost"`
+	Sources []SourceUsage `json:"sources"`
+}
+
+// monthStart returns the first instant of t's month in UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// checkBudget returns ErrBudgetExceeded when the estimated API cost of source
+// this month reached its budget
+func checkBudget(ctx context.Context, postgresDB *sql.DB, cfg *config.Config, source string) error {
+	if cfg == nil {
+		return nil
+	}
+	budget, ok := cfg.APIMonthlyBudget[source]
+	if !ok {
+		return nil
+	}
+	cost, err := db.GetAPICost(ctx, postgresDB, source, monthStart(time.Now()))
+	if err != nil {
+		// An unknown cost does not stop syncs
+		log.Printf("Error reading the API cost of %s: %v", source, err)
+		return nil
+	}
+	if cost >= budget {
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+// recordUsage stores the API calls of a fetch of source with their estimated
+// cost, alerting when they use up its monthly budget
+func recordUsage(ctx context.Context, postgresDB *sql.DB, cfg *config.Config, source string, usage *fetcher.UsageRecorder) {
+	calls := usage.Calls()
+	if len(calls) == 0 {
+		return
+	}
+
+	var requestCost, itemCost float64
+	if cfg != nil {
+		requestCost, itemCost = cfg.APIRequestCost[source], cfg.APIItemCost[source]
+	}
+	records := make([]db.APIUsage, len(calls))
+	for i, call := range calls {
+		cost := requestCost
+		if call.Items != nil {
+			cost += itemCost * float64(*call.Items)
+		}
+		records[i] = db.APIUsage{
+			Source:   source,
+			Endpoint: call.Endpoint,
+			Status:   call.Status,
+			Items:    call.Items,
+			Cost:     cost,
+			CalledAt: call.CalledAt,
+		}
+	}
+
+	// Recorded even when the fetch ran out of time, as the calls were billed
+	ctx = context.WithoutCancel(ctx)
+	if err := db.RecordAPIUsage(ctx, postgresDB, records); err != nil {
+		log.Printf("Error recording %s API usage: %v", source, err)
+		return
+	}
+	if err := checkBudget(ctx, postgresDB, cfg, source); err != nil {
+		log.Printf("ALERT: %s %v, its syncs are paused until next month", source, err)
+		errorlog.Record(errorlog.SubsystemFetcher, source, err)
+	}
+}
+
+// GetUsageReport returns the API usage of every source in month, the sources
+// with a budget listed even without calls
+func GetUsageReport(ctx context.Context, postgresDB *sql.DB, cfg *config.Config, month time.Time) (*UsageReport, error) {
+	from := monthStart(month)
+	summaries, err := db.GetAPIUsage(ctx, postgresDB, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UsageReport{Month: from.Format("2006-01"), Sources: []SourceUsage{}}
+	listed := make(map[string]bool)
+	for _, summary := range summaries {
+		report.Sources = append(report.Sources, SourceUsage{APIUsageSummary: summary})
+		report.Cost += summary.Cost
+		listed[summary.Source] = true
+	}
+	if cfg != nil {
+		for source := range cfg.APIMonthlyBudget {
+			if !listed[source] {
+				report.Sources = append(report.Sources, SourceUsage{APIUsageSummary: db.APIUsageSummary{Source: source}})
+			}
+		}
+	}
+	sort.Slice(report.Sources, func(i, j int) bool { return report.Sources[i].Source < report.Sources[j].Source })
+
+	for i := range report.Sources {
+		usage := &report.Sources[i]
+		if cfg == nil {
+			continue
+		}
+		if budget, ok := cfg.APIMonthlyBudget[usage.Source]; ok {
+			usage.Budget = &budget
+			usage.Paused = usage.Cost >= budget
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Go9jaJobs/internal/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBudget(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	cfg := &config.Config{APIMonthlyBudget: map[string]float64{"jsearch": 10}}
+	ctx := context.Background()
+
+	// Sources without a budget are never paused, nor queried
+	assert.NoError(t, checkBudget(ctx, postgresDB, cfg, "indeed"))
+
+	mock.ExpectQuery("^SELECT COALESCE\\(SUM\\(cost\\), 0\\) FROM api_usage WHERE source = \\$1 AND called_at >= \\$2$").
+		WithArgs("jsearch", monthStart(time.Now())).
+		WillReturnRows(sqlmock.NewRows([]string{"cost"}).AddRow(9.5))
+	assert.NoError(t, checkBudget(ctx, postgresDB, cfg, "jsearch"))
+
+	mock.ExpectQuery("FROM api_usage").
+		WithArgs("jsearch", monthStart(time.Now())).
+		WillReturnRows(sqlmock.NewRows([]string{"cost"}).AddRow(10.0))
+	assert.ErrorIs(t, checkBudget(ctx, postgresDB, cfg, "jsearch"), ErrBudgetExceeded)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUsageReport(t *testing.T) {
+	postgresDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer postgresDB.Close()
+
+	cfg := &config.Config{APIMonthlyBudget: map[string]float64{"jsearch": 10, "linkedin": 5}}
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT source, COUNT\\(\\*\\),(.+) FROM api_usage WHERE called_at >= \\$1 AND called_at < \\$2 GROUP BY source").
+		WithArgs(from, from.AddDate(0, 1, 0)).
+		WillReturnRows(sqlmock.NewRows([]string{"source", "calls", "failed", "items", "cost"}).
+			AddRow("jsearch", 120, 2, 1100, 12.0).
+			AddRow("greenhouse", 40, 0, 0, 0.0))
+
+	report, err := GetUsageReport(context.Background(), postgresDB, cfg, time.Date(2024, 5, 17, 9, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05", report.Month)
+	assert.Equal(t, 12.0, report.Cost)
+	if assert.Len(t, report.Sources, 3) {
+		assert.Equal(t, "greenhouse", report.Sources[0].Source)
+		assert.Nil(t, report.Sources[0].Budget)
+		assert.False(t, report.Sources[0].Paused)
+
+		assert.Equal(t, "jsearch", report.Sources[1].Source)
+		assert.Equal(t, 2, report.Sources[1].FailedCalls)
+		assert.Equal(t, 10.0, *report.Sources[1].Budget)
+		assert.True(t, report.Sources[1].Paused)
+
+		// Budgeted sources are listed before their first call
+		assert.Equal(t, "linkedin", report.Sources[2].Source)
+		assert.Zero(t, report.Sources[2].Calls)
+		assert.False(t, report.Sources[2].Paused)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MinPartSize is the smallest part S3 accepts in a multipart upload, except
+// for the last one
+const MinPartSize = 5 << 20
+
+// maxParts is the most parts a multipart upload may have
+const maxParts = 10000
+
+// S3 is a client of the multipart upload API of S3-compatible storage (AWS
+// S3, Cloudflare R2, MinIO, ...), signing requests with AWS Signature V4.
+// Objects are addressed path-style: endpoint/bucket/key.
+type S3 struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3 creates a client of bucket at endpoint (e.g.
+// "https://s3.eu-west-1.amazonaws.com")
+func NewS3(endpoint, region, bucket, accessKey, secretKey string) *S3 {
+	return &S3{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 5 * time.Minute},
+		now:       time.Now,
+	}
+}
+
+// completedPart is a part of a CompleteMultipartUpload request
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// s3Error is the error document of S3, also returned with a 200 status by
+// CompleteMultipartUpload
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+// createMultipartUpload starts a multipart upload of key, returning its ID
+func (s *S3) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	body, _, err := s.do(ctx, "POST", key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("decoding upload of %s: %w", key, err)
+	}
+	if result.UploadID == "" {
+		return "", fmt.Errorf("no upload ID for %s", key)
+	}
+	return result.UploadID, nil
+}
+
+// uploadPart uploads part number of an upload, returning its ETag
+func (s *S3) uploadPart(ctx context.Context, key, uploadID string, number int, data []byte) (string, error) {
+	query := url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {uploadID}}
+	_, header, err := s.do(ctx, "PUT", key, query, nil, data)
+	if err != nil {
+		return "", err
+	}
+	etag := header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("no ETag for part %d of %s", number, key)
+	}
+	return etag, nil
+}
+
+// completeMultipartUpload assembles the uploaded parts into the object
+func (s *S3) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	data, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	body, _, err := s.do(ctx, "POST", key, url.Values{"uploadId": {uploadID}}, nil, data)
+	if err != nil {
+		return err
+	}
+	var failure s3Error
+	if xml.Unmarshal(body, &failure) == nil && failure.Code != "" {
+		return fmt.Errorf("completing upload of %s: %s: %s", key, failure.Code, failure.Message)
+	}
+	return nil
+}
+
+// abortMultipartUpload discards an upload and its parts
+func (s *S3) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, _, err := s.do(ctx, "DELETE", key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	return err
+}
+
+// do sends a signed request for key, failing on non-2xx statuses
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, header http.Header, payload []byte) ([]byte, http.Header, error) {
+	path := "/" + s.bucket + "/" + key
+	endpoint := s.endpoint + escapePath(path)
+	if len(query) > 0 {
+		endpoint += "?" + canonicalQuery(query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.sign(req, path, query, payload)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var failure s3Error
+		if xml.Unmarshal(body, &failure) == nil && failure.Code != "" {
+			return nil, nil, fmt.Errorf("%s %s: %s: %s", method, key, failure.Code, failure.Message)
+		}
+		return nil, nil, fmt.Errorf("%s %s: status %d", method, key, resp.StatusCode)
+	}
+	return body, resp.Header, nil
+}
+
+// sign adds the AWS Signature V4 headers to req
+func (s *S3) sign(req *http.Request, path string, query url.Values, payload []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Sign the host and every x-amz-* header
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, escapePath(path), canonicalQuery(query),
+		canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.secretKey, date, s.region, "s3"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// signingKey derives the Signature V4 key of a day, region and service
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// escapePath URI-encodes each segment of an object path as Signature V4
+// requires, keeping the slashes
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery encodes query sorted by key, with empty values kept as "key="
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but the RFC 3986 unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
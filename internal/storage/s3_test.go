@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 serves the multipart upload API of one bucket in memory
+type fakeS3 struct {
+	mu        sync.Mutex
+	parts     map[int][]byte
+	objects   map[string][]byte
+	aborted   bool
+	failPart  int
+	authHeads []string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{parts: make(map[int][]byte), objects: make(map[string][]byte)}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authHeads = append(f.authHeads, r.Header.Get("Authorization"))
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == "POST" && query.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == "PUT" && query.Get("uploadId") == "upload-1":
+		var number int
+		fmt.Sscan(query.Get("partNumber"), &number)
+		if number == f.failPart {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `<Error><Code>InternalError</Code><Message>try again</Message></Error>`)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		f.parts[number] = data
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+	case r.Method == "POST" && query.Get("uploadId") == "upload-1":
+		var object []byte
+		for i := 1; i <= len(f.parts); i++ {
+			object = append(object, f.parts[i]...)
+		}
+		f.objects[r.URL.Path] = object
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><Key>k</Key></CompleteMultipartUploadResult>`)
+	case r.Method == "DELETE" && query.Get("uploadId") == "upload-1":
+		f.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	// Example of the AWS Signature V4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
+
+func TestUpload(t *testing.T) {
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s3 := NewS3(server.URL, "eu-west-1", "exports", "AKID", "secret")
+	s3.now = func() time.Time { return time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC) }
+
+	upload, err := s3.NewUpload(context.Background(), "snapshots/jobs.jsonl.gz", "application/gzip", 0)
+	assert.NoError(t, err)
+
+	// Two full parts and a short last one
+	data := bytes.Repeat([]byte("x"), 2*MinPartSize+10)
+	for chunk := data; len(chunk) > 0; {
+		n := min(len(chunk), 1<<20)
+		_, err := upload.Write(chunk[:n])
+		assert.NoError(t, err)
+		chunk = chunk[n:]
+	}
+	assert.Equal(t, int64(2*MinPartSize), upload.Size())
+	assert.NoError(t, upload.Close())
+	assert.NoError(t, upload.Close())
+
+	assert.Len(t, fake.parts, 3)
+	assert.Equal(t, data, fake.objects["/exports/snapshots/jobs.jsonl.gz"])
+	assert.False(t, fake.aborted)
+	for _, auth := range fake.authHeads {
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20261017/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="), auth)
+	}
+}
+
+func TestUploadAbortsOnFailedPart(t *testing.T) {
+	fake := newFakeS3()
+	fake.failPart = 2
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s3 := NewS3(server.URL, "us-east-1", "exports", "AKID", "secret")
+	upload, err := s3.NewUpload(context.Background(), "jobs.jsonl.gz", "application/gzip", MinPartSize)
+	assert.NoError(t, err)
+
+	_, err = upload.Write(bytes.Repeat([]byte("x"), 2*MinPartSize))
+	assert.ErrorContains(t, err, "uploading part 2 of jobs.jsonl.gz")
+	assert.ErrorContains(t, err, "InternalError: try again")
+
+	// Further writes fail and closing discards the upload
+	_, err = upload.Write([]byte("x"))
+	assert.Error(t, err)
+	assert.Error(t, upload.Close())
+	assert.True(t, fake.aborted)
+	assert.Empty(t, fake.objects)
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	assert.Equal(t, "uploads=", canonicalQuery(map[string][]string{"uploads": {""}}))
+	assert.Equal(t, "partNumber=1&uploadId=a%2Bb%2F", canonicalQuery(map[string][]string{"uploadId": {"a+b/"}, "partNumber": {"1"}}))
+	assert.Equal(t, "/bucket/snap%20shots/jobs.jsonl.gz", escapePath("/bucket/snap shots/jobs.jsonl.gz"))
+}
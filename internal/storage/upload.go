@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Upload streams an object to S3 through a multipart upload. Writes are
+// buffered up to one part, which is uploaded before Write returns, so the
+// producer is slowed down to the upload speed and memory stays bounded by
+// the part size whatever the object size.
+type Upload struct {
+	ctx      context.Context
+	s3       *S3
+	key      string
+	uploadID string
+	partSize int
+	buf      []byte
+	parts    []completedPart
+	size     int64
+	err      error
+}
+
+// NewUpload starts a multipart upload of key, buffering partSize bytes per
+// part (at least MinPartSize). Close completes it; Abort discards it.
+func (s *S3) NewUpload(ctx context.Context, key, contentType string, partSize int) (*Upload, error) {
+	if partSize < MinPartSize {
+		partSize = MinPartSize
+	}
+	uploadID, err := s.createMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return nil, err
+	}
+	return &Upload{
+		ctx:      ctx,
+		s3:       s,
+		key:      key,
+		uploadID: uploadID,
+		partSize: partSize,
+		buf:      make([]byte, 0, partSize),
+	}, nil
+}
+
+// Write implements io.Writer
+func (u *Upload) Write(p []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := copy(u.buf[len(u.buf):cap(u.buf)], p)
+		u.buf = u.buf[:len(u.buf)+n]
+		p = p[n:]
+		written += n
+
+		if len(u.buf) == u.partSize {
+			if err := u.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush uploads the buffered bytes as the next part
+func (u *Upload) flush() error {
+	number := len(u.parts) + 1
+	if number > maxParts {
+		u.err = fmt.Errorf("upload of %s exceeds %d parts", u.key, maxParts)
+		return u.err
+	}
+
+	etag, err := u.s3.uploadPart(u.ctx, u.key, u.uploadID, number, u.buf)
+	if err != nil {
+		u.err = fmt.Errorf("uploading part %d of %s: %w", number, u.key, err)
+		return u.err
+	}
+	u.parts = append(u.parts, completedPart{PartNumber: number, ETag: etag})
+	u.size += int64(len(u.buf))
+	u.buf = u.buf[:0]
+	return nil
+}
+
+// Size returns the number of bytes uploaded so far
+func (u *Upload) Size() int64 {
+	return u.size
+}
+
+// Close uploads the last part and completes the upload, aborting it on
+// failure
+func (u *Upload) Close() error {
+	if u.err == errClosed {
+		return nil
+	}
+	if u.err == nil && (len(u.buf) > 0 || len(u.parts) == 0) {
+		u.flush()
+	}
+	if u.err == nil {
+		u.err = u.s3.completeMultipartUpload(u.ctx, u.key, u.uploadID, u.parts)
+	}
+	if u.err != nil {
+		err := u.err
+		u.Abort()
+		return err
+	}
+	u.err = errClosed
+	return nil
+}
+
+// Abort discards the upload and its uploaded parts. It uses its own context
+// so an upload cancelled by its context is still cleaned up.
+func (u *Upload) Abort() {
+	if u.err == errClosed || u.err == errAborted {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := u.s3.abortMultipartUpload(ctx, u.key, u.uploadID); err != nil {
+		log.Printf("Error aborting upload of %s: %v", u.key, err)
+	}
+	u.err = errAborted
+}
+
+// Terminal states of an upload, returned by further writes
+var (
+	errClosed  = errors.New("upload closed")
+	errAborted = errors.New("upload aborted")
+)
@@ -0,0 +1,165 @@
+// Package translate translates job descriptions written in other languages
+// than the one listings are read in, through Google Translate or Gemini.
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Translators selectable with TRANSLATOR
+const (
+	ProviderGoogle = "google"
+	ProviderGemini = "gemini"
+)
+
+const (
+	// googleBaseURL is the Cloud Translation API (v2)
+	googleBaseURL = "https://translation.googleapis.com/language/translate/v2"
+	// geminiBaseURL is the Gemini API
+	geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	// DefaultGeminiModel is the Gemini model used when GEMINI_MODEL is not set
+	DefaultGeminiModel = "gemini-1.5-flash"
+)
+
+// Translator translates text between languages named by ISO 639-1 codes
+type Translator interface {
+	// Name identifies the translator in logs and stored translations
+	Name() string
+	Translate(ctx context.Context, text, from, to string) (string, error)
+}
+
+// Google translates with the Google Cloud Translation API
+type Google struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewGoogle creates a translator using the Cloud Translation API key
+func NewGoogle(apiKey string) *Google {
+	return &Google{apiKey: apiKey, baseURL: googleBaseURL, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Name implements Translator
+func (g *Google) Name() string {
+	return ProviderGoogle
+}
+
+// Translate implements Translator
+func (g *Google) Translate(ctx context.Context, text, from, to string) (string, error) {
+	var resp struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	err := postJSON(ctx, g.client, g.baseURL, g.apiKey, map[string]interface{}{
+		"q":      text,
+		"source": from,
+		"target": to,
+		"format": "text",
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Data.Translations) == 0 {
+		return "", errors.New("google translate returned no translation")
+	}
+	return resp.Data.Translations[0].TranslatedText, nil
+}
+
+// Gemini translates by prompting a Gemini model
+type Gemini struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+}
+
+// NewGemini creates a translator prompting model (DefaultGeminiModel when
+// empty) with the Gemini API key
+func NewGemini(apiKey, model string) *Gemini {
+	if model == "" {
+		model = DefaultGeminiModel
+	}
+	return &Gemini{apiKey: apiKey, model: model, baseURL: geminiBaseURL, client: &http.Client{Timeout: 60 * time.Second}}
+}
+
+// Name implements Translator
+func (g *Gemini) Name() string {
+	return ProviderGemini
+}
+
+// Translate implements Translator
+func (g *Gemini) Translate(ctx context.Context, text, from, to string) (string, error) {
+	prompt := fmt.Sprintf("Translate this job description from the language with ISO 639-1 code %q to the one "+
+		"with code %q. Keep its line breaks and lists, and leave product and technology names as they are. "+
+		"Reply with the translation only.\n\n%s", from, to, text)
+
+	var resp struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+	}
+	endpoint := g.baseURL + "/models/" + url.PathEscape(g.model) + ":generateContent"
+	err := postJSON(ctx, g.client, endpoint, g.apiKey, map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"parts": []map[string]string{{"text": prompt}}},
+		},
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if len(resp.Candidates) > 0 {
+		for _, part := range resp.Candidates[0].Content.Parts {
+			b.WriteString(part.Text)
+		}
+	}
+	translation := strings.TrimSpace(b.String())
+	if translation == "" {
+		return "", errors.New("gemini returned no translation")
+	}
+	return translation, nil
+}
+
+// postJSON posts body as JSON and decodes the response into out, failing on
+// non-2xx statuses. The API key goes in a header, not the URL, which errors
+// and logs would show.
+func postJSON(ctx context.Context, client *http.Client, endpoint, apiKey string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", apiKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,88 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoogle(t *testing.T) {
+	var body map[string]interface{}
+	var query, key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		query, key = r.URL.RawQuery, r.Header.Get("x-goog-api-key")
+		w.Write([]byte(`{"data":{"translations":[{"translatedText":"We are hiring a Go developer"}]}}`))
+	}))
+	defer server.Close()
+
+	google := NewGoogle("key-1")
+	google.baseURL = server.URL
+	text, err := google.Translate(context.Background(), "Nous recrutons un développeur Go", "fr", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, "We are hiring a Go developer", text)
+	assert.Empty(t, query)
+	assert.Equal(t, "key-1", key)
+	assert.Equal(t, "Nous recrutons un développeur Go", body["q"])
+	assert.Equal(t, "fr", body["source"])
+	assert.Equal(t, "en", body["target"])
+	assert.Equal(t, "text", body["format"])
+	assert.Equal(t, ProviderGoogle, google.Name())
+}
+
+func TestGemini(t *testing.T) {
+	var path, key string
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, key = r.URL.String(), r.Header.Get("x-goog-api-key")
+		var body struct {
+			Contents []struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"contents"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		prompt = body.Contents[0].Parts[0].Text
+		w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":" We are hiring "},{"text":"a Go developer\n"}]}}]}`))
+	}))
+	defer server.Close()
+
+	gemini := NewGemini("key-1", "")
+	gemini.baseURL = server.URL
+	text, err := gemini.Translate(context.Background(), "Nous recrutons un développeur Go", "fr", "en")
+	assert.NoError(t, err)
+	assert.Equal(t, "We are hiring a Go developer", text)
+	assert.Equal(t, "/models/"+DefaultGeminiModel+":generateContent", path)
+	assert.Equal(t, "key-1", key)
+	assert.Contains(t, prompt, `code "fr"`)
+	assert.Contains(t, prompt, "Nous recrutons un développeur Go")
+}
+
+func TestTranslatorErrors(t *testing.T) {
+	status := http.StatusTooManyRequests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	google := NewGoogle("key-1")
+	google.baseURL = server.URL
+	gemini := NewGemini("key-1", "gemini-pro")
+	gemini.baseURL = server.URL
+
+	_, err := google.Translate(context.Background(), "Bonjour", "fr", "en")
+	assert.EqualError(t, err, "unexpected status 429")
+
+	// An empty reply is not a translation
+	status = http.StatusOK
+	_, err = google.Translate(context.Background(), "Bonjour", "fr", "en")
+	assert.Error(t, err)
+	_, err = gemini.Translate(context.Background(), "Bonjour", "fr", "en")
+	assert.Error(t, err)
+}